@@ -9,10 +9,13 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Zeitzonen auch ohne Systemdatenbank (Windows, schlanke Container)
 
 	"lernplattform/internal/api"
 	"lernplattform/internal/config"
+	"lernplattform/internal/demo"
 	"lernplattform/internal/llm"
+	"lernplattform/internal/scheduler"
 	"lernplattform/internal/storage"
 )
 
@@ -27,6 +30,7 @@ func main() {
 	// Kommandozeilen-Flags
 	configPath := flag.String("config", "config.json", "Pfad zur Konfigurationsdatei")
 	port := flag.String("port", "8080", "Server-Port")
+	demoData := flag.Bool("demo", false, "Beispielkurs in die Datenbank laden (für Frontend-Entwicklung und Screenshots)")
 	flag.Parse()
 
 	// Konfiguration laden
@@ -38,6 +42,16 @@ func main() {
 	}
 	log.Printf("   ✓ Konfiguration geladen")
 
+	// Tagesgrenzen (Prüfungstermin, Tagesziele, Berichte) in der konfigurierten Zeitzone
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			log.Fatalf("❌ Unbekannte Zeitzone %q: %v", cfg.Timezone, err)
+		}
+		time.Local = loc
+		log.Printf("   ✓ Zeitzone: %s", loc)
+	}
+
 	// Storage initialisieren
 	log.Println("💾 Initialisiere Datenbank...")
 	store, err := storage.NewSQLiteStorage(cfg.DatabasePath)
@@ -47,14 +61,33 @@ func main() {
 	defer store.Close()
 	log.Printf("   ✓ Datenbank: %s", cfg.DatabasePath)
 
+	if *demoData {
+		created, err := demo.Seed(store, time.Now())
+		switch {
+		case err != nil:
+			log.Fatalf("❌ Fehler beim Anlegen des Beispielkurses: %v", err)
+		case created:
+			log.Println("   ✓ Beispielkurs angelegt (Lernplan \"Regelungstechnik (Demo)\")")
+		default:
+			log.Println("   ✓ Beispielkurs bereits vorhanden")
+		}
+	}
+
 	// LLM-Provider initialisieren
 	log.Println("🤖 Initialisiere LLM-Provider...")
-	llmProvider := llm.NewOllamaProvider(cfg.OllamaURL, cfg.DefaultModel)
-	
+	var llmProvider llm.Provider
+	if len(cfg.Backends) > 0 {
+		llmProvider = newFailoverProvider(cfg)
+	} else {
+		ollama := llm.NewOllamaProvider(cfg.OllamaURL, cfg.DefaultModel, cfg.MaxConcurrentLLM)
+		ollama.SetKeepAlive(cfg.KeepAlive)
+		llmProvider = ollama
+	}
+
 	// Prüfe LLM-Verbindung
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	if llmProvider.IsAvailable(ctx) {
-		log.Printf("   ✓ Ollama erreichbar: %s", cfg.OllamaURL)
+		log.Printf("   ✓ %s erreichbar", llmProvider.GetName())
 		models, err := llmProvider.GetModels(ctx)
 		if err == nil {
 			log.Printf("   ✓ Verfügbare Modelle: %d", len(models))
@@ -62,12 +95,27 @@ func main() {
 				log.Printf("      - %s", m.Name)
 			}
 		}
+
+		// Modell im Hintergrund vorladen, damit der erste Chat nicht auf das Laden wartet
+		warmer, ok := llmProvider.(interface {
+			Warmup(ctx context.Context, model string) error
+		})
+		if cfg.WarmupOnStart && ok {
+			go func() {
+				warmCtx, warmCancel := context.WithTimeout(context.Background(), 5*time.Minute)
+				defer warmCancel()
+				if err := warmer.Warmup(warmCtx, ""); err != nil {
+					log.Printf("   ⚠️  Warm-up fehlgeschlagen: %v", err)
+				}
+			}()
+		}
 	} else {
-		log.Printf("   ⚠️  Ollama NICHT erreichbar unter %s", cfg.OllamaURL)
+		log.Printf("   ⚠️  %s NICHT erreichbar", llmProvider.GetName())
 		log.Println("      Starte Ollama mit: ollama serve")
 	}
 	cancel()
 	log.Printf("   ✓ Standard-Modell: %s", cfg.DefaultModel)
+	log.Printf("   ✓ Max. gleichzeitige LLM-Anfragen: %d", cfg.MaxConcurrentLLM)
 
 	// API-Handler erstellen
 	handler := api.NewHandler(store, llmProvider, cfg)
@@ -75,10 +123,78 @@ func main() {
 	// Router erstellen
 	router := api.NewRouter(handler)
 
+	// Hintergrundjobs (z.B. wöchentlicher E-Mail-Bericht)
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	jobs := scheduler.New()
+	// Mit Ressourcenwächter werden aufgeschobene Hintergrundjobs regelmäßig erneut geprüft
+	var backgroundRetry time.Duration
+	if guard := cfg.ResourceGuard; guard.Enabled {
+		backgroundRetry = time.Duration(max(guard.RetryMinutes, 1)) * time.Minute
+		log.Printf("🔋 Ressourcenwächter: Hintergrundjobs nur bei Last unter %.2f je Kern (Akkubetrieb erlaubt: %v)", guard.MaxLoad, guard.AllowOnBattery)
+	}
+	if cfg.Email.WeeklyReport {
+		hour := cfg.Email.ReportHour
+		if hour < 0 || hour > 23 {
+			hour = 18
+		}
+		weekday := time.Weekday((cfg.Email.ReportWeekday%7 + 7) % 7)
+		jobs.Add(scheduler.Job{
+			Name: "weekly-report",
+			Next: scheduler.Weekly(weekday, hour),
+			Run:  handler.SendWeeklyReport,
+		})
+		log.Printf("📧 Wochenbericht: %s um %d:00 an %v", weekday, hour, cfg.Email.To)
+	}
+	if cfg.Email.GoalReminder {
+		hour := cfg.ReminderHour()
+		jobs.Add(scheduler.Job{
+			Name: "goal-reminder",
+			Next: scheduler.Daily(hour),
+			Run:  handler.SendGoalReminder,
+		})
+		log.Printf("⏰ Erinnerung an offene Tagesziele: täglich um %d:00 an %v", hour, cfg.Email.To)
+	}
+	if cfg.SessionIdleMinutes > 0 {
+		jobs.Add(scheduler.Job{
+			Name: "idle-sessions",
+			Next: scheduler.Every(15 * time.Minute),
+			Run:  handler.CloseIdleSessions,
+		})
+	}
+	if topUp := cfg.QuestionTopUp; topUp.Enabled {
+		hour := topUp.Hour
+		if hour < 0 || hour > 23 {
+			hour = 2
+		}
+		jobs.Add(scheduler.Job{
+			Name: "question-top-up",
+			Next: scheduler.Daily(hour),
+			Run:  handler.TopUpQuestions,
+			// nicht in Ruhezeiten, bei aufgebrauchtem LLM-Budget oder im Akkubetrieb
+			Background: true,
+			Retry:      backgroundRetry,
+		})
+		log.Printf("🧩 Fragen auffüllen: täglich um %d:00 (mind. %d je Thema)", hour, topUp.MinQuestions)
+	}
+	if b := cfg.LLMBudget; b.DailyMinutes > 0 || b.QuietStart%24 != b.QuietEnd%24 {
+		log.Printf("⏸️ LLM-Budget: %d Min. pro Tag, Ruhezeit %d-%d Uhr (0 = unbegrenzt, gleiche Stunden = keine)", b.DailyMinutes, b.QuietStart, b.QuietEnd)
+	}
+	jobs.SetGate(handler.BackgroundLLMAllowed)
+	jobs.Start(jobCtx)
+	// Durch Neustart oder Standby unterbrochene Lernplan-Erstellungen fortsetzen
+	go handler.ResumePlanJobs(jobCtx)
+
 	// Server starten
+	// Zeitlimits gegen hängende Clients; die Limits je Route setzt der Router,
+	// WriteTimeout muss die längste Route (Lernplanerstellung) abdecken
 	server := &http.Server{
-		Addr:    ":" + *port,
-		Handler: router,
+		Addr:              ":" + *port,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       5 * time.Minute,
+		WriteTimeout:      api.LongRequestTimeout + time.Minute,
+		IdleTimeout:       2 * time.Minute,
 	}
 
 	// Graceful Shutdown
@@ -88,6 +204,7 @@ func main() {
 		<-sigChan
 		log.Println("")
 		log.Println("⏹️  Server wird heruntergefahren...")
+		stopJobs()
 		server.Close()
 	}()
 
@@ -103,3 +220,53 @@ func main() {
 		log.Fatalf("Server-Fehler: %v", err)
 	}
 }
+
+// newFailoverProvider baut die Failover-Kette aus den konfigurierten Backends
+func newFailoverProvider(cfg *config.Config) llm.Provider {
+	var backends []llm.FailoverBackend
+	for _, b := range cfg.Backends {
+		maxConcurrent := b.MaxConcurrent
+		if maxConcurrent == 0 {
+			maxConcurrent = cfg.MaxConcurrentLLM
+		}
+
+		var provider llm.Provider
+		switch b.Type {
+		case "openai":
+			provider = llm.NewOpenAIProvider(b.URL, b.APIKey, b.Model, maxConcurrent)
+		case "ollama", "":
+			model := b.Model
+			if model == "" {
+				model = cfg.DefaultModel
+			}
+			ollama := llm.NewOllamaProvider(b.URL, model, maxConcurrent)
+			ollama.SetKeepAlive(cfg.KeepAlive)
+			provider = ollama
+		case "mock":
+			// Vorbereitete Antworten aus einer JSON-Datei (url), für Entwicklung ohne GPU
+			mock := llm.NewMockProvider(nil, "")
+			if b.URL != "" {
+				loaded, err := llm.LoadMockProvider(b.URL)
+				if err != nil {
+					log.Printf("   ⚠️  Mock-Skript nicht lesbar, antworte nur mit dem Fallback: %v", err)
+				} else {
+					mock = loaded
+				}
+			}
+			provider = mock
+		default:
+			log.Printf("   ⚠️  Unbekannter Backend-Typ '%s' für %s, wird ignoriert", b.Type, b.Name)
+			continue
+		}
+
+		backends = append(backends, llm.FailoverBackend{
+			Name:     b.Name,
+			Provider: provider,
+			Model:    b.Model,
+			ModelMap: b.ModelMap,
+		})
+		log.Printf("   ✓ Backend %d: %s (%s)", len(backends), b.Name, provider.GetName())
+	}
+
+	return llm.NewFailoverProvider(backends, cfg.DefaultModel)
+}
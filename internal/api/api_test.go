@@ -0,0 +1,227 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"lernplattform/internal/config"
+	"lernplattform/internal/llm"
+	"lernplattform/internal/models"
+	"lernplattform/internal/storage"
+)
+
+// Vorbereitete LLM-Antworten für den Weg Lernplan → Fragen → Bewertung (Indizes wie in Calls().Rule)
+const (
+	ruleAnalyze = iota
+	ruleQuestions
+	ruleEvaluate
+)
+
+var testRules = []llm.MockRule{
+	ruleAnalyze: {
+		Match:    "wichtigsten Lernthemen",
+		Response: `{"topics": [{"name": "Ableitungen", "description": "Steigung von Funktionen", "difficulty": 2, "est_minutes": 45, "objectives": ["Kann Ableitungen berechnen"]}]}`,
+	},
+	ruleQuestions: {
+		Match: "Du erstellst Prüfungsfragen",
+		Response: `{"questions": [{"question": "Was beschreibt die Ableitung einer Funktion?", "expected_answer": "Die Steigung der Funktion in einem Punkt",
+			"hints": ["Denke an Tangenten"], "type": "open", "cognitive_level": "understand",
+			"source_quote": "Die Ableitung beschreibt die Steigung einer Funktion in einem Punkt"}]}`,
+	},
+	ruleEvaluate: {
+		Match:    "Du bist ein FAIRER Prüfer",
+		Response: `{"is_correct": true, "feedback": "✅ Richtig!", "score": 90, "error_type": ""}`,
+	},
+}
+
+// testServer baut Handler und Router mit einer SQLite-Datenbank im Temp-Ordner und dem MockProvider
+func testServer(t *testing.T) (http.Handler, *storage.SQLiteStorage, *llm.MockProvider) {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := storage.NewSQLiteStorage(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("Datenbank: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	cfg := config.Default()
+	cfg.DocumentsPath = dir
+	cfg.DatabasePath = filepath.Join(dir, "test.db")
+
+	mock := llm.NewMockProvider(testRules, "")
+	return NewRouter(NewHandler(store, mock, cfg)), store, mock
+}
+
+// do schickt eine JSON-Anfrage an den Router und dekodiert die Antwort in out (falls nicht nil)
+func do(t *testing.T, router http.Handler, method, path string, body interface{}, wantStatus int, out interface{}) {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != wantStatus {
+		t.Fatalf("%s %s: Status %d, erwartet %d: %s", method, path, rec.Code, wantStatus, rec.Body.String())
+	}
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s: Antwort nicht lesbar: %v", method, path, err)
+		}
+	}
+}
+
+// callsByRule zählt die LLM-Aufrufe je Regel (-1 = Fallback)
+func callsByRule(mock *llm.MockProvider) map[int][]llm.MockCall {
+	calls := make(map[int][]llm.MockCall)
+	for _, call := range mock.Calls() {
+		calls[call.Rule] = append(calls[call.Rule], call)
+	}
+	return calls
+}
+
+func TestPlanQuestionsAnswer(t *testing.T) {
+	router, store, mock := testServer(t)
+
+	doc := &models.Document{
+		ID:         "doc_test",
+		Name:       "Analysis Skript.pdf",
+		Content:    "--- Seite 1 ---\nDie Ableitung beschreibt die Steigung einer Funktion in einem Punkt.",
+		PageCount:  1,
+		UploadedAt: time.Now(),
+		HasText:    true,
+		Language:   "de",
+	}
+	if err := store.SaveDocument(doc); err != nil {
+		t.Fatalf("Dokument speichern: %v", err)
+	}
+
+	// Lernplan aus dem Dokument
+	var plan models.StudyPlan
+	do(t, router, "POST", "/api/v1/plans", map[string]interface{}{
+		"exam_date":    time.Now().AddDate(0, 1, 0).Format("2006-01-02"),
+		"document_ids": []string{doc.ID},
+	}, http.StatusCreated, &plan)
+
+	stored, err := store.GetStudyPlan(plan.ID)
+	if err != nil {
+		t.Fatalf("Lernplan nicht gespeichert: %v", err)
+	}
+	if len(stored.Documents) != 1 || stored.Documents[0] != doc.ID {
+		t.Errorf("Dokumente des Plans = %v, erwartet [%s]", stored.Documents, doc.ID)
+	}
+	topics, err := store.GetTopicsByPlan(plan.ID)
+	if err != nil || len(topics) != 1 {
+		t.Fatalf("Themen = %d (%v), erwartet 1", len(topics), err)
+	}
+	topic := topics[0]
+	if topic.Name != "Ableitungen" || topic.EstMinutes != 45 {
+		t.Errorf("Thema = %q (%d Min.), erwartet Ableitungen (45 Min.)", topic.Name, topic.EstMinutes)
+	}
+
+	calls := callsByRule(mock)
+	if len(calls[ruleAnalyze]) != 1 {
+		t.Fatalf("Analyse-Aufrufe = %d, erwartet 1", len(calls[ruleAnalyze]))
+	}
+	if prompt := calls[ruleAnalyze][0].Prompt; !strings.Contains(prompt, "Steigung einer Funktion") {
+		t.Errorf("Analyse-Prompt ohne Dokumentinhalt: %s", prompt)
+	}
+
+	// Fragen zum Thema
+	do(t, router, "POST", "/api/v1/topics/"+topic.ID+"/questions/generate", map[string]interface{}{
+		"count": 1, "difficulty": 2, "type": "open",
+	}, http.StatusCreated, nil)
+
+	questions, err := store.GetQuestionsByTopic(topic.ID)
+	if err != nil || len(questions) != 1 {
+		t.Fatalf("Fragen = %d (%v), erwartet 1", len(questions), err)
+	}
+	question := questions[0]
+	if question.Type != "open" || question.Difficulty != 2 || question.ExpectedAnswer != "Die Steigung der Funktion in einem Punkt" {
+		t.Errorf("Frage falsch gespeichert: %+v", question)
+	}
+
+	calls = callsByRule(mock)
+	if len(calls[ruleQuestions]) != 1 {
+		t.Fatalf("Fragen-Aufrufe = %d, erwartet 1", len(calls[ruleQuestions]))
+	}
+	prompt := calls[ruleQuestions][0].Prompt
+	if !strings.Contains(prompt, `zum Thema "Ableitungen"`) || !strings.Contains(prompt, "Erstelle genau 1 Fragen mit Schwierigkeitsgrad 2") {
+		t.Errorf("Fragen-Prompt ohne Thema oder Anzahl: %s", prompt)
+	}
+
+	// Antwort bewerten lassen
+	answer := "Die Steigung der Funktion"
+	do(t, router, "POST", "/api/v1/questions/"+question.ID+"/answer", map[string]interface{}{
+		"answer": answer,
+	}, http.StatusOK, nil)
+
+	attempts, err := store.GetAttempts(question.ID)
+	if err != nil || len(attempts) != 1 {
+		t.Fatalf("Antwortversuche = %d (%v), erwartet 1", len(attempts), err)
+	}
+	if attempt := attempts[0]; !attempt.IsCorrect || attempt.Answer != answer || attempt.TopicID != topic.ID {
+		t.Errorf("Antwortversuch falsch gespeichert: %+v", attempt)
+	}
+
+	calls = callsByRule(mock)
+	if len(calls[ruleEvaluate]) != 1 {
+		t.Fatalf("Bewertungs-Aufrufe = %d, erwartet 1", len(calls[ruleEvaluate]))
+	}
+	prompt = calls[ruleEvaluate][0].Prompt
+	if !strings.Contains(prompt, "Antwort des Studenten: "+answer) || !strings.Contains(prompt, question.Question) {
+		t.Errorf("Bewertungs-Prompt ohne Frage oder Antwort: %s", prompt)
+	}
+}
+
+func TestGenerateQuestionsUnknownTopic(t *testing.T) {
+	router, _, mock := testServer(t)
+
+	do(t, router, "POST", "/api/v1/topics/topic_fehlt/questions/generate", map[string]interface{}{"count": 1}, http.StatusNotFound, nil)
+
+	if calls := mock.Calls(); len(calls) != 0 {
+		t.Errorf("LLM-Aufrufe = %d, erwartet keine", len(calls))
+	}
+}
+
+func TestAnswerMultipleChoiceWithoutLLM(t *testing.T) {
+	router, store, mock := testServer(t)
+
+	plan := &models.StudyPlan{ID: "plan_mc", Name: "Test", ExamDate: models.DateOf(time.Now().AddDate(0, 1, 0)), CreatedAt: time.Now(), Status: "active"}
+	if err := store.SaveStudyPlan(plan); err != nil {
+		t.Fatal(err)
+	}
+	topic := &models.Topic{ID: "topic_mc", StudyPlanID: plan.ID, Name: "Grenzwerte", Status: "pending"}
+	if err := store.SaveTopic(topic); err != nil {
+		t.Fatal(err)
+	}
+	question := &models.Question{
+		ID: "q_mc", TopicID: topic.ID, Question: "Welcher Grenzwert gilt für 1/n?", Type: "multiple_choice",
+		Options: []string{"0", "1", "unendlich", "-1"}, ExpectedAnswer: "0", Difficulty: 1,
+	}
+	if err := store.SaveQuestion(question); err != nil {
+		t.Fatal(err)
+	}
+
+	do(t, router, "POST", "/api/v1/questions/"+question.ID+"/answer", map[string]interface{}{"answer": "1"}, http.StatusOK, nil)
+
+	attempts, err := store.GetAttempts(question.ID)
+	if err != nil || len(attempts) != 1 {
+		t.Fatalf("Antwortversuche = %d (%v), erwartet 1", len(attempts), err)
+	}
+	if attempts[0].IsCorrect {
+		t.Error("falsche Option als richtig gewertet")
+	}
+	if calls := mock.Calls(); len(calls) != 0 {
+		t.Errorf("LLM-Aufrufe = %d, Multiple Choice braucht keines", len(calls))
+	}
+}
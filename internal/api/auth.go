@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/config"
+	"lernplattform/internal/models"
+	"lernplattform/internal/storage"
+)
+
+// Rollen der Zugangstokens
+const (
+	roleOwner  = "owner"
+	roleViewer = "viewer"
+	roleDevice = "device" // gekoppeltes Gerät mit Gerätetoken, siehe devices.go
+)
+
+// viewerRoutes sind die Endpoints, die ein viewer lesen darf: Lernpläne, Fortschritt und
+// Statistiken. Alles, was etwas ändert oder das LLM aufruft (Chat, Erklärungen, Quiz), fehlt.
+var viewerRoutes = map[string]bool{
+	"/api/v1/health":                       true,
+	"/api/v1/status":                       true,
+	"/api/v1/auth/me":                      true,
+	"/api/v1/documents":                    true,
+	"/api/v1/documents/{id}/stats":         true,
+	"/api/v1/documents/{id}/toc":           true,
+	"/api/v1/documents/{id}/figures":       true,
+	"/api/v1/figures/{id}":                 true,
+	"/api/v1/plans":                        true,
+	"/api/v1/plans/active":                 true,
+	"/api/v1/plans/{id}":                   true,
+	"/api/v1/plans/{id}/export":            true,
+	"/api/v1/plans/{id}/cheatsheet":        true,
+	"/api/v1/plans/{id}/readiness":         true,
+	"/api/v1/plans/{id}/difficulty-curve":  true,
+	"/api/v1/plans/{id}/question-coverage": true,
+	"/api/v1/plans/{id}/syllabus":          true,
+	"/api/v1/export/csv":                   true,
+	"/api/v1/topics/{id}":                  true,
+	"/api/v1/topics/{id}/objectives":       true,
+	"/api/v1/topics/{id}/explanations":     true,
+	"/api/v1/topics/{id}/misconceptions":   true,
+	"/api/v1/progress":                     true,
+	"/api/v1/stats/answer-speed":           true,
+	"/api/v1/stats/error-types":            true,
+	"/api/v1/sessions":                     true,
+	"/api/v1/boxes":                        true,
+	"/api/v1/quiz/interleaving":            true,
+	"/api/v1/quizzes":                      true,
+	"/api/v1/quizzes/{id}/results":         true,
+	"/api/v1/profiles":                     true,
+	"/api/v1/retention":                    true,
+	"/api/v1/goals/today":                  true,
+	"/api/v1/goals/history":                true,
+}
+
+type accessKey struct{}
+
+// authMiddleware prüft das Zugangstoken (Authorization: Bearer … oder ?token= für Downloads).
+// Ohne konfigurierte Tokens bleibt die API offen. Ein viewer darf nur die viewerRoutes lesen,
+// ein gekoppeltes Gerät nur die Bereiche seines Gerätetokens.
+func authMiddleware(cfg config.SecurityConfig, store storage.Storage) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if len(cfg.Tokens) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := requestToken(r)
+			access := findToken(cfg.Tokens, token)
+			if access == nil {
+				if device := findDevice(store, token); device != nil {
+					if !deviceAllowed(device, r) {
+						errorResponse(w, "Gerät hat keine Berechtigung für diese Anfrage (Bereiche: "+strings.Join(device.Scopes, ", ")+")", http.StatusForbidden)
+						return
+					}
+					next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), deviceKey{}, device)))
+					return
+				}
+				w.Header().Set("WWW-Authenticate", `Bearer realm="lernplattform"`)
+				errorResponse(w, "Anmeldung erforderlich (Zugangstoken fehlt oder ist ungültig)", http.StatusUnauthorized)
+				return
+			}
+			if access.Role != roleOwner && !viewerAllowed(r) {
+				errorResponse(w, "Nur-Lese-Zugang: nur Lernpläne, Fortschritt und Statistiken können angesehen werden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), accessKey{}, access)))
+		})
+	}
+}
+
+// requestToken liest das Token aus dem Authorization-Header oder dem Query-Parameter token
+func requestToken(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	}
+	return r.URL.Query().Get("token")
+}
+
+// findToken sucht das Token in konstanter Zeit; unbekannte Rollen gelten als viewer
+func findToken(tokens []config.AccessToken, token string) *config.AccessToken {
+	if token == "" {
+		return nil
+	}
+	var found *config.AccessToken
+	for i := range tokens {
+		if tokens[i].Token != "" && subtle.ConstantTimeCompare([]byte(tokens[i].Token), []byte(token)) == 1 {
+			found = &tokens[i]
+		}
+	}
+	return found
+}
+
+// viewerAllowed ist true für lesende Anfragen an die viewerRoutes
+func viewerAllowed(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+	tpl, err := route.GetPathTemplate()
+	if err != nil || !viewerRoutes[tpl] {
+		return false
+	}
+	// Die Einschätzung in Worten schreibt das LLM
+	if tpl == "/api/v1/plans/{id}/readiness" && r.URL.Query().Get("narrative") == "true" {
+		return false
+	}
+	return true
+}
+
+// GetAccess liefert Name und Rolle des angemeldeten Zugangs, damit das Frontend
+// im Nur-Lese-Modus die Bedienelemente zum Ändern ausblenden kann
+func (h *Handler) GetAccess(w http.ResponseWriter, r *http.Request) {
+	if device, ok := r.Context().Value(deviceKey{}).(*models.DeviceToken); ok {
+		jsonResponse(w, map[string]interface{}{"auth": true, "name": device.Name, "role": roleDevice, "scopes": device.Scopes}, http.StatusOK)
+		return
+	}
+	access, _ := r.Context().Value(accessKey{}).(*config.AccessToken)
+	if access == nil {
+		jsonResponse(w, map[string]interface{}{"auth": false, "role": roleOwner}, http.StatusOK)
+		return
+	}
+	role := access.Role
+	if role != roleOwner {
+		role = roleViewer
+	}
+	jsonResponse(w, map[string]interface{}{"auth": true, "name": access.Name, "role": role}, http.StatusOK)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/models"
+)
+
+// chatAnswer lädt eine Antwort des Tutors und die Frage, auf die sie antwortet
+func (h *Handler) chatAnswer(w http.ResponseWriter, r *http.Request) (*models.ChatMessage, string, bool) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	msg, err := h.store.GetChatMessage(id)
+	if err != nil {
+		errorResponse(w, "Nachricht nicht gefunden", http.StatusNotFound)
+		return nil, "", false
+	}
+	if msg.Role != "assistant" {
+		errorResponse(w, "Nur Antworten des Tutors können übernommen werden", http.StatusBadRequest)
+		return nil, "", false
+	}
+
+	// Die Frage ist die letzte Nachricht der lernenden Person vor der Antwort
+	var question string
+	history, _ := h.store.GetChatHistory(msg.SessionID)
+	for _, m := range history {
+		if m.ID == msg.ID {
+			break
+		}
+		if m.Role == "user" {
+			question = m.Content
+		}
+	}
+	return msg, question, true
+}
+
+// ChatToFlashcard übernimmt eine Chat-Antwort als Karteikarte (offene Frage) in ein Thema.
+// Body (optional): topic_id, falls die Nachricht keinem Thema zugeordnet ist.
+func (h *Handler) ChatToFlashcard(w http.ResponseWriter, r *http.Request) {
+	msg, question, ok := h.chatAnswer(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		TopicID string `json:"topic_id"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	topicID := req.TopicID
+	if topicID == "" {
+		topicID = msg.TopicID
+	}
+	if topicID == "" {
+		if session, err := h.store.GetChatSession(msg.SessionID); err == nil {
+			topicID = session.TopicID
+		}
+	}
+	if topicID == "" {
+		errorResponse(w, "Bitte topic_id angeben (die Nachricht gehört zu keinem Thema)", http.StatusBadRequest)
+		return
+	}
+	topic, err := h.store.GetTopic(topicID)
+	if err != nil {
+		errorResponse(w, "Thema nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	ctx := h.withTopicSettings(r.Context(), topic)
+	card, err := h.tutor.ChatToFlashcard(ctx, topic, question, msg.Content)
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler beim Erstellen der Karteikarte: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if h.safety.Enabled() {
+		if check := h.safety.CheckQuestion(ctx, card); !check.Safe {
+			log.Printf("⚠️ Karteikarte vom Inhaltsfilter verworfen (%s): %s", check.Reason, card.Question)
+			errorResponse(w, "Karteikarte wurde vom Inhaltsfilter blockiert: "+check.Reason, http.StatusUnprocessableEntity)
+			return
+		}
+	}
+	if err := h.store.SaveQuestion(card); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("🗂️ Chat-Antwort als Karteikarte in '%s' übernommen", topic.Name)
+	jsonResponse(w, card, http.StatusCreated)
+}
+
+// ChatToGlossary übernimmt eine Chat-Antwort als Glossar-Eintrag (Begriff und Definition)
+func (h *Handler) ChatToGlossary(w http.ResponseWriter, r *http.Request) {
+	msg, question, ok := h.chatAnswer(w, r)
+	if !ok {
+		return
+	}
+
+	item, err := h.tutor.ChatToGlossary(r.Context(), question, msg.Content)
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler beim Erstellen des Glossar-Eintrags: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if err := h.store.SaveGlossaryItem(item); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("📖 Chat-Antwort als Glossar-Eintrag „%s“ übernommen", item.Term)
+	jsonResponse(w, item, http.StatusCreated)
+}
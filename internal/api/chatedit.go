@@ -0,0 +1,220 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/llm"
+	"lernplattform/internal/models"
+)
+
+// RegenerateChatMessage beantwortet die vorangehende Frage einer Antwort neu. Die alte Antwort
+// bleibt als Version erhalten (GET /chat/messages/{id}/versions), fehlt aber im Verlauf.
+// Body (optional): include_notes wie beim Chat.
+func (h *Handler) RegenerateChatMessage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req struct {
+		IncludeNotes bool `json:"include_notes"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	msg, err := h.store.GetChatMessage(id)
+	if err != nil {
+		errorResponse(w, "Nachricht nicht gefunden", http.StatusNotFound)
+		return
+	}
+	if msg.Role != "assistant" {
+		errorResponse(w, "Nur Antworten können neu erzeugt werden", http.StatusBadRequest)
+		return
+	}
+	if msg.ReplacedBy != "" {
+		errorResponse(w, "Die Antwort wurde bereits ersetzt", http.StatusConflict)
+		return
+	}
+
+	history, err := h.store.GetChatHistory(msg.SessionID)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	pos := -1
+	for i := range history {
+		if history[i].ID == msg.ID {
+			pos = i
+		}
+	}
+	if pos < 1 || history[pos-1].Role != "user" {
+		errorResponse(w, "Zur Antwort gehört keine Frage", http.StatusConflict)
+		return
+	}
+
+	ctx, end := h.beginGeneration(w, r, r.Context())
+	defer end()
+	resp, citations, err := h.answerChatMessage(ctx, msg.SessionID, history[:pos], req.IncludeNotes)
+	if err != nil {
+		generationFailed(w, ctx, fmt.Sprintf("Chat-Fehler: %v", err))
+		return
+	}
+
+	// Neue Fassung übernimmt den Platz der alten im Verlauf
+	answer := &models.ChatMessage{
+		ID:         fmt.Sprintf("msg_%d", time.Now().UnixNano()),
+		SessionID:  msg.SessionID,
+		Role:       "assistant",
+		Content:    resp.Content,
+		Timestamp:  msg.Timestamp,
+		TopicID:    msg.TopicID,
+		DocumentID: msg.DocumentID,
+	}
+	if err := h.store.ReplaceChatMessage(msg.ID, answer); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+
+	result := map[string]interface{}{
+		"response":    resp.Content,
+		"model":       resp.Model,
+		"message_id":  answer.ID,
+		"previous_id": msg.ID,
+	}
+	if citations != nil {
+		result["citations"] = citations
+	}
+	jsonResponse(w, result, http.StatusOK)
+}
+
+// GetChatMessageVersions listet die früheren Fassungen einer neu erzeugten Antwort, neueste zuerst
+func (h *Handler) GetChatMessageVersions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if _, err := h.store.GetChatMessage(id); err != nil {
+		errorResponse(w, "Nachricht nicht gefunden", http.StatusNotFound)
+		return
+	}
+	versions, err := h.store.GetChatMessageVersions(id)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	if versions == nil {
+		versions = []models.ChatMessage{}
+	}
+	jsonResponse(w, versions, http.StatusOK)
+}
+
+// EditChatMessage ändert eine eigene Frage. Alle späteren Nachrichten der Sitzung werden
+// gelöscht, danach wird die geänderte Frage neu beantwortet. Body: content, include_notes.
+func (h *Handler) EditChatMessage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req struct {
+		Content      string `json:"content"`
+		IncludeNotes bool   `json:"include_notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+	req.Content = strings.TrimSpace(req.Content)
+	if req.Content == "" {
+		errorResponse(w, "Bitte einen Text angeben", http.StatusBadRequest)
+		return
+	}
+
+	msg, err := h.store.GetChatMessage(id)
+	if err != nil {
+		errorResponse(w, "Nachricht nicht gefunden", http.StatusNotFound)
+		return
+	}
+	if msg.Role != "user" {
+		errorResponse(w, "Nur eigene Fragen können bearbeitet werden", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.EditChatMessage(msg.ID, req.Content); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+	history, err := h.store.GetChatHistory(msg.SessionID)
+	if err != nil || len(history) == 0 {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+
+	ctx, end := h.beginGeneration(w, r, r.Context())
+	defer end()
+	resp, citations, err := h.answerChatMessage(ctx, msg.SessionID, history, req.IncludeNotes)
+	if err != nil {
+		generationFailed(w, ctx, fmt.Sprintf("Chat-Fehler: %v", err))
+		return
+	}
+
+	answer := &models.ChatMessage{
+		ID:         fmt.Sprintf("msg_%d", time.Now().UnixNano()),
+		SessionID:  msg.SessionID,
+		Role:       "assistant",
+		Content:    resp.Content,
+		Timestamp:  time.Now(),
+		TopicID:    msg.TopicID,
+		DocumentID: msg.DocumentID,
+	}
+	if err := h.store.SaveChatMessage(answer); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+
+	result := map[string]interface{}{
+		"response":   resp.Content,
+		"model":      resp.Model,
+		"message_id": answer.ID,
+	}
+	if citations != nil {
+		result["citations"] = citations
+	}
+	jsonResponse(w, result, http.StatusOK)
+}
+
+// answerChatMessage beantwortet die letzte Frage eines Verlaufs erneut, mit demselben Kontext
+// wie beim ersten Mal: Dokument-Chat, sonst Thema der Frage und Modus der Sitzung.
+// citations ist nur beim Dokument-Chat gesetzt.
+func (h *Handler) answerChatMessage(ctx context.Context, sessionID string, history []models.ChatMessage, includeNotes bool) (*llm.GenerateResponse, []documentCitation, error) {
+	question := history[len(history)-1]
+
+	messages := make([]llm.ChatMessage, len(history))
+	query := question.Content
+	for i, msg := range history {
+		messages[i] = llm.ChatMessage{Role: msg.Role, Content: msg.Content}
+		// Wie beim Dokument-Chat: die vorige Frage hilft bei Rückfragen in der Suche
+		if msg.Role == "user" && i < len(history)-1 {
+			query = msg.Content + " " + question.Content
+		}
+	}
+
+	if question.DocumentID != "" {
+		doc, err := h.store.GetDocument(question.DocumentID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("dokument nicht gefunden: %w", err)
+		}
+		resp, chunks, err := h.documentChatReply(ctx, doc, sessionID, query, messages)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp, pageCitations(resp.Content, chunks, doc.PageCount), nil
+	}
+
+	mode := ""
+	if session, err := h.store.GetChatSession(sessionID); err == nil {
+		mode = session.Mode
+	}
+	resp, err := h.topicChatReply(ctx, question.TopicID, sessionID, mode, includeNotes, messages)
+	return resp, nil, err
+}
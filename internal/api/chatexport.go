@@ -0,0 +1,138 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/models"
+)
+
+// ExportChatHistory liefert den Verlauf einer Chat-Sitzung als Markdown für eigene Notizen:
+// Kopf mit Thema bzw. Dokument, angeheftetem Kontext, danach Fragen und Antworten mit Quellen
+func (h *Handler) ExportChatHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["sessionId"]
+
+	messages, err := h.store.GetChatHistory(sessionID)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	if len(messages) == 0 {
+		errorResponse(w, "Chat-Verlauf nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	topicLabels := make(map[string]string)
+	topicLabel := func(id string) string {
+		if id == "" {
+			return ""
+		}
+		if label, ok := topicLabels[id]; ok {
+			return label
+		}
+		label := ""
+		if topic, err := h.store.GetTopic(id); err == nil {
+			label = topic.Name
+			if plan, err := h.store.GetStudyPlan(topic.StudyPlanID); err == nil {
+				label = plan.Name + " – " + topic.Name
+			}
+		}
+		topicLabels[id] = label
+		return label
+	}
+	documents := make(map[string]*models.Document)
+	document := func(id string) *models.Document {
+		if id == "" {
+			return nil
+		}
+		if doc, ok := documents[id]; ok {
+			return doc
+		}
+		doc, _ := h.store.GetDocument(id)
+		documents[id] = doc
+		return doc
+	}
+
+	// Kopf: Thema der Sitzung, sonst das der ersten Nachricht
+	session, _ := h.store.GetChatSession(sessionID)
+	mainTopic := messages[0].TopicID
+	if session != nil && session.TopicID != "" {
+		mainTopic = session.TopicID
+	}
+	title := "Chat"
+	switch {
+	case messages[0].DocumentID != "" && document(messages[0].DocumentID) != nil:
+		title = "Chat: " + documentTitle(document(messages[0].DocumentID))
+	case topicLabel(mainTopic) != "":
+		title = "Chat: " + topicLabel(mainTopic)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# %s\n\n", title))
+	sb.WriteString(fmt.Sprintf("- Datum: %s\n", messages[0].Timestamp.Format("02.01.2006 15:04")))
+	if label := topicLabel(mainTopic); label != "" {
+		sb.WriteString(fmt.Sprintf("- Thema: %s\n", label))
+	}
+	if session != nil {
+		sb.WriteString(fmt.Sprintf("- Modus: %s\n", chatModeOrDefault(session.Mode)))
+	}
+	sb.WriteString(fmt.Sprintf("- Exportiert: %s\n\n", time.Now().Format("02.01.2006 15:04")))
+
+	if pins, _ := h.store.GetChatPins(sessionID); len(pins) > 0 {
+		sb.WriteString("**Angeheftet**\n\n")
+		for _, pin := range pins {
+			sb.WriteString(fmt.Sprintf("- %s\n", pin.Label))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("---\n\n")
+
+	currentTopic := mainTopic
+	for _, msg := range messages {
+		switch msg.Role {
+		case "user":
+			// Im Modus "general" kann jede Frage zu einem anderen Thema gehören
+			if msg.TopicID != "" && msg.TopicID != currentTopic {
+				currentTopic = msg.TopicID
+				if label := topicLabel(msg.TopicID); label != "" {
+					sb.WriteString(fmt.Sprintf("*Thema: %s*\n\n", label))
+				}
+			}
+			heading := chatHeading(msg.Content)
+			sb.WriteString(fmt.Sprintf("## 🙋 %s\n\n", heading))
+			if content := strings.TrimSpace(msg.Content); content != heading {
+				sb.WriteString(content + "\n\n") // lange Fragen vollständig
+			}
+		case "assistant":
+			sb.WriteString(strings.TrimSpace(msg.Content) + "\n\n")
+			if doc := document(msg.DocumentID); doc != nil {
+				if pages := citedPages(msg.Content, doc.PageCount); len(pages) > 0 {
+					list := make([]string, len(pages))
+					for i, page := range pages {
+						list[i] = strconv.Itoa(page)
+					}
+					sb.WriteString(fmt.Sprintf("> Quellen: %s, S. %s\n\n", documentTitle(doc), strings.Join(list, ", ")))
+				}
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "chat_"+sessionID+".md"))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(sb.String()))
+}
+
+// chatHeading kürzt eine Frage auf eine Zeile für die Überschrift
+func chatHeading(content string) string {
+	heading := strings.Join(strings.Fields(content), " ")
+	if runes := []rune(heading); len(runes) > 80 {
+		heading = string(runes[:80]) + "…"
+	}
+	return heading
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/models"
+	"lernplattform/internal/pdf"
+)
+
+// Obergrenzen für angehefteten Kontext: je Eintrag und insgesamt im Prompt. Neue Einträge werden
+// abgelehnt, wenn sie nicht mehr hineinpassen, damit alle angehefteten Einträge mitgehen.
+const (
+	maxPinChars    = 2000
+	maxPinnedChars = 4000
+)
+
+// GetChatPins listet den angehefteten Kontext einer Chat-Sitzung
+func (h *Handler) GetChatPins(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	pins, err := h.store.GetChatPins(id)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	if pins == nil {
+		pins = []models.ChatPin{}
+	}
+	jsonResponse(w, pins, http.StatusOK)
+}
+
+// PinChatContext heftet eine Textstelle, einen Glossar-Eintrag oder eine eigene Notiz an eine
+// Chat-Sitzung an. Body: kind ("passage", "glossary", "note"), ref_id (Glossar-Eintrag bzw.
+// Notiz), für Textstellen document_id mit page und/oder text.
+func (h *Handler) PinChatContext(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sessionID := vars["id"]
+
+	if _, err := h.store.GetChatSession(sessionID); err != nil {
+		errorResponse(w, "Chat-Sitzung nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	var req struct {
+		Kind       string `json:"kind"`
+		RefID      string `json:"ref_id"`
+		DocumentID string `json:"document_id"`
+		Page       int    `json:"page"`
+		Text       string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+
+	pin := &models.ChatPin{
+		ID:        fmt.Sprintf("pin_%d", time.Now().UnixNano()),
+		SessionID: sessionID,
+		Kind:      req.Kind,
+		RefID:     req.RefID,
+		CreatedAt: time.Now(),
+	}
+	switch req.Kind {
+	case "passage":
+		doc, err := h.store.GetDocument(req.DocumentID)
+		if err != nil {
+			errorResponse(w, "Dokument nicht gefunden", http.StatusNotFound)
+			return
+		}
+		text := strings.TrimSpace(req.Text)
+		if text == "" && req.Page > 0 {
+			text = pdf.ExtractPages(doc.Content, req.Page, req.Page)
+		}
+		if text == "" {
+			errorResponse(w, "Bitte text oder eine vorhandene page angeben", http.StatusBadRequest)
+			return
+		}
+		if req.Text != "" && utf8.RuneCountInString(text) > maxPinChars {
+			errorResponse(w, fmt.Sprintf("Textstelle zu lang (maximal %d Zeichen)", maxPinChars), http.StatusBadRequest)
+			return
+		}
+		// Eine ganze Seite wird auf das Limit gekürzt
+		if runes := []rune(text); len(runes) > maxPinChars {
+			text = string(runes[:maxPinChars])
+		}
+		pin.RefID, pin.Page, pin.Content = doc.ID, req.Page, text
+		pin.Label = documentTitle(doc)
+		if req.Page > 0 {
+			pin.Label += fmt.Sprintf(", S. %d", req.Page)
+		}
+	case "glossary":
+		item, err := h.store.GetGlossaryItem(req.RefID)
+		if err != nil {
+			errorResponse(w, "Begriff nicht gefunden", http.StatusNotFound)
+			return
+		}
+		pin.Label = item.Term
+		if utf8.RuneCountInString(item.Definition) > maxPinChars {
+			errorResponse(w, fmt.Sprintf("Begriff zu lang zum Anheften (maximal %d Zeichen)", maxPinChars), http.StatusBadRequest)
+			return
+		}
+	case "note":
+		note, err := h.store.GetNote(req.RefID)
+		if err != nil {
+			errorResponse(w, "Notiz nicht gefunden", http.StatusNotFound)
+			return
+		}
+		pin.Label = noteLabel(note.Content)
+		if utf8.RuneCountInString(note.Content) > maxPinChars {
+			errorResponse(w, fmt.Sprintf("Notiz zu lang zum Anheften (maximal %d Zeichen)", maxPinChars), http.StatusBadRequest)
+			return
+		}
+	default:
+		errorResponse(w, "kind muss 'passage', 'glossary' oder 'note' sein", http.StatusBadRequest)
+		return
+	}
+
+	// Was nicht mehr in den Prompt passt, wird gar nicht erst angeheftet
+	pins, err := h.store.GetChatPins(sessionID)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	used := 0
+	for i := range pins {
+		used += utf8.RuneCountInString(h.pinEntry(&pins[i]))
+	}
+	if used+utf8.RuneCountInString(h.pinEntry(pin)) > maxPinnedChars {
+		errorResponse(w, fmt.Sprintf("Kein Platz mehr für angehefteten Kontext (insgesamt maximal %d Zeichen), bitte zuerst etwas lösen", maxPinnedChars), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.SaveChatPin(pin); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+	jsonResponse(w, pin, http.StatusCreated)
+}
+
+// UnpinChatContext löst angehefteten Kontext von einer Chat-Sitzung
+func (h *Handler) UnpinChatContext(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.store.DeleteChatPin(vars["id"], vars["pinId"]); err != nil {
+		errorResponse(w, "Angehefteter Kontext nicht gefunden", http.StatusNotFound)
+		return
+	}
+	jsonResponse(w, map[string]string{"message": "Kontext gelöst"}, http.StatusOK)
+}
+
+// noteLabel kürzt eine Notiz auf ihre erste Zeile
+func noteLabel(content string) string {
+	label, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	if runes := []rune(label); len(runes) > 60 {
+		label = string(runes[:60]) + "…"
+	}
+	return label
+}
+
+// pinnedContext setzt den angehefteten Kontext einer Sitzung für den Prompt zusammen.
+// Glossar-Einträge und Notizen werden aktuell geladen; gelöschte fallen heraus.
+func (h *Handler) pinnedContext(sessionID string) string {
+	if sessionID == "" {
+		return ""
+	}
+	pins, err := h.store.GetChatPins(sessionID)
+	if err != nil || len(pins) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("=== Angeheftet (immer berücksichtigen) ===\n")
+	for i := range pins {
+		sb.WriteString(h.pinEntry(&pins[i]))
+	}
+	sb.WriteString("=== Weitere Abschnitte aus den Lernmaterialien ===\n")
+	return sb.String()
+}
+
+// pinEntry formatiert einen angehefteten Eintrag für den Prompt (leer, wenn Begriff oder Notiz
+// gelöscht wurden). Nachträglich verlängerte Notizen werden auf das Limit je Eintrag gekürzt.
+func (h *Handler) pinEntry(pin *models.ChatPin) string {
+	var text string
+	switch pin.Kind {
+	case "passage":
+		return fmt.Sprintf("[%s]\n%s\n\n", pin.Label, pin.Content)
+	case "glossary":
+		item, err := h.store.GetGlossaryItem(pin.RefID)
+		if err != nil {
+			return ""
+		}
+		text = "Begriff " + item.Term + ": " + item.Definition
+	case "note":
+		note, err := h.store.GetNote(pin.RefID)
+		if err != nil {
+			return ""
+		}
+		text = "Eigene Notiz: " + note.Content
+	}
+	if runes := []rune(text); len(runes) > maxPinChars {
+		text = string(runes[:maxPinChars])
+	}
+	return text + "\n\n"
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"lernplattform/internal/llm"
+)
+
+// Unter dieser Ähnlichkeit passt eine Frage zu keinem Thema (allgemeiner Chat ohne Thema)
+const minRouteSimilarity = 0.35
+
+// chatRoute ist das Thema, dem eine Frage im Chat-Modus "general" zugeordnet wurde
+type chatRoute struct {
+	TopicID   string  `json:"topic_id"`
+	TopicName string  `json:"topic_name"`
+	PlanID    string  `json:"plan_id"`
+	PlanName  string  `json:"plan_name"`
+	Score     float64 `json:"score"`  // Ähnlichkeit (Embeddings) bzw. Anteil gefundener Begriffe
+	Method    string  `json:"method"` // embeddings oder keywords
+}
+
+// routeCandidate ist ein Thema mit dem Text, der für die Zuordnung verglichen wird
+type routeCandidate struct {
+	route chatRoute
+	text  string
+}
+
+// embeddingCache hält die Embeddings der Thementexte, damit sie nicht bei jeder Frage neu
+// berechnet werden (Schlüssel ist der Text selbst, geänderte Themen bekommen neue Einträge)
+type embeddingCache struct {
+	mu      sync.Mutex
+	vectors map[string][]float64
+}
+
+func newEmbeddingCache() *embeddingCache {
+	return &embeddingCache{vectors: make(map[string][]float64)}
+}
+
+// routeCandidates sammelt die Themen aller Lernpläne; abgeschlossene Pläne zählen nicht mit
+func (h *Handler) routeCandidates() []routeCandidate {
+	plans, err := h.store.GetAllStudyPlans()
+	if err != nil {
+		return nil
+	}
+	var candidates []routeCandidate
+	for _, plan := range plans {
+		if plan.Status == "completed" {
+			continue
+		}
+		topics, _ := h.store.GetTopicsByPlan(plan.ID)
+		for _, t := range leafTopics(topics) {
+			candidates = append(candidates, routeCandidate{
+				route: chatRoute{TopicID: t.ID, TopicName: t.Name, PlanID: plan.ID, PlanName: plan.Name},
+				text:  plan.Name + ": " + t.Name + ". " + t.Description,
+			})
+		}
+	}
+	return candidates
+}
+
+// routeChatTopic ordnet eine Frage dem passendsten Thema aller Lernpläne zu, bevorzugt über
+// Embeddings und ohne Embedding-Modell über gemeinsame Begriffe. nil = kein Thema passt.
+func (h *Handler) routeChatTopic(ctx context.Context, message string) *chatRoute {
+	candidates := h.routeCandidates()
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	route, err := h.routeByEmbeddings(ctx, message, candidates)
+	if err != nil {
+		if !errors.Is(err, llm.ErrNoEmbeddings) {
+			log.Printf("   ⚠️ Themenzuordnung ohne Embeddings: %v", err)
+		}
+		route = routeByKeywords(message, candidates)
+	}
+	return route
+}
+
+// routeByEmbeddings wählt das Thema mit der größten Kosinus-Ähnlichkeit zur Frage
+func (h *Handler) routeByEmbeddings(ctx context.Context, message string, candidates []routeCandidate) (*chatRoute, error) {
+	h.embeddings.mu.Lock()
+	var missing []string
+	for _, c := range candidates {
+		if _, ok := h.embeddings.vectors[c.text]; !ok {
+			missing = append(missing, c.text)
+		}
+	}
+	h.embeddings.mu.Unlock()
+
+	vectors, err := h.tutor.Embed(ctx, append([]string{message}, missing...))
+	if err != nil {
+		return nil, err
+	}
+	query := vectors[0]
+
+	h.embeddings.mu.Lock()
+	defer h.embeddings.mu.Unlock()
+	for i, text := range missing {
+		h.embeddings.vectors[text] = vectors[i+1]
+	}
+
+	var best *chatRoute
+	bestScore := 0.0
+	for _, c := range candidates {
+		score := llm.CosineSimilarity(query, h.embeddings.vectors[c.text])
+		if score >= minRouteSimilarity && (best == nil || score > bestScore) {
+			bestScore = score
+			route := c.route
+			route.Score, route.Method = math.Round(score*100)/100, "embeddings"
+			best = &route
+		}
+	}
+	return best, nil
+}
+
+// routeByKeywords wählt das Thema, dessen Name und Beschreibung die meisten Begriffe der Frage enthalten
+func routeByKeywords(message string, candidates []routeCandidate) *chatRoute {
+	var terms []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(message), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		if utf8.RuneCountInString(w) >= 4 {
+			terms = append(terms, w)
+		}
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var best *chatRoute
+	bestScore := 0.0
+	for _, c := range candidates {
+		text := strings.ToLower(c.text)
+		found := 0
+		for _, term := range terms {
+			if strings.Contains(text, term) {
+				found++
+			}
+		}
+		score := float64(found) / float64(len(terms))
+		if found > 0 && (best == nil || score > bestScore) {
+			bestScore = score
+			route := c.route
+			route.Score, route.Method = math.Round(score*100)/100, "keywords"
+			best = &route
+		}
+	}
+	return best
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/models"
+)
+
+// Lernziele je Thema auf dem Spickzettel; Prüfungsschwerpunkte bekommen entsprechend ihrem Gewicht mehr
+const cheatSheetObjectives = 2
+
+// ExportCheatSheet liefert einen kompakten Spickzettel zum Lernplan als Markdown. Prüfungsschwerpunkte
+// aus dem Syllabus stehen vorne, nach Gewicht sortiert und ausführlicher (mehr Lernziele, Merkhilfen);
+// die übrigen Themen folgen knapp in Plan-Reihenfolge.
+func (h *Handler) ExportCheatSheet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	plan, err := h.store.GetStudyPlan(id)
+	if err != nil {
+		errorResponse(w, "Lernplan nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	var focus, rest []models.Topic
+	for _, t := range leafTopics(plan.Topics) {
+		if t.ExamWeight > 0 {
+			focus = append(focus, t)
+		} else {
+			rest = append(rest, t)
+		}
+	}
+	sort.SliceStable(focus, func(i, j int) bool { return focus[i].ExamWeight > focus[j].ExamWeight })
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Spickzettel: %s\n\n", plan.Name))
+	sb.WriteString(fmt.Sprintf("Prüfung: %s · Stand: %s\n\n", plan.ExamDate.Format("02.01.2006"), time.Now().Format("02.01.2006")))
+
+	if len(focus) > 0 {
+		sb.WriteString("## ⭐ Prüfungsschwerpunkte\n\n")
+		for _, t := range focus {
+			sb.WriteString(fmt.Sprintf("### %s (Gewicht %.1f)\n\n", t.Name, t.ExamWeight))
+			if t.Description != "" {
+				sb.WriteString(t.Description + "\n\n")
+			}
+			h.writeCheatSheetObjectives(&sb, t.ID, int(math.Round(cheatSheetObjectives*examWeightFactor(t.ExamWeight))))
+			if mnemonics, _ := h.store.GetMnemonicsByTopic(t.ID); len(mnemonics) > 0 {
+				for _, m := range mnemonics {
+					sb.WriteString(fmt.Sprintf("- 💡 %s: %s\n", m.Term, m.Content))
+				}
+				sb.WriteString("\n")
+			}
+		}
+	}
+
+	if len(rest) > 0 {
+		if len(focus) > 0 {
+			sb.WriteString("## Weitere Themen\n\n")
+		}
+		for _, t := range rest {
+			sb.WriteString(fmt.Sprintf("**%s**", t.Name))
+			if t.Description != "" {
+				sb.WriteString(" – " + t.Description)
+			}
+			sb.WriteString("\n\n")
+			h.writeCheatSheetObjectives(&sb, t.ID, cheatSheetObjectives)
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "spickzettel_"+plan.ID+".md"))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(sb.String()))
+}
+
+// writeCheatSheetObjectives schreibt höchstens limit Lernziele eines Themas als Stichpunkte
+func (h *Handler) writeCheatSheetObjectives(sb *strings.Builder, topicID string, limit int) {
+	objectives, _ := h.store.GetObjectivesByTopic(topicID)
+	if len(objectives) == 0 {
+		return
+	}
+	for i, obj := range objectives {
+		if i == limit {
+			break
+		}
+		sb.WriteString("- " + obj.Text + "\n")
+	}
+	sb.WriteString("\n")
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/llm"
+	"lernplattform/internal/models"
+)
+
+// Anzahl der Gegenstände je Vergleich: mehr passen nicht sinnvoll in eine Tabelle
+const maxCompareItems = 5
+
+// Compare erstellt eine Vergleichstabelle zwischen Themen und/oder Glossar-Begriffen.
+// Body: topic_ids, terms (Begriff oder Glossar-ID), zusammen mindestens 2;
+// refresh erzwingt einen neuen Vergleich statt des gespeicherten
+func (h *Handler) Compare(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TopicIDs []string `json:"topic_ids"`
+		Terms    []string `json:"terms"`
+		Refresh  bool     `json:"refresh"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+
+	var items []llm.CompareItem
+	var topicIDs, glossaryIDs, keys []string
+	seen := make(map[string]bool)
+	for _, id := range req.TopicIDs {
+		id = strings.TrimSpace(id)
+		if id == "" || seen["t:"+id] {
+			continue
+		}
+		topic, err := h.store.GetTopic(id)
+		if err != nil {
+			errorResponse(w, fmt.Sprintf("Thema %s nicht gefunden", id), http.StatusNotFound)
+			return
+		}
+		seen["t:"+id] = true
+		topicIDs = append(topicIDs, topic.ID)
+		keys = append(keys, "t:"+topic.ID)
+		items = append(items, llm.CompareItem{Name: topic.Name, Material: h.topicContent(topic)})
+	}
+
+	if len(req.Terms) > 0 {
+		glossary, err := h.store.GetAllGlossaryItems()
+		if err != nil {
+			errorResponse(w, "Fehler beim Laden des Glossars", http.StatusInternalServerError)
+			return
+		}
+		for _, term := range req.Terms {
+			term = strings.TrimSpace(term)
+			if term == "" {
+				continue
+			}
+			item := findGlossaryItem(term, glossary)
+			if item == nil {
+				errorResponse(w, fmt.Sprintf("Begriff '%s' nicht im Glossar", term), http.StatusNotFound)
+				return
+			}
+			if seen["g:"+item.ID] {
+				continue
+			}
+			seen["g:"+item.ID] = true
+			glossaryIDs = append(glossaryIDs, item.ID)
+			keys = append(keys, "g:"+item.ID)
+			items = append(items, llm.CompareItem{Name: item.Term, Material: glossaryMaterial(item)})
+		}
+	}
+
+	if len(items) < 2 {
+		errorResponse(w, "Mindestens zwei Themen oder Begriffe angeben", http.StatusBadRequest)
+		return
+	}
+	if len(items) > maxCompareItems {
+		errorResponse(w, fmt.Sprintf("Höchstens %d Themen oder Begriffe je Vergleich", maxCompareItems), http.StatusBadRequest)
+		return
+	}
+
+	// Gleiche Auswahl in anderer Reihenfolge ist derselbe Vergleich
+	sort.Strings(keys)
+	key := strings.Join(keys, ",")
+	if !req.Refresh {
+		if existing, err := h.store.GetComparisonByKey(key); err == nil {
+			jsonResponse(w, existing, http.StatusOK)
+			return
+		}
+	}
+
+	comparison, err := h.tutor.CompareItems(r.Context(), items)
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler beim Vergleich: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if h.safety.Enabled() {
+		if check := h.safety.Check(r.Context(), comparisonText(comparison)); !check.Safe {
+			log.Printf("⚠️ Vergleich %s vom Inhaltsfilter verworfen (%s)", strings.Join(comparison.Subjects, " / "), check.Reason)
+			errorResponse(w, "Vergleich wurde vom Inhaltsfilter blockiert: "+check.Reason, http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	comparison.ID = fmt.Sprintf("cmp_%d", time.Now().UnixNano())
+	comparison.Key = key
+	comparison.TopicIDs = topicIDs
+	comparison.GlossaryIDs = glossaryIDs
+	comparison.CreatedAt = time.Now()
+	if err := h.store.SaveComparison(comparison); err != nil {
+		log.Printf("⚠️ Vergleich konnte nicht gespeichert werden: %v", err)
+	}
+
+	log.Printf("⚖️ Vergleich erstellt: %s", strings.Join(comparison.Subjects, " / "))
+	jsonResponse(w, comparison, http.StatusCreated)
+}
+
+// comparisonText fasst die vom LLM geschriebenen Teile eines Vergleichs für den Inhaltsfilter zusammen
+func comparisonText(c *models.Comparison) string {
+	var sb strings.Builder
+	for _, row := range c.Rows {
+		sb.WriteString(row.Criterion + ": " + strings.Join(row.Values, " | ") + "\n")
+	}
+	sb.WriteString(strings.Join(c.Similarities, "\n") + "\n")
+	sb.WriteString(strings.Join(c.Differences, "\n") + "\n")
+	sb.WriteString(c.Summary)
+	return sb.String()
+}
+
+// GetComparisons listet alle gespeicherten Vergleiche (neueste zuerst)
+func (h *Handler) GetComparisons(w http.ResponseWriter, r *http.Request) {
+	comparisons, err := h.store.GetAllComparisons()
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	if comparisons == nil {
+		comparisons = []models.Comparison{}
+	}
+	jsonResponse(w, comparisons, http.StatusOK)
+}
+
+// GetComparison liefert einen gespeicherten Vergleich
+func (h *Handler) GetComparison(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	comparison, err := h.store.GetComparison(id)
+	if err != nil {
+		errorResponse(w, "Vergleich nicht gefunden", http.StatusNotFound)
+		return
+	}
+	jsonResponse(w, comparison, http.StatusOK)
+}
+
+// findGlossaryItem sucht einen Glossar-Eintrag per ID oder Begriff (ohne Groß-/Kleinschreibung)
+func findGlossaryItem(term string, glossary []models.GlossaryItem) *models.GlossaryItem {
+	for i := range glossary {
+		if glossary[i].ID == term || strings.EqualFold(strings.TrimSpace(glossary[i].Term), term) {
+			return &glossary[i]
+		}
+	}
+	return nil
+}
+
+// glossaryMaterial fasst einen Glossar-Eintrag als Material für den Vergleich zusammen
+func glossaryMaterial(item *models.GlossaryItem) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s (%s): %s\n", item.Term, item.Category, item.Definition))
+	if item.Details != "" {
+		sb.WriteString(item.Details + "\n")
+	}
+	if len(item.Related) > 0 {
+		sb.WriteString("Verwandte Begriffe: " + strings.Join(item.Related, ", ") + "\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// changeTracker zählt Datenänderungen, damit teure GET-Endpoints ein ETag ohne
+// Laden und Serialisieren der Daten vergeben können
+type changeTracker struct {
+	mu       sync.Mutex
+	epoch    int64 // Prozessstart, damit ETags einen Neustart nicht überleben
+	version  uint64
+	modified time.Time
+}
+
+func newChangeTracker() *changeTracker {
+	now := time.Now()
+	return &changeTracker{epoch: now.UnixNano(), modified: now}
+}
+
+// bump markiert, dass sich Daten geändert haben (nach jeder schreibenden Anfrage und in Hintergrundjobs)
+func (c *changeTracker) bump() {
+	c.mu.Lock()
+	c.version++
+	c.modified = time.Now()
+	c.mu.Unlock()
+}
+
+func (c *changeTracker) current() (uint64, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.version, c.modified
+}
+
+// trackChanges zählt jede schreibende API-Anfrage als Änderung
+func (c *changeTracker) trackChanges(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			c.bump()
+		}
+	})
+}
+
+// notModified setzt ETag und Last-Modified nach dem Änderungsstand und beantwortet eine
+// bedingte Anfrage mit 304, wenn sich seitdem nichts geändert hat. variant unterscheidet
+// Antworten, die zusätzlich von etwas anderem abhängen (z.B. der aktuellen Zeit).
+func (h *Handler) notModified(w http.ResponseWriter, r *http.Request, variant string) bool {
+	version, modified := h.changes.current()
+	etag := fmt.Sprintf(`W/"%x-%d`, h.changes.epoch, version)
+	if variant != "" {
+		etag += "-" + variant
+	}
+	etag += `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+
+	// If-None-Match hat Vorrang; If-Modified-Since nur ohne ETag vom Client (Sekundengenauigkeit)
+	if r.Header.Get("If-None-Match") != "" {
+		if !etagMatches(r, etag) {
+			return false
+		}
+	} else if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err != nil || modified.Truncate(time.Second).After(since) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
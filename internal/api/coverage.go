@@ -0,0 +1,96 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// topicCoverage zählt die Fragen eines Themas nach Schwierigkeit und Fragetyp
+type topicCoverage struct {
+	TopicID      string         `json:"topic_id"`
+	TopicName    string         `json:"topic_name"`
+	Questions    int            `json:"questions"`
+	ByDifficulty map[string]int `json:"by_difficulty"` // "1" bis "5", fehlende Stufen mit 0
+	ByType       map[string]int `json:"by_type"`
+	Exam         int            `json:"exam_questions,omitempty"`
+	// Schwierigkeitsstufen ohne Fragen; leer bei Themen ganz ohne Fragen (die stehen unter uncovered)
+	MissingDifficulties []int `json:"missing_difficulties,omitempty"`
+}
+
+// GetQuestionCoverage zeigt je Thema des Plans, wie viele Fragen es je Schwierigkeit und Fragetyp
+// gibt, und welche Themen noch gar keine Fragen haben – Lücken vor der Prüfungswoche.
+func (h *Handler) GetQuestionCoverage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	plan, err := h.store.GetStudyPlan(id)
+	if err != nil {
+		errorResponse(w, "Lernplan nicht gefunden", http.StatusNotFound)
+		return
+	}
+	questions, err := h.store.GetQuestionsByPlan(plan.ID)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+
+	newCoverage := func(topicID, name string) *topicCoverage {
+		c := &topicCoverage{TopicID: topicID, TopicName: name, ByDifficulty: make(map[string]int), ByType: make(map[string]int)}
+		for d := 1; d <= 5; d++ {
+			c.ByDifficulty[strconv.Itoa(d)] = 0
+		}
+		return c
+	}
+
+	leaves := leafTopics(plan.Topics)
+	byTopic := make(map[string]*topicCoverage, len(leaves))
+	for _, t := range leaves {
+		byTopic[t.ID] = newCoverage(t.ID, t.Name)
+	}
+	total := newCoverage("", "")
+	for _, q := range questions {
+		c := byTopic[q.TopicID]
+		if c == nil {
+			// Frage an einem Oberthema (z.B. vor dem Aufteilen): zählt nur insgesamt
+			c = newCoverage(q.TopicID, "")
+		}
+		difficulty := strconv.Itoa(min(max(q.Difficulty, 1), 5))
+		for _, counts := range []*topicCoverage{c, total} {
+			counts.Questions++
+			counts.ByDifficulty[difficulty]++
+			counts.ByType[q.Type]++
+			if q.ExamRelevant {
+				counts.Exam++
+			}
+		}
+	}
+
+	topics := make([]topicCoverage, 0, len(leaves))
+	uncovered := []map[string]string{}
+	for _, t := range leaves {
+		c := byTopic[t.ID]
+		if c.Questions == 0 {
+			uncovered = append(uncovered, map[string]string{"topic_id": t.ID, "topic_name": t.Name})
+		} else {
+			for d := 1; d <= 5; d++ {
+				if c.ByDifficulty[strconv.Itoa(d)] == 0 {
+					c.MissingDifficulties = append(c.MissingDifficulties, d)
+				}
+			}
+		}
+		topics = append(topics, *c)
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"plan_id":         plan.ID,
+		"plan_name":       plan.Name,
+		"total_questions": total.Questions,
+		"by_difficulty":   total.ByDifficulty,
+		"by_type":         total.ByType,
+		"exam_questions":  total.Exam,
+		"topics":          topics,
+		"uncovered":       uncovered,
+	}, http.StatusOK)
+}
@@ -0,0 +1,285 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"lernplattform/internal/models"
+)
+
+// Zeitformat in CSV-Dateien (erkennt Excel als Datum mit Uhrzeit)
+const csvTimeLayout = "2006-01-02 15:04"
+
+// csvTopic ist ein Thema mit Lernplan und Oberthema für die CSV-Spalten
+type csvTopic struct {
+	topic  models.Topic
+	plan   string
+	parent string
+}
+
+// csvTable ist der Inhalt einer CSV-Datei
+type csvTable struct {
+	header []string
+	rows   [][]string
+}
+
+// ExportCSV liefert Lernsitzungen, Fragen oder den Themenfortschritt als CSV für eigene Auswertungen.
+// Query: what=sessions|questions|progress, optional plan_id; sep=comma für Komma statt Semikolon.
+// Standard ist das Format des deutschen Excel: Semikolon, Dezimalkomma, UTF-8 mit BOM.
+func (h *Handler) ExportCSV(w http.ResponseWriter, r *http.Request) {
+	what := r.URL.Query().Get("what")
+	planID := r.URL.Query().Get("plan_id")
+	comma := r.URL.Query().Get("sep") == "comma"
+
+	ordered, err := h.csvTopics(planID)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden der Themen", http.StatusInternalServerError)
+		return
+	}
+
+	topics := make(map[string]csvTopic)
+	for _, t := range ordered {
+		topics[t.topic.ID] = t
+	}
+
+	var table *csvTable
+	switch what {
+	case "sessions":
+		table, err = h.sessionsCSV(planID, topics, comma)
+	case "questions":
+		table, err = h.questionsCSV(planID, topics, comma)
+	case "progress":
+		table, err = h.progressCSV(ordered, comma)
+	default:
+		errorResponse(w, "Unbekannter Export (what=sessions, questions oder progress)", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("%s_%s.csv", what, time.Now().Format(dateLayout))))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("\ufeff")) // BOM, damit Excel UTF-8 erkennt
+
+	writer := csv.NewWriter(w)
+	if !comma {
+		writer.Comma = ';'
+	}
+	writer.Write(table.header)
+	for _, row := range table.rows {
+		for i := range row {
+			row[i] = csvCell(row[i])
+		}
+	}
+	writer.WriteAll(table.rows)
+}
+
+// csvCell entschärft Texte, die Excel sonst als Formel ausführen würde (z.B. "=HYPERLINK(…)" in
+// einer Frage), mit einem vorangestellten Apostroph; Zahlen wie "-1,5" bleiben unverändert
+func csvCell(value string) string {
+	if value == "" || !strings.ContainsRune("=+-@", rune(value[0])) {
+		return value
+	}
+	if _, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64); err == nil {
+		return value
+	}
+	return "'" + value
+}
+
+// csvTopics sammelt alle Themen (samt Unterthemen) eines oder aller Lernpläne in Plan-Reihenfolge
+func (h *Handler) csvTopics(planID string) ([]csvTopic, error) {
+	var plans []models.StudyPlan
+	if planID != "" {
+		plan, err := h.store.GetStudyPlan(planID)
+		if err != nil {
+			return nil, err
+		}
+		plans = []models.StudyPlan{*plan}
+	} else {
+		all, err := h.store.GetAllStudyPlans()
+		if err != nil {
+			return nil, err
+		}
+		plans = all
+	}
+
+	var topics []csvTopic
+	var walk func(list []models.Topic, plan, parent string)
+	walk = func(list []models.Topic, plan, parent string) {
+		for _, t := range list {
+			topics = append(topics, csvTopic{topic: t, plan: plan, parent: parent})
+			walk(t.Subtopics, plan, t.Name)
+		}
+	}
+	for _, plan := range plans {
+		list, err := h.store.GetTopicsByPlan(plan.ID)
+		if err != nil {
+			return nil, err
+		}
+		walk(list, plan.Name, "")
+	}
+	return topics, nil
+}
+
+func (h *Handler) sessionsCSV(planID string, topics map[string]csvTopic, comma bool) (*csvTable, error) {
+	var sessions []models.StudySession
+	var err error
+	if planID != "" {
+		sessions, err = h.store.GetSessionsByPlan(planID)
+	} else {
+		sessions, err = h.store.GetAllSessions()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	table := &csvTable{header: []string{"Sitzung", "Lernplan", "Thema", "Beginn", "Ende", "Dauer (Min)", "Fragen", "Richtig", "Trefferquote (%)", "Automatisch beendet"}}
+	for _, s := range sessions {
+		t := topics[s.TopicID]
+		ended := ""
+		if s.EndedAt != nil {
+			ended = s.EndedAt.Local().Format(csvTimeLayout)
+		}
+		table.rows = append(table.rows, []string{
+			s.ID, t.plan, t.topic.Name,
+			s.StartedAt.Local().Format(csvTimeLayout), ended,
+			strconv.Itoa(s.Duration), strconv.Itoa(s.QuestionsAnswered), strconv.Itoa(s.CorrectAnswers),
+			csvNumber(percent(s.CorrectAnswers, s.QuestionsAnswered), comma),
+			csvBool(s.AutoClosed),
+		})
+	}
+	return table, nil
+}
+
+func (h *Handler) questionsCSV(planID string, topics map[string]csvTopic, comma bool) (*csvTable, error) {
+	attempts, err := h.store.GetAllAttempts()
+	if err != nil {
+		return nil, err
+	}
+	byQuestion := make(map[string][]models.QuestionAttempt)
+	for _, a := range attempts {
+		byQuestion[a.QuestionID] = append(byQuestion[a.QuestionID], a)
+	}
+
+	var planIDs []string
+	if planID != "" {
+		planIDs = []string{planID}
+	} else {
+		plans, err := h.store.GetAllStudyPlans()
+		if err != nil {
+			return nil, err
+		}
+		for _, plan := range plans {
+			planIDs = append(planIDs, plan.ID)
+		}
+	}
+
+	table := &csvTable{header: []string{"Frage-ID", "Lernplan", "Thema", "Frage", "Typ", "Schwierigkeit", "Bloom-Stufe",
+		"Versuche", "Richtig", "Trefferquote (%)", "Hinweise genutzt", "Ø Antwortzeit (s)", "Letzter Versuch"}}
+	for _, id := range planIDs {
+		questions, err := h.store.GetQuestionsByPlan(id)
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range questions {
+			t := topics[q.TopicID]
+			var correct, hints, timed, seconds int
+			var last time.Time
+			for _, a := range byQuestion[q.ID] {
+				if a.IsCorrect {
+					correct++
+				}
+				hints += a.HintsUsed
+				if a.AnswerSeconds != nil {
+					timed++
+					seconds += *a.AnswerSeconds
+				}
+				if a.CreatedAt.After(last) {
+					last = a.CreatedAt
+				}
+			}
+			total := len(byQuestion[q.ID])
+			avgSeconds, lastAttempt := "", ""
+			if timed > 0 {
+				avgSeconds = csvNumber(float64(seconds)/float64(timed), comma)
+			}
+			if !last.IsZero() {
+				lastAttempt = last.Local().Format(csvTimeLayout)
+			}
+			table.rows = append(table.rows, []string{
+				q.ID, t.plan, t.topic.Name, q.Question, q.Type, strconv.Itoa(q.Difficulty), q.CognitiveLevel,
+				strconv.Itoa(total), strconv.Itoa(correct), csvNumber(percent(correct, total), comma),
+				strconv.Itoa(hints), avgSeconds, lastAttempt,
+			})
+		}
+	}
+	return table, nil
+}
+
+func (h *Handler) progressCSV(topics []csvTopic, comma bool) (*csvTable, error) {
+	sessions, err := h.store.GetAllSessions()
+	if err != nil {
+		return nil, err
+	}
+	minutes := make(map[string]int)
+	for _, s := range sessions {
+		minutes[s.TopicID] += s.Duration
+	}
+	attempts, err := h.store.GetAllAttempts()
+	if err != nil {
+		return nil, err
+	}
+	attempted := make(map[string]bool)
+	for _, a := range attempts {
+		attempted[a.QuestionID] = true
+	}
+
+	table := &csvTable{header: []string{"Lernplan", "Thema", "Oberthema", "Status", "Fortschritt (%)", "Schwierigkeit",
+		"Geplant (Min)", "Gelernt (Min)", "Prüfungsgewicht", "Fragen", "Beantwortet", "Richtig", "Trefferquote (%)"}}
+	for _, t := range topics {
+		id := t.topic.ID
+		questions, err := h.store.GetQuestionsByTopic(id)
+		if err != nil {
+			return nil, err
+		}
+		var answered, correct int
+		for _, q := range questions {
+			if attempted[q.ID] || q.AnsweredAt != nil {
+				answered++
+			}
+			if q.IsCorrect != nil && *q.IsCorrect {
+				correct++
+			}
+		}
+		table.rows = append(table.rows, []string{
+			t.plan, t.topic.Name, t.parent, t.topic.Status, csvNumber(t.topic.Progress, comma),
+			strconv.Itoa(t.topic.Difficulty), strconv.Itoa(t.topic.EstMinutes), strconv.Itoa(minutes[id]),
+			csvNumber(t.topic.ExamWeight, comma), strconv.Itoa(len(questions)), strconv.Itoa(answered),
+			strconv.Itoa(correct), csvNumber(percent(correct, answered), comma),
+		})
+	}
+	return table, nil
+}
+
+// csvNumber formatiert eine Zahl mit einer Nachkommastelle, bei Semikolon-CSV mit Dezimalkomma
+func csvNumber(value float64, comma bool) string {
+	s := strconv.FormatFloat(value, 'f', 1, 64)
+	if !comma {
+		s = strings.Replace(s, ".", ",", 1)
+	}
+	return s
+}
+
+func csvBool(value bool) string {
+	if value {
+		return "ja"
+	}
+	return "nein"
+}
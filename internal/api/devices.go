@@ -0,0 +1,212 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/models"
+	"lernplattform/internal/qr"
+	"lernplattform/internal/storage"
+)
+
+// Bereiche der Gerätetokens
+const (
+	scopeRead  = "read"  // alles lesen (GET)
+	scopeStudy = "study" // Fragen beantworten, Lernsitzungen und eigene Quizze
+	scopeChat  = "chat"  // Chat mit dem Tutor
+	scopeFull  = "full"  // alles außer Geräteverwaltung und Konto
+)
+
+var deviceScopes = map[string]bool{scopeRead: true, scopeStudy: true, scopeChat: true, scopeFull: true}
+
+// studyRoutes sind die ändernden Endpoints des Bereichs study, Schlüssel "METHODE Pfadvorlage"
+var studyRoutes = map[string]bool{
+	"POST /api/v1/questions/{id}/answer":                  true,
+	"POST /api/v1/questions/{id}/answer/stream":           true,
+	"POST /api/v1/questions/{id}/start":                   true,
+	"POST /api/v1/answers/batch":                          true,
+	"POST /api/v1/sessions":                               true,
+	"POST /api/v1/sessions/{id}/end":                      true,
+	"POST /api/v1/quizzes/{id}/start":                     true,
+	"POST /api/v1/quizzes/{id}/results/{resultId}/finish": true,
+}
+
+// Diese Endpoints bleiben den Zugangstokens aus der Konfiguration vorbehalten,
+// damit ein verlorenes Gerät keine weiteren Geräte koppeln oder die Daten löschen kann
+var ownerOnlyPrefixes = []string{"/api/v1/auth/devices", "/api/v1/account/"}
+
+// Zuletzt-benutzt wird höchstens so oft gespeichert, nicht bei jeder Anfrage
+const deviceTouchInterval = time.Minute
+
+type deviceKey struct{}
+
+// hashDeviceToken: gespeichert wird nur der SHA-256 des Tokens
+func hashDeviceToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// findDevice sucht das Gerät zu einem Gerätetoken und merkt sich die letzte Nutzung
+func findDevice(store storage.Storage, token string) *models.DeviceToken {
+	if !strings.HasPrefix(token, "dev_") {
+		return nil
+	}
+	device, err := store.GetDeviceTokenByHash(hashDeviceToken(token))
+	if err != nil {
+		return nil
+	}
+	now := time.Now()
+	if device.LastUsedAt == nil || now.Sub(*device.LastUsedAt) > deviceTouchInterval {
+		store.TouchDeviceToken(device.ID, now)
+		device.LastUsedAt = &now
+	}
+	return device
+}
+
+// deviceAllowed prüft eine Anfrage gegen die Bereiche des Gerätetokens
+func deviceAllowed(device *models.DeviceToken, r *http.Request) bool {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+	tpl, err := route.GetPathTemplate()
+	if err != nil {
+		return false
+	}
+	for _, prefix := range ownerOnlyPrefixes {
+		if strings.HasPrefix(tpl, prefix) {
+			return false
+		}
+	}
+
+	read := r.Method == http.MethodGet || r.Method == http.MethodHead
+	for _, scope := range device.Scopes {
+		switch {
+		case scope == scopeFull,
+			scope == scopeRead && read,
+			scope == scopeStudy && studyRoutes[r.Method+" "+tpl],
+			scope == scopeChat && (tpl == "/api/v1/chat" || strings.HasPrefix(tpl, "/api/v1/chat/")):
+			return true
+		}
+	}
+	return false
+}
+
+// serverURL ist die Adresse, unter der die App den Server erreicht (wie vom Browser aufgerufen)
+func serverURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// === Geräte Endpoints ===
+
+func (h *Handler) GetDevices(w http.ResponseWriter, r *http.Request) {
+	devices, err := h.store.GetDeviceTokens()
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	if devices == nil {
+		devices = []models.DeviceToken{}
+	}
+
+	jsonResponse(w, devices, http.StatusOK)
+}
+
+// PairDevice legt ein Gerätetoken an und liefert es einmalig zusammen mit einem QR-Code
+// (Serveradresse und Token), den die Begleit-App zum Koppeln scannt
+func (h *Handler) PairDevice(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name      string   `json:"name"`
+		Scopes    []string `json:"scopes"`
+		ServerURL string   `json:"server_url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		errorResponse(w, "Gerätename fehlt", http.StatusBadRequest)
+		return
+	}
+	if len(req.Scopes) == 0 {
+		req.Scopes = []string{scopeRead, scopeStudy}
+	}
+	for _, scope := range req.Scopes {
+		if !deviceScopes[scope] {
+			errorResponse(w, fmt.Sprintf("Unbekannter Bereich: %s (read, study, chat, full)", scope), http.StatusBadRequest)
+			return
+		}
+	}
+	server := strings.TrimRight(strings.TrimSpace(req.ServerURL), "/")
+	if server == "" {
+		server = serverURL(r)
+	} else if u, err := url.Parse(server); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errorResponse(w, "Ungültige server_url", http.StatusBadRequest)
+		return
+	}
+
+	buf := make([]byte, 32)
+	rand.Read(buf)
+	token := "dev_" + base64.RawURLEncoding.EncodeToString(buf)
+
+	device := &models.DeviceToken{
+		ID:        fmt.Sprintf("device_%d", time.Now().UnixNano()),
+		Name:      req.Name,
+		TokenHash: hashDeviceToken(token),
+		Scopes:    req.Scopes,
+		CreatedAt: time.Now(),
+	}
+
+	pairing := "lernplattform://pair?" + url.Values{"server": {server}, "token": {token}}.Encode()
+	code, err := qr.Encode(pairing)
+	if err != nil {
+		errorResponse(w, "Serveradresse zu lang für den QR-Code", http.StatusBadRequest)
+		return
+	}
+	image, err := code.PNG(6)
+	if err != nil {
+		errorResponse(w, "Fehler beim Erstellen des QR-Codes", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.store.SaveDeviceToken(device); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("📱 Gerät '%s' gekoppelt (%s)", device.Name, strings.Join(device.Scopes, ", "))
+
+	jsonResponse(w, map[string]interface{}{
+		"device":      device,
+		"token":       token,
+		"server_url":  server,
+		"pairing_uri": pairing,
+		"qr_code":     "data:image/png;base64," + base64.StdEncoding.EncodeToString(image),
+	}, http.StatusCreated)
+}
+
+// DeleteDevice widerruft ein Gerätetoken (z.B. bei verlorenem Handy)
+func (h *Handler) DeleteDevice(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.store.DeleteDeviceToken(vars["id"]); err != nil {
+		errorResponse(w, "Fehler beim Löschen", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]string{"message": "Gerät entkoppelt"}, http.StatusOK)
+}
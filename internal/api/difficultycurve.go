@@ -0,0 +1,182 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/models"
+)
+
+// Ab dieser Schwierigkeit gilt ein Thema als schwer
+const hardTopicDifficulty = 4
+
+// So viele Lerntage vor der Prüfung werden auf gehäufte schwere Themen geprüft
+const lateStudyDays = 3
+
+// Ab diesem Anteil schwerer Lernzeit in den letzten Lerntagen wird gewarnt
+const lateHardWarnShare = 0.5
+
+// GetDifficultyCurve zeigt, wie sich geschätzte Lernzeit und Schwierigkeit der offenen Themen auf die
+// Tage bis zur Prüfung verteilen (gleiche Tagesplanung wie der Semesterplaner, nur für diesen Plan).
+// Warnt, wenn schwere Themen gehäuft in den letzten Tagen liegen. Query: start (YYYY-MM-DD, Standard heute)
+func (h *Handler) GetDifficultyCurve(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if value := r.URL.Query().Get("start"); value != "" {
+		parsed, err := time.ParseInLocation(dateLayout, value, time.Local)
+		if err != nil {
+			errorResponse(w, "Ungültiges Startdatum (Format: YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		start = parsed
+	}
+
+	subject, err := h.loadSemesterSubject(vars["id"])
+	if err != nil {
+		errorResponse(w, "Lernplan nicht gefunden", http.StatusNotFound)
+		return
+	}
+	if !subject.exam.After(start) {
+		errorResponse(w, "Die Prüfung liegt nicht nach dem Startdatum", http.StatusBadRequest)
+		return
+	}
+
+	difficulty := make(map[string]int)
+	for _, t := range leafTopics(subject.plan.Topics) {
+		difficulty[t.ID] = topicDifficulty(t)
+	}
+
+	days, _ := planSemester([]*semesterSubject{subject}, h.config.Availability, start)
+	curve := difficultyCurve(days, difficulty)
+	curve.PlanID = subject.plan.ID
+	curve.Start = start.Format(dateLayout)
+	curve.ExamDate = subject.plan.ExamDate
+	curve.ShortfallMinutes = subject.remaining
+
+	if curve.LateHardShare >= lateHardWarnShare {
+		curve.Warnings = append(curve.Warnings, fmt.Sprintf("%.0f %% der Lernzeit für schwere Themen liegen in den letzten %d Lerntagen (%s) – schwere Themen früher einplanen, damit Zeit zum Wiederholen bleibt",
+			curve.LateHardShare*100, lateStudyDays, strings.Join(curve.LateHardTopics, ", ")))
+	}
+	if curve.SecondHalfDifficulty-curve.FirstHalfDifficulty >= 1 {
+		curve.Warnings = append(curve.Warnings, fmt.Sprintf("Die Schwierigkeit steigt zum Ende stark an (erste Hälfte %.1f, zweite Hälfte %.1f)",
+			curve.FirstHalfDifficulty, curve.SecondHalfDifficulty))
+	}
+	if subject.remaining > 0 {
+		curve.Warnings = append(curve.Warnings, fmt.Sprintf("Bis zur Prüfung fehlen %d Minuten Lernzeit", subject.remaining))
+	}
+
+	jsonResponse(w, curve, http.StatusOK)
+}
+
+// topicDifficulty ist die Schwierigkeit eines Themas (1-5, ohne Einstufung mittel)
+func topicDifficulty(topic models.Topic) int {
+	if topic.Difficulty < 1 {
+		return 3
+	}
+	return min(topic.Difficulty, 5)
+}
+
+// difficultyCurve fasst die geplanten Tage zur Kurve zusammen (ohne Plan- und Warnungsangaben)
+func difficultyCurve(days []models.SemesterDay, difficulty map[string]int) *models.DifficultyCurve {
+	curve := &models.DifficultyCurve{
+		Days:           make([]models.DifficultyDay, 0, len(days)),
+		LateHardTopics: []string{},
+		Warnings:       []string{},
+	}
+
+	totalMinutes := 0
+	for _, day := range days {
+		entry := models.DifficultyDay{
+			Date:             day.Date,
+			AvailableMinutes: day.AvailableMinutes,
+			PlannedMinutes:   day.PlannedMinutes,
+			Topics:           []models.DifficultyBlock{},
+		}
+		weighted := 0
+		for _, block := range day.Blocks {
+			d := difficulty[block.TopicID]
+			entry.Topics = append(entry.Topics, models.DifficultyBlock{
+				TopicID:    block.TopicID,
+				TopicName:  block.TopicName,
+				Minutes:    block.Minutes,
+				Difficulty: d,
+			})
+			weighted += d * block.Minutes
+			entry.MaxDifficulty = max(entry.MaxDifficulty, d)
+			if d >= hardTopicDifficulty {
+				entry.HardMinutes += block.Minutes
+			}
+		}
+		if entry.PlannedMinutes > 0 {
+			entry.AvgDifficulty = roundTenth(float64(weighted) / float64(entry.PlannedMinutes))
+		}
+		totalMinutes += entry.PlannedMinutes
+		curve.Days = append(curve.Days, entry)
+	}
+
+	// Schwierigkeit der ersten und zweiten Hälfte der geplanten Lernzeit (ein Block kann beide Hälften berühren)
+	var firstWeighted, firstMinutes, secondWeighted, secondMinutes, elapsed int
+	for _, day := range curve.Days {
+		for _, block := range day.Topics {
+			inFirst := min(max(totalMinutes/2-elapsed, 0), block.Minutes)
+			firstWeighted += block.Difficulty * inFirst
+			firstMinutes += inFirst
+			secondWeighted += block.Difficulty * (block.Minutes - inFirst)
+			secondMinutes += block.Minutes - inFirst
+			elapsed += block.Minutes
+		}
+	}
+	if firstMinutes > 0 {
+		curve.FirstHalfDifficulty = roundTenth(float64(firstWeighted) / float64(firstMinutes))
+	}
+	if secondMinutes > 0 {
+		curve.SecondHalfDifficulty = roundTenth(float64(secondWeighted) / float64(secondMinutes))
+	}
+
+	// Letzte Lerntage: die letzten Tage mit verfügbarer Zeit vor der Prüfung; bei so wenigen
+	// Lerntagen insgesamt liegt ohnehin alles darin
+	hardTotal, hardLate, studyDays := 0, 0, 0
+	for _, day := range curve.Days {
+		hardTotal += day.HardMinutes
+		if day.AvailableMinutes > 0 {
+			studyDays++
+		}
+	}
+	if studyDays <= lateStudyDays {
+		return curve
+	}
+	// Beginn der letzten Lerntage suchen, dann vorwärts sammeln, damit die Themen in Planreihenfolge
+	// (und innerhalb eines Tages in Blockreihenfolge) erscheinen
+	start, late := len(curve.Days), 0
+	for start > 0 && late < lateStudyDays {
+		start--
+		if curve.Days[start].AvailableMinutes > 0 {
+			late++
+		}
+	}
+	seen := make(map[string]bool)
+	for _, day := range curve.Days[start:] {
+		hardLate += day.HardMinutes
+		for _, block := range day.Topics {
+			if block.Difficulty >= hardTopicDifficulty && !seen[block.TopicID] {
+				seen[block.TopicID] = true
+				curve.LateHardTopics = append(curve.LateHardTopics, block.TopicName)
+			}
+		}
+	}
+	if hardTotal > 0 {
+		curve.LateHardShare = math.Round(float64(hardLate)/float64(hardTotal)*100) / 100
+	}
+	return curve
+}
+
+// roundTenth rundet auf eine Nachkommastelle
+func roundTenth(value float64) float64 {
+	return math.Round(value*10) / 10
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"lernplattform/internal/models"
+	"lernplattform/internal/pdf"
+)
+
+// Höchstzahl an Dokumenten je Sammelaktion
+const maxBatchDocuments = 500
+
+// batchResult ist das Ergebnis einer Sammelaktion für ein Dokument
+type batchResult struct {
+	DocumentID string   `json:"document_id"`
+	Status     string   `json:"status"` // ok, not_found oder error
+	Name       string   `json:"name,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// BatchDocuments führt eine Aktion für mehrere Dokumente aus.
+// Body: ids, action (delete, tag, untag, reparse), tags (für tag/untag).
+// Fehler einzelner Dokumente brechen nicht ab; die Antwort enthält einen Bericht je Dokument.
+func (h *Handler) BatchDocuments(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs    []string `json:"ids"`
+		Action string   `json:"action"`
+		Tags   []string `json:"tags"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) == 0 {
+		errorResponse(w, "Keine Dokumente angegeben", http.StatusBadRequest)
+		return
+	}
+	if len(req.IDs) > maxBatchDocuments {
+		errorResponse(w, fmt.Sprintf("Höchstens %d Dokumente auf einmal", maxBatchDocuments), http.StatusBadRequest)
+		return
+	}
+
+	var tags []string
+	for _, tag := range req.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	var apply func(doc *models.Document) error
+	switch req.Action {
+	case "delete":
+		apply = func(doc *models.Document) error { return h.store.DeleteDocument(doc.ID) }
+	case "tag", "untag":
+		if len(tags) == 0 {
+			errorResponse(w, "Bitte Schlagworte angeben", http.StatusBadRequest)
+			return
+		}
+		add := req.Action == "tag"
+		apply = func(doc *models.Document) error {
+			doc.Tags = mergeTags(doc.Tags, tags, add)
+			return h.store.UpdateDocumentMetadata(doc)
+		}
+	case "reparse":
+		apply = h.reparseDocument
+	default:
+		errorResponse(w, "Unbekannte Aktion (delete, tag, untag oder reparse)", http.StatusBadRequest)
+		return
+	}
+
+	results := []batchResult{}
+	seen := make(map[string]bool)
+	ok, failed := 0, 0
+	for _, id := range req.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		result := batchResult{DocumentID: id, Status: "ok"}
+		doc, err := h.store.GetDocument(id)
+		if err != nil {
+			result.Status, result.Error = "not_found", "Dokument nicht gefunden"
+		} else if err := apply(doc); err != nil {
+			result.Status, result.Error = "error", err.Error()
+		} else {
+			result.Name = documentTitle(doc)
+			if req.Action != "delete" {
+				result.Tags = doc.Tags
+			}
+		}
+
+		if result.Status == "ok" {
+			ok++
+		} else {
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	log.Printf("📚 Sammelaktion '%s': %d Dokumente, %d Fehler", req.Action, ok, failed)
+	jsonResponse(w, map[string]interface{}{
+		"action":    req.Action,
+		"succeeded": ok,
+		"failed":    failed,
+		"documents": results,
+	}, http.StatusOK)
+}
+
+// mergeTags fügt Schlagworte hinzu bzw. entfernt sie (Groß-/Kleinschreibung egal)
+func mergeTags(current, tags []string, add bool) []string {
+	listed := make(map[string]bool)
+	for _, tag := range tags {
+		listed[strings.ToLower(tag)] = true
+	}
+
+	var merged []string
+	present := make(map[string]bool)
+	for _, tag := range current {
+		if !add && listed[strings.ToLower(tag)] {
+			continue
+		}
+		present[strings.ToLower(tag)] = true
+		merged = append(merged, tag)
+	}
+	if add {
+		for _, tag := range tags {
+			if !present[strings.ToLower(tag)] {
+				present[strings.ToLower(tag)] = true
+				merged = append(merged, tag)
+			}
+		}
+	}
+	return merged
+}
+
+// reparseDocument liest ein Dokument neu ein: aus der Datei, falls sie noch vorhanden ist,
+// sonst aus dem gespeicherten Originaltext (Kopf-/Fußzeilen, Statistik, Inhaltsverzeichnis neu).
+// ID, Name, Schlagworte und selbst gesetzte Angaben bleiben erhalten, die Sprache wird neu erkannt.
+func (h *Handler) reparseDocument(doc *models.Document) error {
+	var parsed *models.Document
+	if _, err := os.Stat(doc.Path); doc.Path != "" && err == nil {
+		parsed, err = h.parseDocumentFile(doc.Path)
+		if err != nil {
+			return err
+		}
+	} else {
+		raw, err := h.store.GetDocumentRawContent(doc.ID)
+		if err != nil {
+			return err
+		}
+		parsed = &models.Document{RawContent: raw, PageCount: doc.PageCount, ProcessedAt: time.Now()}
+		parsed.Content = pdf.StripBoilerplate(raw).Content
+		pdf.ApplyTextStats(parsed)
+	}
+
+	parsed.ID, parsed.Name, parsed.Path = doc.ID, doc.Name, doc.Path
+	parsed.UploadedAt, parsed.SourceURL = doc.UploadedAt, doc.SourceURL
+	parsed.Tags, parsed.Title, parsed.Author, parsed.Semester = doc.Tags, doc.Title, doc.Author, doc.Semester
+	parsed.Links = doc.Links // Wiki-Links lassen sich nur beim Import aller Notizen auflösen
+	if err := h.storeDocument(parsed); err != nil {
+		return err
+	}
+	*doc = *parsed
+	return nil
+}
+
+// parseDocumentFile liest eine Datei aus dem Dokumentenordner je nach Endung ein
+func (h *Handler) parseDocumentFile(path string) (*models.Document, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".pdf":
+		return h.pdfParser.ParseFile(path)
+	case ".md":
+		return h.parseNote(path)
+	case ".docx", ".html", ".htm":
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if ext == ".docx" {
+			return h.pdfParser.ParseDOCX(f, filepath.Base(path))
+		}
+		return h.pdfParser.ParseHTML(f, filepath.Base(path))
+	}
+	return nil, fmt.Errorf("dateityp %s wird nicht unterstützt", ext)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/models"
+	"lernplattform/internal/pdf"
+)
+
+// saveDocumentStats berechnet die Textstatistik beim Einlesen und speichert sie
+func (h *Handler) saveDocumentStats(doc *models.Document) *models.DocumentStats {
+	stats := pdf.ComputeStats(doc)
+	if err := h.store.SaveDocumentStats(stats); err != nil {
+		log.Printf("⚠️ Statistik für '%s' konnte nicht gespeichert werden: %v", doc.Name, err)
+	}
+	return stats
+}
+
+// GetDocumentStats liefert Umfang, Lesezeit, Sprache, Lesbarkeit und Abschnitte eines Dokuments.
+// Für vorher eingelesene Dokumente wird die Statistik beim ersten Aufruf berechnet.
+func (h *Handler) GetDocumentStats(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if stats, err := h.store.GetDocumentStats(id); err == nil {
+		jsonResponse(w, stats, http.StatusOK)
+		return
+	}
+
+	doc, err := h.store.GetDocument(id)
+	if err != nil {
+		errorResponse(w, "Dokument nicht gefunden", http.StatusNotFound)
+		return
+	}
+	jsonResponse(w, h.saveDocumentStats(doc), http.StatusOK)
+}
+
+// saveDocumentTOC speichert das Inhaltsverzeichnis beim Einlesen
+func (h *Handler) saveDocumentTOC(doc *models.Document) *models.DocumentTOC {
+	toc := pdf.BuildTOC(doc)
+	if err := h.store.SaveDocumentTOC(toc); err != nil {
+		log.Printf("⚠️ Inhaltsverzeichnis für '%s' konnte nicht gespeichert werden: %v", doc.Name, err)
+	}
+	return toc
+}
+
+// GetDocumentTOC liefert das Inhaltsverzeichnis eines Dokuments. Für vorher eingelesene
+// Dokumente wird es beim ersten Aufruf aus der PDF-Datei (falls noch vorhanden) oder dem Text erstellt.
+func (h *Handler) GetDocumentTOC(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if toc, err := h.store.GetDocumentTOC(id); err == nil {
+		jsonResponse(w, toc, http.StatusOK)
+		return
+	}
+
+	doc, err := h.store.GetDocument(id)
+	if err != nil {
+		errorResponse(w, "Dokument nicht gefunden", http.StatusNotFound)
+		return
+	}
+	if doc.Path != "" {
+		if headings, err := pdf.ReadHeadings(doc.Path); err == nil {
+			doc.Headings = headings
+		}
+	}
+	jsonResponse(w, h.saveDocumentTOC(doc), http.StatusOK)
+}
+
+// GetDocumentRaw liefert den Text eines Dokuments wie aus der PDF gelesen, dazu die
+// beim Einlesen entfernten Kopf-/Fußzeilen und wiederholten Seiten
+func (h *Handler) GetDocumentRaw(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	raw, err := h.store.GetDocumentRawContent(id)
+	if err != nil {
+		errorResponse(w, "Dokument nicht gefunden", http.StatusNotFound)
+		return
+	}
+	removed := pdf.StripBoilerplate(raw)
+	jsonResponse(w, map[string]interface{}{
+		"document_id":     id,
+		"content":         raw,
+		"boilerplate":     removed.Lines,
+		"duplicate_pages": removed.DuplicatePages,
+	}, http.StatusOK)
+}
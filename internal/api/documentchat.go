@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/llm"
+	"lernplattform/internal/models"
+	"lernplattform/internal/pdf"
+)
+
+// Seitenangaben in der Antwort: "(S. 12)", "S. 12–13", "Seite 4"
+var pageCitationPattern = regexp.MustCompile(`(?:S\.|Seiten?)\s*(\d+)(?:\s*[-–]\s*(\d+))?`)
+
+// documentCitation ist ein Abschnitt, aus dem die Antwort stammt
+type documentCitation struct {
+	Page    int    `json:"page"`
+	Section string `json:"section,omitempty"`
+}
+
+// DocumentChat beantwortet Fragen ausschließlich aus einem Dokument, unabhängig von Themen und
+// Lernplan. Nur die zur Frage passenden Abschnitte des Dokuments werden mitgegeben; die
+// Antwort nennt die Seiten. Body: message, session_id (optional, für Rückfragen).
+func (h *Handler) DocumentChat(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req struct {
+		Message   string `json:"message"`
+		SessionID string `json:"session_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Message == "" {
+		errorResponse(w, "Bitte eine Nachricht angeben", http.StatusBadRequest)
+		return
+	}
+
+	doc, err := h.store.GetDocument(id)
+	if err != nil {
+		errorResponse(w, "Dokument nicht gefunden", http.StatusNotFound)
+		return
+	}
+	if !doc.HasText {
+		errorResponse(w, "Das Dokument enthält keinen Text (eingescannt?)", http.StatusUnprocessableEntity)
+		return
+	}
+
+	// Neue Sitzungen werden wie im Chat beim ersten Kontakt angelegt (für Pins und die Sitzungsliste)
+	if req.SessionID != "" {
+		if _, err := h.store.GetChatSession(req.SessionID); err != nil {
+			h.store.SaveChatSession(&models.ChatSession{
+				ID:        req.SessionID,
+				Mode:      chatModeOrDefault(""),
+				CreatedAt: time.Now(),
+			})
+		}
+	}
+
+	// Bisheriger Verlauf: Rückfragen wie "und was heißt das?" brauchen die letzte Frage für die Suche
+	var messages []llm.ChatMessage
+	query := req.Message
+	if req.SessionID != "" {
+		history, _ := h.store.GetChatHistory(req.SessionID)
+		for _, msg := range history {
+			messages = append(messages, llm.ChatMessage{Role: msg.Role, Content: msg.Content})
+			if msg.Role == "user" {
+				query = msg.Content + " " + req.Message
+			}
+		}
+	}
+	messages = append(messages, llm.ChatMessage{Role: "user", Content: req.Message})
+
+	ctx, end := h.beginGeneration(w, r, r.Context())
+	defer end()
+	resp, chunks, err := h.documentChatReply(ctx, doc, req.SessionID, query, messages)
+	if err != nil {
+		generationFailed(w, ctx, fmt.Sprintf("Chat-Fehler: %v", err))
+		return
+	}
+
+	answerID := ""
+	if req.SessionID != "" {
+		answerID = fmt.Sprintf("msg_%d", time.Now().UnixNano()+1)
+		h.store.SaveChatMessage(&models.ChatMessage{
+			ID:         fmt.Sprintf("msg_%d", time.Now().UnixNano()),
+			SessionID:  req.SessionID,
+			Role:       "user",
+			Content:    req.Message,
+			Timestamp:  time.Now(),
+			DocumentID: doc.ID,
+		})
+		h.store.SaveChatMessage(&models.ChatMessage{
+			ID:         answerID,
+			SessionID:  req.SessionID,
+			Role:       "assistant",
+			Content:    resp.Content,
+			Timestamp:  time.Now(),
+			DocumentID: doc.ID,
+		})
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"response":      resp.Content,
+		"model":         resp.Model,
+		"document_id":   doc.ID,
+		"document_name": documentTitle(doc),
+		"citations":     pageCitations(resp.Content, chunks, doc.PageCount),
+		"session_id":    req.SessionID,
+		"message_id":    answerID,
+	}, http.StatusOK)
+}
+
+// documentChatReply beantwortet die letzte Nachricht in messages aus den zu query passenden
+// Abschnitten des Dokuments; die Abschnitte werden für die Seitenangaben mit zurückgegeben
+func (h *Handler) documentChatReply(ctx context.Context, doc *models.Document, sessionID, query string, messages []llm.ChatMessage) (*llm.GenerateResponse, []pdf.Chunk, error) {
+	pinned := h.pinnedContext(sessionID)
+	budget := max(chatContextChars-len(pinned), chatContextChars/3)
+	chunks := pdf.RelevantChunks(pdf.ExtractChunks(doc.Content, h.config.ChunkTokens), query, budget)
+
+	resp, err := h.tutor.ChatWithDocument(ctx, messages, documentTitle(doc), pinned+pdf.FormatChunks(chunks))
+	return resp, chunks, err
+}
+
+// citedPages liest die Seitenangaben aus einer Antwort, aufsteigend und ohne Seiten außerhalb
+// des Dokuments (pageCount 0 = unbekannt)
+func citedPages(answer string, pageCount int) []int {
+	seen := make(map[int]bool)
+	var pages []int
+	for _, m := range pageCitationPattern.FindAllStringSubmatch(answer, -1) {
+		from, _ := strconv.Atoi(m[1])
+		to := from
+		if m[2] != "" {
+			to, _ = strconv.Atoi(m[2])
+		}
+		for page := from; page <= to && page-from < 20; page++ {
+			if page < 1 || (pageCount > 0 && page > pageCount) || seen[page] {
+				continue
+			}
+			seen[page] = true
+			pages = append(pages, page)
+		}
+	}
+	sort.Ints(pages)
+	return pages
+}
+
+// pageCitations ordnet die zitierten Seiten den mitgegebenen Abschnitten zu. Seiten vor dem
+// ersten Abschnitt sind erfunden und fallen heraus; das Kapitel stammt aus dem Abschnitt,
+// in dem die Seite liegt.
+func pageCitations(answer string, chunks []pdf.Chunk, pageCount int) []documentCitation {
+	citations := []documentCitation{}
+	for _, page := range citedPages(answer, pageCount) {
+		// Abschnitte beginnen auf ihrer Seite und können auf die nächsten reichen
+		var chunk *pdf.Chunk
+		for i := range chunks {
+			if chunks[i].Page > 0 && chunks[i].Page <= page && (chunk == nil || chunks[i].Page > chunk.Page) {
+				chunk = &chunks[i]
+			}
+		}
+		if chunk != nil {
+			citations = append(citations, documentCitation{Page: page, Section: chunk.Section})
+		}
+	}
+	return citations
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"lernplattform/internal/models"
+)
+
+// errorTypeLabels sind die Anzeigenamen der Fehlerarten
+var errorTypeLabels = map[string]string{
+	models.ErrorConceptConfusion: "Begriffe verwechselt",
+	models.ErrorMissingTerm:      "Fachbegriff fehlt",
+	models.ErrorCalculationSlip:  "Rechenfehler",
+	models.ErrorMisreadQuestion:  "Frage falsch gelesen",
+}
+
+// topicErrorTypes zählt die Fehlerarten falscher Antworten in einem Thema
+type topicErrorTypes struct {
+	TopicID      string         `json:"topic_id"`
+	TopicName    string         `json:"topic_name"`
+	Wrong        int            `json:"wrong"`      // falsche Antworten insgesamt
+	Classified   int            `json:"classified"` // davon mit Fehlerart
+	Counts       map[string]int `json:"counts"`     // je Fehlerart
+	Dominant     string         `json:"dominant"`   // häufigste Fehlerart ("" ohne Klassifizierung)
+	DominantName string         `json:"dominant_label,omitempty"`
+}
+
+// GetErrorTypes wertet aus, welche Fehlerarten je Thema am häufigsten vorkommen.
+// Multiple-Choice und leere Antworten werden ohne LLM bewertet und bleiben unklassifiziert.
+func (h *Handler) GetErrorTypes(w http.ResponseWriter, r *http.Request) {
+	planID, ok := h.queryPlanID(w, r)
+	if !ok {
+		return
+	}
+
+	plan, err := h.store.GetStudyPlan(planID)
+	if err != nil {
+		errorResponse(w, "Lernplan nicht gefunden", http.StatusNotFound)
+		return
+	}
+	attempts, err := h.store.GetAttemptsByPlan(planID)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+
+	byTopic := make(map[string]*topicErrorTypes)
+	total := &topicErrorTypes{Counts: make(map[string]int)}
+	for _, a := range attempts {
+		if a.IsCorrect {
+			continue
+		}
+		stats := byTopic[a.TopicID]
+		if stats == nil {
+			stats = &topicErrorTypes{TopicID: a.TopicID, Counts: make(map[string]int)}
+			byTopic[a.TopicID] = stats
+		}
+		for _, s := range []*topicErrorTypes{stats, total} {
+			s.Wrong++
+			if a.ErrorType != "" {
+				s.Classified++
+				s.Counts[a.ErrorType]++
+			}
+		}
+	}
+
+	topics := []topicErrorTypes{}
+	for _, t := range leafTopics(plan.Topics) {
+		stats := byTopic[t.ID]
+		if stats == nil {
+			continue
+		}
+		stats.TopicName = t.Name
+		stats.setDominant()
+		topics = append(topics, *stats)
+	}
+	sort.SliceStable(topics, func(i, j int) bool { return topics[i].Classified > topics[j].Classified })
+	total.setDominant()
+
+	jsonResponse(w, map[string]interface{}{
+		"plan_id": planID,
+		"labels":  errorTypeLabels,
+		"total":   total,
+		"topics":  topics,
+	}, http.StatusOK)
+}
+
+// setDominant bestimmt die häufigste Fehlerart (bei Gleichstand in der Reihenfolge von models.ErrorTypes)
+func (s *topicErrorTypes) setDominant() {
+	best := 0
+	for _, errorType := range models.ErrorTypes {
+		if s.Counts[errorType] > best {
+			best = s.Counts[errorType]
+			s.Dominant = errorType
+		}
+	}
+	s.DominantName = errorTypeLabels[s.Dominant]
+}
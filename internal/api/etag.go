@@ -0,0 +1,135 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// contentETag bildet ein (schwaches) ETag aus dem Inhalt; schwach, weil die Antwort
+// je nach Client komprimiert wird
+func contentETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `W/"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// etagMatches prüft If-None-Match gegen das ETag der Antwort
+func etagMatches(r *http.Request, etag string) bool {
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// etagRecorder puffert eine Antwort, damit das ETag vor dem Senden feststeht
+type etagRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *etagRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *etagRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// etagMiddleware versieht erfolgreiche GET-Antworten der API mit einem ETag und
+// beantwortet unveränderte Inhalte (If-None-Match) mit 304
+func etagMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || websocket.IsWebSocketUpgrade(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &etagRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		// Handler mit eigenem ETag (Änderungsstand) nicht überschreiben
+		if rec.status == http.StatusOK && w.Header().Get("ETag") == "" {
+			etag := contentETag(rec.body.Bytes())
+			w.Header().Set("ETag", etag)
+			if etagMatches(r, etag) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.WriteHeader(rec.status)
+		w.Write(rec.body.Bytes())
+	})
+}
+
+// staticETags merkt sich die ETags der Frontend-Dateien (neu berechnet, wenn sich die Datei ändert)
+type staticETags struct {
+	root    string
+	mu      sync.Mutex
+	entries map[string]staticETag
+}
+
+type staticETag struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+// lookup liefert das ETag der Datei zum URL-Pfad ("" wenn es keine Datei ist)
+func (s *staticETags) lookup(urlPath string) string {
+	name := path.Clean("/" + urlPath)
+	if strings.HasSuffix(urlPath, "/") {
+		name = path.Join(name, "index.html")
+	}
+	file := filepath.Join(s.root, filepath.FromSlash(name))
+
+	info, err := os.Stat(file)
+	if err != nil || info.IsDir() {
+		return ""
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[file]; ok && e.modTime.Equal(info.ModTime()) && e.size == info.Size() {
+		return e.etag
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	e := staticETag{modTime: info.ModTime(), size: info.Size(), etag: contentETag(data)}
+	s.entries[file] = e
+	return e.etag
+}
+
+// staticETagHandler setzt das ETag vor dem FileServer; der beantwortet
+// If-None-Match dann selbst mit 304
+func staticETagHandler(root string, next http.Handler) http.Handler {
+	etags := &staticETags{root: root, entries: make(map[string]staticETag)}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if etag := etags.lookup(r.URL.Path); etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
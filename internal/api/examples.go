@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/llm"
+	"lernplattform/internal/models"
+)
+
+// GenerateWorkedExamples rechnet Beispielaufgaben zu einem quantitativen Thema Schritt für Schritt vor.
+// Body (optional): count (Standard 2, max 5), difficulty (Standard: Schwierigkeit des Themas)
+func (h *Handler) GenerateWorkedExamples(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req struct {
+		Count      int `json:"count"`
+		Difficulty int `json:"difficulty"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Count <= 0 {
+		req.Count = 2
+	}
+	if req.Count > 5 {
+		req.Count = 5
+	}
+
+	topic, err := h.store.GetTopic(id)
+	if err != nil {
+		errorResponse(w, "Thema nicht gefunden", http.StatusNotFound)
+		return
+	}
+	if req.Difficulty < 1 || req.Difficulty > 5 {
+		req.Difficulty = topic.Difficulty
+	}
+	if req.Difficulty < 1 || req.Difficulty > 5 {
+		req.Difficulty = 3
+	}
+
+	ctx := r.Context()
+	examples, err := h.tutor.GenerateWorkedExamples(ctx, topic, h.topicContent(topic), req.Count, req.Difficulty)
+	if errors.Is(err, llm.ErrNotQuantitative) {
+		errorResponse(w, "Zu diesem Thema gibt es keine Rechenaufgaben", http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler bei der Generierung: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	saved := []models.WorkedExample{}
+	for i := range examples {
+		ex := &examples[i]
+		if h.safety.Enabled() {
+			if check := h.safety.Check(ctx, workedExampleText(ex)); !check.Safe {
+				log.Printf("⚠️ Rechenbeispiel '%s' vom Inhaltsfilter verworfen: %s", ex.Title, check.Reason)
+				continue
+			}
+		}
+		ex.ID = fmt.Sprintf("ex_%d_%d", time.Now().UnixNano(), i)
+		ex.CreatedAt = time.Now()
+		if err := h.store.SaveWorkedExample(ex); err != nil {
+			log.Printf("⚠️ Rechenbeispiel konnte nicht gespeichert werden: %v", err)
+			continue
+		}
+		saved = append(saved, *ex)
+	}
+	if len(saved) == 0 {
+		errorResponse(w, "Keine Rechenbeispiele erzeugt", http.StatusUnprocessableEntity)
+		return
+	}
+
+	log.Printf("🧮 %d Rechenbeispiele zu '%s' erstellt", len(saved), topic.Name)
+	jsonResponse(w, saved, http.StatusCreated)
+}
+
+// workedExampleText fasst alle Texte eines Rechenbeispiels für den Inhaltsfilter zusammen
+func workedExampleText(ex *models.WorkedExample) string {
+	parts := []string{ex.Title, ex.Problem}
+	for _, step := range ex.Steps {
+		parts = append(parts, step.Explanation, step.Calculation)
+	}
+	parts = append(parts, ex.Answer)
+	parts = append(parts, ex.Pitfalls...)
+	return strings.Join(parts, "\n")
+}
+
+// GetWorkedExamples listet die gespeicherten Rechenbeispiele eines Themas
+// (format=markdown liefert sie zum Ausdrucken)
+func (h *Handler) GetWorkedExamples(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	examples, err := h.store.GetWorkedExamplesByTopic(id)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	if examples == nil {
+		examples = []models.WorkedExample{}
+	}
+
+	if r.URL.Query().Get("format") == "markdown" {
+		var sb strings.Builder
+		writeWorkedExamplesMarkdown(&sb, examples)
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Write([]byte(sb.String()))
+		return
+	}
+	jsonResponse(w, examples, http.StatusOK)
+}
+
+// writeWorkedExamplesMarkdown schreibt Rechenbeispiele mit allen Zwischenschritten als Markdown
+func writeWorkedExamplesMarkdown(sb *strings.Builder, examples []models.WorkedExample) {
+	for i, ex := range examples {
+		title := ex.Title
+		if title == "" {
+			title = fmt.Sprintf("Beispiel %d", i+1)
+		}
+		sb.WriteString(fmt.Sprintf("*%s*\n\n", title))
+		sb.WriteString(ex.Problem + "\n\n")
+		for n, step := range ex.Steps {
+			sb.WriteString(fmt.Sprintf("%d. %s\n", n+1, step.Explanation))
+			if step.Calculation != "" {
+				sb.WriteString(fmt.Sprintf("   `%s`\n", step.Calculation))
+			}
+			if step.Result != "" {
+				sb.WriteString(fmt.Sprintf("   → %s\n", step.Result))
+			}
+		}
+		if ex.Answer != "" {
+			sb.WriteString(fmt.Sprintf("\n**Ergebnis:** %s\n", ex.Answer))
+		}
+		if len(ex.Pitfalls) > 0 {
+			sb.WriteString("\n⚠️ Typische Fehler:\n")
+			for _, p := range ex.Pitfalls {
+				sb.WriteString("- " + p + "\n")
+			}
+		}
+		sb.WriteString("\n")
+	}
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/models"
+	"lernplattform/internal/pdf"
+)
+
+// examMatchThreshold: Anteil der Begriffe einer Frage, die in einer Klausuraufgabe vorkommen müssen
+const examMatchThreshold = 0.6
+
+// Alte Klausuren am Namen erkennen ("Altklausur_WS21.pdf", "exam-2020.pdf", nicht "examples.pdf")
+var examNamePattern = regexp.MustCompile(`(?i)klausur|(^|[^a-z])exams?([^a-z]|$)`)
+
+// Klausurjahr im Dokumentnamen: "Klausur_2021", "WS 21/22", "SoSe23"
+var (
+	examSemesterPattern = regexp.MustCompile(`(?i)(?:^|[^a-z])(?:ws|wise|ss|sose)[\s_-]*((?:19|20)?\d{2})(?:[^0-9]|$)`)
+	examYearPattern     = regexp.MustCompile(`(?:^|[^0-9])((?:19|20)\d{2})(?:[^0-9]|$)`)
+)
+
+// examDocument ist eine alte Klausur mit vorbereitetem Textvergleich
+type examDocument struct {
+	doc     *models.Document
+	year    int
+	matcher *pdf.Matcher
+}
+
+// isExamDocument erkennt alte Klausuren am Namen oder an einem Tag (Ordner beim Vault-Import)
+func isExamDocument(doc *models.Document) bool {
+	for _, name := range append([]string{doc.Name}, doc.Tags...) {
+		if examNamePattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// examYear liest das Jahr einer Klausur aus dem Namen oder dem Anfang des Texts (0 = unbekannt)
+func examYear(doc *models.Document) int {
+	head := doc.Content
+	if len(head) > 500 {
+		head = head[:500]
+	}
+	for _, text := range []string{doc.Name, head} {
+		if m := examSemesterPattern.FindStringSubmatch(text); m != nil {
+			year, _ := strconv.Atoi(m[1])
+			if year < 100 {
+				year += 2000
+			}
+			return year
+		}
+		if m := examYearPattern.FindStringSubmatch(text); m != nil {
+			year, _ := strconv.Atoi(m[1])
+			return year
+		}
+	}
+	return 0
+}
+
+// examDocuments lädt die alten Klausuren eines Plans
+func (h *Handler) examDocuments(planID string) []examDocument {
+	plan, err := h.store.GetStudyPlan(planID)
+	if err != nil {
+		return nil
+	}
+	var exams []examDocument
+	for _, id := range plan.Documents {
+		doc, err := h.store.GetDocument(id)
+		if err != nil || !isExamDocument(doc) {
+			continue
+		}
+		exams = append(exams, examDocument{doc: doc, year: examYear(doc), matcher: pdf.NewMatcher(doc.Content)})
+	}
+	return exams
+}
+
+// matchExam ordnet eine Frage einer alten Klausur zu: direkt, wenn sie aus der Klausur erzeugt
+// wurde, sonst über den inhaltlichen Vergleich mit den Klausuraufgaben. Bei mehreren Treffern
+// zählt die jüngste Klausur.
+func matchExam(q *models.Question, exams []examDocument) (documentID string, year int) {
+	for _, exam := range exams {
+		if q.SourceDocumentID == exam.doc.ID {
+			return exam.doc.ID, exam.year
+		}
+	}
+	for _, exam := range exams {
+		if exam.matcher.Best(q.Question) >= examMatchThreshold && (documentID == "" || exam.year > year) {
+			documentID, year = exam.doc.ID, exam.year
+		}
+	}
+	return documentID, year
+}
+
+// markExamQuestions markiert neu erzeugte Fragen, die in einer alten Klausur vorkamen
+func (h *Handler) markExamQuestions(topic *models.Topic, questions []models.Question) {
+	exams := h.examDocuments(topic.StudyPlanID)
+	if len(exams) == 0 {
+		return
+	}
+	for i := range questions {
+		q := &questions[i]
+		q.ExamDocumentID, q.ExamYear = matchExam(q, exams)
+		q.ExamRelevant = q.ExamDocumentID != ""
+	}
+}
+
+// ScanExamQuestions gleicht alle Fragen eines Plans mit den alten Klausuren ab, z.B. nachdem
+// eine Klausur hochgeladen wurde, und liefert die Zahl der Klausurfragen je Jahr
+func (h *Handler) ScanExamQuestions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if _, err := h.store.GetStudyPlan(id); err != nil {
+		errorResponse(w, "Lernplan nicht gefunden", http.StatusNotFound)
+		return
+	}
+	questions, err := h.store.GetQuestionsByPlan(id)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+
+	exams := h.examDocuments(id)
+	names := make([]string, 0, len(exams))
+	for _, exam := range exams {
+		names = append(names, documentTitle(exam.doc))
+	}
+
+	flagged, changed := 0, 0
+	byYear := make(map[string]int)
+	for i := range questions {
+		q := &questions[i]
+		documentID, year := matchExam(q, exams)
+		if documentID != "" {
+			flagged++
+			key := "unbekannt"
+			if year > 0 {
+				key = strconv.Itoa(year)
+			}
+			byYear[key]++
+		}
+		if documentID == q.ExamDocumentID && year == q.ExamYear {
+			continue
+		}
+		if err := h.store.SetQuestionExamSource(q.ID, documentID, year); err != nil {
+			log.Printf("   ✗ Klausurmarkierung für Frage %s fehlgeschlagen: %v", q.ID, err)
+			continue
+		}
+		changed++
+	}
+	log.Printf("📝 %d von %d Fragen kamen in alten Klausuren vor (%d geändert)", flagged, len(questions), changed)
+
+	jsonResponse(w, map[string]interface{}{
+		"exam_documents": names,
+		"questions":      len(questions),
+		"exam_relevant":  flagged,
+		"changed":        changed,
+		"by_year":        byYear,
+	}, http.StatusOK)
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"lernplattform/internal/llm"
+	"lernplattform/internal/models"
+	"lernplattform/internal/pdf"
+)
+
+// Höchstens so viele Abbildungen werden je Generierung dem Vision-Modell gezeigt
+// (irrelevante Abbildungen wie Logos kosten einen Versuch)
+const maxFigureAttempts = 8
+
+// errNoFigures: das Thema hat keine Abbildungen, zu denen sich eine Frage stellen lässt
+var errNoFigures = errors.New("keine geeigneten Abbildungen in den Quellen des Themas gefunden")
+
+// saveDocumentFigures speichert die beim Einlesen gefundenen Abbildungen eines Dokuments.
+// Ohne neue Abbildungen (z.B. Neu-Einlesen aus dem Originaltext) bleiben die gespeicherten erhalten.
+func (h *Handler) saveDocumentFigures(doc *models.Document) {
+	if len(doc.Figures) == 0 {
+		return
+	}
+	now := time.Now()
+	for i := range doc.Figures {
+		doc.Figures[i].ID = fmt.Sprintf("fig_%s_%d", doc.ID, i+1)
+		doc.Figures[i].DocumentID = doc.ID
+		doc.Figures[i].CreatedAt = now
+	}
+	if err := h.store.SaveFigures(doc.ID, doc.Figures); err != nil {
+		log.Printf("⚠️ Abbildungen für '%s' konnten nicht gespeichert werden: %v", doc.Name, err)
+		return
+	}
+	log.Printf("   🖼️ %d Abbildungen aus '%s' gespeichert", len(doc.Figures), doc.Name)
+}
+
+// documentFigures liefert die Abbildungen eines Dokuments. Für vorher eingelesene PDFs werden
+// sie beim ersten Aufruf aus der Datei gelesen, falls sie noch im Dokumentenordner liegt.
+func (h *Handler) documentFigures(doc *models.Document) []models.Figure {
+	figures, err := h.store.GetFigures(doc.ID)
+	if err == nil && len(figures) > 0 {
+		return figures
+	}
+	if doc.Path == "" || !strings.EqualFold(filepath.Ext(doc.Path), ".pdf") {
+		return nil
+	}
+	if _, err := os.Stat(doc.Path); err != nil {
+		return nil
+	}
+	doc.Figures, err = pdf.ReadFigures(doc.Path)
+	if err != nil {
+		log.Printf("⚠️ Abbildungen aus '%s' nicht lesbar: %v", doc.Name, err)
+		return nil
+	}
+	h.saveDocumentFigures(doc)
+	return doc.Figures
+}
+
+// topicFigures sammelt die Abbildungen auf den Quellseiten eines Themas
+// (ohne bekannte Seiten alle Abbildungen der Dokumente des Lernplans)
+func (h *Handler) topicFigures(topic *models.Topic) []models.Figure {
+	sources := topic.Sources
+	if len(sources) == 0 && topic.ParentTopicID != "" {
+		sources, _ = h.store.GetTopicSources(topic.ParentTopicID)
+	}
+	if len(sources) == 0 {
+		for _, doc := range h.sourceDocuments(topic) {
+			sources = append(sources, models.TopicSource{DocumentID: doc.ID})
+		}
+	}
+
+	var figures []models.Figure
+	for _, src := range sources {
+		doc, _ := h.store.GetDocument(src.DocumentID)
+		if doc == nil {
+			continue
+		}
+		for _, f := range h.documentFigures(doc) {
+			if src.PageStart > 0 && (f.Page < src.PageStart || f.Page > max(src.PageEnd, src.PageStart)) {
+				continue
+			}
+			figures = append(figures, f)
+		}
+	}
+	return figures
+}
+
+// generateFigureQuestions erstellt Bildfragen zu Abbildungen des Themas, die noch keine Frage haben
+func (h *Handler) generateFigureQuestions(ctx context.Context, topic *models.Topic, difficulty, count int) ([]models.Question, error) {
+	figures := h.topicFigures(topic)
+	if len(figures) == 0 {
+		return nil, errNoFigures
+	}
+
+	used := make(map[string]bool)
+	existing, _ := h.store.GetQuestionsByTopic(topic.ID)
+	for _, q := range existing {
+		used[q.FigureID] = true
+	}
+
+	var questions []models.Question
+	attempts := 0
+	for i := range figures {
+		if len(questions) >= count || attempts >= maxFigureAttempts {
+			break
+		}
+		figure := &figures[i]
+		if used[figure.ID] {
+			continue
+		}
+		attempts++
+
+		doc, _ := h.store.GetDocument(figure.DocumentID)
+		var pageText string
+		if doc != nil {
+			pageText = pdf.ExtractPages(doc.Content, figure.Page, figure.Page)
+		}
+		q, err := h.tutor.GenerateFigureQuestion(ctx, topic, figure, pageText, difficulty)
+		if errors.Is(err, llm.ErrFigureIrrelevant) {
+			log.Printf("   🖼️ Abbildung %s (Seite %d) übersprungen: ohne Lerninhalt", figure.ID, figure.Page)
+			continue
+		}
+		if err != nil {
+			if len(questions) > 0 && !errors.Is(err, llm.ErrNoVision) {
+				log.Printf("   ⚠️ Bildfrage zu %s fehlgeschlagen: %v", figure.ID, err)
+				continue
+			}
+			return nil, err
+		}
+		questions = append(questions, *q)
+	}
+	if len(questions) == 0 {
+		return nil, errNoFigures
+	}
+	return questions, nil
+}
+
+// GetDocumentFigures listet die Abbildungen eines Dokuments (ohne Bilddaten, die liefert GET /figures/{id})
+func (h *Handler) GetDocumentFigures(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	doc, err := h.store.GetDocument(vars["id"])
+	if err != nil {
+		errorResponse(w, "Dokument nicht gefunden", http.StatusNotFound)
+		return
+	}
+	figures := h.documentFigures(doc)
+	if figures == nil {
+		figures = []models.Figure{}
+	}
+	jsonResponse(w, figures, http.StatusOK)
+}
+
+// GetFigure liefert das Bild einer Abbildung
+func (h *Handler) GetFigure(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	figure, err := h.store.GetFigure(vars["id"])
+	if err != nil {
+		errorResponse(w, "Abbildung nicht gefunden", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", figure.MimeType)
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Write(figure.Data)
+}
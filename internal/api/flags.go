@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/models"
+)
+
+// flagView ergänzt eine Markierung um das markierte Element für die Sammelansicht
+type flagView struct {
+	models.Flag
+	Question    *models.Question    `json:"question,omitempty"`
+	Explanation *models.Explanation `json:"explanation,omitempty"`
+}
+
+// === Markierungen Endpoints ===
+
+// FlagQuestion markiert eine Frage zur späteren Wiederholung
+func (h *Handler) FlagQuestion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	question, err := h.store.GetQuestion(id)
+	if err != nil {
+		errorResponse(w, "Frage nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	h.createFlag(w, r, "question", question.ID, question.TopicID)
+}
+
+// FlagExplanation markiert eine (gespeicherte) Erklärung als unverständlich
+func (h *Handler) FlagExplanation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	explanation, err := h.store.GetExplanation(id)
+	if err != nil {
+		errorResponse(w, "Erklärung nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	h.createFlag(w, r, "explanation", explanation.ID, explanation.TopicID)
+}
+
+func (h *Handler) createFlag(w http.ResponseWriter, r *http.Request, itemType, itemID, topicID string) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	// Bereits offene Markierung nicht doppelt anlegen
+	if existing, err := h.store.GetOpenFlag(itemType, itemID); err == nil {
+		jsonResponse(w, existing, http.StatusOK)
+		return
+	}
+
+	flag := &models.Flag{
+		ID:        fmt.Sprintf("flag_%d", time.Now().UnixNano()),
+		ItemType:  itemType,
+		ItemID:    itemID,
+		TopicID:   topicID,
+		Reason:    req.Reason,
+		CreatedAt: time.Now(),
+	}
+
+	if err := h.store.SaveFlag(flag); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, flag, http.StatusCreated)
+}
+
+// GetFlags listet markierte Elemente zur gesammelten Wiederholung
+func (h *Handler) GetFlags(w http.ResponseWriter, r *http.Request) {
+	itemType := r.URL.Query().Get("type")
+	if itemType != "" && itemType != "question" && itemType != "explanation" {
+		errorResponse(w, "Ungültiger Typ (question, explanation)", http.StatusBadRequest)
+		return
+	}
+	topicID := r.URL.Query().Get("topic_id")
+	includeResolved := r.URL.Query().Get("include_resolved") == "true"
+
+	flags, err := h.store.GetFlags(itemType, topicID, includeResolved)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+
+	views := make([]flagView, 0, len(flags))
+	for _, f := range flags {
+		view := flagView{Flag: f}
+		switch f.ItemType {
+		case "question":
+			view.Question, _ = h.store.GetQuestion(f.ItemID)
+		case "explanation":
+			view.Explanation, _ = h.store.GetExplanation(f.ItemID)
+		}
+		views = append(views, view)
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"flags": views,
+		"count": len(views),
+	}, http.StatusOK)
+}
+
+// ResolveFlags hakt mehrere Markierungen nach der Wiederholung ab
+func (h *Handler) ResolveFlags(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs []string `json:"ids"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.IDs) == 0 {
+		errorResponse(w, "Keine Markierungen angegeben", http.StatusBadRequest)
+		return
+	}
+
+	resolved, err := h.store.ResolveFlags(req.IDs)
+	if err != nil {
+		errorResponse(w, "Fehler beim Update", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"message":  "Markierungen erledigt",
+		"resolved": resolved,
+	}, http.StatusOK)
+}
+
+func (h *Handler) DeleteFlag(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if err := h.store.DeleteFlag(id); err != nil {
+		errorResponse(w, "Fehler beim Löschen", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]string{"message": "Markierung entfernt"}, http.StatusOK)
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"lernplattform/internal/llm"
+)
+
+// Status für abgebrochene Generierungen (wie nginx' "Client Closed Request")
+const statusGenerationCancelled = 499
+
+// generation ist eine laufende KI-Anfrage, die sich abbrechen lässt
+type generation struct {
+	ID        string        `json:"id"`
+	Path      string        `json:"path"`
+	StartedAt time.Time     `json:"started_at"`
+	Progress  *llm.Progress `json:"progress,omitempty"` // letzter Zwischenstand, falls der Tutor ihn meldet
+	cancel    context.CancelFunc
+}
+
+// generationEvent wird über GET /generations/events verschickt
+type generationEvent struct {
+	Type       string     `json:"type"` // running (beim Verbinden), started, progress, finished
+	Generation generation `json:"generation"`
+}
+
+// generationRegistry hält die laufenden KI-Anfragen (Chat, Erklärungen, Lernplan)
+// und die Empfänger ihrer Ereignisse
+type generationRegistry struct {
+	mu          sync.Mutex
+	running     map[string]*generation
+	subscribers map[chan generationEvent]struct{}
+}
+
+func newGenerationRegistry() *generationRegistry {
+	return &generationRegistry{running: make(map[string]*generation), subscribers: make(map[chan generationEvent]struct{})}
+}
+
+// publish verteilt ein Ereignis; nur mit gehaltenem g.mu aufrufen.
+// Langsame Empfänger verpassen Ereignisse, statt die Generierung aufzuhalten.
+func (g *generationRegistry) publish(eventType string, gen *generation) {
+	ev := generationEvent{Type: eventType, Generation: *gen}
+	for ch := range g.subscribers {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// subscribe meldet einen Empfänger an und liefert die gerade laufenden Generierungen
+func (g *generationRegistry) subscribe() (chan generationEvent, []generation, func()) {
+	ch := make(chan generationEvent, 32)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.subscribers[ch] = struct{}{}
+	running := make([]generation, 0, len(g.running))
+	for _, gen := range g.running {
+		running = append(running, *gen)
+	}
+	return ch, running, func() {
+		g.mu.Lock()
+		delete(g.subscribers, ch)
+		g.mu.Unlock()
+	}
+}
+
+// beginGeneration macht eine KI-Anfrage abbrechbar. Die ID wählt der Client (Header
+// X-Generation-ID oder ?generation_id=, für WebSockets), damit er schon abbrechen kann, bevor
+// die Antwort da ist; ohne ID wird eine erzeugt. Sie steht im Antwort-Header X-Generation-ID.
+// end gibt die ID nach der Anfrage wieder frei.
+func (h *Handler) beginGeneration(w http.ResponseWriter, r *http.Request, parent context.Context) (context.Context, func()) {
+	id := r.Header.Get("X-Generation-ID")
+	if id == "" {
+		id = r.URL.Query().Get("generation_id")
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	gen := &generation{Path: r.URL.Path, StartedAt: time.Now(), cancel: cancel}
+
+	h.generations.mu.Lock()
+	if _, taken := h.generations.running[id]; id == "" || taken {
+		id = fmt.Sprintf("gen_%d", time.Now().UnixNano())
+	}
+	gen.ID = id
+	h.generations.running[id] = gen
+	h.generations.publish("started", gen)
+	h.generations.mu.Unlock()
+
+	// Zwischenstände des Tutors (Dokumente, Schritt, Restzeit) an der Generierung festhalten
+	ctx = llm.WithProgress(ctx, func(p llm.Progress) {
+		h.generations.mu.Lock()
+		defer h.generations.mu.Unlock()
+		gen.Progress = &p
+		h.generations.publish("progress", gen)
+	})
+
+	w.Header().Set("X-Generation-ID", id)
+	return ctx, func() {
+		h.generations.mu.Lock()
+		delete(h.generations.running, id)
+		h.generations.publish("finished", gen)
+		h.generations.mu.Unlock()
+		cancel()
+	}
+}
+
+// generationFailed meldet einen Fehler der KI-Anfrage; nach einem Abbruch mit 499 statt 500
+func generationFailed(w http.ResponseWriter, ctx context.Context, message string) {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		errorResponse(w, "Generierung abgebrochen", statusGenerationCancelled)
+		return
+	}
+	errorResponse(w, message, http.StatusInternalServerError)
+}
+
+// GetGenerations listet die laufenden KI-Anfragen, älteste zuerst
+func (h *Handler) GetGenerations(w http.ResponseWriter, r *http.Request) {
+	h.generations.mu.Lock()
+	running := make([]generation, 0, len(h.generations.running))
+	for _, gen := range h.generations.running {
+		running = append(running, *gen)
+	}
+	h.generations.mu.Unlock()
+
+	sort.Slice(running, func(i, j int) bool { return running[i].StartedAt.Before(running[j].StartedAt) })
+	jsonResponse(w, running, http.StatusOK)
+}
+
+// CancelGeneration bricht eine laufende KI-Anfrage ab. Der Abbruch reicht über den Context bis
+// zur HTTP-Anfrage an das LLM-Backend; die abgebrochene Anfrage antwortet mit 499.
+func (h *Handler) CancelGeneration(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	h.generations.mu.Lock()
+	gen, ok := h.generations.running[id]
+	h.generations.mu.Unlock()
+	if !ok {
+		errorResponse(w, "Keine laufende Generierung mit dieser ID", http.StatusNotFound)
+		return
+	}
+
+	gen.cancel()
+	log.Printf("⏹️ Generierung %s abgebrochen (%s, nach %v)", id, gen.Path, time.Since(gen.StartedAt).Round(time.Second))
+	jsonResponse(w, map[string]string{"message": "Generierung abgebrochen"}, http.StatusOK)
+}
+
+// GenerationEvents hält eine WebSocket-Verbindung offen und schickt Start, Zwischenstände und
+// Ende aller KI-Anfragen; beim Verbinden kommen zuerst die gerade laufenden (Typ "running")
+func (h *Handler) GenerationEvents(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	// Die Zeitlimits des Servers gelten nicht für die dauerhafte Verbindung
+	conn.SetReadDeadline(time.Time{})
+	conn.SetWriteDeadline(time.Time{})
+
+	events, running, unsubscribe := h.generations.subscribe()
+	defer unsubscribe()
+
+	// Nur lesen, um das Schließen durch den Client zu bemerken
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	sort.Slice(running, func(i, j int) bool { return running[i].StartedAt.Before(running[j].StartedAt) })
+	for _, gen := range running {
+		if err := conn.WriteJSON(generationEvent{Type: "running", Generation: gen}); err != nil {
+			return
+		}
+	}
+
+	ping := time.NewTicker(30 * time.Second)
+	defer ping.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case ev := <-events:
+			if err := conn.WriteJSON(ev); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"lernplattform/internal/mail"
+	"lernplattform/internal/models"
+)
+
+const dateLayout = models.DateLayout
+
+// maxOpenSessionMinutes: nicht beendete Sitzungen zählen höchstens so lange (vergessenes Beenden)
+const maxOpenSessionMinutes = 240
+
+// dayActivity ist die Lernaktivität eines Tages
+type dayActivity struct {
+	minutes    int
+	questions  int
+	flashcards int
+}
+
+// dailyActivity sammelt Lernzeit, beantwortete Fragen und Wiederholungen je Tag ab since
+func (h *Handler) dailyActivity(since time.Time) (map[string]*dayActivity, error) {
+	// Die Datenbank filtert grob mit einem Tag Puffer (Zeitzonen im gespeicherten Text), genau filtert die Schleife
+	cutoff := since.AddDate(0, 0, -1)
+
+	activity := make(map[string]*dayActivity)
+	day := func(t time.Time) *dayActivity {
+		key := t.Local().Format(dateLayout)
+		if activity[key] == nil {
+			activity[key] = &dayActivity{}
+		}
+		return activity[key]
+	}
+
+	sessions, err := h.store.GetSessionsSince(cutoff)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, s := range sessions {
+		if s.StartedAt.Before(since) {
+			continue
+		}
+		minutes := s.Duration
+		if s.EndedAt == nil {
+			minutes = min(int(now.Sub(s.StartedAt).Minutes()), maxOpenSessionMinutes)
+		}
+		day(s.StartedAt).minutes += minutes
+	}
+
+	// Versuche sind nach Zeit sortiert: jeder weitere Versuch derselben Frage ist eine Wiederholung
+	earlier, err := h.store.GetQuestionIDsAnsweredBefore(cutoff)
+	if err != nil {
+		return nil, err
+	}
+	answered := make(map[string]bool, len(earlier))
+	for _, id := range earlier {
+		answered[id] = true
+	}
+	attempts, err := h.store.GetAttemptsSince(cutoff)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range attempts {
+		repeat := answered[a.QuestionID]
+		answered[a.QuestionID] = true
+		if a.CreatedAt.Before(since) {
+			continue
+		}
+		d := day(a.CreatedAt)
+		d.questions++
+		if repeat {
+			d.flashcards++
+		}
+	}
+
+	return activity, nil
+}
+
+// goalDay bewertet die Aktivität eines Tages gegen die konfigurierten Ziele
+func (h *Handler) goalDay(date string, a *dayActivity) models.DailyGoalDay {
+	if a == nil {
+		a = &dayActivity{}
+	}
+	goals := h.config.DailyGoals
+	day := models.DailyGoalDay{Date: date, Goals: []models.GoalProgress{}}
+
+	for _, g := range []struct {
+		name         string
+		target, done int
+	}{
+		{"minutes", goals.Minutes, a.minutes},
+		{"questions", goals.Questions, a.questions},
+		{"flashcards", goals.Flashcards, a.flashcards},
+	} {
+		if g.target <= 0 {
+			continue
+		}
+		day.Goals = append(day.Goals, models.GoalProgress{
+			Goal:      g.name,
+			Target:    g.target,
+			Done:      g.done,
+			Completed: g.done >= g.target,
+		})
+	}
+
+	day.Completed = len(day.Goals) > 0
+	for _, g := range day.Goals {
+		day.Completed = day.Completed && g.Completed
+	}
+	return day
+}
+
+// goalReminder formuliert die Erinnerung für offene Tagesziele
+func goalReminder(day models.DailyGoalDay) string {
+	units := map[string]string{"minutes": "Minuten", "questions": "Fragen", "flashcards": "Wiederholungen"}
+	var missing []string
+	for _, g := range day.Goals {
+		if !g.Completed {
+			missing = append(missing, fmt.Sprintf("%d %s", g.Target-g.Done, units[g.Goal]))
+		}
+	}
+	return "⏰ Dein Tagesziel ist noch offen: noch " + strings.Join(missing, ", ") + ". Ein kurzer Endspurt reicht!"
+}
+
+// GetGoalsToday zeigt den Stand der Tagesziele; ab der Erinnerungszeit werden offene Ziele als gefährdet markiert.
+// Dazu kommen Themen, die nach der geschätzten Vergessenskurve heute wiederholt werden sollten.
+func (h *Handler) GetGoalsToday(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	activity, err := h.dailyActivity(today)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+
+	date := today.Format(dateLayout)
+	day := h.goalDay(date, activity[date])
+
+	atRisk := len(day.Goals) > 0 && !day.Completed && now.Hour() >= h.config.ReminderHour()
+
+	resp := map[string]interface{}{
+		"date":      day.Date,
+		"goals":     day.Goals,
+		"completed": day.Completed,
+		"at_risk":   atRisk,
+	}
+	if atRisk {
+		resp["reminder"] = goalReminder(day)
+	}
+	// Wiederholungen nach Vergessenskurve für den aktiven Plan
+	if plan, err := h.store.GetActiveStudyPlan(); err == nil {
+		if estimates, err := h.planRetention(plan.ID); err == nil {
+			resp["reviews"] = reviewReminders(estimates)
+		}
+	}
+
+	jsonResponse(w, resp, http.StatusOK)
+}
+
+// SendGoalReminder erinnert per E-Mail an offene Tagesziele (täglicher Job zur Erinnerungszeit);
+// sind alle Ziele erreicht oder keine gesetzt, wird nichts verschickt
+func (h *Handler) SendGoalReminder(ctx context.Context) error {
+	sender := mail.NewSender(h.config.Email)
+	if !sender.Enabled() {
+		return fmt.Errorf("e-mail nicht konfiguriert")
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	activity, err := h.dailyActivity(today)
+	if err != nil {
+		return err
+	}
+	date := today.Format(dateLayout)
+	day := h.goalDay(date, activity[date])
+	if len(day.Goals) == 0 || day.Completed {
+		return nil
+	}
+
+	var body strings.Builder
+	body.WriteString("<p>" + html.EscapeString(goalReminder(day)) + "</p>")
+	if plan, err := h.store.GetActiveStudyPlan(); err == nil {
+		if estimates, err := h.planRetention(plan.ID); err == nil {
+			for _, review := range reviewReminders(estimates) {
+				body.WriteString("<p>" + html.EscapeString(review["message"].(string)) + "</p>")
+			}
+		}
+	}
+	if err := sender.Send("Dein Tagesziel ist noch offen", body.String()); err != nil {
+		return err
+	}
+	log.Printf("⏰ Erinnerung an offene Tagesziele an %s verschickt", strings.Join(h.config.Email.To, ", "))
+	return nil
+}
+
+// GetGoalsHistory zeigt die Zielerreichung der letzten Tage (days, Standard 14, max 90) und die aktuelle Serie
+func (h *Handler) GetGoalsHistory(w http.ResponseWriter, r *http.Request) {
+	days := getQueryInt(r, "days", 14)
+	if days < 1 {
+		days = 1
+	}
+	if days > 90 {
+		days = 90
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	since := today.AddDate(0, 0, -(days - 1))
+
+	activity, err := h.dailyActivity(since)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+
+	history := make([]models.DailyGoalDay, 0, days)
+	completedDays := 0
+	for i := 0; i < days; i++ {
+		date := today.AddDate(0, 0, -i).Format(dateLayout)
+		day := h.goalDay(date, activity[date])
+		if day.Completed {
+			completedDays++
+		}
+		history = append(history, day)
+	}
+
+	// Serie: aufeinanderfolgende erreichte Tage bis gestern, heute zählt mit, sobald erreicht
+	streak := 0
+	for i, day := range history {
+		if !day.Completed {
+			if i == 0 {
+				continue
+			}
+			break
+		}
+		streak++
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"days":           history,
+		"completed_days": completedDays,
+		"streak":         streak,
+	}, http.StatusOK)
+}
@@ -2,32 +2,53 @@ package api
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"lernplattform/internal/config"
+	"lernplattform/internal/hooks"
 	"lernplattform/internal/llm"
 	"lernplattform/internal/models"
 	"lernplattform/internal/pdf"
 	"lernplattform/internal/storage"
+	"lernplattform/internal/voice"
+	"lernplattform/internal/xapi"
 )
 
 // Handler verwaltet alle API-Endpunkte
 type Handler struct {
-	store      storage.Storage
-	llm        llm.Provider
-	tutor      *llm.Tutor
-	pdfParser  *pdf.Parser
-	config     *config.Config
-	upgrader   websocket.Upgrader
+	store       storage.Storage
+	llm         llm.Provider
+	prompts     llm.Provider // mit before_prompt-Hooks, für Prompts außerhalb des Tutors
+	tutor       *llm.Tutor
+	pdfParser   *pdf.Parser
+	config      *config.Config
+	upgrader    websocket.Upgrader
+	shuffleKey  []byte
+	safety      *llm.SafetyFilter
+	stt         voice.Transcriber
+	tts         voice.Synthesizer
+	changes     *changeTracker
+	xapi        *xapi.Client
+	embeddings  *embeddingCache
+	generations *generationRegistry
+	llmStatus   *llmStatus
+	wipe        *wipeConfirmation
+	hooks       *hooks.Runner
+	imports     *http.Client
 }
 
 // NewHandler erstellt einen neuen API-Handler
@@ -35,17 +56,65 @@ func NewHandler(store storage.Storage, llmProvider llm.Provider, cfg *config.Con
 	// Schnelles Modell für Dokumentenanalyse, Hauptmodell für Chat/Quiz
 	fastModel := "llama3.2:3b" // Schnell für Analyse
 	numAgents := 1             // Sequentiell (Ollama-Limit)
-	
-	return &Handler{
+
+	// before_prompt-Hooks greifen bei allen Prompts des Tutors und beim Chat-Stream
+	runner := hooks.New(cfg.Hooks)
+	tutorProvider := llmProvider
+	if runner.Enabled(hooks.BeforePrompt) {
+		tutorProvider = llm.NewPromptHookProvider(llmProvider, runner.BeforePrompt)
+	}
+
+	h := &Handler{
 		store:     store,
 		llm:       llmProvider,
-		tutor:     llm.NewTutorWithAgents(llmProvider, fastModel, numAgents),
+		prompts:   tutorProvider,
+		tutor:     llm.NewTutorWithAgents(tutorProvider, fastModel, numAgents),
 		pdfParser: pdf.NewParser(cfg.DocumentsPath),
 		config:    cfg,
 		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool { return true },
+			CheckOrigin: func(r *http.Request) bool { return originAllowed(cfg.Security, r) },
 		},
+		shuffleKey:  loadShuffleKey(store),
+		safety:      llm.NewSafetyFilter(cfg.ContentFilter, llmProvider),
+		changes:     newChangeTracker(),
+		xapi:        xapi.NewClient(cfg.XAPI),
+		embeddings:  newEmbeddingCache(),
+		generations: newGenerationRegistry(),
+		llmStatus:   &llmStatus{},
+		wipe:        &wipeConfirmation{},
+		hooks:       runner,
+		imports:     newImportClient(cfg.Security.ImportAllowedHosts),
+	}
+
+	// Deterministischer Modus: gleicher Seed liefert gleiche Fragen und Bewertungen
+	if cfg.DeterministicMode {
+		seed := cfg.EffectiveSeed()
+		h.tutor.SetSeed(seed)
+		log.Printf("   ✓ Deterministischer Modus (Seed %d)", seed)
+	}
+	h.tutor.SetEmbeddingModel(cfg.EmbeddingModel)
+	h.tutor.SetVisionModel(cfg.VisionModel)
+	h.ensureDefaultProfiles()
+
+	// Gemessene Dauer früherer LLM-Aufrufe für die Restzeit-Schätzung
+	if samples, err := store.GetDurationSamples(2000); err == nil {
+		llm.LoadDurations(samples)
+	}
+	llm.OnDuration(func(sample models.DurationSample) {
+		if err := store.SaveDurationSample(sample); err != nil {
+			log.Printf("⚠️ Dauer des LLM-Aufrufs nicht gespeichert: %v", err)
+		}
+	})
+
+	// Sprach-Backends sind optional
+	if cfg.WhisperURL != "" {
+		h.stt = voice.NewWhisperTranscriber(cfg.WhisperURL)
 	}
+	if cfg.PiperModel != "" {
+		h.tts = voice.NewPiperSynthesizer(cfg.PiperPath, cfg.PiperModel)
+	}
+
+	return h
 }
 
 // Response-Helper
@@ -83,9 +152,11 @@ func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
 	llmAvailable := h.llm.IsAvailable(ctx)
 
 	var activePlan *models.StudyPlan
+	var phase map[string]interface{}
 	for _, p := range plans {
 		if p.Status == "active" {
 			activePlan = &p
+			phase = phaseStatus(activePlan, time.Now())
 			break
 		}
 	}
@@ -94,12 +165,34 @@ func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
 		"documents_count":   len(docs),
 		"study_plans_count": len(plans),
 		"active_plan":       activePlan,
+		"current_phase":     phase,
 		"llm_available":     llmAvailable,
 		"llm_provider":      h.llm.GetName(),
+		"llm_capabilities":  h.llm.Capabilities(),
+		"llm_queue":         llmQueueStats(h.llm),
+		"llm_backends":      llmBackends(h.llm),
+		"llm_budget":        h.llmBudgetStatus(time.Now()),
 		"documents_path":    h.config.DocumentsPath,
 	}, http.StatusOK)
 }
 
+// llmBackends liefert den Zustand der Failover-Kette, falls konfiguriert
+func llmBackends(provider llm.Provider) []llm.BackendStatus {
+	if f, ok := provider.(interface{ Backends() []llm.BackendStatus }); ok {
+		return f.Backends()
+	}
+	return nil
+}
+
+// llmQueueStats liefert die Warteschlangen-Auslastung, falls der Provider sie kennt
+func llmQueueStats(provider llm.Provider) *llm.QueueStats {
+	if q, ok := provider.(interface{ QueueStats() llm.QueueStats }); ok {
+		stats := q.QueueStats()
+		return &stats
+	}
+	return nil
+}
+
 func (h *Handler) GetModels(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -115,6 +208,40 @@ func (h *Handler) GetModels(w http.ResponseWriter, r *http.Request) {
 	}, http.StatusOK)
 }
 
+// RecommendModels schlägt anhand von Modellgrößen und Grafikspeicher ein Analyse-/Chat-Modellpaar vor.
+// Optional: ?vram_gb= für den tatsächlichen Grafikspeicher, sonst Schätzung über /api/ps.
+func (h *Handler) RecommendModels(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var vram int64
+	if v := r.URL.Query().Get("vram_gb"); v != "" {
+		gb, err := strconv.ParseFloat(v, 64)
+		if err != nil || gb <= 0 {
+			errorResponse(w, "Ungültige VRAM-Angabe", http.StatusBadRequest)
+			return
+		}
+		vram = int64(gb * (1 << 30))
+	}
+
+	models, err := h.llm.GetModels(ctx)
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Konnte Modelle nicht abrufen: %v", err), http.StatusServiceUnavailable)
+		return
+	}
+
+	var running []llm.RunningModel
+	if ps, ok := h.llm.(interface {
+		GetRunningModels(ctx context.Context) ([]llm.RunningModel, error)
+	}); ok {
+		running, err = ps.GetRunningModels(ctx)
+		if err != nil {
+			log.Printf("⚠️  Geladene Modelle nicht abrufbar: %v", err)
+		}
+	}
+
+	jsonResponse(w, llm.RecommendModels(models, running, vram, h.llm.GetCurrentModel()), http.StatusOK)
+}
+
 // SetModel ändert das aktive LLM-Modell
 func (h *Handler) SetModel(w http.ResponseWriter, r *http.Request) {
 	var req struct {
@@ -156,6 +283,21 @@ func (h *Handler) SetModel(w http.ResponseWriter, r *http.Request) {
 	h.llm.SetModel(req.Model)
 	h.config.DefaultModel = req.Model
 
+	// Neues Modell vorladen, damit die nächste Anfrage nicht auf das Laden wartet
+	if h.config.WarmupOnStart {
+		if warmer, ok := h.llm.(interface {
+			Warmup(ctx context.Context, model string) error
+		}); ok {
+			go func() {
+				warmCtx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+				defer cancel()
+				if err := warmer.Warmup(warmCtx, req.Model); err != nil {
+					log.Printf("⚠️  Warm-up für %s fehlgeschlagen: %v", req.Model, err)
+				}
+			}()
+		}
+	}
+
 	jsonResponse(w, map[string]interface{}{
 		"message":       "Modell geändert",
 		"current_model": req.Model,
@@ -165,6 +307,10 @@ func (h *Handler) SetModel(w http.ResponseWriter, r *http.Request) {
 // === Dokument Endpoints ===
 
 func (h *Handler) GetDocuments(w http.ResponseWriter, r *http.Request) {
+	if h.notModified(w, r, "") {
+		return
+	}
+
 	docs, err := h.store.GetAllDocuments()
 	if err != nil {
 		errorResponse(w, "Fehler beim Laden der Dokumente", http.StatusInternalServerError)
@@ -178,8 +324,10 @@ func (h *Handler) GetDocuments(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) UploadDocument(w http.ResponseWriter, r *http.Request) {
-	// Max 50MB
-	r.ParseMultipartForm(50 << 20)
+	// Max 50MB (Obergrenze setzt limitsMiddleware)
+	if err := r.ParseMultipartForm(50 << 20); bodyTooLarge(w, err) {
+		return
+	}
 
 	file, header, err := r.FormFile("file")
 	if err != nil {
@@ -188,13 +336,19 @@ func (h *Handler) UploadDocument(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	doc, err := h.pdfParser.ParseFromReader(file, header.Filename)
+	// ZIP-Archive werden entpackt, jede enthaltene PDF/DOCX wird ein eigenes Dokument
+	if strings.HasSuffix(strings.ToLower(header.Filename), ".zip") {
+		h.uploadZip(w, file, header.Size)
+		return
+	}
+
+	doc, err := h.parseUpload(file, header.Filename)
 	if err != nil {
 		errorResponse(w, fmt.Sprintf("Fehler beim Parsen: %v", err), http.StatusBadRequest)
 		return
 	}
 
-	if err := h.store.SaveDocument(doc); err != nil {
+	if err := h.storeDocument(doc); err != nil {
 		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
 		return
 	}
@@ -222,7 +376,7 @@ func (h *Handler) ScanDocumentsFolder(w http.ResponseWriter, r *http.Request) {
 
 	// Dokumente speichern
 	for _, doc := range docs {
-		h.store.SaveDocument(&doc)
+		h.storeDocument(&doc)
 	}
 
 	jsonResponse(w, map[string]interface{}{
@@ -244,6 +398,69 @@ func (h *Handler) GetDocument(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, doc, http.StatusOK)
 }
 
+// UpdateDocument setzt Titel, Autor, Semester und Schlagworte eines Dokuments. Die Datei und
+// ihr Name bleiben unverändert; ein leerer Titel zeigt wieder den Dateinamen.
+func (h *Handler) UpdateDocument(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Nur mitgeschickte Angaben werden geändert; tags ersetzt alle Schlagworte
+	var req struct {
+		Title    *string   `json:"title"`
+		Author   *string   `json:"author"`
+		Semester *string   `json:"semester"`
+		Tags     *[]string `json:"tags"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+
+	doc, err := h.store.GetDocument(id)
+	if err != nil {
+		errorResponse(w, "Dokument nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	if req.Title != nil {
+		doc.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Author != nil {
+		doc.Author = strings.TrimSpace(*req.Author)
+	}
+	if req.Semester != nil {
+		doc.Semester = strings.TrimSpace(*req.Semester)
+	}
+	if req.Tags != nil {
+		doc.Tags = nil
+		seen := make(map[string]bool)
+		for _, tag := range *req.Tags {
+			tag = strings.TrimSpace(tag)
+			if tag == "" || seen[strings.ToLower(tag)] {
+				continue
+			}
+			seen[strings.ToLower(tag)] = true
+			doc.Tags = append(doc.Tags, tag)
+		}
+	}
+
+	if err := h.store.UpdateDocumentMetadata(doc); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("✏️ Angaben zu '%s' gespeichert (%s)", documentTitle(doc), doc.Name)
+	jsonResponse(w, doc, http.StatusOK)
+}
+
+// documentTitle ist der Anzeigename eines Dokuments: der gesetzte Titel, sonst der Dateiname
+func documentTitle(doc *models.Document) string {
+	if doc.Title != "" {
+		return doc.Title
+	}
+	return doc.Name
+}
+
 func (h *Handler) DeleteDocument(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
@@ -259,6 +476,10 @@ func (h *Handler) DeleteDocument(w http.ResponseWriter, r *http.Request) {
 // === Lernplan Endpoints ===
 
 func (h *Handler) GetStudyPlans(w http.ResponseWriter, r *http.Request) {
+	if h.notModified(w, r, "") {
+		return
+	}
+
 	plans, err := h.store.GetAllStudyPlans()
 	if err != nil {
 		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
@@ -272,54 +493,252 @@ func (h *Handler) GetStudyPlans(w http.ResponseWriter, r *http.Request) {
 var studyPlanMutex sync.Mutex
 var studyPlanInProgress bool
 
-func (h *Handler) CreateStudyPlan(w http.ResponseWriter, r *http.Request) {
-	// Verhindere parallele Requests
+// planRequest enthält die Eingaben für die Analyse eines neuen Lernplans
+type planRequest struct {
+	ExamDate    string   `json:"exam_date"`
+	DocumentIDs []string `json:"document_ids"`
+	ProfileID   string   `json:"profile_id"` // Fachprofil (optional)
+}
+
+// validProfile prüft das Fachprofil einer Anfrage; bei einem unbekannten ist die Antwort bereits geschrieben
+func (h *Handler) validProfile(w http.ResponseWriter, profileID string) bool {
+	if profileID == "" {
+		return true
+	}
+	if _, err := h.store.GetSubjectProfile(profileID); err != nil {
+		errorResponse(w, "Fachprofil nicht gefunden", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// beginPlanCreation sperrt die Lernplan-Erstellung; false = es läuft bereits eine
+func beginPlanCreation() bool {
 	studyPlanMutex.Lock()
+	defer studyPlanMutex.Unlock()
 	if studyPlanInProgress {
-		studyPlanMutex.Unlock()
+		return false
+	}
+	studyPlanInProgress = true
+	return true
+}
+
+func endPlanCreation() {
+	studyPlanMutex.Lock()
+	studyPlanInProgress = false
+	studyPlanMutex.Unlock()
+}
+
+func (h *Handler) CreateStudyPlan(w http.ResponseWriter, r *http.Request) {
+	// Verhindere parallele Requests
+	if !beginPlanCreation() {
 		log.Println("⚠️ Lernplan-Erstellung läuft bereits, ignoriere Anfrage")
 		errorResponse(w, "Lernplan wird bereits erstellt, bitte warten", http.StatusTooManyRequests)
 		return
 	}
-	studyPlanInProgress = true
-	studyPlanMutex.Unlock()
-	
-	defer func() {
-		studyPlanMutex.Lock()
-		studyPlanInProgress = false
-		studyPlanMutex.Unlock()
-	}()
+	defer endPlanCreation()
 
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	log.Println("📋 LERNPLAN ERSTELLEN - Start")
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	
+
+	var req planRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("❌ Fehler: Ungültige Anfrage - %v", err)
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+
+	// Der Zwischenstand wird mitgeschrieben, damit ein Neustart die Erstellung nicht verwirft
+	plan, job := h.analyzePlan(w, r, req, true)
+	if plan == nil {
+		return
+	}
+
+	if err := h.persistPlan(plan); err != nil {
+		job.finish("failed", "", err)
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+	job.finish("done", plan.ID, nil)
+
+	log.Println("")
+	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	log.Println("✅ LERNPLAN ERFOLGREICH ERSTELLT!")
+	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	jsonResponse(w, plan, http.StatusCreated)
+}
+
+// PreviewStudyPlan analysiert die Dokumente und liefert den Lernplan-Vorschlag, ohne ihn zu speichern
+func (h *Handler) PreviewStudyPlan(w http.ResponseWriter, r *http.Request) {
+	if !beginPlanCreation() {
+		log.Println("⚠️ Lernplan-Erstellung läuft bereits, ignoriere Anfrage")
+		errorResponse(w, "Lernplan wird bereits erstellt, bitte warten", http.StatusTooManyRequests)
+		return
+	}
+	defer endPlanCreation()
+
+	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	log.Println("📋 LERNPLAN-VORSCHAU - Start")
+	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	var req planRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+
+	plan, _ := h.analyzePlan(w, r, req, false)
+	if plan == nil {
+		return
+	}
+
+	log.Println("✅ Vorschau erstellt (nicht gespeichert)")
+	jsonResponse(w, planPreview(plan), http.StatusOK)
+}
+
+// ConfirmStudyPlan speichert einen (ggf. vom Nutzer bearbeiteten) Lernplan-Vorschlag
+func (h *Handler) ConfirmStudyPlan(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		ExamDate    string   `json:"exam_date"`
-		DocumentIDs []string `json:"document_ids"`
+		planRequest
+		Name   string         `json:"name"`
+		Topics []models.Topic `json:"topics"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("❌ Fehler: Ungültige Anfrage - %v", err)
 		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
 		return
 	}
 
+	examDate, err := models.ParseDate(req.ExamDate)
+	if err != nil {
+		errorResponse(w, "Ungültiges Datum (Format: YYYY-MM-DD)", http.StatusBadRequest)
+		return
+	}
+	if len(req.DocumentIDs) == 0 {
+		errorResponse(w, "Keine Dokumente angegeben", http.StatusBadRequest)
+		return
+	}
+	req.ProfileID = strings.TrimSpace(req.ProfileID)
+	if !h.validProfile(w, req.ProfileID) {
+		return
+	}
+
+	// Nur Themen mit Namen übernehmen, Werte in gültige Bereiche bringen
+	var topics []models.Topic
+	for _, t := range req.Topics {
+		t.Name = strings.TrimSpace(t.Name)
+		if t.Name == "" {
+			continue
+		}
+		if t.Difficulty < 1 || t.Difficulty > 5 {
+			t.Difficulty = 3
+		}
+		if t.EstMinutes <= 0 {
+			t.EstMinutes = 30
+		}
+		topics = append(topics, t)
+	}
+	if len(topics) == 0 {
+		errorResponse(w, "Der Lernplan braucht mindestens ein Thema", http.StatusBadRequest)
+		return
+	}
+
+	ctx, end := h.beginGeneration(w, r, r.Context())
+	defer end()
+	plan, err := h.tutor.CreateStudyPlan(ctx, topics, examDate, "")
+	if err != nil {
+		generationFailed(w, ctx, fmt.Sprintf("Fehler beim Erstellen des Lernplans: %v", err))
+		return
+	}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		plan.Name = name
+	}
+	plan.Documents, plan.ProfileID = req.DocumentIDs, req.ProfileID
+
+	if err := h.persistPlan(plan); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("✅ Lernplan bestätigt: %s (%d Themen)", plan.Name, len(plan.Topics))
+	jsonResponse(w, plan, http.StatusCreated)
+}
+
+// planPreview ergänzt den Vorschlag um den geplanten Tagesaufwand
+func planPreview(plan *models.StudyPlan) map[string]interface{} {
+	daysUntilExam := max(plan.ExamDate.DaysFrom(time.Now()), 1)
+	minutesPerDay := plan.TotalMinutes / daysUntilExam
+	if minutesPerDay < 30 {
+		minutesPerDay = 30
+	}
+
+	return map[string]interface{}{
+		"plan":            plan,
+		"days_until_exam": daysUntilExam,
+		"minutes_per_day": minutesPerDay,
+	}
+}
+
+// analyzePlan lädt die Dokumente und erstellt per KI einen noch nicht gespeicherten Lernplan.
+// Mit resumable wird der Zwischenstand als PlanJob gespeichert (siehe ResumePlanJobs).
+// Bei Fehlern ist die Antwort bereits geschrieben und der Rückgabewert nil.
+func (h *Handler) analyzePlan(w http.ResponseWriter, r *http.Request, req planRequest, resumable bool) (*models.StudyPlan, *planJobProgress) {
 	log.Printf("📅 Prüfungsdatum: %s", req.ExamDate)
 	log.Printf("📄 Dokument-IDs: %v", req.DocumentIDs)
 
-	examDate, err := time.Parse("2006-01-02", req.ExamDate)
+	examDate, err := models.ParseDate(req.ExamDate)
 	if err != nil {
 		log.Printf("❌ Fehler: Ungültiges Datum - %v", err)
 		errorResponse(w, "Ungültiges Datum (Format: YYYY-MM-DD)", http.StatusBadRequest)
-		return
+		return nil, nil
+	}
+	req.ProfileID = strings.TrimSpace(req.ProfileID)
+	if !h.validProfile(w, req.ProfileID) {
+		return nil, nil
+	}
+
+	docs, allContent := h.loadPlanDocuments(req.DocumentIDs)
+	if len(docs) == 0 {
+		log.Println("❌ Fehler: Keine gültigen Dokumente gefunden")
+		errorResponse(w, "Keine gültigen Dokumente gefunden", http.StatusBadRequest)
+		return nil, nil
+	}
+
+	var job *planJobProgress
+	if resumable {
+		job = h.startPlanJob(req)
+	}
+
+	// Eigener Context mit langem Timeout (nicht abhängig vom HTTP-Request, aber über
+	// /generations/{id}/cancel abbrechbar). Als Hintergrundjob, damit Chat-Nachrichten nicht
+	// hinter der Analyse warten.
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	defer cancel()
+	ctx, end := h.beginGeneration(w, r, llm.WithPriority(ctx, llm.PriorityBackground))
+	defer end()
+
+	plan, err := h.buildPlan(ctx, docs, allContent, examDate, job)
+	if err != nil {
+		status := "failed"
+		if errors.Is(ctx.Err(), context.Canceled) {
+			status = "canceled"
+		}
+		job.finish(status, "", err)
+		generationFailed(w, ctx, err.Error())
+		return nil, nil
 	}
+	plan.Documents, plan.ProfileID = req.DocumentIDs, req.ProfileID
+	return plan, job
+}
 
-	// Dokumente laden
+// loadPlanDocuments lädt die Dokumente eines Lernplans; fehlende werden übersprungen
+func (h *Handler) loadPlanDocuments(ids []string) ([]models.Document, string) {
 	log.Println("📚 Lade Dokumente...")
 	var docs []models.Document
 	var allContent string
-	for _, id := range req.DocumentIDs {
+	for _, id := range ids {
 		doc, err := h.store.GetDocument(id)
 		if err == nil {
 			log.Printf("   ✓ Geladen: %s (%d Zeichen)", doc.Name, len(doc.Content))
@@ -329,36 +748,52 @@ func (h *Handler) CreateStudyPlan(w http.ResponseWriter, r *http.Request) {
 			log.Printf("   ✗ Fehler bei ID %s: %v", id, err)
 		}
 	}
-
-	if len(docs) == 0 {
-		log.Println("❌ Fehler: Keine gültigen Dokumente gefunden")
-		errorResponse(w, "Keine gültigen Dokumente gefunden", http.StatusBadRequest)
-		return
+	if len(docs) > 0 {
+		log.Printf("✓ %d Dokumente geladen, Gesamtinhalt: %d Zeichen", len(docs), len(allContent))
 	}
+	return docs, allContent
+}
 
-	log.Printf("✓ %d Dokumente geladen, Gesamtinhalt: %d Zeichen", len(docs), len(allContent))
-
-	// Eigener Context mit langem Timeout (nicht abhängig vom HTTP-Request)
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
-	defer cancel()
-
+// buildPlan analysiert die Dokumente und erstellt den Lernplan. Mit job werden bereits
+// analysierte Dokumente übernommen und neue Ergebnisse sofort gespeichert.
+func (h *Handler) buildPlan(ctx context.Context, docs []models.Document, allContent string, examDate models.Date, job *planJobProgress) (*models.StudyPlan, error) {
 	// Themen analysieren
-	log.Println("")
-	log.Println("🤖 SCHRITT 1: Analysiere Dokumente mit KI...")
-	log.Printf("   Verwende Modell: %s", h.llm.GetCurrentModel())
-	log.Println("   ⏳ Dies kann einige Minuten dauern (max. 15 Min)...")
-	
-	startAnalyze := time.Now()
-	topics, err := h.tutor.AnalyzeDocuments(ctx, docs)
-	if err != nil {
-		log.Printf("❌ Fehler bei der Analyse: %v", err)
-		errorResponse(w, fmt.Sprintf("Fehler bei der Analyse: %v", err), http.StatusInternalServerError)
-		return
-	}
-	log.Printf("✓ Analyse abgeschlossen in %v", time.Since(startAnalyze))
-	log.Printf("   Gefundene Themen: %d", len(topics))
-	for i, t := range topics {
-		log.Printf("   %d. %s", i+1, t.Name)
+	var topics []models.Topic
+	if job != nil {
+		topics = job.analyzedTopics()
+	}
+	if topics != nil {
+		log.Printf("↩️ SCHRITT 1 übersprungen: %d Themen aus unterbrochenem Lauf", len(topics))
+	} else {
+		log.Println("")
+		log.Println("🤖 SCHRITT 1: Analysiere Dokumente mit KI...")
+		log.Printf("   Verwende Modell: %s", h.llm.GetCurrentModel())
+		if estimate, model := h.tutor.EstimateAnalysis(docs); estimate > 0 {
+			log.Printf("   ⏱️ Voraussichtlich %s (nach früheren Läufen)", llm.FormatETA(estimate, model))
+			job.saveEstimate(estimate, model)
+		} else {
+			log.Println("   ⏳ Dies kann einige Minuten dauern (max. 15 Min)...")
+		}
+
+		analyzeCtx := ctx
+		if job != nil {
+			analyzeCtx = llm.WithAnalysisProgress(ctx, job)
+		}
+		startAnalyze := time.Now()
+		var err error
+		topics, err = h.tutor.AnalyzeDocuments(analyzeCtx, docs)
+		if err != nil {
+			log.Printf("❌ Fehler bei der Analyse: %v", err)
+			return nil, fmt.Errorf("Fehler bei der Analyse: %v", err)
+		}
+		log.Printf("✓ Analyse abgeschlossen in %v", time.Since(startAnalyze))
+		log.Printf("   Gefundene Themen: %d", len(topics))
+		for i, t := range topics {
+			log.Printf("   %d. %s", i+1, t.Name)
+		}
+		if job != nil {
+			job.saveAnalysis(topics)
+		}
 	}
 
 	// Lernplan erstellen
@@ -367,20 +802,22 @@ func (h *Handler) CreateStudyPlan(w http.ResponseWriter, r *http.Request) {
 	plan, err := h.tutor.CreateStudyPlan(ctx, topics, examDate, allContent)
 	if err != nil {
 		log.Printf("❌ Fehler beim Erstellen des Lernplans: %v", err)
-		errorResponse(w, fmt.Sprintf("Fehler beim Erstellen des Lernplans: %v", err), http.StatusInternalServerError)
-		return
+		return nil, fmt.Errorf("Fehler beim Erstellen des Lernplans: %v", err)
 	}
 	log.Printf("✓ Lernplan erstellt: %s", plan.Name)
+	return plan, nil
+}
 
-	plan.Documents = req.DocumentIDs
-
-	// Speichern
+// persistPlan speichert Lernplan, Themen und Lernziele
+func (h *Handler) persistPlan(plan *models.StudyPlan) error {
 	log.Println("")
 	log.Println("💾 SCHRITT 3: Speichere in Datenbank...")
+	if len(plan.Phases) == 0 {
+		plan.Phases = planPhases(plan.CreatedAt, plan.ExamDate)
+	}
 	if err := h.store.SaveStudyPlan(plan); err != nil {
 		log.Printf("❌ Fehler beim Speichern des Lernplans: %v", err)
-		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
-		return
+		return err
 	}
 	log.Println("   ✓ Lernplan gespeichert")
 
@@ -390,15 +827,62 @@ func (h *Handler) CreateStudyPlan(w http.ResponseWriter, r *http.Request) {
 			log.Printf("   ✗ Fehler beim Speichern von Thema '%s': %v", topic.Name, err)
 		} else {
 			log.Printf("   ✓ Thema gespeichert: %s", topic.Name)
+			h.saveObjectives(topic.ID, topic.Objectives)
+			if len(topic.Sources) > 0 {
+				if err := h.store.SaveTopicSources(topic.ID, topic.Sources); err != nil {
+					log.Printf("   ⚠️ Quellen für '%s' nicht gespeichert: %v", topic.Name, err)
+				}
+			}
 		}
 	}
+	return nil
+}
 
-	log.Println("")
-	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	log.Println("✅ LERNPLAN ERFOLGREICH ERSTELLT!")
-	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+// topicContent lädt das Material zu einem Thema: nur die Quellseiten, wenn bekannt,
+// sonst (oder wenn dort nichts zu finden ist) alle Dokumente des Plans
+func (h *Handler) topicContent(topic *models.Topic) string {
+	sources := topic.Sources
+	if len(sources) == 0 && topic.ParentTopicID != "" {
+		// Unterthemen erben die Quellen ihres Kapitels
+		sources, _ = h.store.GetTopicSources(topic.ParentTopicID)
+	}
 
-	jsonResponse(w, plan, http.StatusCreated)
+	var content strings.Builder
+	for _, src := range sources {
+		if src.DocumentID == "" {
+			continue
+		}
+		doc, _ := h.store.GetDocument(src.DocumentID)
+		if doc == nil {
+			continue
+		}
+		text := doc.Content
+		label := documentTitle(doc)
+		if src.PageStart > 0 {
+			pages := pdf.ExtractPages(doc.Content, src.PageStart, src.PageEnd)
+			if pages == "" {
+				continue
+			}
+			text = pages
+			label = fmt.Sprintf("%s, Seiten %d-%d", documentTitle(doc), src.PageStart, max(src.PageEnd, src.PageStart))
+		}
+		content.WriteString(fmt.Sprintf("=== %s ===\n%s\n", label, text))
+	}
+	if content.Len() > 0 {
+		log.Printf("📄 Lade nur Quellseiten für '%s' (%d Zeichen)", topic.Name, content.Len())
+		return content.String()
+	}
+
+	plan, _ := h.store.GetStudyPlan(topic.StudyPlanID)
+	if plan != nil {
+		for _, docID := range plan.Documents {
+			doc, _ := h.store.GetDocument(docID)
+			if doc != nil {
+				content.WriteString(doc.Content + "\n")
+			}
+		}
+	}
+	return content.String()
 }
 
 func (h *Handler) GetActiveStudyPlan(w http.ResponseWriter, r *http.Request) {
@@ -424,6 +908,9 @@ func (h *Handler) GetStudyPlan(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, plan, http.StatusOK)
 }
 
+// planColorPattern: Farben der Lernpläne als #rrggbb, wie sie ein <input type="color"> liefert
+var planColorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
 func (h *Handler) UpdateStudyPlan(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
@@ -431,6 +918,13 @@ func (h *Handler) UpdateStudyPlan(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Status   string  `json:"status"`
 		Progress float64 `json:"progress"`
+		// Nur mitgeschickte Angaben werden geändert
+		Name    *string `json:"name"`
+		Subject *string `json:"subject"`
+		Color   *string `json:"color"`
+		Notes   *string `json:"notes"`
+		// Fachprofil ("" = ohne Profil)
+		ProfileID *string `json:"profile_id"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -438,38 +932,785 @@ func (h *Handler) UpdateStudyPlan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Status != "" {
-		// Status-Update würde hier implementiert
-	}
-	if req.Progress > 0 {
-		h.store.UpdateStudyPlanProgress(id, req.Progress)
-	}
-
-	plan, _ := h.store.GetStudyPlan(id)
-	jsonResponse(w, plan, http.StatusOK)
-}
-
-func (h *Handler) DeleteStudyPlan(w http.ResponseWriter, r *http.Request) {
-	// Implementierung
+	if req.Name != nil || req.Subject != nil || req.Color != nil || req.Notes != nil || req.ProfileID != nil {
+		plan, err := h.store.GetStudyPlan(id)
+		if err != nil {
+			errorResponse(w, "Lernplan nicht gefunden", http.StatusNotFound)
+			return
+		}
+		if req.Name != nil {
+			plan.Name = strings.TrimSpace(*req.Name)
+			if plan.Name == "" {
+				errorResponse(w, "Der Name darf nicht leer sein", http.StatusBadRequest)
+				return
+			}
+		}
+		if req.Subject != nil {
+			plan.Subject = strings.TrimSpace(*req.Subject)
+		}
+		if req.Color != nil {
+			plan.Color = strings.ToLower(strings.TrimSpace(*req.Color))
+			if plan.Color != "" && !planColorPattern.MatchString(plan.Color) {
+				errorResponse(w, "Farbe bitte als #rrggbb angeben", http.StatusBadRequest)
+				return
+			}
+		}
+		if req.Notes != nil {
+			plan.Notes = strings.TrimSpace(*req.Notes)
+		}
+		if req.ProfileID != nil {
+			plan.ProfileID = strings.TrimSpace(*req.ProfileID)
+			if !h.validProfile(w, plan.ProfileID) {
+				return
+			}
+		}
+		if err := h.store.UpdateStudyPlanDetails(plan); err != nil {
+			errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if req.Status != "" {
+		// Status-Update würde hier implementiert
+	}
+	if req.Progress > 0 {
+		h.store.UpdateStudyPlanProgress(id, req.Progress)
+	}
+
+	plan, _ := h.store.GetStudyPlan(id)
+	jsonResponse(w, plan, http.StatusOK)
+}
+
+func (h *Handler) DeleteStudyPlan(w http.ResponseWriter, r *http.Request) {
+	// Implementierung
 	jsonResponse(w, map[string]string{"message": "Lernplan gelöscht"}, http.StatusOK)
 }
 
-// === Themen Endpoints ===
+// === Themen Endpoints ===
+
+func (h *Handler) GetTopic(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	topic, err := h.store.GetTopic(id)
+	if err != nil {
+		errorResponse(w, "Thema nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	jsonResponse(w, topic, http.StatusOK)
+}
+
+func (h *Handler) ExplainTopic(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	topic, err := h.store.GetTopic(id)
+	if err != nil {
+		errorResponse(w, "Thema nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	// Gespeicherte Erklärung wiederverwenden; neu erzeugt (und gespeichert) wird nur ohne
+	// gespeicherte Erklärung oder mit ?refresh=true
+	latest, _ := h.store.GetLatestExplanation(topic.ID)
+	online := h.llmAvailable(r.Context())
+	if latest != nil && (!online || r.URL.Query().Get("refresh") != "true") {
+		if !online {
+			w.Header().Set("X-LLM-Offline", "true")
+		}
+		jsonResponse(w, latest, http.StatusOK)
+		return
+	}
+	if !online {
+		h.llmUnavailable(w, "Erklärung abrufen")
+		return
+	}
+
+	// Dokumentinhalt für Kontext laden (nur die Quellseiten des Themas, wenn bekannt)
+	content := h.topicContent(topic)
+
+	ctx, end := h.beginGeneration(w, r, h.withTopicSettings(r.Context(), topic))
+	defer end()
+	explanation, err := h.tutor.ExplainTopic(ctx, topic, content)
+	if err != nil {
+		generationFailed(w, ctx, fmt.Sprintf("Fehler bei der Erklärung: %v", err))
+		return
+	}
+
+	if !h.storeExplanation(w, r, topic, explanation) {
+		return
+	}
+	jsonResponse(w, explanation, http.StatusOK)
+}
+
+// RegenerateExplanation erklärt ein Thema anders, weil die letzte Erklärung nicht geholfen hat.
+// Body: feedback (z.B. ["too_abstract", "more_examples"]), comment (eigene Anmerkung),
+// explanation_id (Standard: letzte Erklärung des Themas). Jede Variante wird gespeichert.
+func (h *Handler) RegenerateExplanation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req struct {
+		Feedback      []string `json:"feedback"`
+		Comment       string   `json:"comment"`
+		ExplanationID string   `json:"explanation_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+	for _, key := range req.Feedback {
+		if !llm.IsExplanationFeedback(key) {
+			errorResponse(w, "Unbekannte Rückmeldung: "+key+" (too_abstract, more_examples, shorter, simpler, more_detail, analogy)", http.StatusBadRequest)
+			return
+		}
+	}
+	req.Comment = strings.TrimSpace(req.Comment)
+	if comment := []rune(req.Comment); len(comment) > 500 {
+		req.Comment = string(comment[:500])
+	}
+	if len(req.Feedback) == 0 && req.Comment == "" {
+		errorResponse(w, "Bitte feedback oder comment angeben", http.StatusBadRequest)
+		return
+	}
+
+	topic, err := h.store.GetTopic(id)
+	if err != nil {
+		errorResponse(w, "Thema nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	var previous *models.Explanation
+	if req.ExplanationID != "" {
+		previous, err = h.store.GetExplanation(req.ExplanationID)
+		if err != nil || previous.TopicID != topic.ID {
+			errorResponse(w, "Erklärung nicht gefunden", http.StatusNotFound)
+			return
+		}
+	} else {
+		previous, _ = h.store.GetLatestExplanation(topic.ID)
+	}
+
+	content := h.topicContent(topic)
+
+	ctx, end := h.beginGeneration(w, r, h.withTopicSettings(r.Context(), topic))
+	defer end()
+	explanation, err := h.tutor.ExplainTopicDifferently(ctx, topic, content, previous, req.Feedback, req.Comment)
+	if err != nil {
+		generationFailed(w, ctx, fmt.Sprintf("Fehler bei der Erklärung: %v", err))
+		return
+	}
+	if previous != nil {
+		explanation.ParentID = previous.ID
+	}
+	feedback := append([]string{}, req.Feedback...)
+	if req.Comment != "" {
+		feedback = append(feedback, req.Comment)
+	}
+	explanation.Feedback = strings.Join(feedback, "; ")
+
+	if !h.storeExplanation(w, r, topic, explanation) {
+		return
+	}
+	log.Printf("🔁 Neue Erklärung zu '%s' (%s)", topic.Name, explanation.Feedback)
+	jsonResponse(w, explanation, http.StatusCreated)
+}
+
+// GetExplanations listet alle gespeicherten Erklärungen (Varianten) eines Themas
+func (h *Handler) GetExplanations(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	explanations, err := h.store.GetExplanationsByTopic(id)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	if explanations == nil {
+		explanations = []models.Explanation{}
+	}
+	jsonResponse(w, explanations, http.StatusOK)
+}
+
+// storeExplanation prüft eine Erklärung mit dem Inhaltsfilter und speichert sie.
+// Bei einer Blockierung wird die Antwort bereits geschrieben und false zurückgegeben.
+func (h *Handler) storeExplanation(w http.ResponseWriter, r *http.Request, topic *models.Topic, explanation *models.Explanation) bool {
+	// Inhaltsfilter (optional, z.B. für Schulen)
+	if check := h.safety.Check(r.Context(), explanation.Content); !check.Safe {
+		log.Printf("⚠️ Erklärung zu '%s' vom Inhaltsfilter blockiert: %s", topic.Name, check.Reason)
+		errorResponse(w, "Erklärung wurde vom Inhaltsfilter blockiert: "+check.Reason, http.StatusUnprocessableEntity)
+		return false
+	}
+
+	// Erklärung speichern (für Vorlesen und spätere Wiederverwendung)
+	explanation.ID = fmt.Sprintf("exp_%d", time.Now().UnixNano())
+	explanation.CreatedAt = time.Now()
+	if err := h.store.SaveExplanation(explanation); err != nil {
+		log.Printf("⚠️ Erklärung konnte nicht gespeichert werden: %v", err)
+	}
+	return true
+}
+
+func (h *Handler) GetQuestions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	// Optional: Nach Schwierigkeit und kognitiver Stufe filtern
+	difficultyStr := r.URL.Query().Get("difficulty")
+	level := r.URL.Query().Get("level")
+	if level != "" && !llm.IsValidCognitiveLevel(level) {
+		errorResponse(w, "Ungültige kognitive Stufe (remember, understand, apply, analyze)", http.StatusBadRequest)
+		return
+	}
+
+	questions, err := h.store.GetQuestionsByTopic(id)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+
+	// Filtere nach Schwierigkeit wenn angegeben
+	if difficultyStr != "" {
+		difficulty := 0
+		fmt.Sscanf(difficultyStr, "%d", &difficulty)
+		if difficulty > 0 {
+			filtered := make([]models.Question, 0)
+			for _, q := range questions {
+				if q.Difficulty == difficulty {
+					filtered = append(filtered, q)
+				}
+			}
+			questions = filtered
+		}
+	}
+
+	if level != "" {
+		filtered := make([]models.Question, 0)
+		for _, q := range questions {
+			if q.CognitiveLevel == level {
+				filtered = append(filtered, q)
+			}
+		}
+		questions = filtered
+	}
+
+	// Nur markierte Fragen (Wiederholung)
+	if r.URL.Query().Get("flagged") == "true" {
+		flags, _ := h.store.GetFlags("question", id, false)
+		flagged := make(map[string]bool)
+		for _, f := range flags {
+			flagged[f.ItemID] = true
+		}
+		filtered := make([]models.Question, 0)
+		for _, q := range questions {
+			if flagged[q.ID] {
+				filtered = append(filtered, q)
+			}
+		}
+		questions = filtered
+	}
+
+	h.shuffleAll(questions)
+	jsonResponse(w, questions, http.StatusOK)
+}
+
+func (h *Handler) GenerateQuestions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req struct {
+		Difficulty     int    `json:"difficulty"`
+		Count          int    `json:"count"`
+		CognitiveLevel string `json:"cognitive_level"`
+		Type           string `json:"type"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	useDefaultCount := req.Count <= 0 || req.Count > maxQuestionsPerGeneration
+	if useDefaultCount {
+		req.Count = 3 // Standard: 3 Fragen
+	}
+	if req.CognitiveLevel != "" && !llm.IsValidCognitiveLevel(req.CognitiveLevel) {
+		errorResponse(w, "Ungültige kognitive Stufe (remember, understand, apply, analyze)", http.StatusBadRequest)
+		return
+	}
+	if req.Type != "" && req.Type != "open" && req.Type != "multiple_choice" && req.Type != "cloze" && req.Type != "ordering" && req.Type != "image" {
+		errorResponse(w, "Ungültiger Fragetyp (open, multiple_choice, cloze, ordering, image)", http.StatusBadRequest)
+		return
+	}
+
+	topic, err := h.store.GetTopic(id)
+	if err != nil {
+		errorResponse(w, "Thema nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	// Ohne Vorgabe richtet sich die Schwierigkeit nach der Phase des Plans
+	if req.Difficulty == 0 {
+		if phase, settings, ok := h.activePhaseSettings(topic.StudyPlanID); ok {
+			req.Difficulty = settings.difficulty
+			log.Printf("   Phase '%s': Schwierigkeit %d", phase, req.Difficulty)
+		}
+	}
+	if req.Difficulty < 1 || req.Difficulty > 5 {
+		req.Difficulty = 1
+	}
+
+	// Prüfungsschwerpunkte (Syllabus) bekommen standardmäßig mehr Fragen
+	if useDefaultCount && topic.ExamWeight > 1 {
+		req.Count = int(math.Min(10, math.Round(float64(req.Count)*topic.ExamWeight)))
+	}
+
+	questions, err := h.generateTopicQuestions(r.Context(), topic, req.Difficulty, req.Count, req.CognitiveLevel, req.Type)
+	if errors.Is(err, errQuestionsBlocked) {
+		errorResponse(w, "Alle generierten Fragen wurden vom Inhaltsfilter blockiert", http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, errQuestionsDropped) || errors.Is(err, errNoFigures) || errors.Is(err, llm.ErrNoVision) {
+		errorResponse(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler bei der Generierung: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	h.shuffleAll(questions)
+	jsonResponse(w, questions, http.StatusCreated)
+}
+
+// errQuestionsBlocked: der Inhaltsfilter hat alle generierten Fragen verworfen
+var errQuestionsBlocked = errors.New("alle generierten Fragen wurden vom Inhaltsfilter blockiert")
+
+// errQuestionsDropped: ein after_questions-Hook hat alle Fragen verworfen
+var errQuestionsDropped = errors.New("alle generierten Fragen wurden von einem after_questions-Hook verworfen")
+
+// generateTopicQuestions erzeugt Fragen zu einem Thema aus seinen Quellen, filtert sie, sucht die
+// Fundstellen und speichert sie (für POST /topics/{id}/questions/generate und das nächtliche Auffüllen)
+func (h *Handler) generateTopicQuestions(ctx context.Context, topic *models.Topic, difficulty, count int, cognitiveLevel, questionType string) ([]models.Question, error) {
+	ctx = h.withTopicSettings(ctx, topic)
+	var questions []models.Question
+	var err error
+	if questionType == "image" {
+		// Bildfragen zu Abbildungen aus den Quellseiten (Vision-Modell)
+		questions, err = h.generateFigureQuestions(ctx, topic, difficulty, count)
+	} else {
+		// Dokumentinhalt laden (Quellseiten des Themas, wenn bekannt); ohne Typvorgabe mischt das Fachprofil die Typen
+		content := h.topicContent(topic)
+		for _, part := range questionTypeMix(llm.ProfileFrom(ctx), questionType, count) {
+			var generated []models.Question
+			generated, err = h.tutor.GenerateQuestions(ctx, topic, content, difficulty, part.count, cognitiveLevel, part.questionType)
+			if err != nil {
+				break
+			}
+			questions = append(questions, generated...)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Ungeeignete Fragen verwerfen (Inhaltsfilter, optional)
+	if h.safety.Enabled() {
+		var allowed []models.Question
+		for _, q := range questions {
+			if check := h.safety.CheckQuestion(ctx, &q); !check.Safe {
+				log.Printf("⚠️ Frage vom Inhaltsfilter verworfen (%s): %s", check.Reason, q.Question)
+				continue
+			}
+			allowed = append(allowed, q)
+		}
+		if len(allowed) == 0 {
+			return nil, errQuestionsBlocked
+		}
+		questions = allowed
+	}
+
+	// Eigene Filter und Ergänzungen (after_questions-Hooks)
+	if h.hooks.Enabled(hooks.AfterQuestions) {
+		questions = h.hooks.AfterQuestions(ctx, topic, questions)
+		if len(questions) == 0 {
+			return nil, errQuestionsDropped
+		}
+	}
+
+	// Fundstellen im Skript merken ("im Skript zeigen") und Fragen aus alten Klausuren markieren
+	h.locateQuestionSources(topic, questions)
+	h.markExamQuestions(topic, questions)
+
+	// Fragen speichern
+	for _, q := range questions {
+		h.store.SaveQuestion(&q)
+	}
+	return questions, nil
+}
+
+func (h *Handler) UpdateTopicStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req struct {
+		Status   string  `json:"status"`
+		Progress float64 `json:"progress"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+
+	before, _ := h.store.GetTopic(id)
+	if err := h.store.UpdateTopicStatus(id, req.Status, req.Progress); err != nil {
+		errorResponse(w, "Fehler beim Update", http.StatusInternalServerError)
+		return
+	}
+	if req.Status == "completed" && before != nil && before.Status != "completed" {
+		before.Status = req.Status
+		h.emitTopicCompleted(before)
+	}
+
+	// Fortschritt an übergeordnete Kapitel weitergeben
+	if err := h.store.RollUpTopicProgress(id); err != nil {
+		log.Printf("⚠️ Fortschritt konnte nicht hochgerechnet werden: %v", err)
+	}
+
+	jsonResponse(w, map[string]string{"message": "Status aktualisiert"}, http.StatusOK)
+}
+
+// SetTopicParent ordnet ein Thema einem Kapitel unter (leere parent_topic_id = oberste Ebene)
+func (h *Handler) SetTopicParent(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req struct {
+		ParentTopicID string `json:"parent_topic_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+
+	topic, err := h.store.GetTopic(id)
+	if err != nil {
+		errorResponse(w, "Thema nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	if req.ParentTopicID != "" {
+		parent, err := h.store.GetTopic(req.ParentTopicID)
+		if err != nil {
+			errorResponse(w, "Übergeordnetes Thema nicht gefunden", http.StatusNotFound)
+			return
+		}
+		if parent.StudyPlanID != topic.StudyPlanID {
+			errorResponse(w, "Themen müssen zum selben Lernplan gehören", http.StatusBadRequest)
+			return
+		}
+		// Zyklen verhindern: das neue Elternthema darf nicht unterhalb des Themas liegen
+		for p := parent; p != nil; {
+			if p.ID == topic.ID {
+				errorResponse(w, "Ein Thema kann nicht unter sich selbst eingeordnet werden", http.StatusBadRequest)
+				return
+			}
+			if p.ParentTopicID == "" {
+				break
+			}
+			p, _ = h.store.GetTopic(p.ParentTopicID)
+		}
+	}
+
+	oldParentID := topic.ParentTopicID
+	if err := h.store.SetTopicParent(id, req.ParentTopicID); err != nil {
+		errorResponse(w, "Fehler beim Update", http.StatusInternalServerError)
+		return
+	}
+
+	// Fortschritt des alten und neuen Kapitels neu berechnen
+	if oldParentID != "" {
+		if remaining, _ := h.store.GetSubtopics(oldParentID); len(remaining) > 0 {
+			h.store.RollUpTopicProgress(remaining[0].ID)
+		}
+	}
+	h.store.RollUpTopicProgress(id)
+
+	updated, _ := h.store.GetTopic(id)
+	jsonResponse(w, updated, http.StatusOK)
+}
+
+// MergeTopics fasst mehrere Themen zu einem zusammen. Das erste Thema bleibt erhalten,
+// Fragen, Lernziele und Sitzungen der übrigen werden ihm zugeordnet.
+func (h *Handler) MergeTopics(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TopicIDs []string `json:"topic_ids"`
+		Name     string   `json:"name"`
+	}
 
-func (h *Handler) GetTopic(w http.ResponseWriter, r *http.Request) {
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+	if len(req.TopicIDs) < 2 {
+		errorResponse(w, "Mindestens zwei Themen angeben", http.StatusBadRequest)
+		return
+	}
+
+	var topics []*models.Topic
+	seen := make(map[string]bool)
+	for _, id := range req.TopicIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		topic, err := h.store.GetTopic(id)
+		if err != nil {
+			errorResponse(w, fmt.Sprintf("Thema %s nicht gefunden", id), http.StatusNotFound)
+			return
+		}
+		if len(topics) > 0 && topic.StudyPlanID != topics[0].StudyPlanID {
+			errorResponse(w, "Nur Themen desselben Lernplans können zusammengeführt werden", http.StatusBadRequest)
+			return
+		}
+		topics = append(topics, topic)
+	}
+	if len(topics) < 2 {
+		errorResponse(w, "Mindestens zwei verschiedene Themen angeben", http.StatusBadRequest)
+		return
+	}
+
+	target := topics[0]
+	var sourceIDs []string
+	var descriptions []string
+	var weightedProgress float64
+	totalMinutes, completed, started := 0, 0, false
+	for _, t := range topics {
+		if t != target {
+			sourceIDs = append(sourceIDs, t.ID)
+		}
+		if t.Description != "" {
+			descriptions = append(descriptions, t.Description)
+		}
+		if t.Order < target.Order {
+			target.Order = t.Order
+		}
+		if t.Difficulty > target.Difficulty {
+			target.Difficulty = t.Difficulty
+		}
+		totalMinutes += t.EstMinutes
+		weightedProgress += t.Progress * float64(t.EstMinutes)
+		if t.Status == "completed" {
+			completed++
+		}
+		if t.Status != "pending" || t.Progress > 0 {
+			started = true
+		}
+	}
+
+	// Fortschritt nach Lernzeit gewichten, damit kleine Themen nicht überwiegen
+	target.EstMinutes = totalMinutes
+	if totalMinutes > 0 {
+		target.Progress = weightedProgress / float64(totalMinutes)
+	}
+	switch {
+	case completed == len(topics):
+		target.Status = "completed"
+	case started:
+		target.Status = "in_progress"
+	default:
+		target.Status = "pending"
+	}
+	target.Description = strings.Join(descriptions, " ")
+	// Liegt das Ziel unter einem der zusammengeführten Themen, rückt es eine Ebene nach oben
+	for _, t := range topics[1:] {
+		if target.ParentTopicID == t.ID {
+			target.ParentTopicID = t.ParentTopicID
+		}
+	}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		target.Name = name
+	}
+
+	if err := h.store.MergeTopics(target.ID, sourceIDs); err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler beim Zusammenführen: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if err := h.store.SaveTopic(target); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+	h.renumberTopics(target.StudyPlanID)
+	h.store.RollUpTopicProgress(target.ID)
+
+	merged, _ := h.store.GetTopic(target.ID)
+	jsonResponse(w, merged, http.StatusOK)
+}
+
+// SplitTopic teilt ein Thema per KI in Unterthemen auf. Das erste Unterthema übernimmt
+// die ID (und damit Sitzungen, Erklärungen und Lernziele) des ursprünglichen Themas.
+func (h *Handler) SplitTopic(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
+	var req struct {
+		Count int `json:"count"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	if req.Count < 2 || req.Count > 5 {
+		req.Count = 2
+	}
+
 	topic, err := h.store.GetTopic(id)
 	if err != nil {
 		errorResponse(w, "Thema nicht gefunden", http.StatusNotFound)
 		return
 	}
 
-	jsonResponse(w, topic, http.StatusOK)
+	// Dokumentinhalt laden
+	plan, _ := h.store.GetStudyPlan(topic.StudyPlanID)
+	var content string
+	if plan != nil {
+		for _, docID := range plan.Documents {
+			doc, _ := h.store.GetDocument(docID)
+			if doc != nil {
+				content += doc.Content + "\n"
+			}
+		}
+	}
+
+	splits, err := h.tutor.SplitTopic(r.Context(), topic, content, req.Count)
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler beim Aufteilen: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Nachfolgende Themen derselben Ebene nach hinten schieben
+	if siblings, err := h.siblingTopics(topic); err == nil {
+		for _, s := range siblings {
+			if s.Order > topic.Order {
+				h.store.UpdateTopicOrder(s.ID, s.Order+len(splits)-1)
+			}
+		}
+	}
+
+	assigned := make(map[int]bool)
+	var result []models.Topic
+	for i, split := range splits {
+		sub := split.Topic
+		sub.ID = topic.ID
+		if i > 0 {
+			sub.ID = fmt.Sprintf("topic_%d_%d", time.Now().UnixNano(), i)
+		}
+		sub.StudyPlanID = topic.StudyPlanID
+		sub.ParentTopicID = topic.ParentTopicID
+		sub.Order = topic.Order + i
+		// Fortschritt des ursprünglichen Themas gilt für alle Teile
+		sub.Status = topic.Status
+		sub.Progress = topic.Progress
+		if sub.EstMinutes <= 0 {
+			sub.EstMinutes = topic.EstMinutes / len(splits)
+		}
+
+		if err := h.store.SaveTopic(&sub); err != nil {
+			errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+			return
+		}
+
+		// Zugeordnete Fragen verschieben (nicht zugeordnete bleiben beim ersten Teil)
+		var questionIDs []string
+		for _, qi := range split.Questions {
+			if qi >= 0 && qi < len(topic.Questions) && !assigned[qi] {
+				assigned[qi] = true
+				questionIDs = append(questionIDs, topic.Questions[qi].ID)
+			}
+		}
+		if i > 0 && len(questionIDs) > 0 {
+			if err := h.store.MoveQuestions(questionIDs, sub.ID); err != nil {
+				log.Printf("⚠️ Fragen konnten nicht verschoben werden: %v", err)
+			}
+		}
+
+		result = append(result, sub)
+	}
+
+	jsonResponse(w, result, http.StatusCreated)
 }
 
-func (h *Handler) ExplainTopic(w http.ResponseWriter, r *http.Request) {
+// siblingTopics liefert die Themen auf derselben Ebene wie topic (inklusive topic)
+func (h *Handler) siblingTopics(topic *models.Topic) ([]models.Topic, error) {
+	if topic.ParentTopicID != "" {
+		return h.store.GetSubtopics(topic.ParentTopicID)
+	}
+	return h.store.GetTopicsByPlan(topic.StudyPlanID)
+}
+
+// renumberTopics vergibt nach Änderungen wieder eine lückenlose Reihenfolge je Ebene
+func (h *Handler) renumberTopics(planID string) {
+	topics, err := h.store.GetTopicsByPlan(planID)
+	if err != nil {
+		return
+	}
+	renumberLevel(h.store, topics)
+}
+
+func renumberLevel(store storage.Storage, topics []models.Topic) {
+	for i, t := range topics {
+		if t.Order != i+1 {
+			store.UpdateTopicOrder(t.ID, i+1)
+		}
+		renumberLevel(store, t.Subtopics)
+	}
+}
+
+// leafTopics liefert alle Themen ohne Unterthemen (die eigentlichen Lerneinheiten)
+func leafTopics(topics []models.Topic) []models.Topic {
+	var leaves []models.Topic
+	for _, t := range topics {
+		if len(t.Subtopics) == 0 {
+			leaves = append(leaves, t)
+			continue
+		}
+		leaves = append(leaves, leafTopics(t.Subtopics)...)
+	}
+	return leaves
+}
+
+// saveObjectives speichert die Lernziele eines Themas mit fortlaufenden IDs
+func (h *Handler) saveObjectives(topicID string, objectives []models.LearningObjective) {
+	for i := range objectives {
+		objectives[i].ID = fmt.Sprintf("obj_%d_%d", time.Now().UnixNano(), i)
+		objectives[i].TopicID = topicID
+		if err := h.store.SaveObjective(&objectives[i]); err != nil {
+			log.Printf("   ✗ Fehler beim Speichern von Lernziel '%s': %v", objectives[i].Text, err)
+		}
+	}
+}
+
+func (h *Handler) GetObjectives(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	objectives, err := h.store.GetObjectivesByTopic(id)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+
+	achieved := 0
+	for _, obj := range objectives {
+		if obj.Achieved {
+			achieved++
+		}
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"objectives": objectives,
+		"total":      len(objectives),
+		"achieved":   achieved,
+	}, http.StatusOK)
+}
+
+// GenerateObjectives erstellt die Lernziele eines Themas neu (z.B. für ältere Lernpläne)
+func (h *Handler) GenerateObjectives(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
@@ -479,7 +1720,7 @@ func (h *Handler) ExplainTopic(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Dokumentinhalt für Kontext laden
+	// Dokumentinhalt laden
 	plan, _ := h.store.GetStudyPlan(topic.StudyPlanID)
 	var content string
 	if plan != nil {
@@ -491,121 +1732,433 @@ func (h *Handler) ExplainTopic(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	ctx := r.Context()
-	explanation, err := h.tutor.ExplainTopic(ctx, topic, content)
+	objectives, err := h.tutor.GenerateObjectives(r.Context(), topic, content)
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler bei der Generierung: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if len(objectives) == 0 {
+		errorResponse(w, "Keine Lernziele erhalten", http.StatusInternalServerError)
+		return
+	}
+
+	// Bestehende Lernziele ersetzen
+	if err := h.store.DeleteObjectivesByTopic(id); err != nil {
+		errorResponse(w, "Fehler beim Löschen", http.StatusInternalServerError)
+		return
+	}
+	h.saveObjectives(id, objectives)
+
+	jsonResponse(w, objectives, http.StatusCreated)
+}
+
+// UpdateObjective hakt ein Lernziel ab (Selbsteinschätzung)
+func (h *Handler) UpdateObjective(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req struct {
+		Achieved bool `json:"achieved"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.store.SetObjectiveAchieved(id, req.Achieved); err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, "Lernziel nicht gefunden", http.StatusNotFound)
+			return
+		}
+		errorResponse(w, "Fehler beim Update", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"message":  "Lernziel aktualisiert",
+		"achieved": req.Achieved,
+	}, http.StatusOK)
+}
+
+// === Fragen Endpoints ===
+
+func (h *Handler) GetQuestion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	question, err := h.store.GetQuestion(id)
+	if err != nil {
+		errorResponse(w, "Frage nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	h.shuffleOptions(question)
+	jsonResponse(w, question, http.StatusOK)
+}
+
+func (h *Handler) SubmitAnswer(w http.ResponseWriter, r *http.Request) {
+	sub, ok := h.decodeAnswer(w, r)
+	if !ok {
+		return
+	}
+	question := sub.question
+
+	if llm.NeedsEvaluation(question, sub.answer) {
+		if sub.selfCorrect != nil {
+			jsonResponse(w, h.recordAnswer(sub, selfAssessment(question, *sub.selfCorrect)), http.StatusOK)
+			return
+		}
+		if !h.llmAvailable(r.Context()) {
+			h.llmUnavailable(w, "Bewertung offener Antworten")
+			return
+		}
+	}
+
+	// Dokumentinhalt für Bewertung laden
+	topic, _ := h.store.GetTopic(question.TopicID)
+	var content string
+	if topic != nil {
+		plan, _ := h.store.GetStudyPlan(topic.StudyPlanID)
+		if plan != nil {
+			for _, docID := range plan.Documents {
+				doc, _ := h.store.GetDocument(docID)
+				if doc != nil {
+					content += doc.Content + "\n"
+				}
+			}
+		}
+	}
+
+	ctx := h.withQuestionProfile(r.Context(), question)
+	eval, err := h.tutor.EvaluateAnswer(ctx, question, sub.answer, content)
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler bei der Bewertung: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, h.recordAnswer(sub, eval), http.StatusOK)
+}
+
+// SubmitAnswerStream bewertet eine Antwort und streamt das Feedback per Server-Sent Events:
+// "feedback"-Events mit Textstücken, am Ende ein "result"-Event mit dem Ergebnis
+func (h *Handler) SubmitAnswerStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		errorResponse(w, "Streaming nicht unterstützt", http.StatusInternalServerError)
+		return
+	}
+
+	sub, ok := h.decodeAnswer(w, r)
+	if !ok {
+		return
+	}
+	selfAssessed := sub.selfCorrect != nil && llm.NeedsEvaluation(sub.question, sub.answer)
+	if !selfAssessed && llm.NeedsEvaluation(sub.question, sub.answer) && !h.llmAvailable(r.Context()) {
+		h.llmUnavailable(w, "Bewertung offener Antworten")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	if selfAssessed {
+		writeSSE(w, flusher, "result", h.recordAnswer(sub, selfAssessment(sub.question, *sub.selfCorrect)))
+		return
+	}
+
+	eval, err := h.tutor.EvaluateAnswerStream(h.withQuestionProfile(r.Context(), sub.question), sub.question, sub.answer, func(text string) {
+		writeSSE(w, flusher, "feedback", map[string]string{"content": text})
+	})
 	if err != nil {
-		errorResponse(w, fmt.Sprintf("Fehler bei der Erklärung: %v", err), http.StatusInternalServerError)
+		writeSSE(w, flusher, "error", map[string]string{"error": fmt.Sprintf("Fehler bei der Bewertung: %v", err)})
 		return
 	}
 
-	jsonResponse(w, explanation, http.StatusOK)
+	writeSSE(w, flusher, "result", h.recordAnswer(sub, *eval))
+}
+
+// writeSSE schreibt ein Server-Sent Event und sendet es sofort
+func writeSSE(w http.ResponseWriter, flusher http.Flusher, event string, data interface{}) {
+	payload, _ := json.Marshal(data)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	flusher.Flush()
+}
+
+// submittedAnswer ist eine eingereichte Antwort samt serverseitig gemessener Antwortzeit
+type submittedAnswer struct {
+	question  *models.Question
+	answer    string
+	hintsUsed int
+	seconds   *int // nil = nicht gemessen
+	late      bool
+	timeLimit int
+	// Selbstbewertung statt LLM-Bewertung (nil = vom LLM bewerten lassen)
+	selfCorrect *bool
+}
+
+// decodeAnswer liest eine Antwort-Anfrage, löst gewählte Optionen auf und misst die
+// Antwortzeit (vor der Bewertung, damit die LLM-Laufzeit nicht mitzählt).
+// Bei Fehlern ist die Antwort bereits geschrieben und ok false.
+func (h *Handler) decodeAnswer(w http.ResponseWriter, r *http.Request) (*submittedAnswer, bool) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req struct {
+		Answer      string `json:"answer"`
+		Option      *int   `json:"option"`       // Index der angezeigten (gemischten) Option
+		OptionToken string `json:"option_token"` // Token aus GET /questions
+		Order       []int  `json:"order"`        // Reihenfolge-Fragen: Indizes der angezeigten Optionen in gewählter Folge
+		HintsUsed   int    `json:"hints_used"`   // Anzahl aufgedeckter Hinweise
+		SelfCorrect *bool  `json:"self_correct"` // Selbstbewertung offener Antworten (z.B. offline)
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return nil, false
+	}
+
+	question, err := h.store.GetQuestion(id)
+	if err != nil {
+		errorResponse(w, "Frage nicht gefunden", http.StatusNotFound)
+		return nil, false
+	}
+
+	// Gewählte Option über die Zuordnung auf die gespeicherte Reihenfolge abbilden
+	if req.Option != nil {
+		answer, err := h.resolveOption(question, req.OptionToken, *req.Option)
+		if err != nil {
+			errorResponse(w, err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+		req.Answer = answer
+	}
+	if req.Order != nil {
+		answer, err := h.resolveOrder(question, req.OptionToken, req.Order)
+		if err != nil {
+			errorResponse(w, err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+		req.Answer = answer
+	}
+
+	sub := h.newSubmittedAnswer(question, req.Answer, req.HintsUsed)
+	sub.selfCorrect = req.SelfCorrect
+	return sub, true
+}
+
+// selfAssessment übernimmt die eigene Einschätzung einer offenen Antwort als Bewertung
+func selfAssessment(q *models.Question, correct bool) llm.AnswerEvaluation {
+	if correct {
+		return llm.AnswerEvaluation{IsCorrect: true, Score: 100, Feedback: "✅ Selbst als richtig bewertet. Erwartet war: " + q.ExpectedAnswer}
+	}
+	return llm.AnswerEvaluation{Feedback: "💡 Die richtige Antwort ist: " + q.ExpectedAnswer}
+}
+
+func (h *Handler) newSubmittedAnswer(question *models.Question, answer string, hintsUsed int) *submittedAnswer {
+	sub := &submittedAnswer{question: question, answer: answer, hintsUsed: max(hintsUsed, 0)}
+	sub.seconds, sub.late, sub.timeLimit = h.answerTiming(question)
+	return sub
+}
+
+// recordAnswer speichert die Antwort als neuen Versuch (plus letzte Antwort an der Frage)
+// und baut das Ergebnis für den Client
+func (h *Handler) recordAnswer(sub *submittedAnswer, eval llm.AnswerEvaluation) map[string]interface{} {
+	q := sub.question
+	h.store.SaveQuestionAnswer(q.ID, sub.answer, eval.IsCorrect, eval.Feedback)
+	if sub.seconds != nil {
+		h.store.SaveAnswerTiming(q.ID, *sub.seconds, sub.late)
+	}
+
+	attempt := &models.QuestionAttempt{
+		ID:            fmt.Sprintf("att_%d_%s", time.Now().UnixNano(), q.ID),
+		QuestionID:    q.ID,
+		TopicID:       q.TopicID,
+		Answer:        sub.answer,
+		IsCorrect:     eval.IsCorrect,
+		Score:         eval.Score,
+		Feedback:      eval.Feedback,
+		HintsUsed:     sub.hintsUsed,
+		AnswerSeconds: sub.seconds,
+		AnsweredLate:  sub.late,
+		ErrorType:     eval.ErrorType,
+		CreatedAt:     time.Now(),
+	}
+	if err := h.store.SaveAttempt(attempt); err != nil {
+		log.Printf("⚠️ Antwortversuch konnte nicht gespeichert werden: %v", err)
+	}
+	h.emitAnswered(q, attempt)
+
+	// Richtig beantwortet: Die Markierung zur Wiederholung ist erledigt
+	flagResolved := false
+	if eval.IsCorrect {
+		if flag, err := h.store.GetOpenFlag("question", q.ID); err == nil {
+			if n, err := h.store.ResolveFlags([]string{flag.ID}); err != nil {
+				log.Printf("⚠️ Markierung konnte nicht erledigt werden: %v", err)
+			} else {
+				flagResolved = n > 0
+			}
+		}
+	}
+
+	result := map[string]interface{}{
+		"is_correct":         eval.IsCorrect,
+		"feedback":           eval.Feedback,
+		"expected":           q.ExpectedAnswer,
+		"answer_seconds":     sub.seconds,
+		"time_limit_seconds": sub.timeLimit,
+		"late":               sub.late,
+		"attempt_id":         attempt.ID,
+	}
+	if eval.Score > 0 {
+		result["score"] = eval.Score
+	}
+	if eval.ErrorType != "" {
+		result["error_type"] = eval.ErrorType
+		result["error_label"] = errorTypeLabels[eval.ErrorType]
+	}
+	if flagResolved {
+		result["flag_resolved"] = true
+	}
+	return result
 }
 
-func (h *Handler) GetQuestions(w http.ResponseWriter, r *http.Request) {
+// GetQuestionAttempts liefert alle Antwortversuche einer Frage
+func (h *Handler) GetQuestionAttempts(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
-	
-	// Optional: Nach Schwierigkeit filtern
-	difficultyStr := r.URL.Query().Get("difficulty")
 
-	questions, err := h.store.GetQuestionsByTopic(id)
+	if _, err := h.store.GetQuestion(id); err != nil {
+		errorResponse(w, "Frage nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	attempts, err := h.store.GetAttempts(id)
 	if err != nil {
 		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
 		return
 	}
-	
-	// Filtere nach Schwierigkeit wenn angegeben
-	if difficultyStr != "" {
-		difficulty := 0
-		fmt.Sscanf(difficultyStr, "%d", &difficulty)
-		if difficulty > 0 {
-			filtered := make([]models.Question, 0)
-			for _, q := range questions {
-				if q.Difficulty == difficulty {
-					filtered = append(filtered, q)
-				}
-			}
-			questions = filtered
-		}
+	if attempts == nil {
+		attempts = []models.QuestionAttempt{}
 	}
 
-	jsonResponse(w, questions, http.StatusOK)
+	jsonResponse(w, attempts, http.StatusOK)
 }
 
-func (h *Handler) GenerateQuestions(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
+// maxBatchAnswers begrenzt die Anzahl der Antworten pro Sammelbewertung
+const maxBatchAnswers = 20
 
+// SubmitAnswersBatch bewertet mehrere Antworten in einem Request mit einem LLM-Aufruf
+func (h *Handler) SubmitAnswersBatch(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Difficulty int `json:"difficulty"`
-		Count      int `json:"count"`
+		Answers []struct {
+			QuestionID  string `json:"question_id"`
+			Answer      string `json:"answer"`
+			Option      *int   `json:"option"`
+			OptionToken string `json:"option_token"`
+			Order       []int  `json:"order"`
+			HintsUsed   int    `json:"hints_used"`
+		} `json:"answers"`
 	}
-	json.NewDecoder(r.Body).Decode(&req)
-	if req.Difficulty < 1 || req.Difficulty > 5 {
-		req.Difficulty = 1
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
 	}
-	if req.Count <= 0 || req.Count > 10 {
-		req.Count = 3 // Standard: 3 Fragen
+	if len(req.Answers) == 0 {
+		errorResponse(w, "Keine Antworten angegeben", http.StatusBadRequest)
+		return
 	}
-
-	topic, err := h.store.GetTopic(id)
-	if err != nil {
-		errorResponse(w, "Thema nicht gefunden", http.StatusNotFound)
+	if len(req.Answers) > maxBatchAnswers {
+		errorResponse(w, fmt.Sprintf("Maximal %d Antworten pro Anfrage", maxBatchAnswers), http.StatusBadRequest)
 		return
 	}
 
-	// Dokumentinhalt laden
-	plan, _ := h.store.GetStudyPlan(topic.StudyPlanID)
-	var content string
-	if plan != nil {
-		for _, docID := range plan.Documents {
-			doc, _ := h.store.GetDocument(docID)
-			if doc != nil {
-				content += doc.Content + "\n"
+	subs := make([]*submittedAnswer, len(req.Answers))
+	questions := make([]*models.Question, len(req.Answers))
+	answers := make([]string, len(req.Answers))
+	for i, a := range req.Answers {
+		question, err := h.store.GetQuestion(a.QuestionID)
+		if err != nil {
+			errorResponse(w, fmt.Sprintf("Frage %s nicht gefunden", a.QuestionID), http.StatusNotFound)
+			return
+		}
+		answers[i] = a.Answer
+		if a.Option != nil {
+			answer, err := h.resolveOption(question, a.OptionToken, *a.Option)
+			if err != nil {
+				errorResponse(w, fmt.Sprintf("Frage %s: %v", a.QuestionID, err), http.StatusBadRequest)
+				return
 			}
+			answers[i] = answer
 		}
+		if a.Order != nil {
+			answer, err := h.resolveOrder(question, a.OptionToken, a.Order)
+			if err != nil {
+				errorResponse(w, fmt.Sprintf("Frage %s: %v", a.QuestionID, err), http.StatusBadRequest)
+				return
+			}
+			answers[i] = answer
+		}
+		questions[i] = question
+		subs[i] = h.newSubmittedAnswer(question, answers[i], a.HintsUsed)
 	}
 
-	ctx := r.Context()
-	questions, err := h.tutor.GenerateQuestions(ctx, topic, content, req.Difficulty, req.Count)
+	for i, q := range questions {
+		if llm.NeedsEvaluation(q, answers[i]) && !h.llmAvailable(r.Context()) {
+			h.llmUnavailable(w, "Bewertung offener Antworten")
+			return
+		}
+	}
+
+	// Die Strenge richtet sich nach dem Fachprofil der ersten Frage (ein Batch stammt aus einer Sitzung)
+	evaluations, err := h.tutor.EvaluateAnswersBatch(h.withQuestionProfile(r.Context(), questions[0]), questions, answers)
 	if err != nil {
-		errorResponse(w, fmt.Sprintf("Fehler bei der Generierung: %v", err), http.StatusInternalServerError)
+		errorResponse(w, fmt.Sprintf("Fehler bei der Bewertung: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// Fragen speichern
-	for _, q := range questions {
-		h.store.SaveQuestion(&q)
+	results := make([]map[string]interface{}, len(questions))
+	correct := 0
+	for i, q := range questions {
+		if evaluations[i].IsCorrect {
+			correct++
+		}
+		results[i] = h.recordAnswer(subs[i], evaluations[i])
+		results[i]["question_id"] = q.ID
 	}
 
-	jsonResponse(w, questions, http.StatusCreated)
+	jsonResponse(w, map[string]interface{}{
+		"results": results,
+		"correct": correct,
+		"total":   len(results),
+	}, http.StatusOK)
 }
 
-func (h *Handler) UpdateTopicStatus(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
-
-	var req struct {
-		Status   string  `json:"status"`
-		Progress float64 `json:"progress"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
-		return
+// answerTiming misst die Antwortzeit serverseitig (nur wenn die Frage gestartet wurde, sonst nil)
+func (h *Handler) answerTiming(q *models.Question) (seconds *int, late bool, limit int) {
+	limit = h.timeLimitFor(q)
+	if q.StartedAt != nil {
+		measured := int(time.Since(*q.StartedAt).Seconds())
+		seconds = &measured
+		late = limit > 0 && measured > limit
 	}
+	return seconds, late, limit
+}
 
-	if err := h.store.UpdateTopicStatus(id, req.Status, req.Progress); err != nil {
-		errorResponse(w, "Fehler beim Update", http.StatusInternalServerError)
-		return
+// timeLimitFor liefert das Zeitlimit einer Frage (eigenes Limit vor Standard je Schwierigkeit)
+func (h *Handler) timeLimitFor(q *models.Question) int {
+	if q.TimeLimit > 0 {
+		return q.TimeLimit
 	}
-
-	jsonResponse(w, map[string]string{"message": "Status aktualisiert"}, http.StatusOK)
+	return h.config.AnswerTimeLimits[q.Difficulty]
 }
 
-// === Fragen Endpoints ===
-
-func (h *Handler) GetQuestion(w http.ResponseWriter, r *http.Request) {
+// StartQuestion startet die Zeitmessung für eine Frage
+func (h *Handler) StartQuestion(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
@@ -615,67 +2168,67 @@ func (h *Handler) GetQuestion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jsonResponse(w, question, http.StatusOK)
-}
-
-func (h *Handler) SubmitAnswer(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
-
-	var req struct {
-		Answer string `json:"answer"`
+	startedAt := time.Now()
+	if err := h.store.MarkQuestionStarted(id, startedAt); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
-		return
+	resp := map[string]interface{}{
+		"question_id":        id,
+		"started_at":         startedAt,
+		"time_limit_seconds": 0,
+	}
+	if limit := h.timeLimitFor(question); limit > 0 {
+		resp["time_limit_seconds"] = limit
+		resp["deadline"] = startedAt.Add(time.Duration(limit) * time.Second)
 	}
 
-	question, err := h.store.GetQuestion(id)
+	jsonResponse(w, resp, http.StatusOK)
+}
+
+// queryPlanID liest ?plan_id= und nimmt ohne Angabe den aktiven Lernplan.
+// Bei Fehlern ist die Antwort bereits geschrieben und ok false.
+func (h *Handler) queryPlanID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if planID := r.URL.Query().Get("plan_id"); planID != "" {
+		return planID, true
+	}
+	plan, err := h.store.GetActiveStudyPlan()
 	if err != nil {
-		errorResponse(w, "Frage nicht gefunden", http.StatusNotFound)
-		return
+		errorResponse(w, "Kein aktiver Lernplan", http.StatusNotFound)
+		return "", false
 	}
+	return plan.ID, true
+}
 
-	// Dokumentinhalt für Bewertung laden
-	topic, _ := h.store.GetTopic(question.TopicID)
-	var content string
-	if topic != nil {
-		plan, _ := h.store.GetStudyPlan(topic.StudyPlanID)
-		if plan != nil {
-			for _, docID := range plan.Documents {
-				doc, _ := h.store.GetDocument(docID)
-				if doc != nil {
-					content += doc.Content + "\n"
-				}
-			}
-		}
+// GetAnswerSpeed liefert die durchschnittliche Antwortzeit pro Thema
+func (h *Handler) GetAnswerSpeed(w http.ResponseWriter, r *http.Request) {
+	planID, ok := h.queryPlanID(w, r)
+	if !ok {
+		return
 	}
 
-	ctx := r.Context()
-	isCorrect, feedback, err := h.tutor.EvaluateAnswer(ctx, question, req.Answer, content)
+	stats, err := h.store.GetAnswerSpeedStats(planID)
 	if err != nil {
-		errorResponse(w, fmt.Sprintf("Fehler bei der Bewertung: %v", err), http.StatusInternalServerError)
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
 		return
 	}
 
-	// Antwort speichern
-	h.store.SaveQuestionAnswer(id, req.Answer, isCorrect, feedback)
-
-	jsonResponse(w, map[string]interface{}{
-		"is_correct": isCorrect,
-		"feedback":   feedback,
-		"expected":   question.ExpectedAnswer,
-	}, http.StatusOK)
+	jsonResponse(w, stats, http.StatusOK)
 }
 
 // === Chat Endpoints ===
 
+// chatContextChars: Umfang des Materials, das dem Chat mitgegeben wird (wie im Tutor-Prompt)
+const chatContextChars = 6000
+
 func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Message   string `json:"message"`
-		TopicID   string `json:"topic_id"`
-		SessionID string `json:"session_id"`
+		Message      string `json:"message"`
+		TopicID      string `json:"topic_id"`
+		SessionID    string `json:"session_id"`
+		Mode         string `json:"mode"`
+		IncludeNotes bool   `json:"include_notes"` // eigene Notizen zum Thema als Kontext mitgeben
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -683,22 +2236,37 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Topic und Kontext laden
-	topic, _ := h.store.GetTopic(req.TopicID)
-	if topic == nil {
-		topic = &models.Topic{Name: "Allgemein", Description: "Allgemeine Lernfragen"}
+	if req.Mode != "" && !isValidChatMode(req.Mode) {
+		errorResponse(w, fmt.Sprintf("Unbekannter Chat-Modus '%s'", req.Mode), http.StatusBadRequest)
+		return
 	}
 
-	var content string
-	if topic.StudyPlanID != "" {
-		plan, _ := h.store.GetStudyPlan(topic.StudyPlanID)
-		if plan != nil {
-			for _, docID := range plan.Documents {
-				doc, _ := h.store.GetDocument(docID)
-				if doc != nil {
-					content += doc.Content + "\n"
-				}
+	ctx, end := h.beginGeneration(w, r, r.Context())
+	defer end()
+
+	// Modus der Sitzung bestimmen (neue Sitzungen werden beim ersten Kontakt angelegt)
+	mode := req.Mode
+	if req.SessionID != "" {
+		session, err := h.store.GetChatSession(req.SessionID)
+		if err != nil {
+			session = &models.ChatSession{
+				ID:        req.SessionID,
+				Mode:      chatModeOrDefault(req.Mode),
+				TopicID:   req.TopicID,
+				CreatedAt: time.Now(),
 			}
+			h.store.SaveChatSession(session)
+		}
+		mode = session.Mode
+	}
+
+	// Modus "general" (Sitzung oder einzelne Nachricht): passendes Thema aus allen Lernplänen selbst finden
+	var route *chatRoute
+	if (mode == "general" || req.Mode == "general") && req.TopicID == "" {
+		route = h.routeChatTopic(ctx, req.Message)
+		if route != nil {
+			req.TopicID = route.TopicID
+			log.Printf("🧭 Chat-Frage dem Thema '%s' (%s) zugeordnet (%s, %.2f)", route.TopicName, route.PlanName, route.Method, route.Score)
 		}
 	}
 
@@ -720,15 +2288,16 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 		Content: req.Message,
 	})
 
-	ctx := r.Context()
-	resp, err := h.tutor.ChatWithContext(ctx, messages, content, topic)
+	resp, err := h.topicChatReply(ctx, req.TopicID, req.SessionID, mode, req.IncludeNotes, messages)
 	if err != nil {
-		errorResponse(w, fmt.Sprintf("Chat-Fehler: %v", err), http.StatusInternalServerError)
+		generationFailed(w, ctx, fmt.Sprintf("Chat-Fehler: %v", err))
 		return
 	}
 
 	// Nachrichten speichern
+	answerID := ""
 	if req.SessionID != "" {
+		answerID = fmt.Sprintf("msg_%d", time.Now().UnixNano()+1)
 		h.store.SaveChatMessage(&models.ChatMessage{
 			ID:        fmt.Sprintf("msg_%d", time.Now().UnixNano()),
 			SessionID: req.SessionID,
@@ -738,7 +2307,7 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 			TopicID:   req.TopicID,
 		})
 		h.store.SaveChatMessage(&models.ChatMessage{
-			ID:        fmt.Sprintf("msg_%d", time.Now().UnixNano()+1),
+			ID:        answerID,
 			SessionID: req.SessionID,
 			Role:      "assistant",
 			Content:   resp.Content,
@@ -748,14 +2317,172 @@ func (h *Handler) Chat(w http.ResponseWriter, r *http.Request) {
 	}
 
 	jsonResponse(w, map[string]interface{}{
-		"response": resp.Content,
-		"model":    resp.Model,
+		"response":   resp.Content,
+		"model":      resp.Model,
+		"mode":       chatModeOrDefault(mode),
+		"message_id": answerID, // für /chat/messages/{id}/to-flashcard und /to-glossary
+		"routed":     route,    // nur im Modus "general": zugeordnetes Thema (null = keins passte)
 	}, http.StatusOK)
 }
 
+// topicChatReply beantwortet die letzte Nachricht in messages mit den Materialien eines Themas
+// (leer = allgemeine Lernfragen) und dem angehefteten Kontext der Sitzung
+func (h *Handler) topicChatReply(ctx context.Context, topicID, sessionID, mode string, includeNotes bool, messages []llm.ChatMessage) (*llm.GenerateResponse, error) {
+	message := messages[len(messages)-1].Content
+
+	// Topic und Kontext laden
+	topic, _ := h.store.GetTopic(topicID)
+	if topic == nil {
+		topic = &models.Topic{Name: "Allgemein", Description: "Allgemeine Lernfragen"}
+	}
+
+	// Materialien: Dokumente des Lernplans und importierte Notizen, die auf das Thema verweisen
+	var docs, notes []*models.Document
+	included := make(map[string]bool)
+	if topic.StudyPlanID != "" {
+		plan, _ := h.store.GetStudyPlan(topic.StudyPlanID)
+		if plan != nil {
+			for _, docID := range plan.Documents {
+				doc, _ := h.store.GetDocument(docID)
+				if doc != nil {
+					docs = append(docs, doc)
+					included[doc.ID] = true
+				}
+			}
+		}
+	}
+	if topic.ID != "" {
+		notes = h.linkedNotes(topic, included)
+		docs = append(docs, notes...)
+	}
+
+	var content string
+	var chunks []pdf.Chunk
+	for _, doc := range docs {
+		content += doc.Content + "\n"
+		for _, c := range pdf.ExtractChunks(doc.Content, h.config.ChunkTokens) {
+			if c.Section == "" {
+				c.Section = documentTitle(doc)
+			} else {
+				c.Section = documentTitle(doc) + " – " + c.Section
+			}
+			chunks = append(chunks, c)
+		}
+	}
+	// Angehefteter Kontext der Sitzung steht immer vorn und verkleinert den Platz für die Suche
+	pinned := h.pinnedContext(sessionID)
+	budget := max(chatContextChars-len(pinned), chatContextChars/3)
+
+	// Lange Materialien: nur die zur Frage passenden Abschnitte statt des Anfangs mitgeben
+	if len(content) > budget {
+		content = pdf.FormatChunks(pdf.RelevantChunks(chunks, topic.Name+" "+message, budget))
+	}
+	content += h.noteGlossaryContext(notes)
+
+	if includeNotes && topic.ID != "" {
+		content += h.notesContext(topic.ID)
+	}
+	content = pinned + content
+
+	if mode == "socratic" {
+		return h.tutor.ChatSocratic(ctx, messages, content, topic)
+	}
+	return h.tutor.ChatWithContext(ctx, messages, content, topic)
+}
+
+// isValidChatMode prüft, ob ein Chat-Modus unterstützt wird
+func isValidChatMode(mode string) bool {
+	return mode == "standard" || mode == "socratic" || mode == "general"
+}
+
+func chatModeOrDefault(mode string) string {
+	if mode == "" {
+		return "standard"
+	}
+	return mode
+}
+
+// CreateChatSession legt eine neue Chat-Sitzung mit gewähltem Modus an
+func (h *Handler) CreateChatSession(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Mode    string `json:"mode"`
+		TopicID string `json:"topic_id"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	if req.Mode != "" && !isValidChatMode(req.Mode) {
+		errorResponse(w, fmt.Sprintf("Unbekannter Chat-Modus '%s'", req.Mode), http.StatusBadRequest)
+		return
+	}
+
+	session := &models.ChatSession{
+		ID:        fmt.Sprintf("chat_%d", time.Now().UnixNano()),
+		Mode:      chatModeOrDefault(req.Mode),
+		TopicID:   req.TopicID,
+		CreatedAt: time.Now(),
+	}
+
+	if err := h.store.SaveChatSession(session); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, session, http.StatusCreated)
+}
+
+func (h *Handler) GetChatSession(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	session, err := h.store.GetChatSession(id)
+	if err != nil {
+		errorResponse(w, "Chat-Sitzung nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	jsonResponse(w, session, http.StatusOK)
+}
+
+// UpdateChatSession ändert den Modus einer bestehenden Chat-Sitzung
+func (h *Handler) UpdateChatSession(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req struct {
+		Mode string `json:"mode"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+
+	if !isValidChatMode(req.Mode) {
+		errorResponse(w, fmt.Sprintf("Unbekannter Chat-Modus '%s'", req.Mode), http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.store.GetChatSession(id)
+	if err != nil {
+		errorResponse(w, "Chat-Sitzung nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	session.Mode = req.Mode
+	if err := h.store.SaveChatSession(session); err != nil {
+		errorResponse(w, "Fehler beim Update", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, session, http.StatusOK)
+}
+
 func (h *Handler) ChatStream(w http.ResponseWriter, r *http.Request) {
+	// Abbrechbar über ?generation_id= (Browser können beim WebSocket keine Header setzen)
+	ctx, end := h.beginGeneration(w, r, llm.WithPriority(r.Context(), llm.PriorityInteractive))
+	defer end()
+
 	// WebSocket für Streaming
-	conn, err := h.upgrader.Upgrade(w, r, nil)
+	conn, err := h.upgrader.Upgrade(w, r, http.Header{"X-Generation-ID": {w.Header().Get("X-Generation-ID")}})
 	if err != nil {
 		return
 	}
@@ -763,8 +2490,8 @@ func (h *Handler) ChatStream(w http.ResponseWriter, r *http.Request) {
 
 	// Nachricht empfangen
 	var req struct {
-		Message   string `json:"message"`
-		TopicID   string `json:"topic_id"`
+		Message string `json:"message"`
+		TopicID string `json:"topic_id"`
 	}
 
 	if err := conn.ReadJSON(&req); err != nil {
@@ -772,8 +2499,7 @@ func (h *Handler) ChatStream(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Streaming-Antwort
-	ctx := r.Context()
-	chunks, err := h.llm.GenerateStream(ctx, req.Message, nil)
+	chunks, err := h.prompts.GenerateStream(ctx, req.Message, nil)
 	if err != nil {
 		conn.WriteJSON(map[string]string{"error": err.Error()})
 		return
@@ -807,13 +2533,19 @@ func (h *Handler) GetChatHistory(w http.ResponseWriter, r *http.Request) {
 // === Fortschritt Endpoints ===
 
 func (h *Handler) GetProgress(w http.ResponseWriter, r *http.Request) {
+	// Tage bis zur Prüfung hängen von der Uhrzeit ab
+	if h.notModified(w, r, time.Now().Format("200601021504")) {
+		return
+	}
+
 	plan, err := h.store.GetActiveStudyPlan()
 	if err != nil {
 		errorResponse(w, "Kein aktiver Lernplan", http.StatusNotFound)
 		return
 	}
 
-	topics := plan.Topics
+	// Kapitel zählen über ihre Unterthemen
+	topics := leafTopics(plan.Topics)
 	var completed, totalQuestions, answeredQuestions, correctAnswers int
 
 	for _, topic := range topics {
@@ -832,10 +2564,7 @@ func (h *Handler) GetProgress(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	daysUntilExam := int(time.Until(plan.ExamDate).Hours() / 24)
-	if daysUntilExam < 0 {
-		daysUntilExam = 0
-	}
+	daysUntilExam := max(plan.ExamDate.DaysFrom(time.Now()), 0)
 
 	var avgScore float64
 	if answeredQuestions > 0 {
@@ -907,11 +2636,34 @@ func (h *Handler) EndSession(w http.ResponseWriter, r *http.Request) {
 	}
 	json.NewDecoder(r.Body).Decode(&req)
 
-	// Session aktualisieren (vereinfacht)
-	_ = id
-	_ = req
+	session, err := h.store.GetSession(id)
+	if err != nil {
+		errorResponse(w, "Sitzung nicht gefunden", http.StatusNotFound)
+		return
+	}
+	if session.EndedAt != nil {
+		jsonResponse(w, session, http.StatusOK)
+		return
+	}
+
+	endedAt := time.Now()
+	session.EndedAt = &endedAt
+	session.Duration = int(math.Round(endedAt.Sub(session.StartedAt).Minutes()))
+	session.QuestionsAnswered = req.QuestionsAnswered
+	session.CorrectAnswers = req.CorrectAnswers
+	session.RecapStatus = "pending"
+
+	if err := h.store.SaveSession(session); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+
+	h.emitSessionEnded(session)
+
+	// Kurzes Rückblick-Quiz zum Gelernten, abrufbar unter /sessions/{id}/recap
+	go h.generateSessionRecap(*session)
 
-	jsonResponse(w, map[string]string{"message": "Session beendet"}, http.StatusOK)
+	jsonResponse(w, session, http.StatusOK)
 }
 
 // Hilfsfunktion für optionale Query-Parameter
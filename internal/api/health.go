@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+)
+
+var errLLMUnavailable = errors.New("LLM-Backend nicht erreichbar")
+
+// dependencyCheck ist das Ergebnis einer Prüfung für /readyz
+type dependencyCheck struct {
+	Status    string `json:"status"` // ok oder error
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// checkDependency führt check mit Zeitlimit aus und misst die Dauer
+func checkDependency(ctx context.Context, timeout time.Duration, check func(ctx context.Context) error) dependencyCheck {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := dependencyCheck{Status: "ok", LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Healthz meldet nur, dass der Prozess läuft (Liveness)
+func (h *Handler) Healthz(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, map[string]interface{}{
+		"status":    "ok",
+		"timestamp": time.Now(),
+	}, http.StatusOK)
+}
+
+// Readyz prüft alle Abhängigkeiten (Readiness): Datenbank, Migrationen, LLM-Backend
+// und Dokumentenordner. Fällt eine aus, antwortet der Endpoint mit 503.
+func (h *Handler) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	checks := map[string]dependencyCheck{
+		"database": checkDependency(ctx, 2*time.Second, h.store.Ping),
+		"migrations": checkDependency(ctx, 2*time.Second, func(context.Context) error {
+			return h.store.CheckMigrations()
+		}),
+		"llm": checkDependency(ctx, 5*time.Second, func(ctx context.Context) error {
+			if !h.llm.IsAvailable(ctx) {
+				return errLLMUnavailable
+			}
+			return nil
+		}),
+		"documents_path": checkDependency(ctx, 2*time.Second, func(context.Context) error {
+			f, err := os.CreateTemp(h.config.DocumentsPath, ".readyz-*")
+			if err != nil {
+				return err
+			}
+			f.Close()
+			return os.Remove(f.Name())
+		}),
+	}
+
+	status, code := "ok", http.StatusOK
+	for _, c := range checks {
+		if c.Status != "ok" {
+			status, code = "error", http.StatusServiceUnavailable
+			break
+		}
+	}
+	jsonResponse(w, map[string]interface{}{
+		"status":       status,
+		"checks":       checks,
+		"llm_provider": h.llm.GetName(),
+		"timestamp":    time.Now(),
+	}, code)
+}
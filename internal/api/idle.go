@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+)
+
+// CloseIdleSessions beendet offene Lernsitzungen, in denen seit session_idle_minutes nichts
+// mehr passiert ist. Ende und Dauer richten sich nach der letzten Antwort in der Sitzung,
+// damit vergessene Sitzungen die Statistik nicht verfälschen.
+func (h *Handler) CloseIdleSessions(ctx context.Context) error {
+	idle := time.Duration(h.config.SessionIdleMinutes) * time.Minute
+	if idle <= 0 {
+		return nil
+	}
+
+	sessions, err := h.store.GetAllSessions()
+	if err != nil {
+		return err
+	}
+	var closed int
+	now := time.Now()
+	for i := range sessions {
+		session := &sessions[i]
+		if session.EndedAt != nil || now.Sub(session.StartedAt) < idle {
+			continue
+		}
+		// Nur Antworten im Plan der Sitzung zählen als Aktivität (eine Stunde Puffer für die
+		// Zeitumstellung, genau filtert die Schleife)
+		attempts, err := h.store.GetAttemptsByPlanSince(session.StudyPlanID, session.StartedAt.Add(-time.Hour))
+		if err != nil {
+			return err
+		}
+
+		lastActivity := session.StartedAt
+		answered, correct := 0, 0
+		// Versuche sind zeitlich sortiert; eine Lücke größer als idle beendet die Sitzung
+		for _, a := range attempts {
+			if a.CreatedAt.Before(session.StartedAt) {
+				continue
+			}
+			if a.CreatedAt.Sub(lastActivity) > idle {
+				break
+			}
+			answered++
+			if a.IsCorrect {
+				correct++
+			}
+			lastActivity = a.CreatedAt
+		}
+		if now.Sub(lastActivity) < idle {
+			continue
+		}
+
+		endedAt := lastActivity
+		session.EndedAt = &endedAt
+		session.Duration = int(math.Round(endedAt.Sub(session.StartedAt).Minutes()))
+		if session.QuestionsAnswered == 0 {
+			session.QuestionsAnswered = answered
+			session.CorrectAnswers = correct
+		}
+		session.AutoClosed = true
+		if err := h.store.SaveSession(session); err != nil {
+			return err
+		}
+		h.emitSessionEnded(session)
+		closed++
+		log.Printf("💤 Sitzung %s automatisch beendet (letzte Aktivität %s, %d Min.)",
+			session.ID, lastActivity.Format("02.01. 15:04"), session.Duration)
+	}
+	if closed > 0 {
+		h.changes.bump()
+		log.Printf("💤 %d inaktive Sitzungen beendet", closed)
+	}
+	return nil
+}
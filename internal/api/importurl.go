@@ -0,0 +1,219 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"lernplattform/internal/models"
+)
+
+// Maximale Größe eines per URL importierten Dokuments (wie beim Upload)
+const maxImportBytes = 50 << 20
+
+// errImportBlocked meldet eine URL, die auf den Rechner selbst oder ins lokale Netz zeigt
+var errImportBlocked = errors.New("interne Adresse")
+
+// newImportClient erstellt den Client für den URL-Import. Er verbindet sich nur mit öffentlichen
+// Adressen, geprüft nach der DNS-Auflösung und damit auch bei Weiterleitungen, damit sich über den
+// Import keine Dienste auf dem Rechner oder im LAN abfragen lassen. allowed nennt Ausnahmen
+// (Hostnamen, IP-Adressen oder Netze in CIDR-Schreibweise).
+func newImportClient(allowed []string) *http.Client {
+	guard := newImportGuard(allowed)
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		// Ohne Proxy aus der Umgebung, sonst prüfte die Sperre nur die Adresse des Proxys
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			if guard.hosts[strings.ToLower(host)] {
+				return dialer.DialContext(ctx, network, addr)
+			}
+			checked := *dialer
+			checked.Control = func(_, address string, _ syscall.RawConn) error {
+				return guard.check(host, address)
+			}
+			return checked.DialContext(ctx, network, addr)
+		},
+		ForceAttemptHTTP2:   true,
+		TLSHandshakeTimeout: 10 * time.Second,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &http.Client{Timeout: 2 * time.Minute, Transport: transport}
+}
+
+// importGuard sind die Ausnahmen von der Sperre interner Adressen
+type importGuard struct {
+	hosts map[string]bool
+	nets  []*net.IPNet
+}
+
+func newImportGuard(allowed []string) *importGuard {
+	guard := &importGuard{hosts: make(map[string]bool)}
+	for _, entry := range allowed {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			guard.nets = append(guard.nets, network)
+		} else if entry != "" {
+			guard.hosts[strings.Trim(entry, "[]")] = true
+		}
+	}
+	return guard
+}
+
+// check prüft die aufgelöste Adresse (IP:Port) einer Verbindung zu host
+func (g *importGuard) check(host, address string) error {
+	ipText, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(ipText)
+	if ip == nil {
+		return fmt.Errorf("%w: %s", errImportBlocked, address)
+	}
+	if g.hosts[ip.String()] {
+		return nil
+	}
+	for _, network := range g.nets {
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("%w: %s zeigt auf %s", errImportBlocked, host, ip)
+	}
+	return nil
+}
+
+// ImportDocumentURL lädt eine PDF oder HTML-Seite von einer URL und liest sie als Dokument ein.
+// Body: url, optional name; die URL wird am Dokument gespeichert.
+func (h *Handler) ImportDocumentURL(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URL  string `json:"url"`
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+	source, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (source.Scheme != "http" && source.Scheme != "https") || source.Host == "" {
+		errorResponse(w, "Ungültige URL (nur http/https)", http.StatusBadRequest)
+		return
+	}
+
+	httpReq, err := http.NewRequestWithContext(r.Context(), http.MethodGet, source.String(), nil)
+	if err != nil {
+		errorResponse(w, "Ungültige URL", http.StatusBadRequest)
+		return
+	}
+	httpReq.Header.Set("Accept", "application/pdf, text/html;q=0.9")
+	resp, err := h.imports.Do(httpReq)
+	if errors.Is(err, errImportBlocked) {
+		errorResponse(w, "Die URL zeigt auf eine interne Adresse (Ausnahmen unter security.import_allowed_hosts)", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Download fehlgeschlagen: %v", err), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		errorResponse(w, fmt.Sprintf("Download fehlgeschlagen: %s", resp.Status), http.StatusBadGateway)
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImportBytes+1))
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Download fehlgeschlagen: %v", err), http.StatusBadGateway)
+		return
+	}
+	if len(data) > maxImportBytes {
+		errorResponse(w, fmt.Sprintf("Dokument zu groß (maximal %s)", formatBytes(maxImportBytes)), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = downloadName(resp, source)
+	}
+
+	var doc *models.Document
+	switch kind := downloadKind(resp, data); kind {
+	case "pdf":
+		doc, err = h.pdfParser.ParseFromReader(bytes.NewReader(data), name)
+	case "html":
+		// Ohne eigenen Namen zählt der <title> der Seite
+		if strings.TrimSpace(req.Name) == "" {
+			name = ""
+		}
+		doc, err = h.pdfParser.ParseHTML(bytes.NewReader(data), name)
+		if err == nil && doc.Name == "" {
+			doc.Name = downloadName(resp, source)
+		}
+	default:
+		errorResponse(w, fmt.Sprintf("Nicht unterstützter Inhalt (%s), nur PDF oder HTML", kind), http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler beim Parsen: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	doc.SourceURL = source.String()
+	if err := h.storeDocument(doc); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("🌐 Dokument importiert: %s (%s)", doc.Name, doc.SourceURL)
+	jsonResponse(w, doc, http.StatusCreated)
+}
+
+// downloadKind erkennt PDF oder HTML an Content-Type bzw. am Inhalt
+func downloadKind(resp *http.Response, data []byte) string {
+	if bytes.HasPrefix(data, []byte("%PDF")) {
+		return "pdf"
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "" {
+		mediaType = http.DetectContentType(data)
+		mediaType, _, _ = mime.ParseMediaType(mediaType)
+	}
+	switch mediaType {
+	case "application/pdf":
+		return "pdf"
+	case "text/html", "application/xhtml+xml":
+		return "html"
+	}
+	return mediaType
+}
+
+// downloadName bestimmt den Dokumentnamen aus Content-Disposition oder dem letzten Pfadteil der URL
+func downloadName(resp *http.Response, source *url.URL) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		return path.Base(params["filename"])
+	}
+	if base := path.Base(source.Path); base != "." && base != "/" {
+		if unescaped, err := url.PathUnescape(base); err == nil {
+			return unescaped
+		}
+		return base
+	}
+	return source.Host
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/lms"
+	"lernplattform/internal/models"
+)
+
+// syncEntry ist eine Zeile im Bericht einer Kurs-Synchronisation
+type syncEntry struct {
+	Course     string `json:"course"`
+	File       string `json:"file"`
+	Status     string `json:"status"` // new, updated, unchanged, skipped oder error
+	DocumentID string `json:"document_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// lmsConnector liefert den Connector zu einer Lernplattform (nil = unbekannt)
+func (h *Handler) lmsConnector(name string) lms.Connector {
+	switch name {
+	case "moodle":
+		return lms.NewMoodle(h.config.Integrations.Moodle)
+	case "ilias":
+		return lms.NewILIAS(h.config.Integrations.ILIAS)
+	}
+	return nil
+}
+
+// SyncIntegration holt die Kursdateien aus Moodle bzw. ILIAS in den Dokumentenordner und liest
+// neue oder geänderte PDF/DOCX ein. Der Kursname wird zum Schlagwort des Dokuments.
+func (h *Handler) SyncIntegration(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	connector := h.lmsConnector(vars["name"])
+	if connector == nil {
+		errorResponse(w, "Unbekannte Integration (moodle oder ilias)", http.StatusNotFound)
+		return
+	}
+	if !connector.Enabled() {
+		errorResponse(w, fmt.Sprintf("%s ist nicht konfiguriert (integrations.%s in config.json)", connector.Name(), connector.Name()), http.StatusBadRequest)
+		return
+	}
+
+	files, err := connector.ListFiles(r.Context())
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Kursdateien konnten nicht gelesen werden: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	// Bereits eingelesene Dateien behalten ihre Dokument-ID
+	byPath := make(map[string]string)
+	if docs, err := h.store.GetAllDocuments(); err == nil {
+		for _, doc := range docs {
+			if doc.Path != "" {
+				byPath[doc.Path] = doc.ID
+			}
+		}
+	}
+
+	report := []syncEntry{}
+	counts := make(map[string]int)
+	for _, file := range files {
+		entry := syncEntry{Course: file.Course, File: strings.Trim(file.Folder+"/"+file.Name, "/"), Status: "skipped"}
+		lower := strings.ToLower(file.Name)
+		if !strings.HasSuffix(lower, ".pdf") && !strings.HasSuffix(lower, ".docx") {
+			report = append(report, entry)
+			counts[entry.Status]++
+			continue
+		}
+
+		local := lms.LocalPath(h.config.DocumentsPath, connector, file)
+		existingID := byPath[local]
+		if existingID != "" && lms.Unchanged(local, file) {
+			entry.Status, entry.DocumentID = "unchanged", existingID
+		} else if doc, err := h.syncCourseFile(r.Context(), connector, file, local); err != nil {
+			entry.Status, entry.Error = "error", err.Error()
+		} else {
+			entry.Status = "new"
+			if existingID != "" {
+				entry.Status = "updated"
+				doc.ID = existingID
+				// Selbst gesetzte Angaben überstehen die neue Fassung der Datei
+				if old, err := h.store.GetDocument(existingID); err == nil {
+					doc.Title, doc.Author, doc.Semester = old.Title, old.Author, old.Semester
+				}
+			}
+			if err := h.storeDocument(doc); err != nil {
+				entry.Status, entry.Error = "error", "Fehler beim Speichern"
+			} else {
+				entry.DocumentID = doc.ID
+			}
+		}
+		report = append(report, entry)
+		counts[entry.Status]++
+	}
+
+	log.Printf("🎓 %s-Synchronisation: %d neu, %d aktualisiert, %d unverändert, %d Fehler",
+		connector.Name(), counts["new"], counts["updated"], counts["unchanged"], counts["error"])
+	jsonResponse(w, map[string]interface{}{
+		"integration": connector.Name(),
+		"new":         counts["new"],
+		"updated":     counts["updated"],
+		"unchanged":   counts["unchanged"],
+		"failed":      counts["error"],
+		"files":       report,
+	}, http.StatusOK)
+}
+
+// syncCourseFile lädt eine Kursdatei in den Dokumentenordner und liest sie ein
+func (h *Handler) syncCourseFile(ctx context.Context, connector lms.Connector, file lms.CourseFile, local string) (*models.Document, error) {
+	if err := lms.Fetch(ctx, connector, file, local); err != nil {
+		return nil, err
+	}
+
+	var doc *models.Document
+	var err error
+	if strings.HasSuffix(strings.ToLower(local), ".docx") {
+		f, openErr := os.Open(local)
+		if openErr != nil {
+			return nil, openErr
+		}
+		defer f.Close()
+		doc, err = h.pdfParser.ParseDOCX(f, file.Name)
+	} else {
+		doc, err = h.pdfParser.ParseFile(local)
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc.Path = local
+	doc.Tags = []string{file.Course}
+	return doc, nil
+}
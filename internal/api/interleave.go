@@ -0,0 +1,172 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Höchstzahl gemischter Themen pro Quiz
+const maxInterleaveTopics = 3
+
+// interleaveCooldown: so lange gilt ein Themenpaar als "kürzlich gemischt" und wird nachrangig gewählt
+const interleaveCooldown = 3 * 24 * time.Hour
+
+// interleaveCandidate ist ein Thema, das mit dem Ausgangsthema gemischt werden könnte
+type interleaveCandidate struct {
+	topicID  string
+	sibling  bool // gleiches Oberthema wie das Ausgangsthema
+	distance int  // Abstand in der Reihenfolge des Plans
+	pairedAt time.Time
+}
+
+// interleaveCards mischt Fragen aus 2-3 verwandten Themen abwechselnd (A B C A B C …).
+// Ausgangsthema ist anchorID oder das Thema der dringendsten Karte; dazu kommen Themen mit
+// gleichem Oberthema bzw. in der Nähe im Plan. Paare, die in den letzten Tagen schon gemischt
+// wurden, kommen erst zum Zug, wenn nichts anderes übrig ist.
+func (h *Handler) interleaveCards(planID, anchorID string, cards []leitnerCard, count, topics int) []leitnerCard {
+	sortByBox(cards)
+	byTopic := make(map[string][]leitnerCard)
+	for _, c := range cards {
+		byTopic[c.TopicID] = append(byTopic[c.TopicID], c)
+	}
+	if anchorID == "" && len(cards) > 0 {
+		anchorID = cards[0].TopicID
+	}
+	if len(byTopic[anchorID]) == 0 {
+		return []leitnerCard{}
+	}
+
+	plan, err := h.store.GetStudyPlan(planID)
+	if err != nil {
+		return byTopic[anchorID][:min(count, len(byTopic[anchorID]))]
+	}
+	leaves := leafTopics(plan.Topics)
+	index := make(map[string]int, len(leaves))
+	for i, t := range leaves {
+		index[t.ID] = i
+	}
+	anchor, ok := index[anchorID]
+	if !ok {
+		return byTopic[anchorID][:min(count, len(byTopic[anchorID]))]
+	}
+
+	pairs, _ := h.store.GetInterleavedPairs(planID)
+	lastPaired := make(map[[2]string]time.Time, len(pairs))
+	for _, p := range pairs {
+		lastPaired[[2]string{p.TopicA, p.TopicB}] = p.LastAt
+	}
+
+	var candidates []interleaveCandidate
+	for i, t := range leaves {
+		if t.ID == anchorID || len(byTopic[t.ID]) == 0 {
+			continue
+		}
+		candidates = append(candidates, interleaveCandidate{
+			topicID:  t.ID,
+			sibling:  t.ParentTopicID != "" && t.ParentTopicID == leaves[anchor].ParentTopicID,
+			distance: max(i-anchor, anchor-i),
+		})
+	}
+
+	// Schrittweise wählen, damit auch das Paar aus zweitem und drittem Thema berücksichtigt wird
+	chosen := []string{anchorID}
+	now := time.Now()
+	for len(chosen) < topics && len(candidates) > 0 {
+		for i := range candidates {
+			candidates[i].pairedAt = time.Time{}
+			for _, id := range chosen {
+				if at := lastPaired[pairKey(id, candidates[i].topicID)]; at.After(candidates[i].pairedAt) {
+					candidates[i].pairedAt = at
+				}
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			a, b := candidates[i], candidates[j]
+			recentA, recentB := now.Sub(a.pairedAt) < interleaveCooldown, now.Sub(b.pairedAt) < interleaveCooldown
+			switch {
+			case recentA != recentB:
+				return !recentA
+			case a.sibling != b.sibling:
+				return a.sibling
+			case a.distance != b.distance:
+				return a.distance < b.distance
+			}
+			return a.pairedAt.Before(b.pairedAt)
+		})
+		chosen = append(chosen, candidates[0].topicID)
+		candidates = candidates[1:]
+	}
+
+	// Reihum je eine Frage pro Thema, bis count erreicht ist oder alle Themen leer sind
+	selected := make([]leitnerCard, 0, count)
+	for round := 0; len(selected) < count; round++ {
+		added := false
+		for _, id := range chosen {
+			if round < len(byTopic[id]) && len(selected) < count {
+				selected = append(selected, byTopic[id][round])
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+
+	for i := 0; i < len(chosen); i++ {
+		for j := i + 1; j < len(chosen); j++ {
+			if err := h.store.RecordInterleavedPair(planID, chosen[i], chosen[j], now); err != nil {
+				log.Printf("⚠️ Interleaving-Paar konnte nicht gespeichert werden: %v", err)
+			}
+		}
+	}
+	return selected
+}
+
+// pairKey ordnet zwei Themen-IDs wie in der Tabelle interleave_pairs (kleinere zuerst)
+func pairKey(a, b string) [2]string {
+	if b < a {
+		a, b = b, a
+	}
+	return [2]string{a, b}
+}
+
+// GetInterleavedPairs zeigt, welche Themen zuletzt gemischt abgefragt wurden
+func (h *Handler) GetInterleavedPairs(w http.ResponseWriter, r *http.Request) {
+	planID, ok := h.queryPlanID(w, r)
+	if !ok {
+		return
+	}
+
+	pairs, err := h.store.GetInterleavedPairs(planID)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+
+	names := make(map[string]string)
+	if plan, err := h.store.GetStudyPlan(planID); err == nil {
+		for _, t := range leafTopics(plan.Topics) {
+			names[t.ID] = t.Name
+		}
+	}
+
+	now := time.Now()
+	result := make([]map[string]interface{}, 0, len(pairs))
+	for _, p := range pairs {
+		if names[p.TopicA] == "" || names[p.TopicB] == "" {
+			continue // Thema inzwischen zusammengeführt oder gelöscht
+		}
+		result = append(result, map[string]interface{}{
+			"topic_a":      p.TopicA,
+			"topic_a_name": names[p.TopicA],
+			"topic_b":      p.TopicB,
+			"topic_b_name": names[p.TopicB],
+			"count":        p.Count,
+			"last_at":      p.LastAt,
+			"recent":       now.Sub(p.LastAt) < interleaveCooldown,
+		})
+	}
+	jsonResponse(w, map[string]interface{}{"plan_id": planID, "pairs": result}, http.StatusOK)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/llm"
+	"lernplattform/internal/models"
+	"lernplattform/internal/pdf"
+)
+
+// topicLanguage bestimmt, in welcher Sprache Erklärungen und Fragen zu einem Thema entstehen:
+// die Vorgabe aus der Konfiguration, sonst die überwiegende Sprache der Quelldokumente
+func (h *Handler) topicLanguage(topic *models.Topic) string {
+	if llm.IsSupportedLanguage(h.config.Language) {
+		return h.config.Language
+	}
+
+	counts := make(map[string]int)
+	for _, doc := range h.sourceDocuments(topic) {
+		if llm.IsSupportedLanguage(doc.Language) {
+			counts[doc.Language] += max(doc.WordCount, 1)
+		}
+	}
+	lang := "de"
+	for l, n := range counts {
+		if n > counts[lang] {
+			lang = l
+		}
+	}
+	return lang
+}
+
+// withTopicSettings hängt Sprache und Fachprofil (des Lernplans) eines Themas an den Context der LLM-Aufrufe
+func (h *Handler) withTopicSettings(ctx context.Context, topic *models.Topic) context.Context {
+	ctx = llm.WithLanguage(ctx, h.topicLanguage(topic))
+	return llm.WithProfile(ctx, h.planProfile(topic.StudyPlanID))
+}
+
+// SetDocumentLanguage korrigiert die erkannte Sprache eines Dokuments.
+// Body: language ("de", "en" oder leer = neu erkennen)
+func (h *Handler) SetDocumentLanguage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req struct {
+		Language string `json:"language"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+	req.Language = strings.ToLower(strings.TrimSpace(req.Language))
+	if req.Language != "" && !llm.IsSupportedLanguage(req.Language) {
+		errorResponse(w, "Sprache muss 'de' oder 'en' sein", http.StatusBadRequest)
+		return
+	}
+
+	doc, err := h.store.GetDocument(id)
+	if err != nil {
+		errorResponse(w, "Dokument nicht gefunden", http.StatusNotFound)
+		return
+	}
+	if req.Language == "" {
+		req.Language = pdf.DetectLanguage(doc.Content)
+	}
+	if err := h.store.SetDocumentLanguage(doc.ID, req.Language); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+	doc.Language = req.Language
+	h.saveDocumentStats(doc)
+
+	log.Printf("🌐 Sprache von '%s' auf %s gesetzt", doc.Name, doc.Language)
+	jsonResponse(w, doc, http.StatusOK)
+}
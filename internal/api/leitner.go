@@ -0,0 +1,256 @@
+package api
+
+import (
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"lernplattform/internal/llm"
+	"lernplattform/internal/models"
+)
+
+// leitnerIntervals: Wiederholungsabstand je Box in Tagen (Box 0 = neu, sofort fällig)
+var leitnerIntervals = []int{0, 1, 2, 4, 8, 16}
+
+var leitnerLabels = []string{"Neu", "Täglich", "Alle 2 Tage", "Alle 4 Tage", "Wöchentlich", "Gelernt"}
+
+const leitnerMaxBox = 5
+
+// leitnerCard ist eine Frage mit ihrer aus der Antworthistorie berechneten Box
+type leitnerCard struct {
+	models.Question
+	Box         int        `json:"box"`
+	LastAttempt *time.Time `json:"last_attempt,omitempty"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	Flagged     bool       `json:"flagged,omitempty"` // offen zur Wiederholung markiert
+}
+
+// leitnerBox spielt die Antwortversuche (ältester zuerst) nach:
+// richtig -> eine Box weiter, falsch -> zurück in Box 1.
+// Richtig mit Hinweisen zählt als "noch nicht sicher" und bleibt in der Box.
+func leitnerBox(attempts []models.QuestionAttempt) int {
+	box := 0
+	for _, a := range attempts {
+		switch {
+		case !a.IsCorrect:
+			box = 1
+		case a.HintsUsed > 0:
+			box = max(box, 1)
+		default:
+			box = min(box+1, leitnerMaxBox)
+		}
+	}
+	return box
+}
+
+func (c *leitnerCard) due(now time.Time) bool {
+	return c.DueAt == nil || !c.DueAt.After(now)
+}
+
+// leitnerCards berechnet die Boxen aller Fragen eines Plans
+func (h *Handler) leitnerCards(planID string) ([]leitnerCard, error) {
+	questions, err := h.store.GetQuestionsByPlan(planID)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := h.store.GetAttemptsByPlan(planID)
+	if err != nil {
+		return nil, err
+	}
+
+	byQuestion := make(map[string][]models.QuestionAttempt)
+	for _, a := range attempts {
+		byQuestion[a.QuestionID] = append(byQuestion[a.QuestionID], a)
+	}
+	flags, _ := h.store.GetFlags("question", "", false)
+	flagged := make(map[string]bool, len(flags))
+	for _, f := range flags {
+		flagged[f.ItemID] = true
+	}
+
+	cards := make([]leitnerCard, len(questions))
+	for i, q := range questions {
+		cards[i] = leitnerCard{Question: q, Flagged: flagged[q.ID]}
+		history := byQuestion[q.ID]
+		if len(history) == 0 {
+			continue
+		}
+		cards[i].Box = leitnerBox(history)
+		last := history[len(history)-1].CreatedAt
+		dueAt := last.AddDate(0, 0, leitnerIntervals[cards[i].Box])
+		cards[i].LastAttempt = &last
+		cards[i].DueAt = &dueAt
+	}
+	return cards, nil
+}
+
+// GetLeitnerBoxes zeigt, wie viele Fragen in welcher Box liegen und wie viele fällig sind
+func (h *Handler) GetLeitnerBoxes(w http.ResponseWriter, r *http.Request) {
+	planID, ok := h.queryPlanID(w, r)
+	if !ok {
+		return
+	}
+
+	cards, err := h.leitnerCards(planID)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+
+	boxes := make([]models.LeitnerBox, leitnerMaxBox+1)
+	for i := range boxes {
+		boxes[i] = models.LeitnerBox{Box: i, Label: leitnerLabels[i], IntervalDays: leitnerIntervals[i]}
+	}
+	now := time.Now()
+	due := 0
+	for i := range cards {
+		boxes[cards[i].Box].Count++
+		if cards[i].due(now) {
+			boxes[cards[i].Box].Due++
+			due++
+		}
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"plan_id": planID,
+		"boxes":   boxes,
+		"total":   len(cards),
+		"due":     due,
+	}, http.StatusOK)
+}
+
+// GetQuiz stellt ein Quiz aus bestimmten Leitner-Boxen zusammen.
+// Parameter: boxes=1,2, count (max 50), due_only=true (nur fällige), topic_id (optional),
+// interleave=2|3 (Fragen aus so vielen verwandten Themen abwechselnd, topic_id ist dann das Ausgangsthema),
+// exam_only=true (nur Fragen, die in alten Klausuren vorkamen), level (nur eine kognitive Stufe).
+// Fehlende Parameter ergeben sich aus der aktuellen Phase des Plans. Zur Wiederholung markierte Fragen
+// kommen zuerst; in der Wiederholungsphase auch, wenn sie nicht in den Boxen der Phase liegen oder nicht fällig sind.
+func (h *Handler) GetQuiz(w http.ResponseWriter, r *http.Request) {
+	planID, ok := h.queryPlanID(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+
+	// Ohne Angaben stellt die aktuelle Phase des Plans das Quiz zusammen
+	count, dueOnly := 10, false
+	var wanted map[int]bool
+	phase, settings, hasPhase := h.activePhaseSettings(planID)
+	if hasPhase {
+		count, dueOnly = settings.quizCount, settings.dueOnly
+		if settings.quizBoxes != nil {
+			wanted = make(map[int]bool)
+			for _, box := range settings.quizBoxes {
+				wanted[box] = true
+			}
+		}
+	}
+
+	if boxesStr := query.Get("boxes"); boxesStr != "" {
+		wanted = make(map[int]bool)
+		for _, part := range strings.Split(boxesStr, ",") {
+			box, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || box < 0 || box > leitnerMaxBox {
+				errorResponse(w, "Ungültige Box (0-5)", http.StatusBadRequest)
+				return
+			}
+			wanted[box] = true
+		}
+	}
+	if n, err := strconv.Atoi(query.Get("count")); err == nil && n > 0 {
+		count = n
+	}
+	if count > 50 {
+		count = 50
+	}
+	if d := query.Get("due_only"); d != "" {
+		dueOnly = d == "true"
+	}
+	topicID := query.Get("topic_id")
+	examOnly := query.Get("exam_only") == "true"
+	level := query.Get("level")
+	if level != "" && !llm.IsValidCognitiveLevel(level) {
+		errorResponse(w, "Ungültige kognitive Stufe (remember, understand, apply, analyze)", http.StatusBadRequest)
+		return
+	}
+	interleave := 0
+	if s := query.Get("interleave"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 2 || n > maxInterleaveTopics {
+			errorResponse(w, "interleave muss 2 oder 3 sein", http.StatusBadRequest)
+			return
+		}
+		interleave = n
+	}
+
+	cards, err := h.leitnerCards(planID)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	flaggedFirst := phase == "review" && query.Get("boxes") == "" && query.Get("due_only") == ""
+	selected := make([]leitnerCard, 0, count)
+	for _, c := range cards {
+		if wanted != nil && !wanted[c.Box] && !(flaggedFirst && c.Flagged) {
+			continue
+		}
+		if dueOnly && !c.due(now) && !(flaggedFirst && c.Flagged) {
+			continue
+		}
+		if topicID != "" && c.TopicID != topicID && interleave == 0 {
+			continue
+		}
+		if examOnly && !c.ExamRelevant {
+			continue
+		}
+		if level != "" && c.CognitiveLevel != level {
+			continue
+		}
+		selected = append(selected, c)
+	}
+
+	// Interleaving: verwandte Themen abwechselnd; Generalprobe: gemischt wie in der Prüfung;
+	// sonst niedrige Boxen zuerst und innerhalb einer Box die am längsten nicht geübten
+	switch {
+	case interleave > 0:
+		selected = h.interleaveCards(planID, topicID, selected, count, interleave)
+	case phase == "dry_run" && query.Get("boxes") == "":
+		rand.Shuffle(len(selected), func(i, j int) { selected[i], selected[j] = selected[j], selected[i] })
+	default:
+		sortByBox(selected)
+	}
+	if len(selected) > count {
+		selected = selected[:count]
+	}
+
+	for i := range selected {
+		h.shuffleOptions(&selected[i].Question)
+	}
+
+	jsonResponse(w, selected, http.StatusOK)
+}
+
+// sortByBox ordnet markierte Fragen zuerst, dann niedrige Boxen, innerhalb einer Box Fragen
+// aus alten Klausuren und danach die am längsten nicht geübten
+func sortByBox(selected []leitnerCard) {
+	sort.SliceStable(selected, func(i, j int) bool {
+		if selected[i].Flagged != selected[j].Flagged {
+			return selected[i].Flagged
+		}
+		if selected[i].Box != selected[j].Box {
+			return selected[i].Box < selected[j].Box
+		}
+		if selected[i].ExamRelevant != selected[j].ExamRelevant {
+			return selected[i].ExamRelevant
+		}
+		if selected[i].LastAttempt == nil || selected[j].LastAttempt == nil {
+			return selected[i].LastAttempt == nil && selected[j].LastAttempt != nil
+		}
+		return selected[i].LastAttempt.Before(*selected[j].LastAttempt)
+	})
+}
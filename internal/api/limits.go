@@ -0,0 +1,109 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Zeitlimits je Anfrage. Die meisten Endpoints warten höchstens auf einen LLM-Aufruf,
+// Dokumentanalyse und Lernplanerstellung laufen deutlich länger.
+const (
+	DefaultRequestTimeout = 5 * time.Minute
+	LongRequestTimeout    = 20 * time.Minute
+)
+
+// Maximale Größe des Request-Bodys (JSON)
+const defaultMaxBodyBytes = 1 << 20
+
+// routeLimit legt Zeitlimit und Body-Größe für eine Route fest (timeout 0 = kein Limit, z.B. Streaming)
+type routeLimit struct {
+	timeout  time.Duration
+	maxBytes int64
+}
+
+// routeLimits enthält die Ausnahmen vom Standard, Schlüssel "METHODE Pfadvorlage"
+var routeLimits = map[string]routeLimit{
+	"POST /api/v1/documents":                    {LongRequestTimeout, 50 << 20},
+	"POST /api/v1/documents/scan":               {LongRequestTimeout, defaultMaxBodyBytes},
+	"POST /api/v1/documents/batch":              {LongRequestTimeout, defaultMaxBodyBytes},
+	"POST /api/v1/account/wipe":                 {LongRequestTimeout, defaultMaxBodyBytes},
+	"POST /api/v1/documents/import-url":         {LongRequestTimeout, defaultMaxBodyBytes},
+	"POST /api/v1/documents/import-vault":       {LongRequestTimeout, defaultMaxBodyBytes},
+	"POST /api/v1/integrations/{name}/sync":     {LongRequestTimeout, defaultMaxBodyBytes},
+	"POST /api/v1/plans":                        {LongRequestTimeout, defaultMaxBodyBytes},
+	"POST /api/v1/plans/preview":                {LongRequestTimeout, defaultMaxBodyBytes},
+	"POST /api/v1/rpc":                          {LongRequestTimeout, 70 << 20}, // documents.ingest: Base64 einer Datei bis 50 MB
+	"POST /api/v1/stt":                          {DefaultRequestTimeout, 25 << 20},
+	"POST /api/v1/questions/{id}/answer/stream": {0, defaultMaxBodyBytes},
+	"POST /api/v1/chat/stream":                  {0, defaultMaxBodyBytes},
+	"GET /api/v1/generations/events":            {0, defaultMaxBodyBytes},
+}
+
+// timeoutBody ist die Antwort, wenn ein Endpoint sein Zeitlimit überschreitet
+const timeoutBody = `{"error":"Zeitüberschreitung: die Anfrage hat zu lange gedauert"}`
+
+// limitFor liefert die Limits der aufgerufenen Route
+func limitFor(r *http.Request) routeLimit {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			if limit, ok := routeLimits[r.Method+" "+tpl]; ok {
+				return limit
+			}
+		}
+	}
+	return routeLimit{DefaultRequestTimeout, defaultMaxBodyBytes}
+}
+
+// limitsMiddleware begrenzt Body-Größe und Laufzeit jeder API-Anfrage,
+// damit ein fehlerhafter Client den Server nicht blockieren kann
+func limitsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := limitFor(r)
+
+		if r.ContentLength > limit.maxBytes {
+			errorResponse(w, fmt.Sprintf("Anfrage zu groß (maximal %s)", formatBytes(limit.maxBytes)), http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit.maxBytes)
+
+		if limit.timeout == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		http.TimeoutHandler(next, limit.timeout, timeoutBody).ServeHTTP(timeoutJSONWriter{w}, r)
+	})
+}
+
+// timeoutJSONWriter kennzeichnet die Zeitüberschreitungs-Antwort von http.TimeoutHandler als JSON
+type timeoutJSONWriter struct {
+	http.ResponseWriter
+}
+
+func (w timeoutJSONWriter) WriteHeader(status int) {
+	if status == http.StatusServiceUnavailable && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// bodyTooLarge beantwortet einen zu großen Upload mit 413; false, wenn err etwas anderes ist
+func bodyTooLarge(w http.ResponseWriter, err error) bool {
+	var tooLarge *http.MaxBytesError
+	if !errors.As(err, &tooLarge) {
+		return false
+	}
+	errorResponse(w, fmt.Sprintf("Anfrage zu groß (maximal %s)", formatBytes(tooLarge.Limit)), http.StatusRequestEntityTooLarge)
+	return true
+}
+
+// formatBytes gibt eine Größe in KB/MB aus
+func formatBytes(n int64) string {
+	if n >= 1<<20 {
+		return fmt.Sprintf("%d MB", n>>20)
+	}
+	return fmt.Sprintf("%d KB", n>>10)
+}
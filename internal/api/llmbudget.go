@@ -0,0 +1,85 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"lernplattform/internal/llm"
+	"lernplattform/internal/sysload"
+)
+
+var errLLMBudgetUsed = errors.New("LLM-Zeitbudget für heute verbraucht")
+
+// BackgroundLLMAllowed prüft Ruhezeit, Tagesbudget und Rechnerzustand;
+// der Scheduler lässt Hintergrundjobs aus, solange ein Fehler zurückkommt
+func (h *Handler) BackgroundLLMAllowed(now time.Time) error {
+	if err := h.llmBudgetPaused(now); err != nil {
+		return err
+	}
+	return h.resourcesPaused(true)
+}
+
+// llmBudgetPaused prüft Ruhezeit und Tagesbudget aus llm_budget
+func (h *Handler) llmBudgetPaused(now time.Time) error {
+	cfg := h.config.LLMBudget
+	if cfg.Quiet(now) {
+		return fmt.Errorf("Ruhezeit (%d-%d Uhr)", cfg.QuietStart, cfg.QuietEnd)
+	}
+	if cfg.DailyMinutes > 0 && llm.ComputeToday() >= time.Duration(cfg.DailyMinutes)*time.Minute {
+		return errLLMBudgetUsed
+	}
+	return nil
+}
+
+// resourcesPaused prüft Akku und, mit checkLoad, die Rechnerlast (resource_guard).
+// Während ein Job läuft, wird die Last nicht mehr geprüft, weil ein lokales Modell sie selbst erzeugt.
+func (h *Handler) resourcesPaused(checkLoad bool) error {
+	cfg := h.config.ResourceGuard
+	if !cfg.Enabled {
+		return nil
+	}
+	status, err := sysload.Read()
+	if err != nil {
+		return nil // ohne Messwerte nicht blockieren
+	}
+	if status.OnBattery && !cfg.AllowOnBattery {
+		return errors.New("Akkubetrieb")
+	}
+	maxLoad := cfg.MaxLoad
+	if maxLoad <= 0 {
+		maxLoad = 0.7
+	}
+	if checkLoad && status.LoadPerCPU() > maxLoad {
+		return fmt.Errorf("Rechner ausgelastet (Last %.2f je Kern, erlaubt %.2f)", status.LoadPerCPU(), maxLoad)
+	}
+	return nil
+}
+
+// llmBudgetStatus beschreibt den heutigen Verbrauch für /status
+func (h *Handler) llmBudgetStatus(now time.Time) map[string]interface{} {
+	cfg := h.config.LLMBudget
+	used := llm.ComputeToday()
+	status := map[string]interface{}{
+		"used_minutes":       used.Minutes(),
+		"daily_minutes":      cfg.DailyMinutes,
+		"quiet_now":          cfg.Quiet(now),
+		"background_allowed": true,
+	}
+	if cfg.DailyMinutes > 0 {
+		status["remaining_minutes"] = max(float64(cfg.DailyMinutes)-used.Minutes(), 0)
+	}
+	if cfg.QuietStart%24 != cfg.QuietEnd%24 {
+		status["quiet_hours"] = fmt.Sprintf("%02d:00-%02d:00", cfg.QuietStart%24, cfg.QuietEnd%24)
+	}
+	if h.config.ResourceGuard.Enabled {
+		if machine, err := sysload.Read(); err == nil {
+			status["machine"] = machine
+		}
+	}
+	if err := h.BackgroundLLMAllowed(now); err != nil {
+		status["background_allowed"] = false
+		status["paused_reason"] = err.Error()
+	}
+	return status
+}
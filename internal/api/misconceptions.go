@@ -0,0 +1,231 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/models"
+)
+
+// Höchstzahl Fehlvorstellungen, die in eine gezielte Erklärung einfließen
+const maxAddressedMisconceptions = 6
+
+// Aus so vielen eigenen Erklärungen (Teach-Back) werden Fehlvorstellungen übernommen
+const teachBackMisconceptionSources = 3
+
+// misconception ist eine Frage, bei der immer wieder falsch geantwortet wurde
+type misconception struct {
+	QuestionID string    `json:"question_id"`
+	Question   string    `json:"question"`
+	WrongCount int       `json:"wrong_count"`
+	ErrorType  string    `json:"error_type,omitempty"` // häufigste Fehlerart dieser Frage
+	ErrorLabel string    `json:"error_label,omitempty"`
+	LastAnswer string    `json:"last_answer"`
+	Feedback   string    `json:"feedback,omitempty"` // Rückmeldung zur letzten falschen Antwort
+	Resolved   bool      `json:"resolved"`           // letzte Antwort war richtig
+	LastWrong  time.Time `json:"last_wrong"`
+}
+
+// topicMisconceptions sammelt die Fehlvorstellungen eines Themas aus falschen Antworten und
+// Teach-Back-Bewertungen. Rechenfehler und falsch gelesene Fragen sind keine Fehlvorstellungen
+// und zählen nicht mit. Offene und häufige Fehler stehen vorn.
+func (h *Handler) topicMisconceptions(topic *models.Topic) ([]misconception, []string, error) {
+	attempts, err := h.store.GetAttemptsByPlan(topic.StudyPlanID)
+	if err != nil {
+		return nil, nil, err
+	}
+	questions, err := h.store.GetQuestionsByTopic(topic.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	questionText := make(map[string]string, len(questions))
+	for _, q := range questions {
+		questionText[q.ID] = q.Question
+	}
+
+	byQuestion := make(map[string]*misconception)
+	errorCounts := make(map[string]map[string]int)
+	var order []string
+	for _, a := range attempts {
+		if a.TopicID != topic.ID {
+			continue
+		}
+		m := byQuestion[a.QuestionID]
+		if a.IsCorrect {
+			if m != nil {
+				m.Resolved = true
+			}
+			continue
+		}
+		if a.ErrorType == models.ErrorCalculationSlip || a.ErrorType == models.ErrorMisreadQuestion {
+			continue
+		}
+		if m == nil {
+			m = &misconception{QuestionID: a.QuestionID, Question: questionText[a.QuestionID]}
+			byQuestion[a.QuestionID] = m
+			errorCounts[a.QuestionID] = make(map[string]int)
+			order = append(order, a.QuestionID)
+		}
+		m.WrongCount++
+		m.LastAnswer = a.Answer
+		m.Feedback = a.Feedback
+		m.LastWrong = a.CreatedAt
+		m.Resolved = false
+		if a.ErrorType != "" {
+			errorCounts[a.QuestionID][a.ErrorType]++
+		}
+	}
+
+	result := []misconception{}
+	for _, id := range order {
+		m := byQuestion[id]
+		if m.Question == "" {
+			continue // Frage wurde inzwischen gelöscht
+		}
+		best := 0
+		for _, errorType := range models.ErrorTypes {
+			if errorCounts[id][errorType] > best {
+				best = errorCounts[id][errorType]
+				m.ErrorType = errorType
+			}
+		}
+		m.ErrorLabel = errorTypeLabels[m.ErrorType]
+		result = append(result, *m)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Resolved != result[j].Resolved {
+			return !result[i].Resolved
+		}
+		if result[i].WrongCount != result[j].WrongCount {
+			return result[i].WrongCount > result[j].WrongCount
+		}
+		return result[i].LastWrong.After(result[j].LastWrong)
+	})
+
+	teachBack := []string{}
+	teachBacks, _ := h.store.GetTeachBacksByTopic(topic.ID)
+	seen := make(map[string]bool)
+	for i, tb := range teachBacks {
+		if i == teachBackMisconceptionSources {
+			break
+		}
+		for _, text := range tb.Misconceptions {
+			if key := strings.ToLower(strings.TrimSpace(text)); key != "" && !seen[key] {
+				seen[key] = true
+				teachBack = append(teachBack, text)
+			}
+		}
+	}
+	return result, teachBack, nil
+}
+
+// describe beschreibt eine Fehlvorstellung in Textform für das LLM
+func (m misconception) describe() string {
+	answer := []rune(strings.TrimSpace(m.LastAnswer))
+	if len(answer) > 300 {
+		answer = append(answer[:300], '…')
+	}
+	text := fmt.Sprintf("Frage „%s“ – %d-mal falsch, zuletzt geantwortet: „%s“", m.Question, m.WrongCount, string(answer))
+	if m.ErrorLabel != "" {
+		text += " (" + m.ErrorLabel + ")"
+	}
+	if m.Feedback != "" {
+		text += ". Rückmeldung damals: " + m.Feedback
+	}
+	return text
+}
+
+// GetMisconceptions listet die wiederkehrenden Fehlvorstellungen eines Themas
+func (h *Handler) GetMisconceptions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	topic, err := h.store.GetTopic(id)
+	if err != nil {
+		errorResponse(w, "Thema nicht gefunden", http.StatusNotFound)
+		return
+	}
+	misconceptions, teachBack, err := h.topicMisconceptions(topic)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+
+	open := 0
+	for _, m := range misconceptions {
+		if !m.Resolved {
+			open++
+		}
+	}
+	jsonResponse(w, map[string]interface{}{
+		"topic_id":       topic.ID,
+		"topic_name":     topic.Name,
+		"open":           open,
+		"misconceptions": misconceptions,
+		"teach_back":     teachBack,
+	}, http.StatusOK)
+}
+
+// AddressMisconceptions erzeugt eine Erklärung, die gezielt die offenen Fehlvorstellungen eines
+// Themas richtigstellt. Sie wird als Variante der letzten Erklärung gespeichert.
+func (h *Handler) AddressMisconceptions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	topic, err := h.store.GetTopic(id)
+	if err != nil {
+		errorResponse(w, "Thema nicht gefunden", http.StatusNotFound)
+		return
+	}
+	misconceptions, teachBack, err := h.topicMisconceptions(topic)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+
+	var descriptions []string
+	for _, m := range misconceptions {
+		if m.Resolved || len(descriptions) == maxAddressedMisconceptions {
+			break
+		}
+		descriptions = append(descriptions, m.describe())
+	}
+	for _, text := range teachBack {
+		if len(descriptions) == maxAddressedMisconceptions {
+			break
+		}
+		descriptions = append(descriptions, "In der eigenen Erklärung: "+text)
+	}
+	if len(descriptions) == 0 {
+		errorResponse(w, "Keine offenen Fehlvorstellungen in diesem Thema", http.StatusConflict)
+		return
+	}
+
+	content := h.topicContent(topic)
+
+	ctx, end := h.beginGeneration(w, r, h.withTopicSettings(r.Context(), topic))
+	defer end()
+	explanation, err := h.tutor.ExplainMisconceptions(ctx, topic, content, descriptions)
+	if err != nil {
+		generationFailed(w, ctx, fmt.Sprintf("Fehler bei der Erklärung: %v", err))
+		return
+	}
+	if previous, err := h.store.GetLatestExplanation(topic.ID); err == nil {
+		explanation.ParentID = previous.ID
+	}
+	explanation.Feedback = "misconceptions"
+
+	if !h.storeExplanation(w, r, topic, explanation) {
+		return
+	}
+	log.Printf("🧩 Erklärung gegen %d Fehlvorstellungen zu '%s'", len(descriptions), topic.Name)
+	jsonResponse(w, map[string]interface{}{
+		"explanation":    explanation,
+		"misconceptions": descriptions,
+	}, http.StatusCreated)
+}
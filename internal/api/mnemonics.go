@@ -0,0 +1,149 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/models"
+)
+
+// GenerateMnemonics erstellt Eselsbrücken, Analogien und Merkhilfen für die Schlüsselbegriffe eines Themas
+// und verknüpft sie mit passenden Glossar-Einträgen und Fragen (Karteikarten).
+// Body (optional): terms (Begriffe vorgeben)
+func (h *Handler) GenerateMnemonics(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req struct {
+		Terms []string `json:"terms"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+			return
+		}
+	}
+	var terms []string
+	for _, t := range req.Terms {
+		if t = strings.TrimSpace(t); t != "" {
+			terms = append(terms, t)
+		}
+	}
+
+	topic, err := h.store.GetTopic(id)
+	if err != nil {
+		errorResponse(w, "Thema nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	glossary, _ := h.store.GetAllGlossaryItems()
+	glossaryTerms := make([]string, len(glossary))
+	for i, item := range glossary {
+		glossaryTerms[i] = item.Term
+	}
+
+	ctx := r.Context()
+	mnemonics, err := h.tutor.GenerateMnemonics(ctx, topic, h.topicContent(topic), terms, glossaryTerms)
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler bei der Generierung: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var saved []models.Mnemonic
+	for i := range mnemonics {
+		m := &mnemonics[i]
+		if h.safety.Enabled() {
+			if check := h.safety.Check(ctx, m.Content); !check.Safe {
+				log.Printf("⚠️ Merkhilfe zu '%s' vom Inhaltsfilter verworfen: %s", m.Term, check.Reason)
+				continue
+			}
+		}
+		m.ID = fmt.Sprintf("mn_%d_%d", time.Now().UnixNano(), i)
+		m.CreatedAt = time.Now()
+		m.GlossaryIDs = glossaryMatches(m.Term, glossary)
+		m.QuestionIDs = questionMatches(m.Term, topic.Questions)
+		if err := h.store.SaveMnemonic(m); err != nil {
+			log.Printf("⚠️ Merkhilfe konnte nicht gespeichert werden: %v", err)
+			continue
+		}
+		saved = append(saved, *m)
+	}
+	if len(saved) == 0 {
+		errorResponse(w, "Keine Merkhilfen erzeugt", http.StatusUnprocessableEntity)
+		return
+	}
+
+	log.Printf("🧠 %d Merkhilfen zu '%s' erstellt", len(saved), topic.Name)
+	jsonResponse(w, saved, http.StatusCreated)
+}
+
+// GetTopicMnemonics listet die gespeicherten Merkhilfen eines Themas
+func (h *Handler) GetTopicMnemonics(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	mnemonics, err := h.store.GetMnemonicsByTopic(id)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	if mnemonics == nil {
+		mnemonics = []models.Mnemonic{}
+	}
+	jsonResponse(w, mnemonics, http.StatusOK)
+}
+
+// GetQuestionMnemonics liefert die Merkhilfen, die zu einer Frage (Karteikarte) verknüpft sind
+func (h *Handler) GetQuestionMnemonics(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	question, err := h.store.GetQuestion(id)
+	if err != nil {
+		errorResponse(w, "Frage nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	mnemonics, err := h.store.GetMnemonicsByQuestion(question.ID)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	if mnemonics == nil {
+		mnemonics = []models.Mnemonic{}
+	}
+	jsonResponse(w, mnemonics, http.StatusOK)
+}
+
+// glossaryMatches findet Glossar-Einträge zum Begriff (gleicher Begriff oder einer enthält den anderen)
+func glossaryMatches(term string, glossary []models.GlossaryItem) []string {
+	t := strings.ToLower(term)
+	var ids []string
+	for _, item := range glossary {
+		g := strings.ToLower(strings.TrimSpace(item.Term))
+		if g == "" {
+			continue
+		}
+		if g == t || (len(g) >= 4 && strings.Contains(t, g)) || (len(t) >= 4 && strings.Contains(g, t)) {
+			ids = append(ids, item.ID)
+		}
+	}
+	return ids
+}
+
+// questionMatches findet die Fragen, in deren Frage oder Musterlösung der Begriff vorkommt
+func questionMatches(term string, questions []models.Question) []string {
+	t := strings.ToLower(term)
+	var ids []string
+	for _, q := range questions {
+		if strings.Contains(strings.ToLower(q.Question), t) || strings.Contains(strings.ToLower(q.ExpectedAnswer), t) {
+			ids = append(ids, q.ID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/models"
+)
+
+// === Notizen Endpoints ===
+
+func (h *Handler) GetTopicNotes(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	notes, err := h.store.GetNotesByTopic(id)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	if notes == nil {
+		notes = []models.Note{}
+	}
+
+	jsonResponse(w, notes, http.StatusOK)
+}
+
+func (h *Handler) GetQuestionNotes(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	notes, err := h.store.GetNotesByQuestion(id)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	if notes == nil {
+		notes = []models.Note{}
+	}
+
+	jsonResponse(w, notes, http.StatusOK)
+}
+
+// CreateNote legt eine Notiz zu einem Thema oder einer Frage an.
+// Bei Fragen-Notizen wird das Thema aus der Frage übernommen.
+func (h *Handler) CreateNote(w http.ResponseWriter, r *http.Request) {
+	var note models.Note
+	if err := json.NewDecoder(r.Body).Decode(&note); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(note.Content) == "" {
+		errorResponse(w, "Notiz ist leer", http.StatusBadRequest)
+		return
+	}
+
+	if note.QuestionID != "" {
+		question, err := h.store.GetQuestion(note.QuestionID)
+		if err != nil {
+			errorResponse(w, "Frage nicht gefunden", http.StatusNotFound)
+			return
+		}
+		note.TopicID = question.TopicID
+	} else if note.TopicID == "" {
+		errorResponse(w, "topic_id oder question_id angeben", http.StatusBadRequest)
+		return
+	} else if _, err := h.store.GetTopic(note.TopicID); err != nil {
+		errorResponse(w, "Thema nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	note.ID = fmt.Sprintf("note_%d", time.Now().UnixNano())
+	note.CreatedAt = time.Now()
+	note.UpdatedAt = time.Now()
+
+	if err := h.store.SaveNote(&note); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, note, http.StatusCreated)
+}
+
+func (h *Handler) GetNote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	note, err := h.store.GetNote(id)
+	if err != nil {
+		errorResponse(w, "Notiz nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	jsonResponse(w, note, http.StatusOK)
+}
+
+// UpdateNote ändert den Inhalt einer Notiz (Zuordnung bleibt erhalten)
+func (h *Handler) UpdateNote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		errorResponse(w, "Notiz ist leer", http.StatusBadRequest)
+		return
+	}
+
+	note, err := h.store.GetNote(id)
+	if err != nil {
+		errorResponse(w, "Notiz nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	note.Content = req.Content
+	note.UpdatedAt = time.Now()
+
+	if err := h.store.SaveNote(note); err != nil {
+		errorResponse(w, "Fehler beim Aktualisieren", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, note, http.StatusOK)
+}
+
+func (h *Handler) DeleteNote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if err := h.store.DeleteNote(id); err != nil {
+		errorResponse(w, "Fehler beim Löschen", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]string{"message": "Notiz gelöscht"}, http.StatusOK)
+}
+
+// notesContext fasst die Notizen eines Themas für den Chat-Kontext zusammen
+func (h *Handler) notesContext(topicID string) string {
+	notes, err := h.store.GetNotesByTopic(topicID)
+	if err != nil || len(notes) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n=== Eigene Notizen des Lernenden ===\n")
+	for _, note := range notes {
+		sb.WriteString(note.Content)
+		sb.WriteString("\n---\n")
+	}
+	return sb.String()
+}
+
+// === Export ===
+
+// ExportStudyPlan liefert den Lernplan mit Themen, Lernzielen, Rechenbeispielen und Notizen als Markdown
+func (h *Handler) ExportStudyPlan(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	plan, err := h.store.GetStudyPlan(id)
+	if err != nil {
+		errorResponse(w, "Lernplan nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# %s\n\n", plan.Name))
+	if plan.Subject != "" {
+		sb.WriteString(fmt.Sprintf("- Fach: %s\n", plan.Subject))
+	}
+	sb.WriteString(fmt.Sprintf("- Prüfung: %s\n", plan.ExamDate.Format("02.01.2006")))
+	sb.WriteString(fmt.Sprintf("- Fortschritt: %.0f %%\n", plan.Progress))
+	sb.WriteString(fmt.Sprintf("- Exportiert: %s\n\n", time.Now().Format("02.01.2006 15:04")))
+	if plan.Notes != "" {
+		sb.WriteString("> " + strings.ReplaceAll(plan.Notes, "\n", "\n> ") + "\n\n")
+	}
+
+	// Prüfungsschwerpunkte aus dem Syllabus vorab
+	if syllabus, _ := h.store.GetSyllabus(plan.ID); len(syllabus) > 0 {
+		names := make(map[string]string)
+		for _, t := range leafTopics(plan.Topics) {
+			names[t.ID] = t.Name
+		}
+
+		sb.WriteString("## ⭐ Prüfungsschwerpunkte\n\n")
+		for _, item := range syllabus {
+			var mapped []string
+			for _, topicID := range item.TopicIDs {
+				if name, ok := names[topicID]; ok {
+					mapped = append(mapped, name)
+				}
+			}
+			if len(mapped) == 0 {
+				sb.WriteString(fmt.Sprintf("- %s *(kein passendes Thema)*\n", item.Text))
+			} else {
+				sb.WriteString(fmt.Sprintf("- %s → %s\n", item.Text, strings.Join(mapped, ", ")))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	h.writeTopicsMarkdown(&sb, plan.Topics, 2, "")
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "lernplan_"+plan.ID+".md"))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(sb.String()))
+}
+
+func (h *Handler) writeTopicsMarkdown(sb *strings.Builder, topics []models.Topic, level int, prefix string) {
+	if level > 6 {
+		level = 6
+	}
+
+	for i, t := range topics {
+		number := fmt.Sprintf("%s%d", prefix, i+1)
+		sb.WriteString(fmt.Sprintf("%s %s. %s\n\n", strings.Repeat("#", level), number, t.Name))
+		if t.Description != "" {
+			sb.WriteString(t.Description + "\n\n")
+		}
+		sb.WriteString(fmt.Sprintf("Status: %s · Fortschritt: %.0f %%\n\n", t.Status, t.Progress))
+		if t.ExamWeight > 0 {
+			sb.WriteString(fmt.Sprintf("⭐ **Prüfungsschwerpunkt** (Gewicht %.1f)\n\n", t.ExamWeight))
+		}
+
+		if objectives, _ := h.store.GetObjectivesByTopic(t.ID); len(objectives) > 0 {
+			sb.WriteString("**Lernziele**\n\n")
+			for _, obj := range objectives {
+				check := " "
+				if obj.Achieved {
+					check = "x"
+				}
+				sb.WriteString(fmt.Sprintf("- [%s] %s\n", check, obj.Text))
+			}
+			sb.WriteString("\n")
+		}
+
+		if examples, _ := h.store.GetWorkedExamplesByTopic(t.ID); len(examples) > 0 {
+			sb.WriteString("**Rechenbeispiele**\n\n")
+			writeWorkedExamplesMarkdown(sb, examples)
+		}
+
+		if notes, _ := h.store.GetNotesByTopic(t.ID); len(notes) > 0 {
+			sb.WriteString("**Notizen**\n\n")
+			for _, note := range notes {
+				if note.QuestionID != "" {
+					if q, err := h.store.GetQuestion(note.QuestionID); err == nil {
+						sb.WriteString(fmt.Sprintf("> Zur Frage: %s\n\n", q.Question))
+					}
+				}
+				sb.WriteString(note.Content + "\n\n")
+			}
+		}
+
+		h.writeTopicsMarkdown(sb, t.Subtopics, level+1, number+".")
+	}
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Wie lange das Ergebnis der LLM-Prüfung gilt: kurz genug, um ein wieder gestartetes Ollama
+// schnell zu bemerken, lang genug, um nicht jede Anfrage zu verzögern
+const llmStatusTTL = 15 * time.Second
+
+// llmFeature beschreibt eine Funktion, die das LLM-Backend braucht
+type llmFeature struct {
+	Name      string
+	Endpoints []string // "METHODE Pfadvorlage"
+	Offline   string   // was ohne LLM noch geht; leer = die Endpoints antworten mit 503
+}
+
+// llmFeatures ist die Übersicht, was ohne LLM-Backend wegfällt. Alles andere (Dokumente,
+// Lernpläne, Notizen, Glossar, Fortschritt, Exporte) arbeitet nur mit der Datenbank.
+var llmFeatures = []llmFeature{
+	{Name: "Chat", Endpoints: []string{
+		"POST /api/v1/chat", "POST /api/v1/chat/stream", "POST /api/v1/documents/{id}/chat",
+		"PUT /api/v1/chat/messages/{id}", "POST /api/v1/chat/messages/{id}/regenerate",
+		"POST /api/v1/chat/messages/{id}/to-flashcard", "POST /api/v1/chat/messages/{id}/to-glossary",
+	}},
+	{Name: "Lernplan erstellen", Endpoints: []string{
+		"POST /api/v1/plans", "POST /api/v1/plans/preview", "POST /api/v1/plans/confirm",
+		"POST /api/v1/plans/{id}/syllabus", "POST /api/v1/topics/{id}/split", "POST /api/v1/plans/jobs/{id}/resume",
+	}},
+	{Name: "Neue Erklärungen", Endpoints: []string{
+		"POST /api/v1/topics/{id}/explain/regenerate", "POST /api/v1/topics/{id}/address-misconceptions",
+	}},
+	{Name: "Fragen und Lernziele generieren", Endpoints: []string{
+		"POST /api/v1/topics/{id}/questions/generate", "POST /api/v1/topics/{id}/objectives/generate",
+	}},
+	{Name: "Lernhilfen", Endpoints: []string{
+		"POST /api/v1/topics/{id}/mnemonics", "POST /api/v1/topics/{id}/worked-examples",
+		"POST /api/v1/topics/{id}/teach-back", "POST /api/v1/compare",
+	}},
+	{Name: "Erklärung abrufen", Endpoints: []string{
+		"GET /api/v1/topics/{id}/explain", "GET /api/v1/topics/{id}/worksheet.pdf",
+	}, Offline: "letzte gespeicherte Erklärung des Themas"},
+	{Name: "Antworten bewerten", Endpoints: []string{
+		"POST /api/v1/questions/{id}/answer", "POST /api/v1/questions/{id}/answer/stream", "POST /api/v1/answers/batch",
+	}, Offline: "Multiple Choice wie gewohnt, offene Antworten mit self_correct selbst bewerten"},
+	{Name: "Einschätzungen des Tutors", Endpoints: []string{
+		"GET /api/v1/plans/{id}/readiness", "GET /api/v1/reports/weekly",
+	}, Offline: "Zahlen ohne Einschätzung"},
+}
+
+// llmRoutes ordnet die Endpoints, die ohne LLM mit 503 antworten, ihrer Funktion zu
+var llmRoutes = func() map[string]string {
+	routes := make(map[string]string)
+	for _, f := range llmFeatures {
+		if f.Offline != "" {
+			continue
+		}
+		for _, e := range f.Endpoints {
+			routes[e] = f.Name
+		}
+	}
+	return routes
+}()
+
+// llmStatus speichert das Ergebnis der letzten Erreichbarkeitsprüfung
+type llmStatus struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	available bool
+}
+
+// llmAvailable prüft, ob das LLM-Backend erreichbar ist (zwischengespeichert für llmStatusTTL)
+func (h *Handler) llmAvailable(ctx context.Context) bool {
+	h.llmStatus.mu.Lock()
+	defer h.llmStatus.mu.Unlock()
+
+	if time.Since(h.llmStatus.checkedAt) > llmStatusTTL {
+		ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+		h.llmStatus.available = h.llm.IsAvailable(ctx)
+		h.llmStatus.checkedAt = time.Now()
+	}
+	return h.llmStatus.available
+}
+
+// offlineReason beschreibt, warum LLM-Funktionen gerade fehlen
+func (h *Handler) offlineReason() string {
+	return fmt.Sprintf("%v (%s)", errLLMUnavailable, h.llm.GetName())
+}
+
+// llmUnavailable antwortet mit 503 und dem Grund, statt die Anfrage am Backend scheitern zu lassen
+func (h *Handler) llmUnavailable(w http.ResponseWriter, feature string) {
+	w.Header().Set("Retry-After", fmt.Sprintf("%d", int(llmStatusTTL.Seconds())))
+	jsonResponse(w, map[string]interface{}{
+		"error":   fmt.Sprintf("%s ist offline nicht verfügbar: %s", feature, h.offlineReason()),
+		"feature": feature,
+		"reason":  h.offlineReason(),
+		"offline": true,
+	}, http.StatusServiceUnavailable)
+}
+
+// offlineMiddleware lehnt Anfragen an LLM-Endpoints ab, solange das Backend nicht erreichbar ist
+func (h *Handler) offlineMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := mux.CurrentRoute(r); route != nil {
+			if tpl, err := route.GetPathTemplate(); err == nil {
+				if feature, ok := llmRoutes[r.Method+" "+tpl]; ok && !h.llmAvailable(r.Context()) {
+					h.llmUnavailable(w, feature)
+					return
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GetFeatures zeigt, welche Funktionen gerade verfügbar sind, und was ohne LLM-Backend bleibt
+func (h *Handler) GetFeatures(w http.ResponseWriter, r *http.Request) {
+	online := h.llmAvailable(r.Context())
+
+	features := make([]map[string]interface{}, len(llmFeatures))
+	for i, f := range llmFeatures {
+		feature := map[string]interface{}{
+			"name":      f.Name,
+			"available": online || f.Offline != "",
+			"endpoints": f.Endpoints,
+		}
+		if !online && f.Offline != "" {
+			feature["degraded"] = f.Offline
+		}
+		features[i] = feature
+	}
+
+	resp := map[string]interface{}{
+		"llm_available": online,
+		"llm_provider":  h.llm.GetName(),
+		"features":      features,
+		"offline": []string{
+			"Dokumente, Lernpläne, Themen und Lernziele ansehen und bearbeiten",
+			"Gespeicherte Erklärungen, Arbeitsblätter und Merkhilfen",
+			"Karteikarten und Quiz (offene Antworten mit Selbstbewertung)",
+			"Notizen, Glossar, Chat-Verläufe, Fortschritt und Exporte",
+		},
+	}
+	if !online {
+		resp["reason"] = h.offlineReason()
+	}
+	jsonResponse(w, resp, http.StatusOK)
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"time"
+
+	"lernplattform/internal/models"
+)
+
+// planPhaseDefs: Phasen bis zur Prüfung mit ihrem Anteil an der verbleibenden Zeit
+var planPhaseDefs = []struct {
+	phase string
+	label string
+	share float64
+}{
+	{"learn", "Lernen", 0.5},
+	{"practice", "Üben", 0.25},
+	{"review", "Wiederholen", 0.15},
+	{"dry_run", "Generalprobe", 0.1},
+}
+
+// phaseSettings legt fest, wie sich Fragen und Quiz in einer Phase verhalten
+type phaseSettings struct {
+	difficulty int   // Standard-Schwierigkeit neuer Fragen
+	quizBoxes  []int // Leitner-Boxen für das Quiz (nil = alle)
+	dueOnly    bool  // nur fällige Fragen
+	quizCount  int
+}
+
+var phaseDefaults = map[string]phaseSettings{
+	// Neues Material: leichte Fragen, neue und frisch gelernte Karten
+	"learn": {difficulty: 2, quizBoxes: []int{0, 1, 2}, quizCount: 10},
+	// Anwenden: mittlere Schwierigkeit, alles Fällige
+	"practice": {difficulty: 3, dueOnly: true, quizCount: 15},
+	// Lücken schließen: Schwächen aus den unteren Boxen
+	"review": {difficulty: 3, quizBoxes: []int{1, 2, 3}, quizCount: 20},
+	// Prüfungssimulation: schwere Fragen, quer durch alle Boxen
+	"dry_run": {difficulty: 4, quizCount: 30},
+}
+
+// planPhases teilt die Zeit zwischen start und Prüfung in Lernphasen auf.
+// Jede Phase bekommt mindestens einen Tag, solange genug Tage da sind; bei
+// sehr kurzer Vorbereitung fallen die frühen Phasen weg.
+func planPhases(start time.Time, examDate models.Date) []models.PlanPhase {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.Local)
+	exam := examDate.Time
+	totalDays := int(exam.Sub(start).Hours()/24 + 0.5)
+	if totalDays < 1 {
+		totalDays = 1
+	}
+
+	// Tage je Phase, von hinten aufgefüllt: die Generalprobe findet immer statt
+	days := make([]int, len(planPhaseDefs))
+	remaining := totalDays
+	for i := len(planPhaseDefs) - 1; i >= 0 && remaining > 0; i-- {
+		days[i] = 1
+		remaining--
+	}
+	for i, def := range planPhaseDefs {
+		if days[i] == 0 {
+			continue
+		}
+		extra := int(float64(totalDays)*def.share) - 1
+		extra = max(0, min(extra, remaining))
+		days[i] += extra
+		remaining -= extra
+	}
+	// Rundungsrest kommt der Lernphase zugute
+	for i := range days {
+		if days[i] > 0 {
+			days[i] += remaining
+			break
+		}
+	}
+
+	var phases []models.PlanPhase
+	cursor := start
+	for i, def := range planPhaseDefs {
+		if days[i] == 0 {
+			continue
+		}
+		end := cursor.AddDate(0, 0, days[i])
+		phases = append(phases, models.PlanPhase{Phase: def.phase, Label: def.label, Start: cursor, End: end})
+		cursor = end
+	}
+	return phases
+}
+
+// phasesOf liefert die gespeicherten Phasen eines Plans, ältere Pläne werden nachträglich eingeteilt
+func phasesOf(plan *models.StudyPlan) []models.PlanPhase {
+	if len(plan.Phases) > 0 {
+		return plan.Phases
+	}
+	return planPhases(plan.CreatedAt, plan.ExamDate)
+}
+
+// currentPhase bestimmt die Phase, in der sich ein Plan zum Zeitpunkt now befindet
+func currentPhase(plan *models.StudyPlan, now time.Time) *models.PlanPhase {
+	phases := phasesOf(plan)
+	if len(phases) == 0 {
+		return nil
+	}
+	for i := range phases {
+		if now.Before(phases[i].End) {
+			return &phases[i]
+		}
+	}
+	// Prüfung vorbei oder Prüfungstag: Generalprobe bleibt aktiv
+	return &phases[len(phases)-1]
+}
+
+// activePhaseSettings liefert die Einstellungen der aktuellen Phase eines Plans
+func (h *Handler) activePhaseSettings(planID string) (string, phaseSettings, bool) {
+	plan, err := h.store.GetStudyPlan(planID)
+	if err != nil || plan == nil {
+		return "", phaseSettings{}, false
+	}
+	phase := currentPhase(plan, time.Now())
+	if phase == nil {
+		return "", phaseSettings{}, false
+	}
+	return phase.Phase, phaseDefaults[phase.Phase], true
+}
+
+// phaseStatus beschreibt die aktuelle Phase und den nächsten Meilenstein für /status
+func phaseStatus(plan *models.StudyPlan, now time.Time) map[string]interface{} {
+	phase := currentPhase(plan, now)
+	if phase == nil {
+		return nil
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	status := map[string]interface{}{
+		"phase":           phase.Phase,
+		"label":           phase.Label,
+		"phase_ends":      phase.End,
+		"days_left_phase": max(0, int(phase.End.Sub(today).Hours()/24+0.5)),
+		"days_until_exam": max(0, plan.ExamDate.DaysFrom(now)),
+	}
+	for _, p := range phasesOf(plan) {
+		if p.Start.After(now) {
+			status["next_phase"] = p.Phase
+			status["next_phase_label"] = p.Label
+			break
+		}
+	}
+	return status
+}
@@ -0,0 +1,276 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"lernplattform/internal/llm"
+	"lernplattform/internal/models"
+)
+
+// Wie oft eine unterbrochene Lernplan-Erstellung automatisch wieder aufgenommen wird
+const maxPlanJobAttempts = 3
+
+// resumingJobs sind die Lernplan-Erstellungen, die gerade fortgesetzt werden oder darauf warten
+var (
+	resumingMu   sync.Mutex
+	resumingJobs = make(map[string]bool)
+)
+
+// beginResume reserviert eine Lernplan-Erstellung zum Fortsetzen; false = läuft bereits
+func beginResume(jobID string) bool {
+	resumingMu.Lock()
+	defer resumingMu.Unlock()
+	if resumingJobs[jobID] {
+		return false
+	}
+	resumingJobs[jobID] = true
+	return true
+}
+
+func endResume(jobID string) {
+	resumingMu.Lock()
+	delete(resumingJobs, jobID)
+	resumingMu.Unlock()
+}
+
+// planJobProgress schreibt den Zwischenstand einer Lernplan-Erstellung nach jedem
+// analysierten Dokument in die Datenbank (implementiert llm.AnalysisProgress)
+type planJobProgress struct {
+	h   *Handler
+	mu  sync.Mutex
+	job *models.PlanJob
+}
+
+// startPlanJob legt den Zwischenstand für eine neue Lernplan-Erstellung an
+func (h *Handler) startPlanJob(req planRequest) *planJobProgress {
+	now := time.Now()
+	job := &models.PlanJob{
+		ID:          fmt.Sprintf("planjob_%d", now.UnixNano()),
+		ExamDate:    req.ExamDate,
+		DocumentIDs: req.DocumentIDs,
+		ProfileID:   req.ProfileID,
+		Status:      "running",
+		Attempts:    1,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	p := &planJobProgress{h: h, job: job}
+	p.save()
+	return p
+}
+
+// save speichert den Stand; nur mit gehaltenem p.mu aufrufen (oder vor der ersten Nutzung)
+func (p *planJobProgress) save() {
+	p.job.UpdatedAt = time.Now()
+	p.job.DocumentsDone = len(p.job.DocumentTopics)
+	if err := p.h.store.SavePlanJob(p.job); err != nil {
+		log.Printf("   ⚠️ Zwischenstand der Lernplan-Erstellung nicht gespeichert: %v", err)
+	}
+}
+
+func (p *planJobProgress) DocumentTopics(docID string) ([]models.Topic, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	topics, ok := p.job.DocumentTopics[docID]
+	return topics, ok
+}
+
+func (p *planJobProgress) SaveDocumentTopics(docID string, topics []models.Topic) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.job.DocumentTopics == nil {
+		p.job.DocumentTopics = make(map[string][]models.Topic)
+	}
+	p.job.DocumentTopics[docID] = topics
+	p.save()
+}
+
+// analyzedTopics liefert das gespeicherte Analyseergebnis (nil = Analyse noch nicht fertig)
+func (p *planJobProgress) analyzedTopics() []models.Topic {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.job.Analyzed {
+		return nil
+	}
+	return append([]models.Topic{}, p.job.Topics...)
+}
+
+func (p *planJobProgress) saveAnalysis(topics []models.Topic) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.job.Topics, p.job.Analyzed = topics, true
+	p.save()
+}
+
+// saveEstimate hält die geschätzte Analysedauer fest; ohne Job passiert nichts
+func (p *planJobProgress) saveEstimate(estimate time.Duration, model string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.job.EstimatedSeconds, p.job.Model = int(estimate.Seconds()), model
+	p.save()
+}
+
+// finish schließt die Erstellung ab (done, failed oder canceled); ohne Job passiert nichts
+func (p *planJobProgress) finish(status, planID string, err error) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.job.Status, p.job.PlanID, p.job.Error = status, planID, ""
+	if err != nil {
+		p.job.Error = err.Error()
+	}
+	p.save()
+}
+
+// resumable meldet, ob eine Erstellung beim Start automatisch fortgesetzt wird:
+// "running" bleibt nach einem Absturz oder Neustart stehen, "failed" nach einem Fehler
+// des Backends (z.B. Zeitüberschreitung im Standby)
+func resumable(job models.PlanJob) bool {
+	return (job.Status == "running" || job.Status == "failed") && job.Attempts < maxPlanJobAttempts
+}
+
+// ResumePlanJobs setzt beim Start unterbrochene Lernplan-Erstellungen fort, älteste zuerst.
+// Bereits analysierte Dokumente werden übernommen; gewartet wird, bis das LLM-Backend erreichbar ist.
+func (h *Handler) ResumePlanJobs(ctx context.Context) {
+	jobs, err := h.store.GetPlanJobs()
+	if err != nil {
+		log.Printf("⚠️ Unterbrochene Lernplan-Erstellungen nicht geladen: %v", err)
+		return
+	}
+	for i := len(jobs) - 1; i >= 0; i-- {
+		if !resumable(jobs[i]) || !beginResume(jobs[i].ID) {
+			continue
+		}
+		log.Printf("↩️ Setze Lernplan-Erstellung %s fort (%d von %d Dokumenten analysiert)", jobs[i].ID, jobs[i].DocumentsDone, len(jobs[i].DocumentIDs))
+		for !h.llmAvailable(ctx) {
+			select {
+			case <-ctx.Done():
+				endResume(jobs[i].ID)
+				return
+			case <-time.After(30 * time.Second):
+			}
+		}
+		if err := h.resumePlanJob(ctx, jobs[i].ID); err != nil {
+			log.Printf("   ⚠️ Lernplan-Erstellung %s: %v", jobs[i].ID, err)
+		}
+		endResume(jobs[i].ID)
+	}
+}
+
+// resumePlanJob führt eine gespeicherte Lernplan-Erstellung zu Ende (der Aufrufer hält sie per beginResume).
+// Der Stand wird erst mit der Sperre geladen, damit eine inzwischen fertige Erstellung nicht doppelt läuft.
+func (h *Handler) resumePlanJob(parent context.Context, jobID string) error {
+	for !beginPlanCreation() {
+		select {
+		case <-parent.Done():
+			return parent.Err()
+		case <-time.After(10 * time.Second):
+		}
+	}
+	defer endPlanCreation()
+
+	job, err := h.store.GetPlanJob(jobID)
+	if err != nil {
+		return err
+	}
+	if job.Status == "done" {
+		return nil
+	}
+
+	p := &planJobProgress{h: h, job: job}
+	p.mu.Lock()
+	job.Status, job.Error = "running", ""
+	job.Attempts++
+	p.save()
+	p.mu.Unlock()
+
+	examDate, err := models.ParseDate(job.ExamDate)
+	if err != nil {
+		p.finish("failed", "", err)
+		return err
+	}
+	docs, allContent := h.loadPlanDocuments(job.DocumentIDs)
+	if len(docs) == 0 {
+		err := errors.New("keine gültigen Dokumente mehr vorhanden")
+		p.finish("failed", "", err)
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(parent, 15*time.Minute)
+	defer cancel()
+	plan, err := h.buildPlan(llm.WithPriority(ctx, llm.PriorityBackground), docs, allContent, examDate, p)
+	if err == nil {
+		plan.Documents, plan.ProfileID = job.DocumentIDs, job.ProfileID
+		err = h.persistPlan(plan)
+	}
+	if err != nil {
+		if parent.Err() != nil {
+			return err // Server fährt herunter: beim nächsten Start weiter
+		}
+		p.finish("failed", "", err)
+		return err
+	}
+	p.finish("done", plan.ID, nil)
+	h.changes.bump()
+	log.Printf("✅ Unterbrochene Lernplan-Erstellung abgeschlossen: %s", plan.Name)
+	return nil
+}
+
+// GetPlanJobs listet die Lernplan-Erstellungen mit ihrem Zwischenstand, neueste zuerst
+func (h *Handler) GetPlanJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.store.GetPlanJobs()
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	if jobs == nil {
+		jobs = []models.PlanJob{}
+	}
+	jsonResponse(w, jobs, http.StatusOK)
+}
+
+// ResumePlanJob setzt eine abgebrochene oder fehlgeschlagene Lernplan-Erstellung im
+// Hintergrund fort, ohne auf einen Neustart zu warten; der fertige Plan steht danach in plan_id
+func (h *Handler) ResumePlanJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	job, err := h.store.GetPlanJob(vars["id"])
+	if err != nil {
+		errorResponse(w, "Lernplan-Erstellung nicht gefunden", http.StatusNotFound)
+		return
+	}
+	if job.Status == "done" {
+		errorResponse(w, "Lernplan wurde bereits erstellt", http.StatusConflict)
+		return
+	}
+	if !beginResume(job.ID) {
+		errorResponse(w, "Lernplan-Erstellung wird bereits fortgesetzt", http.StatusConflict)
+		return
+	}
+	if !beginPlanCreation() {
+		endResume(job.ID)
+		errorResponse(w, "Lernplan wird bereits erstellt, bitte warten", http.StatusTooManyRequests)
+		return
+	}
+	endPlanCreation() // resumePlanJob sperrt selbst
+
+	go func() {
+		defer endResume(job.ID)
+		if err := h.resumePlanJob(context.Background(), job.ID); err != nil {
+			log.Printf("⚠️ Lernplan-Erstellung %s: %v", job.ID, err)
+		}
+	}()
+	jsonResponse(w, job, http.StatusAccepted)
+}
@@ -0,0 +1,244 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/llm"
+	"lernplattform/internal/models"
+)
+
+// Höchstlänge der Prompt-Vorlagen eines Fachprofils (Zeichen)
+const maxProfilePrompt = 4000
+
+// ensureDefaultProfiles legt fehlende mitgelieferte Fachprofile an (beim Start und nach dem Löschen aller Daten);
+// geänderte Profile bleiben unverändert
+func (h *Handler) ensureDefaultProfiles() {
+	now := time.Now()
+	for _, profile := range llm.DefaultProfiles() {
+		if _, err := h.store.GetSubjectProfile(profile.ID); err == nil {
+			continue
+		}
+		profile.Builtin = true
+		profile.CreatedAt = now
+		profile.UpdatedAt = now
+		if err := h.store.SaveSubjectProfile(&profile); err != nil {
+			log.Printf("⚠️ Fachprofil '%s' konnte nicht angelegt werden: %v", profile.Name, err)
+		}
+	}
+}
+
+// planProfile liefert das Fachprofil eines Lernplans (nil = ohne Profil)
+func (h *Handler) planProfile(planID string) *models.SubjectProfile {
+	plan, err := h.store.GetStudyPlan(planID)
+	if err != nil || plan.ProfileID == "" {
+		return nil
+	}
+	profile, err := h.store.GetSubjectProfile(plan.ProfileID)
+	if err != nil {
+		return nil
+	}
+	return profile
+}
+
+// withQuestionProfile hängt das Fachprofil zum Thema einer Frage an den Context (für Bewertungen)
+func (h *Handler) withQuestionProfile(ctx context.Context, question *models.Question) context.Context {
+	topic, err := h.store.GetTopic(question.TopicID)
+	if err != nil {
+		return ctx
+	}
+	return llm.WithProfile(ctx, h.planProfile(topic.StudyPlanID))
+}
+
+// questionTypePart ist ein Teil einer Fragengenerierung mit gemischten Typen
+type questionTypePart struct {
+	questionType string
+	count        int
+}
+
+// questionTypeMix teilt count nach der Gewichtung des Fachprofils auf die Fragetypen auf
+// (größte Reste zuerst). Mit vorgegebenem Typ oder ohne Profil bleibt es bei einem Aufruf.
+func questionTypeMix(profile *models.SubjectProfile, questionType string, count int) []questionTypePart {
+	if questionType != "" || profile == nil || count <= 0 {
+		return []questionTypePart{{questionType, count}}
+	}
+	var types []string
+	total := 0
+	for t, weight := range profile.QuestionTypes {
+		if weight > 0 && llm.ProfileQuestionTypes[t] {
+			types = append(types, t)
+			total += weight
+		}
+	}
+	if total == 0 {
+		return []questionTypePart{{questionType, count}}
+	}
+	sort.Strings(types)
+
+	parts := make([]questionTypePart, len(types))
+	remainders := make([]int, len(types))
+	assigned := 0
+	for i, t := range types {
+		share := count * profile.QuestionTypes[t]
+		parts[i] = questionTypePart{t, share / total}
+		remainders[i] = share % total
+		assigned += parts[i].count
+	}
+	order := make([]int, len(types))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for i := 0; assigned < count; i++ {
+		parts[order[i%len(order)]].count++
+		assigned++
+	}
+
+	mix := parts[:0]
+	for _, part := range parts {
+		if part.count > 0 {
+			mix = append(mix, part)
+		}
+	}
+	return mix
+}
+
+// validateProfile prüft ein Fachprofil aus einer Anfrage und setzt Standardwerte
+func validateProfile(profile *models.SubjectProfile) error {
+	profile.Name = strings.TrimSpace(profile.Name)
+	if profile.Name == "" {
+		return fmt.Errorf("Name fehlt")
+	}
+	if len(profile.ExplanationPrompt) > maxProfilePrompt || len(profile.QuestionPrompt) > maxProfilePrompt {
+		return fmt.Errorf("Prompt-Vorlagen dürfen höchstens %d Zeichen lang sein", maxProfilePrompt)
+	}
+	if profile.Strictness == "" {
+		profile.Strictness = llm.StrictnessNormal
+	}
+	if !llm.IsValidStrictness(profile.Strictness) {
+		return fmt.Errorf("Unbekannte Strenge: %s (lenient, normal, strict)", profile.Strictness)
+	}
+	for t, weight := range profile.QuestionTypes {
+		if !llm.ProfileQuestionTypes[t] {
+			return fmt.Errorf("Unbekannter Fragetyp: %s (open, multiple_choice, cloze, ordering)", t)
+		}
+		if weight < 0 {
+			return fmt.Errorf("Gewichtung für %s darf nicht negativ sein", t)
+		}
+		if weight == 0 {
+			delete(profile.QuestionTypes, t)
+		}
+	}
+	if profile.QuestionTypes == nil {
+		profile.QuestionTypes = map[string]int{}
+	}
+	return nil
+}
+
+// === Fachprofile Endpoints ===
+
+func (h *Handler) GetSubjectProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.store.GetSubjectProfiles()
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	if profiles == nil {
+		profiles = []models.SubjectProfile{}
+	}
+
+	jsonResponse(w, profiles, http.StatusOK)
+}
+
+func (h *Handler) GetSubjectProfile(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	profile, err := h.store.GetSubjectProfile(vars["id"])
+	if err != nil {
+		errorResponse(w, "Fachprofil nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	jsonResponse(w, profile, http.StatusOK)
+}
+
+// CreateSubjectProfile legt ein eigenes Fachprofil an
+func (h *Handler) CreateSubjectProfile(w http.ResponseWriter, r *http.Request) {
+	var profile models.SubjectProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+	if err := validateProfile(&profile); err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	profile.ID = fmt.Sprintf("profile_%d", time.Now().UnixNano())
+	profile.Builtin = false
+	profile.CreatedAt = time.Now()
+	profile.UpdatedAt = profile.CreatedAt
+	if err := h.store.SaveSubjectProfile(&profile); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, profile, http.StatusCreated)
+}
+
+// UpdateSubjectProfile ersetzt die Einstellungen eines Fachprofils (auch der mitgelieferten)
+func (h *Handler) UpdateSubjectProfile(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	existing, err := h.store.GetSubjectProfile(vars["id"])
+	if err != nil {
+		errorResponse(w, "Fachprofil nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	var profile models.SubjectProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+	if err := validateProfile(&profile); err != nil {
+		errorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	profile.ID = existing.ID
+	profile.Builtin = existing.Builtin
+	profile.CreatedAt = existing.CreatedAt
+	profile.UpdatedAt = time.Now()
+	if err := h.store.SaveSubjectProfile(&profile); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, profile, http.StatusOK)
+}
+
+// DeleteSubjectProfile löscht ein eigenes Fachprofil; Lernpläne damit laufen ohne Profil weiter
+func (h *Handler) DeleteSubjectProfile(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	profile, err := h.store.GetSubjectProfile(vars["id"])
+	if err != nil {
+		errorResponse(w, "Fachprofil nicht gefunden", http.StatusNotFound)
+		return
+	}
+	if profile.Builtin {
+		errorResponse(w, "Mitgelieferte Fachprofile können nur geändert, nicht gelöscht werden", http.StatusConflict)
+		return
+	}
+	if err := h.store.DeleteSubjectProfile(profile.ID); err != nil {
+		errorResponse(w, "Fehler beim Löschen", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]string{"message": "Fachprofil gelöscht"}, http.StatusOK)
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/models"
+	"lernplattform/internal/pdf"
+)
+
+// passageChars: Umfang der Textstelle um ein Zitat, wenn das Dokument keine Seiten hat
+const passageChars = 1500
+
+// questionSource ist die Fundstelle einer Frage im Material
+type questionSource struct {
+	QuestionID   string `json:"question_id"`
+	DocumentID   string `json:"document_id"`
+	DocumentName string `json:"document_name"`
+	Page         int    `json:"page,omitempty"`
+	PageEnd      int    `json:"page_end,omitempty"`
+	Quote        string `json:"quote,omitempty"`
+	Passage      string `json:"passage"`
+	Approximate  bool   `json:"approximate"` // keine genaue Fundstelle, nur die Quelle des Themas
+}
+
+// sourceDocuments liefert die Dokumente, in denen das Material eines Themas steht:
+// zuerst die Quellen des Themas, danach die übrigen Dokumente des Plans
+func (h *Handler) sourceDocuments(topic *models.Topic) []*models.Document {
+	var docs []*models.Document
+	seen := make(map[string]bool)
+	add := func(id string) {
+		if id == "" || seen[id] {
+			return
+		}
+		seen[id] = true
+		if doc, _ := h.store.GetDocument(id); doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+
+	for _, src := range topic.Sources {
+		add(src.DocumentID)
+	}
+	if plan, _ := h.store.GetStudyPlan(topic.StudyPlanID); plan != nil {
+		for _, id := range plan.Documents {
+			add(id)
+		}
+	}
+	return docs
+}
+
+// locateQuestionSources sucht die Zitate der generierten Fragen in den Dokumenten
+// und merkt sich Dokument und Seite
+func (h *Handler) locateQuestionSources(topic *models.Topic, questions []models.Question) {
+	var docs []*models.Document
+	for i := range questions {
+		q := &questions[i]
+		if q.SourceQuote == "" {
+			continue
+		}
+		if docs == nil {
+			docs = h.sourceDocuments(topic)
+		}
+		for _, doc := range docs {
+			if offset := pdf.FindQuote(doc.Content, q.SourceQuote); offset >= 0 {
+				q.SourceDocumentID = doc.ID
+				q.SourcePage = pdf.PageAt(doc.Content, offset)
+				break
+			}
+		}
+	}
+}
+
+// GetQuestionSource liefert die Stelle im Skript, auf der eine Frage beruht
+func (h *Handler) GetQuestionSource(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	question, err := h.store.GetQuestion(id)
+	if err != nil {
+		errorResponse(w, "Frage nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	result := questionSource{QuestionID: question.ID, Quote: question.SourceQuote}
+
+	if question.SourceDocumentID != "" {
+		if doc, _ := h.store.GetDocument(question.SourceDocumentID); doc != nil {
+			result.DocumentID = doc.ID
+			result.DocumentName = documentTitle(doc)
+			result.Page = question.SourcePage
+			if question.SourcePage > 0 {
+				result.Passage = pdf.ExtractPages(doc.Content, question.SourcePage, question.SourcePage)
+			} else if offset := pdf.FindQuote(doc.Content, question.SourceQuote); offset >= 0 {
+				result.Passage = passageAround(doc.Content, offset, passageChars)
+			}
+		}
+	}
+
+	// Ohne genaue Fundstelle: Quellseiten des Themas
+	if result.Passage == "" {
+		topic, _ := h.store.GetTopic(question.TopicID)
+		if topic == nil || len(topic.Sources) == 0 {
+			errorResponse(w, "Keine Quelle für diese Frage bekannt", http.StatusNotFound)
+			return
+		}
+		src := topic.Sources[0]
+		doc, _ := h.store.GetDocument(src.DocumentID)
+		if doc == nil {
+			errorResponse(w, "Quelldokument nicht mehr vorhanden", http.StatusNotFound)
+			return
+		}
+		result.DocumentID = doc.ID
+		result.DocumentName = documentTitle(doc)
+		result.Page = src.PageStart
+		result.PageEnd = src.PageEnd
+		result.Approximate = true
+		if src.PageStart > 0 {
+			result.Passage = pdf.ExtractPages(doc.Content, src.PageStart, src.PageEnd)
+		}
+		if result.Passage == "" {
+			result.Passage = passageAround(doc.Content, 0, passageChars)
+		}
+	}
+
+	jsonResponse(w, result, http.StatusOK)
+}
+
+// passageAround schneidet etwa size Zeichen um offset aus, an Zeilengrenzen ausgerichtet
+func passageAround(content string, offset, size int) string {
+	start := max(0, offset-size/3)
+	end := min(len(content), start+size)
+
+	if i := strings.LastIndex(content[:start], "\n"); i >= 0 && start-i < 200 {
+		start = i + 1
+	}
+	if i := strings.Index(content[end:], "\n"); i >= 0 && i < 200 {
+		end += i
+	}
+	// Nicht mitten in einem UTF-8-Zeichen schneiden
+	for start > 0 && !utf8.RuneStart(content[start]) {
+		start--
+	}
+	for end < len(content) && !utf8.RuneStart(content[end]) {
+		end++
+	}
+	return strings.TrimSpace(content[start:end])
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/llm"
+)
+
+// exportedQuestion ist eine Frage in der HTML-Datei; Abbildungen werden als data-URL eingebettet,
+// damit die Datei ohne Server funktioniert
+type exportedQuestion struct {
+	Number   int
+	Text     string
+	Kind     string
+	Options  []string
+	Ordering bool
+	Hints    []string
+	Answer   string
+	Figure   template.URL
+}
+
+type exportedQuiz struct {
+	Name      string
+	Date      string
+	TimeLimit int
+	Questions []exportedQuestion
+}
+
+var quizExportTemplate = template.Must(template.New("quiz").Funcs(template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html lang="de"><head><meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Name}}</title>
+<style>
+body{font-family:sans-serif;max-width:760px;margin:auto;padding:16px;color:#222;line-height:1.5}
+.question{border:1px solid #ddd;border-radius:8px;padding:12px 16px;margin:16px 0}
+.kind{color:#888;font-size:13px}
+img{display:block;max-width:100%;max-height:420px;margin:8px auto}
+details{margin-top:8px}
+summary{cursor:pointer;display:inline-block;padding:4px 10px;border-radius:6px;background:#eef2ff}
+.answer{margin-top:8px;padding:8px 12px;background:#f3faf3;border-radius:6px;white-space:pre-wrap}
+</style></head><body>
+<h1>📝 {{.Name}}</h1>
+<p class="kind">{{len .Questions}} Fragen · exportiert am {{.Date}}{{if .TimeLimit}} · Zeitlimit {{.TimeLimit}} Min.{{end}}</p>
+{{range .Questions}}<div class="question">
+<p><b>{{.Number}}.</b> {{.Text}} <span class="kind">({{.Kind}})</span></p>
+{{if .Figure}}<img src="{{.Figure}}" alt="Abbildung zu Frage {{.Number}}">{{end}}
+{{if .Options}}{{if .Ordering}}<ol type="A">{{else}}<ul>{{end}}{{range .Options}}<li>{{.}}</li>{{end}}{{if .Ordering}}</ol>{{else}}</ul>{{end}}{{end}}
+{{range $i, $hint := .Hints}}<details><summary>💡 Hinweis {{$i | inc}}</summary><div class="answer">{{$hint}}</div></details>{{end}}
+<details><summary>✅ Antwort zeigen</summary><div class="answer">{{.Answer}}</div></details>
+</div>
+{{end}}</body></html>
+`))
+
+// questionKinds beschriftet die Fragetypen in der HTML-Datei
+var questionKinds = map[string]string{
+	"multiple_choice": "Multiple Choice",
+	"cloze":           "Lückentext",
+	"ordering":        "Reihenfolge",
+	"image":           "Abbildung",
+}
+
+// ExportQuizHTML liefert ein Quiz als eigenständige HTML-Datei zum Weitergeben: Fragen, Hinweise und
+// aufklappbare Antworten, ohne Skripte und ohne Verbindung zum Server
+func (h *Handler) ExportQuizHTML(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	quiz, err := h.store.GetQuiz(vars["id"])
+	if err != nil {
+		errorResponse(w, "Quiz nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	questions := h.drawQuizQuestions(quiz)
+	if len(questions) == 0 {
+		errorResponse(w, "Keine Fragen für dieses Quiz vorhanden", http.StatusUnprocessableEntity)
+		return
+	}
+
+	export := exportedQuiz{
+		Name:      quiz.Name,
+		Date:      time.Now().Format("02.01.2006"),
+		TimeLimit: quiz.TimeLimitMinutes,
+	}
+	for i := range questions {
+		q := &questions[i]
+		canonical := append([]string(nil), q.Options...)
+		h.shuffleOptions(q)
+		item := exportedQuestion{
+			Number:   i + 1,
+			Text:     q.Question,
+			Kind:     questionKinds[q.Type],
+			Options:  q.Options,
+			Ordering: q.Type == "ordering",
+			Hints:    q.Hints,
+			Answer:   q.ExpectedAnswer,
+		}
+		if item.Kind == "" {
+			item.Kind = "Offene Frage"
+		}
+		if item.Ordering {
+			item.Answer = orderingLetters(canonical, q.Options) + "\n" + strings.Join(canonical, llm.OrderingSeparator)
+		}
+		if q.FigureID != "" {
+			if figure, err := h.store.GetFigure(q.FigureID); err == nil {
+				item.Figure = template.URL(fmt.Sprintf("data:%s;base64,%s", figure.MimeType, base64.StdEncoding.EncodeToString(figure.Data)))
+			}
+		}
+		export.Questions = append(export.Questions, item)
+	}
+
+	var buf bytes.Buffer
+	if err := quizExportTemplate.Execute(&buf, export); err != nil {
+		errorResponse(w, "Fehler beim Erstellen der Datei", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "quiz_"+quiz.ID+".html"))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// orderingLetters gibt die richtige Reihenfolge als Buchstaben der angezeigten Schritte an ("C → A → B")
+func orderingLetters(canonical, displayed []string) string {
+	letters := make([]string, len(canonical))
+	for i, step := range canonical {
+		for j, shown := range displayed {
+			if shown == step {
+				letters[i] = string(rune('A' + j))
+				break
+			}
+		}
+	}
+	return strings.Join(letters, llm.OrderingSeparator)
+}
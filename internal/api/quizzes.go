@@ -0,0 +1,314 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/llm"
+	"lernplattform/internal/models"
+)
+
+// Höchstens so viele Fragen je eigenem Quiz (feste und zufällige zusammen)
+const maxQuizQuestions = 100
+
+// quizRun ist ein gestarteter Durchlauf: die gezogenen Fragen und, mit Zeitlimit, das Ende der Bearbeitungszeit
+type quizRun struct {
+	Result    *models.QuizResult `json:"result"`
+	Questions []models.Question  `json:"questions"`
+	EndsAt    *time.Time         `json:"ends_at,omitempty"`
+}
+
+// === Eigene Quizze Endpoints ===
+
+// CreateQuiz speichert ein selbst zusammengestelltes Quiz: feste Fragen, zufällige Fragen je Thema
+// (optional nur einer kognitiven Stufe), Zeitlimit und ob die Reihenfolge gemischt wird
+func (h *Handler) CreateQuiz(w http.ResponseWriter, r *http.Request) {
+	var quiz models.Quiz
+	if err := json.NewDecoder(r.Body).Decode(&quiz); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+
+	quiz.Name = strings.TrimSpace(quiz.Name)
+	if quiz.Name == "" {
+		errorResponse(w, "Name fehlt", http.StatusBadRequest)
+		return
+	}
+	if quiz.TimeLimitMinutes < 0 {
+		errorResponse(w, "Ungültiges Zeitlimit", http.StatusBadRequest)
+		return
+	}
+	if quiz.CognitiveLevel != "" && !llm.IsValidCognitiveLevel(quiz.CognitiveLevel) {
+		errorResponse(w, "Ungültige kognitive Stufe (remember, understand, apply, analyze)", http.StatusBadRequest)
+		return
+	}
+	if quiz.StudyPlanID == "" {
+		plan, err := h.store.GetActiveStudyPlan()
+		if err != nil {
+			errorResponse(w, "Kein aktiver Lernplan", http.StatusNotFound)
+			return
+		}
+		quiz.StudyPlanID = plan.ID
+	} else if _, err := h.store.GetStudyPlan(quiz.StudyPlanID); err != nil {
+		errorResponse(w, "Lernplan nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	total := 0
+	seen := make(map[string]bool)
+	planOf := make(map[string]string) // Thema -> Lernplan
+	for _, id := range quiz.QuestionIDs {
+		if seen[id] {
+			errorResponse(w, fmt.Sprintf("Frage %s ist doppelt angegeben", id), http.StatusBadRequest)
+			return
+		}
+		seen[id] = true
+		q, err := h.store.GetQuestion(id)
+		if err != nil {
+			errorResponse(w, fmt.Sprintf("Frage %s nicht gefunden", id), http.StatusBadRequest)
+			return
+		}
+		if planOf[q.TopicID] == "" {
+			if topic, err := h.store.GetTopic(q.TopicID); err == nil {
+				planOf[q.TopicID] = topic.StudyPlanID
+			}
+		}
+		if planOf[q.TopicID] != quiz.StudyPlanID {
+			errorResponse(w, fmt.Sprintf("Frage %s gehört nicht zum Lernplan", id), http.StatusBadRequest)
+			return
+		}
+		total++
+	}
+	for _, tc := range quiz.TopicCounts {
+		if tc.Count <= 0 {
+			errorResponse(w, "Anzahl je Thema muss größer als 0 sein", http.StatusBadRequest)
+			return
+		}
+		topic, err := h.store.GetTopic(tc.TopicID)
+		if err != nil {
+			errorResponse(w, fmt.Sprintf("Thema %s nicht gefunden", tc.TopicID), http.StatusNotFound)
+			return
+		}
+		if topic.StudyPlanID != quiz.StudyPlanID {
+			errorResponse(w, fmt.Sprintf("Thema %s gehört nicht zum Lernplan", tc.TopicID), http.StatusBadRequest)
+			return
+		}
+		total += tc.Count
+	}
+	if total == 0 {
+		errorResponse(w, "question_ids oder topic_counts angeben", http.StatusBadRequest)
+		return
+	}
+	if total > maxQuizQuestions {
+		errorResponse(w, fmt.Sprintf("Maximal %d Fragen pro Quiz", maxQuizQuestions), http.StatusBadRequest)
+		return
+	}
+	if quiz.QuestionIDs == nil {
+		quiz.QuestionIDs = []string{}
+	}
+	if quiz.TopicCounts == nil {
+		quiz.TopicCounts = []models.QuizTopicCount{}
+	}
+
+	quiz.ID = fmt.Sprintf("quiz_%d", time.Now().UnixNano())
+	quiz.CreatedAt = time.Now()
+	if err := h.store.SaveQuiz(&quiz); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, quiz, http.StatusCreated)
+}
+
+// GetQuizzes listet die eigenen Quizze (optional ?plan_id=)
+func (h *Handler) GetQuizzes(w http.ResponseWriter, r *http.Request) {
+	quizzes, err := h.store.GetQuizzes(r.URL.Query().Get("plan_id"))
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	if quizzes == nil {
+		quizzes = []models.Quiz{}
+	}
+
+	jsonResponse(w, quizzes, http.StatusOK)
+}
+
+func (h *Handler) GetSavedQuiz(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	quiz, err := h.store.GetQuiz(vars["id"])
+	if err != nil {
+		errorResponse(w, "Quiz nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	jsonResponse(w, quiz, http.StatusOK)
+}
+
+func (h *Handler) DeleteQuiz(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if _, err := h.store.GetQuiz(vars["id"]); err != nil {
+		errorResponse(w, "Quiz nicht gefunden", http.StatusNotFound)
+		return
+	}
+	if err := h.store.DeleteQuiz(vars["id"]); err != nil {
+		errorResponse(w, "Fehler beim Löschen", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]string{"message": "Quiz gelöscht"}, http.StatusOK)
+}
+
+// StartQuiz zieht die Fragen für einen neuen Durchlauf
+func (h *Handler) StartQuiz(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	quiz, err := h.store.GetQuiz(vars["id"])
+	if err != nil {
+		errorResponse(w, "Quiz nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	questions := h.drawQuizQuestions(quiz)
+	if len(questions) == 0 {
+		errorResponse(w, "Keine Fragen für dieses Quiz vorhanden", http.StatusUnprocessableEntity)
+		return
+	}
+
+	result := &models.QuizResult{
+		ID:        fmt.Sprintf("quizrun_%d", time.Now().UnixNano()),
+		QuizID:    quiz.ID,
+		StartedAt: time.Now(),
+	}
+	for i := range questions {
+		result.QuestionIDs = append(result.QuestionIDs, questions[i].ID)
+		h.shuffleOptions(&questions[i])
+	}
+	if err := h.store.SaveQuizResult(result); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+
+	run := quizRun{Result: result, Questions: questions}
+	if quiz.TimeLimitMinutes > 0 {
+		endsAt := result.StartedAt.Add(time.Duration(quiz.TimeLimitMinutes) * time.Minute)
+		run.EndsAt = &endsAt
+	}
+	jsonResponse(w, run, http.StatusCreated)
+}
+
+// drawQuizQuestions zieht die Fragen eines Quiz: Die festen Fragen kommen immer vor,
+// je Thema werden zusätzlich zufällige Fragen gewählt (mit Stufe nur aus dieser); gelöschte Fragen fallen weg.
+func (h *Handler) drawQuizQuestions(quiz *models.Quiz) []models.Question {
+	var questions []models.Question
+	chosen := make(map[string]bool)
+	for _, id := range quiz.QuestionIDs {
+		q, err := h.store.GetQuestion(id)
+		if err != nil || chosen[q.ID] {
+			continue
+		}
+		chosen[q.ID] = true
+		questions = append(questions, *q)
+	}
+	for _, tc := range quiz.TopicCounts {
+		candidates, _ := h.store.GetQuestionsByTopic(tc.TopicID)
+		rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+		taken := 0
+		for _, q := range candidates {
+			if taken >= tc.Count {
+				break
+			}
+			if chosen[q.ID] || (quiz.CognitiveLevel != "" && q.CognitiveLevel != quiz.CognitiveLevel) {
+				continue
+			}
+			chosen[q.ID] = true
+			questions = append(questions, q)
+			taken++
+		}
+	}
+
+	if quiz.Shuffle {
+		rand.Shuffle(len(questions), func(i, j int) { questions[i], questions[j] = questions[j], questions[i] })
+	}
+	return questions
+}
+
+// FinishQuiz schließt einen Durchlauf ab. Gewertet wird je Frage der letzte Antwortversuch seit dem Start
+// (eingereicht über die normalen Antwort-Endpoints); Teilpunkte wie bei Reihenfolgen zählen anteilig.
+func (h *Handler) FinishQuiz(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	quiz, err := h.store.GetQuiz(vars["id"])
+	if err != nil {
+		errorResponse(w, "Quiz nicht gefunden", http.StatusNotFound)
+		return
+	}
+	result, err := h.store.GetQuizResult(vars["resultId"])
+	if err != nil || result.QuizID != quiz.ID {
+		errorResponse(w, "Durchlauf nicht gefunden", http.StatusNotFound)
+		return
+	}
+	if result.FinishedAt != nil {
+		jsonResponse(w, result, http.StatusOK)
+		return
+	}
+
+	finishedAt := time.Now()
+	result.Answered, result.Correct = 0, 0
+	points := 0
+	for _, id := range result.QuestionIDs {
+		attempts, _ := h.store.GetAttempts(id)
+		var last *models.QuestionAttempt
+		for i := range attempts {
+			if !attempts[i].CreatedAt.Before(result.StartedAt) && (last == nil || attempts[i].CreatedAt.After(last.CreatedAt)) {
+				last = &attempts[i]
+			}
+		}
+		if last == nil {
+			continue
+		}
+		result.Answered++
+		if last.IsCorrect {
+			result.Correct++
+			points += 100
+		} else {
+			points += last.Score
+		}
+	}
+	if len(result.QuestionIDs) > 0 {
+		result.Score = points / len(result.QuestionIDs)
+	}
+	result.FinishedAt = &finishedAt
+	result.Overtime = quiz.TimeLimitMinutes > 0 &&
+		finishedAt.Sub(result.StartedAt) > time.Duration(quiz.TimeLimitMinutes)*time.Minute
+
+	if err := h.store.SaveQuizResult(result); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, result, http.StatusOK)
+}
+
+// GetQuizResults liefert die bisherigen Durchläufe eines Quiz, neueste zuerst
+func (h *Handler) GetQuizResults(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if _, err := h.store.GetQuiz(vars["id"]); err != nil {
+		errorResponse(w, "Quiz nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	results, err := h.store.GetQuizResults(vars["id"])
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	if results == nil {
+		results = []models.QuizResult{}
+	}
+
+	jsonResponse(w, results, http.StatusOK)
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/models"
+)
+
+// Gewichte der Teilwerte im Bereitschaftswert eines Themas
+const (
+	readinessAccuracyWeight = 0.45
+	readinessCoverageWeight = 0.30
+	readinessRecencyWeight  = 0.25
+)
+
+// Anteil der verbleibenden Zeit am Gesamtwert: reicht die Zeit bis zur Prüfung, um die Lücken zu schließen?
+const readinessTimeWeight = 0.2
+
+// Themen unter diesem Wert gelten als Lücke
+const readinessGapThreshold = 70
+
+// Fragen aus alten Klausuren zählen bei Trefferquote und Abdeckung doppelt
+const examQuestionWeight = 2
+
+// topicReadiness ist die Prüfungsbereitschaft eines Themas (alle Werte 0-100)
+type topicReadiness struct {
+	TopicID    string  `json:"topic_id"`
+	TopicName  string  `json:"topic_name"`
+	Score      int     `json:"score"`
+	Accuracy   int     `json:"accuracy"` // letzte Antwort je Frage richtig
+	Coverage   int     `json:"coverage"` // Anteil der Fragen, die schon beantwortet wurden
+	Recency    int     `json:"recency"`  // geschätzte Behaltensquote laut Vergessenskurve
+	Questions  int     `json:"questions"`
+	Answered   int     `json:"answered"`
+	Exam       int     `json:"exam_questions,omitempty"` // davon aus alten Klausuren
+	ExamWeight float64 `json:"exam_weight,omitempty"`
+}
+
+// GetReadiness berechnet die Prüfungsbereitschaft eines Plans je Thema und insgesamt.
+// Je Thema zählen Trefferquote, Abdeckung (Klausurfragen doppelt) und Auffrischung; insgesamt zusätzlich, ob die
+// verbleibende Zeit bis zur Prüfung für die offenen Lücken reicht. Mit narrative=true beschreibt
+// der Tutor die größten Lücken (ein LLM-Aufruf, daher nur auf Anfrage).
+func (h *Handler) GetReadiness(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	plan, err := h.store.GetStudyPlan(id)
+	if err != nil {
+		errorResponse(w, "Lernplan nicht gefunden", http.StatusNotFound)
+		return
+	}
+	questions, err := h.store.GetQuestionsByPlan(plan.ID)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	attempts, err := h.store.GetAttemptsByPlan(plan.ID)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+
+	questionCount, examCount := make(map[string]int), make(map[string]int)
+	weightOf := make(map[string]int, len(questions))
+	byTopicQuestions := make(map[string][]string)
+	for _, q := range questions {
+		questionCount[q.TopicID]++
+		weightOf[q.ID] = 1
+		if q.ExamRelevant {
+			examCount[q.TopicID]++
+			weightOf[q.ID] = examQuestionWeight
+		}
+		byTopicQuestions[q.TopicID] = append(byTopicQuestions[q.TopicID], q.ID)
+	}
+	byTopic := make(map[string][]models.QuestionAttempt)
+	lastCorrect := make(map[string]bool) // Versuche sind nach Zeit sortiert: der letzte zählt
+	for _, a := range attempts {
+		byTopic[a.TopicID] = append(byTopic[a.TopicID], a)
+		lastCorrect[a.QuestionID] = a.IsCorrect && a.HintsUsed == 0
+	}
+
+	now := time.Now()
+	topics := []topicReadiness{}
+	var weighted, weights, neededMinutes float64
+	for _, t := range leafTopics(plan.Topics) {
+		tr := topicReadiness{TopicID: t.ID, TopicName: t.Name, Questions: questionCount[t.ID], Exam: examCount[t.ID], ExamWeight: t.ExamWeight}
+
+		var total, done, correct int
+		for _, questionID := range byTopicQuestions[t.ID] {
+			total += weightOf[questionID]
+			if _, answered := lastCorrect[questionID]; !answered {
+				continue
+			}
+			tr.Answered++
+			done += weightOf[questionID]
+			if lastCorrect[questionID] {
+				correct += weightOf[questionID]
+			}
+		}
+		tr.Accuracy = int(math.Round(percent(correct, done)))
+		switch {
+		case total > 0:
+			tr.Coverage = int(math.Round(percent(done, total)))
+		case t.Status == "completed":
+			tr.Coverage = 100
+		}
+		if retention, _, _ := estimateRetention(byTopic[t.ID], now); retention > 0 {
+			tr.Recency = int(math.Round(retention * 100))
+		}
+		tr.Score = int(math.Round(readinessAccuracyWeight*float64(tr.Accuracy) +
+			readinessCoverageWeight*float64(tr.Coverage) +
+			readinessRecencyWeight*float64(tr.Recency)))
+
+		weight := examWeightFactor(t.ExamWeight)
+		weighted += weight * float64(tr.Score)
+		weights += weight
+		neededMinutes += float64(max(t.EstMinutes, 15)) * float64(100-tr.Score) / 100
+		topics = append(topics, tr)
+	}
+
+	knowledge := 0.0
+	if weights > 0 {
+		knowledge = weighted / weights
+	}
+
+	// Verfügbare Zeit: verbleibende Tage im Tempo des Plans (Gesamtminuten / Plandauer)
+	daysLeft := float64(max(plan.ExamDate.DaysFrom(now), 0))
+	planDays := max(plan.ExamDate.Sub(plan.CreatedAt).Hours()/24, 1)
+	availableMinutes := daysLeft * float64(plan.TotalMinutes) / planDays
+	timeScore := 1.0
+	if neededMinutes > 0 {
+		timeScore = min(availableMinutes/neededMinutes, 1)
+	}
+	overall := int(math.Round((1-readinessTimeWeight)*knowledge + readinessTimeWeight*100*timeScore))
+	if len(topics) == 0 {
+		overall = 0
+	}
+
+	gaps := make([]topicReadiness, 0, len(topics))
+	for _, tr := range topics {
+		if tr.Score < readinessGapThreshold {
+			gaps = append(gaps, tr)
+		}
+	}
+	sort.SliceStable(gaps, func(i, j int) bool { return gaps[i].Score < gaps[j].Score })
+
+	resp := map[string]interface{}{
+		"plan_id":           plan.ID,
+		"plan_name":         plan.Name,
+		"overall":           overall,
+		"knowledge":         int(math.Round(knowledge)),
+		"time_score":        int(math.Round(timeScore * 100)),
+		"days_left":         int(daysLeft),
+		"needed_minutes":    int(math.Round(neededMinutes)),
+		"available_minutes": int(math.Round(availableMinutes)),
+		"topics":            topics,
+		"gaps":              gaps,
+	}
+
+	if r.URL.Query().Get("narrative") == "true" && len(topics) > 0 && h.llmAvailable(r.Context()) {
+		narrative, err := h.tutor.ReadinessNarrative(r.Context(), readinessSummary(plan, overall, int(daysLeft), neededMinutes, availableMinutes, topics))
+		if err != nil {
+			log.Printf("   ⚠️ Prüfungsbereitschaft ohne Einschätzung: %v", err)
+		} else {
+			resp["narrative"] = narrative
+		}
+	}
+
+	jsonResponse(w, resp, http.StatusOK)
+}
+
+// readinessSummary beschreibt die Bereitschaftswerte in Textform für das LLM
+func readinessSummary(plan *models.StudyPlan, overall, daysLeft int, needed, available float64, topics []topicReadiness) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Lernplan: %s, Prüfung am %s (noch %d Tage)\n", plan.Name, plan.ExamDate.Format("02.01.2006"), daysLeft)
+	fmt.Fprintf(&sb, "Gesamtbereitschaft: %d von 100\n", overall)
+	fmt.Fprintf(&sb, "Geschätzter Lernaufwand für die Lücken: %.0f Minuten, verfügbar im bisherigen Tempo: %.0f Minuten\n\n", needed, available)
+	for _, t := range topics {
+		fmt.Fprintf(&sb, "- %s: %d (Trefferquote %d, Abdeckung %d von %d Fragen, Auffrischung %d)",
+			t.TopicName, t.Score, t.Accuracy, t.Answered, t.Questions, t.Recency)
+		if t.Exam > 0 {
+			fmt.Fprintf(&sb, ", %d Fragen aus alten Klausuren", t.Exam)
+		}
+		if t.ExamWeight > 0 {
+			fmt.Fprintf(&sb, ", Prüfungsgewicht %.1f-fach", t.ExamWeight)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/llm"
+	"lernplattform/internal/models"
+)
+
+// Fragen im Rückblick-Quiz nach einer Lernsitzung
+const recapQuestionCount = 3
+
+// sessionTopics ermittelt, was in einer Sitzung gelernt wurde: das Thema der Sitzung
+// und die Themen der in dieser Zeit beantworteten Fragen (häufigste zuerst)
+func (h *Handler) sessionTopics(session *models.StudySession) []*models.Topic {
+	counts := make(map[string]int)
+	if session.TopicID != "" {
+		counts[session.TopicID] = 0
+	}
+	attempts, _ := h.store.GetAllAttempts()
+	for _, a := range attempts {
+		if a.TopicID == "" || a.CreatedAt.Before(session.StartedAt) || a.CreatedAt.After(*session.EndedAt) {
+			continue
+		}
+		counts[a.TopicID]++
+	}
+
+	ids := make([]string, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		// Thema der Sitzung immer zuerst
+		if (ids[i] == session.TopicID) != (ids[j] == session.TopicID) {
+			return ids[i] == session.TopicID
+		}
+		if counts[ids[i]] != counts[ids[j]] {
+			return counts[ids[i]] > counts[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+
+	var topics []*models.Topic
+	for _, id := range ids {
+		if len(topics) == recapQuestionCount {
+			break
+		}
+		if topic, err := h.store.GetTopic(id); err == nil {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
+
+// generateSessionRecap erstellt im Hintergrund das Rückblick-Quiz einer beendeten Sitzung.
+// Die Fragen landen wie alle anderen in den Leitner-Boxen und werden an die Sitzung gehängt.
+func (h *Handler) generateSessionRecap(session models.StudySession) {
+	topics := h.sessionTopics(&session)
+	if len(topics) == 0 {
+		h.store.SaveSessionRecap(session.ID, "skipped", nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	ctx = llm.WithPriority(ctx, llm.PriorityBackground)
+
+	// Fragen reihum auf die Themen verteilen
+	counts := make([]int, len(topics))
+	for i := 0; i < recapQuestionCount; i++ {
+		counts[i%len(topics)]++
+	}
+
+	var ids []string
+	for i, topic := range topics {
+		difficulty := topic.Difficulty
+		if difficulty < 1 || difficulty > 5 {
+			difficulty = 2
+		}
+		questions, err := h.tutor.GenerateQuestions(h.withTopicSettings(ctx, topic), topic, h.topicContent(topic), difficulty, counts[i], "", "")
+		if err != nil {
+			log.Printf("⚠️ Rückblick-Quiz zu '%s' fehlgeschlagen: %v", topic.Name, err)
+			continue
+		}
+		if h.safety.Enabled() {
+			var allowed []models.Question
+			for _, q := range questions {
+				if check := h.safety.CheckQuestion(ctx, &q); !check.Safe {
+					log.Printf("⚠️ Frage vom Inhaltsfilter verworfen (%s): %s", check.Reason, q.Question)
+					continue
+				}
+				allowed = append(allowed, q)
+			}
+			questions = allowed
+		}
+		h.locateQuestionSources(topic, questions)
+		h.markExamQuestions(topic, questions)
+		for _, q := range questions {
+			if err := h.store.SaveQuestion(&q); err != nil {
+				log.Printf("⚠️ Frage konnte nicht gespeichert werden: %v", err)
+				continue
+			}
+			ids = append(ids, q.ID)
+		}
+	}
+
+	status := "ready"
+	if len(ids) == 0 {
+		status = "failed"
+	}
+	if err := h.store.SaveSessionRecap(session.ID, status, ids); err != nil {
+		log.Printf("⚠️ Rückblick-Quiz konnte nicht gespeichert werden: %v", err)
+		return
+	}
+	h.changes.bump() // neue Fragen zählen im Fortschritt
+	log.Printf("🔁 Rückblick-Quiz für Sitzung %s: %d Fragen zu %d Themen", session.ID, len(ids), len(topics))
+}
+
+// GetSessionRecap liefert das Rückblick-Quiz einer beendeten Sitzung
+// (202, solange es noch erstellt wird)
+func (h *Handler) GetSessionRecap(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	session, err := h.store.GetSession(id)
+	if err != nil {
+		errorResponse(w, "Sitzung nicht gefunden", http.StatusNotFound)
+		return
+	}
+	if session.EndedAt == nil {
+		errorResponse(w, "Sitzung ist noch nicht beendet", http.StatusConflict)
+		return
+	}
+	if session.RecapStatus == "" {
+		errorResponse(w, "Für diese Sitzung gibt es kein Rückblick-Quiz", http.StatusNotFound)
+		return
+	}
+
+	questions := []models.Question{}
+	for _, qid := range session.RecapQuestionIDs {
+		if q, err := h.store.GetQuestion(qid); err == nil {
+			questions = append(questions, *q)
+		}
+	}
+	h.shuffleAll(questions)
+
+	status := http.StatusOK
+	if session.RecapStatus == "pending" {
+		status = http.StatusAccepted
+	}
+	jsonResponse(w, map[string]interface{}{
+		"session_id": session.ID,
+		"status":     session.RecapStatus,
+		"questions":  questions,
+	}, status)
+}
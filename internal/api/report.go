@@ -0,0 +1,237 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"lernplattform/internal/mail"
+)
+
+// weeklyReport ist die Lernbilanz der letzten sieben Tage
+type weeklyReport struct {
+	From            string      `json:"from"`
+	To              string      `json:"to"`
+	PlanName        string      `json:"plan_name,omitempty"`
+	Minutes         int         `json:"minutes"`
+	PrevMinutes     int         `json:"prev_minutes"`
+	Questions       int         `json:"questions"`
+	Accuracy        float64     `json:"accuracy"`      // Prozent richtiger Antworten diese Woche
+	PrevAccuracy    float64     `json:"prev_accuracy"` // Prozent der Vorwoche
+	TopicsCompleted int         `json:"topics_completed"`
+	TopicsTotal     int         `json:"topics_total"`
+	Days            []reportDay `json:"days"`
+	Encouragement   string      `json:"encouragement,omitempty"`
+}
+
+// reportDay ist ein Tag im Wochenbericht
+type reportDay struct {
+	Date      string  `json:"date"`
+	Minutes   int     `json:"minutes"`
+	Questions int     `json:"questions"`
+	Accuracy  float64 `json:"accuracy"`
+}
+
+// buildWeeklyReport sammelt Lernzeit, Trefferquote (mit Vorwoche) und Themenstand des aktiven Plans
+func (h *Handler) buildWeeklyReport() (*weeklyReport, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	weekStart := today.AddDate(0, 0, -6)
+	prevStart := weekStart.AddDate(0, 0, -7)
+
+	activity, err := h.dailyActivity(prevStart)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := h.store.GetAllAttempts()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &weeklyReport{
+		From: weekStart.Format(dateLayout),
+		To:   today.Format(dateLayout),
+	}
+
+	correctByDay := make(map[string]int)
+	var correct, prevCorrect, prevQuestions int
+	for _, a := range attempts {
+		switch {
+		case a.CreatedAt.Before(prevStart):
+			continue
+		case a.CreatedAt.Before(weekStart):
+			prevQuestions++
+			if a.IsCorrect {
+				prevCorrect++
+			}
+		default:
+			report.Questions++
+			if a.IsCorrect {
+				correct++
+				correctByDay[a.CreatedAt.Local().Format(dateLayout)]++
+			}
+		}
+	}
+	report.Accuracy = percent(correct, report.Questions)
+	report.PrevAccuracy = percent(prevCorrect, prevQuestions)
+
+	for i := 0; i < 7; i++ {
+		date := weekStart.AddDate(0, 0, i).Format(dateLayout)
+		day := reportDay{Date: date}
+		if a := activity[date]; a != nil {
+			day.Minutes = a.minutes
+			day.Questions = a.questions
+			day.Accuracy = percent(correctByDay[date], a.questions)
+		}
+		report.Minutes += day.Minutes
+		report.Days = append(report.Days, day)
+
+		if a := activity[weekStart.AddDate(0, 0, i-7).Format(dateLayout)]; a != nil {
+			report.PrevMinutes += a.minutes
+		}
+	}
+
+	if plan, _ := h.store.GetActiveStudyPlan(); plan != nil {
+		report.PlanName = plan.Name
+		topics := leafTopics(plan.Topics)
+		report.TopicsTotal = len(topics)
+		for _, t := range topics {
+			if t.Status == "completed" {
+				report.TopicsCompleted++
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
+
+// summary beschreibt den Bericht in Textform für das LLM
+func (r *weeklyReport) summary() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Zeitraum: %s bis %s\n", r.From, r.To)
+	fmt.Fprintf(&sb, "Lernzeit: %d Minuten (Vorwoche: %d Minuten)\n", r.Minutes, r.PrevMinutes)
+	fmt.Fprintf(&sb, "Beantwortete Fragen: %d, davon richtig: %.0f%% (Vorwoche: %.0f%%)\n", r.Questions, r.Accuracy, r.PrevAccuracy)
+	if r.TopicsTotal > 0 {
+		fmt.Fprintf(&sb, "Abgeschlossene Themen im Lernplan \"%s\": %d von %d\n", r.PlanName, r.TopicsCompleted, r.TopicsTotal)
+	}
+	return sb.String()
+}
+
+var weeklyReportTemplate = template.Must(template.New("weekly").Parse(`<!DOCTYPE html>
+<html><body style="font-family:sans-serif;max-width:600px;margin:auto;color:#222">
+<h2>🎓 Dein Lernwochenbericht</h2>
+<p style="color:#666">{{.From}} bis {{.To}}{{if .PlanName}} · {{.PlanName}}{{end}}</p>
+<table style="border-collapse:collapse;width:100%">
+<tr><td style="padding:6px 0">⏱️ Lernzeit</td><td><b>{{.Minutes}} Min.</b> (Vorwoche {{.PrevMinutes}} Min.)</td></tr>
+<tr><td style="padding:6px 0">❓ Beantwortete Fragen</td><td><b>{{.Questions}}</b></td></tr>
+<tr><td style="padding:6px 0">🎯 Trefferquote</td><td><b>{{printf "%.0f" .Accuracy}}%</b> {{.Trend}} (Vorwoche {{printf "%.0f" .PrevAccuracy}}%)</td></tr>
+{{if .TopicsTotal}}<tr><td style="padding:6px 0">✅ Themen abgeschlossen</td><td><b>{{.TopicsCompleted}} von {{.TopicsTotal}}</b></td></tr>{{end}}
+</table>
+<h3>Tag für Tag</h3>
+<table style="border-collapse:collapse;width:100%;font-size:14px">
+<tr style="text-align:left;color:#666"><th>Tag</th><th>Minuten</th><th>Fragen</th><th>Richtig</th></tr>
+{{range .Days}}<tr><td>{{.Date}}</td><td>{{.Minutes}}</td><td>{{.Questions}}</td><td>{{if .Questions}}{{printf "%.0f" .Accuracy}}%{{else}}–{{end}}</td></tr>
+{{end}}</table>
+{{if .Encouragement}}<p style="margin-top:24px;padding:12px;background:#f3f7ff;border-radius:6px">{{.Encouragement}}</p>{{end}}
+</body></html>
+`))
+
+// Trend zeigt die Entwicklung der Trefferquote gegenüber der Vorwoche
+func (r *weeklyReport) Trend() string {
+	switch {
+	case r.Questions == 0:
+		return ""
+	case r.Accuracy > r.PrevAccuracy+2:
+		return "▲"
+	case r.Accuracy < r.PrevAccuracy-2:
+		return "▼"
+	default:
+		return "▶"
+	}
+}
+
+// renderWeeklyReport erstellt den Bericht samt Ermutigung des Tutors als HTML
+func (h *Handler) renderWeeklyReport(ctx context.Context) (*weeklyReport, string, error) {
+	report, err := h.buildWeeklyReport()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if h.llmAvailable(ctx) {
+		encouragement, err := h.tutor.WeeklyEncouragement(ctx, report.summary())
+		if err != nil {
+			log.Printf("   ⚠️ Wochenbericht ohne Ermutigung: %v", err)
+		} else {
+			report.Encouragement = encouragement
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := weeklyReportTemplate.Execute(&buf, report); err != nil {
+		return nil, "", err
+	}
+	return report, buf.String(), nil
+}
+
+// SendWeeklyReport verschickt den Wochenbericht per E-Mail (für den Scheduler)
+func (h *Handler) SendWeeklyReport(ctx context.Context) error {
+	sender := mail.NewSender(h.config.Email)
+	if !sender.Enabled() {
+		return fmt.Errorf("e-mail nicht konfiguriert")
+	}
+
+	report, body, err := h.renderWeeklyReport(ctx)
+	if err != nil {
+		return err
+	}
+	subject := fmt.Sprintf("Dein Lernwochenbericht (%s bis %s)", report.From, report.To)
+	if err := sender.Send(subject, body); err != nil {
+		return err
+	}
+	log.Printf("📧 Wochenbericht an %s verschickt", strings.Join(h.config.Email.To, ", "))
+	return nil
+}
+
+// GetWeeklyReport zeigt den Wochenbericht an (format=html liefert die E-Mail-Ansicht)
+func (h *Handler) GetWeeklyReport(w http.ResponseWriter, r *http.Request) {
+	report, body, err := h.renderWeeklyReport(r.Context())
+	if err != nil {
+		errorResponse(w, "Fehler beim Erstellen des Berichts", http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "html" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(body))
+		return
+	}
+	jsonResponse(w, report, http.StatusOK)
+}
+
+// SendWeeklyReportNow verschickt den Wochenbericht sofort (z.B. zum Testen der SMTP-Einstellungen)
+func (h *Handler) SendWeeklyReportNow(w http.ResponseWriter, r *http.Request) {
+	if !mail.NewSender(h.config.Email).Enabled() {
+		errorResponse(w, "E-Mail ist nicht konfiguriert (email.smtp_host, email.from, email.to)", http.StatusBadRequest)
+		return
+	}
+	if err := h.SendWeeklyReport(r.Context()); err != nil {
+		errorResponse(w, "Versand fehlgeschlagen: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	jsonResponse(w, map[string]interface{}{
+		"sent": true,
+		"to":   h.config.Email.To,
+	}, http.StatusOK)
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"lernplattform/internal/models"
+)
+
+// Unter dieser geschätzten Behaltensquote wird ein Thema zur Wiederholung vorgeschlagen
+const reviewRetentionThreshold = 0.7
+
+// Höchstzahl Wiederholungsvorschläge in den Tageszielen
+const maxReviewReminders = 5
+
+// Ein Übungstag gilt als erfolgreich ab dieser Trefferquote
+const retentionPassRate = 0.7
+
+// topicRetention ist die Schätzung der Vergessenskurve eines Themas
+type topicRetention struct {
+	TopicID       string    `json:"topic_id"`
+	TopicName     string    `json:"topic_name"`
+	Retention     float64   `json:"retention"`      // 0..1, geschätzter Anteil, der heute noch sitzt
+	StabilityDays float64   `json:"stability_days"` // nach so vielen Tagen ist die Quote auf ca. 37 % gefallen
+	LastPracticed time.Time `json:"last_practiced"`
+	ReviewDays    int       `json:"review_days"` // Tage, an denen geübt wurde
+}
+
+// estimateRetention schätzt die Vergessenskurve R = e^(-t/S) aus den Antwortversuchen eines
+// Themas (ältester zuerst). Jeder Übungstag mit guter Trefferquote vergrößert die Stabilität S,
+// umso mehr, je länger die letzte Übung zurücklag (Spacing-Effekt); schlechte Tage halbieren sie.
+func estimateRetention(attempts []models.QuestionAttempt, now time.Time) (retention, stability float64, days int) {
+	if len(attempts) == 0 {
+		return 0, 0, 0
+	}
+
+	type practiceDay struct {
+		at             time.Time
+		correct, total int
+	}
+	var practice []practiceDay
+	for _, a := range attempts {
+		key := a.CreatedAt.Local().Format(dateLayout)
+		if len(practice) == 0 || practice[len(practice)-1].at.Local().Format(dateLayout) != key {
+			practice = append(practice, practiceDay{at: a.CreatedAt})
+		}
+		d := &practice[len(practice)-1]
+		d.at = a.CreatedAt
+		d.total++
+		if a.IsCorrect && a.HintsUsed == 0 {
+			d.correct++
+		}
+	}
+
+	stability = 1
+	for i, d := range practice {
+		rate := float64(d.correct) / float64(d.total)
+		if rate < retentionPassRate {
+			stability = max(stability/2, 1)
+			continue
+		}
+		spacing := 1.0
+		if i > 0 {
+			spacing = min(d.at.Sub(practice[i-1].at).Hours()/24/stability, 1)
+		}
+		stability *= 1 + 1.5*rate*spacing
+	}
+
+	elapsed := now.Sub(practice[len(practice)-1].at).Hours() / 24
+	return math.Exp(-max(elapsed, 0) / stability), stability, len(practice)
+}
+
+// planRetention schätzt die Behaltensquote aller geübten Themen eines Plans, niedrigste zuerst
+func (h *Handler) planRetention(planID string) ([]topicRetention, error) {
+	plan, err := h.store.GetStudyPlan(planID)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := h.store.GetAttemptsByPlan(planID)
+	if err != nil {
+		return nil, err
+	}
+	byTopic := make(map[string][]models.QuestionAttempt)
+	for _, a := range attempts {
+		byTopic[a.TopicID] = append(byTopic[a.TopicID], a)
+	}
+
+	now := time.Now()
+	result := []topicRetention{}
+	for _, t := range leafTopics(plan.Topics) {
+		history := byTopic[t.ID]
+		if len(history) == 0 {
+			continue
+		}
+		retention, stability, days := estimateRetention(history, now)
+		result = append(result, topicRetention{
+			TopicID:       t.ID,
+			TopicName:     t.Name,
+			Retention:     math.Round(retention*100) / 100,
+			StabilityDays: math.Round(stability*10) / 10,
+			LastPracticed: history[len(history)-1].CreatedAt,
+			ReviewDays:    days,
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Retention < result[j].Retention })
+	return result, nil
+}
+
+// reviewReminders formuliert Wiederholungsvorschläge für Themen unter der Schwelle
+func reviewReminders(estimates []topicRetention) []map[string]interface{} {
+	reminders := []map[string]interface{}{}
+	for _, e := range estimates {
+		if e.Retention >= reviewRetentionThreshold || len(reminders) == maxReviewReminders {
+			break
+		}
+		reminders = append(reminders, map[string]interface{}{
+			"topic_id":  e.TopicID,
+			"retention": e.Retention,
+			"message":   fmt.Sprintf("🔁 Wiederhole heute „%s“ – geschätzt sitzen noch %.0f %%", e.TopicName, e.Retention*100),
+		})
+	}
+	return reminders
+}
+
+// GetRetention zeigt die geschätzte Behaltensquote je Thema (Vergessenskurve aus den Antwortversuchen)
+func (h *Handler) GetRetention(w http.ResponseWriter, r *http.Request) {
+	planID, ok := h.queryPlanID(w, r)
+	if !ok {
+		return
+	}
+
+	estimates, err := h.planRetention(planID)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"plan_id":   planID,
+		"threshold": reviewRetentionThreshold,
+		"topics":    estimates,
+		"reviews":   reviewReminders(estimates),
+	}, http.StatusOK)
+}
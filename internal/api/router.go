@@ -8,7 +8,7 @@ import (
 	"sync"
 
 	"github.com/gorilla/mux"
-	"github.com/rs/cors"
+	"github.com/gorilla/websocket"
 )
 
 // gzipResponseWriter wraps http.ResponseWriter für Komprimierung
@@ -21,6 +21,16 @@ func (w gzipResponseWriter) Write(b []byte) (int, error) {
 	return w.Writer.Write(b)
 }
 
+// Flush leert den gzip-Puffer, damit Server-Sent Events sofort beim Client ankommen
+func (w gzipResponseWriter) Flush() {
+	if gz, ok := w.Writer.(*gzip.Writer); ok {
+		gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 // gzipWriterPool für Performance
 var gzipWriterPool = sync.Pool{
 	New: func() interface{} {
@@ -31,8 +41,8 @@ var gzipWriterPool = sync.Pool{
 // compressionMiddleware komprimiert Responses
 func compressionMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Prüfe ob Client gzip unterstützt
-		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		// Prüfe ob Client gzip unterstützt; WebSockets brauchen die unveränderte Verbindung
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") || websocket.IsWebSocketUpgrade(r) {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -64,17 +74,16 @@ func cacheMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Statische Assets lange cachen
+		// Frontend-Dateien bei jedem Laden per ETag prüfen (meist 304),
+		// damit nach einem Update kein veralteter Code ausgeliefert wird
 		if strings.HasSuffix(path, ".css") ||
 			strings.HasSuffix(path, ".js") ||
 			strings.HasSuffix(path, ".png") ||
 			strings.HasSuffix(path, ".svg") ||
-			strings.HasSuffix(path, ".woff2") {
-			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
-		} else if strings.HasSuffix(path, ".html") || path == "/" {
-			// HTML kurz cachen für Updates
-			w.Header().Set("Cache-Control", "public, max-age=3600, must-revalidate")
-		} else if strings.HasPrefix(path, "/api/") {
+			strings.HasSuffix(path, ".woff2") ||
+			strings.HasSuffix(path, ".html") || path == "/" {
+			w.Header().Set("Cache-Control", "public, no-cache")
+		} else if strings.HasPrefix(path, "/api/") || path == "/healthz" || path == "/readyz" {
 			// API Responses nicht cachen (außer explizit)
 			w.Header().Set("Cache-Control", "no-cache")
 		}
@@ -87,51 +96,185 @@ func cacheMiddleware(next http.Handler) http.Handler {
 func NewRouter(h *Handler) http.Handler {
 	r := mux.NewRouter()
 
+	// Liveness/Readiness für Reverse-Proxy und Skripte
+	r.HandleFunc("/healthz", h.Healthz).Methods("GET")
+	r.HandleFunc("/readyz", h.Readyz).Methods("GET")
+
 	// API-Version
 	api := r.PathPrefix("/api/v1").Subrouter()
+	api.Use(authMiddleware(h.config.Security, h.store), limitsMiddleware, h.offlineMiddleware, h.changes.trackChanges, etagMiddleware)
 
 	// System
 	api.HandleFunc("/health", h.HealthCheck).Methods("GET")
 	api.HandleFunc("/status", h.GetStatus).Methods("GET")
+	api.HandleFunc("/features", h.GetFeatures).Methods("GET")
 	api.HandleFunc("/models", h.GetModels).Methods("GET")
 	api.HandleFunc("/models", h.SetModel).Methods("POST")
+	api.HandleFunc("/models/recommend", h.RecommendModels).Methods("GET")
+	api.HandleFunc("/generations", h.GetGenerations).Methods("GET")
+	api.HandleFunc("/generations/events", h.GenerationEvents).Methods("GET")
+	api.HandleFunc("/generations/{id}/cancel", h.CancelGeneration).Methods("POST")
+	api.HandleFunc("/auth/me", h.GetAccess).Methods("GET")
+	api.HandleFunc("/auth/devices", h.GetDevices).Methods("GET")
+	api.HandleFunc("/auth/devices", h.PairDevice).Methods("POST")
+	api.HandleFunc("/auth/devices/{id}", h.DeleteDevice).Methods("DELETE")
+	api.HandleFunc("/rpc", h.RPC).Methods("POST")
+	api.HandleFunc("/account/wipe", h.WipeAccount).Methods("POST")
 
 	// Dokumente
 	api.HandleFunc("/documents", h.GetDocuments).Methods("GET")
 	api.HandleFunc("/documents", h.UploadDocument).Methods("POST")
 	api.HandleFunc("/documents/scan", h.ScanDocumentsFolder).Methods("POST")
+	api.HandleFunc("/documents/batch", h.BatchDocuments).Methods("POST")
+	api.HandleFunc("/documents/import-url", h.ImportDocumentURL).Methods("POST")
+	api.HandleFunc("/documents/import-vault", h.ImportVault).Methods("POST")
+	api.HandleFunc("/integrations/{name}/sync", h.SyncIntegration).Methods("POST")
 	api.HandleFunc("/documents/{id}", h.GetDocument).Methods("GET")
+	api.HandleFunc("/documents/{id}", h.UpdateDocument).Methods("PUT")
 	api.HandleFunc("/documents/{id}", h.DeleteDocument).Methods("DELETE")
+	api.HandleFunc("/documents/{id}/stats", h.GetDocumentStats).Methods("GET")
+	api.HandleFunc("/documents/{id}/toc", h.GetDocumentTOC).Methods("GET")
+	api.HandleFunc("/documents/{id}/figures", h.GetDocumentFigures).Methods("GET")
+	api.HandleFunc("/figures/{id}", h.GetFigure).Methods("GET")
+	api.HandleFunc("/documents/{id}/chat", h.DocumentChat).Methods("POST")
+	api.HandleFunc("/documents/{id}/raw", h.GetDocumentRaw).Methods("GET")
+	api.HandleFunc("/documents/{id}/language", h.SetDocumentLanguage).Methods("PUT")
 
 	// Lernpläne
 	api.HandleFunc("/plans", h.GetStudyPlans).Methods("GET")
 	api.HandleFunc("/plans", h.CreateStudyPlan).Methods("POST")
+	api.HandleFunc("/plans/preview", h.PreviewStudyPlan).Methods("POST")
+	api.HandleFunc("/plans/confirm", h.ConfirmStudyPlan).Methods("POST")
 	api.HandleFunc("/plans/active", h.GetActiveStudyPlan).Methods("GET")
+	api.HandleFunc("/plans/semester", h.PlanSemester).Methods("POST")
+	api.HandleFunc("/plans/jobs", h.GetPlanJobs).Methods("GET")
+	api.HandleFunc("/plans/jobs/{id}/resume", h.ResumePlanJob).Methods("POST")
 	api.HandleFunc("/plans/{id}", h.GetStudyPlan).Methods("GET")
 	api.HandleFunc("/plans/{id}", h.UpdateStudyPlan).Methods("PUT")
 	api.HandleFunc("/plans/{id}", h.DeleteStudyPlan).Methods("DELETE")
+	api.HandleFunc("/plans/{id}/export", h.ExportStudyPlan).Methods("GET")
+	api.HandleFunc("/plans/{id}/cheatsheet", h.ExportCheatSheet).Methods("GET")
+	api.HandleFunc("/plans/{id}/readiness", h.GetReadiness).Methods("GET")
+	api.HandleFunc("/plans/{id}/difficulty-curve", h.GetDifficultyCurve).Methods("GET")
+	api.HandleFunc("/plans/{id}/question-coverage", h.GetQuestionCoverage).Methods("GET")
+	api.HandleFunc("/plans/{id}/exam-questions/scan", h.ScanExamQuestions).Methods("POST")
+	api.HandleFunc("/export/csv", h.ExportCSV).Methods("GET")
+	api.HandleFunc("/plans/{id}/syllabus", h.GetSyllabus).Methods("GET")
+	api.HandleFunc("/plans/{id}/syllabus", h.SetSyllabus).Methods("POST")
 
 	// Themen
+	api.HandleFunc("/topics/merge", h.MergeTopics).Methods("POST")
 	api.HandleFunc("/topics/{id}", h.GetTopic).Methods("GET")
+	api.HandleFunc("/topics/{id}/split", h.SplitTopic).Methods("POST")
 	api.HandleFunc("/topics/{id}/explain", h.ExplainTopic).Methods("GET")
+	api.HandleFunc("/topics/{id}/explain/audio", h.ExplainTopicAudio).Methods("POST")
+	api.HandleFunc("/topics/{id}/explain/regenerate", h.RegenerateExplanation).Methods("POST")
+	api.HandleFunc("/topics/{id}/misconceptions", h.GetMisconceptions).Methods("GET")
+	api.HandleFunc("/topics/{id}/address-misconceptions", h.AddressMisconceptions).Methods("POST")
+	api.HandleFunc("/topics/{id}/explanations", h.GetExplanations).Methods("GET")
+	api.HandleFunc("/topics/{id}/worksheet.pdf", h.Worksheet).Methods("GET")
+	api.HandleFunc("/topics/{id}/mnemonics", h.GetTopicMnemonics).Methods("GET")
+	api.HandleFunc("/topics/{id}/mnemonics", h.GenerateMnemonics).Methods("POST")
+	api.HandleFunc("/topics/{id}/worked-examples", h.GetWorkedExamples).Methods("GET")
+	api.HandleFunc("/topics/{id}/worked-examples", h.GenerateWorkedExamples).Methods("POST")
+	api.HandleFunc("/topics/{id}/teach-back", h.GetTeachBacks).Methods("GET")
+	api.HandleFunc("/topics/{id}/teach-back", h.TeachBack).Methods("POST")
 	api.HandleFunc("/topics/{id}/questions", h.GetQuestions).Methods("GET")
 	api.HandleFunc("/topics/{id}/questions/generate", h.GenerateQuestions).Methods("POST")
 	api.HandleFunc("/topics/{id}/status", h.UpdateTopicStatus).Methods("PUT")
+	api.HandleFunc("/topics/{id}/parent", h.SetTopicParent).Methods("PUT")
+	api.HandleFunc("/topics/{id}/notes", h.GetTopicNotes).Methods("GET")
+	api.HandleFunc("/topics/{id}/objectives", h.GetObjectives).Methods("GET")
+	api.HandleFunc("/topics/{id}/objectives/generate", h.GenerateObjectives).Methods("POST")
+
+	// Lernziele
+	api.HandleFunc("/objectives/{id}", h.UpdateObjective).Methods("PUT")
 
 	// Fragen
 	api.HandleFunc("/questions/{id}", h.GetQuestion).Methods("GET")
 	api.HandleFunc("/questions/{id}/answer", h.SubmitAnswer).Methods("POST")
+	api.HandleFunc("/questions/{id}/answer/stream", h.SubmitAnswerStream).Methods("POST")
+	api.HandleFunc("/questions/{id}/start", h.StartQuestion).Methods("POST")
+	api.HandleFunc("/questions/{id}/mnemonics", h.GetQuestionMnemonics).Methods("GET")
+	api.HandleFunc("/questions/{id}/attempts", h.GetQuestionAttempts).Methods("GET")
+	api.HandleFunc("/questions/{id}/notes", h.GetQuestionNotes).Methods("GET")
+	api.HandleFunc("/questions/{id}/source", h.GetQuestionSource).Methods("GET")
+	api.HandleFunc("/questions/{id}/flag", h.FlagQuestion).Methods("POST")
+
+	api.HandleFunc("/answers/batch", h.SubmitAnswersBatch).Methods("POST")
+
+	// Leitner-Boxen und Quiz
+	api.HandleFunc("/boxes", h.GetLeitnerBoxes).Methods("GET")
+	api.HandleFunc("/quiz", h.GetQuiz).Methods("GET")
+	api.HandleFunc("/quiz/interleaving", h.GetInterleavedPairs).Methods("GET")
+	api.HandleFunc("/retention", h.GetRetention).Methods("GET")
+
+	// Fachprofile (Prompts, Fragetypen und Bewertungsstrenge je Fach)
+	api.HandleFunc("/profiles", h.GetSubjectProfiles).Methods("GET")
+	api.HandleFunc("/profiles", h.CreateSubjectProfile).Methods("POST")
+	api.HandleFunc("/profiles/{id}", h.GetSubjectProfile).Methods("GET")
+	api.HandleFunc("/profiles/{id}", h.UpdateSubjectProfile).Methods("PUT")
+	api.HandleFunc("/profiles/{id}", h.DeleteSubjectProfile).Methods("DELETE")
+
+	// Eigene Quizze
+	api.HandleFunc("/quizzes", h.GetQuizzes).Methods("GET")
+	api.HandleFunc("/quizzes", h.CreateQuiz).Methods("POST")
+	api.HandleFunc("/quizzes/{id}", h.GetSavedQuiz).Methods("GET")
+	api.HandleFunc("/quizzes/{id}", h.DeleteQuiz).Methods("DELETE")
+	api.HandleFunc("/quizzes/{id}/export.html", h.ExportQuizHTML).Methods("GET")
+	api.HandleFunc("/quizzes/{id}/start", h.StartQuiz).Methods("POST")
+	api.HandleFunc("/quizzes/{id}/results", h.GetQuizResults).Methods("GET")
+	api.HandleFunc("/quizzes/{id}/results/{resultId}/finish", h.FinishQuiz).Methods("POST")
+
+	// Erklärungen
+	api.HandleFunc("/explanations/{id}/flag", h.FlagExplanation).Methods("POST")
+
+	// Markierungen
+	api.HandleFunc("/flags", h.GetFlags).Methods("GET")
+	api.HandleFunc("/flags/resolve", h.ResolveFlags).Methods("POST")
+	api.HandleFunc("/flags/{id}", h.DeleteFlag).Methods("DELETE")
+
+	// Notizen
+	api.HandleFunc("/notes", h.CreateNote).Methods("POST")
+	api.HandleFunc("/notes/{id}", h.GetNote).Methods("GET")
+	api.HandleFunc("/notes/{id}", h.UpdateNote).Methods("PUT")
+	api.HandleFunc("/notes/{id}", h.DeleteNote).Methods("DELETE")
 
 	// Chat
 	api.HandleFunc("/chat", h.Chat).Methods("POST")
 	api.HandleFunc("/chat/stream", h.ChatStream).Methods("POST")
 	api.HandleFunc("/chat/history/{sessionId}", h.GetChatHistory).Methods("GET")
+	api.HandleFunc("/chat/history/{sessionId}/export.md", h.ExportChatHistory).Methods("GET")
+	api.HandleFunc("/chat/messages/{id}/to-flashcard", h.ChatToFlashcard).Methods("POST")
+	api.HandleFunc("/chat/messages/{id}/to-glossary", h.ChatToGlossary).Methods("POST")
+	api.HandleFunc("/chat/messages/{id}", h.EditChatMessage).Methods("PUT")
+	api.HandleFunc("/chat/messages/{id}/regenerate", h.RegenerateChatMessage).Methods("POST")
+	api.HandleFunc("/chat/messages/{id}/versions", h.GetChatMessageVersions).Methods("GET")
+	api.HandleFunc("/chat/sessions", h.CreateChatSession).Methods("POST")
+	api.HandleFunc("/chat/sessions/{id}", h.GetChatSession).Methods("GET")
+	api.HandleFunc("/chat/sessions/{id}", h.UpdateChatSession).Methods("PUT")
+	api.HandleFunc("/chat/sessions/{id}/pins", h.GetChatPins).Methods("GET")
+	api.HandleFunc("/chat/sessions/{id}/pins", h.PinChatContext).Methods("POST")
+	api.HandleFunc("/chat/sessions/{id}/pins/{pinId}", h.UnpinChatContext).Methods("DELETE")
+
+	// Sprache
+	api.HandleFunc("/stt", h.SpeechToText).Methods("POST")
+	api.HandleFunc("/tts", h.TextToSpeech).Methods("GET")
 
 	// Fortschritt
 	api.HandleFunc("/progress", h.GetProgress).Methods("GET")
+	api.HandleFunc("/stats/answer-speed", h.GetAnswerSpeed).Methods("GET")
+	api.HandleFunc("/stats/error-types", h.GetErrorTypes).Methods("GET")
 	api.HandleFunc("/sessions", h.GetSessions).Methods("GET")
 	api.HandleFunc("/sessions", h.StartSession).Methods("POST")
 	api.HandleFunc("/sessions/{id}/end", h.EndSession).Methods("POST")
+	api.HandleFunc("/sessions/{id}/recap", h.GetSessionRecap).Methods("GET")
+
+	// Tagesziele
+	api.HandleFunc("/goals/today", h.GetGoalsToday).Methods("GET")
+	api.HandleFunc("/goals/history", h.GetGoalsHistory).Methods("GET")
+	api.HandleFunc("/reports/weekly", h.GetWeeklyReport).Methods("GET")
+	api.HandleFunc("/reports/weekly/send", h.SendWeeklyReportNow).Methods("POST")
 
 	// Glossar
 	api.HandleFunc("/glossary", h.GetGlossary).Methods("GET")
@@ -140,17 +283,17 @@ func NewRouter(h *Handler) http.Handler {
 	api.HandleFunc("/glossary/{id}", h.UpdateGlossaryItem).Methods("PUT")
 	api.HandleFunc("/glossary/{id}", h.DeleteGlossaryItem).Methods("DELETE")
 
+	// Vergleichstabellen
+	api.HandleFunc("/compare", h.GetComparisons).Methods("GET")
+	api.HandleFunc("/compare", h.Compare).Methods("POST")
+	api.HandleFunc("/compare/{id}", h.GetComparison).Methods("GET")
+
 	// Statische Dateien (Frontend)
-	r.PathPrefix("/").Handler(http.FileServer(http.Dir("./web/static")))
-
-	// CORS für lokale Entwicklung
-	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
-		AllowCredentials: true,
-	})
+	r.PathPrefix("/").Handler(staticETagHandler("./web/static", http.FileServer(http.Dir("./web/static"))))
+
+	// CORS nur für die konfigurierten Origins
+	c := newCORS(h.config.Security)
 
-	// Middleware Chain: CORS -> Cache -> Compression -> Router
-	return c.Handler(cacheMiddleware(compressionMiddleware(r)))
+	// Middleware Chain: CORS -> Sicherheits-Header -> Cache -> Compression -> Router
+	return c.Handler(securityHeadersMiddleware(h.config.Security, cacheMiddleware(compressionMiddleware(r))))
 }
@@ -0,0 +1,298 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// JSON-RPC 2.0 unter POST /api/v1/rpc: die Kernfunktionen für Skripte und andere Programme.
+// Jede Methode ruft den REST-Handler des entsprechenden Endpoints auf, Verhalten und Antworten
+// sind also dieselben; HTTP-Fehler werden zu JSON-RPC-Fehlern.
+
+// Fehlercodes nach JSON-RPC 2.0; rpcErrServer für Fehler, die der REST-Handler meldet
+const (
+	rpcErrParse          = -32700
+	rpcErrInvalidRequest = -32600
+	rpcErrMethodNotFound = -32601
+	rpcErrInvalidParams  = -32602
+	rpcErrInternal       = -32603
+	rpcErrServer         = -32000
+)
+
+// Höchstens so viele Aufrufe je Batch
+const maxRPCBatch = 20
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// rpcMethod verbindet eine Methode mit dem REST-Endpoint, der sie ausführt. idParam ist der
+// Parameter, der als {id} in die Pfadvorlage kommt; die übrigen Parameter sind der Request-Body.
+type rpcMethod struct {
+	route       string // "METHODE Pfadvorlage", auch für die Offline-Prüfung
+	idParam     string
+	description string
+	handler     func(*Handler, http.ResponseWriter, *http.Request)
+}
+
+var rpcMethods = map[string]rpcMethod{
+	"documents.ingest": {
+		route:       "POST /api/v1/documents",
+		description: "Dokument einlesen: filename und content (Base64 der PDF-, DOCX- oder ZIP-Datei)",
+		handler:     (*Handler).ingestDocumentRPC,
+	},
+	"plans.create": {
+		route:       "POST /api/v1/plans",
+		description: "Lernplan erstellen: exam_date und document_ids wie bei POST /api/v1/plans",
+		handler:     (*Handler).CreateStudyPlan,
+	},
+	"plans.get": {
+		route:       "GET /api/v1/plans/{id}",
+		idParam:     "plan_id",
+		description: "Lernplan mit Themen",
+		handler:     (*Handler).GetStudyPlan,
+	},
+	"questions.generate": {
+		route:       "POST /api/v1/topics/{id}/questions/generate",
+		idParam:     "topic_id",
+		description: "Fragen zu einem Thema generieren: topic_id, optional count, difficulty, type, cognitive_level",
+		handler:     (*Handler).GenerateQuestions,
+	},
+	"answers.evaluate": {
+		route:       "POST /api/v1/questions/{id}/answer",
+		idParam:     "question_id",
+		description: "Antwort bewerten: question_id und answer (bzw. option/order mit option_token), optional hints_used",
+		handler:     (*Handler).SubmitAnswer,
+	},
+}
+
+// rpcRecorder nimmt die Antwort eines REST-Handlers auf
+type rpcRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *rpcRecorder) Header() http.Header { return rec.header }
+
+func (rec *rpcRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+}
+
+func (rec *rpcRecorder) Write(p []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	return rec.body.Write(p)
+}
+
+// RPC nimmt einen JSON-RPC-Aufruf oder einen Batch (Array) entgegen
+func (h *Handler) RPC(w http.ResponseWriter, r *http.Request) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		if bodyTooLarge(w, err) {
+			return
+		}
+		jsonResponse(w, rpcFailure(nil, rpcErrParse, "Ungültiges JSON", nil), http.StatusOK)
+		return
+	}
+
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(raw, &batch); err != nil || len(batch) == 0 {
+			jsonResponse(w, rpcFailure(nil, rpcErrInvalidRequest, "Leerer oder ungültiger Batch", nil), http.StatusOK)
+			return
+		}
+		if len(batch) > maxRPCBatch {
+			jsonResponse(w, rpcFailure(nil, rpcErrInvalidRequest, fmt.Sprintf("Maximal %d Aufrufe pro Batch", maxRPCBatch), nil), http.StatusOK)
+			return
+		}
+		responses := []rpcResponse{}
+		for _, item := range batch {
+			if resp := h.rpcCall(r, item); resp != nil {
+				responses = append(responses, *resp)
+			}
+		}
+		if len(responses) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		jsonResponse(w, responses, http.StatusOK)
+		return
+	}
+
+	resp := h.rpcCall(r, raw)
+	if resp == nil {
+		w.WriteHeader(http.StatusNoContent) // Benachrichtigung ohne id: keine Antwort
+		return
+	}
+	jsonResponse(w, resp, http.StatusOK)
+}
+
+// rpcCall führt einen einzelnen Aufruf aus; nil bei Benachrichtigungen (ohne id)
+func (h *Handler) rpcCall(r *http.Request, raw json.RawMessage) *rpcResponse {
+	var req rpcRequest
+	if err := json.Unmarshal(raw, &req); err != nil || req.JSONRPC != "2.0" || req.Method == "" {
+		return rpcFailure(req.ID, rpcErrInvalidRequest, `Ungültiger Aufruf (jsonrpc "2.0" und method erforderlich)`, nil)
+	}
+	notification := len(req.ID) == 0
+
+	resp := h.rpcDispatch(r, req)
+	if notification {
+		return nil
+	}
+	return resp
+}
+
+func (h *Handler) rpcDispatch(r *http.Request, req rpcRequest) *rpcResponse {
+	if req.Method == "rpc.methods" {
+		return rpcSuccess(req.ID, rpcMethodList())
+	}
+	method, ok := rpcMethods[req.Method]
+	if !ok {
+		return rpcFailure(req.ID, rpcErrMethodNotFound, "Unbekannte Methode: "+req.Method, nil)
+	}
+
+	params := map[string]json.RawMessage{}
+	if len(req.Params) > 0 && string(req.Params) != "null" {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return rpcFailure(req.ID, rpcErrInvalidParams, "params muss ein Objekt sein", nil)
+		}
+	}
+
+	httpMethod, path, _ := strings.Cut(method.route, " ")
+	vars := map[string]string{}
+	if method.idParam != "" {
+		var id string
+		if err := json.Unmarshal(params[method.idParam], &id); err != nil || id == "" {
+			return rpcFailure(req.ID, rpcErrInvalidParams, method.idParam+" fehlt", nil)
+		}
+		vars["id"] = id
+		path = strings.Replace(path, "{id}", url.PathEscape(id), 1)
+		delete(params, method.idParam)
+	}
+
+	if feature, ok := llmRoutes[method.route]; ok && !h.llmAvailable(r.Context()) {
+		return rpcFailure(req.ID, rpcErrServer, fmt.Sprintf("%s ist offline nicht verfügbar: %s", feature, h.offlineReason()),
+			map[string]interface{}{"status": http.StatusServiceUnavailable, "offline": true})
+	}
+
+	body, _ := json.Marshal(params)
+	inner, err := http.NewRequestWithContext(r.Context(), httpMethod, path, bytes.NewReader(body))
+	if err != nil {
+		return rpcFailure(req.ID, rpcErrInternal, err.Error(), nil)
+	}
+	inner.Header.Set("Content-Type", "application/json")
+	inner = mux.SetURLVars(inner, vars)
+
+	rec := &rpcRecorder{header: http.Header{}}
+	method.handler(h, rec, inner)
+
+	if rec.status >= 400 {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		json.Unmarshal(rec.body.Bytes(), &failure)
+		if failure.Error == "" {
+			failure.Error = http.StatusText(rec.status)
+		}
+		code := rpcErrServer
+		if rec.status == http.StatusBadRequest {
+			code = rpcErrInvalidParams
+		}
+		return rpcFailure(req.ID, code, failure.Error, map[string]interface{}{"status": rec.status})
+	}
+	result := bytes.TrimSpace(rec.body.Bytes())
+	if len(result) == 0 {
+		result = []byte("null")
+	}
+	return &rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+// ingestDocumentRPC liest ein Base64-kodiertes Dokument ein (Gegenstück zum Datei-Upload)
+func (h *Handler) ingestDocumentRPC(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Filename string `json:"filename"`
+		Content  string `json:"content"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Filename == "" || req.Content == "" {
+		errorResponse(w, "filename und content (Base64) erforderlich", http.StatusBadRequest)
+		return
+	}
+	data, err := base64.StdEncoding.DecodeString(req.Content)
+	if err != nil {
+		errorResponse(w, "content ist kein gültiges Base64", http.StatusBadRequest)
+		return
+	}
+
+	if strings.HasSuffix(strings.ToLower(req.Filename), ".zip") {
+		h.uploadZip(w, bytes.NewReader(data), int64(len(data)))
+		return
+	}
+	doc, err := h.parseUpload(bytes.NewReader(data), req.Filename)
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler beim Parsen: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := h.storeDocument(doc); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+
+	jsonResponse(w, doc, http.StatusCreated)
+}
+
+// rpcMethodList beschreibt die verfügbaren Methoden (Ergebnis von rpc.methods)
+func rpcMethodList() []map[string]string {
+	names := make([]string, 0, len(rpcMethods))
+	for name := range rpcMethods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := make([]map[string]string, len(names))
+	for i, name := range names {
+		m := rpcMethods[name]
+		list[i] = map[string]string{"method": name, "description": m.description, "rest": m.route}
+	}
+	return list
+}
+
+func rpcSuccess(id json.RawMessage, result interface{}) *rpcResponse {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return rpcFailure(id, rpcErrInternal, err.Error(), nil)
+	}
+	return &rpcResponse{JSONRPC: "2.0", ID: id, Result: data}
+}
+
+func rpcFailure(id json.RawMessage, code int, message string, data interface{}) *rpcResponse {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &rpcResponse{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message, Data: data}}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/rs/cors"
+	"lernplattform/internal/config"
+)
+
+// defaultCSP erlaubt nur Inhalte vom eigenen Server. Das Frontend nutzt Inline-Handler
+// und -Styles, Audio (TTS, Aufnahmen) als blob:, der Chat eine WebSocket-Verbindung.
+const defaultCSP = "default-src 'self'; " +
+	"script-src 'self' 'unsafe-inline'; " +
+	"style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data: blob:; " +
+	"media-src 'self' blob:; " +
+	"connect-src 'self' ws: wss:; " +
+	"object-src 'none'; base-uri 'self'; frame-ancestors 'none'"
+
+// securityHeadersMiddleware setzt die Standard-Sicherheits-Header
+func securityHeadersMiddleware(cfg config.SecurityConfig, next http.Handler) http.Handler {
+	if !cfg.Headers {
+		return next
+	}
+	csp := cfg.ContentSecurityPolicy
+	if csp == "" {
+		csp = defaultCSP
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Referrer-Policy", "no-referrer")
+		header.Set("X-Frame-Options", "DENY")
+		header.Set("Content-Security-Policy", csp)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newCORS erlaubt nur die konfigurierten Origins. Ohne Eintrag antwortet der Server
+// ohne CORS-Header, dann darf nur das eigene Frontend die API nutzen.
+func newCORS(cfg config.SecurityConfig) *cors.Cors {
+	wildcard := allowsAnyOrigin(cfg)
+	options := cors.Options{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowCredentials: !wildcard, // Cookies nie an beliebige Seiten
+	}
+	if len(cfg.AllowedOrigins) == 0 {
+		// Ohne Liste würde cors alle Origins erlauben
+		options.AllowOriginFunc = func(string) bool { return false }
+	}
+	return cors.New(options)
+}
+
+func allowsAnyOrigin(cfg config.SecurityConfig) bool {
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// originAllowed prüft den Origin eines WebSocket-Verbindungsaufbaus:
+// eigener Host, konfigurierte Origins oder Anfragen ohne Origin (keine Browser)
+func originAllowed(cfg config.SecurityConfig, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || allowsAnyOrigin(cfg) {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && u.Host == r.Host {
+		return true
+	}
+	for _, o := range cfg.AllowedOrigins {
+		if o == origin {
+			return true
+		}
+	}
+	return false
+}
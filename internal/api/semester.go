@@ -0,0 +1,264 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"lernplattform/internal/config"
+	"lernplattform/internal/models"
+)
+
+// semesterSubject ist ein Fach (Lernplan) mit seinen noch offenen Themen
+type semesterSubject struct {
+	plan      *models.StudyPlan
+	exam      time.Time // Prüfungstag, 0 Uhr
+	topics    []openTopic
+	remaining int
+	summary   models.SemesterSubject
+}
+
+// openTopic ist ein Thema mit der noch nötigen Lernzeit
+type openTopic struct {
+	id      string
+	name    string
+	minutes int
+}
+
+// loadSemesterSubject lädt einen Plan und schätzt die offene Lernzeit aus Themenumfang und Fortschritt
+func (h *Handler) loadSemesterSubject(planID string) (*semesterSubject, error) {
+	plan, err := h.store.GetStudyPlan(planID)
+	if err != nil {
+		return nil, err
+	}
+	s := &semesterSubject{
+		plan: plan,
+		exam: plan.ExamDate.Time,
+	}
+	for _, t := range leafTopics(plan.Topics) {
+		if t.Status == "completed" {
+			continue
+		}
+		minutes := int(float64(t.EstMinutes) * (100 - min(max(t.Progress, 0), 100)) / 100)
+		if minutes <= 0 {
+			continue
+		}
+		s.topics = append(s.topics, openTopic{id: t.ID, name: t.Name, minutes: minutes})
+		s.remaining += minutes
+	}
+	s.summary = models.SemesterSubject{
+		PlanID:          plan.ID,
+		Name:            plan.Name,
+		ExamDate:        plan.ExamDate,
+		RequiredMinutes: s.remaining,
+	}
+	return s, nil
+}
+
+// take verbraucht minutes Lernzeit der offenen Themen in Planreihenfolge
+func (s *semesterSubject) take(minutes int) []models.SemesterBlock {
+	var blocks []models.SemesterBlock
+	for minutes > 0 && len(s.topics) > 0 {
+		t := &s.topics[0]
+		n := min(minutes, t.minutes)
+		blocks = append(blocks, models.SemesterBlock{
+			PlanID:    s.plan.ID,
+			PlanName:  s.plan.Name,
+			TopicID:   t.id,
+			TopicName: t.name,
+			Minutes:   n,
+		})
+		t.minutes -= n
+		minutes -= n
+		s.remaining -= n
+		s.summary.AllocatedMinutes += n
+		if t.minutes == 0 {
+			s.topics = s.topics[1:]
+		}
+	}
+	return blocks
+}
+
+// planSemester verteilt die verfügbare Zeit ab start Tag für Tag auf die Fächer.
+// Jedes Fach bekommt seinen Anteil der Zeit bis zur eigenen Prüfung; reicht ein Tag
+// nicht, hat die nächste Prüfung Vorrang (earliest deadline first). Prüfungstage bleiben frei.
+// Zurück kommen die Tage und die Daten, an denen der Bedarf die verfügbare Zeit übersteigt.
+func planSemester(subjects []*semesterSubject, availability config.Availability, start time.Time) ([]models.SemesterDay, []string) {
+	sort.SliceStable(subjects, func(i, j int) bool { return subjects[i].exam.Before(subjects[j].exam) })
+	last := subjects[len(subjects)-1].exam
+
+	examDays := make(map[string][]string)
+	for _, s := range subjects {
+		date := s.exam.Format(dateLayout)
+		examDays[date] = append(examDays[date], s.plan.ID)
+	}
+
+	// Verfügbare Minuten je Tag, Prüfungstage zählen nicht
+	var dates []time.Time
+	var avail []int
+	for d := start; !d.After(last); d = d.AddDate(0, 0, 1) {
+		minutes := availability.MinutesOn(d)
+		if len(examDays[d.Format(dateLayout)]) > 0 {
+			minutes = 0
+		}
+		dates = append(dates, d)
+		avail = append(avail, max(minutes, 0))
+	}
+	// futureAvail[i] = Summe der verfügbaren Minuten ab Tag i
+	futureAvail := make([]int, len(avail)+1)
+	for i := len(avail) - 1; i >= 0; i-- {
+		futureAvail[i] = futureAvail[i+1] + avail[i]
+	}
+	dayIndex := func(t time.Time) int {
+		return min(max(int(t.Sub(start).Hours()/24+0.5), 0), len(avail))
+	}
+
+	var days []models.SemesterDay
+	var overloaded []string
+	for i, d := range dates {
+		date := d.Format(dateLayout)
+		day := models.SemesterDay{
+			Date:             date,
+			AvailableMinutes: avail[i],
+			Exams:            examDays[date],
+			Blocks:           []models.SemesterBlock{},
+		}
+
+		left := avail[i]
+		shares := make([]int, len(subjects))
+		need := 0
+		for j, s := range subjects {
+			if s.remaining == 0 || !d.Before(s.exam) {
+				continue
+			}
+			// Anteil, damit das Fach bei gleichmäßiger Verteilung bis zur Prüfung fertig wird
+			until := futureAvail[i] - futureAvail[dayIndex(s.exam)]
+			if until <= 0 {
+				continue
+			}
+			shares[j] = min(s.remaining, (s.remaining*avail[i]+until-1)/until)
+			need += shares[j]
+		}
+		// Aufrunden der Anteile kostet höchstens eine Minute je Fach
+		if need > avail[i]+len(subjects) {
+			overloaded = append(overloaded, date)
+		}
+
+		// Zuerst die Anteile (nächste Prüfung zuerst), übrige Zeit nach derselben Reihenfolge
+		for j, s := range subjects {
+			n := min(shares[j], left)
+			day.Blocks = append(day.Blocks, s.take(n)...)
+			left -= n
+		}
+		for _, s := range subjects {
+			if left == 0 {
+				break
+			}
+			if s.remaining == 0 || !d.Before(s.exam) {
+				continue
+			}
+			n := min(s.remaining, left)
+			day.Blocks = append(day.Blocks, s.take(n)...)
+			left -= n
+		}
+
+		day.PlannedMinutes = avail[i] - left
+		days = append(days, day)
+	}
+	return days, overloaded
+}
+
+// PlanSemester erstellt einen gemeinsamen Tagesplan für mehrere Prüfungen.
+// Body (optional): plan_ids (Standard: alle aktiven Pläne mit kommender Prüfung),
+// start (YYYY-MM-DD, Standard heute), availability (ersetzt das Profil aus der Konfiguration)
+func (h *Handler) PlanSemester(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PlanIDs      []string             `json:"plan_ids"`
+		Start        string               `json:"start"`
+		Availability *config.Availability `json:"availability"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+			return
+		}
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if req.Start != "" {
+		parsed, err := time.ParseInLocation(dateLayout, req.Start, time.Local)
+		if err != nil {
+			errorResponse(w, "Ungültiges Startdatum (Format: YYYY-MM-DD)", http.StatusBadRequest)
+			return
+		}
+		start = parsed
+	}
+
+	availability := h.config.Availability
+	if req.Availability != nil {
+		availability = *req.Availability
+	}
+
+	planIDs := req.PlanIDs
+	if len(planIDs) == 0 {
+		plans, err := h.store.GetAllStudyPlans()
+		if err != nil {
+			errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+			return
+		}
+		for _, p := range plans {
+			if p.Status == "active" && p.ExamDate.After(start) {
+				planIDs = append(planIDs, p.ID)
+			}
+		}
+	}
+	if len(planIDs) == 0 {
+		errorResponse(w, "Keine Lernpläne mit kommender Prüfung", http.StatusNotFound)
+		return
+	}
+
+	var subjects []*semesterSubject
+	for _, id := range planIDs {
+		s, err := h.loadSemesterSubject(id)
+		if err != nil {
+			errorResponse(w, fmt.Sprintf("Lernplan %s nicht gefunden", id), http.StatusNotFound)
+			return
+		}
+		if !s.exam.After(start) {
+			errorResponse(w, fmt.Sprintf("Die Prüfung von '%s' liegt nicht nach dem Startdatum", s.plan.Name), http.StatusBadRequest)
+			return
+		}
+		subjects = append(subjects, s)
+	}
+
+	days, overloaded := planSemester(subjects, availability, start)
+
+	var warnings []string
+	for _, day := range days {
+		if len(day.Exams) > 1 {
+			warnings = append(warnings, fmt.Sprintf("%d Prüfungen am %s", len(day.Exams), day.Date))
+		}
+	}
+	summaries := make([]models.SemesterSubject, len(subjects))
+	for i, s := range subjects {
+		s.summary.ShortfallMinutes = s.remaining
+		summaries[i] = s.summary
+		if s.remaining > 0 {
+			warnings = append(warnings, fmt.Sprintf("Für '%s' fehlen bis zur Prüfung noch %d Minuten Lernzeit", s.plan.Name, s.remaining))
+		}
+	}
+	if len(overloaded) > 0 {
+		warnings = append(warnings, fmt.Sprintf("An %d Tagen reicht die verfügbare Zeit nicht für alle Fächer (%s)", len(overloaded), strings.Join(overloaded, ", ")))
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"start":    start.Format(dateLayout),
+		"subjects": summaries,
+		"days":     days,
+		"warnings": warnings,
+	}, http.StatusOK)
+}
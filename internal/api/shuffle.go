@@ -0,0 +1,147 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash/fnv"
+	"log"
+	mrand "math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"lernplattform/internal/llm"
+	"lernplattform/internal/models"
+	"lernplattform/internal/storage"
+)
+
+// errInvalidOptionToken wird bei manipulierten oder veralteten Mapping-Tokens zurückgegeben
+var errInvalidOptionToken = errors.New("ungültiges Options-Token")
+
+// errInvalidOrder: eine Reihenfolge muss jede angezeigte Option genau einmal enthalten
+var errInvalidOrder = errors.New("Reihenfolge muss jede Option genau einmal enthalten")
+
+// loadShuffleKey liefert den Schlüssel, mit dem Options-Tokens signiert werden. Er wird in der
+// Datenbank abgelegt, damit ausgegebene Tokens einen Neustart überstehen.
+func loadShuffleKey(store storage.Storage) []byte {
+	if stored, err := store.GetSetting("shuffle_key"); err == nil {
+		if key, err := hex.DecodeString(stored); err == nil && len(key) == 32 {
+			return key
+		}
+	}
+
+	key := make([]byte, 32)
+	rand.Read(key)
+	if err := store.SaveSetting("shuffle_key", hex.EncodeToString(key)); err != nil {
+		log.Printf("⚠️ Schlüssel für Options-Tokens nicht gespeichert (gilt bis zum Neustart): %v", err)
+	}
+	return key
+}
+
+// shuffleOptions mischt die Antwortoptionen einer Multiple-Choice-Frage für die Ausgabe.
+// Die kanonische Reihenfolge bleibt gespeichert; das Token hält die Zuordnung fest.
+func (h *Handler) shuffleOptions(q *models.Question) {
+	if len(q.Options) < 2 {
+		return
+	}
+
+	perm := h.optionPerm(q.ID, len(q.Options))
+	// Bei Reihenfolge-Fragen darf die Anzeige nicht schon die Lösung sein
+	if q.Type == "ordering" && sort.IntsAreSorted(perm) {
+		perm[0], perm[1] = perm[1], perm[0]
+	}
+	shuffled := make([]string, len(q.Options))
+	for displayed, canonical := range perm {
+		shuffled[displayed] = q.Options[canonical]
+	}
+
+	q.Options = shuffled
+	q.OptionToken = h.signOptionOrder(q.ID, perm)
+}
+
+// optionPerm liefert die Mischreihenfolge; im deterministischen Modus je Frage immer dieselbe
+func (h *Handler) optionPerm(questionID string, n int) []int {
+	if !h.config.DeterministicMode {
+		return mrand.Perm(n)
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(questionID))
+	rng := mrand.New(mrand.NewSource(int64(hash.Sum64()) + int64(h.config.EffectiveSeed())))
+	return rng.Perm(n)
+}
+
+// shuffleAll mischt die Optionen aller Fragen einer Liste
+func (h *Handler) shuffleAll(questions []models.Question) {
+	for i := range questions {
+		h.shuffleOptions(&questions[i])
+	}
+}
+
+// resolveOption übersetzt den Index einer angezeigten Option zurück in die kanonische Option
+func (h *Handler) resolveOption(q *models.Question, token string, displayed int) (string, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return "", errInvalidOptionToken
+	}
+
+	var perm []int
+	for _, s := range strings.Split(parts[0], ",") {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return "", errInvalidOptionToken
+		}
+		perm = append(perm, i)
+	}
+
+	if !hmac.Equal([]byte(h.signOptionOrder(q.ID, perm)), []byte(token)) {
+		return "", errInvalidOptionToken
+	}
+	if len(perm) != len(q.Options) || displayed < 0 || displayed >= len(perm) {
+		return "", errInvalidOptionToken
+	}
+
+	canonical := perm[displayed]
+	if canonical < 0 || canonical >= len(q.Options) {
+		return "", errInvalidOptionToken
+	}
+	return q.Options[canonical], nil
+}
+
+// resolveOrder übersetzt die Indizes der angezeigten Optionen in der gewählten Reihenfolge
+// in die Antwort einer Reihenfolge-Frage (Schritte mit llm.OrderingSeparator verbunden)
+func (h *Handler) resolveOrder(q *models.Question, token string, order []int) (string, error) {
+	if len(order) != len(q.Options) {
+		return "", errInvalidOrder
+	}
+	seen := make(map[int]bool, len(order))
+	steps := make([]string, len(order))
+	for i, displayed := range order {
+		if seen[displayed] {
+			return "", errInvalidOrder
+		}
+		seen[displayed] = true
+		step, err := h.resolveOption(q, token, displayed)
+		if err != nil {
+			return "", err
+		}
+		steps[i] = step
+	}
+	return strings.Join(steps, llm.OrderingSeparator), nil
+}
+
+// signOptionOrder erstellt das Token "<permutation>.<signatur>" für eine Frage
+func (h *Handler) signOptionOrder(questionID string, perm []int) string {
+	order := make([]string, len(perm))
+	for i, p := range perm {
+		order[i] = strconv.Itoa(p)
+	}
+	encoded := strings.Join(order, ",")
+
+	mac := hmac.New(sha256.New, h.shuffleKey)
+	mac.Write([]byte(questionID + ":" + encoded))
+	return encoded + "." + hex.EncodeToString(mac.Sum(nil))[:16]
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/models"
+)
+
+const (
+	maxSyllabusItems = 100
+	maxExamWeight    = 3.0 // höchstens dreifache Lernzeit und Fragenzahl
+)
+
+// syllabusBullet entfernt Aufzählungszeichen und Nummerierungen ("-", "•", "1.", "2.3)", "a)")
+var syllabusBullet = regexp.MustCompile(`^\s*([-*•·]|\d+(\.\d+)+\.?|\d+[.)]|[a-zA-Z][.)])\s+`)
+
+// parseSyllabusItems zerlegt eingefügten Text in einzelne Syllabus-Punkte (eine Zeile = ein Punkt)
+func parseSyllabusItems(text string) []string {
+	var items []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(syllabusBullet.ReplaceAllString(line, ""))
+		if len([]rune(line)) < 3 {
+			continue
+		}
+		items = append(items, line)
+	}
+	return items
+}
+
+// examWeightFactor: Faktor für Lernzeit und Fragenzahl (Gewicht 0 = normal = 1)
+func examWeightFactor(weight float64) float64 {
+	if weight <= 0 {
+		return 1
+	}
+	return weight
+}
+
+// === Syllabus Endpoints ===
+
+// SetSyllabus ordnet das eingefügte Modulhandbuch bzw. die Prüfungsthemen per KI den Themen zu
+// und gewichtet passende Themen höher (mehr Lernzeit, mehr Fragen, markiert im Export)
+func (h *Handler) SetSyllabus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req struct {
+		Text  string   `json:"text"`
+		Items []string `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+
+	items := req.Items
+	if len(items) == 0 {
+		items = parseSyllabusItems(req.Text)
+	}
+	if len(items) == 0 {
+		errorResponse(w, "Keine Syllabus-Punkte angegeben", http.StatusBadRequest)
+		return
+	}
+	if len(items) > maxSyllabusItems {
+		errorResponse(w, fmt.Sprintf("Höchstens %d Syllabus-Punkte", maxSyllabusItems), http.StatusBadRequest)
+		return
+	}
+
+	plan, err := h.store.GetStudyPlan(id)
+	if err != nil {
+		errorResponse(w, "Lernplan nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	// Zugeordnet wird auf Lerneinheiten; Kapitel rechnen ihre Unterthemen hoch
+	topics := leafTopics(plan.Topics)
+	if len(topics) == 0 {
+		errorResponse(w, "Lernplan hat keine Themen", http.StatusBadRequest)
+		return
+	}
+
+	mapping, err := h.tutor.MapSyllabus(r.Context(), items, topics)
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler bei der Zuordnung: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	hits := make([]int, len(topics))
+	syllabus := make([]models.SyllabusItem, len(items))
+	for i, text := range items {
+		syllabus[i] = models.SyllabusItem{
+			ID:          fmt.Sprintf("syl_%d_%d", now.UnixNano(), i),
+			StudyPlanID: plan.ID,
+			Text:        text,
+			TopicIDs:    []string{},
+			Order:       i + 1,
+			CreatedAt:   now,
+		}
+		for _, ti := range mapping[i] {
+			syllabus[i].TopicIDs = append(syllabus[i].TopicIDs, topics[ti].ID)
+			hits[ti]++
+		}
+	}
+
+	if err := h.store.ReplaceSyllabus(plan.ID, syllabus); err != nil {
+		errorResponse(w, "Fehler beim Speichern", http.StatusInternalServerError)
+		return
+	}
+
+	// Gewichte neu setzen; die Lernzeit wird vom ungewichteten Wert aus skaliert
+	minutesDelta := 0
+	for i, t := range topics {
+		weight := 0.0
+		if hits[i] > 0 {
+			weight = math.Min(1+0.5*float64(hits[i]), maxExamWeight)
+		}
+		base := float64(t.EstMinutes) / examWeightFactor(t.ExamWeight)
+		estMinutes := int(math.Round(base * examWeightFactor(weight)))
+
+		if err := h.store.SetTopicExamWeight(t.ID, weight, estMinutes); err != nil {
+			log.Printf("   ✗ Gewicht für '%s' nicht gespeichert: %v", t.Name, err)
+			continue
+		}
+		if estMinutes != t.EstMinutes {
+			minutesDelta += estMinutes - t.EstMinutes
+			h.store.RollUpTopicProgress(t.ID)
+		}
+	}
+
+	if minutesDelta != 0 {
+		plan.TotalMinutes += minutesDelta
+		if err := h.store.SaveStudyPlan(plan); err != nil {
+			log.Printf("   ✗ Gesamtlernzeit nicht aktualisiert: %v", err)
+		}
+	}
+
+	unmapped := 0
+	for _, item := range syllabus {
+		if len(item.TopicIDs) == 0 {
+			unmapped++
+		}
+	}
+
+	updated, _ := h.store.GetStudyPlan(plan.ID)
+	jsonResponse(w, map[string]interface{}{
+		"items":    syllabus,
+		"unmapped": unmapped,
+		"plan":     updated,
+	}, http.StatusOK)
+}
+
+func (h *Handler) GetSyllabus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	items, err := h.store.GetSyllabus(id)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	if items == nil {
+		items = []models.SyllabusItem{}
+	}
+
+	jsonResponse(w, items, http.StatusOK)
+}
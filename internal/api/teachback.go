@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/models"
+)
+
+// Länge der eigenen Erklärung: kürzere lassen sich nicht sinnvoll bewerten
+const (
+	minTeachBackChars = 80
+	maxTeachBackChars = 8000
+)
+
+// TeachBack bewertet eine eigene Erklärung des Themas am Material (Feynman-Methode)
+func (h *Handler) TeachBack(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var req struct {
+		Explanation string `json:"explanation"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+	req.Explanation = strings.TrimSpace(req.Explanation)
+	length := utf8.RuneCountInString(req.Explanation)
+	if length < minTeachBackChars {
+		errorResponse(w, fmt.Sprintf("Erklärung zu kurz (mindestens %d Zeichen)", minTeachBackChars), http.StatusBadRequest)
+		return
+	}
+	if length > maxTeachBackChars {
+		errorResponse(w, fmt.Sprintf("Erklärung zu lang (maximal %d Zeichen)", maxTeachBackChars), http.StatusBadRequest)
+		return
+	}
+
+	topic, err := h.store.GetTopic(id)
+	if err != nil {
+		errorResponse(w, "Thema nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	result, err := h.tutor.EvaluateTeachBack(r.Context(), topic, h.topicContent(topic), req.Explanation)
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler bei der Bewertung: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	result.ID = fmt.Sprintf("tb_%d", time.Now().UnixNano())
+	result.CreatedAt = time.Now()
+	if err := h.store.SaveTeachBack(result); err != nil {
+		log.Printf("⚠️ Erklärung konnte nicht gespeichert werden: %v", err)
+	}
+
+	log.Printf("🗣️ Eigene Erklärung zu '%s': %d%% vollständig, %d%% korrekt", topic.Name, result.Completeness, result.Correctness)
+	jsonResponse(w, result, http.StatusCreated)
+}
+
+// GetTeachBacks listet die bisherigen eigenen Erklärungen eines Themas (neueste zuerst)
+func (h *Handler) GetTeachBacks(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	teachBacks, err := h.store.GetTeachBacksByTopic(id)
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	if teachBacks == nil {
+		teachBacks = []models.TeachBack{}
+	}
+	jsonResponse(w, teachBacks, http.StatusOK)
+}
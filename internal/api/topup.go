@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"lernplattform/internal/llm"
+	"lernplattform/internal/models"
+)
+
+// Höchstzahl an Fragen je Generierung (wie bei POST /topics/{id}/questions/generate)
+const maxQuestionsPerGeneration = 10
+
+// topUpTask ist eine geplante Generierung für ein Thema
+type topUpTask struct {
+	topic      models.Topic
+	difficulty int
+	count      int
+	existing   int // vorhandene Fragen des Themas; Themen mit den wenigsten kommen zuerst
+}
+
+// TopUpQuestions erzeugt nachts Fragen für Themen aktiver Lernpläne, die unter der Mindestzahl
+// liegen oder denen Schwierigkeitsstufen fehlen. Es wird nur so lange generiert, wie das
+// LLM-Zeitbudget des Tages (llm_budget) reicht; was offen bleibt, kommt in der nächsten Nacht dran.
+func (h *Handler) TopUpQuestions(ctx context.Context) error {
+	now := time.Now()
+	if err := h.llmBudgetPaused(now); err != nil {
+		log.Printf("🧩 Fragen-Auffüllen ausgelassen: %v", err)
+		return nil
+	}
+	if !h.llmAvailable(ctx) {
+		return errLLMUnavailable
+	}
+
+	plans, err := h.store.GetAllStudyPlans()
+	if err != nil {
+		return err
+	}
+	var tasks []topUpTask
+	for _, p := range plans {
+		if p.Status != "active" || p.ExamDate.DaysFrom(now) < 0 {
+			continue
+		}
+		planTasks, err := h.topUpTasks(p.ID, now)
+		if err != nil {
+			return err
+		}
+		tasks = append(tasks, planTasks...)
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		if tasks[i].existing != tasks[j].existing {
+			return tasks[i].existing < tasks[j].existing
+		}
+		return tasks[i].topic.ExamWeight > tasks[j].topic.ExamWeight
+	})
+
+	ctx = llm.WithPriority(ctx, llm.PriorityBackground)
+	generated, failed, done := 0, 0, 0
+	var used time.Duration
+	for _, task := range tasks {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := h.llmBudgetPaused(time.Now()); err != nil {
+			log.Printf("   ⏸️ Fragen-Auffüllen unterbrochen: %v", err)
+			break
+		}
+		if err := h.resourcesPaused(false); err != nil {
+			log.Printf("   ⏸️ Fragen-Auffüllen unterbrochen: %v", err)
+			break
+		}
+		start := time.Now()
+		questions, err := h.generateTopicQuestions(ctx, &task.topic, task.difficulty, task.count, "", "")
+		used += time.Since(start)
+		done++
+		if err != nil {
+			log.Printf("   ⚠️ Fragen für '%s' (Schwierigkeit %d): %v", task.topic.Name, task.difficulty, err)
+			failed++
+			continue
+		}
+		generated += len(questions)
+	}
+
+	if generated > 0 {
+		h.changes.bump()
+	}
+	log.Printf("🧩 Fragen aufgefüllt: %d neue Fragen in %d von %d Generierungen (%v LLM-Zeit, %d fehlgeschlagen)",
+		generated, done-failed, len(tasks), used.Round(time.Second), failed)
+	if failed > 0 && generated == 0 {
+		return fmt.Errorf("%d generierungen fehlgeschlagen", failed)
+	}
+	return ctx.Err()
+}
+
+// topUpTasks plant die Generierungen für einen Lernplan: je fehlende Schwierigkeitsstufe eine Frage,
+// der Rest bis zur Mindestzahl in der Schwierigkeit der aktuellen Phase
+func (h *Handler) topUpTasks(planID string, now time.Time) ([]topUpTask, error) {
+	cfg := h.config.QuestionTopUp
+	plan, err := h.store.GetStudyPlan(planID)
+	if err != nil {
+		return nil, err
+	}
+	questions, err := h.store.GetQuestionsByPlan(planID)
+	if err != nil {
+		return nil, err
+	}
+	byDifficulty := make(map[string]map[int]int)
+	total := make(map[string]int)
+	for _, q := range questions {
+		if byDifficulty[q.TopicID] == nil {
+			byDifficulty[q.TopicID] = make(map[int]int)
+		}
+		byDifficulty[q.TopicID][min(max(q.Difficulty, 1), 5)]++
+		total[q.TopicID]++
+	}
+
+	difficulty := 3
+	if phase := currentPhase(plan, now); phase != nil {
+		difficulty = phaseDefaults[phase.Phase].difficulty
+	}
+
+	var tasks []topUpTask
+	for _, t := range leafTopics(plan.Topics) {
+		deficit := cfg.MinQuestions - total[t.ID]
+		if cfg.Difficulties {
+			for d := 1; d <= 5; d++ {
+				if byDifficulty[t.ID][d] == 0 {
+					tasks = append(tasks, topUpTask{topic: t, difficulty: d, count: 1, existing: total[t.ID]})
+					deficit--
+				}
+			}
+		}
+		if deficit > 0 {
+			tasks = append(tasks, topUpTask{topic: t, difficulty: difficulty, count: min(deficit, maxQuestionsPerGeneration), existing: total[t.ID]})
+		}
+	}
+	return tasks, nil
+}
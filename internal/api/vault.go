@@ -0,0 +1,254 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"lernplattform/internal/models"
+	"lernplattform/internal/pdf"
+)
+
+// Obergrenze für die Anzahl Notizen eines Imports
+const maxVaultNotes = 2000
+
+// vaultEntry ist eine Zeile im Bericht eines Notiz-Imports
+type vaultEntry struct {
+	File       string                `json:"file"`
+	Status     string                `json:"status"` // new, updated oder error
+	DocumentID string                `json:"document_id,omitempty"`
+	Tags       []string              `json:"tags,omitempty"`
+	Links      []models.DocumentLink `json:"links,omitempty"`
+	Error      string                `json:"error,omitempty"`
+}
+
+// ImportVault liest einen Ordner mit verlinkten Markdown-Notizen (Obsidian-Vault, Notion-Export) ein.
+// Jede Notiz wird ein Dokument, Ordner werden zu Schlagworten. [[Wiki-Links]] werden zu Verweisen auf
+// andere Notizen, Themen oder Glossarbegriffe gleichen Namens; Notizen mit Verweis auf ein Thema
+// fließen in den Chat-Kontext dieses Themas ein. Erneutes Importieren aktualisiert die Dokumente.
+func (h *Handler) ImportVault(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Path string `json:"path"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Path) == "" {
+		errorResponse(w, "Pfad zum Notizordner fehlt", http.StatusBadRequest)
+		return
+	}
+	root, err := filepath.Abs(strings.TrimSpace(req.Path))
+	if err != nil {
+		errorResponse(w, "Ungültiger Pfad", http.StatusBadRequest)
+		return
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		errorResponse(w, "Notizordner nicht gefunden", http.StatusBadRequest)
+		return
+	}
+
+	var files []string
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// .obsidian, .trash usw. enthalten Einstellungen und Gelöschtes
+		if p != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".md") {
+			if len(files) >= maxVaultNotes {
+				return fmt.Errorf("mehr als %d Notizen", maxVaultNotes)
+			}
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler beim Lesen des Notizordners: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Bereits importierte Notizen behalten ihre Dokument-ID und selbst gesetzte Angaben
+	byPath := make(map[string]models.Document)
+	if docs, err := h.store.GetAllDocuments(); err == nil {
+		for _, doc := range docs {
+			if doc.Path != "" {
+				byPath[doc.Path] = doc
+			}
+		}
+	}
+
+	report := []vaultEntry{}
+	var notes []*models.Document
+	var entries []int // Index im Bericht je Notiz
+	for _, file := range files {
+		rel, _ := filepath.Rel(root, file)
+		entry := vaultEntry{File: filepath.ToSlash(rel), Status: "new"}
+		doc, err := h.parseNote(file)
+		if err != nil {
+			entry.Status, entry.Error = "error", err.Error()
+			report = append(report, entry)
+			continue
+		}
+		if old, ok := byPath[file]; ok {
+			doc.ID, entry.Status = old.ID, "updated"
+			doc.Title, doc.Author, doc.Semester = old.Title, old.Author, old.Semester
+		}
+		for _, tag := range zipTags(filepath.ToSlash(rel)) {
+			if tag = pdf.NoteName(tag); tag != "" {
+				doc.Tags = append(doc.Tags, tag)
+			}
+		}
+		notes = append(notes, doc)
+		entries = append(entries, len(report))
+		report = append(report, entry)
+	}
+
+	h.resolveNoteLinks(notes)
+
+	counts := make(map[string]int)
+	for i, doc := range notes {
+		entry := &report[entries[i]]
+		if err := h.storeDocument(doc); err != nil {
+			entry.Status, entry.Error = "error", "Fehler beim Speichern"
+			continue
+		}
+		entry.DocumentID, entry.Tags, entry.Links = doc.ID, doc.Tags, doc.Links
+	}
+	for _, entry := range report {
+		counts[entry.Status]++
+	}
+
+	log.Printf("🗒️ Notiz-Import aus %s: %d neu, %d aktualisiert, %d Fehler", root, counts["new"], counts["updated"], counts["error"])
+	jsonResponse(w, map[string]interface{}{
+		"new":     counts["new"],
+		"updated": counts["updated"],
+		"failed":  counts["error"],
+		"notes":   report,
+	}, http.StatusOK)
+}
+
+// parseNote liest eine Markdown-Datei; der Dateiname ohne .md ist der Name, über den verlinkt wird
+func (h *Handler) parseNote(file string) (*models.Document, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	doc, err := h.pdfParser.ParseMarkdown(f, pdf.NoteName(filepath.Base(file)))
+	if err != nil {
+		return nil, err
+	}
+	doc.Path = file
+	return doc, nil
+}
+
+// resolveNoteLinks ordnet die Links der Notizen anderen Notizen, Themen und Glossarbegriffen gleichen
+// Namens zu (Groß-/Kleinschreibung egal). Ein Ziel kann zugleich Notiz, Thema und Begriff sein.
+func (h *Handler) resolveNoteLinks(notes []*models.Document) {
+	noteIDs := make(map[string]string)
+	for _, doc := range notes {
+		noteIDs[strings.ToLower(doc.Name)] = doc.ID
+	}
+	topicIDs := make(map[string]string)
+	if plans, err := h.store.GetAllStudyPlans(); err == nil {
+		for _, plan := range plans {
+			topics, _ := h.store.GetTopicsByPlan(plan.ID)
+			collectTopicIDs(topics, topicIDs)
+		}
+	}
+	glossaryIDs := make(map[string]string)
+	if items, err := h.store.GetAllGlossaryItems(); err == nil {
+		for _, item := range items {
+			glossaryIDs[strings.ToLower(item.Term)] = item.ID
+		}
+	}
+
+	for _, doc := range notes {
+		var links []models.DocumentLink
+		for _, link := range doc.Links {
+			key := strings.ToLower(link.Target)
+			resolved := false
+			if id, ok := noteIDs[key]; ok && id != doc.ID {
+				links = append(links, models.DocumentLink{Target: link.Target, Kind: "note", RefID: id})
+				resolved = true
+			}
+			if id, ok := topicIDs[key]; ok {
+				links = append(links, models.DocumentLink{Target: link.Target, Kind: "topic", RefID: id})
+				resolved = true
+			}
+			if id, ok := glossaryIDs[key]; ok {
+				links = append(links, models.DocumentLink{Target: link.Target, Kind: "glossary", RefID: id})
+				resolved = true
+			}
+			if !resolved {
+				links = append(links, models.DocumentLink{Target: link.Target, Kind: "unresolved"})
+			}
+		}
+		doc.Links = links
+	}
+}
+
+// collectTopicIDs trägt Themen und Unterthemen nach Namen ein (bei gleichem Namen gilt das erste)
+func collectTopicIDs(topics []models.Topic, ids map[string]string) {
+	for _, topic := range topics {
+		if _, ok := ids[strings.ToLower(topic.Name)]; !ok {
+			ids[strings.ToLower(topic.Name)] = topic.ID
+		}
+		collectTopicIDs(topic.Subtopics, ids)
+	}
+}
+
+// linkedNotes liefert die importierten Notizen, die per Wiki-Link auf das Thema verweisen. Neben der
+// beim Import zugeordneten ID zählt auch der Name, damit später angelegte Themen ihre Notizen finden.
+func (h *Handler) linkedNotes(topic *models.Topic, exclude map[string]bool) []*models.Document {
+	docs, err := h.store.GetAllDocuments()
+	if err != nil {
+		return nil
+	}
+	var notes []*models.Document
+	for _, doc := range docs {
+		if exclude[doc.ID] {
+			continue
+		}
+		for _, link := range doc.Links {
+			if (link.Kind == "topic" && link.RefID == topic.ID) || strings.EqualFold(link.Target, topic.Name) {
+				if note, err := h.store.GetDocument(doc.ID); err == nil {
+					notes = append(notes, note)
+				}
+				break
+			}
+		}
+	}
+	return notes
+}
+
+// noteGlossaryContext liefert die Definitionen der Glossarbegriffe, auf die die Notizen verweisen
+func (h *Handler) noteGlossaryContext(notes []*models.Document) string {
+	var sb strings.Builder
+	seen := make(map[string]bool)
+	for _, note := range notes {
+		for _, link := range note.Links {
+			if link.Kind != "glossary" || seen[link.RefID] {
+				continue
+			}
+			seen[link.RefID] = true
+			item, err := h.store.GetGlossaryItem(link.RefID)
+			if err != nil {
+				continue
+			}
+			if sb.Len() == 0 {
+				sb.WriteString("\n=== Verknüpfte Glossarbegriffe ===\n")
+			}
+			sb.WriteString(fmt.Sprintf("%s: %s\n", item.Term, item.Definition))
+		}
+	}
+	return sb.String()
+}
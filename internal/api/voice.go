@@ -0,0 +1,141 @@
+package api
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/voice"
+)
+
+// Obergrenzen der Textlänge: pro Sprachausgabe und für eine vorgelesene Erklärung (ca. 20 Minuten)
+const (
+	maxTTSChars              = 5000
+	maxExplanationAudioChars = 20000
+)
+
+// === Sprach-Endpoints ===
+
+// SpeechToText wandelt eine hochgeladene Audiodatei in Text um
+func (h *Handler) SpeechToText(w http.ResponseWriter, r *http.Request) {
+	if h.stt == nil {
+		errorResponse(w, "Spracherkennung nicht konfiguriert (whisper_url)", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Max 25MB Audio (Obergrenze setzt limitsMiddleware)
+	if err := r.ParseMultipartForm(25 << 20); bodyTooLarge(w, err) {
+		return
+	}
+
+	file, header, err := r.FormFile("audio")
+	if err != nil {
+		errorResponse(w, "Keine Audiodatei gefunden", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	text, err := h.stt.Transcribe(r.Context(), file, header.Filename, r.FormValue("language"))
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler bei der Spracherkennung: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	jsonResponse(w, map[string]interface{}{
+		"text": text,
+	}, http.StatusOK)
+}
+
+// TextToSpeech liefert den übergebenen Text als Audiodatei
+func (h *Handler) TextToSpeech(w http.ResponseWriter, r *http.Request) {
+	if h.tts == nil {
+		errorResponse(w, "Sprachausgabe nicht konfiguriert (piper_model)", http.StatusServiceUnavailable)
+		return
+	}
+
+	text := strings.TrimSpace(r.URL.Query().Get("text"))
+	if text == "" {
+		errorResponse(w, "Kein Text angegeben", http.StatusBadRequest)
+		return
+	}
+	if utf8.RuneCountInString(text) > maxTTSChars {
+		errorResponse(w, fmt.Sprintf("Text zu lang (max. %d Zeichen)", maxTTSChars), http.StatusBadRequest)
+		return
+	}
+
+	audio, contentType, err := h.tts.Synthesize(r.Context(), text)
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler bei der Sprachausgabe: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	writeAudio(w, audio, contentType, "sprachausgabe.wav")
+}
+
+// ExplainTopicAudio wandelt die gespeicherte Erklärung eines Themas in eine MP3 um.
+// Das Ergebnis wird pro Erklärungsinhalt zwischengespeichert.
+func (h *Handler) ExplainTopicAudio(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if h.tts == nil {
+		errorResponse(w, "Sprachausgabe nicht konfiguriert (piper_model)", http.StatusServiceUnavailable)
+		return
+	}
+
+	explanation, err := h.store.GetLatestExplanation(id)
+	if err != nil {
+		errorResponse(w, "Keine gespeicherte Erklärung vorhanden – bitte zuerst die Erklärung abrufen", http.StatusNotFound)
+		return
+	}
+
+	text := voice.SpeakableText(explanation.Title + "\n" + explanation.Content)
+	if utf8.RuneCountInString(text) > maxExplanationAudioChars {
+		errorResponse(w, fmt.Sprintf("Erklärung zu lang zum Vorlesen (max. %d Zeichen)", maxExplanationAudioChars), http.StatusBadRequest)
+		return
+	}
+
+	// Cache-Schlüssel aus Thema und Inhalt, damit neue Erklärungen neu vertont werden
+	hash := sha1.Sum([]byte(explanation.Content))
+	cacheFile := filepath.Join(h.config.AudioCachePath, fmt.Sprintf("%s_%s.mp3", id, hex.EncodeToString(hash[:])[:12]))
+
+	if data, err := os.ReadFile(cacheFile); err == nil {
+		log.Printf("🔊 Audio aus Cache: %s", cacheFile)
+		writeAudio(w, data, "audio/mpeg", filepath.Base(cacheFile))
+		return
+	}
+
+	wav, _, err := h.tts.Synthesize(r.Context(), text)
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler bei der Sprachausgabe: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	mp3, err := voice.EncodeMP3(r.Context(), h.config.FFmpegPath, wav)
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Fehler bei der MP3-Umwandlung: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if err := os.MkdirAll(h.config.AudioCachePath, 0755); err == nil {
+		if err := os.WriteFile(cacheFile, mp3, 0644); err != nil {
+			log.Printf("⚠️ Audio konnte nicht gecacht werden: %v", err)
+		}
+	}
+
+	writeAudio(w, mp3, "audio/mpeg", filepath.Base(cacheFile))
+}
+
+func writeAudio(w http.ResponseWriter, data []byte, contentType string, filename string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
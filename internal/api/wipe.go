@@ -0,0 +1,195 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Gültigkeit eines Bestätigungstokens für POST /account/wipe
+const wipeTokenTTL = 5 * time.Minute
+
+// wipeConfirmation hält das zuletzt ausgegebene Bestätigungstoken (nur einmal einlösbar)
+type wipeConfirmation struct {
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func (c *wipeConfirmation) issue(now time.Time) (string, time.Time) {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token, c.expires = hex.EncodeToString(buf), now.Add(wipeTokenTTL)
+	return c.token, c.expires
+}
+
+func (c *wipeConfirmation) redeem(token string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == "" || now.After(c.expires) || subtle.ConstantTimeCompare([]byte(token), []byte(c.token)) != 1 {
+		return false
+	}
+	c.token = ""
+	return true
+}
+
+// WipeAccount löscht alle Daten der Plattform in zwei Schritten: ohne confirm_token gibt es eine
+// Übersicht und ein Token, das fünf Minuten gilt; mit dem Token werden Dokumente, Pläne, Chats,
+// Lernverlauf und zwischengespeicherte Embeddings und Audiodateien gelöscht.
+// Mit shred_files werden die Dateien der Dokumente im Dokumentenordner überschrieben und entfernt.
+func (h *Handler) WipeAccount(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ConfirmToken string `json:"confirm_token"`
+		ShredFiles   bool   `json:"shred_files"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		errorResponse(w, "Ungültige Anfrage", http.StatusBadRequest)
+		return
+	}
+
+	docs, err := h.store.GetAllDocuments()
+	if err != nil {
+		errorResponse(w, "Fehler beim Laden", http.StatusInternalServerError)
+		return
+	}
+	var files []string
+	for _, doc := range docs {
+		if h.inDocumentsPath(doc.Path) {
+			files = append(files, doc.Path)
+		}
+	}
+
+	now := time.Now()
+	if req.ConfirmToken == "" {
+		counts, err := h.store.CountAllData()
+		if err != nil {
+			errorResponse(w, "Fehler beim Zählen", http.StatusInternalServerError)
+			return
+		}
+		token, expires := h.wipe.issue(now)
+		jsonResponse(w, map[string]interface{}{
+			"message":        "Zum Löschen erneut mit confirm_token senden",
+			"confirm_token":  token,
+			"expires_at":     expires,
+			"entries":        counts,
+			"document_files": len(files),
+			"documents_path": h.config.DocumentsPath,
+		}, http.StatusOK)
+		return
+	}
+	if !h.wipe.redeem(req.ConfirmToken, now) {
+		errorResponse(w, "Bestätigungstoken ungültig oder abgelaufen", http.StatusForbidden)
+		return
+	}
+
+	counts, err := h.store.WipeAllData()
+	if err != nil {
+		log.Printf("⚠️ Daten löschen: %v", err)
+		errorResponse(w, "Fehler beim Löschen", http.StatusInternalServerError)
+		return
+	}
+
+	h.embeddings.mu.Lock()
+	h.embeddings.vectors = make(map[string][]float64)
+	h.embeddings.mu.Unlock()
+	h.ensureDefaultProfiles()
+
+	audioRemoved := 0
+	if entries, err := os.ReadDir(h.config.AudioCachePath); err == nil {
+		for _, e := range entries {
+			if !e.IsDir() && os.Remove(filepath.Join(h.config.AudioCachePath, e.Name())) == nil {
+				audioRemoved++
+			}
+		}
+	}
+
+	shredded := 0
+	fileErrors := []string{}
+	if req.ShredFiles {
+		for _, path := range files {
+			if err := shredFile(path); err != nil {
+				fileErrors = append(fileErrors, filepath.Base(path)+": "+err.Error())
+				continue
+			}
+			shredded++
+		}
+	}
+
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	log.Printf("🗑️ Alle Daten gelöscht: %d Einträge, %d Dateien geschreddert, %d Audiodateien", total, shredded, audioRemoved)
+	jsonResponse(w, map[string]interface{}{
+		"message":        "Alle Daten gelöscht",
+		"deleted":        counts,
+		"files_shredded": shredded,
+		"file_errors":    fileErrors,
+		"audio_removed":  audioRemoved,
+	}, http.StatusOK)
+}
+
+// inDocumentsPath prüft, ob eine Datei im Dokumentenordner liegt; nur dort wird geschreddert
+// (importierte Notizen aus einem Vault bleiben unangetastet)
+func (h *Handler) inDocumentsPath(path string) bool {
+	if path == "" || h.config.DocumentsPath == "" {
+		return false
+	}
+	root, err := filepath.Abs(h.config.DocumentsPath)
+	if err != nil {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(root, abs)
+	return err == nil && rel != "." && !strings.HasPrefix(rel, "..")
+}
+
+// shredFile überschreibt eine Datei mit Zufallsdaten und löscht sie. Auf SSDs und Dateisystemen
+// mit Copy-on-Write ist das keine Garantie, verhindert aber das einfache Wiederherstellen.
+func shredFile(path string) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, 64*1024)
+	for written := int64(0); written < info.Size(); {
+		n := min(int64(len(buf)), info.Size()-written)
+		rand.Read(buf[:n])
+		if _, err := f.Write(buf[:n]); err != nil {
+			f.Close()
+			return err
+		}
+		written += n
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
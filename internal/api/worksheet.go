@@ -0,0 +1,196 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"lernplattform/internal/models"
+	"lernplattform/internal/pdf"
+)
+
+// Anzahl Übungsfragen auf einem Arbeitsblatt (Standard und Obergrenze)
+const (
+	defaultWorksheetQuestions = 10
+	maxWorksheetQuestions     = 30
+)
+
+// Höchstzahl Fachbegriffe auf einem Arbeitsblatt
+const maxWorksheetTerms = 12
+
+// Markdown-Auszeichnung, die im PDF nicht dargestellt wird
+var markdownEmphasis = regexp.MustCompile("\\*\\*|__|`")
+
+// Worksheet erstellt ein druckbares Arbeitsblatt (PDF) zu einem Thema: Erklärung, wichtige
+// Fachbegriffe aus dem Glossar und Übungsfragen; die Lösungen stehen auf einer eigenen Seite.
+// Ohne gespeicherte Erklärung wird eine erzeugt. Query: questions (Anzahl, Standard 10).
+func (h *Handler) Worksheet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	limit := defaultWorksheetQuestions
+	if s := r.URL.Query().Get("questions"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > maxWorksheetQuestions {
+			errorResponse(w, fmt.Sprintf("questions muss zwischen 0 und %d liegen", maxWorksheetQuestions), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	topic, err := h.store.GetTopic(id)
+	if err != nil {
+		errorResponse(w, "Thema nicht gefunden", http.StatusNotFound)
+		return
+	}
+
+	explanation, err := h.store.GetLatestExplanation(topic.ID)
+	if err != nil || explanation == nil {
+		if !h.llmAvailable(r.Context()) {
+			h.llmUnavailable(w, "Arbeitsblatt ohne gespeicherte Erklärung")
+			return
+		}
+		ctx := h.withTopicSettings(r.Context(), topic)
+		explanation, err = h.tutor.ExplainTopic(ctx, topic, h.topicContent(topic))
+		if err != nil {
+			errorResponse(w, fmt.Sprintf("Fehler bei der Erklärung: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if !h.storeExplanation(w, r, topic, explanation) {
+			return
+		}
+	}
+
+	glossary, _ := h.store.GetAllGlossaryItems()
+	terms := worksheetTerms(topic, explanation, glossary)
+	questions := worksheetQuestions(topic.Questions, limit)
+
+	doc := pdf.NewWriter("Arbeitsblatt: " + topic.Name)
+	doc.Heading("Arbeitsblatt: "+topic.Name, 1)
+	doc.Paragraph("Erstellt am " + time.Now().Format("02.01.2006") + "    Name: ______________________")
+
+	doc.Heading("Erklärung", 2)
+	if explanation.Title != "" && explanation.Title != topic.Name {
+		doc.Heading(explanation.Title, 3)
+	}
+	writeMarkdown(doc, explanation.Content)
+	if len(explanation.KeyPoints) > 0 {
+		doc.Heading("Das Wichtigste", 3)
+		for _, point := range explanation.KeyPoints {
+			doc.Bullet(stripMarkdown(point))
+		}
+	}
+	if len(explanation.Examples) > 0 {
+		doc.Heading("Beispiele", 3)
+		for _, example := range explanation.Examples {
+			doc.Bullet(stripMarkdown(example))
+		}
+	}
+
+	if len(terms) > 0 {
+		doc.Heading("Fachbegriffe", 2)
+		for _, item := range terms {
+			doc.Term(item.Term, stripMarkdown(item.Definition))
+		}
+	}
+
+	if len(questions) > 0 {
+		doc.Heading("Übungsfragen", 2)
+		for i, q := range questions {
+			doc.Numbered(i+1, stripMarkdown(q.Question))
+			switch {
+			case q.Type == "ordering":
+				h.shuffleOptions(&q) // gespeichert ist die richtige Reihenfolge
+				for _, option := range q.Options {
+					doc.Choice("[  ]", stripMarkdown(option))
+				}
+			case len(q.Options) > 0:
+				for j, option := range q.Options {
+					doc.Choice(fmt.Sprintf("%c)", 'a'+j), stripMarkdown(option))
+				}
+			case q.Type == "true_false":
+				doc.Choice("[ ]", "richtig")
+				doc.Choice("[ ]", "falsch")
+			case q.Type == "cloze":
+				doc.AnswerLines(1)
+			default:
+				doc.AnswerLines(3)
+			}
+		}
+
+		doc.PageBreak()
+		doc.Heading("Lösungen", 2)
+		for i, q := range questions {
+			doc.Numbered(i+1, stripMarkdown(q.ExpectedAnswer))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "arbeitsblatt_"+topic.ID+".pdf"))
+	w.WriteHeader(http.StatusOK)
+	w.Write(doc.Bytes())
+}
+
+// worksheetTerms wählt die Glossarbegriffe, die im Thema oder in der Erklärung vorkommen
+func worksheetTerms(topic *models.Topic, explanation *models.Explanation, glossary []models.GlossaryItem) []models.GlossaryItem {
+	text := strings.ToLower(strings.Join(append([]string{topic.Name, topic.Description, explanation.Content}, explanation.KeyPoints...), "\n"))
+	var terms []models.GlossaryItem
+	for _, item := range glossary {
+		term := strings.ToLower(strings.TrimSpace(item.Term))
+		if len([]rune(term)) < 3 || !strings.Contains(text, term) {
+			continue
+		}
+		terms = append(terms, item)
+		if len(terms) == maxWorksheetTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// worksheetQuestions nimmt bis zu limit Fragen, von leicht nach schwer sortiert
+func worksheetQuestions(questions []models.Question, limit int) []models.Question {
+	sorted := append([]models.Question(nil), questions...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Difficulty < sorted[j].Difficulty })
+	if len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
+
+// writeMarkdown überträgt einfaches Markdown (Überschriften, Aufzählungen, Absätze) ins PDF
+func writeMarkdown(doc *pdf.Writer, content string) {
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			doc.Paragraph(strings.Join(paragraph, " "))
+			paragraph = nil
+		}
+	}
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "#"):
+			flush()
+			doc.Heading(stripMarkdown(strings.TrimLeft(trimmed, "# ")), 3)
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
+			flush()
+			doc.Bullet(stripMarkdown(trimmed[2:]))
+		default:
+			paragraph = append(paragraph, stripMarkdown(trimmed))
+		}
+	}
+	flush()
+}
+
+// stripMarkdown entfernt Fett-/Code-Auszeichnung
+func stripMarkdown(text string) string {
+	return markdownEmphasis.ReplaceAllString(text, "")
+}
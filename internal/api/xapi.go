@@ -0,0 +1,86 @@
+package api
+
+import (
+	"lernplattform/internal/models"
+	"lernplattform/internal/xapi"
+)
+
+// xapiTopicContext ordnet ein Statement dem Thema (parent) und dem Lernplan (grouping) zu
+func (h *Handler) xapiTopicContext(statement *xapi.Statement, topicID string) {
+	topic, err := h.store.GetTopic(topicID)
+	if err != nil {
+		return
+	}
+	activities := map[string][]xapi.Activity{
+		"parent": {h.xapi.Activity("topics", topic.ID, topic.Name, xapi.TypeModule)},
+	}
+	if plan, err := h.store.GetStudyPlan(topic.StudyPlanID); err == nil {
+		activities["grouping"] = []xapi.Activity{h.xapi.Activity("plans", plan.ID, plan.Name, xapi.TypeCourse)}
+	}
+	statement.Context.ContextActivities = activities
+	statement.Context.Language = h.topicLanguage(topic)
+}
+
+// emitAnswered meldet einen Antwortversuch an den LRS
+func (h *Handler) emitAnswered(q *models.Question, attempt *models.QuestionAttempt) {
+	if !h.xapi.Enabled() {
+		return
+	}
+	interaction := "long-fill-in"
+	switch {
+	case q.Type == "true_false":
+		interaction = "true-false"
+	case q.Type == "cloze":
+		interaction = "fill-in"
+	case q.Type == "ordering":
+		interaction = "sequencing"
+	case len(q.Options) > 0:
+		interaction = "choice"
+	}
+	object := h.xapi.Activity("questions", q.ID, q.Question, xapi.TypeInteraction)
+	object.Definition.InteractionType = interaction
+
+	statement := h.xapi.Statement(xapi.VerbAnswered, object)
+	success := attempt.IsCorrect
+	statement.Result = &xapi.Result{Success: &success, Response: attempt.Answer}
+	if attempt.Score > 0 {
+		statement.Result.Score = &xapi.Score{Scaled: float64(attempt.Score) / 100}
+	}
+	if attempt.AnswerSeconds != nil {
+		statement.Result.Duration = xapi.Duration(*attempt.AnswerSeconds)
+	}
+	h.xapiTopicContext(&statement, q.TopicID)
+	h.xapi.Emit(statement)
+}
+
+// emitTopicCompleted meldet ein abgeschlossenes Thema an den LRS
+func (h *Handler) emitTopicCompleted(topic *models.Topic) {
+	if !h.xapi.Enabled() {
+		return
+	}
+	statement := h.xapi.Statement(xapi.VerbCompleted, h.xapi.Activity("topics", topic.ID, topic.Name, xapi.TypeModule))
+	completion := true
+	statement.Result = &xapi.Result{Completion: &completion}
+	if plan, err := h.store.GetStudyPlan(topic.StudyPlanID); err == nil {
+		statement.Context.ContextActivities = map[string][]xapi.Activity{
+			"parent": {h.xapi.Activity("plans", plan.ID, plan.Name, xapi.TypeCourse)},
+		}
+	}
+	h.xapi.Emit(statement)
+}
+
+// emitSessionEnded meldet eine beendete Lernsitzung mit Dauer und Trefferquote an den LRS
+func (h *Handler) emitSessionEnded(session *models.StudySession) {
+	if !h.xapi.Enabled() {
+		return
+	}
+	statement := h.xapi.Statement(xapi.VerbTerminated, h.xapi.Activity("sessions", session.ID, "Lernsitzung", xapi.TypeAttempt))
+	statement.Result = &xapi.Result{Duration: xapi.Duration(session.Duration * 60)}
+	if session.QuestionsAnswered > 0 {
+		statement.Result.Score = &xapi.Score{Scaled: min(float64(session.CorrectAnswers)/float64(session.QuestionsAnswered), 1)}
+	}
+	if session.TopicID != "" {
+		h.xapiTopicContext(&statement, session.TopicID)
+	}
+	h.xapi.Emit(statement)
+}
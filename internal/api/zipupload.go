@@ -0,0 +1,177 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+
+	"lernplattform/internal/hooks"
+	"lernplattform/internal/models"
+	"lernplattform/internal/pdf"
+)
+
+// Obergrenzen für entpackte ZIP-Inhalte (Schutz vor ZIP-Bomben)
+const (
+	maxZipFileBytes  = 50 << 20
+	maxZipTotalBytes = 500 << 20
+	maxZipFiles      = 200
+)
+
+// zipEntryResult ist eine Zeile im Bericht eines ZIP-Uploads
+type zipEntryResult struct {
+	File       string   `json:"file"`
+	Status     string   `json:"status"` // ok, skipped oder error
+	DocumentID string   `json:"document_id,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Pages      int      `json:"pages,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// parseUpload liest eine hochgeladene PDF- oder DOCX-Datei
+func (h *Handler) parseUpload(reader io.Reader, filename string) (*models.Document, error) {
+	if strings.HasSuffix(strings.ToLower(filename), ".docx") {
+		return h.pdfParser.ParseDOCX(reader, filename)
+	}
+	return h.pdfParser.ParseFromReader(reader, filename)
+}
+
+// storeDocument speichert ein eingelesenes Dokument samt Statistik und Inhaltsverzeichnis
+func (h *Handler) storeDocument(doc *models.Document) error {
+	// Eigene Bereinigung oder Anreicherung (after_ingest-Hooks), danach die Kennzahlen neu berechnen
+	if h.hooks.Enabled(hooks.AfterIngest) {
+		h.hooks.AfterIngest(context.Background(), doc)
+		pdf.ApplyTextStats(doc)
+	}
+	if err := h.store.SaveDocument(doc); err != nil {
+		return err
+	}
+	h.saveDocumentStats(doc)
+	h.saveDocumentTOC(doc)
+	h.saveDocumentFigures(doc)
+	if !doc.HasText {
+		log.Printf("⚠️ '%s' enthält kaum Text (%d Wörter auf %d Seiten) – vermutlich eingescannt, OCR nötig", doc.Name, doc.WordCount, doc.PageCount)
+	}
+	return nil
+}
+
+// uploadZip entpackt ein ZIP-Archiv und liest jede PDF/DOCX als eigenes Dokument ein.
+// Die Ordner im Archiv werden zu Schlagworten; die Antwort enthält einen Bericht je Datei.
+func (h *Handler) uploadZip(w http.ResponseWriter, file io.ReaderAt, size int64) {
+	archive, err := zip.NewReader(file, size)
+	if err != nil {
+		errorResponse(w, fmt.Sprintf("Ungültiges ZIP-Archiv: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	report := []zipEntryResult{}
+	documents := []models.Document{}
+	var total int64
+	files := 0
+	for _, f := range archive.File {
+		name := path.Clean(strings.ReplaceAll(f.Name, "\\", "/"))
+		base := path.Base(name)
+		if f.FileInfo().IsDir() || strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(base, ".") {
+			continue
+		}
+
+		entry := zipEntryResult{File: name, Status: "skipped"}
+		lower := strings.ToLower(base)
+		if !strings.HasSuffix(lower, ".pdf") && !strings.HasSuffix(lower, ".docx") {
+			entry.Error = "kein PDF- oder DOCX-Dokument"
+			report = append(report, entry)
+			continue
+		}
+
+		files++
+		switch {
+		case files > maxZipFiles:
+			entry.Error = fmt.Sprintf("mehr als %d Dokumente im Archiv", maxZipFiles)
+		case f.UncompressedSize64 > maxZipFileBytes:
+			entry.Error = fmt.Sprintf("Datei zu groß (maximal %s)", formatBytes(maxZipFileBytes))
+		case total >= maxZipTotalBytes:
+			entry.Error = fmt.Sprintf("Archiv entpackt zu groß (maximal %s)", formatBytes(maxZipTotalBytes))
+		}
+		if entry.Error != "" {
+			report = append(report, entry)
+			continue
+		}
+
+		// Gezählt wird, was tatsächlich entpackt wurde, nicht die Größenangabe im Archiv
+		doc, read, err := h.parseZipEntry(f, base, maxZipTotalBytes-total)
+		total += read
+		if err != nil {
+			entry.Status, entry.Error = "error", err.Error()
+			report = append(report, entry)
+			continue
+		}
+		doc.Tags = zipTags(name)
+		if err := h.storeDocument(doc); err != nil {
+			entry.Status, entry.Error = "error", "Fehler beim Speichern"
+			report = append(report, entry)
+			continue
+		}
+
+		entry.Status, entry.DocumentID, entry.Tags, entry.Pages = "ok", doc.ID, doc.Tags, doc.PageCount
+		report = append(report, entry)
+		doc.Content = "" // Liste bleibt klein, Inhalt über GET /documents/{id}
+		documents = append(documents, *doc)
+	}
+
+	log.Printf("📦 ZIP-Upload: %d von %d Dateien eingelesen", len(documents), len(report))
+
+	status := http.StatusCreated
+	if len(documents) == 0 {
+		status = http.StatusBadRequest
+	}
+	jsonResponse(w, map[string]interface{}{
+		"documents": documents,
+		"count":     len(documents),
+		"report":    report,
+	}, status)
+}
+
+// parseZipEntry liest eine Datei aus dem Archiv und liefert, wie viele Bytes entpackt wurden.
+// Entpackt wird höchstens maxZipFileBytes bzw. das, was vom Gesamtlimit noch übrig ist (remaining).
+func (h *Handler) parseZipEntry(f *zip.File, name string, remaining int64) (*models.Document, int64, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rc.Close()
+
+	limit := min(int64(maxZipFileBytes), remaining)
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	read := int64(len(data))
+	if err != nil {
+		return nil, read, err
+	}
+	if read > limit {
+		if limit < maxZipFileBytes {
+			return nil, read, fmt.Errorf("Archiv entpackt zu groß (maximal %s)", formatBytes(maxZipTotalBytes))
+		}
+		return nil, read, fmt.Errorf("Datei zu groß (maximal %s)", formatBytes(maxZipFileBytes))
+	}
+	doc, err := h.parseUpload(bytes.NewReader(data), name)
+	return doc, read, err
+}
+
+// zipTags macht aus den Ordnern eines Pfads im Archiv Schlagworte ("Mathe/Analysis/blatt1.pdf" → Mathe, Analysis)
+func zipTags(name string) []string {
+	dir := path.Dir(name)
+	if dir == "." || dir == "/" {
+		return nil
+	}
+	var tags []string
+	for _, part := range strings.Split(dir, "/") {
+		if part = strings.TrimSpace(part); part != "" && part != "." && part != ".." {
+			tags = append(tags, part)
+		}
+	}
+	return tags
+}
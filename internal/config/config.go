@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 // Config enthält alle Konfigurationseinstellungen
@@ -11,6 +13,9 @@ type Config struct {
 	// Server-Einstellungen
 	ServerPort string `json:"server_port"`
 
+	// Zeitzone für Tagesgrenzen wie Prüfungstermin und Tagesziele (z.B. "Europe/Berlin", leer = Systemzeitzone)
+	Timezone string `json:"timezone"`
+
 	// Pfade
 	DocumentsPath string `json:"documents_path"`
 	DatabasePath  string `json:"database_path"`
@@ -19,9 +24,247 @@ type Config struct {
 	OllamaURL    string `json:"ollama_url"`
 	DefaultModel string `json:"default_model"`
 
+	// Maximal gleichzeitige LLM-Anfragen (Standard 1, mehr nur bei genug VRAM bzw. OLLAMA_NUM_PARALLEL)
+	MaxConcurrentLLM int `json:"max_concurrent_llm"`
+
+	// Modell beim Start vorladen und wie lange Ollama es danach im Speicher hält
+	// (keep_alive: z.B. "30m", "0" = sofort entladen, "-1" = dauerhaft, leer = Ollama-Standard)
+	WarmupOnStart bool   `json:"warmup_on_start"`
+	KeepAlive     string `json:"keep_alive"`
+
+	// Reproduzierbare Fragengenerierung und Bewertung mit festem Seed (z.B. zum Debuggen)
+	DeterministicMode bool `json:"deterministic_mode"`
+	Seed              int  `json:"seed"` // 0 = 42
+
+	// Sprache für Erklärungen und Fragen: "" = Sprache der Dokumente, "de" oder "en" erzwingt eine Sprache
+	Language string `json:"language"`
+
+	// Embedding-Modell für die automatische Themenzuordnung im Chat-Modus "general"
+	// (leer = nomic-embed-text bei Ollama, text-embedding-3-small bei OpenAI-kompatiblen APIs)
+	EmbeddingModel string `json:"embedding_model"`
+
+	// Modell mit Bildverständnis für Fragen zu Abbildungen (z.B. llava, qwen2.5vl; leer = aktuelles Modell)
+	VisionModel string `json:"vision_model"`
+
+	// Zielgröße der Textabschnitte (Tokens), aus denen der Chat-Kontext langer Materialien ausgewählt wird
+	ChunkTokens int `json:"chunk_tokens"`
+
+	// Inhaltsfilter für generierte Erklärungen und Fragen: "" (aus), "rules" oder "llm"
+	ContentFilter string `json:"content_filter"`
+
+	// Failover-Kette aus mehreren Backends (leer = nur ollama_url)
+	Backends []BackendConfig `json:"backends"`
+
 	// Lern-Einstellungen
 	MinStudySessionMinutes int `json:"min_study_session_minutes"`
 	MaxQuestionsPerTopic   int `json:"max_questions_per_topic"`
+
+	// Offene Lernsitzungen nach so vielen Minuten ohne Aktivität automatisch beenden (0 = nie)
+	SessionIdleMinutes int `json:"session_idle_minutes"`
+
+	// Nachts fehlende Fragen erzeugen (Themen unter der Mindestzahl oder ohne alle Schwierigkeitsstufen)
+	QuestionTopUp QuestionTopUpConfig `json:"question_top_up"`
+
+	// Tägliches LLM-Zeitbudget und Ruhezeiten für Hintergrundjobs
+	LLMBudget LLMBudgetConfig `json:"llm_budget"`
+
+	// Hintergrundjobs auf Laptops erst am Netzteil bzw. bei wenig Last starten
+	ResourceGuard ResourceGuardConfig `json:"resource_guard"`
+
+	// Zeitlimit pro Frage in Sekunden je Schwierigkeitsgrad (1-5), leer = kein Limit
+	AnswerTimeLimits map[int]int `json:"answer_time_limits"`
+
+	// Tagesziele und ab welcher Uhrzeit (Stunde) an ein gefährdetes Ziel erinnert wird
+	DailyGoals       DailyGoals `json:"daily_goals"`
+	GoalReminderHour int        `json:"goal_reminder_hour"`
+
+	// Verfügbare Lernzeit pro Tag (Semesterplaner für mehrere Prüfungen)
+	Availability Availability `json:"availability"`
+
+	// E-Mail-Versand (wöchentlicher Fortschrittsbericht)
+	Email EmailConfig `json:"email"`
+
+	// CORS, Sicherheits-Header und Zugangstokens
+	Security SecurityConfig `json:"security"`
+
+	// Kursdateien aus Moodle oder ILIAS in den Dokumentenordner holen
+	Integrations IntegrationsConfig `json:"integrations"`
+
+	// Lernereignisse als xAPI-Statements an einen Learning Record Store senden
+	XAPI XAPIConfig `json:"xapi"`
+
+	// Externe Programme, die an festen Stellen eingreifen (eigene Filter und Ergänzungen)
+	Hooks HooksConfig `json:"hooks"`
+
+	// Sprach-Einstellungen (leer = deaktiviert)
+	WhisperURL     string `json:"whisper_url"`      // whisper.cpp-Server für Speech-to-Text
+	PiperPath      string `json:"piper_path"`       // Piper-Binary für Text-to-Speech
+	PiperModel     string `json:"piper_model"`      // Piper-Stimmmodell (.onnx)
+	FFmpegPath     string `json:"ffmpeg_path"`      // ffmpeg für die MP3-Umwandlung
+	AudioCachePath string `json:"audio_cache_path"` // Ablage für erzeugte Audiodateien
+}
+
+// DailyGoals legt die Tagesziele fest (0 = kein Ziel)
+type DailyGoals struct {
+	Minutes    int `json:"minutes"`    // Lernzeit aus beendeten Sitzungen
+	Questions  int `json:"questions"`  // beantwortete Fragen
+	Flashcards int `json:"flashcards"` // Wiederholungen bereits beantworteter Fragen (Leitner-Karten)
+}
+
+// Availability beschreibt, wie viele Minuten an welchen Tagen zum Lernen zur Verfügung stehen
+type Availability struct {
+	DefaultMinutes int            `json:"default_minutes"`
+	Weekdays       map[string]int `json:"weekdays"` // "monday" ... "sunday", fehlende Tage = default_minutes
+	Dates          map[string]int `json:"dates"`    // Ausnahmen je Datum (YYYY-MM-DD), 0 = keine Zeit
+}
+
+// MinutesOn liefert die verfügbaren Lernminuten an einem Tag
+func (a Availability) MinutesOn(day time.Time) int {
+	if minutes, ok := a.Dates[day.Format("2006-01-02")]; ok {
+		return minutes
+	}
+	if minutes, ok := a.Weekdays[strings.ToLower(day.Weekday().String())]; ok {
+		return minutes
+	}
+	return a.DefaultMinutes
+}
+
+// QuestionTopUpConfig steuert das nächtliche Auffüllen der Fragen aktiver Lernpläne
+// (die LLM-Zeit begrenzt llm_budget)
+type QuestionTopUpConfig struct {
+	Enabled      bool `json:"enabled"`
+	Hour         int  `json:"hour"`          // Startzeit (Stunde, Standard 2 Uhr)
+	MinQuestions int  `json:"min_questions"` // Mindestzahl an Fragen je Thema
+	Difficulties bool `json:"difficulties"`  // auch fehlende Schwierigkeitsstufen 1-5 ergänzen
+}
+
+// LLMBudgetConfig begrenzt die Hintergrund-Generierung: Jobs mit LLM-Anfragen laufen nicht,
+// solange die Ruhezeit gilt oder die Rechenzeit des Tages verbraucht ist.
+// Anfragen aus der Oberfläche zählen zum Verbrauch, werden aber nie blockiert.
+type LLMBudgetConfig struct {
+	DailyMinutes int `json:"daily_minutes"` // LLM-Rechenzeit pro Tag (0 = unbegrenzt)
+	QuietStart   int `json:"quiet_start"`   // Ruhezeit ab Stunde ...
+	QuietEnd     int `json:"quiet_end"`     // ... bis Stunde (exklusiv, darf über Mitternacht gehen; gleich = keine Ruhezeit)
+}
+
+// Quiet meldet, ob zum Zeitpunkt t Ruhezeit ist
+func (b LLMBudgetConfig) Quiet(t time.Time) bool {
+	start, end, hour := b.QuietStart%24, b.QuietEnd%24, t.Hour()
+	if start == end {
+		return false
+	}
+	if start < end {
+		return hour >= start && hour < end
+	}
+	return hour >= start || hour < end
+}
+
+// ResourceGuardConfig prüft vor dem Start von Hintergrundjobs Rechnerlast und Akku (Linux, macOS).
+// Aufgeschobene Jobs werden alle retry_minutes erneut geprüft, bis zum nächsten regulären Termin.
+type ResourceGuardConfig struct {
+	Enabled        bool    `json:"enabled"`
+	MaxLoad        float64 `json:"max_load"`         // höchste 1-Minuten-Last je CPU-Kern (Standard 0.7)
+	AllowOnBattery bool    `json:"allow_on_battery"` // auch im Akkubetrieb starten
+	RetryMinutes   int     `json:"retry_minutes"`    // Abstand der erneuten Prüfung (Standard 15)
+}
+
+// EmailConfig enthält die SMTP-Einstellungen und den Zeitpunkt des Wochenberichts
+type EmailConfig struct {
+	SMTPHost      string   `json:"smtp_host"`
+	SMTPPort      int      `json:"smtp_port"` // 587 = STARTTLS, 465 = TLS
+	Username      string   `json:"username"`
+	Password      string   `json:"password"`
+	From          string   `json:"from"`
+	To            []string `json:"to"`
+	WeeklyReport  bool     `json:"weekly_report"`
+	ReportWeekday int      `json:"report_weekday"` // 0 = Sonntag ... 6 = Samstag
+	ReportHour    int      `json:"report_hour"`
+	GoalReminder  bool     `json:"goal_reminder"` // täglich zur goal_reminder_hour an offene Tagesziele erinnern
+}
+
+// SecurityConfig legt fest, welche fremden Origins die API nutzen dürfen und welche Sicherheits-Header gesendet werden
+type SecurityConfig struct {
+	AllowedOrigins        []string `json:"allowed_origins"`         // leer = nur das eigene Frontend, "*" = alle (nur im vertrauenswürdigen LAN)
+	Headers               bool     `json:"headers"`                 // X-Content-Type-Options, Referrer-Policy, CSP usw. senden
+	ContentSecurityPolicy string   `json:"content_security_policy"` // leer = Standard für das mitgelieferte Frontend
+
+	// Hosts, IP-Adressen oder Netze (CIDR), von denen der URL-Import trotz interner Adresse laden darf
+	ImportAllowedHosts []string `json:"import_allowed_hosts"`
+
+	// Zugangstokens für die API; leer = kein Login (alle Anfragen erlaubt)
+	Tokens []AccessToken `json:"tokens"`
+}
+
+// AccessToken ist ein Zugang zur API mit Rolle: owner darf alles,
+// viewer nur Lernpläne, Fortschritt und Statistiken ansehen (z.B. Eltern, Nachhilfe)
+type AccessToken struct {
+	Name  string `json:"name"`
+	Token string `json:"token"`
+	Role  string `json:"role"` // owner oder viewer
+}
+
+// IntegrationsConfig enthält die Zugänge zu den Lernplattformen der Hochschule (leer = deaktiviert)
+type IntegrationsConfig struct {
+	Moodle MoodleConfig `json:"moodle"`
+	ILIAS  ILIASConfig  `json:"ilias"`
+}
+
+// MoodleConfig ist der Zugang über den Moodle-Webservice (Token unter Profil → Sicherheitsschlüssel)
+type MoodleConfig struct {
+	URL     string `json:"url"`
+	Token   string `json:"token"`
+	Courses []int  `json:"courses"` // Kurs-IDs, leer = alle eingeschriebenen Kurse
+}
+
+// ILIASConfig ist der Zugang über die WebDAV-Schnittstelle von ILIAS
+type ILIASConfig struct {
+	URL      string        `json:"url"`
+	Client   string        `json:"client"` // ILIAS-Mandant (client_id)
+	Username string        `json:"username"`
+	Password string        `json:"password"`
+	Courses  []ILIASCourse `json:"courses"`
+}
+
+// ILIASCourse ist ein Kurs, identifiziert über die ref_id aus der Kurs-URL
+type ILIASCourse struct {
+	RefID int    `json:"ref_id"`
+	Name  string `json:"name"`
+}
+
+// XAPIConfig ist der Zugang zu einem Learning Record Store (LRS), leerer Endpoint = deaktiviert
+type XAPIConfig struct {
+	Endpoint     string `json:"endpoint"` // z.B. https://lrs.schule.de/xapi (ohne /statements)
+	Username     string `json:"username"` // Basic-Auth (Key/Secret des LRS)
+	Password     string `json:"password"`
+	ActorName    string `json:"actor_name"`
+	ActorEmail   string `json:"actor_email"`   // identifiziert den Lernenden im LRS
+	ActivityBase string `json:"activity_base"` // Präfix der Aktivitäts-IDs, leer = http://lernplattform.local
+}
+
+// HooksConfig listet die Hook-Programme je Aufrufstelle; mehrere Programme laufen nacheinander,
+// jedes bekommt die Ausgabe des vorigen
+type HooksConfig struct {
+	AfterIngest    []HookCommand `json:"after_ingest"`    // nach dem Einlesen eines Dokuments, vor dem Speichern
+	BeforePrompt   []HookCommand `json:"before_prompt"`   // vor jeder Anfrage des Tutors an das LLM
+	AfterQuestions []HookCommand `json:"after_questions"` // nach der Fragengenerierung, vor dem Speichern
+}
+
+// HookCommand ist ein Hook-Programm: JSON auf stdin, geändertes JSON (oder nichts) auf stdout
+type HookCommand struct {
+	Command        string   `json:"command"`
+	Args           []string `json:"args"`
+	TimeoutSeconds int      `json:"timeout_seconds"` // 0 = 30 Sekunden
+}
+
+// BackendConfig beschreibt ein LLM-Backend der Failover-Kette
+type BackendConfig struct {
+	Name          string            `json:"name"`
+	Type          string            `json:"type"` // "ollama", "openai" (OpenAI-kompatibel) oder "mock" (url = Skript)
+	URL           string            `json:"url"`
+	APIKey        string            `json:"api_key"`
+	Model         string            `json:"model"`     // Modell, falls model_map keinen Eintrag hat
+	ModelMap      map[string]string `json:"model_map"` // eingestelltes Modell -> Modell dieses Backends
+	MaxConcurrent int               `json:"max_concurrent"`
 }
 
 // Default gibt die Standardkonfiguration zurück
@@ -33,8 +276,20 @@ func Default() *Config {
 		DatabasePath:           "lernplattform.db",
 		OllamaURL:              "http://localhost:11434",
 		DefaultModel:           "qwen2.5:7b",
+		MaxConcurrentLLM:       1,
+		ChunkTokens:            500,
 		MinStudySessionMinutes: 30,
 		MaxQuestionsPerTopic:   10,
+		SessionIdleMinutes:     60,
+		QuestionTopUp:          QuestionTopUpConfig{Hour: 2, MinQuestions: 5, Difficulties: true},
+		ResourceGuard:          ResourceGuardConfig{MaxLoad: 0.7, RetryMinutes: 15},
+		DailyGoals:             DailyGoals{Minutes: 30, Questions: 10},
+		GoalReminderHour:       19,
+		Availability:           Availability{DefaultMinutes: 120},
+		Email:                  EmailConfig{SMTPPort: 587, ReportWeekday: 0, ReportHour: 18},
+		Security:               SecurityConfig{Headers: true},
+		FFmpegPath:             "ffmpeg",
+		AudioCachePath:         "audio_cache",
 	}
 }
 
@@ -62,3 +317,19 @@ func (c *Config) Save(path string) error {
 	}
 	return os.WriteFile(path, data, 0644)
 }
+
+// EffectiveSeed liefert den Seed des deterministischen Modus (0 = 42), für Tutor und Optionsreihenfolge
+func (c *Config) EffectiveSeed() int {
+	if c.Seed == 0 {
+		return 42
+	}
+	return c.Seed
+}
+
+// ReminderHour liefert die Stunde, ab der an offene Tagesziele erinnert wird (ungültig = 19 Uhr)
+func (c *Config) ReminderHour() int {
+	if c.GoalReminderHour <= 0 || c.GoalReminderHour > 23 {
+		return 19
+	}
+	return c.GoalReminderHour
+}
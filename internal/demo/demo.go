@@ -0,0 +1,308 @@
+package demo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"lernplattform/internal/models"
+	"lernplattform/internal/storage"
+)
+
+// PlanID ist die feste ID des Beispiel-Lernplans; daran erkennt Seed, ob er schon existiert
+const PlanID = "demo_plan"
+
+// demoTopic ist ein Thema des Beispielkurses samt Seiten im Skript, Fragen und Lernzielen
+type demoTopic struct {
+	name        string
+	description string
+	page        int
+	difficulty  int
+	status      string
+	text        string
+	objectives  []string
+	questions   []demoQuestion
+	explanation *models.Explanation
+}
+
+type demoQuestion struct {
+	question string
+	expected string
+	options  []string // leer = offene Frage
+	level    string
+	answers  []bool // Verlauf der Antwortversuche (true = richtig), ältester zuerst
+}
+
+var topics = []demoTopic{
+	{
+		name:        "Grundbegriffe der Regelung",
+		description: "Regelkreis, Stellgröße, Regelgröße und Störgröße; Unterschied zwischen Steuerung und Regelung",
+		page:        1, difficulty: 1, status: "completed",
+		text: `Eine Regelung misst die Regelgröße fortlaufend und vergleicht sie mit der Führungsgröße (Sollwert).
+Die Differenz heißt Regelabweichung e = w - y. Der Regler bildet daraus die Stellgröße u,
+die über die Regelstrecke auf die Regelgröße wirkt. Störgrößen z wirken von außen auf die Strecke.
+Im Gegensatz zur Steuerung ist der Wirkungsablauf bei der Regelung geschlossen (Rückführung).`,
+		objectives: []string{"Kann die Signale eines Standard-Regelkreises benennen", "Kann Steuerung und Regelung unterscheiden"},
+		questions: []demoQuestion{
+			{question: "Wie ist die Regelabweichung definiert?", expected: "e = w - y", options: []string{"e = w - y", "e = y - u", "e = u - z", "e = w + y"}, level: "remember", answers: []bool{true, true, true}},
+			{question: "Worin unterscheidet sich eine Regelung von einer Steuerung?", expected: "Die Regelung hat eine Rückführung der Regelgröße (geschlossener Wirkungsablauf), die Steuerung nicht.", level: "understand", answers: []bool{false, true}},
+			{question: "Welche Größe wirkt von außen ungewollt auf die Regelstrecke?", expected: "Die Störgröße", options: []string{"Die Führungsgröße", "Die Störgröße", "Die Stellgröße", "Die Regelabweichung"}, level: "remember", answers: []bool{true}},
+		},
+		explanation: &models.Explanation{
+			Title:     "Der Regelkreis in fünf Signalen",
+			Content:   "Stell dir eine Heizung vor: Der **Sollwert** (Führungsgröße w) ist die gewünschte Raumtemperatur, das Thermometer misst die **Regelgröße** y. Aus der **Regelabweichung** e = w - y berechnet der Regler die **Stellgröße** u, also wie weit das Ventil öffnet. Ein offenes Fenster ist eine **Störgröße** z.\n\nWeil die gemessene Temperatur zurückgeführt wird, ist der Kreis geschlossen – das unterscheidet die Regelung von der Steuerung.",
+			KeyPoints: []string{"Regelabweichung e = w - y", "Die Rückführung schließt den Kreis", "Störgrößen wirken von außen auf die Strecke"},
+		},
+	},
+	{
+		name:        "Laplace-Transformation",
+		description: "Definition, Korrespondenzen und Rechenregeln; Lösen linearer Differentialgleichungen im Bildbereich",
+		page:        2, difficulty: 3, status: "in_progress",
+		text: `Die Laplace-Transformation F(s) = ∫ f(t) e^(-st) dt bildet Zeitfunktionen in den Bildbereich ab.
+Ableitungen werden dort zu Multiplikationen mit s: L{f'(t)} = s F(s) - f(0).
+Wichtige Korrespondenzen: Sprung 1/s, Rampe 1/s², Exponentialfunktion e^(-at) → 1/(s+a).
+Der Endwertsatz liefert lim f(t) für t→∞ als lim s F(s) für s→0, sofern der Grenzwert existiert.`,
+		objectives: []string{"Kann Standard-Korrespondenzen anwenden", "Kann den Endwertsatz anwenden"},
+		questions: []demoQuestion{
+			{question: "Was ist die Laplace-Transformierte des Einheitssprungs?", expected: "1/s", options: []string{"1", "1/s", "1/s²", "s"}, level: "remember", answers: []bool{true, true}},
+			{question: "Wie lautet der Differentiationssatz der Laplace-Transformation?", expected: "L{f'(t)} = s F(s) - f(0)", level: "remember", answers: []bool{false, false, true}},
+			{question: "Berechne mit dem Endwertsatz den Endwert von F(s) = 5 / (s (s + 2)).", expected: "lim s F(s) für s→0 = 5/2 = 2,5", level: "apply", answers: []bool{false}},
+			{question: "Welche Zeitfunktion gehört zu 1/(s+3)?", expected: "e^(-3t)", options: []string{"e^(3t)", "e^(-3t)", "3t", "sin(3t)"}, level: "understand"},
+		},
+		explanation: &models.Explanation{
+			Title:     "Warum die Laplace-Transformation das Rechnen vereinfacht",
+			Content:   "Im Zeitbereich muss man Differentialgleichungen lösen. Die Laplace-Transformation macht aus jeder **Ableitung** eine **Multiplikation mit s** – aus der Differentialgleichung wird eine algebraische Gleichung.\n\nBeispiel: y' + 2y = u mit y(0) = 0 wird zu s Y(s) + 2 Y(s) = U(s), also Y(s) = U(s) / (s + 2).",
+			KeyPoints: []string{"Ableitung → Multiplikation mit s", "Korrespondenztabellen statt Integrale", "Endwertsatz: lim s F(s) für s→0"},
+		},
+	},
+	{
+		name:        "Übertragungsfunktion",
+		description: "Übertragungsfunktion G(s), Pole und Nullstellen, Reihen-, Parallel- und Rückkopplungsschaltung",
+		page:        3, difficulty: 3, status: "pending",
+		text: `Die Übertragungsfunktion G(s) = Y(s) / U(s) beschreibt ein lineares System bei Anfangswerten null.
+Reihenschaltung: G = G1 · G2. Parallelschaltung: G = G1 + G2.
+Rückkopplung (Gegenkopplung): G = G1 / (1 + G1 G2).
+Die Nullstellen des Nennerpolynoms heißen Pole, die des Zählerpolynoms Nullstellen.`,
+		objectives: []string{"Kann Blockschaltbilder zusammenfassen", "Kann Pole und Nullstellen bestimmen"},
+		questions: []demoQuestion{
+			{question: "Wie lautet die Übertragungsfunktion einer Gegenkopplung mit G1 im Vorwärts- und G2 im Rückwärtszweig?", expected: "G = G1 / (1 + G1 G2)", level: "remember"},
+			{question: "Was ergibt die Reihenschaltung zweier Blöcke G1 und G2?", expected: "G1 · G2", options: []string{"G1 + G2", "G1 · G2", "G1 / G2", "G1 - G2"}, level: "remember"},
+		},
+	},
+	{
+		name:        "Stabilität",
+		description: "BIBO-Stabilität, Lage der Pole, Hurwitz-Kriterium",
+		page:        4, difficulty: 4, status: "pending",
+		text: `Ein lineares System ist BIBO-stabil, wenn alle Pole der Übertragungsfunktion einen negativen Realteil haben.
+Pole auf der imaginären Achse führen zu Dauerschwingungen (grenzstabil).
+Das Hurwitz-Kriterium prüft die Stabilität anhand der Koeffizienten des Nennerpolynoms, ohne die Pole zu berechnen.`,
+		objectives: []string{"Kann Stabilität an der Pollage ablesen", "Kann das Hurwitz-Kriterium anwenden"},
+		questions: []demoQuestion{
+			{question: "Wann ist ein lineares zeitinvariantes System BIBO-stabil?", expected: "Wenn alle Pole der Übertragungsfunktion einen negativen Realteil haben (linke s-Halbebene).", level: "understand"},
+			{question: "Ein System hat die Pole -1 und +2. Ist es stabil?", expected: "Nein", options: []string{"Ja", "Nein", "Grenzstabil", "Nicht entscheidbar"}, level: "apply"},
+		},
+	},
+	{
+		name:        "PID-Regler",
+		description: "P-, I- und D-Anteil, bleibende Regelabweichung, Einstellregeln nach Ziegler-Nichols",
+		page:        5, difficulty: 4, status: "pending",
+		text: `Der PID-Regler kombiniert drei Anteile: u(t) = Kp e(t) + Ki ∫ e dt + Kd de/dt.
+Der P-Anteil reagiert sofort, hinterlässt aber bei Strecken ohne I-Verhalten eine bleibende Regelabweichung.
+Der I-Anteil beseitigt die bleibende Regelabweichung, macht den Kreis aber langsamer und schwingungsanfälliger.
+Der D-Anteil reagiert auf Änderungen der Regelabweichung und dämpft Schwingungen.
+Ziegler-Nichols: Kp bis zur Stabilitätsgrenze erhöhen (Kkrit, Tkrit) und daraus die Parameter ableiten.`,
+		objectives: []string{"Kann die Wirkung von P-, I- und D-Anteil erklären", "Kann einen PID-Regler nach Ziegler-Nichols einstellen"},
+		questions: []demoQuestion{
+			{question: "Welcher Anteil des PID-Reglers beseitigt die bleibende Regelabweichung?", expected: "Der I-Anteil", options: []string{"Der P-Anteil", "Der I-Anteil", "Der D-Anteil", "Keiner"}, level: "remember"},
+			{question: "Warum verwendet man den D-Anteil selten allein?", expected: "Er reagiert nur auf Änderungen der Regelabweichung; bei konstanter Abweichung liefert er keine Stellgröße (und verstärkt Messrauschen).", level: "analyze"},
+		},
+	},
+}
+
+var glossary = []models.GlossaryItem{
+	{Term: "Regelabweichung", Category: "definition", Definition: "Differenz zwischen Führungsgröße und Regelgröße: e = w - y."},
+	{Term: "Übertragungsfunktion", Category: "formula", Definition: "G(s) = Y(s) / U(s), Verhältnis der Laplace-Transformierten von Ausgang und Eingang bei Anfangswerten null.", Related: []string{"Laplace-Transformation", "Pol"}},
+	{Term: "Pol", Category: "concept", Definition: "Nullstelle des Nennerpolynoms der Übertragungsfunktion; bestimmt das Eigenverhalten des Systems.", Related: []string{"Stabilität"}},
+	{Term: "PID", Category: "abbreviation", Definition: "Proportional-Integral-Differential-Regler."},
+}
+
+// Seed legt den Beispielkurs an: ein Skript, einen aktiven Lernplan mit fünf Themen, Fragen
+// mit Antwortverlauf, Lernsitzungen der letzten Tage, Erklärungen und Glossar-Einträge.
+// Ist der Kurs schon vorhanden, passiert nichts (created = false).
+func Seed(store storage.Storage, now time.Time) (created bool, err error) {
+	if _, err := store.GetStudyPlan(PlanID); err == nil {
+		return false, nil
+	}
+
+	start := now.AddDate(0, 0, -10)
+	day := func(offset int, hour int) time.Time {
+		d := start.AddDate(0, 0, offset)
+		return time.Date(d.Year(), d.Month(), d.Day(), hour, 0, 0, 0, d.Location())
+	}
+
+	// Skript mit Seitenmarkierungen wie aus einer PDF gelesen
+	var content strings.Builder
+	for _, t := range topics {
+		content.WriteString(fmt.Sprintf("\n--- Seite %d ---\n%d %s\n%s\n", t.page, t.page, t.name, t.text))
+	}
+	doc := &models.Document{
+		ID:          "demo_doc",
+		Name:        "Regelungstechnik_Skript_Demo.pdf",
+		Path:        "Regelungstechnik_Skript_Demo.pdf",
+		Content:     content.String(),
+		PageCount:   len(topics),
+		UploadedAt:  start,
+		ProcessedAt: start,
+		HasText:     true,
+		Language:    "de",
+		Tags:        []string{"Demo"},
+	}
+	doc.ContentLength = len(doc.Content)
+	doc.WordCount = len(strings.Fields(doc.Content))
+	if err := store.SaveDocument(doc); err != nil {
+		return false, fmt.Errorf("dokument: %w", err)
+	}
+
+	plan := &models.StudyPlan{
+		ID:        PlanID,
+		Name:      "Regelungstechnik (Demo)",
+		ExamDate:  models.DateOf(day(31, 9)),
+		CreatedAt: start,
+		Documents: []string{doc.ID},
+		Status:    "active",
+	}
+	for _, t := range topics {
+		plan.TotalMinutes += 30 + 15*t.difficulty
+	}
+	if err := store.SaveStudyPlan(plan); err != nil {
+		return false, fmt.Errorf("lernplan: %w", err)
+	}
+
+	session := 0
+	for i, t := range topics {
+		topicID := fmt.Sprintf("demo_topic_%d", i+1)
+		topic := &models.Topic{
+			ID:          topicID,
+			StudyPlanID: plan.ID,
+			Name:        t.name,
+			Description: t.description,
+			Order:       i + 1,
+			Difficulty:  t.difficulty,
+			EstMinutes:  30 + 15*t.difficulty,
+			Status:      t.status,
+		}
+		if t.status == "completed" {
+			topic.Progress = 100
+		}
+		if err := store.SaveTopic(topic); err != nil {
+			return false, fmt.Errorf("thema %s: %w", t.name, err)
+		}
+		store.SaveTopicSources(topicID, []models.TopicSource{{DocumentID: doc.ID, DocumentName: doc.Name, PageStart: t.page, PageEnd: t.page}})
+
+		for j, text := range t.objectives {
+			obj := &models.LearningObjective{ID: fmt.Sprintf("%s_obj_%d", topicID, j+1), TopicID: topicID, Text: text, Order: j + 1}
+			if t.status == "completed" {
+				achieved := day(i+2, 18)
+				obj.Achieved, obj.AchievedAt = true, &achieved
+			}
+			if err := store.SaveObjective(obj); err != nil {
+				return false, fmt.Errorf("lernziel: %w", err)
+			}
+		}
+
+		if t.explanation != nil {
+			exp := *t.explanation
+			exp.ID = topicID + "_exp"
+			exp.TopicID = topicID
+			exp.CreatedAt = day(i*2, 17)
+			if err := store.SaveExplanation(&exp); err != nil {
+				return false, fmt.Errorf("erklärung: %w", err)
+			}
+		}
+
+		// Fragen mit Antwortverlauf: ein Versuch je Tag ab dem Lerntag des Themas
+		answered, correct := 0, 0
+		for j, dq := range t.questions {
+			q := &models.Question{
+				ID:               fmt.Sprintf("%s_q_%d", topicID, j+1),
+				TopicID:          topicID,
+				Question:         dq.question,
+				ExpectedAnswer:   dq.expected,
+				Difficulty:       t.difficulty,
+				Type:             "open",
+				CognitiveLevel:   dq.level,
+				Options:          dq.options,
+				SourceDocumentID: doc.ID,
+				SourcePage:       t.page,
+			}
+			if len(dq.options) > 0 {
+				q.Type = "multiple_choice"
+			}
+			for k, ok := range dq.answers {
+				at := day(i*2+k*2, 16+k%3)
+				answer := dq.expected
+				feedback := "✅ Richtig!"
+				errorType := ""
+				if !ok {
+					answer, feedback, errorType = "Weiß ich nicht genau", "💡 Die richtige Antwort ist: "+dq.expected, models.ErrorMissingTerm
+				}
+				seconds := 20 + 10*k
+				attempt := &models.QuestionAttempt{
+					ID:            fmt.Sprintf("%s_att_%d", q.ID, k+1),
+					QuestionID:    q.ID,
+					TopicID:       topicID,
+					Answer:        answer,
+					IsCorrect:     ok,
+					Feedback:      feedback,
+					AnswerSeconds: &seconds,
+					ErrorType:     errorType,
+					CreatedAt:     at,
+				}
+				if ok {
+					attempt.Score = 100
+				}
+				if err := store.SaveAttempt(attempt); err != nil {
+					return false, fmt.Errorf("antwortversuch: %w", err)
+				}
+				isCorrect := ok
+				q.UserAnswer, q.IsCorrect, q.Feedback, q.AnsweredAt = answer, &isCorrect, feedback, &at
+				q.AnswerSeconds = attempt.AnswerSeconds
+				answered++
+				if ok {
+					correct++
+				}
+			}
+			if err := store.SaveQuestion(q); err != nil {
+				return false, fmt.Errorf("frage: %w", err)
+			}
+		}
+
+		// Eine Lernsitzung je Thema, das schon begonnen wurde
+		if t.status != "pending" {
+			session++
+			started := day(i*2, 16)
+			ended := started.Add(time.Duration(25+10*i) * time.Minute)
+			if err := store.SaveSession(&models.StudySession{
+				ID:                fmt.Sprintf("demo_session_%d", session),
+				StudyPlanID:       plan.ID,
+				TopicID:           topicID,
+				StartedAt:         started,
+				EndedAt:           &ended,
+				Duration:          int(ended.Sub(started).Minutes()),
+				QuestionsAnswered: answered,
+				CorrectAnswers:    correct,
+			}); err != nil {
+				return false, fmt.Errorf("lernsitzung: %w", err)
+			}
+		}
+	}
+
+	for i, item := range glossary {
+		item.ID = fmt.Sprintf("demo_glossary_%d", i+1)
+		item.CreatedAt, item.UpdatedAt = start, start
+		if err := store.SaveGlossaryItem(&item); err != nil {
+			return false, fmt.Errorf("glossar: %w", err)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,190 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"time"
+
+	"lernplattform/internal/config"
+	"lernplattform/internal/models"
+)
+
+// Aufrufstellen, auch als Feld "hook" im JSON und in LERNPLATTFORM_HOOK
+const (
+	AfterIngest    = "after_ingest"
+	BeforePrompt   = "before_prompt"
+	AfterQuestions = "after_questions"
+)
+
+// Standard-Zeitlimit je Hook-Programm
+const defaultTimeout = 30 * time.Second
+
+// Runner ruft die konfigurierten Hook-Programme auf. Ein Programm bekommt die Daten als JSON auf
+// stdin und gibt sie vollständig (verändert) als JSON auf stdout zurück; leere Ausgabe heißt unverändert.
+// Schlägt ein Hook fehl, wird er übersprungen: ein kaputtes Skript soll das Lernen nicht aufhalten.
+type Runner struct {
+	cfg config.HooksConfig
+}
+
+// New erstellt einen Runner für die Hooks aus der Konfiguration
+func New(cfg config.HooksConfig) *Runner {
+	return &Runner{cfg: cfg}
+}
+
+// Enabled gibt an, ob für die Aufrufstelle Hooks konfiguriert sind
+func (r *Runner) Enabled(hook string) bool {
+	return len(r.commands(hook)) > 0
+}
+
+func (r *Runner) commands(hook string) []config.HookCommand {
+	if r == nil {
+		return nil
+	}
+	switch hook {
+	case AfterIngest:
+		return r.cfg.AfterIngest
+	case BeforePrompt:
+		return r.cfg.BeforePrompt
+	case AfterQuestions:
+		return r.cfg.AfterQuestions
+	}
+	return nil
+}
+
+// ingestPayload ist die Ein- und Ausgabe von after_ingest; der Inhalt steht in document.content
+type ingestPayload struct {
+	Hook     string          `json:"hook"`
+	Document models.Document `json:"document"`
+}
+
+// AfterIngest lässt die Hooks ein eingelesenes Dokument vor dem Speichern ändern (Inhalt bereinigen,
+// Schlagworte ergänzen usw.). Übernommen werden Inhalt, Sprache, Schlagworte, Titel, Autor und Semester.
+func (r *Runner) AfterIngest(ctx context.Context, doc *models.Document) {
+	if !r.Enabled(AfterIngest) {
+		return
+	}
+	payload := ingestPayload{Hook: AfterIngest, Document: *doc}
+	if !r.run(ctx, AfterIngest, &payload) || payload.Document.ID == "" {
+		return
+	}
+	changed := payload.Document
+	doc.Content = changed.Content
+	doc.Language = changed.Language
+	doc.Tags = changed.Tags
+	doc.Title = changed.Title
+	doc.Author = changed.Author
+	doc.Semester = changed.Semester
+}
+
+// promptPayload ist die Ein- und Ausgabe von before_prompt
+type promptPayload struct {
+	Hook   string `json:"hook"`
+	Prompt string `json:"prompt"`
+	System string `json:"system"`
+}
+
+// BeforePrompt lässt die Hooks Prompt und Systemanweisung einer LLM-Anfrage ändern
+func (r *Runner) BeforePrompt(ctx context.Context, prompt, system string) (string, string) {
+	if !r.Enabled(BeforePrompt) {
+		return prompt, system
+	}
+	payload := promptPayload{Hook: BeforePrompt, Prompt: prompt, System: system}
+	if !r.run(ctx, BeforePrompt, &payload) || payload.Prompt == "" {
+		return prompt, system
+	}
+	return payload.Prompt, payload.System
+}
+
+// questionsPayload ist die Ein- und Ausgabe von after_questions
+type questionsPayload struct {
+	Hook      string            `json:"hook"`
+	Topic     models.Topic      `json:"topic"`
+	Questions []models.Question `json:"questions"`
+}
+
+// AfterQuestions lässt die Hooks generierte Fragen filtern oder ändern, bevor sie gespeichert werden.
+// Eine leere Liste verwirft alle Fragen; fehlende IDs und Themenzuordnungen werden ergänzt.
+func (r *Runner) AfterQuestions(ctx context.Context, topic *models.Topic, questions []models.Question) []models.Question {
+	if !r.Enabled(AfterQuestions) {
+		return questions
+	}
+	payload := questionsPayload{Hook: AfterQuestions, Topic: *topic, Questions: questions}
+	if !r.run(ctx, AfterQuestions, &payload) {
+		return questions
+	}
+	now := time.Now()
+	for i := range payload.Questions {
+		q := &payload.Questions[i]
+		if q.ID == "" {
+			q.ID = fmt.Sprintf("q_%d_%d", now.UnixNano(), i)
+		}
+		if q.TopicID == "" {
+			q.TopicID = topic.ID
+		}
+	}
+	return payload.Questions
+}
+
+// run führt die Hooks einer Aufrufstelle nacheinander aus; payload wird jeweils durch die Ausgabe
+// ersetzt. false, wenn kein Hook etwas geändert hat.
+func (r *Runner) run(ctx context.Context, hook string, payload interface{}) bool {
+	changed := false
+	for _, command := range r.commands(hook) {
+		input, err := json.Marshal(payload)
+		if err != nil {
+			log.Printf("⚠️ Hook %s: %v", hook, err)
+			return changed
+		}
+		output, err := execute(ctx, hook, command, input)
+		if err != nil {
+			log.Printf("⚠️ Hook %s (%s) übersprungen: %v", hook, command.Command, err)
+			continue
+		}
+		if len(bytes.TrimSpace(output)) == 0 {
+			continue
+		}
+		// Die Ausgabe ersetzt die Daten vollständig (sonst blieben Felder weggelassener Fragen stehen)
+		value := reflect.ValueOf(payload).Elem()
+		value.Set(reflect.Zero(value.Type()))
+		if err := json.Unmarshal(output, payload); err != nil {
+			log.Printf("⚠️ Hook %s (%s) übersprungen: ungültige Ausgabe: %v", hook, command.Command, err)
+			value.Set(reflect.Zero(value.Type()))
+			json.Unmarshal(input, payload)
+			continue
+		}
+		changed = true
+	}
+	return changed
+}
+
+// execute startet ein Hook-Programm und liefert seine Ausgabe
+func execute(ctx context.Context, hook string, command config.HookCommand, input []byte) ([]byte, error) {
+	if command.Command == "" {
+		return nil, fmt.Errorf("command fehlt")
+	}
+	timeout := defaultTimeout
+	if command.TimeoutSeconds > 0 {
+		timeout = time.Duration(command.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command.Command, command.Args...)
+	cmd.Env = append(os.Environ(), "LERNPLATTFORM_HOOK="+hook)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
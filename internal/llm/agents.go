@@ -57,6 +57,27 @@ func NewAgentPool(provider Provider, config ParallelAgentConfig) *AgentPool {
 	}
 }
 
+// AnalysisProgress nimmt die Themen fertig analysierter Dokumente auf, damit eine
+// unterbrochene Analyse (Neustart, Standby) nicht von vorn beginnt
+type AnalysisProgress interface {
+	// DocumentTopics liefert die Themen eines bereits analysierten Dokuments
+	DocumentTopics(docID string) ([]models.Topic, bool)
+	// SaveDocumentTopics hält die Themen eines gerade analysierten Dokuments fest
+	SaveDocumentTopics(docID string, topics []models.Topic)
+}
+
+type progressKey struct{}
+
+// WithAnalysisProgress hängt einen Zwischenstand an den Context für die Dokumentanalyse
+func WithAnalysisProgress(ctx context.Context, p AnalysisProgress) context.Context {
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+func analysisProgressFrom(ctx context.Context) AnalysisProgress {
+	p, _ := ctx.Value(progressKey{}).(AnalysisProgress)
+	return p
+}
+
 // AnalyzeDocumentsParallel analysiert Dokumente sequentiell (Ollama-Limit)
 func (ap *AgentPool) AnalyzeDocumentsParallel(ctx context.Context, documents []models.Document) ([]models.Topic, error) {
 	startTime := time.Now()
@@ -108,6 +129,8 @@ func (ap *AgentPool) analyzeDocumentsSequentially(ctx context.Context, docs []mo
 
 	var allTopics []models.Topic
 	successCount := 0
+	progress := analysisProgressFrom(ctx)
+	model := ap.analysisModel()
 	
 	for i, doc := range docs {
 		docName := doc.Name
@@ -115,7 +138,18 @@ func (ap *AgentPool) analyzeDocumentsSequentially(ctx context.Context, docs []mo
 			docName = docName[:32] + "..."
 		}
 		
+		if progress != nil {
+			if topics, ok := progress.DocumentTopics(doc.ID); ok {
+				log.Printf("   [%d/%d] ↩️ Bereits analysiert: %s (%d Themen)", i+1, len(docs), docName, len(topics))
+				successCount++
+				allTopics = append(allTopics, topics...)
+				continue
+			}
+		}
+
 		log.Printf("   [%d/%d] 🔍 Analysiere: %s", i+1, len(docs), docName)
+		eta, _ := ap.estimateDocuments(docs[i:])
+		reportProgress(ctx, Progress{Step: "analyze_document", Detail: doc.Name, Model: model, Done: i, Total: len(docs), ETASeconds: int(eta.Seconds())})
 		startTime := time.Now()
 		
 		topics, err := ap.analyzeOneDocument(ctx, doc)
@@ -128,13 +162,47 @@ func (ap *AgentPool) analyzeDocumentsSequentially(ctx context.Context, docs []mo
 		
 		successCount++
 		allTopics = append(allTopics, topics...)
+		durations.observe("analyze_document", model, ap.analysisSize(doc), duration)
+		if progress != nil {
+			progress.SaveDocumentTopics(doc.ID, topics)
+		}
 		log.Printf("   [%d/%d] ✓ Fertig in %v (%d Themen)", i+1, len(docs), duration, len(topics))
 	}
 	
+	reportProgress(ctx, Progress{Step: "analyze_document", Model: model, Done: len(docs), Total: len(docs)})
 	log.Printf("   ✓ %d/%d Dokumente erfolgreich analysiert", successCount, len(docs))
 	return allTopics
 }
 
+// analysisModel ist das Modell, mit dem einzelne Dokumente analysiert werden
+func (ap *AgentPool) analysisModel() string {
+	if ap.config.FastModel != "" {
+		return ap.config.FastModel
+	}
+	return ap.provider.GetCurrentModel()
+}
+
+// analysisSize ist die Zahl der Zeichen, die von einem Dokument in die Analyse eingehen
+func (ap *AgentPool) analysisSize(doc models.Document) int {
+	maxChars := min(4000, contentLimit(ap.provider, 4000)) // Kurz für schnelle Verarbeitung
+	return min(len(doc.Content), maxChars)
+}
+
+// estimateDocuments schätzt die Analysedauer für die Dokumente aus früheren Läufen;
+// false, solange für das Modell noch keine Messungen vorliegen
+func (ap *AgentPool) estimateDocuments(docs []models.Document) (time.Duration, bool) {
+	var total time.Duration
+	model := ap.analysisModel()
+	for _, doc := range docs {
+		d, ok := durations.predict("analyze_document", model, ap.analysisSize(doc))
+		if !ok {
+			return 0, false
+		}
+		total += d
+	}
+	return total, true
+}
+
 // analyzeDocumentsInParallel führt parallele Dokumentenanalyse durch (Legacy)
 func (ap *AgentPool) analyzeDocumentsInParallel(ctx context.Context, docs []models.Document) []models.Topic {
 	// Verwende jetzt sequentielle Verarbeitung
@@ -183,22 +251,20 @@ func (ap *AgentPool) documentWorker(ctx context.Context, workerID int, tasks <-c
 // analyzeOneDocument analysiert ein einzelnes Dokument
 func (ap *AgentPool) analyzeOneDocument(ctx context.Context, doc models.Document) ([]models.Topic, error) {
 	// Kürze Inhalt für schnelle Analyse
-	content := doc.Content
-	maxChars := 4000 // Kurz für schnelle Verarbeitung
-	if len(content) > maxChars {
-		content = content[:maxChars]
-	}
+	content := doc.Content[:ap.analysisSize(doc)]
 
 	prompt := fmt.Sprintf(`Analysiere dieses Dokument und liste die 3-5 wichtigsten Lernthemen auf.
 
 Dokument: %s
----
 %s
----
+
+Gib zu jedem Thema 3-6 konkrete, überprüfbare Lernziele an (z.B. "Kann X berechnen", "Kann den Unterschied zwischen Y und Z erklären").
 
 Antworte NUR im JSON-Format:
-{"topics": [{"name": "Thema", "description": "Kurzbeschreibung", "difficulty": 1-5, "est_minutes": 30}]}`, 
-		doc.Name, content)
+{"topics": [{"name": "Thema", "description": "Kurzbeschreibung", "difficulty": 1-5, "est_minutes": 30, "objectives": ["Kann ...", "Kann ..."], "pages": [von, bis]}]}
+
+"pages" ist der Seitenbereich laut "--- Seite N ---"-Markierungen (weglassen, wenn nicht erkennbar).`,
+		doc.Name, guardMaterial(content))
 
 	// Verwende schnelles Modell
 	oldModel := ap.provider.GetCurrentModel()
@@ -210,12 +276,25 @@ Antworte NUR im JSON-Format:
 	resp, err := ap.provider.Generate(ctx, prompt, &GenerateOptions{
 		Temperature: 0.3,
 		System:      "Du bist ein Lernassistent. Antworte kurz und nur im JSON-Format.",
+		JSON:        jsonMode(ap.provider),
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return parseTopicsFromResponse(resp.Content)
+	topics, err := parseTopicsFromResponse(resp.Content)
+	if err != nil {
+		return nil, err
+	}
+	// Das Dokument ist bekannt, nur die Seiten kommen vom LLM
+	for i := range topics {
+		src := models.TopicSource{DocumentID: doc.ID, DocumentName: doc.Name}
+		if len(topics[i].Sources) > 0 {
+			src.PageStart, src.PageEnd = topics[i].Sources[0].PageStart, topics[i].Sources[0].PageEnd
+		}
+		topics[i].Sources = []models.TopicSource{src}
+	}
+	return topics, nil
 }
 
 // prioritizeWithExams gewichtet Themen basierend auf Klausuren
@@ -254,7 +333,7 @@ Klausurinhalt:
 
 Antworte NUR mit der sortierten Liste als JSON:
 {"priority": ["Wichtigstes Thema", "Zweitwichtigstes", ...]}`,
-		strings.Join(topicNames, ", "), examContent.String())
+		strings.Join(topicNames, ", "), guardMaterial(examContent.String()))
 
 	// Schnelle Anfrage
 	taskCtx, cancel := context.WithTimeout(ctx, 1*time.Minute)
@@ -266,10 +345,13 @@ Antworte NUR mit der sortierten Liste als JSON:
 		defer ap.provider.SetModel(oldModel)
 	}
 
+	done := trackStep(ctx, "exam_topics", "", ap.provider.GetCurrentModel(), len(prompt))
 	resp, err := ap.provider.Generate(taskCtx, prompt, &GenerateOptions{
 		Temperature: 0.2,
 		System:      "Du bist ein Prüfungsexperte. Antworte nur im JSON-Format.",
+		JSON:        jsonMode(ap.provider),
 	})
+	done(err)
 	if err != nil {
 		log.Printf("   ⚠️ Priorisierung übersprungen: %v", err)
 		return topics
@@ -351,14 +433,17 @@ func categorizeDocuments(docs []models.Document) (main, exams []models.Document)
 }
 
 func deduplicateTopics(topics []models.Topic) []models.Topic {
-	seen := make(map[string]bool)
+	seen := make(map[string]int)
 	var result []models.Topic
 	for _, t := range topics {
 		key := strings.ToLower(t.Name)
-		if !seen[key] {
-			seen[key] = true
-			result = append(result, t)
+		if i, ok := seen[key]; ok {
+			// Gleiches Thema aus mehreren Dokumenten: Quellen zusammenführen
+			result[i].Sources = append(result[i].Sources, t.Sources...)
+			continue
 		}
+		seen[key] = len(result)
+		result = append(result, t)
 	}
 	return result
 }
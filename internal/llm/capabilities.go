@@ -0,0 +1,28 @@
+package llm
+
+// charsPerToken: grobe Schätzung für deutsche Texte
+const charsPerToken = 3
+
+// contentLimit passt eine Zeichen-Obergrenze für Lernmaterial an das Kontextfenster
+// des Backends an. Etwa die Hälfte des Fensters bleibt für Anweisungen und Antwort frei.
+// Bei unbekanntem Fenster gilt defaultChars, größere Fenster erlauben höchstens das Doppelte.
+func contentLimit(p Provider, defaultChars int) int {
+	maxContext := p.Capabilities().MaxContext
+	if maxContext <= 0 {
+		return defaultChars
+	}
+
+	budget := maxContext * charsPerToken / 2
+	if budget < 1000 {
+		budget = 1000
+	}
+	if budget > 2*defaultChars {
+		budget = 2 * defaultChars
+	}
+	return budget
+}
+
+// jsonMode gibt an, ob die Antwort auf JSON festgelegt werden kann
+func jsonMode(p Provider) bool {
+	return p.Capabilities().JSONMode
+}
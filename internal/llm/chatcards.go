@@ -0,0 +1,147 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"lernplattform/internal/models"
+)
+
+// glossaryCategories sind die Kategorien eines Glossar-Eintrags
+var glossaryCategories = map[string]bool{
+	"definition": true, "formula": true, "concept": true, "abbreviation": true, "other": true,
+}
+
+// chatExchange beschreibt Frage und Antwort aus dem Chat für den Prompt
+func chatExchange(question, answer string) string {
+	var sb strings.Builder
+	if question != "" {
+		sb.WriteString("Frage der lernenden Person:\n" + limitContent(question, 1500) + "\n\n")
+	}
+	sb.WriteString("Antwort des Tutors:\n" + limitContent(answer, 4000))
+	return sb.String()
+}
+
+// ChatToFlashcard formt eine hilfreiche Chat-Antwort in eine Karteikarte (offene Frage) um
+func (t *Tutor) ChatToFlashcard(ctx context.Context, topic *models.Topic, question, answer string) (*models.Question, error) {
+	ctx = withDefaultPriority(ctx, PriorityInteractive)
+
+	prompt := fmt.Sprintf(`Mache aus diesem Chat-Verlauf eine Karteikarte zum Thema "%s".
+
+%s
+
+REGELN:
+- Vorderseite: eine klare, eigenständig verständliche Frage zum Kern der Antwort (ohne "wie oben" o.ä.)
+- Rückseite: die Antwort knapp und vollständig (max. 4 Sätze oder Stichpunkte), fachlich genau wie im Chat
+- Nur Inhalte aus der Antwort, nichts hinzuerfinden
+- 1-2 Hinweise, die auf die Antwort hinführen, ohne sie zu verraten
+- difficulty: 1 (sehr leicht) bis 5 (sehr schwer)
+
+Antworte NUR im JSON-Format:
+{"front": "...", "back": "...", "hints": ["..."], "difficulty": 2}`, topic.Name, chatExchange(question, answer))
+
+	resp, err := t.provider.Generate(ctx, prompt, &GenerateOptions{
+		Temperature: 0.3,
+		System:      "Du erstellst präzise Karteikarten aus Lernmaterial. Antworte nur im JSON-Format.",
+		JSON:        jsonMode(t.provider),
+		Seed:        t.seed,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Front      string   `json:"front"`
+		Back       string   `json:"back"`
+		Hints      []string `json:"hints"`
+		Difficulty int      `json:"difficulty"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &result); err != nil {
+		return nil, fmt.Errorf("konnte Karteikarte nicht parsen: %w", err)
+	}
+	front, back := strings.TrimSpace(result.Front), strings.TrimSpace(result.Back)
+	if front == "" || back == "" {
+		return nil, errors.New("keine Vorder- oder Rückseite erkannt")
+	}
+	difficulty := result.Difficulty
+	if difficulty < 1 || difficulty > 5 {
+		difficulty = 2
+	}
+
+	return &models.Question{
+		ID:             fmt.Sprintf("q_%d", time.Now().UnixNano()),
+		TopicID:        topic.ID,
+		Question:       front,
+		ExpectedAnswer: back,
+		Hints:          result.Hints,
+		Difficulty:     difficulty,
+		Type:           "open",
+	}, nil
+}
+
+// ChatToGlossary formt eine hilfreiche Chat-Antwort in einen Glossar-Eintrag (Begriff und Definition) um
+func (t *Tutor) ChatToGlossary(ctx context.Context, question, answer string) (*models.GlossaryItem, error) {
+	ctx = withDefaultPriority(ctx, PriorityInteractive)
+
+	prompt := fmt.Sprintf(`Mache aus diesem Chat-Verlauf einen Glossar-Eintrag.
+
+%s
+
+REGELN:
+- term: der zentrale Fachbegriff, genau so geschrieben wie in der Antwort
+- definition: ein bis zwei Sätze, eigenständig verständlich
+- details: weitere wichtige Punkte aus der Antwort (Beispiel, Formel, Abgrenzung), sonst leer
+- category: "definition", "formula", "concept", "abbreviation" oder "other"
+- related: verwandte Fachbegriffe aus der Antwort (höchstens 5)
+- Nur Inhalte aus der Antwort, nichts hinzuerfinden
+
+Antworte NUR im JSON-Format:
+{"term": "...", "category": "definition", "definition": "...", "details": "...", "related": []}`, chatExchange(question, answer))
+
+	resp, err := t.provider.Generate(ctx, prompt, &GenerateOptions{
+		Temperature: 0.3,
+		System:      "Du erstellst präzise Glossar-Einträge aus Lernmaterial. Antworte nur im JSON-Format.",
+		JSON:        jsonMode(t.provider),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Term       string   `json:"term"`
+		Category   string   `json:"category"`
+		Definition string   `json:"definition"`
+		Details    string   `json:"details"`
+		Related    []string `json:"related"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &result); err != nil {
+		return nil, fmt.Errorf("konnte Glossar-Eintrag nicht parsen: %w", err)
+	}
+	term, definition := strings.TrimSpace(result.Term), strings.TrimSpace(result.Definition)
+	if term == "" || definition == "" {
+		return nil, errors.New("kein Begriff oder keine Definition erkannt")
+	}
+	category := strings.ToLower(strings.TrimSpace(result.Category))
+	if !glossaryCategories[category] {
+		category = "other"
+	}
+	if len(result.Related) > 5 {
+		result.Related = result.Related[:5]
+	}
+
+	now := time.Now()
+	return &models.GlossaryItem{
+		ID:         fmt.Sprintf("%d", now.UnixNano()),
+		Term:       term,
+		Category:   category,
+		Definition: definition,
+		Details:    strings.TrimSpace(result.Details),
+		Related:    result.Related,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}, nil
+}
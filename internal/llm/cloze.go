@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"lernplattform/internal/models"
+)
+
+// ClozeGap markiert die Lücke in einer Lückentext-Frage ("type": "cloze")
+const ClozeGap = "____"
+
+// Ab dieser Ähnlichkeit der Embeddings gilt ein anderer Begriff als richtig (Synonym, Schreibweise)
+const clozeSimilarity = 0.88
+
+// clozeTypeInstruction beschreibt dem Modell das Format für Lückentexte
+const clozeTypeInstruction = `Alle Fragen sind Lückentexte ("type": "cloze"):
+- Nimm einen Satz WÖRTLICH aus dem Material, in dem ein Fachbegriff oder eine Definition vorkommt
+- "expected_answer" ist der vollständige Originalsatz, "source_quote" ebenfalls
+- "question" ist derselbe Satz, in dem GENAU EIN Schlüsselbegriff durch "` + ClozeGap + `" ersetzt ist
+- Ersetze nur Fachbegriffe, Namen oder Zahlen, keine Füllwörter
+- Die Hinweise verraten den Begriff nicht`
+
+// clozeTerm liefert den Begriff in der Lücke: der Originalsatz ohne den Text vor und nach der Lücke.
+// false, wenn die Frage keine (oder mehr als eine) Lücke hat oder nicht zum Originalsatz passt.
+func clozeTerm(question *models.Question) (string, bool) {
+	if strings.Count(question.Question, ClozeGap) != 1 {
+		return "", false
+	}
+	before, after, _ := strings.Cut(question.Question, ClozeGap)
+	before, after = strings.TrimSpace(before), strings.TrimSpace(after)
+	sentence := strings.TrimSpace(question.ExpectedAnswer)
+	if len(before)+len(after) >= len(sentence) || !strings.HasPrefix(sentence, before) || !strings.HasSuffix(sentence, after) {
+		return "", false
+	}
+	term := strings.TrimSpace(sentence[len(before) : len(sentence)-len(after)])
+	return term, term != ""
+}
+
+// normalizeClozeAnswer vergleicht Begriffe ohne Groß-/Kleinschreibung, Artikel und Satzzeichen
+func normalizeClozeAnswer(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.Trim(s, ".,;:!?\"'„“()")
+	for _, article := range []string{"der ", "die ", "das ", "ein ", "eine ", "the ", "a "} {
+		s = strings.TrimPrefix(s, article)
+	}
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// evaluateCloze bewertet eine Lückentext-Antwort ohne LLM: exakt gleicher Begriff oder, falls
+// Embeddings verfügbar sind, ein sehr ähnlicher (Synonym, andere Schreibweise)
+func (t *Tutor) evaluateCloze(ctx context.Context, question *models.Question, userAnswer string) AnswerEvaluation {
+	term, ok := clozeTerm(question)
+	if !ok {
+		term = question.ExpectedAnswer
+	}
+	wrong := AnswerEvaluation{Feedback: "💡 Gesucht war: " + term + " – " + question.ExpectedAnswer}
+
+	answer := normalizeClozeAnswer(userAnswer)
+	if answer == "" {
+		return wrong
+	}
+	if answer == normalizeClozeAnswer(term) {
+		return AnswerEvaluation{IsCorrect: true, Feedback: "✅ Richtig!"}
+	}
+
+	vectors, err := t.Embed(ctx, []string{answer, normalizeClozeAnswer(term)})
+	if err != nil || len(vectors) != 2 {
+		if err != nil && !errors.Is(err, ErrNoEmbeddings) {
+			log.Printf("   [Tutor] Lückentext nur exakt bewertet: %v", err)
+		}
+		return wrong
+	}
+	if CosineSimilarity(vectors[0], vectors[1]) >= clozeSimilarity {
+		return AnswerEvaluation{IsCorrect: true, Feedback: "✅ Richtig! Im Original steht: " + term}
+	}
+	return wrong
+}
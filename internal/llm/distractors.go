@@ -0,0 +1,170 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"lernplattform/internal/models"
+)
+
+// maxDistractorRetries begrenzt die Neugenerierungen pro fehlerhafter Multiple-Choice-Frage
+const maxDistractorRetries = 2
+
+// checkMultipleChoice prüft die Antwortoptionen einer Multiple-Choice-Frage
+// und liefert die gefundenen Probleme (leer = in Ordnung)
+func checkMultipleChoice(q *models.Question) []string {
+	var problems []string
+
+	if len(q.Options) < 3 {
+		problems = append(problems, fmt.Sprintf("nur %d Antwortoptionen (mindestens 3 nötig)", len(q.Options)))
+	}
+
+	correct := normalizeOption(q.ExpectedAnswer)
+	seen := make(map[string]bool)
+	correctCount := 0
+	var distractors []string
+
+	for _, opt := range q.Options {
+		norm := normalizeOption(opt)
+		if norm == "" {
+			problems = append(problems, "leere Antwortoption")
+			continue
+		}
+		if seen[norm] {
+			problems = append(problems, fmt.Sprintf("doppelte Antwortoption \"%s\"", opt))
+			continue
+		}
+		seen[norm] = true
+
+		if norm == correct {
+			correctCount++
+			continue
+		}
+		distractors = append(distractors, norm)
+
+		// Richtige Antwort steckt in einem Distraktor (z.B. "X, aber nur bei Y")
+		if utf8.RuneCountInString(correct) >= 3 && strings.Contains(norm, correct) {
+			problems = append(problems, fmt.Sprintf("Distraktor \"%s\" enthält die richtige Antwort", opt))
+		}
+	}
+
+	if correctCount == 0 {
+		problems = append(problems, "richtige Antwort ist keine der Optionen")
+	}
+
+	// Auffällige Längenunterschiede verraten die richtige Antwort
+	if correctCount == 1 && len(distractors) > 0 {
+		correctLen := utf8.RuneCountInString(correct)
+		shortest, longest := -1, 0
+		for _, d := range distractors {
+			l := utf8.RuneCountInString(d)
+			if shortest < 0 || l < shortest {
+				shortest = l
+			}
+			if l > longest {
+				longest = l
+			}
+		}
+		if correctLen > 20 && correctLen >= 2*longest {
+			problems = append(problems, "richtige Antwort ist deutlich länger als alle Distraktoren")
+		}
+		if shortest > 20 && 2*correctLen <= shortest {
+			problems = append(problems, "richtige Antwort ist deutlich kürzer als alle Distraktoren")
+		}
+	}
+
+	return problems
+}
+
+func normalizeOption(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return strings.TrimRight(s, ".!")
+}
+
+// ensureDistractorQuality generiert Multiple-Choice-Fragen mit schlechten Distraktoren neu.
+// Gelingt das nicht, wird die Frage als offene Frage behalten.
+func (t *Tutor) ensureDistractorQuality(ctx context.Context, topic *models.Topic, questions []models.Question) []models.Question {
+	for i := range questions {
+		if questions[i].Type != "multiple_choice" {
+			continue
+		}
+		reportProgress(ctx, Progress{Step: "distractors", Detail: topic.Name, Model: t.provider.GetCurrentModel(), Done: i, Total: len(questions)})
+
+		problems := checkMultipleChoice(&questions[i])
+		for attempt := 1; len(problems) > 0 && attempt <= maxDistractorRetries; attempt++ {
+			log.Printf("   [Tutor] ⚠️ MC-Frage fehlerhaft (%s), Versuch %d", strings.Join(problems, "; "), attempt)
+
+			fixed, err := t.regenerateMultipleChoice(ctx, topic, &questions[i], problems)
+			if err != nil {
+				log.Printf("   [Tutor] Neugenerierung fehlgeschlagen: %v", err)
+				continue
+			}
+			problems = checkMultipleChoice(fixed)
+			if len(problems) == 0 {
+				questions[i] = *fixed
+			}
+		}
+
+		if len(problems) > 0 {
+			log.Printf("   [Tutor] MC-Frage wird als offene Frage gespeichert: %s", questions[i].Question)
+			questions[i].Type = "open"
+			questions[i].Options = nil
+		}
+	}
+	return questions
+}
+
+// regenerateMultipleChoice lässt eine einzelne Multiple-Choice-Frage mit den gefundenen Problemen überarbeiten
+func (t *Tutor) regenerateMultipleChoice(ctx context.Context, topic *models.Topic, q *models.Question, problems []string) (*models.Question, error) {
+	options, _ := json.Marshal(q.Options)
+	prompt := fmt.Sprintf(`Diese Multiple-Choice-Frage zum Thema "%s" hat fehlerhafte Antwortoptionen.
+
+Frage: %s
+Richtige Antwort: %s
+Optionen: %s
+
+Probleme:
+- %s
+
+Überarbeite die Frage so, dass:
+- es genau 4 verschiedene Optionen gibt
+- "expected_answer" wörtlich einer der Optionen entspricht
+- kein Distraktor die richtige Antwort enthält
+- alle Optionen ähnlich lang und plausibel sind
+- die Sprache der Frage erhalten bleibt
+
+Antworte NUR im JSON-Format:
+{"questions": [{"question": "...", "expected_answer": "...", "options": ["...", "...", "...", "..."], "hints": ["..."], "type": "multiple_choice"}]}`,
+		topic.Name, q.Question, q.ExpectedAnswer, string(options), strings.Join(problems, "\n- "))
+
+	resp, err := t.provider.Generate(ctx, prompt, &GenerateOptions{
+		Temperature: 0.5,
+		System:      "Du überarbeitest Multiple-Choice-Fragen für Prüfungen. Antworte nur im JSON-Format.",
+		JSON:        jsonMode(t.provider),
+		Seed:        t.seed,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fixed, err := parseQuestionsFromResponse(resp.Content, q.TopicID, q.Difficulty)
+	if err != nil {
+		return nil, err
+	}
+	if len(fixed) == 0 {
+		return nil, fmt.Errorf("keine Frage in der Antwort")
+	}
+
+	result := fixed[0]
+	result.ID = q.ID
+	result.Type = "multiple_choice"
+	result.CognitiveLevel = q.CognitiveLevel
+	if result.SourceQuote == "" {
+		result.SourceQuote = q.SourceQuote
+	}
+	return &result, nil
+}
@@ -0,0 +1,180 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+)
+
+// Standard-Embedding-Modelle, wenn keins konfiguriert ist
+const (
+	defaultOllamaEmbeddingModel = "nomic-embed-text"
+	defaultOpenAIEmbeddingModel = "text-embedding-3-small"
+)
+
+// ErrNoEmbeddings bedeutet, dass das Backend keine Embeddings berechnen kann
+var ErrNoEmbeddings = errors.New("backend unterstützt keine embeddings")
+
+// Embedder ist ein Backend, das Texte in Vektoren umrechnen kann (optional neben Provider).
+// model leer = Standard-Embedding-Modell des Backends.
+type Embedder interface {
+	Embed(ctx context.Context, model string, texts []string) ([][]float64, error)
+}
+
+// Embed berechnet Embeddings über Ollama (/api/embed)
+func (o *OllamaProvider) Embed(ctx context.Context, model string, texts []string) ([][]float64, error) {
+	if model == "" {
+		model = defaultOllamaEmbeddingModel
+	}
+	reqBody := map[string]interface{}{
+		"model": model,
+		"input": texts,
+	}
+	o.applyKeepAlive(reqBody)
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := o.limiter.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer o.limiter.release()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/embed", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ollama nicht erreichbar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ollama-fehler (%d): %s", resp.StatusCode, string(body))
+	}
+	var result struct {
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if len(result.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama lieferte %d statt %d embeddings", len(result.Embeddings), len(texts))
+	}
+	return result.Embeddings, nil
+}
+
+// Embed berechnet Embeddings über eine OpenAI-kompatible API (/embeddings)
+func (o *OpenAIProvider) Embed(ctx context.Context, model string, texts []string) ([][]float64, error) {
+	if model == "" {
+		model = defaultOpenAIEmbeddingModel
+	}
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"model": model,
+		"input": texts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := o.newRequest(ctx, "POST", "/embeddings", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := o.limiter.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer o.limiter.release()
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("api-anfrage fehlgeschlagen: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("api-fehler (%d): %s", resp.StatusCode, string(body))
+	}
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	embeddings := make([][]float64, len(texts))
+	for _, d := range result.Data {
+		if d.Index >= 0 && d.Index < len(embeddings) {
+			embeddings[d.Index] = d.Embedding
+		}
+	}
+	for i, e := range embeddings {
+		if e == nil {
+			return nil, fmt.Errorf("api-antwort ohne embedding für text %d", i)
+		}
+	}
+	return embeddings, nil
+}
+
+// Embed nutzt das erste Backend, das Embeddings berechnen kann
+func (f *FailoverProvider) Embed(ctx context.Context, model string, texts []string) ([][]float64, error) {
+	var embeddings [][]float64
+	found := false
+	err := f.try(ctx, func(b *backendState) error {
+		embedder, ok := b.Provider.(Embedder)
+		if !ok {
+			return ErrNoEmbeddings
+		}
+		found = true
+		var err error
+		embeddings, err = embedder.Embed(ctx, model, texts)
+		return err
+	})
+	if !found {
+		return nil, ErrNoEmbeddings
+	}
+	return embeddings, err
+}
+
+// Embed berechnet Embeddings mit dem Backend des Tutors (ErrNoEmbeddings, falls nicht möglich)
+func (t *Tutor) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	embedder, ok := t.provider.(Embedder)
+	if !ok {
+		return nil, ErrNoEmbeddings
+	}
+	return embedder.Embed(withDefaultPriority(ctx, PriorityInteractive), t.embeddingModel, texts)
+}
+
+// SetEmbeddingModel legt das Embedding-Modell fest (leer = Standard des Backends)
+func (t *Tutor) SetEmbeddingModel(model string) {
+	t.embeddingModel = model
+}
+
+// CosineSimilarity vergleicht zwei Embeddings (-1..1, 0 bei unterschiedlicher Länge)
+func CosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
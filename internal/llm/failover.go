@@ -0,0 +1,260 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// failoverCooldown: so lange wird ein fehlgeschlagenes Backend übersprungen
+const failoverCooldown = 30 * time.Second
+
+// FailoverBackend ist ein Backend in der Failover-Kette
+type FailoverBackend struct {
+	Name     string
+	Provider Provider
+	// Model ersetzt das eingestellte Modell, wenn ModelMap keinen Eintrag hat (leer = unverändert)
+	Model string
+	// ModelMap übersetzt Modellnamen für dieses Backend (z.B. "qwen2.5:7b" -> "gpt-4o-mini")
+	ModelMap map[string]string
+}
+
+// BackendStatus beschreibt den Zustand eines Backends in der Failover-Kette
+type BackendStatus struct {
+	Name      string    `json:"name"`
+	Provider  string    `json:"provider"`
+	Healthy   bool      `json:"healthy"`
+	Failures  int       `json:"failures"`
+	LastError string    `json:"last_error,omitempty"`
+	DownUntil time.Time `json:"down_until,omitempty"`
+}
+
+type backendState struct {
+	FailoverBackend
+	failures  int
+	lastError string
+	downUntil time.Time
+}
+
+// FailoverProvider probiert mehrere Backends der Reihe nach
+// (z.B. lokales Ollama -> Ollama auf dem Desktop -> Cloud-API).
+// Fehlgeschlagene Backends werden für eine Weile übersprungen.
+type FailoverProvider struct {
+	mu       sync.Mutex
+	backends []*backendState
+	model    string
+}
+
+// NewFailoverProvider erstellt einen Provider über die angegebenen Backends (Reihenfolge = Priorität)
+func NewFailoverProvider(backends []FailoverBackend, defaultModel string) *FailoverProvider {
+	f := &FailoverProvider{model: defaultModel}
+	for _, b := range backends {
+		if b.Name == "" {
+			b.Name = b.Provider.GetName()
+		}
+		f.backends = append(f.backends, &backendState{FailoverBackend: b})
+	}
+	return f
+}
+
+func (f *FailoverProvider) GetName() string {
+	names := make([]string, len(f.backends))
+	for i, b := range f.backends {
+		names[i] = b.Name
+	}
+	return "Failover (" + strings.Join(names, " → ") + ")"
+}
+
+func (f *FailoverProvider) SetModel(model string) {
+	if model == "" {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.model = model
+}
+
+func (f *FailoverProvider) GetCurrentModel() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.model
+}
+
+// Capabilities gibt die Fähigkeiten des Backends zurück, das als nächstes angefragt wird
+func (f *FailoverProvider) Capabilities() Capabilities {
+	if candidates := f.candidates(); len(candidates) > 0 {
+		return candidates[0].Provider.Capabilities()
+	}
+	return Capabilities{}
+}
+
+// Backends gibt den Zustand aller Backends zurück
+func (f *FailoverProvider) Backends() []BackendStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	status := make([]BackendStatus, len(f.backends))
+	for i, b := range f.backends {
+		status[i] = BackendStatus{
+			Name:      b.Name,
+			Provider:  b.Provider.GetName(),
+			Healthy:   !now.Before(b.downUntil),
+			Failures:  b.failures,
+			LastError: b.lastError,
+		}
+		if !status[i].Healthy {
+			status[i].DownUntil = b.downUntil
+		}
+	}
+	return status
+}
+
+// candidates liefert die Backends in Versuchsreihenfolge: gesunde zuerst,
+// gesperrte nur als letzter Ausweg
+func (f *FailoverProvider) candidates() []*backendState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	var healthy, down []*backendState
+	for _, b := range f.backends {
+		if now.Before(b.downUntil) {
+			down = append(down, b)
+		} else {
+			healthy = append(healthy, b)
+		}
+	}
+	return append(healthy, down...)
+}
+
+func (f *FailoverProvider) markFailed(b *backendState, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b.failures++
+	b.lastError = err.Error()
+	b.downUntil = time.Now().Add(failoverCooldown)
+	log.Printf("   [Failover] ⚠️ %s fehlgeschlagen (%v), nächstes Backend...", b.Name, err)
+}
+
+func (f *FailoverProvider) markHealthy(b *backendState) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	b.failures = 0
+	b.lastError = ""
+	b.downUntil = time.Time{}
+}
+
+// optionsFor übersetzt das Modell für ein Backend
+func (f *FailoverProvider) optionsFor(b *backendState, options *GenerateOptions) *GenerateOptions {
+	var opts GenerateOptions
+	if options != nil {
+		opts = *options
+	}
+	model := opts.Model
+	if model == "" {
+		model = f.GetCurrentModel()
+	}
+
+	if mapped, ok := b.ModelMap[model]; ok {
+		opts.Model = mapped
+	} else if b.Model != "" {
+		opts.Model = b.Model
+	} else {
+		opts.Model = model
+	}
+	return &opts
+}
+
+// try ruft fn für jedes Backend auf, bis eines erfolgreich ist
+func (f *FailoverProvider) try(ctx context.Context, fn func(b *backendState) error) error {
+	var errs []string
+	for _, b := range f.candidates() {
+		err := fn(b)
+		if err == nil {
+			f.markHealthy(b)
+			return nil
+		}
+		// Abbruch durch den Aufrufer ist kein Backend-Fehler
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		f.markFailed(b, err)
+		errs = append(errs, fmt.Sprintf("%s: %v", b.Name, err))
+	}
+	if len(errs) == 0 {
+		return errors.New("keine LLM-Backends konfiguriert")
+	}
+	return fmt.Errorf("alle LLM-Backends fehlgeschlagen: %s", strings.Join(errs, "; "))
+}
+
+func (f *FailoverProvider) Generate(ctx context.Context, prompt string, options *GenerateOptions) (*GenerateResponse, error) {
+	var resp *GenerateResponse
+	err := f.try(ctx, func(b *backendState) error {
+		var err error
+		resp, err = b.Provider.Generate(ctx, prompt, f.optionsFor(b, options))
+		return err
+	})
+	return resp, err
+}
+
+// GenerateStream wechselt das Backend nur, solange der Stream noch nicht begonnen hat
+func (f *FailoverProvider) GenerateStream(ctx context.Context, prompt string, options *GenerateOptions) (<-chan StreamChunk, error) {
+	var ch <-chan StreamChunk
+	err := f.try(ctx, func(b *backendState) error {
+		var err error
+		ch, err = b.Provider.GenerateStream(ctx, prompt, f.optionsFor(b, options))
+		return err
+	})
+	return ch, err
+}
+
+func (f *FailoverProvider) Chat(ctx context.Context, messages []ChatMessage, options *GenerateOptions) (*GenerateResponse, error) {
+	var resp *GenerateResponse
+	err := f.try(ctx, func(b *backendState) error {
+		var err error
+		resp, err = b.Provider.Chat(ctx, messages, f.optionsFor(b, options))
+		return err
+	})
+	return resp, err
+}
+
+// GetModels gibt die Modelle des ersten erreichbaren Backends zurück
+func (f *FailoverProvider) GetModels(ctx context.Context) ([]ModelInfo, error) {
+	var models []ModelInfo
+	err := f.try(ctx, func(b *backendState) error {
+		var err error
+		models, err = b.Provider.GetModels(ctx)
+		return err
+	})
+	return models, err
+}
+
+// IsAvailable ist true, sobald irgendein Backend erreichbar ist
+func (f *FailoverProvider) IsAvailable(ctx context.Context) bool {
+	for _, b := range f.candidates() {
+		if b.Provider.IsAvailable(ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+// Warmup lädt das Modell beim ersten Backend, das Vorladen unterstützt (lokales Ollama)
+func (f *FailoverProvider) Warmup(ctx context.Context, model string) error {
+	if model == "" {
+		model = f.GetCurrentModel()
+	}
+	for _, b := range f.candidates() {
+		if w, ok := b.Provider.(interface {
+			Warmup(ctx context.Context, model string) error
+		}); ok {
+			return w.Warmup(ctx, f.optionsFor(b, &GenerateOptions{Model: model}).Model)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"lernplattform/internal/models"
+)
+
+// ErrNoVision: das Modell kann keine Bilder verarbeiten und es ist kein Vision-Modell eingestellt
+var ErrNoVision = errors.New("Modell kann keine Bilder verarbeiten (vision_model einstellen)")
+
+// ErrFigureIrrelevant: die Abbildung hat keinen Lerninhalt (Logo, Dekoration)
+var ErrFigureIrrelevant = errors.New("Abbildung ohne Lerninhalt")
+
+// SetVisionModel legt das Modell für Bildfragen fest (leer = aktuelles Modell, falls es Bilder kann)
+func (t *Tutor) SetVisionModel(model string) {
+	t.visionModel = model
+}
+
+// GenerateFigureQuestion erstellt mit einem Vision-Modell eine Frage zu einer Abbildung aus dem Material:
+// Bei beschrifteten oder nummerierten Teilen wird nach dem markierten Bauteil bzw. Schritt gefragt,
+// sonst nach einer Beschreibung der Abbildung. Bewertet wird wie bei offenen Fragen.
+func (t *Tutor) GenerateFigureQuestion(ctx context.Context, topic *models.Topic, figure *models.Figure, pageText string, difficulty int) (*models.Question, error) {
+	model := t.visionModel
+	if model == "" {
+		if !t.provider.Capabilities().Vision {
+			return nil, ErrNoVision
+		}
+		model = t.provider.GetCurrentModel()
+	}
+
+	prompt := fmt.Sprintf(`Das Bild ist eine Abbildung aus dem Lernmaterial zum Thema "%s" (Seite %d).
+
+Text der Seite (zur Einordnung):
+%s
+
+Erstelle GENAU EINE Prüfungsfrage zu dieser Abbildung (Schwierigkeit %d von 5):
+- Hat die Abbildung beschriftete, nummerierte oder markierte Teile (Buchstaben, Zahlen, Pfeile, Hervorhebungen), frage, welches Bauteil bzw. welcher Schritt an einer bestimmten Markierung gezeigt wird. Nenne die Markierung in der Frage genau ("Was zeigt die mit 3 beschriftete Komponente?").
+- Sonst bitte darum, die Abbildung zu beschreiben und zu erklären, was sie über das Thema aussagt.
+- "expected_answer" nennt die Kernpunkte der richtigen Antwort.
+- Hinweise sind inhaltlich und verraten die Antwort nicht.
+- Zeigt die Abbildung nichts Lernrelevantes (Logo, Foto ohne Inhalt, Dekoration), antworte nur {"relevant": false}.
+%s
+Antworte NUR im JSON-Format:
+{"relevant": true, "question": "...", "expected_answer": "...", "hints": ["..."], "cognitive_level": "remember|understand|apply|analyze"}`,
+		topic.Name, figure.Page, guardMaterial(limitContent(pageText, 2000)), difficulty, outputLanguageRule(ctx))
+
+	done := trackStep(ctx, "figure_question", topic.Name, model, len(prompt))
+	resp, err := t.provider.Generate(ctx, prompt, &GenerateOptions{
+		Model:       model,
+		Temperature: 0.4,
+		System:      "Du erstellst Prüfungsfragen zu Abbildungen aus Lernmaterialien. Beziehe dich nur auf das, was im Bild zu sehen ist. JSON-Format.",
+		JSON:        jsonMode(t.provider),
+		Seed:        t.seed,
+		Images:      [][]byte{figure.Data},
+	})
+	done(err)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Relevant       *bool    `json:"relevant"`
+		Question       string   `json:"question"`
+		ExpectedAnswer string   `json:"expected_answer"`
+		Hints          []string `json:"hints"`
+		CognitiveLevel string   `json:"cognitive_level"`
+	}
+	content := resp.Content
+	if start, end := strings.Index(content, "{"), strings.LastIndex(content, "}"); start != -1 && end > start {
+		content = content[start : end+1]
+	}
+	if err := json.Unmarshal([]byte(content), &result); err != nil {
+		return nil, fmt.Errorf("antwort des Vision-Modells nicht lesbar: %w", err)
+	}
+	if (result.Relevant != nil && !*result.Relevant) || strings.TrimSpace(result.Question) == "" {
+		return nil, ErrFigureIrrelevant
+	}
+
+	level := strings.ToLower(strings.TrimSpace(result.CognitiveLevel))
+	if !IsValidCognitiveLevel(level) {
+		level = ""
+	}
+	return &models.Question{
+		ID:               fmt.Sprintf("q_%d_fig", time.Now().UnixNano()),
+		TopicID:          topic.ID,
+		Question:         strings.TrimSpace(result.Question),
+		ExpectedAnswer:   strings.TrimSpace(result.ExpectedAnswer),
+		Hints:            result.Hints,
+		Difficulty:       difficulty,
+		Type:             "image",
+		CognitiveLevel:   level,
+		SourceDocumentID: figure.DocumentID,
+		SourcePage:       figure.Page,
+		FigureID:         figure.ID,
+	}, nil
+}
@@ -0,0 +1,40 @@
+package llm
+
+import "context"
+
+// Unterstützte Sprachen für Erklärungen und Fragen
+var languageNames = map[string]string{
+	"de": "Deutsch",
+	"en": "Englisch",
+}
+
+// IsSupportedLanguage prüft, ob für eine Sprache Prompts vorliegen
+func IsSupportedLanguage(lang string) bool {
+	_, ok := languageNames[lang]
+	return ok
+}
+
+type languageKey struct{}
+
+// WithLanguage legt die Sprache fest, in der Erklärungen und Fragen erstellt werden
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, languageKey{}, lang)
+}
+
+// languageFrom liest die Sprache aus dem Context (ohne Angabe: Deutsch)
+func languageFrom(ctx context.Context) string {
+	if lang, ok := ctx.Value(languageKey{}).(string); ok && IsSupportedLanguage(lang) {
+		return lang
+	}
+	return "de"
+}
+
+// outputLanguageRule ist die Sprachanweisung am Ende eines Prompts
+func outputLanguageRule(ctx context.Context) string {
+	lang := languageFrom(ctx)
+	if lang == "de" {
+		return "Antworte **nur auf Deutsch**."
+	}
+	return "Antworte **nur auf " + languageNames[lang] + "** (Sprache des Materials). " +
+		"Übersetze auch die hier vorgegebenen Überschriften; JSON-Schlüssel bleiben unverändert."
+}
@@ -0,0 +1,188 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Priority legt fest, in welcher Reihenfolge wartende LLM-Anfragen bedient werden.
+// Höhere Werte werden zuerst bedient.
+type Priority int
+
+const (
+	// PriorityBackground für lange Hintergrundjobs (z.B. Dokumentanalyse, Lernplan)
+	PriorityBackground Priority = iota
+	// PriorityEvaluation für Antwortbewertung und Fragengenerierung (Standard)
+	PriorityEvaluation
+	// PriorityInteractive für Chat und Erklärungen, auf die der Nutzer direkt wartet
+	PriorityInteractive
+
+	numPriorities
+)
+
+var priorityNames = [numPriorities]string{"background", "evaluation", "interactive"}
+
+func (p Priority) String() string {
+	if p >= 0 && p < numPriorities {
+		return priorityNames[p]
+	}
+	return "unknown"
+}
+
+type priorityKey struct{}
+
+// WithPriority hängt eine Priorität an den Context für alle folgenden LLM-Aufrufe
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFrom liest die Priorität aus dem Context (ohne Angabe: PriorityEvaluation)
+func PriorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok && p >= 0 && p < numPriorities {
+		return p
+	}
+	return PriorityEvaluation
+}
+
+// withDefaultPriority setzt die Priorität nur, wenn der Aufrufer keine vorgegeben hat
+func withDefaultPriority(ctx context.Context, p Priority) context.Context {
+	if _, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return ctx
+	}
+	return WithPriority(ctx, p)
+}
+
+// QueueStats beschreibt die aktuelle Auslastung der LLM-Warteschlange
+type QueueStats struct {
+	Limit   int            `json:"limit"`
+	Active  int            `json:"active"`
+	Waiting map[string]int `json:"waiting"`
+}
+
+// computeMeter summiert die Rechenzeit aller Backends (belegte Plätze mal Dauer) je Kalendertag
+type computeMeter struct {
+	mu    sync.Mutex
+	day   string
+	today time.Duration
+}
+
+var compute = &computeMeter{}
+
+func (m *computeMeter) add(d time.Duration, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if day := now.Format("2006-01-02"); m.day != day {
+		m.day, m.today = day, 0
+	}
+	m.today += d
+}
+
+// ComputeToday liefert die heute verbrauchte LLM-Rechenzeit aller Backends.
+// Laufende Anfragen zählen, sobald ein Platz frei oder neu belegt wird.
+func ComputeToday() time.Duration {
+	compute.mu.Lock()
+	defer compute.mu.Unlock()
+	if compute.day != time.Now().Format("2006-01-02") {
+		return 0
+	}
+	return compute.today
+}
+
+// limiter begrenzt gleichzeitige Anfragen an ein Backend.
+// Freie Plätze gehen immer zuerst an die Warteschlange mit der höchsten Priorität.
+type limiter struct {
+	mu      sync.Mutex
+	limit   int
+	active  int
+	queues  [numPriorities][]chan struct{}
+	changed time.Time // letzte Änderung von active, für die Rechenzeit
+}
+
+func newLimiter(limit int) *limiter {
+	if limit < 1 {
+		limit = 1
+	}
+	return &limiter{limit: limit}
+}
+
+// acquire wartet auf einen freien Platz oder bricht mit dem Context ab
+func (l *limiter) acquire(ctx context.Context) error {
+	l.mu.Lock()
+	if l.active < l.limit && l.waiting() == 0 {
+		l.account()
+		l.active++
+		l.mu.Unlock()
+		return nil
+	}
+
+	p := PriorityFrom(ctx)
+	ready := make(chan struct{})
+	l.queues[p] = append(l.queues[p], ready)
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for i, ch := range l.queues[p] {
+			if ch == ready {
+				l.queues[p] = append(l.queues[p][:i], l.queues[p][i+1:]...)
+				return ctx.Err()
+			}
+		}
+		// Platz wurde gleichzeitig zugeteilt - direkt weitergeben
+		l.handOff()
+		return ctx.Err()
+	}
+}
+
+// release gibt einen Platz frei und weckt ggf. den nächsten Wartenden
+func (l *limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.handOff()
+}
+
+func (l *limiter) handOff() {
+	for p := numPriorities - 1; p >= 0; p-- {
+		if len(l.queues[p]) > 0 {
+			next := l.queues[p][0]
+			l.queues[p] = l.queues[p][1:]
+			close(next)
+			return
+		}
+	}
+	l.account()
+	l.active--
+}
+
+// account bucht die Rechenzeit seit der letzten Änderung; nur mit gehaltenem l.mu aufrufen
+func (l *limiter) account() {
+	now := time.Now()
+	if l.active > 0 {
+		compute.add(time.Duration(l.active)*now.Sub(l.changed), now)
+	}
+	l.changed = now
+}
+
+func (l *limiter) stats() QueueStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	waiting := make(map[string]int, numPriorities)
+	for p := Priority(0); p < numPriorities; p++ {
+		waiting[p.String()] = len(l.queues[p])
+	}
+	return QueueStats{Limit: l.limit, Active: l.active, Waiting: waiting}
+}
+
+func (l *limiter) waiting() int {
+	n := 0
+	for _, q := range l.queues {
+		n += len(q)
+	}
+	return n
+}
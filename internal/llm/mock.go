@@ -0,0 +1,172 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MockRule ist eine vorbereitete Antwort: enthält der Prompt (bei Chats System-Prompt und
+// Nachrichten) den Text Match, liefert der MockProvider Response
+type MockRule struct {
+	Match    string `json:"match"`
+	Response string `json:"response"`
+}
+
+// MockCall ist eine Anfrage an den MockProvider, zum Nachprüfen der Prompts
+type MockCall struct {
+	Prompt   string        `json:"prompt,omitempty"`
+	System   string        `json:"system,omitempty"`
+	Messages []ChatMessage `json:"messages,omitempty"`
+	Rule     int           `json:"rule"` // Index der passenden Regel, -1 = Fallback
+}
+
+// MockProvider antwortet mit vorbereiteten Texten statt eines Modells, damit Lernplan,
+// Fragen und Bewertung ohne GPU durchgespielt werden können. Die erste passende Regel gewinnt.
+type MockProvider struct {
+	mu        sync.Mutex
+	rules     []MockRule
+	fallback  string
+	model     string
+	available bool
+	calls     []MockCall
+}
+
+// mockScript ist das Dateiformat für LoadMockProvider
+type mockScript struct {
+	Rules    []MockRule `json:"rules"`
+	Fallback string     `json:"fallback"`
+}
+
+// NewMockProvider erstellt einen MockProvider; fallback gilt, wenn keine Regel passt
+func NewMockProvider(rules []MockRule, fallback string) *MockProvider {
+	if fallback == "" {
+		fallback = "Mock-Antwort"
+	}
+	return &MockProvider{rules: rules, fallback: fallback, model: "mock", available: true}
+}
+
+// LoadMockProvider liest die Regeln aus einer JSON-Datei ({"rules": [...], "fallback": "..."})
+func LoadMockProvider(path string) (*MockProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var script mockScript
+	if err := json.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("mock-skript %s: %w", path, err)
+	}
+	return NewMockProvider(script.Rules, script.Fallback), nil
+}
+
+// SetAvailable simuliert ein erreichbares bzw. ausgefallenes Backend
+func (m *MockProvider) SetAvailable(available bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.available = available
+}
+
+// Calls liefert alle bisherigen Anfragen, älteste zuerst
+func (m *MockProvider) Calls() []MockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MockCall(nil), m.calls...)
+}
+
+// respond sucht die passende Regel und merkt sich die Anfrage
+func (m *MockProvider) respond(ctx context.Context, call MockCall, text string) (*GenerateResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.available {
+		return nil, fmt.Errorf("mock-backend nicht erreichbar")
+	}
+
+	content := m.fallback
+	call.Rule = -1
+	for i, rule := range m.rules {
+		if strings.Contains(text, rule.Match) {
+			content = rule.Response
+			call.Rule = i
+			break
+		}
+	}
+	m.calls = append(m.calls, call)
+	return &GenerateResponse{Content: content, Model: m.model, Done: true}, nil
+}
+
+func (m *MockProvider) Generate(ctx context.Context, prompt string, options *GenerateOptions) (*GenerateResponse, error) {
+	call := MockCall{Prompt: prompt}
+	if options != nil {
+		call.System = options.System
+	}
+	return m.respond(ctx, call, call.System+"\n"+prompt)
+}
+
+// GenerateStream liefert die Antwort wortweise, wie ein echtes Modell
+func (m *MockProvider) GenerateStream(ctx context.Context, prompt string, options *GenerateOptions) (<-chan StreamChunk, error) {
+	resp, err := m.Generate(ctx, prompt, options)
+	if err != nil {
+		return nil, err
+	}
+
+	words := strings.SplitAfter(resp.Content, " ")
+	ch := make(chan StreamChunk, len(words)+1)
+	for _, w := range words {
+		ch <- StreamChunk{Content: w}
+	}
+	ch <- StreamChunk{Done: true}
+	close(ch)
+	return ch, nil
+}
+
+func (m *MockProvider) Chat(ctx context.Context, messages []ChatMessage, options *GenerateOptions) (*GenerateResponse, error) {
+	call := MockCall{Messages: messages}
+	var text strings.Builder
+	if options != nil {
+		call.System = options.System
+		text.WriteString(options.System + "\n")
+	}
+	for _, msg := range messages {
+		text.WriteString(msg.Content + "\n")
+	}
+	return m.respond(ctx, call, text.String())
+}
+
+func (m *MockProvider) GetModels(ctx context.Context) ([]ModelInfo, error) {
+	return []ModelInfo{{Name: m.GetCurrentModel(), ModifiedAt: time.Now()}}, nil
+}
+
+func (m *MockProvider) IsAvailable(ctx context.Context) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.available
+}
+
+func (m *MockProvider) GetName() string {
+	return "Mock"
+}
+
+func (m *MockProvider) SetModel(model string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.model = model
+}
+
+func (m *MockProvider) GetCurrentModel() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.model
+}
+
+// Capabilities: JSON-Modus, damit die Prompts dieselben Optionen bekommen wie bei Ollama
+func (m *MockProvider) Capabilities() Capabilities {
+	return Capabilities{MaxContext: 8192, JSONMode: true}
+}
@@ -0,0 +1,343 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIProvider implementiert den Provider für OpenAI-kompatible APIs
+// (OpenAI, LM Studio, vLLM, OpenRouter, ...)
+type OpenAIProvider struct {
+	baseURL      string
+	apiKey       string
+	defaultModel string
+	client       *http.Client
+	limiter      *limiter
+}
+
+// NewOpenAIProvider erstellt einen Provider für eine OpenAI-kompatible API.
+// baseURL inklusive Versionspfad, z.B. "https://api.openai.com/v1".
+func NewOpenAIProvider(baseURL, apiKey, defaultModel string, maxConcurrent int) *OpenAIProvider {
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	if defaultModel == "" {
+		defaultModel = "gpt-4o-mini"
+	}
+
+	return &OpenAIProvider{
+		baseURL:      strings.TrimSuffix(baseURL, "/"),
+		apiKey:       apiKey,
+		defaultModel: defaultModel,
+		client: &http.Client{
+			Timeout: 15 * time.Minute,
+		},
+		limiter: newLimiter(maxConcurrent),
+	}
+}
+
+func (o *OpenAIProvider) GetName() string {
+	return "OpenAI-kompatibel"
+}
+
+func (o *OpenAIProvider) SetModel(model string) {
+	if model != "" {
+		o.defaultModel = model
+	}
+}
+
+func (o *OpenAIProvider) GetCurrentModel() string {
+	return o.defaultModel
+}
+
+// openAIContext: Kontextfenster bekannter Modellfamilien (Präfix -> Tokens)
+var openAIContext = []struct {
+	prefix string
+	tokens int
+	vision bool
+}{
+	{"gpt-4.1", 1047576, true},
+	{"gpt-4o", 128000, true},
+	{"gpt-4-turbo", 128000, true},
+	{"gpt-3.5-turbo", 16385, false},
+}
+
+// Capabilities schätzt die Fähigkeiten anhand des Modellnamens; unbekannte Modelle
+// (z.B. bei LM Studio oder vLLM) melden kein Kontextfenster
+func (o *OpenAIProvider) Capabilities() Capabilities {
+	caps := Capabilities{JSONMode: true, Embeddings: true}
+	for _, m := range openAIContext {
+		if strings.HasPrefix(o.defaultModel, m.prefix) {
+			caps.MaxContext = m.tokens
+			caps.Vision = m.vision
+			break
+		}
+	}
+	return caps
+}
+
+func (o *OpenAIProvider) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, o.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+	return req, nil
+}
+
+func (o *OpenAIProvider) IsAvailable(ctx context.Context) bool {
+	_, err := o.GetModels(ctx)
+	return err == nil
+}
+
+func (o *OpenAIProvider) GetModels(ctx context.Context) ([]ModelInfo, error) {
+	req, err := o.newRequest(ctx, "GET", "/models", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("api nicht erreichbar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("api-fehler (%d): %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Data []struct {
+			ID      string `json:"id"`
+			Created int64  `json:"created"`
+		} `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	var models []ModelInfo
+	for _, m := range result.Data {
+		models = append(models, ModelInfo{
+			Name:       m.ID,
+			ModifiedAt: time.Unix(m.Created, 0),
+		})
+	}
+
+	return models, nil
+}
+
+func (o *OpenAIProvider) Generate(ctx context.Context, prompt string, options *GenerateOptions) (*GenerateResponse, error) {
+	var messages []ChatMessage
+	if options != nil && options.System != "" {
+		messages = append(messages, ChatMessage{Role: "system", Content: options.System})
+	}
+	messages = append(messages, ChatMessage{Role: "user", Content: prompt})
+
+	return o.Chat(ctx, messages, options)
+}
+
+// completionBody baut den Request-Body für /chat/completions
+func (o *OpenAIProvider) completionBody(messages []ChatMessage, options *GenerateOptions, stream bool) ([]byte, string, error) {
+	model := o.defaultModel
+	if options != nil && options.Model != "" {
+		model = options.Model
+	}
+
+	reqBody := map[string]interface{}{
+		"model":    model,
+		"messages": messages,
+		"stream":   stream,
+	}
+	if options != nil && len(options.Images) > 0 && len(messages) > 0 {
+		reqBody["messages"] = withImages(messages, options.Images)
+	}
+
+	if options != nil {
+		if options.Temperature > 0 {
+			reqBody["temperature"] = options.Temperature
+		}
+		if options.MaxTokens > 0 {
+			reqBody["max_tokens"] = options.MaxTokens
+		}
+		if options.TopP > 0 {
+			reqBody["top_p"] = options.TopP
+		}
+		if options.JSON {
+			reqBody["response_format"] = map[string]string{"type": "json_object"}
+		}
+		if options.Seed != 0 {
+			reqBody["seed"] = options.Seed
+		}
+	}
+
+	data, err := json.Marshal(reqBody)
+	return data, model, err
+}
+
+// withImages hängt Bilder als Data-URLs an die letzte Nachricht an (Format für Vision-Modelle)
+func withImages(messages []ChatMessage, images [][]byte) []interface{} {
+	result := make([]interface{}, len(messages))
+	for i, m := range messages {
+		result[i] = m
+	}
+	last := messages[len(messages)-1]
+	parts := []map[string]interface{}{{"type": "text", "text": last.Content}}
+	for _, img := range images {
+		url := "data:" + http.DetectContentType(img) + ";base64," + base64.StdEncoding.EncodeToString(img)
+		parts = append(parts, map[string]interface{}{"type": "image_url", "image_url": map[string]string{"url": url}})
+	}
+	result[len(result)-1] = map[string]interface{}{"role": last.Role, "content": parts}
+	return result
+}
+
+func (o *OpenAIProvider) Chat(ctx context.Context, messages []ChatMessage, options *GenerateOptions) (*GenerateResponse, error) {
+	jsonData, model, err := o.completionBody(messages, options, false)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := o.newRequest(ctx, "POST", "/chat/completions", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := o.limiter.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer o.limiter.release()
+
+	log.Printf("   [OpenAI] Sende Anfrage an %s (Modell: %s)", o.baseURL, model)
+	start := time.Now()
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("api-anfrage fehlgeschlagen: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("api-fehler (%d): %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage struct {
+			PromptTokens int `json:"prompt_tokens"`
+			TotalTokens  int `json:"total_tokens"`
+		} `json:"usage"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if len(result.Choices) == 0 {
+		return nil, fmt.Errorf("api-antwort ohne Inhalt")
+	}
+
+	log.Printf("   [OpenAI] ✓ Antwort nach %v", time.Since(start))
+
+	return &GenerateResponse{
+		Content:      result.Choices[0].Message.Content,
+		Model:        result.Model,
+		TotalTokens:  result.Usage.TotalTokens,
+		PromptTokens: result.Usage.PromptTokens,
+		Done:         true,
+	}, nil
+}
+
+func (o *OpenAIProvider) GenerateStream(ctx context.Context, prompt string, options *GenerateOptions) (<-chan StreamChunk, error) {
+	var messages []ChatMessage
+	if options != nil && options.System != "" {
+		messages = append(messages, ChatMessage{Role: "system", Content: options.System})
+	}
+	messages = append(messages, ChatMessage{Role: "user", Content: prompt})
+
+	jsonData, _, err := o.completionBody(messages, options, true)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := o.newRequest(ctx, "POST", "/chat/completions", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := o.limiter.acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		o.limiter.release()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		o.limiter.release()
+		return nil, fmt.Errorf("api-fehler (%d): %s", resp.StatusCode, string(body))
+	}
+
+	ch := make(chan StreamChunk, 100)
+
+	go func() {
+		defer o.limiter.release()
+		defer close(ch)
+		defer resp.Body.Close()
+
+		// Server-Sent Events: "data: {...}" bis "data: [DONE]"
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if data == "[DONE]" {
+				ch <- StreamChunk{Done: true}
+				return
+			}
+
+			var chunk struct {
+				Choices []struct {
+					Delta struct {
+						Content string `json:"content"`
+					} `json:"delta"`
+				} `json:"choices"`
+			}
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				ch <- StreamChunk{Error: err}
+				return
+			}
+			if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+				ch <- StreamChunk{Content: chunk.Choices[0].Delta.Content}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			ch <- StreamChunk{Error: err}
+		}
+	}()
+
+	return ch, nil
+}
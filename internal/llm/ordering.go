@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+
+	"lernplattform/internal/models"
+)
+
+// OrderingSeparator trennt die Schritte einer Reihenfolge-Antwort ("type": "ordering")
+const OrderingSeparator = " → "
+
+// orderingTypeInstruction beschreibt dem Modell das Format für Reihenfolge-Fragen
+const orderingTypeInstruction = `Alle Fragen sind Reihenfolge-Fragen ("type": "ordering"), z.B. Prozessschritte, Phasen eines Algorithmus oder historische Abfolgen:
+- "question" fordert dazu auf, die Schritte in die richtige Reihenfolge zu bringen
+- "options" enthält 3-6 kurze, eindeutig unterscheidbare Schritte in der RICHTIGEN Reihenfolge (sie werden für die Anzeige gemischt), ohne "→" im Text
+- Die Reihenfolge muss sich eindeutig aus dem Material ergeben
+- "expected_answer" sind die Schritte in richtiger Reihenfolge, getrennt durch "` + OrderingSeparator + `"`
+
+// validOrdering prüft, ob eine Reihenfolge-Frage genug eindeutige Schritte hat. Schritte mit
+// Trennzeichen oder Zeilenumbruch würden beim Bewerten zerteilt und sind deshalb nicht erlaubt.
+func validOrdering(options []string) bool {
+	if len(options) < 3 {
+		return false
+	}
+	seen := make(map[string]bool)
+	for _, option := range options {
+		key := normalizeOption(option)
+		if key == "" || seen[key] || strings.ContainsAny(option, strings.TrimSpace(OrderingSeparator)+"\n") {
+			return false
+		}
+		seen[key] = true
+	}
+	return true
+}
+
+// orderingPositions ordnet die Schritte einer Antwort den Positionen der richtigen Reihenfolge zu;
+// false, wenn Schritte fehlen, doppelt oder unbekannt sind
+func orderingPositions(question *models.Question, answer string) ([]int, bool) {
+	index := make(map[string]int, len(question.Options))
+	for i, option := range question.Options {
+		index[normalizeOption(option)] = i
+	}
+
+	steps := strings.Split(strings.ReplaceAll(answer, "\n", OrderingSeparator), strings.TrimSpace(OrderingSeparator))
+	var positions []int
+	used := make(map[int]bool)
+	for _, step := range steps {
+		if strings.TrimSpace(step) == "" {
+			continue
+		}
+		i, ok := index[normalizeOption(step)]
+		if !ok || used[i] {
+			return nil, false
+		}
+		used[i] = true
+		positions = append(positions, i)
+	}
+	return positions, len(positions) == len(question.Options)
+}
+
+// evaluateOrdering bewertet eine Reihenfolge ohne LLM. Richtig ist nur die exakte Reihenfolge;
+// der Score ist der Anteil der Schrittpaare, die zueinander richtig stehen (Teilpunkte für fast richtige Folgen).
+func evaluateOrdering(question *models.Question, userAnswer string) AnswerEvaluation {
+	expected := strings.Join(question.Options, OrderingSeparator)
+	positions, ok := orderingPositions(question, userAnswer)
+	if !ok {
+		return AnswerEvaluation{Feedback: "💡 Bitte ordne alle Schritte genau einmal an. Richtige Reihenfolge: " + expected}
+	}
+
+	pairs, inOrder := 0, 0
+	for i := range positions {
+		for j := i + 1; j < len(positions); j++ {
+			pairs++
+			if positions[i] < positions[j] {
+				inOrder++
+			}
+		}
+	}
+	score := inOrder * 100 / pairs
+	if score == 100 {
+		return AnswerEvaluation{IsCorrect: true, Score: 100, Feedback: "✅ Richtig!"}
+	}
+
+	wrong := 0
+	for i, p := range positions {
+		if p != i {
+			wrong++
+		}
+	}
+	feedback := fmt.Sprintf("💡 %d von %d Schritten stehen an der falschen Stelle. Richtige Reihenfolge: %s", wrong, len(positions), expected)
+	if score >= 80 {
+		feedback = "💡 Fast richtig! " + feedback[len("💡 "):]
+	}
+	return AnswerEvaluation{Score: score, Feedback: feedback}
+}
@@ -0,0 +1,154 @@
+package llm
+
+import (
+	"context"
+	"strings"
+
+	"lernplattform/internal/models"
+)
+
+// Strenge der Bewertung offener Antworten im Fachprofil
+const (
+	StrictnessLenient = "lenient"
+	StrictnessNormal  = "normal"
+	StrictnessStrict  = "strict"
+)
+
+// IsValidStrictness prüft eine Bewertungsstrenge
+func IsValidStrictness(strictness string) bool {
+	switch strictness {
+	case StrictnessLenient, StrictnessNormal, StrictnessStrict:
+		return true
+	}
+	return false
+}
+
+// ProfileQuestionTypes sind die Fragetypen, die ein Fachprofil mischen kann
+// (Bildfragen brauchen Abbildungen und werden nur ausdrücklich erzeugt)
+var ProfileQuestionTypes = map[string]bool{"open": true, "multiple_choice": true, "cloze": true, "ordering": true}
+
+// strictnessRules ergänzen die Bewertungsregeln; normal = unverändert
+var strictnessRules = map[string]string{
+	StrictnessLenient: `BEWERTUNGSSTRENGE (Fachprofil): NACHSICHTIG
+- is_correct = TRUE schon, wenn die Kernidee erkennbar stimmt, auch wenn Details oder Fachbegriffe fehlen`,
+	StrictnessStrict: `BEWERTUNGSSTRENGE (Fachprofil): STRENG
+- is_correct = TRUE nur, wenn ALLE Kernpunkte fachlich exakt genannt sind
+- Fachbegriffe, Fristen, Dosierungen, Formeln und Einheiten müssen stimmen; Umschreibungen reichen nicht
+- Tippfehler in Fachbegriffen sind nur ok, wenn der Begriff eindeutig bleibt`,
+}
+
+const formulaRule = `FORMELN (Fachprofil): Schreibe alle Formeln und Rechenwege in LaTeX, inline als $…$ und abgesetzt als $$…$$. Keine Formeln als Bild- oder Fließtext umschreiben.`
+
+type profileKey struct{}
+
+// WithProfile legt das Fachprofil fest, das Erklärungen, Fragen und Bewertungen ergänzt
+func WithProfile(ctx context.Context, profile *models.SubjectProfile) context.Context {
+	if profile == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, profileKey{}, profile)
+}
+
+// ProfileFrom liest das Fachprofil aus dem Context (nil = ohne Profil)
+func ProfileFrom(ctx context.Context) *models.SubjectProfile {
+	profile, _ := ctx.Value(profileKey{}).(*models.SubjectProfile)
+	return profile
+}
+
+// explanationProfileRules sind die Anweisungen des Fachprofils für Erklärungen (leer ohne Profil)
+func explanationProfileRules(ctx context.Context) string {
+	if profile := ProfileFrom(ctx); profile != nil {
+		return profileRules(profile, profile.ExplanationPrompt)
+	}
+	return ""
+}
+
+// questionProfileRules sind die Anweisungen des Fachprofils für Fragen (leer ohne Profil)
+func questionProfileRules(ctx context.Context) string {
+	if profile := ProfileFrom(ctx); profile != nil {
+		return profileRules(profile, profile.QuestionPrompt)
+	}
+	return ""
+}
+
+// profileRules verbindet die Prompt-Vorlage des Profils mit der Formel-Einstellung
+func profileRules(profile *models.SubjectProfile, instructions string) string {
+	var rules []string
+	if text := strings.TrimSpace(instructions); text != "" {
+		rules = append(rules, "FACHPROFIL "+profile.Name+":\n"+text)
+	}
+	if profile.Formulas {
+		rules = append(rules, formulaRule)
+	}
+	if len(rules) == 0 {
+		return ""
+	}
+	return strings.Join(rules, "\n\n") + "\n\n"
+}
+
+// strictnessRule ist die Strenge-Anweisung des Fachprofils für Bewertungen (leer bei normal)
+func strictnessRule(ctx context.Context) string {
+	profile := ProfileFrom(ctx)
+	if profile == nil {
+		return ""
+	}
+	if rule, ok := strictnessRules[profile.Strictness]; ok {
+		return "\n\n" + rule
+	}
+	return ""
+}
+
+// DefaultProfiles sind die mitgelieferten Fachprofile
+func DefaultProfiles() []models.SubjectProfile {
+	return []models.SubjectProfile{
+		{
+			ID:   "math",
+			Name: "Mathematik",
+			ExplanationPrompt: `- Leite Formeln Schritt für Schritt her und nenne jede Umformung
+- Zeige zu jedem Satz ein durchgerechnetes Beispiel mit konkreten Zahlen
+- Nenne Voraussetzungen (Definitionsbereich, Stetigkeit usw.) ausdrücklich`,
+			QuestionPrompt: `- Bevorzuge Rechen- und Beweisaufgaben mit eindeutigem Ergebnis
+- "expected_answer" enthält den Rechenweg in Kurzform und das Endergebnis
+- Hinweise nennen den Ansatz (Formel, Satz), nicht das Ergebnis`,
+			QuestionTypes: map[string]int{"open": 3, "multiple_choice": 1},
+			Strictness:    StrictnessStrict,
+			Formulas:      true,
+		},
+		{
+			ID:   "law",
+			Name: "Jura",
+			ExplanationPrompt: `- Erkläre entlang von Tatbestand und Rechtsfolge und nenne die einschlägigen Normen (§, Absatz, Gesetz)
+- Zeige die Prüfungsreihenfolge im Gutachtenstil an einem kurzen Fall
+- Grenze herrschende Meinung und Gegenansichten ab, wo das Material sie nennt`,
+			QuestionPrompt: `- Stelle kurze Fälle (Sachverhalt in 2-4 Sätzen) mit einer konkreten Fallfrage
+- "expected_answer" nennt Anspruchsgrundlage bzw. Norm, die Prüfungspunkte und das Ergebnis
+- Frage Definitionen wörtlich ab, wenn das Material sie vorgibt`,
+			QuestionTypes: map[string]int{"open": 3, "ordering": 1},
+			Strictness:    StrictnessStrict,
+		},
+		{
+			ID:   "medicine",
+			Name: "Medizin",
+			ExplanationPrompt: `- Gliedere nach Ätiologie, Pathophysiologie, Klinik, Diagnostik und Therapie, soweit das Material es hergibt
+- Nenne Fachbegriffe mit deutscher Übersetzung
+- Hebe Leitsymptome, Normwerte und Kontraindikationen hervor`,
+			QuestionPrompt: `- Bevorzuge kurze Fallvignetten (Alter, Leitsymptom, Befund) wie in Staatsexamensfragen
+- Multiple-Choice-Distraktoren sind klinisch plausible Differentialdiagnosen
+- Frage Normwerte und Dosierungen nur ab, wenn sie im Material stehen`,
+			QuestionTypes: map[string]int{"multiple_choice": 3, "open": 1, "cloze": 1},
+			Strictness:    StrictnessNormal,
+		},
+		{
+			ID:   "programming",
+			Name: "Programmierung",
+			ExplanationPrompt: `- Zeige zu jedem Konzept ein kurzes, lauffähiges Codebeispiel in einem Markdown-Codeblock mit Sprache
+- Erkläre, was der Code ausgibt und warum
+- Nenne typische Fehler (Off-by-one, Null-Referenzen, Seiteneffekte)`,
+			QuestionPrompt: `- Frage nach der Ausgabe kurzer Codeausschnitte, nach Fehlern im Code oder nach der Laufzeitkomplexität
+- Code steht in der Frage in einem Markdown-Codeblock
+- Reihenfolge-Fragen eignen sich für Algorithmenschritte`,
+			QuestionTypes: map[string]int{"open": 2, "multiple_choice": 2, "ordering": 1},
+			Strictness:    StrictnessNormal,
+		},
+	}
+}
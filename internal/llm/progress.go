@@ -0,0 +1,151 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"lernplattform/internal/models"
+)
+
+// Progress ist ein Zwischenstand einer langen Tutor-Operation
+type Progress struct {
+	Step   string `json:"step"`             // analyze_document, exam_topics, analyze, explain, questions, distractors
+	Detail string `json:"detail,omitempty"` // z.B. Name des Dokuments
+	Model  string `json:"model,omitempty"`  // Modell, mit dem der Schritt läuft
+	Done   int    `json:"done"`
+	Total  int    `json:"total"`
+	// Geschätzte Restzeit des Schritts aus früheren Läufen mit Modell und Eingabegröße (0 = unbekannt)
+	ETASeconds int `json:"eta_seconds,omitempty"`
+}
+
+// ProgressFunc empfängt die Zwischenstände; wird aus der laufenden Operation heraus aufgerufen
+type ProgressFunc func(Progress)
+
+type progressFuncKey struct{}
+
+// WithProgress hängt einen Empfänger für Zwischenstände an den Context
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressFuncKey{}, fn)
+}
+
+// reportProgress meldet einen Zwischenstand, falls der Aufrufer einen Empfänger angehängt hat
+func reportProgress(ctx context.Context, p Progress) {
+	if fn, ok := ctx.Value(progressFuncKey{}).(ProgressFunc); ok && fn != nil {
+		fn(p)
+	}
+}
+
+// Messungen je Schritt und Modell, die für die Schätzung herangezogen werden
+const maxDurationSamples = 100
+
+type durationKey struct{ step, model string }
+
+// durationModel schätzt die Dauer eines LLM-Aufrufs per linearer Regression über die
+// Eingabegröße (Zeichen), getrennt nach Schritt und Modell
+type durationModel struct {
+	mu      sync.Mutex
+	samples map[durationKey][]models.DurationSample
+	onAdd   func(models.DurationSample)
+}
+
+var durations = &durationModel{samples: make(map[durationKey][]models.DurationSample)}
+
+// LoadDurations übernimmt gespeicherte Messungen, z.B. beim Start aus der Datenbank (älteste zuerst)
+func LoadDurations(samples []models.DurationSample) {
+	durations.mu.Lock()
+	defer durations.mu.Unlock()
+	for _, s := range samples {
+		durations.add(s)
+	}
+}
+
+// OnDuration setzt eine Funktion, die jede neue Messung erhält (zum Speichern)
+func OnDuration(fn func(models.DurationSample)) {
+	durations.mu.Lock()
+	defer durations.mu.Unlock()
+	durations.onAdd = fn
+}
+
+// add nimmt eine Messung auf; nur mit gehaltenem d.mu aufrufen
+func (d *durationModel) add(s models.DurationSample) {
+	key := durationKey{s.Step, s.Model}
+	list := append(d.samples[key], s)
+	if len(list) > maxDurationSamples {
+		list = list[len(list)-maxDurationSamples:]
+	}
+	d.samples[key] = list
+}
+
+// observe nimmt die Dauer eines erfolgreichen Aufrufs auf
+func (d *durationModel) observe(step, model string, size int, took time.Duration) {
+	s := models.DurationSample{Step: step, Model: model, Size: size, Seconds: took.Seconds(), CreatedAt: time.Now()}
+	d.mu.Lock()
+	d.add(s)
+	onAdd := d.onAdd
+	d.mu.Unlock()
+	if onAdd != nil {
+		onAdd(s)
+	}
+}
+
+// predict schätzt die Dauer für eine Eingabe der Größe size; false ohne frühere Messungen.
+// Bei wenigen Messungen, gleich großen Eingaben oder fallender Gerade gilt der Mittelwert.
+func (d *durationModel) predict(step, model string, size int) (time.Duration, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	list := d.samples[durationKey{step, model}]
+	if len(list) == 0 {
+		return 0, false
+	}
+
+	n := float64(len(list))
+	var sumX, sumY float64
+	for _, s := range list {
+		sumX += float64(s.Size)
+		sumY += s.Seconds
+	}
+	meanX, meanY := sumX/n, sumY/n
+	var cov, varX float64
+	for _, s := range list {
+		dx := float64(s.Size) - meanX
+		cov += dx * (s.Seconds - meanY)
+		varX += dx * dx
+	}
+
+	seconds := meanY
+	if len(list) >= 3 && varX > 0 && cov > 0 {
+		slope := cov / varX
+		if estimate := meanY + slope*(float64(size)-meanX); estimate > 0 {
+			seconds = estimate
+		}
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// trackStep meldet einen Schritt aus einer Einheit (z.B. eine Erklärung) samt geschätzter Dauer
+// und liefert eine Funktion für das Ende; bei Erfolg fließt die Dauer in künftige Schätzungen ein
+func trackStep(ctx context.Context, step, detail, model string, size int) func(err error) {
+	start := time.Now()
+	eta, _ := durations.predict(step, model, size)
+	reportProgress(ctx, Progress{Step: step, Detail: detail, Model: model, Total: 1, ETASeconds: int(eta.Seconds())})
+	return func(err error) {
+		if err == nil {
+			durations.observe(step, model, size, time.Since(start))
+		}
+		reportProgress(ctx, Progress{Step: step, Detail: detail, Model: model, Done: 1, Total: 1})
+	}
+}
+
+// FormatETA beschreibt eine geschätzte Dauer für Logs ("ca. 7 Min. mit qwen2.5:7b")
+func FormatETA(d time.Duration, model string) string {
+	text := fmt.Sprintf("ca. %d Sek.", max(int(d.Seconds()), 1))
+	if d >= time.Minute {
+		text = fmt.Sprintf("ca. %d Min.", int(d.Round(time.Minute).Minutes()))
+	}
+	if model != "" {
+		text += " mit " + model
+	}
+	return text
+}
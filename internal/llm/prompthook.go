@@ -0,0 +1,89 @@
+package llm
+
+import "context"
+
+// PromptHook ändert Prompt und Systemanweisung, bevor sie an das Backend gehen
+type PromptHook func(ctx context.Context, prompt, system string) (string, string)
+
+// PromptHookProvider schaltet einen PromptHook (z.B. die before_prompt-Hooks aus der
+// Konfiguration) vor ein Backend. Alle anderen Methoden gehen unverändert durch.
+type PromptHookProvider struct {
+	Provider
+	hook PromptHook
+}
+
+// NewPromptHookProvider legt den Hook um einen Provider
+func NewPromptHookProvider(provider Provider, hook PromptHook) *PromptHookProvider {
+	return &PromptHookProvider{Provider: provider, hook: hook}
+}
+
+// withSystem liefert die Optionen mit geänderter Systemanweisung, ohne die des Aufrufers zu verändern
+func withSystem(options *GenerateOptions, system string) *GenerateOptions {
+	var changed GenerateOptions
+	if options != nil {
+		changed = *options
+	}
+	changed.System = system
+	return &changed
+}
+
+func (p *PromptHookProvider) Generate(ctx context.Context, prompt string, options *GenerateOptions) (*GenerateResponse, error) {
+	system := ""
+	if options != nil {
+		system = options.System
+	}
+	prompt, system = p.hook(ctx, prompt, system)
+	return p.Provider.Generate(ctx, prompt, withSystem(options, system))
+}
+
+func (p *PromptHookProvider) GenerateStream(ctx context.Context, prompt string, options *GenerateOptions) (<-chan StreamChunk, error) {
+	system := ""
+	if options != nil {
+		system = options.System
+	}
+	prompt, system = p.hook(ctx, prompt, system)
+	return p.Provider.GenerateStream(ctx, prompt, withSystem(options, system))
+}
+
+// Chat gibt dem Hook die letzte Nachricht des Nutzers als Prompt und die Systemnachricht
+func (p *PromptHookProvider) Chat(ctx context.Context, messages []ChatMessage, options *GenerateOptions) (*GenerateResponse, error) {
+	userIdx, systemIdx := -1, -1
+	for i, m := range messages {
+		switch m.Role {
+		case "user":
+			userIdx = i
+		case "system":
+			if systemIdx < 0 {
+				systemIdx = i
+			}
+		}
+	}
+	if userIdx < 0 {
+		return p.Provider.Chat(ctx, messages, options)
+	}
+
+	system := ""
+	if systemIdx >= 0 {
+		system = messages[systemIdx].Content
+	}
+	prompt, system := p.hook(ctx, messages[userIdx].Content, system)
+
+	changed := append([]ChatMessage(nil), messages...)
+	changed[userIdx].Content = prompt
+	switch {
+	case systemIdx >= 0:
+		changed[systemIdx].Content = system
+	case system != "":
+		changed = append([]ChatMessage{{Role: "system", Content: system}}, changed...)
+	}
+	return p.Provider.Chat(ctx, changed, options)
+}
+
+// Embed reicht Embeddings an das Backend weiter (sonst ginge die Fähigkeit durch den Hook verloren)
+func (p *PromptHookProvider) Embed(ctx context.Context, model string, texts []string) ([][]float64, error) {
+	embedder, ok := p.Provider.(Embedder)
+	if !ok {
+		return nil, ErrNoEmbeddings
+	}
+	return embedder.Embed(ctx, model, texts)
+}
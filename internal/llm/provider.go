@@ -3,26 +3,17 @@ package llm
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
-// ollamaSemaphore limitiert gleichzeitige Ollama-Anfragen (verhindert Speicherüberlauf)
-var ollamaSemaphore = make(chan struct{}, 1) // Nur 1 gleichzeitige Anfrage
-
-func acquireOllama() {
-	ollamaSemaphore <- struct{}{}
-}
-
-func releaseOllama() {
-	<-ollamaSemaphore
-}
-
 // Provider definiert das Interface für LLM-Backends
 type Provider interface {
 	// Generate erzeugt eine Antwort basierend auf dem Prompt
@@ -48,6 +39,18 @@ type Provider interface {
 
 	// GetCurrentModel gibt das aktuelle Modell zurück
 	GetCurrentModel() string
+
+	// Capabilities beschreibt, was das Backend mit dem aktuellen Modell kann
+	Capabilities() Capabilities
+}
+
+// Capabilities beschreibt Fähigkeiten eines Backends, damit Prompts und
+// Textmengen angepasst werden können (0/false = unbekannt bzw. nicht unterstützt)
+type Capabilities struct {
+	MaxContext int  `json:"max_context"` // Kontextfenster in Tokens
+	JSONMode   bool `json:"json_mode"`   // Ausgabe kann auf gültiges JSON festgelegt werden
+	Vision     bool `json:"vision"`      // Bilder als Eingabe
+	Embeddings bool `json:"embeddings"`  // Embeddings verfügbar
 }
 
 // GenerateOptions enthält optionale Parameter für die Generierung
@@ -58,6 +61,10 @@ type GenerateOptions struct {
 	TopP        float64 `json:"top_p,omitempty"`
 	TopK        int     `json:"top_k,omitempty"`
 	System      string  `json:"system,omitempty"`
+	JSON        bool    `json:"json,omitempty"` // Antwort als JSON erzwingen (nur bei Capabilities().JSONMode)
+	Seed        int     `json:"seed,omitempty"` // fester Seed für reproduzierbare Ausgaben (0 = zufällig)
+	// Bilder (JPEG/PNG) zum Prompt, nur bei Generate und nur für Modelle mit Capabilities().Vision
+	Images [][]byte `json:"-"`
 }
 
 // GenerateResponse enthält die Antwort des LLM
@@ -82,6 +89,14 @@ type ModelInfo struct {
 	Size       int64     `json:"size"`
 }
 
+// RunningModel beschreibt ein aktuell von Ollama geladenes Modell
+type RunningModel struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`      // belegter Speicher insgesamt
+	SizeVRAM  int64     `json:"size_vram"` // davon im Grafikspeicher
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
 // StreamChunk repräsentiert einen Chunk im Streaming-Modus
 type StreamChunk struct {
 	Content string `json:"content"`
@@ -92,24 +107,99 @@ type StreamChunk struct {
 // OllamaProvider implementiert den Provider für Ollama
 type OllamaProvider struct {
 	baseURL      string
+	modelMu      sync.RWMutex // schützt defaultModel (SetModel läuft parallel zu Anfragen)
 	defaultModel string
 	client       *http.Client
+	limiter      *limiter // begrenzt gleichzeitige Anfragen (verhindert Speicherüberlauf)
+	keepAlive    string   // wie lange Ollama das Modell nach der letzten Anfrage geladen hält
+
+	capsMu sync.Mutex
+	caps   map[string]capsEntry // Fähigkeiten je Modell (aus /api/show)
+}
+
+// capsEntry ist ein Cache-Eintrag der Modell-Fähigkeiten
+type capsEntry struct {
+	caps    Capabilities
+	expires time.Time // nur beim Ersatzwert nach einem Fehler gesetzt, danach neu abfragen
 }
 
+// capsRetryAfter: so lange gilt der Ersatzwert, wenn /api/show nicht antwortet
+// (sonst zahlt bei ausgefallenem Ollama jede Anfrage einen weiteren blockierenden Aufruf)
+const capsRetryAfter = 30 * time.Second
+
 // SetModel ändert das Standard-Modell
 func (o *OllamaProvider) SetModel(model string) {
 	if model != "" {
+		o.modelMu.Lock()
 		o.defaultModel = model
+		o.modelMu.Unlock()
 	}
 }
 
 // GetCurrentModel gibt das aktuelle Modell zurück
 func (o *OllamaProvider) GetCurrentModel() string {
+	o.modelMu.RLock()
+	defer o.modelMu.RUnlock()
 	return o.defaultModel
 }
 
-// NewOllamaProvider erstellt einen neuen Ollama-Provider
-func NewOllamaProvider(baseURL, defaultModel string) *OllamaProvider {
+// SetKeepAlive legt fest, wie lange Ollama das Modell nach einer Anfrage im Speicher hält
+// (z.B. "30m", "0" = sofort entladen, "-1" = dauerhaft; leer = Ollama-Standard)
+func (o *OllamaProvider) SetKeepAlive(keepAlive string) {
+	o.keepAlive = keepAlive
+}
+
+// applyKeepAlive ergänzt den Request-Body um keep_alive, falls konfiguriert
+func (o *OllamaProvider) applyKeepAlive(reqBody map[string]interface{}) {
+	if o.keepAlive != "" {
+		reqBody["keep_alive"] = o.keepAlive
+	}
+}
+
+// Warmup lädt ein Modell vorab in den Speicher, damit die erste echte Anfrage
+// nicht auf das Laden warten muss (leeres Modell = Standard-Modell)
+func (o *OllamaProvider) Warmup(ctx context.Context, model string) error {
+	if model == "" {
+		model = o.GetCurrentModel()
+	}
+
+	// Eine Anfrage ohne Prompt lädt bei Ollama nur das Modell
+	reqBody := map[string]interface{}{
+		"model":  model,
+		"stream": false,
+	}
+	o.applyKeepAlive(reqBody)
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/generate", bytes.NewReader(jsonData))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ollama-warmup fehlgeschlagen: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama-fehler (%d): %s", resp.StatusCode, string(body))
+	}
+
+	log.Printf("   [Ollama] 🔥 Modell %s geladen nach %v", model, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// NewOllamaProvider erstellt einen neuen Ollama-Provider.
+// maxConcurrent begrenzt die gleichzeitigen Anfragen (< 1 = 1).
+func NewOllamaProvider(baseURL, defaultModel string, maxConcurrent int) *OllamaProvider {
 	if baseURL == "" {
 		baseURL = "http://localhost:11434"
 	}
@@ -123,12 +213,13 @@ func NewOllamaProvider(baseURL, defaultModel string) *OllamaProvider {
 		client: &http.Client{
 			Timeout: 15 * time.Minute, // Erhöht für große Prompts
 		},
+		limiter: newLimiter(maxConcurrent),
 	}
 
 	// Prüfe ob das Modell existiert, sonst erstes verfügbares nehmen
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	
+
 	models, err := provider.GetModels(ctx)
 	if err == nil && len(models) > 0 {
 		found := false
@@ -147,6 +238,119 @@ func NewOllamaProvider(baseURL, defaultModel string) *OllamaProvider {
 	return provider
 }
 
+// QueueStats gibt die Auslastung der Anfrage-Warteschlange zurück
+func (o *OllamaProvider) QueueStats() QueueStats {
+	return o.limiter.stats()
+}
+
+// Capabilities liest Kontextlänge und Fähigkeiten des aktuellen Modells aus /api/show (gecacht)
+func (o *OllamaProvider) Capabilities() Capabilities {
+	model := o.GetCurrentModel()
+
+	o.capsMu.Lock()
+	if entry, ok := o.caps[model]; ok && (entry.expires.IsZero() || time.Now().Before(entry.expires)) {
+		o.capsMu.Unlock()
+		return entry.caps
+	}
+	o.capsMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	entry := capsEntry{}
+	caps, err := o.showModel(ctx, model)
+	if err != nil {
+		log.Printf("   [Ollama] ⚠️ Fähigkeiten von %s nicht abrufbar: %v", model, err)
+		// Format "json" unterstützt Ollama für alle Modelle
+		caps = Capabilities{JSONMode: true}
+		entry.expires = time.Now().Add(capsRetryAfter)
+	}
+	entry.caps = caps
+
+	o.capsMu.Lock()
+	if o.caps == nil {
+		o.caps = make(map[string]capsEntry)
+	}
+	o.caps[model] = entry
+	o.capsMu.Unlock()
+
+	return caps
+}
+
+func (o *OllamaProvider) showModel(ctx context.Context, model string) (Capabilities, error) {
+	caps := Capabilities{JSONMode: true}
+
+	jsonData, err := json.Marshal(map[string]string{"model": model})
+	if err != nil {
+		return caps, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/show", bytes.NewReader(jsonData))
+	if err != nil {
+		return caps, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return caps, fmt.Errorf("ollama nicht erreichbar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return caps, fmt.Errorf("ollama-fehler (%d): %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Parameters    string                 `json:"parameters"`
+		ModelInfo     map[string]interface{} `json:"model_info"`
+		ProjectorInfo map[string]interface{} `json:"projector_info"`
+		Capabilities  []string               `json:"capabilities"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return caps, err
+	}
+
+	// Kontextlänge des Modells, z.B. "qwen2.context_length"
+	for key, value := range result.ModelInfo {
+		if strings.HasSuffix(key, ".context_length") {
+			if n, ok := value.(float64); ok {
+				caps.MaxContext = int(n)
+			}
+		}
+	}
+	// Ein im Modelfile gesetztes num_ctx begrenzt das tatsächlich genutzte Fenster
+	for _, line := range strings.Split(result.Parameters, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "num_ctx" {
+			var n int
+			if _, err := fmt.Sscanf(fields[1], "%d", &n); err == nil && n > 0 {
+				caps.MaxContext = n
+			}
+		}
+	}
+
+	// Neuere Ollama-Versionen melden die Fähigkeiten direkt
+	for _, c := range result.Capabilities {
+		switch c {
+		case "vision":
+			caps.Vision = true
+		case "embedding":
+			caps.Embeddings = true
+		}
+	}
+	if len(result.ProjectorInfo) > 0 {
+		caps.Vision = true
+	}
+	if strings.Contains(strings.ToLower(model), "embed") {
+		caps.Embeddings = true
+	}
+
+	return caps, nil
+}
+
 func (o *OllamaProvider) GetName() string {
 	return "Ollama"
 }
@@ -202,16 +406,47 @@ func (o *OllamaProvider) GetModels(ctx context.Context) ([]ModelInfo, error) {
 	return models, nil
 }
 
+// GetRunningModels gibt die aktuell geladenen Modelle inkl. VRAM-Belegung zurück (/api/ps)
+func (o *OllamaProvider) GetRunningModels(ctx context.Context) ([]RunningModel, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", o.baseURL+"/api/ps", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ollama nicht erreichbar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ollama-fehler (%d): %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Models []RunningModel `json:"models"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result.Models, nil
+}
+
 func (o *OllamaProvider) Generate(ctx context.Context, prompt string, options *GenerateOptions) (*GenerateResponse, error) {
-	// Semaphore: Nur eine Anfrage gleichzeitig an Ollama
-	acquireOllama()
-	defer releaseOllama()
-	
+	// Nur begrenzt viele Anfragen gleichzeitig an Ollama, Reihenfolge nach Priorität
+	if err := o.limiter.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer o.limiter.release()
+
 	return o.generateWithRetry(ctx, prompt, options, 3) // Max 3 Versuche
 }
 
 func (o *OllamaProvider) generateWithRetry(ctx context.Context, prompt string, options *GenerateOptions, maxRetries int) (*GenerateResponse, error) {
-	model := o.defaultModel
+	model := o.GetCurrentModel()
 	if options != nil && options.Model != "" {
 		model = options.Model
 	}
@@ -222,27 +457,27 @@ func (o *OllamaProvider) generateWithRetry(ctx context.Context, prompt string, o
 			log.Printf("   [Ollama] 🔄 Retry %d/%d...", attempt, maxRetries)
 			time.Sleep(time.Duration(attempt) * 2 * time.Second) // Exponential backoff
 		}
-		
+
 		resp, err := o.doGenerate(ctx, prompt, model, options)
 		if err == nil {
 			return resp, nil
 		}
-		
+
 		lastErr = err
-		
+
 		// Bei "runner terminated" warte und versuche erneut
 		if strings.Contains(err.Error(), "terminated") || strings.Contains(err.Error(), "500") {
 			log.Printf("   [Ollama] ⚠️ Ollama-Prozess abgestürzt, warte 5s...")
 			time.Sleep(5 * time.Second)
 			continue
 		}
-		
+
 		// Bei Context-Abbruch sofort aufhören
 		if ctx.Err() != nil {
 			return nil, ctx.Err()
 		}
 	}
-	
+
 	return nil, lastErr
 }
 
@@ -256,16 +491,25 @@ func (o *OllamaProvider) doGenerate(ctx context.Context, prompt string, model st
 		"prompt": prompt,
 		"stream": false,
 	}
+	o.applyKeepAlive(reqBody)
 
 	if options != nil {
-		if options.Temperature > 0 {
-			reqBody["options"] = map[string]interface{}{
-				"temperature": options.Temperature,
-			}
+		if opts := ollamaOptions(options); opts != nil {
+			reqBody["options"] = opts
 		}
 		if options.System != "" {
 			reqBody["system"] = options.System
 		}
+		if options.JSON {
+			reqBody["format"] = "json"
+		}
+		if len(options.Images) > 0 {
+			images := make([]string, len(options.Images))
+			for i, img := range options.Images {
+				images[i] = base64.StdEncoding.EncodeToString(img)
+			}
+			reqBody["images"] = images
+		}
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -319,8 +563,27 @@ func (o *OllamaProvider) doGenerate(ctx context.Context, prompt string, model st
 	}, nil
 }
 
+// ollamaOptions übersetzt die Sampling-Parameter in Ollamas "options" (nil = Modell-Standard)
+func ollamaOptions(options *GenerateOptions) map[string]interface{} {
+	if options == nil {
+		return nil
+	}
+
+	opts := make(map[string]interface{})
+	if options.Temperature > 0 {
+		opts["temperature"] = options.Temperature
+	}
+	if options.Seed != 0 {
+		opts["seed"] = options.Seed
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+	return opts
+}
+
 func (o *OllamaProvider) GenerateStream(ctx context.Context, prompt string, options *GenerateOptions) (<-chan StreamChunk, error) {
-	model := o.defaultModel
+	model := o.GetCurrentModel()
 	if options != nil && options.Model != "" {
 		model = options.Model
 	}
@@ -330,10 +593,14 @@ func (o *OllamaProvider) GenerateStream(ctx context.Context, prompt string, opti
 		"prompt": prompt,
 		"stream": true,
 	}
+	o.applyKeepAlive(reqBody)
 
 	if options != nil && options.System != "" {
 		reqBody["system"] = options.System
 	}
+	if opts := ollamaOptions(options); opts != nil {
+		reqBody["options"] = opts
+	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
@@ -346,14 +613,21 @@ func (o *OllamaProvider) GenerateStream(ctx context.Context, prompt string, opti
 	}
 	req.Header.Set("Content-Type", "application/json")
 
+	// Platz bleibt belegt, bis der Stream vollständig gelesen ist
+	if err := o.limiter.acquire(ctx); err != nil {
+		return nil, err
+	}
+
 	resp, err := o.client.Do(req)
 	if err != nil {
+		o.limiter.release()
 		return nil, err
 	}
 
 	ch := make(chan StreamChunk, 100)
 
 	go func() {
+		defer o.limiter.release()
 		defer close(ch)
 		defer resp.Body.Close()
 
@@ -364,16 +638,21 @@ func (o *OllamaProvider) GenerateStream(ctx context.Context, prompt string, opti
 				Done     bool   `json:"done"`
 			}
 
+			// Bei Abbruch (ctx) endet das Lesen mit einem Fehler; liest niemand mehr, nicht blockieren
 			if err := decoder.Decode(&chunk); err != nil {
 				if err != io.EOF {
-					ch <- StreamChunk{Error: err}
+					select {
+					case ch <- StreamChunk{Error: err}:
+					case <-ctx.Done():
+					}
 				}
 				return
 			}
 
-			ch <- StreamChunk{
-				Content: chunk.Response,
-				Done:    chunk.Done,
+			select {
+			case ch <- StreamChunk{Content: chunk.Response, Done: chunk.Done}:
+			case <-ctx.Done():
+				return
 			}
 
 			if chunk.Done {
@@ -386,7 +665,7 @@ func (o *OllamaProvider) GenerateStream(ctx context.Context, prompt string, opti
 }
 
 func (o *OllamaProvider) Chat(ctx context.Context, messages []ChatMessage, options *GenerateOptions) (*GenerateResponse, error) {
-	model := o.defaultModel
+	model := o.GetCurrentModel()
 	if options != nil && options.Model != "" {
 		model = options.Model
 	}
@@ -396,6 +675,13 @@ func (o *OllamaProvider) Chat(ctx context.Context, messages []ChatMessage, optio
 		"messages": messages,
 		"stream":   false,
 	}
+	o.applyKeepAlive(reqBody)
+	if options != nil && options.JSON {
+		reqBody["format"] = "json"
+	}
+	if opts := ollamaOptions(options); opts != nil {
+		reqBody["options"] = opts
+	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
@@ -408,6 +694,11 @@ func (o *OllamaProvider) Chat(ctx context.Context, messages []ChatMessage, optio
 	}
 	req.Header.Set("Content-Type", "application/json")
 
+	if err := o.limiter.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer o.limiter.release()
+
 	resp, err := o.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("ollama-chat fehlgeschlagen: %w", err)
@@ -0,0 +1,130 @@
+package llm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// memoryOverhead schlägt KV-Cache und Laufzeitpuffer auf die Modellgröße auf
+const memoryOverhead = 1.2
+
+// ModelFit bewertet, ob ein Modell in den verfügbaren Grafikspeicher passt
+type ModelFit struct {
+	Name      string `json:"name"`
+	Size      int64  `json:"size"`
+	Required  int64  `json:"required"` // geschätzter Speicherbedarf im Betrieb
+	FitsVRAM  bool   `json:"fits_vram"`
+	Loaded    bool   `json:"loaded"`
+	Offloaded int64  `json:"offloaded,omitempty"` // aktuell in den Arbeitsspeicher ausgelagert
+}
+
+// ModelRecommendation schlägt ein Modellpaar für Analyse und Chat vor
+type ModelRecommendation struct {
+	AvailableVRAM int64      `json:"available_vram"`
+	VRAMEstimated bool       `json:"vram_estimated"` // aus den geladenen Modellen geschätzt
+	AnalysisModel string     `json:"analysis_model,omitempty"`
+	ChatModel     string     `json:"chat_model,omitempty"`
+	CurrentModel  string     `json:"current_model"`
+	Models        []ModelFit `json:"models"`
+	Warnings      []string   `json:"warnings,omitempty"`
+}
+
+// RecommendModels wählt anhand der Modellgrößen und des Grafikspeichers ein Modellpaar:
+// das größte passende Modell für die Analyse und ein kleines, schnelles für den Chat,
+// die nach Möglichkeit gleichzeitig geladen bleiben können.
+// vram <= 0 bedeutet unbekannt; dann wird aus den geladenen Modellen geschätzt.
+func RecommendModels(available []ModelInfo, running []RunningModel, vram int64, current string) *ModelRecommendation {
+	rec := &ModelRecommendation{CurrentModel: current}
+
+	loaded := make(map[string]RunningModel)
+	for _, m := range running {
+		loaded[m.Name] = m
+	}
+
+	if vram <= 0 {
+		vram = estimateVRAM(running)
+		rec.VRAMEstimated = true
+	}
+	rec.AvailableVRAM = vram
+
+	var fitting []ModelFit
+	for _, m := range available {
+		// Embedding-Modelle eignen sich weder für Analyse noch Chat
+		if strings.Contains(strings.ToLower(m.Name), "embed") {
+			continue
+		}
+
+		fit := ModelFit{
+			Name:     m.Name,
+			Size:     m.Size,
+			Required: int64(float64(m.Size) * memoryOverhead),
+		}
+		fit.FitsVRAM = vram > 0 && fit.Required <= vram
+		if r, ok := loaded[m.Name]; ok {
+			fit.Loaded = true
+			if r.Size > r.SizeVRAM {
+				fit.Offloaded = r.Size - r.SizeVRAM
+				rec.Warnings = append(rec.Warnings, fmt.Sprintf("Modell %s liegt aktuell zu %.0f %% im Arbeitsspeicher (langsam)",
+					m.Name, float64(fit.Offloaded)/float64(r.Size)*100))
+			}
+		}
+		if fit.FitsVRAM {
+			fitting = append(fitting, fit)
+		}
+		rec.Models = append(rec.Models, fit)
+
+		if m.Name == current && vram > 0 && !fit.FitsVRAM {
+			rec.Warnings = append(rec.Warnings, fmt.Sprintf("Aktuelles Modell %s braucht ca. %s, verfügbar sind %s - es wird ausgelagert",
+				m.Name, formatBytes(fit.Required), formatBytes(vram)))
+		}
+	}
+
+	if vram <= 0 {
+		rec.Warnings = append(rec.Warnings, "Grafikspeicher unbekannt (kein Modell geladen) - bitte vram_gb angeben oder ein Modell laden")
+		return rec
+	}
+	if len(fitting) == 0 {
+		rec.Warnings = append(rec.Warnings, "Kein installiertes Modell passt vollständig in den Grafikspeicher")
+		return rec
+	}
+
+	// Größte Modelle zuerst
+	sort.Slice(fitting, func(i, j int) bool { return fitting[i].Size > fitting[j].Size })
+	chat := fitting[len(fitting)-1]
+
+	// Größtes Analyse-Modell, das zusammen mit dem Chat-Modell in den Speicher passt
+	for _, f := range fitting {
+		if f.Name == chat.Name || f.Required+chat.Required <= vram {
+			rec.AnalysisModel = f.Name
+			break
+		}
+	}
+	// Passt kein Paar zusammen, lieber ein Modell für beides als ständiges Umladen
+	if rec.AnalysisModel == chat.Name || rec.AnalysisModel == "" {
+		rec.AnalysisModel = fitting[0].Name
+		rec.ChatModel = fitting[0].Name
+	} else {
+		rec.ChatModel = chat.Name
+	}
+
+	if rec.VRAMEstimated {
+		rec.Warnings = append(rec.Warnings, "Grafikspeicher aus den geladenen Modellen geschätzt - für genauere Empfehlungen vram_gb angeben")
+	}
+
+	return rec
+}
+
+// estimateVRAM schätzt den Grafikspeicher aus der Belegung der geladenen Modelle.
+// Ist ein Modell teilweise ausgelagert, ist der Speicher voll; sonst ist es eine Untergrenze.
+func estimateVRAM(running []RunningModel) int64 {
+	var total int64
+	for _, m := range running {
+		total += m.SizeVRAM
+	}
+	return total
+}
+
+func formatBytes(b int64) string {
+	return fmt.Sprintf("%.1f GB", float64(b)/(1<<30))
+}
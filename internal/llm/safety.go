@@ -0,0 +1,122 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"lernplattform/internal/models"
+)
+
+// Modi des Inhaltsfilters
+const (
+	SafetyOff   = ""      // kein Filter
+	SafetyRules = "rules" // nur Regeln (schnell, ohne LLM)
+	SafetyLLM   = "llm"   // Regeln plus Einstufung durch das lokale Modell
+)
+
+// SafetyResult ist das Ergebnis einer Inhaltsprüfung
+type SafetyResult struct {
+	Safe   bool   `json:"safe"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// safetyRules erkennt eindeutig ungeeignete Inhalte. Bewusst eng gefasst, damit
+// Fachinhalte (Biologie, Geschichte, Chemie) nicht fälschlich blockiert werden.
+var safetyRules = []struct {
+	reason  string
+	pattern *regexp.Regexp
+}{
+	{"sexuelle Inhalte", regexp.MustCompile(`(?i)\b(porn\w*|hardcore|nacktbilder|sexting)\b`)},
+	{"Anleitung zur Selbstverletzung", regexp.MustCompile(`(?i)(wie\s+(man\s+sich|du\s+dich)\s+(umbringst|umbringt|selbst\s+verletz\w*|ritz\w*)|how\s+to\s+(kill|hurt)\s+yourself)`)},
+	{"Anleitung zu Waffen oder Sprengstoff", regexp.MustCompile(`(?i)((bombe|sprengsatz|rohrbombe|molotow\w*)\s+(selbst\s+)?(bauen|basteln|herstellen)|how\s+to\s+(build|make)\s+a\s+(bomb|pipe\s*bomb))`)},
+	{"Drogenbeschaffung", regexp.MustCompile(`(?i)(drogen|koks|crystal\s*meth)\s+(kaufen|bestellen|herstellen)`)},
+	{"Beleidigung oder Hassrede", regexp.MustCompile(`(?i)\b(du\s+bist\s+(dumm|behindert|ein\s+idiot)|halt\s+die\s+fresse|untermensch\w*)\b`)},
+}
+
+// SafetyFilter prüft generierte Inhalte vor dem Speichern (z.B. für Schulen)
+type SafetyFilter struct {
+	mode     string
+	provider Provider
+}
+
+// NewSafetyFilter erstellt einen Inhaltsfilter (mode: "", "rules" oder "llm")
+func NewSafetyFilter(mode string, provider Provider) *SafetyFilter {
+	switch mode {
+	case SafetyOff, SafetyRules, SafetyLLM:
+	default:
+		log.Printf("⚠️  Unbekannter Inhaltsfilter '%s', verwende Regeln", mode)
+		mode = SafetyRules
+	}
+	return &SafetyFilter{mode: mode, provider: provider}
+}
+
+// Enabled gibt an, ob der Filter aktiv ist
+func (f *SafetyFilter) Enabled() bool {
+	return f != nil && f.mode != SafetyOff
+}
+
+// Check prüft einen Text. Im LLM-Modus gilt ein Fehler bei der Einstufung als
+// nicht bestanden, damit im Zweifel nichts Ungeprüftes gespeichert wird.
+func (f *SafetyFilter) Check(ctx context.Context, text string) SafetyResult {
+	if !f.Enabled() {
+		return SafetyResult{Safe: true}
+	}
+
+	for _, rule := range safetyRules {
+		if rule.pattern.MatchString(text) {
+			return SafetyResult{Safe: false, Reason: rule.reason}
+		}
+	}
+
+	if f.mode != SafetyLLM {
+		return SafetyResult{Safe: true}
+	}
+
+	prompt := fmt.Sprintf(`Prüfe, ob der folgende Lerninhalt für Schülerinnen und Schüler unter 18 Jahren geeignet ist.
+Ungeeignet sind: sexuelle Inhalte, verherrlichte Gewalt, Anleitungen zu Selbstverletzung, Waffen oder Drogen, Beleidigungen und Hassrede.
+Sachliche Fachinhalte (z.B. Biologie, Geschichte, Medizin, Chemie) sind geeignet.
+
+Text:
+%s
+
+Antworte NUR im JSON-Format:
+{"safe": true, "reason": ""}`, limitContent(text, 4000))
+
+	resp, err := f.provider.Generate(ctx, prompt, &GenerateOptions{
+		Temperature: 0.1,
+		System:      "Du bist ein Jugendschutz-Prüfer für eine Lernplattform. Antworte nur im JSON-Format.",
+		JSON:        jsonMode(f.provider),
+	})
+	if err != nil {
+		log.Printf("   [Filter] ⚠️ Einstufung fehlgeschlagen: %v", err)
+		return SafetyResult{Safe: false, Reason: "Inhaltsprüfung fehlgeschlagen"}
+	}
+
+	var verdict struct {
+		Safe   *bool  `json:"safe"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &verdict); err != nil || verdict.Safe == nil {
+		excerpt := []rune(resp.Content)
+		log.Printf("   [Filter] ⚠️ Einstufung nicht lesbar: %s", string(excerpt[:min(200, len(excerpt))]))
+		return SafetyResult{Safe: false, Reason: "Inhaltsprüfung fehlgeschlagen"}
+	}
+
+	result := SafetyResult{Safe: *verdict.Safe, Reason: verdict.Reason}
+	if !result.Safe && result.Reason == "" {
+		result.Reason = "vom Modell als ungeeignet eingestuft"
+	}
+	return result
+}
+
+// CheckQuestion prüft Frage, Antwort, Optionen und Hinweise einer Frage gemeinsam
+func (f *SafetyFilter) CheckQuestion(ctx context.Context, q *models.Question) SafetyResult {
+	parts := []string{q.Question, q.ExpectedAnswer}
+	parts = append(parts, q.Options...)
+	parts = append(parts, q.Hints...)
+	return f.Check(ctx, strings.Join(parts, "\n"))
+}
@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -16,6 +17,10 @@ type Tutor struct {
 	provider  Provider
 	agentPool *AgentPool
 	useAgents bool
+	seed      int // fester Seed für Fragengenerierung und Bewertung (0 = zufällig)
+
+	embeddingModel string // Modell für Embeddings (leer = Standard des Backends)
+	visionModel    string // Modell für Bildfragen (leer = aktuelles Modell)
 }
 
 // NewTutor erstellt einen neuen Tutor
@@ -53,8 +58,35 @@ func (t *Tutor) SetAgentMode(enabled bool, fastModel string, numAgents int) {
 	}
 }
 
+// SetSeed legt einen festen Seed für Fragengenerierung und Antwortbewertung fest,
+// damit sich auffällige Ausgaben reproduzieren lassen (0 = zufällig)
+func (t *Tutor) SetSeed(seed int) {
+	t.seed = seed
+}
+
+// EstimateAnalysis schätzt die Dauer einer Dokumentanalyse aus früheren Läufen mit demselben
+// Modell und liefert das Modell mit; 0, solange für das Modell noch keine Messungen vorliegen
+func (t *Tutor) EstimateAnalysis(documents []models.Document) (time.Duration, string) {
+	if t.useAgents && t.agentPool != nil {
+		mainDocs, _ := categorizeDocuments(deduplicateDocuments(documents))
+		estimate, _ := t.agentPool.estimateDocuments(mainDocs)
+		return estimate, t.agentPool.analysisModel()
+	}
+
+	model := t.provider.GetCurrentModel()
+	size := 0
+	for _, doc := range documents {
+		size += len(doc.Content)
+	}
+	estimate, _ := durations.predict("analyze", model, min(size, contentLimit(t.provider, 30000)))
+	return estimate, model
+}
+
 // AnalyzeDocuments analysiert Dokumente und extrahiert Themen
 func (t *Tutor) AnalyzeDocuments(ctx context.Context, documents []models.Document) ([]models.Topic, error) {
+	// Lange Analyse läuft im Hintergrund, Chat hat Vorrang
+	ctx = withDefaultPriority(ctx, PriorityBackground)
+
 	// Verwende Agenten-Modus wenn aktiviert
 	if t.useAgents && t.agentPool != nil {
 		return t.agentPool.AnalyzeDocumentsParallel(ctx, documents)
@@ -96,7 +128,7 @@ func (t *Tutor) AnalyzeDocuments(ctx context.Context, documents []models.Documen
 
 	// Kombiniere Dokumenteninhalte mit striktem Limit
 	var allContent strings.Builder
-	maxTotalChars := 30000 // Max 30k Zeichen gesamt für den Prompt
+	maxTotalChars := contentLimit(t.provider, 30000) // Standard: max 30k Zeichen gesamt für den Prompt
 	charsPerDoc := maxTotalChars / max(len(docsToAnalyze), 1)
 	if charsPerDoc > 8000 {
 		charsPerDoc = 8000
@@ -133,18 +165,26 @@ Antworte NUR im folgenden JSON-Format:
       "name": "Themenname",
       "description": "Kurze Beschreibung des Themas",
       "difficulty": 1-5,
-      "est_minutes": geschätzte Lernzeit in Minuten
+      "est_minutes": geschätzte Lernzeit in Minuten,
+      "objectives": ["3-6 konkrete Lernziele, z.B. Kann X berechnen", "Kann den Unterschied zwischen Y und Z erklären"],
+      "source_document": "Name des Dokuments, aus dem das Thema stammt",
+      "pages": [von, bis]
     }
   ]
 }
 
+Seitenzahlen stehen im Material als "--- Seite N ---". Lass "pages" weg, wenn keine Seiten erkennbar sind.
+
 Materialien:
-%s`, allContent.String())
+%s`, guardMaterial(allContent.String()))
 
+	done := trackStep(ctx, "analyze", "", t.provider.GetCurrentModel(), len(prompt))
 	resp, err := t.provider.Generate(ctx, prompt, &GenerateOptions{
 		Temperature: 0.3,
 		System:      "Du bist ein erfahrener Dozent, der Lernmaterialien analysiert und strukturiert. Antworte immer auf Deutsch und nur im angeforderten JSON-Format.",
+		JSON:        jsonMode(t.provider),
 	})
+	done(err)
 	if err != nil {
 		log.Printf("   [Tutor] ❌ LLM-Fehler: %v", err)
 		return nil, err
@@ -161,6 +201,8 @@ Materialien:
 		return nil, fmt.Errorf("konnte Themen nicht parsen: %w", err)
 	}
 
+	resolveTopicSources(topics, docsToAnalyze)
+
 	log.Printf("   [Tutor] ✓ %d Themen erfolgreich geparst", len(topics))
 	return topics, nil
 }
@@ -180,11 +222,10 @@ func max(a, b int) int {
 }
 
 // CreateStudyPlan erstellt einen Lernplan basierend auf Prüfungsdatum
-func (t *Tutor) CreateStudyPlan(ctx context.Context, topics []models.Topic, examDate time.Time, documentsContent string) (*models.StudyPlan, error) {
-	daysUntilExam := int(time.Until(examDate).Hours() / 24)
-	if daysUntilExam < 1 {
-		daysUntilExam = 1
-	}
+func (t *Tutor) CreateStudyPlan(ctx context.Context, topics []models.Topic, examDate models.Date, documentsContent string) (*models.StudyPlan, error) {
+	ctx = withDefaultPriority(ctx, PriorityBackground)
+
+	daysUntilExam := max(examDate.DaysFrom(time.Now()), 1)
 
 	// Berechne verfügbare Lernzeit
 	totalMinutes := 0
@@ -223,6 +264,73 @@ func (t *Tutor) CreateStudyPlan(ctx context.Context, topics []models.Topic, exam
 
 // ExplainTopic erklärt ein Thema basierend auf den Dokumenten
 func (t *Tutor) ExplainTopic(ctx context.Context, topic *models.Topic, documentContent string) (*models.Explanation, error) {
+	return t.explainTopic(ctx, topic, documentContent, "")
+}
+
+// explanationFeedback übersetzt die vorgegebenen Rückmeldungen in Anweisungen für die neue Erklärung
+var explanationFeedback = map[string]string{
+	"too_abstract":  "Die Erklärung war zu abstrakt. Gehe vom Konkreten aus: erst ein greifbares Beispiel oder Bild, dann die allgemeine Regel.",
+	"more_examples": "Es fehlten Beispiele. Erkläre jeden Schritt mit mindestens einem konkreten Beispiel, gern mit Zahlen oder aus dem Alltag.",
+	"shorter":       "Die Erklärung war zu lang. Beschränke dich auf das Wesentliche: höchstens halb so lang, nur die wichtigsten Begriffe.",
+	"simpler":       "Die Erklärung war zu schwer verständlich. Verwende kürzere Sätze, weniger Fachbegriffe und mehr Zwischenschritte.",
+	"more_detail":   "Die Erklärung war zu oberflächlich. Gehe bei den Zusammenhängen und Begründungen mehr in die Tiefe.",
+	"analogy":       "Erkläre das Thema über eine Analogie aus einem ganz anderen, vertrauten Bereich.",
+}
+
+// IsExplanationFeedback prüft, ob eine vorgegebene Rückmeldung bekannt ist
+func IsExplanationFeedback(key string) bool {
+	_, ok := explanationFeedback[key]
+	return ok
+}
+
+// ExplainTopicDifferently erklärt ein Thema neu, weil die vorherige Erklärung nicht geholfen hat.
+// feedback enthält vorgegebene Rückmeldungen (siehe explanationFeedback), comment eine eigene Anmerkung.
+func (t *Tutor) ExplainTopicDifferently(ctx context.Context, topic *models.Topic, documentContent string, previous *models.Explanation, feedback []string, comment string) (*models.Explanation, error) {
+	var revision strings.Builder
+	revision.WriteString("\nDIESE ERKLÄRUNG IST EIN NEUER ANLAUF. Die bisherige Erklärung hat nicht geholfen:\n")
+	for _, key := range feedback {
+		if instruction, ok := explanationFeedback[key]; ok {
+			revision.WriteString("- " + instruction + "\n")
+		}
+	}
+	if comment != "" {
+		revision.WriteString("- Anmerkung der lernenden Person: " + comment + "\n")
+	}
+	if previous != nil {
+		revision.WriteString("\nBisherige Erklärung (NICHT wiederholen, sondern anders erklären):\n")
+		revision.WriteString(limitContent(previous.Content, 3000))
+		revision.WriteString("\n")
+	}
+	revision.WriteString("\nPasse Aufbau und Länge an diese Rückmeldung an, auch wenn das von der Gliederung unten abweicht.\n")
+
+	return t.explainTopic(ctx, topic, documentContent, revision.String())
+}
+
+// ExplainMisconceptions erklärt ein Thema gezielt gegen wiederkehrende Fehlvorstellungen
+// (aus falschen Antworten und eigenen Erklärungen der lernenden Person)
+func (t *Tutor) ExplainMisconceptions(ctx context.Context, topic *models.Topic, documentContent string, misconceptions []string) (*models.Explanation, error) {
+	var revision strings.Builder
+	revision.WriteString("\nDIESE ERKLÄRUNG RÄUMT MIT FEHLVORSTELLUNGEN AUF. Die lernende Person macht immer wieder diese Fehler:\n")
+	for _, m := range misconceptions {
+		revision.WriteString("- " + m + "\n")
+	}
+	revision.WriteString(`
+Gehe jede Fehlvorstellung einzeln durch (eigene Überschrift je Fehler):
+1. Was die Person vermutlich denkt – wertschätzend, ohne sie bloßzustellen
+2. Warum dieser Gedanke naheliegt
+3. Warum er falsch ist und wie es richtig ist
+4. Ein kurzes Gegenbeispiel oder eine Gegenüberstellung richtig/falsch
+5. Ein Merksatz, der die Verwechslung künftig verhindert
+
+Erkläre nur so viel vom übrigen Thema, wie zum Verständnis der Fehler nötig ist, auch wenn das von der Gliederung unten abweicht.
+`)
+
+	return t.explainTopic(ctx, topic, documentContent, revision.String())
+}
+
+func (t *Tutor) explainTopic(ctx context.Context, topic *models.Topic, documentContent string, revision string) (*models.Explanation, error) {
+	ctx = withDefaultPriority(ctx, PriorityInteractive)
+
 	prompt := fmt.Sprintf(`Du bist ein geduldiger, sehr klar erklärender Tutor.
 Dein Ziel ist es, einer Person mit Lernschwierigkeiten das Thema wirklich verständlich zu machen.
 
@@ -234,7 +342,7 @@ Beschreibung: %s
 
 Material (nutze es als Hauptquelle, aber erkläre bei Bedarf Grundlagen):
 %s
-
+%s
 WICHTIG:
 - Schreibe **einfach**, **klar** und **schrittweise**
 - Gehe davon aus, dass die Person wenig Vorwissen hat
@@ -288,13 +396,15 @@ WICHTIG:
 
 > **Merke:** Ein zentraler Satz, den man sich merken sollte
 
-Antworte **nur auf Deutsch**.
-Halte alles **übersichtlich, ruhig und lernfreundlich**.`, topic.Name, topic.Description, limitContent(documentContent, 8000))
+%s
+Halte alles **übersichtlich, ruhig und lernfreundlich**.`, topic.Name, topic.Description, guardMaterial(limitContent(documentContent, contentLimit(t.provider, 8000))), revision, explanationProfileRules(ctx)+outputLanguageRule(ctx))
 
+	done := trackStep(ctx, "explain", topic.Name, t.provider.GetCurrentModel(), len(prompt))
 	resp, err := t.provider.Generate(ctx, prompt, &GenerateOptions{
 		Temperature: 0.5,
 		System:      "Du bist ein geduldiger Tutor für Menschen mit Lernschwierigkeiten. Erkläre alles von Grund auf. Keine Annahmen über Vorwissen. Fachbegriffe immer fett und erklären. Kurze Absätze. Typische Denkfehler aufzeigen.",
 	})
+	done(err)
 	if err != nil {
 		return nil, err
 	}
@@ -308,12 +418,52 @@ Halte alles **übersichtlich, ruhig und lernfreundlich**.`, topic.Name, topic.De
 	return explanation, nil
 }
 
-// GenerateQuestions generiert Fragen zu einem Thema
-func (t *Tutor) GenerateQuestions(ctx context.Context, topic *models.Topic, documentContent string, difficulty int, count int) ([]models.Question, error) {
+// cognitiveLevelDesc beschreibt die unterstützten Stufen der Bloom'schen Taxonomie
+var cognitiveLevelDesc = map[string]string{
+	"remember":   "Erinnern – Fakten, Begriffe und Definitionen wiedergeben",
+	"understand": "Verstehen – Zusammenhänge in eigenen Worten erklären",
+	"apply":      "Anwenden – Wissen auf eine neue, konkrete Situation übertragen",
+	"analyze":    "Analysieren – Bestandteile zerlegen, vergleichen und Ursachen erkennen",
+}
+
+// IsValidCognitiveLevel prüft, ob eine kognitive Stufe unterstützt wird
+func IsValidCognitiveLevel(level string) bool {
+	_, ok := cognitiveLevelDesc[level]
+	return ok
+}
+
+// GenerateQuestions generiert Fragen zu einem Thema.
+// Ist level gesetzt, werden nur Fragen dieser kognitiven Stufe erstellt.
+// questionType ist "open" (Standard), "multiple_choice", "cloze" (Lückentext) oder "ordering" (Reihenfolge).
+func (t *Tutor) GenerateQuestions(ctx context.Context, topic *models.Topic, documentContent string, difficulty int, count int, level string, questionType string) ([]models.Question, error) {
 	if count <= 0 {
 		count = 3 // Standard: 3 Fragen
 	}
 
+	typeInstruction := `Alle Fragen sind offene Fragen ("type": "open").`
+	if questionType == "multiple_choice" {
+		typeInstruction = `Alle Fragen sind Multiple-Choice-Fragen ("type": "multiple_choice"):
+- Genau 4 Optionen in "options", davon genau eine richtig
+- "expected_answer" entspricht WÖRTLICH der richtigen Option
+- Distraktoren sind plausibel, ähnlich lang und enthalten die richtige Antwort NICHT`
+	}
+	if questionType == "cloze" {
+		typeInstruction = clozeTypeInstruction
+	}
+	if questionType == "ordering" {
+		typeInstruction = orderingTypeInstruction
+	}
+
+	levelInstruction := `Ordne jede Frage einer kognitiven Stufe zu ("cognitive_level") und mische die Stufen:
+- "remember": ` + cognitiveLevelDesc["remember"] + `
+- "understand": ` + cognitiveLevelDesc["understand"] + `
+- "apply": ` + cognitiveLevelDesc["apply"] + `
+- "analyze": ` + cognitiveLevelDesc["analyze"]
+	if IsValidCognitiveLevel(level) {
+		levelInstruction = fmt.Sprintf(`Alle Fragen gehören zur kognitiven Stufe "%s": %s
+Setze "cognitive_level" bei jeder Frage auf "%s".`, level, cognitiveLevelDesc[level], level)
+	}
+
 	difficultyDesc := map[int]string{
 		1: "einfache Verständnisfragen",
 		2: "grundlegende Wissensfragen",
@@ -330,6 +480,12 @@ Material:
 Erstelle genau %d Fragen mit Schwierigkeitsgrad %d.
 Schwierigkeitstyp: %s
 
+%s
+
+%s
+
+%s
+
 Antworte NUR im JSON-Format:
 {
   "questions": [
@@ -337,11 +493,16 @@ Antworte NUR im JSON-Format:
       "question": "Die Frage",
       "expected_answer": "Die direkte Antwort",
       "hints": ["Inhaltlicher Denkansatz", "Weiterer inhaltlicher Hinweis"],
-      "type": "open"
+      "type": "open",
+      "options": [],
+      "cognitive_level": "remember|understand|apply|analyze",
+      "source_quote": "Wörtliches Zitat (8-15 Wörter) aus dem Material, auf dem die Frage beruht"
     }
   ]
 }
 
+"source_quote" wird nur intern zum Auffinden der Stelle im Skript genutzt - exakt aus dem Material kopieren, nicht umformulieren.
+
 **KRITISCHE REGELN FÜR FRAGEN:**
 
 1. **EINE Frage = EIN Aspekt:**
@@ -374,12 +535,16 @@ Antworte NUR im JSON-Format:
      * "Siehe Seite 5"
      * "Kapitel 2.3 behandelt das"
      * "Im Skript wird das in Abschnitt 1.3 erklärt"
-     * "Schauen Sie in den Lernmaterialien nach"`, difficultyDesc[difficulty], topic.Name, limitContent(documentContent, 6000), count, difficulty, difficultyDesc[difficulty])
+     * "Schauen Sie in den Lernmaterialien nach"`, difficultyDesc[difficulty], topic.Name, guardMaterial(limitContent(documentContent, contentLimit(t.provider, 6000))), count, difficulty, difficultyDesc[difficulty], levelInstruction, typeInstruction, questionProfileRules(ctx)+outputLanguageRule(ctx))
 
+	done := trackStep(ctx, "questions", topic.Name, t.provider.GetCurrentModel(), len(prompt))
 	resp, err := t.provider.Generate(ctx, prompt, &GenerateOptions{
 		Temperature: 0.4,
 		System:      "Du erstellst Prüfungsfragen. JEDE Frage fragt NUR EINEN Aspekt ab - niemals 'X und Y'. Hinweise und Antworten sind IMMER inhaltlich konkret, NIEMALS mit Seitenverweisen oder Kapitelangaben. JSON-Format.",
+		JSON:        jsonMode(t.provider),
+		Seed:        t.seed,
 	})
+	done(err)
 	if err != nil {
 		return nil, err
 	}
@@ -389,33 +554,24 @@ Antworte NUR im JSON-Format:
 		return nil, err
 	}
 
-	return questions, nil
-}
-
-// EvaluateAnswer bewertet eine Antwort des Studenten
-func (t *Tutor) EvaluateAnswer(ctx context.Context, question *models.Question, userAnswer string, documentContent string) (bool, string, error) {
-	// Leere oder zu kurze Antworten sofort als falsch werten
-	if len(strings.TrimSpace(userAnswer)) < 3 {
-		return false, "💡 Du hast keine richtige Antwort eingegeben. Versuch es nochmal!", nil
+	// Gewünschte Stufe erzwingen, falls das Modell abweicht
+	if IsValidCognitiveLevel(level) {
+		for i := range questions {
+			questions[i].CognitiveLevel = level
+		}
 	}
 
-	prompt := fmt.Sprintf(`Bewerte diese Antwort FAIR aber nicht zu großzügig:
-
-Frage: %s
-Erwartete Kernpunkte: %s
-Antwort des Studenten: %s
+	// Antwortoptionen prüfen und fehlerhafte Fragen neu generieren
+	questions = t.ensureDistractorQuality(ctx, topic, questions)
 
-Antworte im JSON-Format:
-{
-  "is_correct": true/false,
-  "feedback": "Kurzes Feedback",
-  "score": 0-100
+	return questions, nil
 }
 
-**BEWERTUNGSREGELN:**
+// answerEvaluationRules sind die gemeinsamen Bewertungsregeln für offene Antworten
+const answerEvaluationRules = `**BEWERTUNGSREGELN:**
 
 1. **is_correct = TRUE wenn:**
-   - Mindestens 70-80%% der Kernpunkte inhaltlich genannt wurden
+   - Mindestens 70-80% der Kernpunkte inhaltlich genannt wurden
    - Tippfehler vorhanden sind ("Diputive" statt "Dispositive")
    - Synonyme verwendet werden
    - Die Formulierung anders aber inhaltlich korrekt ist
@@ -438,33 +594,477 @@ BEISPIELE für verschiedene Fächer:
 - Formel richtig aber andere Variablennamen -> TRUE
 - "keine", "weiß nicht", "k.A." -> FALSE
 - Nur ein Wort ohne Kontext (zu vage) -> FALSE
-- Komplett falsches Thema -> FALSE`, question.Question, question.ExpectedAnswer, userAnswer)
+- Komplett falsches Thema -> FALSE`
+
+// errorTypeRules beschreibt die Fehlerarten, nach denen falsche Antworten klassifiziert werden
+const errorTypeRules = `FEHLERART (nur bei is_correct = false, sonst ""):
+- "concept_confusion": Begriffe oder Konzepte verwechselt (z.B. Mitose statt Meiose beschrieben)
+- "missing_term": Ansatz stimmt, aber ein wichtiger Fachbegriff oder Kernpunkt fehlt
+- "calculation_slip": Rechen-, Vorzeichen- oder Umformungsfehler bei richtigem Ansatz
+- "misread_question": An der Frage vorbei geantwortet, z.B. Ursache statt Wirkung erklärt`
+
+// normalizeErrorType akzeptiert nur die bekannten Fehlerarten und nur bei falschen Antworten
+func normalizeErrorType(errorType string, isCorrect bool) string {
+	errorType = strings.ToLower(strings.TrimSpace(errorType))
+	if isCorrect {
+		return ""
+	}
+	for _, known := range models.ErrorTypes {
+		if errorType == known {
+			return errorType
+		}
+	}
+	return ""
+}
+
+// NeedsEvaluation ist false, wenn eine Antwort ohne LLM bewertet werden kann
+// (Multiple Choice, Lückentext, Reihenfolge oder leere bzw. zu kurze Antworten)
+func NeedsEvaluation(question *models.Question, userAnswer string) bool {
+	if (question.Type == "multiple_choice" && len(question.Options) > 0) || question.Type == "cloze" || question.Type == "ordering" {
+		return false
+	}
+	return len(strings.TrimSpace(userAnswer)) >= 3
+}
+
+// EvaluateAnswer bewertet eine Antwort des Studenten; falsche Antworten bekommen eine Fehlerart
+func (t *Tutor) EvaluateAnswer(ctx context.Context, question *models.Question, userAnswer string, documentContent string) (AnswerEvaluation, error) {
+	ctx = withDefaultPriority(ctx, PriorityEvaluation)
+
+	// Multiple-Choice braucht kein LLM: die gewählte Option muss der richtigen entsprechen
+	if question.Type == "multiple_choice" && len(question.Options) > 0 {
+		if normalizeOption(userAnswer) == normalizeOption(question.ExpectedAnswer) {
+			return AnswerEvaluation{IsCorrect: true, Feedback: "✅ Richtig!"}, nil
+		}
+		return AnswerEvaluation{Feedback: "💡 Die richtige Antwort ist: " + question.ExpectedAnswer}, nil
+	}
+
+	// Lückentext: Begriff exakt oder per Embedding vergleichen
+	if question.Type == "cloze" {
+		return t.evaluateCloze(ctx, question, userAnswer), nil
+	}
+
+	// Reihenfolge: Schritte mit der gespeicherten Folge vergleichen, Teilpunkte für fast richtige
+	if question.Type == "ordering" {
+		return evaluateOrdering(question, userAnswer), nil
+	}
+
+	// Leere oder zu kurze Antworten sofort als falsch werten
+	if len(strings.TrimSpace(userAnswer)) < 3 {
+		return AnswerEvaluation{Feedback: "💡 Du hast keine richtige Antwort eingegeben. Versuch es nochmal!"}, nil
+	}
+
+	prompt := fmt.Sprintf(`Bewerte diese Antwort FAIR aber nicht zu großzügig:
+
+Frage: %s
+Erwartete Kernpunkte: %s
+Antwort des Studenten: %s
+
+Antworte im JSON-Format:
+{
+  "is_correct": true/false,
+  "feedback": "Kurzes Feedback",
+  "score": 0-100,
+  "error_type": "concept_confusion|missing_term|calculation_slip|misread_question oder leer"
+}
+
+%s
+
+%s`, question.Question, question.ExpectedAnswer, userAnswer, answerEvaluationRules+strictnessRule(ctx), errorTypeRules)
 
 	resp, err := t.provider.Generate(ctx, prompt, &GenerateOptions{
 		Temperature: 0.1,
 		System:      "Du bist ein FAIRER Prüfer. Akzeptiere Antworten wenn die Kernidee stimmt. ABER: Leere, zu kurze oder völlig falsche Antworten sind FALSCH. Tippfehler ignorieren. JSON-Format.",
+		JSON:        jsonMode(t.provider),
+		Seed:        t.seed,
 	})
 	if err != nil {
-		return false, "", err
+		return AnswerEvaluation{}, err
 	}
 
 	var result struct {
 		IsCorrect bool   `json:"is_correct"`
 		Feedback  string `json:"feedback"`
+		ErrorType string `json:"error_type"`
 	}
 
 	// JSON aus Antwort extrahieren
 	jsonStr := extractJSON(resp.Content)
 	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
 		// Fallback: Einfache Heuristik
-		return strings.Contains(strings.ToLower(resp.Content), "richtig"), resp.Content, nil
+		return AnswerEvaluation{IsCorrect: strings.Contains(strings.ToLower(resp.Content), "richtig"), Feedback: resp.Content}, nil
+	}
+
+	return AnswerEvaluation{
+		IsCorrect: result.IsCorrect,
+		Feedback:  result.Feedback,
+		ErrorType: normalizeErrorType(result.ErrorType, result.IsCorrect),
+	}, nil
+}
+
+// AnswerEvaluation ist das Ergebnis einer einzelnen Bewertung
+type AnswerEvaluation struct {
+	IsCorrect bool   `json:"is_correct"`
+	Feedback  string `json:"feedback"`
+	Score     int    `json:"score,omitempty"`      // 0-100, bei gestreamter Bewertung und Reihenfolge-Fragen
+	ErrorType string `json:"error_type,omitempty"` // Fehlerart bei falschen Antworten, siehe models.ErrorTypes
+}
+
+// EvaluateAnswersBatch bewertet mehrere Antworten mit einem einzigen LLM-Aufruf.
+// Multiple-Choice, Lückentexte, Reihenfolgen und leere Antworten werden ohne LLM bewertet.
+func (t *Tutor) EvaluateAnswersBatch(ctx context.Context, questions []*models.Question, answers []string) ([]AnswerEvaluation, error) {
+	ctx = withDefaultPriority(ctx, PriorityEvaluation)
+
+	results := make([]AnswerEvaluation, len(questions))
+	done := make([]bool, len(questions))
+
+	var items strings.Builder
+	pending := 0
+	for i, q := range questions {
+		if !NeedsEvaluation(q, answers[i]) {
+			results[i], _ = t.EvaluateAnswer(ctx, q, answers[i], "")
+			done[i] = true
+			continue
+		}
+		pending++
+		items.WriteString(fmt.Sprintf("\n[%d]\nFrage: %s\nErwartete Kernpunkte: %s\nAntwort des Studenten: %s\n", i, q.Question, q.ExpectedAnswer, answers[i]))
 	}
 
-	return result.IsCorrect, result.Feedback, nil
+	if pending > 0 {
+		prompt := fmt.Sprintf(`Bewerte die folgenden %d Antworten FAIR aber nicht zu großzügig.
+
+REGELN:
+- is_correct = true, wenn 70-80%% der Kernpunkte inhaltlich genannt wurden (Tippfehler und Synonyme sind ok)
+- is_correct = false bei falschen, zu vagen oder inhaltsleeren Antworten ("weiß nicht", nur 1-2 Wörter)
+- Feedback bei TRUE: "✅ Richtig! [kurzes Lob]"
+- Feedback bei FALSE: "💡 [Was konkret fehlt] - Die richtige Antwort ist: [Antwort]"
+- Max 2 Sätze pro Feedback%s
+
+%s
+%s
+Antworte NUR im JSON-Format, mit dem Index aus den eckigen Klammern:
+{"results": [{"index": 0, "is_correct": true, "feedback": "...", "error_type": ""}]}`, pending, strictnessRule(ctx), errorTypeRules, items.String())
+
+		resp, err := t.provider.Generate(ctx, prompt, &GenerateOptions{
+			Temperature: 0.1,
+			System:      "Du bist ein FAIRER Prüfer. Akzeptiere Antworten wenn die Kernidee stimmt. Tippfehler ignorieren. JSON-Format.",
+			JSON:        jsonMode(t.provider),
+			Seed:        t.seed,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var parsed struct {
+			Results []struct {
+				Index int `json:"index"`
+				AnswerEvaluation
+			} `json:"results"`
+		}
+		if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &parsed); err == nil {
+			for _, r := range parsed.Results {
+				if r.Index >= 0 && r.Index < len(results) && !done[r.Index] {
+					results[r.Index] = r.AnswerEvaluation
+					results[r.Index].ErrorType = normalizeErrorType(r.ErrorType, r.IsCorrect)
+					done[r.Index] = true
+				}
+			}
+		}
+	}
+
+	// Vom Modell ausgelassene Antworten einzeln nachbewerten
+	for i := range questions {
+		if done[i] {
+			continue
+		}
+		eval, err := t.EvaluateAnswer(ctx, questions[i], answers[i], "")
+		if err != nil {
+			return nil, err
+		}
+		results[i] = eval
+	}
+
+	return results, nil
+}
+
+// evaluationResultMarker trennt beim Streaming das Feedback vom strukturierten Ergebnis
+const evaluationResultMarker = "ERGEBNIS:"
+
+// EvaluateAnswerStream bewertet eine offene Antwort und gibt das Feedback schon während
+// der Generierung an onFeedback weiter. Das Ergebnis (is_correct, score) steht erst am Ende fest.
+func (t *Tutor) EvaluateAnswerStream(ctx context.Context, question *models.Question, userAnswer string, onFeedback func(string)) (*AnswerEvaluation, error) {
+	ctx = withDefaultPriority(ctx, PriorityEvaluation)
+
+	// Multiple-Choice, Lückentext, Reihenfolge und leere Antworten brauchen kein Streaming
+	if !NeedsEvaluation(question, userAnswer) {
+		eval, err := t.EvaluateAnswer(ctx, question, userAnswer, "")
+		if err != nil {
+			return nil, err
+		}
+		onFeedback(eval.Feedback)
+		return &eval, nil
+	}
+
+	prompt := fmt.Sprintf(`Bewerte diese Antwort FAIR aber nicht zu großzügig:
+
+Frage: %s
+Erwartete Kernpunkte: %s
+Antwort des Studenten: %s
+
+Schreibe ZUERST das Feedback als normalen Text (kein JSON).
+Danach in einer eigenen, letzten Zeile:
+%s {"is_correct": true/false, "score": 0-100, "error_type": "..."}
+
+%s
+
+%s`, question.Question, question.ExpectedAnswer, userAnswer, evaluationResultMarker, answerEvaluationRules+strictnessRule(ctx), errorTypeRules)
+
+	chunks, err := t.provider.GenerateStream(ctx, prompt, &GenerateOptions{
+		Temperature: 0.1,
+		System:      "Du bist ein FAIRER Prüfer. Akzeptiere Antworten wenn die Kernidee stimmt. ABER: Leere, zu kurze oder völlig falsche Antworten sind FALSCH. Tippfehler ignorieren.",
+		Seed:        t.seed,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var full strings.Builder
+	sent := 0 // bereits weitergegebene Bytes
+	for chunk := range chunks {
+		if chunk.Error != nil {
+			return nil, chunk.Error
+		}
+		full.WriteString(chunk.Content)
+
+		// Alles vor der Ergebnis-Zeile weitergeben; ein möglicher Anfang der
+		// Markierung wird zurückgehalten, bis klar ist, ob sie folgt
+		text := full.String()
+		limit := len(text) - markerPrefixLen(text, evaluationResultMarker)
+		if i := strings.Index(text, evaluationResultMarker); i >= 0 {
+			limit = i
+		}
+		if limit > sent {
+			onFeedback(text[sent:limit])
+			sent = limit
+		}
+	}
+
+	return parseStreamedEvaluation(full.String()), nil
+}
+
+// markerPrefixLen liefert die Länge des längsten Textendes, das ein Anfang von marker ist
+func markerPrefixLen(text, marker string) int {
+	for n := min(len(marker)-1, len(text)); n > 0; n-- {
+		if strings.HasSuffix(text, marker[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// parseStreamedEvaluation trennt Feedback und Ergebnis-Zeile einer gestreamten Bewertung
+func parseStreamedEvaluation(text string) *AnswerEvaluation {
+	eval := &AnswerEvaluation{Feedback: strings.TrimSpace(text)}
+
+	i := strings.LastIndex(text, evaluationResultMarker)
+	if i < 0 {
+		// Fallback: Einfache Heuristik wie bei EvaluateAnswer
+		eval.IsCorrect = strings.HasPrefix(eval.Feedback, "✅") || strings.Contains(strings.ToLower(eval.Feedback), "richtig!")
+		return eval
+	}
+
+	eval.Feedback = strings.TrimSpace(text[:i])
+	var result struct {
+		IsCorrect bool   `json:"is_correct"`
+		Score     int    `json:"score"`
+		ErrorType string `json:"error_type"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(text[i+len(evaluationResultMarker):])), &result); err != nil {
+		eval.IsCorrect = strings.HasPrefix(eval.Feedback, "✅")
+		return eval
+	}
+	eval.IsCorrect = result.IsCorrect
+	eval.Score = result.Score
+	eval.ErrorType = normalizeErrorType(result.ErrorType, result.IsCorrect)
+	return eval
+}
+
+// EvaluateTeachBack bewertet eine eigene Erklärung des Themas am Material (Feynman-Methode):
+// Vollständigkeit, Korrektheit, fehlende Punkte und Fehlvorstellungen
+func (t *Tutor) EvaluateTeachBack(ctx context.Context, topic *models.Topic, documentContent string, explanation string) (*models.TeachBack, error) {
+	ctx = withDefaultPriority(ctx, PriorityEvaluation)
+
+	var objectives strings.Builder
+	for _, obj := range topic.Objectives {
+		objectives.WriteString("- " + obj.Text + "\n")
+	}
+	if objectives.Len() == 0 {
+		objectives.WriteString("(keine, leite die Kernpunkte aus dem Material ab)\n")
+	}
+
+	prompt := fmt.Sprintf(`Eine lernende Person erklärt das Thema "%s" in eigenen Worten (Feynman-Methode).
+Prüfe die Erklärung am Material.
+
+Lernziele des Themas:
+%s
+Material:
+%s
+
+Erklärung der Person:
+"""
+%s
+"""
+
+BEWERTUNG:
+- "completeness" (0-100): Wie viele Kernpunkte des Themas sind abgedeckt?
+- "correctness" (0-100): Wie fachlich richtig ist das Gesagte? (Fehlendes zählt hier nicht)
+- "missing_points": wichtige Punkte aus dem Material, die fehlen (konkret, je ein Satz)
+- "misconceptions": falsche oder ungenaue Aussagen, jeweils mit Richtigstellung
+- "strengths": was gut und verständlich erklärt ist
+- "feedback": 2-3 Sätze, ermutigend und konkret: was als Nächstes verbessern?
+- Eigene Worte und Vereinfachungen sind erwünscht, solange sie nicht falsch werden
+
+Antworte NUR im JSON-Format:
+{"completeness": 0, "correctness": 0, "missing_points": [], "misconceptions": [], "strengths": [], "feedback": "..."}`,
+		topic.Name, objectives.String(), guardMaterial(limitContent(documentContent, contentLimit(t.provider, 8000))), explanation)
+
+	resp, err := t.provider.Generate(ctx, prompt, &GenerateOptions{
+		Temperature: 0.2,
+		System:      "Du bist ein fairer, genauer Prüfer und Tutor. Du bewertest Erklärungen streng am Material, aber wohlwollend im Ton. Antworte nur im JSON-Format.",
+		JSON:        jsonMode(t.provider),
+		Seed:        t.seed,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Completeness   int      `json:"completeness"`
+		Correctness    int      `json:"correctness"`
+		MissingPoints  []string `json:"missing_points"`
+		Misconceptions []string `json:"misconceptions"`
+		Strengths      []string `json:"strengths"`
+		Feedback       string   `json:"feedback"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &result); err != nil {
+		return nil, fmt.Errorf("konnte Bewertung nicht parsen: %w", err)
+	}
+
+	clamp := func(v int) int { return max(0, min(v, 100)) }
+	tb := &models.TeachBack{
+		TopicID:        topic.ID,
+		Explanation:    explanation,
+		Completeness:   clamp(result.Completeness),
+		Correctness:    clamp(result.Correctness),
+		MissingPoints:  result.MissingPoints,
+		Misconceptions: result.Misconceptions,
+		Strengths:      result.Strengths,
+		Feedback:       strings.TrimSpace(result.Feedback),
+	}
+	if tb.MissingPoints == nil {
+		tb.MissingPoints = []string{}
+	}
+	if tb.Misconceptions == nil {
+		tb.Misconceptions = []string{}
+	}
+	if tb.Strengths == nil {
+		tb.Strengths = []string{}
+	}
+	return tb, nil
+}
+
+// CompareItem ist ein Gegenstand eines Vergleichs (Thema oder Glossar-Begriff) mit seinem Material
+type CompareItem struct {
+	Name     string
+	Material string
+}
+
+// CompareItems erstellt eine Vergleichstabelle (Kriterien, Gemeinsamkeiten, Unterschiede) zwischen zwei oder mehr Gegenständen
+func (t *Tutor) CompareItems(ctx context.Context, items []CompareItem) (*models.Comparison, error) {
+	ctx = withDefaultPriority(ctx, PriorityInteractive)
+
+	// Das Material teilen sich alle Gegenstände
+	limit := contentLimit(t.provider, 9000) / max(len(items), 1)
+	var material strings.Builder
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = item.Name
+		material.WriteString(fmt.Sprintf("=== %d. %s ===\n%s\n\n", i+1, item.Name, limitContent(item.Material, limit)))
+	}
+
+	prompt := fmt.Sprintf(`Vergleiche die folgenden %d Gegenstände für die Prüfungsvorbereitung ("Vergleichen Sie X und Y"):
+%s
+
+Material:
+%s
+
+REGELN:
+- "rows": 4-8 aussagekräftige Vergleichskriterien; je Kriterium genau %d Werte in der Reihenfolge oben, kurz und präzise
+- "similarities": die wichtigsten Gemeinsamkeiten
+- "differences": die prüfungsrelevanten Unterschiede, je ein Satz
+- "summary": 1-2 Sätze, worin der Kern des Unterschieds liegt
+- Nur Aussagen, die durch das Material gedeckt sind; fehlt etwas, schreibe "k. A."
+
+Antworte NUR im JSON-Format:
+{"rows": [{"criterion": "...", "values": ["...", "..."]}], "similarities": [], "differences": [], "summary": "..."}`,
+		len(items), "- "+strings.Join(names, "\n- "), guardMaterial(material.String()), len(items))
+
+	resp, err := t.provider.Generate(ctx, prompt, &GenerateOptions{
+		Temperature: 0.3,
+		System:      "Du bist ein erfahrener Tutor, der Zusammenhänge und Abgrenzungen klar strukturiert. Antworte nur im JSON-Format.",
+		JSON:        jsonMode(t.provider),
+		Seed:        t.seed,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Rows         []models.ComparisonRow `json:"rows"`
+		Similarities []string               `json:"similarities"`
+		Differences  []string               `json:"differences"`
+		Summary      string                 `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &result); err != nil {
+		return nil, fmt.Errorf("konnte Vergleich nicht parsen: %w", err)
+	}
+
+	c := &models.Comparison{
+		Subjects:     names,
+		Similarities: result.Similarities,
+		Differences:  result.Differences,
+		Summary:      strings.TrimSpace(result.Summary),
+		Rows:         []models.ComparisonRow{},
+	}
+	for _, row := range result.Rows {
+		if strings.TrimSpace(row.Criterion) == "" {
+			continue
+		}
+		// Tabelle rechteckig halten, auch wenn das Modell Werte auslässt
+		values := make([]string, len(items))
+		for i := range values {
+			values[i] = "k. A."
+			if i < len(row.Values) && strings.TrimSpace(row.Values[i]) != "" {
+				values[i] = strings.TrimSpace(row.Values[i])
+			}
+		}
+		c.Rows = append(c.Rows, models.ComparisonRow{Criterion: strings.TrimSpace(row.Criterion), Values: values})
+	}
+	if len(c.Rows) == 0 {
+		return nil, fmt.Errorf("keine Vergleichskriterien erhalten")
+	}
+	if c.Similarities == nil {
+		c.Similarities = []string{}
+	}
+	if c.Differences == nil {
+		c.Differences = []string{}
+	}
+	return c, nil
 }
 
 // ChatWithContext ermöglicht einen kontextbezogenen Chat
 func (t *Tutor) ChatWithContext(ctx context.Context, messages []ChatMessage, documentContext string, topic *models.Topic) (*GenerateResponse, error) {
+	ctx = withDefaultPriority(ctx, PriorityInteractive)
+
 	systemPrompt := fmt.Sprintf(`Du bist ein hilfreicher Lernassistent. 
 Du hilfst dem Studenten beim Lernen und beantwortest Fragen.
 
@@ -475,7 +1075,7 @@ Aktuelles Thema: %s
 Beschreibung: %s
 
 Verfügbarer Kontext aus den Lernmaterialien:
-%s`, topic.Name, topic.Description, limitContent(documentContext, 6000))
+%s`, topic.Name, topic.Description, guardMaterial(limitContent(documentContext, contentLimit(t.provider, 6000))))
 
 	// Füge System-Nachricht hinzu
 	allMessages := append([]ChatMessage{{Role: "system", Content: systemPrompt}}, messages...)
@@ -485,8 +1085,433 @@ Verfügbarer Kontext aus den Lernmaterialien:
 	})
 }
 
+// ChatWithDocument beantwortet Fragen ausschließlich aus einem Dokument. documentContext sind
+// die passenden Abschnitte mit Seitenangabe (pdf.FormatChunks); die Antwort nennt die Seiten.
+func (t *Tutor) ChatWithDocument(ctx context.Context, messages []ChatMessage, documentName, documentContext string) (*GenerateResponse, error) {
+	ctx = withDefaultPriority(ctx, PriorityInteractive)
+
+	systemPrompt := fmt.Sprintf(`Du bist ein hilfreicher Lernassistent und beantwortest Fragen zu EINEM Dokument: "%s".
+
+WICHTIG:
+- Verwende NUR Informationen aus den Abschnitten unten, kein Wissen aus anderen Quellen
+- Belege jede Aussage mit der Seite in der Form (S. 12); die Seite steht in eckigen Klammern über jedem Abschnitt
+- Steht die Antwort nicht in den Abschnitten, sage ehrlich, dass das Dokument dazu nichts enthält
+
+Abschnitte aus dem Dokument:
+%s`, documentName, guardMaterial(limitContent(documentContext, contentLimit(t.provider, 6000))))
+
+	allMessages := append([]ChatMessage{{Role: "system", Content: systemPrompt}}, messages...)
+
+	return t.provider.Chat(ctx, allMessages, &GenerateOptions{
+		Temperature: 0.3,
+	})
+}
+
+// socraticPromptTemplate ist die Vorlage für den sokratischen Chat-Modus
+const socraticPromptTemplate = `Du bist ein sokratischer Tutor.
+Du gibst die Lösung NIEMALS direkt preis. Stattdessen führst du den Studenten
+mit gezielten Rückfragen und kleinen Hinweisen Schritt für Schritt zur Antwort.
+
+REGELN:
+1. Stelle pro Antwort höchstens EINE Rückfrage
+2. Knüpfe an das an, was der Student bereits richtig gesagt hat
+3. Wenn der Student feststeckt, gib einen kleinen inhaltlichen Hinweis – aber nicht die Lösung
+4. Erst wenn der Student die Antwort selbst formuliert hat, bestätige sie und fasse kurz zusammen
+5. Verlangt der Student direkt die Lösung, erkläre freundlich, dass er sie gemeinsam mit dir erarbeitet
+6. Nutze NUR Informationen aus dem folgenden Kontext
+
+Aktuelles Thema: %s
+Beschreibung: %s
+
+Verfügbarer Kontext aus den Lernmaterialien:
+%s`
+
+// ChatSocratic führt einen Chat im sokratischen Modus: Der Tutor leitet mit Fragen
+// und Hinweisen zur Lösung, statt sie direkt zu verraten
+func (t *Tutor) ChatSocratic(ctx context.Context, messages []ChatMessage, documentContext string, topic *models.Topic) (*GenerateResponse, error) {
+	ctx = withDefaultPriority(ctx, PriorityInteractive)
+
+	systemPrompt := fmt.Sprintf(socraticPromptTemplate, topic.Name, topic.Description, guardMaterial(limitContent(documentContext, contentLimit(t.provider, 6000))))
+
+	allMessages := append([]ChatMessage{{Role: "system", Content: systemPrompt}}, messages...)
+
+	return t.provider.Chat(ctx, allMessages, &GenerateOptions{
+		Temperature: 0.6,
+	})
+}
+
+// WeeklyEncouragement schreibt einen kurzen, ermutigenden Absatz zum Wochenbericht
+func (t *Tutor) WeeklyEncouragement(ctx context.Context, summary string) (string, error) {
+	ctx = withDefaultPriority(ctx, PriorityBackground)
+
+	prompt := fmt.Sprintf(`Hier ist die Lernbilanz eines Studierenden für die letzte Woche:
+
+%s
+
+Schreibe einen kurzen, persönlichen Absatz (3-4 Sätze), der die Woche ehrlich würdigt und für die nächste Woche motiviert.
+- Beziehe dich auf konkrete Zahlen aus der Bilanz
+- Bei wenig Aktivität: kein Vorwurf, sondern ein realistischer kleiner Vorschlag
+- Keine Überschrift, keine Aufzählung, kein Markdown`, summary)
+
+	resp, err := t.provider.Generate(ctx, prompt, &GenerateOptions{
+		Temperature: 0.7,
+		System:      "Du bist ein freundlicher, motivierender Lerncoach. Antworte auf Deutsch.",
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Content), nil
+}
+
+// ReadinessNarrative beschreibt die größten Lücken vor der Prüfung anhand der Bereitschaftswerte
+func (t *Tutor) ReadinessNarrative(ctx context.Context, summary string) (string, error) {
+	prompt := fmt.Sprintf(`Hier ist die Prüfungsbereitschaft eines Studierenden, je Thema mit Werten von 0-100
+(Trefferquote, Abdeckung der Fragen, Auffrischung laut Vergessenskurve):
+
+%s
+
+Schreibe einen kurzen Absatz (3-5 Sätze) über die größten Lücken und was bis zur Prüfung am meisten bringt.
+- Nenne die schwächsten Themen beim Namen und woran es liegt (z.B. kaum geübt, viele Fehler, lange nicht wiederholt)
+- Berücksichtige die verbleibende Zeit: bei knapper Zeit priorisieren, bei viel Zeit einen Plan vorschlagen
+- Ehrlich, aber ermutigend
+- Keine Überschrift, keine Aufzählung, kein Markdown`, summary)
+
+	resp, err := t.provider.Generate(ctx, prompt, &GenerateOptions{
+		Temperature: 0.5,
+		System:      "Du bist ein erfahrener Lerncoach, der Studierende auf Prüfungen vorbereitet. Antworte auf Deutsch.",
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Content), nil
+}
+
+// GenerateObjectives erstellt 3-6 konkrete Lernziele für ein bestehendes Thema
+func (t *Tutor) GenerateObjectives(ctx context.Context, topic *models.Topic, documentContent string) ([]models.LearningObjective, error) {
+	prompt := fmt.Sprintf(`Formuliere 3-6 konkrete, überprüfbare Lernziele zum Thema "%s".
+Beschreibung: %s
+
+Material:
+%s
+
+REGELN:
+- Jedes Lernziel beginnt mit "Kann ..." (z.B. "Kann X berechnen", "Kann den Unterschied zwischen Y und Z erklären")
+- Jedes Lernziel prüft GENAU EINE Fähigkeit
+- Keine Verweise auf Seiten oder Kapitel
+
+Antworte NUR im JSON-Format:
+{"objectives": ["Kann ...", "Kann ..."]}`, topic.Name, topic.Description, guardMaterial(limitContent(documentContent, contentLimit(t.provider, 6000))))
+
+	resp, err := t.provider.Generate(ctx, prompt, &GenerateOptions{
+		Temperature: 0.3,
+		System:      "Du bist ein Didaktik-Experte und formulierst präzise Lernziele. Antworte nur im JSON-Format.",
+		JSON:        jsonMode(t.provider),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Objectives []string `json:"objectives"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &result); err != nil {
+		return nil, fmt.Errorf("konnte Lernziele nicht parsen: %w", err)
+	}
+
+	objectives := objectivesFromTexts(result.Objectives)
+	for i := range objectives {
+		objectives[i].TopicID = topic.ID
+	}
+	return objectives, nil
+}
+
+// mnemonicKinds sind die Arten von Merkhilfen
+var mnemonicKinds = map[string]bool{"eselsbruecke": true, "analogy": true, "memory_aid": true}
+
+// GenerateMnemonics erstellt Eselsbrücken, Analogien und Merkhilfen zu den Schlüsselbegriffen eines Themas.
+// terms gibt die Begriffe vor (leer = das Modell wählt 3-6 Schlüsselbegriffe, bekannte Glossarbegriffe bevorzugt).
+func (t *Tutor) GenerateMnemonics(ctx context.Context, topic *models.Topic, documentContent string, terms []string, glossaryTerms []string) ([]models.Mnemonic, error) {
+	termRule := "Wähle 3-6 Schlüsselbegriffe des Themas, die man sich merken muss."
+	if len(terms) > 0 {
+		termRule = "Erstelle Merkhilfen GENAU für diese Begriffe: " + strings.Join(terms, ", ")
+	} else if len(glossaryTerms) > 0 {
+		termRule += "\nBevorzuge Begriffe aus dem Glossar, wenn sie zum Thema gehören: " + strings.Join(glossaryTerms, ", ")
+	}
+
+	prompt := fmt.Sprintf(`Erstelle Merkhilfen zum Thema "%s".
+Beschreibung: %s
+
+Material:
+%s
+
+%s
+
+Für jeden Begriff 1-3 Merkhilfen verschiedener Art:
+- "eselsbruecke": Eselsbrücke, Akronym, Reim oder Merksatz
+- "analogy": Vergleich mit etwas Vertrautem aus dem Alltag
+- "memory_aid": Bild, Geschichte oder Struktur, die beim Erinnern hilft
+
+REGELN:
+- Fachlich korrekt, keine Vereinfachung, die falsch wird
+- Kurz und einprägsam (max. 2-3 Sätze)
+- Den Begriff genau so schreiben, wie er im Material vorkommt
+
+Antworte NUR im JSON-Format:
+{"mnemonics": [{"term": "Begriff", "kind": "eselsbruecke", "content": "..."}]}`, topic.Name, topic.Description, guardMaterial(limitContent(documentContent, contentLimit(t.provider, 6000))), termRule)
+
+	resp, err := t.provider.Generate(ctx, prompt, &GenerateOptions{
+		Temperature: 0.7,
+		System:      "Du bist ein kreativer Lerncoach, der sich einprägsame, fachlich korrekte Merkhilfen ausdenkt. Antworte nur im JSON-Format.",
+		JSON:        jsonMode(t.provider),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Mnemonics []struct {
+			Term    string `json:"term"`
+			Kind    string `json:"kind"`
+			Content string `json:"content"`
+		} `json:"mnemonics"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &result); err != nil {
+		return nil, fmt.Errorf("konnte Merkhilfen nicht parsen: %w", err)
+	}
+
+	var mnemonics []models.Mnemonic
+	for _, m := range result.Mnemonics {
+		term := strings.TrimSpace(m.Term)
+		content := strings.TrimSpace(m.Content)
+		if term == "" || content == "" {
+			continue
+		}
+		kind := strings.ToLower(strings.TrimSpace(m.Kind))
+		if !mnemonicKinds[kind] {
+			kind = "memory_aid"
+		}
+		mnemonics = append(mnemonics, models.Mnemonic{
+			TopicID: topic.ID,
+			Term:    term,
+			Kind:    kind,
+			Content: content,
+		})
+	}
+	return mnemonics, nil
+}
+
+// ErrNotQuantitative bedeutet, dass ein Thema keine Rechenaufgaben hergibt
+var ErrNotQuantitative = errors.New("thema enthält keine Rechenaufgaben")
+
+// GenerateWorkedExamples rechnet Beispielaufgaben zu einem quantitativen Thema Schritt für Schritt vor
+func (t *Tutor) GenerateWorkedExamples(ctx context.Context, topic *models.Topic, documentContent string, count int, difficulty int) ([]models.WorkedExample, error) {
+	prompt := fmt.Sprintf(`Erstelle %d vollständig vorgerechnete Beispielaufgaben zum Thema "%s" (Schwierigkeit %d von 5).
+Beschreibung: %s
+
+Material:
+%s
+
+REGELN:
+- Nur Aufgaben, die wirklich gerechnet werden (Formeln, Zahlenwerte, Umformungen)
+- Aufgabentyp und Formeln wie im Material, aber mit eigenen Zahlen
+- Jeder Schritt: was wird gemacht und warum, die Rechnung und das Zwischenergebnis (mit Einheit)
+- Keine Schritte überspringen, auch einfache Umformungen zeigen
+- "pitfalls": 1-3 typische Fehler bei genau dieser Aufgabe
+- Rechne jedes Ergebnis nach, bevor du antwortest
+
+Wenn das Thema keine Rechnungen enthält, antworte mit {"quantitative": false, "examples": []}.
+
+Antworte NUR im JSON-Format:
+{"quantitative": true, "examples": [{"title": "Kurzer Titel", "problem": "Aufgabenstellung", "steps": [{"explanation": "...", "calculation": "...", "result": "..."}], "answer": "Endergebnis", "pitfalls": ["..."]}]}`,
+		count, topic.Name, difficulty, topic.Description, guardMaterial(limitContent(documentContent, contentLimit(t.provider, 8000))))
+
+	resp, err := t.provider.Generate(ctx, prompt, &GenerateOptions{
+		Temperature: 0.2,
+		System:      "Du bist ein sorgfältiger Tutor für Rechenaufgaben. Du rechnest fehlerfrei und erklärst jeden Schritt. Antworte nur im JSON-Format.",
+		JSON:        jsonMode(t.provider),
+		Seed:        t.seed,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Quantitative *bool `json:"quantitative"`
+		Examples     []struct {
+			Title    string              `json:"title"`
+			Problem  string              `json:"problem"`
+			Steps    []models.WorkedStep `json:"steps"`
+			Answer   string              `json:"answer"`
+			Pitfalls []string            `json:"pitfalls"`
+		} `json:"examples"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &result); err != nil {
+		return nil, fmt.Errorf("konnte Rechenbeispiele nicht parsen: %w", err)
+	}
+	if result.Quantitative != nil && !*result.Quantitative {
+		return nil, ErrNotQuantitative
+	}
+
+	var examples []models.WorkedExample
+	for _, ex := range result.Examples {
+		if strings.TrimSpace(ex.Problem) == "" || len(ex.Steps) == 0 {
+			continue
+		}
+		examples = append(examples, models.WorkedExample{
+			TopicID:    topic.ID,
+			Title:      strings.TrimSpace(ex.Title),
+			Problem:    strings.TrimSpace(ex.Problem),
+			Steps:      ex.Steps,
+			Answer:     strings.TrimSpace(ex.Answer),
+			Pitfalls:   ex.Pitfalls,
+			Difficulty: difficulty,
+		})
+	}
+	if len(examples) == 0 {
+		return nil, ErrNotQuantitative
+	}
+	return examples, nil
+}
+
+// TopicSplit ist ein Unterthema-Vorschlag beim Aufteilen eines Themas
+type TopicSplit struct {
+	Topic     models.Topic
+	Questions []int // Indizes der bestehenden Fragen, die zu diesem Unterthema gehören
+}
+
+// SplitTopic schlägt eine Aufteilung eines zu breiten Themas in Unterthemen vor
+func (t *Tutor) SplitTopic(ctx context.Context, topic *models.Topic, documentContent string, count int) ([]TopicSplit, error) {
+	var questionList strings.Builder
+	for i, q := range topic.Questions {
+		questionList.WriteString(fmt.Sprintf("%d: %s\n", i, q.Question))
+	}
+	if questionList.Len() == 0 {
+		questionList.WriteString("(keine)")
+	}
+
+	prompt := fmt.Sprintf(`Teile das Thema "%s" in genau %d klar abgegrenzte Unterthemen auf.
+Beschreibung: %s
+Geschätzte Lernzeit gesamt: %d Minuten
+
+Material:
+%s
+
+Bestehende Fragen (Index: Frage):
+%s
+
+Ordne jede bestehende Frage über ihren Index genau einem Unterthema zu.
+Verteile die Lernzeit sinnvoll auf die Unterthemen.
+
+Antworte NUR im JSON-Format:
+{"subtopics": [{"name": "Unterthema", "description": "Kurzbeschreibung", "difficulty": 1-5, "est_minutes": 20, "questions": [0, 2]}]}`,
+		topic.Name, count, topic.Description, topic.EstMinutes, guardMaterial(limitContent(documentContent, contentLimit(t.provider, 6000))), questionList.String())
+
+	resp, err := t.provider.Generate(ctx, prompt, &GenerateOptions{
+		Temperature: 0.3,
+		System:      "Du bist ein erfahrener Dozent, der Lernthemen strukturiert. Antworte nur im JSON-Format.",
+		JSON:        jsonMode(t.provider),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Subtopics []struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			Difficulty  int    `json:"difficulty"`
+			EstMinutes  int    `json:"est_minutes"`
+			Questions   []int  `json:"questions"`
+		} `json:"subtopics"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &result); err != nil {
+		return nil, fmt.Errorf("konnte Unterthemen nicht parsen: %w", err)
+	}
+
+	var splits []TopicSplit
+	for _, st := range result.Subtopics {
+		if strings.TrimSpace(st.Name) == "" {
+			continue
+		}
+		if st.Difficulty < 1 || st.Difficulty > 5 {
+			st.Difficulty = topic.Difficulty
+		}
+		splits = append(splits, TopicSplit{
+			Topic: models.Topic{
+				Name:        st.Name,
+				Description: st.Description,
+				Difficulty:  st.Difficulty,
+				EstMinutes:  st.EstMinutes,
+			},
+			Questions: st.Questions,
+		})
+	}
+	if len(splits) < 2 {
+		return nil, fmt.Errorf("zu wenige Unterthemen erhalten (%d)", len(splits))
+	}
+
+	return splits, nil
+}
+
 // Helper-Funktionen
 
+// MapSyllabus ordnet Punkte aus dem Modulhandbuch den erkannten Themen zu.
+// Ergebnis: je Syllabus-Punkt die Indizes der passenden Themen (leer = kein passendes Thema).
+func (t *Tutor) MapSyllabus(ctx context.Context, items []string, topics []models.Topic) ([][]int, error) {
+	var itemList, topicList strings.Builder
+	for i, item := range items {
+		itemList.WriteString(fmt.Sprintf("%d: %s\n", i, item))
+	}
+	for i, topic := range topics {
+		topicList.WriteString(fmt.Sprintf("%d: %s - %s\n", i, topic.Name, topic.Description))
+	}
+
+	prompt := fmt.Sprintf(`Ordne die Punkte aus dem offiziellen Modulhandbuch / der Prüfungsthemenliste den Themen eines Lernplans zu.
+
+Syllabus-Punkte (Index: Text):
+%s
+Themen (Index: Name - Beschreibung):
+%s
+Ein Punkt kann zu mehreren Themen passen. Ordne nur zu, wenn der Inhalt wirklich übereinstimmt.
+Punkte ohne passendes Thema bekommen eine leere Liste.
+
+Antworte NUR im JSON-Format:
+{"mapping": [{"item": 0, "topics": [1, 3]}]}`, itemList.String(), topicList.String())
+
+	resp, err := t.provider.Generate(ctx, prompt, &GenerateOptions{
+		Temperature: 0.2,
+		System:      "Du bist ein erfahrener Dozent und kennst Modulhandbücher. Antworte nur im JSON-Format.",
+		JSON:        jsonMode(t.provider),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Mapping []struct {
+			Item   int   `json:"item"`
+			Topics []int `json:"topics"`
+		} `json:"mapping"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &result); err != nil {
+		return nil, fmt.Errorf("konnte Zuordnung nicht parsen: %w", err)
+	}
+
+	mapping := make([][]int, len(items))
+	for _, m := range result.Mapping {
+		if m.Item < 0 || m.Item >= len(items) {
+			continue
+		}
+		for _, ti := range m.Topics {
+			if ti >= 0 && ti < len(topics) {
+				mapping[m.Item] = append(mapping[m.Item], ti)
+			}
+		}
+	}
+
+	return mapping, nil
+}
+
 func limitContent(content string, maxLen int) string {
 	if len(content) <= maxLen {
 		return content
@@ -508,10 +1533,13 @@ func parseTopicsFromResponse(response string) ([]models.Topic, error) {
 
 	var result struct {
 		Topics []struct {
-			Name        string `json:"name"`
-			Description string `json:"description"`
-			Difficulty  int    `json:"difficulty"`
-			EstMinutes  int    `json:"est_minutes"`
+			Name           string   `json:"name"`
+			Description    string   `json:"description"`
+			Difficulty     int      `json:"difficulty"`
+			EstMinutes     int      `json:"est_minutes"`
+			Objectives     []string `json:"objectives"`
+			SourceDocument string   `json:"source_document"`
+			Pages          []int    `json:"pages"`
 		} `json:"topics"`
 	}
 
@@ -521,17 +1549,100 @@ func parseTopicsFromResponse(response string) ([]models.Topic, error) {
 
 	var topics []models.Topic
 	for _, t := range result.Topics {
-		topics = append(topics, models.Topic{
+		topic := models.Topic{
 			Name:        t.Name,
 			Description: t.Description,
 			Difficulty:  t.Difficulty,
 			EstMinutes:  t.EstMinutes,
-		})
+			Objectives:  objectivesFromTexts(t.Objectives),
+		}
+		if t.SourceDocument != "" || len(t.Pages) > 0 {
+			src := models.TopicSource{DocumentName: strings.TrimSpace(t.SourceDocument)}
+			src.PageStart, src.PageEnd = pageRange(t.Pages)
+			topic.Sources = []models.TopicSource{src}
+		}
+		topics = append(topics, topic)
 	}
 
 	return topics, nil
 }
 
+// pageRange macht aus der LLM-Angabe [von, bis] einen gültigen Seitenbereich (0 = unbekannt)
+func pageRange(pages []int) (int, int) {
+	if len(pages) == 0 || pages[0] < 1 {
+		return 0, 0
+	}
+	start, end := pages[0], pages[len(pages)-1]
+	if end < start {
+		end = start
+	}
+	return start, end
+}
+
+// resolveTopicSources ordnet die vom LLM genannten Dokumentnamen den Dokumenten zu.
+// Ohne Angabe und bei nur einem Dokument stammt das Thema aus diesem.
+func resolveTopicSources(topics []models.Topic, documents []models.Document) {
+	for i := range topics {
+		if len(topics[i].Sources) == 0 && len(documents) == 1 {
+			topics[i].Sources = []models.TopicSource{{DocumentName: documents[0].Name}}
+		}
+		var resolved []models.TopicSource
+		for _, src := range topics[i].Sources {
+			if doc := matchDocument(src.DocumentName, documents); doc != nil {
+				src.DocumentID = doc.ID
+				src.DocumentName = doc.Name
+			} else if len(documents) == 1 {
+				src.DocumentID = documents[0].ID
+				src.DocumentName = documents[0].Name
+			} else {
+				// Unbekanntes Dokument: Seitenangabe wäre nicht zuzuordnen
+				continue
+			}
+			resolved = append(resolved, src)
+		}
+		topics[i].Sources = resolved
+	}
+}
+
+// matchDocument sucht ein Dokument per Name (exakt, sonst als Teilstring)
+func matchDocument(name string, documents []models.Document) *models.Document {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return nil
+	}
+	for i := range documents {
+		if strings.ToLower(documents[i].Name) == name {
+			return &documents[i]
+		}
+	}
+	for i := range documents {
+		docName := strings.ToLower(documents[i].Name)
+		if strings.Contains(docName, name) || strings.Contains(name, docName) {
+			return &documents[i]
+		}
+	}
+	return nil
+}
+
+// objectivesFromTexts wandelt die vom LLM gelieferten Lernziel-Texte in Lernziele um
+func objectivesFromTexts(texts []string) []models.LearningObjective {
+	var objectives []models.LearningObjective
+	for _, text := range texts {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		objectives = append(objectives, models.LearningObjective{
+			Text:  text,
+			Order: len(objectives) + 1,
+		})
+		if len(objectives) == 6 {
+			break
+		}
+	}
+	return objectives
+}
+
 func parseQuestionsFromResponse(response string, topicID string, difficulty int) ([]models.Question, error) {
 	jsonStr := extractJSON(response)
 
@@ -541,6 +1652,9 @@ func parseQuestionsFromResponse(response string, topicID string, difficulty int)
 			ExpectedAnswer string   `json:"expected_answer"`
 			Hints          []string `json:"hints"`
 			Type           string   `json:"type"`
+			Options        []string `json:"options"`
+			CognitiveLevel string   `json:"cognitive_level"`
+			SourceQuote    string   `json:"source_quote"`
 		} `json:"questions"`
 	}
 
@@ -551,18 +1665,45 @@ func parseQuestionsFromResponse(response string, topicID string, difficulty int)
 	var questions []models.Question
 	for i, q := range result.Questions {
 		qType := q.Type
-		if qType == "" {
+		if qType == "" || (qType == "multiple_choice" && len(q.Options) == 0) {
 			qType = "open"
 		}
+		expected := q.ExpectedAnswer
+		var options []string
+		if qType == "multiple_choice" {
+			options = q.Options
+		}
+		if qType == "ordering" {
+			if !validOrdering(q.Options) {
+				log.Printf("   [Tutor] ⚠️ Reihenfolge-Frage verworfen (zu wenige oder doppelte Schritte): %s", q.Question)
+				continue
+			}
+			options = q.Options
+			expected = strings.Join(options, OrderingSeparator)
+		}
+		if qType == "cloze" {
+			if _, ok := clozeTerm(&models.Question{Question: q.Question, ExpectedAnswer: q.ExpectedAnswer}); !ok {
+				log.Printf("   [Tutor] ⚠️ Lückentext verworfen (Lücke passt nicht zum Originalsatz): %s", q.Question)
+				continue
+			}
+		}
+
+		level := strings.ToLower(strings.TrimSpace(q.CognitiveLevel))
+		if !IsValidCognitiveLevel(level) {
+			level = ""
+		}
 
 		questions = append(questions, models.Question{
 			ID:             fmt.Sprintf("q_%d_%d", time.Now().UnixNano(), i),
 			TopicID:        topicID,
 			Question:       q.Question,
-			ExpectedAnswer: q.ExpectedAnswer,
+			ExpectedAnswer: expected,
 			Hints:          q.Hints,
 			Difficulty:     difficulty,
 			Type:           qType,
+			Options:        options,
+			CognitiveLevel: level,
+			SourceQuote:    strings.TrimSpace(q.SourceQuote),
 		})
 	}
 
@@ -0,0 +1,149 @@
+package lms
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"lernplattform/internal/config"
+)
+
+// Tiefe der Ordnerstruktur, die in einem ILIAS-Kurs durchsucht wird
+const maxILIASDepth = 6
+
+// ILIAS liest Kursdateien über die WebDAV-Schnittstelle von ILIAS
+type ILIAS struct {
+	cfg    config.ILIASConfig
+	client *http.Client
+}
+
+// NewILIAS erstellt einen Connector für die konfigurierte ILIAS-Instanz
+func NewILIAS(cfg config.ILIASConfig) *ILIAS {
+	cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+	return &ILIAS{cfg: cfg, client: &http.Client{Timeout: 5 * time.Minute}}
+}
+
+// Enabled ist true, wenn URL, Mandant, Zugangsdaten und mindestens ein Kurs konfiguriert sind
+func (i *ILIAS) Enabled() bool {
+	return i.cfg.URL != "" && i.cfg.Client != "" && i.cfg.Username != "" && len(i.cfg.Courses) > 0
+}
+
+func (i *ILIAS) Name() string { return "ilias" }
+
+// davMultistatus ist die Antwort auf PROPFIND
+type davMultistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Prop struct {
+				DisplayName   string `xml:"displayname"`
+				ContentLength int64  `xml:"getcontentlength"`
+				LastModified  string `xml:"getlastmodified"`
+				ResourceType  struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<propfind xmlns="DAV:"><prop><displayname/><getcontentlength/><getlastmodified/><resourcetype/></prop></propfind>`
+
+func (i *ILIAS) ListFiles(ctx context.Context) ([]CourseFile, error) {
+	var files []CourseFile
+	for _, course := range i.cfg.Courses {
+		name := course.Name
+		if name == "" {
+			name = fmt.Sprintf("Kurs %d", course.RefID)
+		}
+		root := fmt.Sprintf("%s/webdav.php/%s/ref_%d/", i.cfg.URL, url.PathEscape(i.cfg.Client), course.RefID)
+		found, err := i.walk(ctx, root, name, "", 0)
+		if err != nil {
+			return nil, fmt.Errorf("kurs %s: %w", name, err)
+		}
+		files = append(files, found...)
+	}
+	return files, nil
+}
+
+// walk listet einen WebDAV-Ordner und steigt in Unterordner ab
+func (i *ILIAS) walk(ctx context.Context, dirURL, course, folder string, depth int) ([]CourseFile, error) {
+	req, err := http.NewRequestWithContext(ctx, "PROPFIND", dirURL, strings.NewReader(propfindBody))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(i.cfg.Username, i.cfg.Password)
+	req.Header.Set("Depth", "1")
+	req.Header.Set("Content-Type", "application/xml")
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("webdav %s: %s", dirURL, resp.Status)
+	}
+
+	var result davMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("webdav-antwort nicht lesbar: %w", err)
+	}
+
+	base, _ := url.Parse(dirURL)
+	var files []CourseFile
+	for _, r := range result.Responses {
+		ref, err := url.Parse(r.Href)
+		if err != nil {
+			continue
+		}
+		target := base.ResolveReference(ref)
+		if strings.TrimSuffix(target.Path, "/") == strings.TrimSuffix(base.Path, "/") {
+			continue // der Ordner selbst
+		}
+		if len(r.Propstat) == 0 {
+			continue
+		}
+		prop := r.Propstat[0].Prop
+		name := prop.DisplayName
+		if name == "" {
+			name = path.Base(strings.TrimSuffix(target.Path, "/"))
+		}
+
+		if prop.ResourceType.Collection != nil {
+			if depth+1 >= maxILIASDepth {
+				continue
+			}
+			sub := strings.TrimSuffix(target.String(), "/") + "/"
+			found, err := i.walk(ctx, sub, course, strings.Trim(folder+"/"+name, "/"), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, found...)
+			continue
+		}
+
+		file := CourseFile{Course: course, Folder: folder, Name: name, URL: target.String(), Size: prop.ContentLength}
+		if t, err := http.ParseTime(prop.LastModified); err == nil {
+			file.Modified = t
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func (i *ILIAS) Download(ctx context.Context, file CourseFile, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", file.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(i.cfg.Username, i.cfg.Password)
+	return download(i.client, req, w)
+}
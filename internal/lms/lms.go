@@ -0,0 +1,125 @@
+package lms
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CourseFile ist eine Datei aus einem Kurs der Lernplattform
+type CourseFile struct {
+	Course   string    // Kursname, wird zum Schlagwort
+	Folder   string    // Abschnitt bzw. Ordner im Kurs
+	Name     string    // Dateiname
+	URL      string    // Download-Adresse
+	Size     int64     // 0 = unbekannt
+	Modified time.Time // Null = unbekannt
+}
+
+// Connector liest die Kursdateien einer Lernplattform
+type Connector interface {
+	// Name ist der Name der Plattform (moodle, ilias), auch Unterordner im Dokumentenordner
+	Name() string
+
+	// Enabled ist true, wenn der Zugang konfiguriert ist
+	Enabled() bool
+
+	// ListFiles listet alle Dateien der konfigurierten Kurse
+	ListFiles(ctx context.Context) ([]CourseFile, error)
+
+	// Download schreibt den Inhalt einer Datei nach w
+	Download(ctx context.Context, file CourseFile, w io.Writer) error
+}
+
+// Maximale Größe einer heruntergeladenen Kursdatei
+const maxFileBytes = 200 << 20
+
+// LocalPath ist der Ablageort einer Kursdatei im Dokumentenordner: <Plattform>/<Kurs>/<Ordner>/<Datei>
+func LocalPath(root string, c Connector, file CourseFile) string {
+	parts := []string{root, c.Name(), safeName(file.Course)}
+	for _, folder := range strings.Split(file.Folder, "/") {
+		if folder = safeName(folder); folder != "" {
+			parts = append(parts, folder)
+		}
+	}
+	return filepath.Join(append(parts, safeName(file.Name))...)
+}
+
+// Unchanged prüft, ob die lokale Kopie noch aktuell ist (gleiche Größe, nicht älter)
+func Unchanged(path string, file CourseFile) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if file.Size > 0 && info.Size() != file.Size {
+		return false
+	}
+	return file.Modified.IsZero() || !info.ModTime().Before(file.Modified)
+}
+
+// Fetch lädt eine Kursdatei nach path; erst nach vollständigem Download wird die alte Datei ersetzt
+func Fetch(ctx context.Context, c Connector, file CourseFile, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".download-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := c.Download(ctx, file, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	if !file.Modified.IsZero() {
+		os.Chtimes(path, file.Modified, file.Modified)
+	}
+	return nil
+}
+
+// download lädt eine URL mit begrenzter Größe nach w
+func download(client *http.Client, req *http.Request, w io.Writer) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download fehlgeschlagen: %s", resp.Status)
+	}
+	n, err := io.Copy(w, io.LimitReader(resp.Body, maxFileBytes+1))
+	if err != nil {
+		return err
+	}
+	if n > maxFileBytes {
+		return fmt.Errorf("datei größer als %d MB", maxFileBytes>>20)
+	}
+	return nil
+}
+
+// safeName macht aus einem Kurs-, Ordner- oder Dateinamen einen gültigen Pfadteil
+func safeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 32 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	name = strings.Trim(name, ". ")
+	if runes := []rune(name); len(runes) > 120 {
+		name = string(runes[:120])
+	}
+	return name
+}
@@ -0,0 +1,154 @@
+package lms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"lernplattform/internal/config"
+)
+
+// Moodle liest Kursdateien über den REST-Webservice von Moodle
+type Moodle struct {
+	cfg    config.MoodleConfig
+	client *http.Client
+}
+
+// NewMoodle erstellt einen Connector für die konfigurierte Moodle-Instanz
+func NewMoodle(cfg config.MoodleConfig) *Moodle {
+	cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+	return &Moodle{cfg: cfg, client: &http.Client{Timeout: 5 * time.Minute}}
+}
+
+// Enabled ist true, wenn URL und Token konfiguriert sind
+func (m *Moodle) Enabled() bool {
+	return m.cfg.URL != "" && m.cfg.Token != ""
+}
+
+func (m *Moodle) Name() string { return "moodle" }
+
+// call ruft eine Webservice-Funktion auf; Moodle meldet Fehler mit Status 200 und "exception"
+func (m *Moodle) call(ctx context.Context, function string, params url.Values, out interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("wstoken", m.cfg.Token)
+	params.Set("wsfunction", function)
+	params.Set("moodlewsrestformat", "json")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", m.cfg.URL+"/webservice/rest/server.php?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("moodle %s: %s", function, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var failure struct {
+		Exception string `json:"exception"`
+		Message   string `json:"message"`
+	}
+	if json.Unmarshal(body, &failure) == nil && failure.Exception != "" {
+		return fmt.Errorf("moodle %s: %s", function, failure.Message)
+	}
+	return json.Unmarshal(body, out)
+}
+
+func (m *Moodle) ListFiles(ctx context.Context) ([]CourseFile, error) {
+	var site struct {
+		UserID int `json:"userid"`
+	}
+	if err := m.call(ctx, "core_webservice_get_site_info", nil, &site); err != nil {
+		return nil, err
+	}
+
+	var courses []struct {
+		ID       int    `json:"id"`
+		FullName string `json:"fullname"`
+	}
+	if err := m.call(ctx, "core_enrol_get_users_courses", url.Values{"userid": {strconv.Itoa(site.UserID)}}, &courses); err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[int]bool)
+	for _, id := range m.cfg.Courses {
+		wanted[id] = true
+	}
+
+	var files []CourseFile
+	for _, course := range courses {
+		if len(wanted) > 0 && !wanted[course.ID] {
+			continue
+		}
+		var sections []struct {
+			Name    string `json:"name"`
+			Modules []struct {
+				Name     string `json:"name"`
+				Contents []struct {
+					Type         string `json:"type"`
+					FileName     string `json:"filename"`
+					FilePath     string `json:"filepath"`
+					FileURL      string `json:"fileurl"`
+					FileSize     int64  `json:"filesize"`
+					TimeModified int64  `json:"timemodified"`
+				} `json:"contents"`
+			} `json:"modules"`
+		}
+		if err := m.call(ctx, "core_course_get_contents", url.Values{"courseid": {strconv.Itoa(course.ID)}}, &sections); err != nil {
+			return nil, fmt.Errorf("kurs %s: %w", course.FullName, err)
+		}
+		for _, section := range sections {
+			for _, module := range section.Modules {
+				for _, content := range module.Contents {
+					if content.Type != "file" || content.FileURL == "" {
+						continue
+					}
+					file := CourseFile{
+						Course: course.FullName,
+						Folder: strings.Trim(section.Name+"/"+strings.Trim(content.FilePath, "/"), "/"),
+						Name:   content.FileName,
+						URL:    content.FileURL,
+						Size:   content.FileSize,
+					}
+					if content.TimeModified > 0 {
+						file.Modified = time.Unix(content.TimeModified, 0)
+					}
+					files = append(files, file)
+				}
+			}
+		}
+	}
+	return files, nil
+}
+
+// Download hängt das Token an die Datei-URL (webservice/pluginfile.php)
+func (m *Moodle) Download(ctx context.Context, file CourseFile, w io.Writer) error {
+	u, err := url.Parse(file.URL)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("token", m.cfg.Token)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	return download(m.client, req, w)
+}
@@ -0,0 +1,106 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"lernplattform/internal/config"
+)
+
+// Sender verschickt HTML-E-Mails über SMTP
+type Sender struct {
+	cfg config.EmailConfig
+}
+
+// NewSender erstellt einen Sender aus der E-Mail-Konfiguration
+func NewSender(cfg config.EmailConfig) *Sender {
+	return &Sender{cfg: cfg}
+}
+
+// Enabled ist true, wenn SMTP-Server, Absender und Empfänger konfiguriert sind
+func (s *Sender) Enabled() bool {
+	return s.cfg.SMTPHost != "" && s.cfg.From != "" && len(s.cfg.To) > 0
+}
+
+// Send verschickt eine HTML-Nachricht an alle konfigurierten Empfänger.
+// Port 465 nutzt implizites TLS, sonst wird STARTTLS verwendet, falls der Server es anbietet.
+func (s *Sender) Send(subject, htmlBody string) error {
+	if !s.Enabled() {
+		return errors.New("e-mail nicht konfiguriert (smtp_host, from, to)")
+	}
+
+	port := s.cfg.SMTPPort
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(s.cfg.SMTPHost, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.SMTPHost)
+	}
+
+	msg := s.buildMessage(subject, htmlBody)
+
+	if port != 465 {
+		return smtp.SendMail(addr, auth, s.cfg.From, s.cfg.To, msg)
+	}
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: s.cfg.SMTPHost})
+	if err != nil {
+		return fmt.Errorf("smtp-verbindung fehlgeschlagen: %w", err)
+	}
+	client, err := smtp.NewClient(conn, s.cfg.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp-anmeldung fehlgeschlagen: %w", err)
+		}
+	}
+	if err := client.Mail(s.cfg.From); err != nil {
+		return err
+	}
+	for _, to := range s.cfg.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (s *Sender) buildMessage(subject, htmlBody string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("From: " + s.cfg.From + "\r\n")
+	buf.WriteString("To: " + strings.Join(s.cfg.To, ", ") + "\r\n")
+	buf.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	buf.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(htmlBody, "\n", "\r\n"))
+	return buf.Bytes()
+}
@@ -1,85 +1,429 @@
 package models
 
-import "time"
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateLayout ist das Format reiner Kalendertage in API und Datenbank
+const DateLayout = "2006-01-02"
+
+// Date ist ein Kalendertag ohne Uhrzeit (Mitternacht in time.Local, also der konfigurierten
+// Zeitzone). In JSON und in der Datenbank steht er als "2006-01-02", damit ein Prüfungstermin
+// nicht durch Zeitzonen-Umrechnung auf den Vortag rutscht.
+type Date struct {
+	time.Time
+}
+
+// DateOf liefert den Kalendertag, auf den t in seiner eigenen Zeitzone fällt
+func DateOf(t time.Time) Date {
+	return Date{time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)}
+}
+
+// ParseDate liest einen Kalendertag im Format YYYY-MM-DD
+func ParseDate(s string) (Date, error) {
+	t, err := time.ParseInLocation(DateLayout, strings.TrimSpace(s), time.Local)
+	if err != nil {
+		return Date{}, err
+	}
+	return Date{t}, nil
+}
+
+// DaysFrom zählt die Kalendertage vom Tag von t (in time.Local) bis zu d; negativ, wenn d vorbei ist.
+// Gezählt wird über Datumsangaben, nicht über Stunden, damit Sommerzeit und Uhrzeit nichts verschieben.
+func (d Date) DaysFrom(t time.Time) int {
+	t = t.In(time.Local)
+	from := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
+
+func (d Date) String() string {
+	return d.Format(DateLayout)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + d.Format(DateLayout) + `"`), nil
+}
+
+// UnmarshalJSON nimmt "2006-01-02" und, für ältere Sicherungen, vollständige Zeitstempel an
+func (d *Date) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*d = Date{}
+		return nil
+	}
+	if parsed, err := ParseDate(s); err == nil {
+		*d = parsed
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return fmt.Errorf("ungültiges Datum %q (Format: YYYY-MM-DD)", s)
+	}
+	*d = DateOf(t)
+	return nil
+}
+
+// Value speichert nur den Kalendertag
+func (d Date) Value() (driver.Value, error) {
+	return d.Format(DateLayout), nil
+}
+
+// Scan liest Kalendertage und ältere Einträge mit Uhrzeit (Tag in der gespeicherten Zeitzone)
+func (d *Date) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case time.Time:
+		*d = DateOf(v)
+		return nil
+	case string:
+		return d.scanString(v)
+	case []byte:
+		return d.scanString(string(v))
+	case nil:
+		*d = Date{}
+		return nil
+	}
+	return fmt.Errorf("datum: typ %T nicht unterstützt", src)
+}
+
+func (d *Date) scanString(s string) error {
+	if len(s) < len(DateLayout) {
+		return fmt.Errorf("ungültiges Datum %q", s)
+	}
+	parsed, err := ParseDate(s[:len(DateLayout)])
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
 
 // Document repräsentiert ein hochgeladenes PDF-Dokument
 type Document struct {
 	ID          string    `json:"id"`
-	Name        string    `json:"name"`
+	Name        string    `json:"name"` // Dateiname
 	Path        string    `json:"path"`
-	Content     string    `json:"content,omitempty"`
+	Content     string    `json:"content,omitempty"` // ohne wiederkehrende Kopf-/Fußzeilen
+	RawContent  string    `json:"-"`                 // Text wie aus der PDF gelesen
 	PageCount   int       `json:"page_count"`
 	UploadedAt  time.Time `json:"uploaded_at"`
 	ProcessedAt time.Time `json:"processed_at,omitempty"`
+	// Beim Einlesen ermittelt: Textlänge in Zeichen, Wörter und ob überhaupt Text
+	// gefunden wurde (false = vermutlich eingescannt, braucht OCR)
+	ContentLength int  `json:"content_length"`
+	WordCount     int  `json:"word_count"`
+	HasText       bool `json:"has_text"`
+	// Sprache des Texts (de, en, unknown); bestimmt die Sprache von Erklärungen und Fragen
+	Language string `json:"language"`
+	// Schlagworte, z.B. aus den Ordnernamen eines ZIP-Uploads
+	Tags []string `json:"tags,omitempty"`
+	// Selbst gepflegte Angaben unabhängig von der Datei (PUT /documents/{id}); ohne Titel gilt der Dateiname
+	Title    string `json:"title,omitempty"`
+	Author   string `json:"author,omitempty"`
+	Semester string `json:"semester,omitempty"`
+	// Herkunft bei Import per URL (für ein späteres erneutes Abrufen)
+	SourceURL string `json:"source_url,omitempty"`
+	// Verweise einer importierten Notiz ([[Wiki-Links]]) auf andere Notizen, Themen oder Glossarbegriffe
+	Links []DocumentLink `json:"links,omitempty"`
+	// Überschriften aus den Schriftinformationen der PDF bzw. den Formatvorlagen der DOCX, nur beim Einlesen gesetzt
+	Headings []TocEntry `json:"-"`
+	// Eingebettete Abbildungen der PDF, nur beim Einlesen gesetzt
+	Figures []Figure `json:"-"`
+}
+
+// Figure ist eine aus einer PDF gelesene Abbildung (Diagramm, Skizze, Foto)
+type Figure struct {
+	ID         string    `json:"id"`
+	DocumentID string    `json:"document_id"`
+	Page       int       `json:"page"`
+	Width      int       `json:"width"`
+	Height     int       `json:"height"`
+	MimeType   string    `json:"mime_type"` // image/jpeg oder image/png
+	Data       []byte    `json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// DocumentLink ist ein Verweis aus einer Notiz
+type DocumentLink struct {
+	Target string `json:"target"`           // Name des Linkziels, z.B. "Laplace-Transformation"
+	Kind   string `json:"kind"`             // note, topic, glossary oder unresolved
+	RefID  string `json:"ref_id,omitempty"` // ID der Notiz, des Themas bzw. Glossarbegriffs
+}
+
+// DocumentTOC ist das Inhaltsverzeichnis eines Dokuments
+type DocumentTOC struct {
+	DocumentID string     `json:"document_id"`
+	Source     string     `json:"source"` // font (Schriftgröße/-schnitt der PDF bzw. Formatvorlagen der DOCX) oder text (Textmuster)
+	Entries    []TocEntry `json:"entries"`
+	ComputedAt time.Time  `json:"computed_at"`
+}
+
+// TocEntry ist eine Überschrift mit Gliederungsebene (1 = oberste) und Seite
+type TocEntry struct {
+	Title string `json:"title"`
+	Level int    `json:"level"`
+	Page  int    `json:"page,omitempty"`
+}
+
+// DocumentStats beschreibt Umfang, Sprache, Lesbarkeit und Gliederung eines Dokuments
+type DocumentStats struct {
+	DocumentID       string            `json:"document_id"`
+	PageCount        int               `json:"page_count"`
+	WordCount        int               `json:"word_count"`
+	SentenceCount    int               `json:"sentence_count"`
+	ReadingMinutes   int               `json:"reading_minutes"`
+	Language         string            `json:"language"` // de, en oder unknown
+	AvgSentenceWords float64           `json:"avg_sentence_words"`
+	AvgWordSyllables float64           `json:"avg_word_syllables"`
+	Readability      float64           `json:"readability"` // 0-100, höher = leichter (Flesch bzw. Amstad für Deutsch)
+	Sections         []DocumentSection `json:"sections"`
+	ComputedAt       time.Time         `json:"computed_at"`
+}
+
+// DocumentSection ist ein erkannter Abschnitt mit Startseite und Umfang
+type DocumentSection struct {
+	Title     string `json:"title"`
+	Page      int    `json:"page,omitempty"`
+	WordCount int    `json:"word_count"`
 }
 
 // Topic repräsentiert ein Lernthema/Kapitel
 type Topic struct {
-	ID          string     `json:"id"`
-	StudyPlanID string     `json:"study_plan_id"`
-	Name        string     `json:"name"`
-	Description string     `json:"description"`
-	Content     string     `json:"content,omitempty"`
-	Order       int        `json:"order"`
-	Difficulty  int        `json:"difficulty"` // 1-5
-	EstMinutes  int        `json:"est_minutes"`
-	Status      string     `json:"status"` // pending, in_progress, completed
-	Progress    float64    `json:"progress"`
-	Questions   []Question `json:"questions,omitempty"`
+	ID            string              `json:"id"`
+	StudyPlanID   string              `json:"study_plan_id"`
+	ParentTopicID string              `json:"parent_topic_id,omitempty"` // leer = Kapitel auf oberster Ebene
+	Name          string              `json:"name"`
+	Description   string              `json:"description"`
+	Content       string              `json:"content,omitempty"`
+	Order         int                 `json:"order"`
+	Difficulty    int                 `json:"difficulty"` // 1-5
+	EstMinutes    int                 `json:"est_minutes"`
+	Status        string              `json:"status"` // pending, in_progress, completed
+	Progress      float64             `json:"progress"`
+	ExamWeight    float64             `json:"exam_weight,omitempty"` // Prüfungsgewicht aus dem Syllabus (0 = normal)
+	Sources       []TopicSource       `json:"sources,omitempty"`
+	Questions     []Question          `json:"questions,omitempty"`
+	Examples      []WorkedExample     `json:"worked_examples,omitempty"`
+	Objectives    []LearningObjective `json:"objectives,omitempty"`
+	Subtopics     []Topic             `json:"subtopics,omitempty"`
+}
+
+// TopicSource gibt an, aus welchem Dokument (und welchen Seiten) ein Thema stammt
+type TopicSource struct {
+	DocumentID   string `json:"document_id,omitempty"`
+	DocumentName string `json:"document_name"`
+	PageStart    int    `json:"page_start,omitempty"` // 0 = ganzes Dokument
+	PageEnd      int    `json:"page_end,omitempty"`
+}
+
+// LearningObjective ist ein konkretes Lernziel eines Themas ("Kann X berechnen")
+type LearningObjective struct {
+	ID         string     `json:"id"`
+	TopicID    string     `json:"topic_id"`
+	Text       string     `json:"text"`
+	Order      int        `json:"order"`
+	Achieved   bool       `json:"achieved"`
+	AchievedAt *time.Time `json:"achieved_at,omitempty"`
 }
 
 // Question repräsentiert eine Lernfrage
 type Question struct {
-	ID            string   `json:"id"`
-	TopicID       string   `json:"topic_id"`
-	Question      string   `json:"question"`
-	ExpectedAnswer string  `json:"expected_answer"`
-	Hints         []string `json:"hints,omitempty"`
-	Difficulty    int      `json:"difficulty"` // 1-5
-	Type          string   `json:"type"`       // multiple_choice, open, true_false
-	Options       []string `json:"options,omitempty"`
-	UserAnswer    string   `json:"user_answer,omitempty"`
-	IsCorrect     *bool    `json:"is_correct,omitempty"`
-	Feedback      string   `json:"feedback,omitempty"`
-	AnsweredAt    *time.Time `json:"answered_at,omitempty"`
+	ID             string     `json:"id"`
+	TopicID        string     `json:"topic_id"`
+	Question       string     `json:"question"`
+	ExpectedAnswer string     `json:"expected_answer"`
+	Hints          []string   `json:"hints,omitempty"`
+	Difficulty     int        `json:"difficulty"`                // 1-5
+	Type           string     `json:"type"`                      // multiple_choice, open, true_false, cloze, ordering, image
+	CognitiveLevel string     `json:"cognitive_level,omitempty"` // remember, understand, apply, analyze (Bloom)
+	Options        []string   `json:"options,omitempty"`
+	OptionToken    string     `json:"option_token,omitempty"` // Zuordnung der gemischten Optionen (nicht gespeichert)
+	UserAnswer     string     `json:"user_answer,omitempty"`
+	IsCorrect      *bool      `json:"is_correct,omitempty"`
+	Feedback       string     `json:"feedback,omitempty"`
+	AnsweredAt     *time.Time `json:"answered_at,omitempty"`
+	TimeLimit      int        `json:"time_limit_seconds,omitempty"` // 0 = Standard je Schwierigkeit
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	AnswerSeconds  *int       `json:"answer_seconds,omitempty"` // nil = nicht gemessen
+	AnsweredLate   bool       `json:"answered_late,omitempty"`
+	// Fundstelle im Material ("im Skript zeigen")
+	SourceDocumentID string `json:"source_document_id,omitempty"`
+	SourcePage       int    `json:"source_page,omitempty"`
+	SourceQuote      string `json:"-"` // verrät ggf. die Antwort, nur über /questions/{id}/source
+	// Kam in einer alten Klausur vor (aus der Klausur erzeugt oder inhaltlich gefunden)
+	ExamRelevant   bool   `json:"exam_relevant,omitempty"`
+	ExamDocumentID string `json:"exam_document_id,omitempty"`
+	ExamYear       int    `json:"exam_year,omitempty"` // 0 = unbekannt
+	// Abbildung, auf die sich eine Bildfrage ("type": "image") bezieht
+	FigureID string `json:"figure_id,omitempty"`
 }
 
 // StudyPlan repräsentiert einen Lernplan
 type StudyPlan struct {
-	ID           string    `json:"id"`
-	Name         string    `json:"name"`
-	ExamDate     time.Time `json:"exam_date"`
-	CreatedAt    time.Time `json:"created_at"`
-	TotalMinutes int       `json:"total_minutes"`
-	Topics       []Topic   `json:"topics,omitempty"`
-	Documents    []string  `json:"document_ids"`
-	Status       string    `json:"status"` // active, completed, paused
-	Progress     float64   `json:"progress"`
+	ID           string      `json:"id"`
+	Name         string      `json:"name"`
+	ExamDate     Date        `json:"exam_date"`
+	CreatedAt    time.Time   `json:"created_at"`
+	TotalMinutes int         `json:"total_minutes"`
+	Topics       []Topic     `json:"topics,omitempty"`
+	Documents    []string    `json:"document_ids"`
+	Status       string      `json:"status"` // active, completed, paused
+	Progress     float64     `json:"progress"`
+	Phases       []PlanPhase `json:"phases,omitempty"`
+	// Selbst gepflegte Angaben (PUT /plans/{id}): Fach, Farbe (#rrggbb) und freie Notizen
+	Subject string `json:"subject,omitempty"`
+	Color   string `json:"color,omitempty"`
+	Notes   string `json:"notes,omitempty"`
+	// Fachprofil für Prompts, Fragetypen und Bewertung (leer = ohne Profil)
+	ProfileID string `json:"profile_id,omitempty"`
+}
+
+// PlanPhase ist ein Abschnitt des Lernplans bis zur Prüfung (learn, practice, review, dry_run)
+type PlanPhase struct {
+	Phase string    `json:"phase"`
+	Label string    `json:"label"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"` // exklusiv; die letzte Phase endet am Prüfungstag
+}
+
+// PlanJob hält den Stand einer Lernplan-Erstellung fest, damit sie nach einem Neustart
+// oder Standby an der Stelle weiterläuft, an der sie unterbrochen wurde
+type PlanJob struct {
+	ID          string   `json:"id"`
+	ExamDate    string   `json:"exam_date"`
+	DocumentIDs []string `json:"document_ids"`
+	Status      string   `json:"status"` // running, failed, canceled, done
+	// Themen je bereits analysiertem Dokument (Schlüssel: Dokument-ID)
+	DocumentTopics map[string][]Topic `json:"-"`
+	DocumentsDone  int                `json:"documents_done"`
+	// Ergebnis der Analyse; ist es gesetzt, fehlt nur noch der Lernplan selbst
+	Topics   []Topic `json:"-"`
+	Analyzed bool    `json:"analyzed"`
+	PlanID   string  `json:"plan_id,omitempty"`
+	Error    string  `json:"error,omitempty"`
+	Attempts int     `json:"attempts"`
+	// Geschätzte Dauer der Analyse beim Start ("ca. 7 Min. mit qwen2.5:7b"), 0 = unbekannt
+	EstimatedSeconds int       `json:"estimated_seconds,omitempty"`
+	Model            string    `json:"model,omitempty"`
+	ProfileID        string    `json:"profile_id,omitempty"` // Fachprofil des entstehenden Plans
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// SubjectProfile bündelt fachspezifische Einstellungen, die einem Lernplan zugeordnet werden
+// (z.B. Mathematik, Jura, Medizin, Programmierung)
+type SubjectProfile struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Builtin bool   `json:"builtin"` // mitgeliefert: änderbar, aber nicht löschbar
+	// Zusätzliche Anweisungen an das Modell für Erklärungen bzw. Fragen
+	ExplanationPrompt string `json:"explanation_prompt"`
+	QuestionPrompt    string `json:"question_prompt"`
+	// Gewichtung der Fragetypen, wenn beim Generieren kein Typ gewählt ist (z.B. {"open": 2, "multiple_choice": 1})
+	QuestionTypes map[string]int `json:"question_types"`
+	// Strenge der Bewertung offener Antworten: lenient, normal, strict
+	Strictness string `json:"strictness"`
+	// Formeln in LaTeX schreiben ($…$ bzw. $$…$$)
+	Formulas  bool      `json:"formulas"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Quiz ist ein selbst zusammengestelltes, wiederverwendbares Quiz, z.B. mit dem Aufbau einer alten Klausur
+type Quiz struct {
+	ID          string `json:"id"`
+	StudyPlanID string `json:"study_plan_id"`
+	Name        string `json:"name"`
+	// Fest gewählte Fragen, die in jedem Durchlauf vorkommen
+	QuestionIDs []string `json:"question_ids"`
+	// Zusätzlich je Thema so viele zufällig gewählte Fragen
+	TopicCounts      []QuizTopicCount `json:"topic_counts"`
+	TimeLimitMinutes int              `json:"time_limit_minutes,omitempty"` // 0 = ohne Zeitlimit
+	Shuffle          bool             `json:"shuffle"`                      // Fragen in zufälliger Reihenfolge
+	CognitiveLevel   string           `json:"cognitive_level,omitempty"`    // zufällige Fragen nur dieser Stufe (leer = alle)
+	CreatedAt        time.Time        `json:"created_at"`
+}
+
+// QuizTopicCount ist die Anzahl zufälliger Fragen aus einem Thema
+type QuizTopicCount struct {
+	TopicID string `json:"topic_id"`
+	Count   int    `json:"count"`
+}
+
+// QuizResult ist ein Durchlauf eines Quiz; ausgewertet werden die Antwortversuche zu seinen Fragen
+// zwischen Start und Abschluss
+type QuizResult struct {
+	ID          string     `json:"id"`
+	QuizID      string     `json:"quiz_id"`
+	QuestionIDs []string   `json:"question_ids"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Answered    int        `json:"answered"`
+	Correct     int        `json:"correct"`
+	Score       int        `json:"score"`              // 0-100 über alle Fragen, unbeantwortete zählen 0
+	Overtime    bool       `json:"overtime,omitempty"` // nach Ablauf des Zeitlimits abgeschlossen
+}
+
+// DeviceToken ist ein langlebiger Zugang für ein gekoppeltes Gerät (z.B. die Begleit-App) mit
+// eingeschränkten Bereichen; gespeichert wird nur der Hash des Tokens
+type DeviceToken struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	TokenHash  string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// DurationSample ist die gemessene Dauer eines LLM-Aufrufs; daraus werden Restzeiten geschätzt
+type DurationSample struct {
+	Step      string    `json:"step"`
+	Model     string    `json:"model"`
+	Size      int       `json:"size"` // Eingabegröße in Zeichen
+	Seconds   float64   `json:"seconds"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // StudySession repräsentiert eine Lernsitzung
 type StudySession struct {
-	ID          string    `json:"id"`
-	StudyPlanID string    `json:"study_plan_id"`
-	TopicID     string    `json:"topic_id"`
-	StartedAt   time.Time `json:"started_at"`
-	EndedAt     *time.Time `json:"ended_at,omitempty"`
-	Duration    int       `json:"duration_minutes"`
-	QuestionsAnswered int `json:"questions_answered"`
-	CorrectAnswers    int `json:"correct_answers"`
+	ID                string     `json:"id"`
+	StudyPlanID       string     `json:"study_plan_id"`
+	TopicID           string     `json:"topic_id"`
+	StartedAt         time.Time  `json:"started_at"`
+	EndedAt           *time.Time `json:"ended_at,omitempty"`
+	Duration          int        `json:"duration_minutes"`
+	QuestionsAnswered int        `json:"questions_answered"`
+	CorrectAnswers    int        `json:"correct_answers"`
+	// Rückblick-Quiz nach Sitzungsende: pending, ready, failed oder skipped (nichts gelernt)
+	RecapStatus      string   `json:"recap_status,omitempty"`
+	RecapQuestionIDs []string `json:"recap_question_ids,omitempty"`
+	// Automatisch beendet, weil die Sitzung zu lange ohne Aktivität offen war
+	AutoClosed bool `json:"auto_closed,omitempty"`
 }
 
 // LearningProgress repräsentiert den Gesamtfortschritt
 type LearningProgress struct {
-	TotalTopics      int     `json:"total_topics"`
-	CompletedTopics  int     `json:"completed_topics"`
-	TotalQuestions   int     `json:"total_questions"`
-	AnsweredQuestions int    `json:"answered_questions"`
-	CorrectAnswers   int     `json:"correct_answers"`
-	TotalStudyTime   int     `json:"total_study_time_minutes"`
-	AverageScore     float64 `json:"average_score"`
-	DaysUntilExam    int     `json:"days_until_exam"`
-	OnTrack          bool    `json:"on_track"`
+	TotalTopics       int     `json:"total_topics"`
+	CompletedTopics   int     `json:"completed_topics"`
+	TotalQuestions    int     `json:"total_questions"`
+	AnsweredQuestions int     `json:"answered_questions"`
+	CorrectAnswers    int     `json:"correct_answers"`
+	TotalStudyTime    int     `json:"total_study_time_minutes"`
+	AverageScore      float64 `json:"average_score"`
+	DaysUntilExam     int     `json:"days_until_exam"`
+	OnTrack           bool    `json:"on_track"`
+}
+
+// TopicAnswerSpeed fasst die Antwortgeschwindigkeit eines Themas zusammen
+type TopicAnswerSpeed struct {
+	TopicID      string  `json:"topic_id"`
+	TopicName    string  `json:"topic_name"`
+	TimedAnswers int     `json:"timed_answers"`
+	AvgSeconds   float64 `json:"avg_seconds"`
+	LateAnswers  int     `json:"late_answers"`
 }
 
 // ChatMessage repräsentiert eine Nachricht im Lern-Chat
@@ -90,26 +434,275 @@ type ChatMessage struct {
 	Content   string    `json:"content"`
 	Timestamp time.Time `json:"timestamp"`
 	TopicID   string    `json:"topic_id,omitempty"`
+	// Chat mit einem Dokument: Seitenangaben der Antwort beziehen sich auf dieses Dokument
+	DocumentID string `json:"document_id,omitempty"`
+	// Neu erzeugte Antwort, die diese ersetzt (ersetzte Antworten fehlen im Verlauf)
+	ReplacedBy string `json:"replaced_by,omitempty"`
+}
+
+// ChatSession repräsentiert eine Chat-Sitzung mit ihrem Tutor-Modus
+type ChatSession struct {
+	ID        string    `json:"id"`
+	Mode      string    `json:"mode"` // standard, socratic, general (Thema wird je Frage automatisch gewählt)
+	TopicID   string    `json:"topic_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChatPin ist ein an eine Chat-Sitzung angehefteter Kontext, der unabhängig von der
+// Abschnittssuche in jeden Prompt der Sitzung eingeht
+type ChatPin struct {
+	ID        string    `json:"id"`
+	SessionID string    `json:"session_id"`
+	Kind      string    `json:"kind"`           // passage, glossary, note
+	RefID     string    `json:"ref_id"`         // Dokument, Glossar-Eintrag bzw. Notiz
+	Page      int       `json:"page,omitempty"` // Seite der Textstelle (0 = unbekannt)
+	Label     string    `json:"label"`
+	Content   string    `json:"content,omitempty"` // Text der Textstelle (Glossar und Notizen werden aktuell geladen)
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // Explanation repräsentiert eine Themenerklärung
 type Explanation struct {
-	TopicID     string   `json:"topic_id"`
-	Title       string   `json:"title"`
-	Content     string   `json:"content"`
-	KeyPoints   []string `json:"key_points"`
-	Examples    []string `json:"examples,omitempty"`
-	SourcePages []int    `json:"source_pages,omitempty"`
+	ID          string    `json:"id,omitempty"`
+	TopicID     string    `json:"topic_id"`
+	Title       string    `json:"title"`
+	Content     string    `json:"content"`
+	KeyPoints   []string  `json:"key_points"`
+	Examples    []string  `json:"examples,omitempty"`
+	SourcePages []int     `json:"source_pages,omitempty"`
+	ParentID    string    `json:"parent_id,omitempty"` // Erklärung, die diese Variante ersetzt
+	Feedback    string    `json:"feedback,omitempty"`  // Rückmeldung, aus der die Variante entstand
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+}
+
+// Note ist eine eigene Markdown-Notiz zu einem Thema oder einer Frage
+type Note struct {
+	ID         string    `json:"id"`
+	TopicID    string    `json:"topic_id"`
+	QuestionID string    `json:"question_id,omitempty"`
+	Content    string    `json:"content"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// QuestionAttempt ist ein einzelner Antwortversuch zu einer Frage
+type QuestionAttempt struct {
+	ID            string    `json:"id"`
+	QuestionID    string    `json:"question_id"`
+	TopicID       string    `json:"topic_id"`
+	Answer        string    `json:"answer"`
+	IsCorrect     bool      `json:"is_correct"`
+	Score         int       `json:"score,omitempty"` // 0-100, falls vom Modell geliefert
+	Feedback      string    `json:"feedback,omitempty"`
+	HintsUsed     int       `json:"hints_used"`
+	AnswerSeconds *int      `json:"answer_seconds,omitempty"` // nil = nicht gemessen
+	AnsweredLate  bool      `json:"answered_late,omitempty"`
+	ErrorType     string    `json:"error_type,omitempty"` // nur bei falschen Antworten, siehe ErrorTypes
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Fehlerarten falscher Antworten, vom Bewerter klassifiziert
+const (
+	ErrorConceptConfusion = "concept_confusion" // Begriffe oder Konzepte verwechselt
+	ErrorMissingTerm      = "missing_term"      // wichtiger Fachbegriff oder Kernpunkt fehlt
+	ErrorCalculationSlip  = "calculation_slip"  // Rechen- oder Umformungsfehler bei richtigem Ansatz
+	ErrorMisreadQuestion  = "misread_question"  // Frage falsch gelesen, an ihr vorbei geantwortet
+)
+
+// ErrorTypes listet alle Fehlerarten in fester Reihenfolge
+var ErrorTypes = []string{ErrorConceptConfusion, ErrorMissingTerm, ErrorCalculationSlip, ErrorMisreadQuestion}
+
+// LeitnerBox fasst die Fragen einer Leitner-Box zusammen (Box 0 = noch nie beantwortet)
+type LeitnerBox struct {
+	Box          int    `json:"box"`
+	Label        string `json:"label"`
+	IntervalDays int    `json:"interval_days"`
+	Count        int    `json:"count"`
+	Due          int    `json:"due"` // heute zur Wiederholung fällig
+}
+
+// InterleavePair hält fest, wann zwei Themen zuletzt gemischt abgefragt wurden (TopicA < TopicB)
+type InterleavePair struct {
+	StudyPlanID string    `json:"study_plan_id"`
+	TopicA      string    `json:"topic_a"`
+	TopicB      string    `json:"topic_b"`
+	Count       int       `json:"count"`
+	LastAt      time.Time `json:"last_at"`
+}
+
+// GoalProgress ist der Stand eines einzelnen Tagesziels
+type GoalProgress struct {
+	Goal      string `json:"goal"` // minutes, questions, flashcards
+	Target    int    `json:"target"`
+	Done      int    `json:"done"`
+	Completed bool   `json:"completed"`
+}
+
+// DailyGoalDay fasst die Zielerreichung eines Tages zusammen
+type DailyGoalDay struct {
+	Date      string         `json:"date"` // YYYY-MM-DD (lokale Zeit)
+	Goals     []GoalProgress `json:"goals"`
+	Completed bool           `json:"completed"`
+}
+
+// SemesterDay ist ein Tag im gemeinsamen Plan für mehrere Prüfungen
+type SemesterDay struct {
+	Date             string          `json:"date"` // YYYY-MM-DD
+	AvailableMinutes int             `json:"available_minutes"`
+	PlannedMinutes   int             `json:"planned_minutes"`
+	Exams            []string        `json:"exams,omitempty"` // IDs der Pläne mit Prüfung an diesem Tag
+	Blocks           []SemesterBlock `json:"blocks"`
+}
+
+// SemesterBlock ist die Lernzeit für ein Thema eines Plans an einem Tag
+type SemesterBlock struct {
+	PlanID    string `json:"plan_id"`
+	PlanName  string `json:"plan_name"`
+	TopicID   string `json:"topic_id"`
+	TopicName string `json:"topic_name"`
+	Minutes   int    `json:"minutes"`
+}
+
+// SemesterSubject fasst die Planung eines Fachs (Lernplans) zusammen
+type SemesterSubject struct {
+	PlanID           string `json:"plan_id"`
+	Name             string `json:"name"`
+	ExamDate         Date   `json:"exam_date"`
+	RequiredMinutes  int    `json:"required_minutes"`
+	AllocatedMinutes int    `json:"allocated_minutes"`
+	ShortfallMinutes int    `json:"shortfall_minutes"` // fehlt bis zur Prüfung
+}
+
+// DifficultyCurve zeigt, wie sich Lernzeit und Schwierigkeit eines Plans auf die Tage bis zur Prüfung verteilen
+type DifficultyCurve struct {
+	PlanID   string          `json:"plan_id"`
+	Start    string          `json:"start"` // YYYY-MM-DD
+	ExamDate Date            `json:"exam_date"`
+	Days     []DifficultyDay `json:"days"`
+	// Nach Minuten gewichtete Schwierigkeit der ersten und der zweiten Hälfte der Lernzeit
+	FirstHalfDifficulty  float64 `json:"first_half_difficulty"`
+	SecondHalfDifficulty float64 `json:"second_half_difficulty"`
+	// Anteil der Minuten schwerer Themen, der auf die letzten Lerntage fällt (0-1), und diese Themen
+	LateHardShare    float64  `json:"late_hard_share"`
+	LateHardTopics   []string `json:"late_hard_topics"`
+	ShortfallMinutes int      `json:"shortfall_minutes"` // passt bis zur Prüfung nicht mehr hinein
+	Warnings         []string `json:"warnings"`
+}
+
+// DifficultyDay ist ein Tag der Schwierigkeitskurve
+type DifficultyDay struct {
+	Date             string            `json:"date"` // YYYY-MM-DD
+	AvailableMinutes int               `json:"available_minutes"`
+	PlannedMinutes   int               `json:"planned_minutes"`
+	AvgDifficulty    float64           `json:"avg_difficulty"` // nach Minuten gewichtet, 0 = nichts geplant
+	MaxDifficulty    int               `json:"max_difficulty"`
+	HardMinutes      int               `json:"hard_minutes"` // Minuten mit Themen ab Schwierigkeit 4
+	Topics           []DifficultyBlock `json:"topics"`
+}
+
+// DifficultyBlock ist die Lernzeit für ein Thema an einem Tag der Schwierigkeitskurve
+type DifficultyBlock struct {
+	TopicID    string `json:"topic_id"`
+	TopicName  string `json:"topic_name"`
+	Minutes    int    `json:"minutes"`
+	Difficulty int    `json:"difficulty"`
+}
+
+// Flag markiert eine Frage oder Erklärung zur späteren Wiederholung
+type Flag struct {
+	ID         string     `json:"id"`
+	ItemType   string     `json:"item_type"` // question, explanation
+	ItemID     string     `json:"item_id"`
+	TopicID    string     `json:"topic_id"`
+	Reason     string     `json:"reason,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// SyllabusItem ist ein Punkt aus dem offiziellen Modulhandbuch bzw. der Prüfungsthemenliste
+type SyllabusItem struct {
+	ID          string    `json:"id"`
+	StudyPlanID string    `json:"study_plan_id"`
+	Text        string    `json:"text"`
+	TopicIDs    []string  `json:"topic_ids"` // zugeordnete Themen (leer = nicht zugeordnet)
+	Order       int       `json:"order"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 // GlossaryItem repräsentiert einen Glossar-Eintrag
 type GlossaryItem struct {
-	ID         string   `json:"id"`
-	Term       string   `json:"term"`
-	Category   string   `json:"category"` // definition, formula, concept, abbreviation, other
-	Definition string   `json:"definition"`
-	Details    string   `json:"details,omitempty"`
-	Related    []string `json:"related,omitempty"`
+	ID         string    `json:"id"`
+	Term       string    `json:"term"`
+	Category   string    `json:"category"` // definition, formula, concept, abbreviation, other
+	Definition string    `json:"definition"`
+	Details    string    `json:"details,omitempty"`
+	Related    []string  `json:"related,omitempty"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 }
+
+// Mnemonic ist eine Merkhilfe zu einem Fachbegriff eines Themas
+type Mnemonic struct {
+	ID          string    `json:"id"`
+	TopicID     string    `json:"topic_id"`
+	Term        string    `json:"term"`
+	Kind        string    `json:"kind"` // eselsbruecke, analogy, memory_aid
+	Content     string    `json:"content"`
+	GlossaryIDs []string  `json:"glossary_ids,omitempty"` // passende Glossar-Einträge
+	QuestionIDs []string  `json:"question_ids,omitempty"` // Fragen (Karteikarten), bei denen sie hilft
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WorkedExample ist eine Schritt für Schritt vorgerechnete Beispielaufgabe
+type WorkedExample struct {
+	ID         string       `json:"id"`
+	TopicID    string       `json:"topic_id"`
+	Title      string       `json:"title"`
+	Problem    string       `json:"problem"`
+	Steps      []WorkedStep `json:"steps"`
+	Answer     string       `json:"answer"`
+	Pitfalls   []string     `json:"pitfalls,omitempty"` // typische Fehler
+	Difficulty int          `json:"difficulty"`         // 1-5
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// WorkedStep ist ein Zwischenschritt eines Rechenbeispiels
+type WorkedStep struct {
+	Explanation string `json:"explanation"`
+	Calculation string `json:"calculation,omitempty"`
+	Result      string `json:"result,omitempty"`
+}
+
+// TeachBack ist eine eigene Erklärung eines Themas mit der Bewertung des Tutors (Feynman-Methode)
+type TeachBack struct {
+	ID             string    `json:"id"`
+	TopicID        string    `json:"topic_id"`
+	Explanation    string    `json:"explanation"`
+	Completeness   int       `json:"completeness"` // 0-100
+	Correctness    int       `json:"correctness"`  // 0-100
+	MissingPoints  []string  `json:"missing_points"`
+	Misconceptions []string  `json:"misconceptions"`
+	Strengths      []string  `json:"strengths"`
+	Feedback       string    `json:"feedback"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Comparison ist eine Vergleichstabelle zwischen zwei oder mehr Themen bzw. Begriffen
+type Comparison struct {
+	ID           string          `json:"id"`
+	Key          string          `json:"-"` // sortierte Themen-/Glossar-IDs, zum Wiederverwenden
+	Subjects     []string        `json:"subjects"`
+	TopicIDs     []string        `json:"topic_ids,omitempty"`
+	GlossaryIDs  []string        `json:"glossary_ids,omitempty"`
+	Rows         []ComparisonRow `json:"rows"`
+	Similarities []string        `json:"similarities"`
+	Differences  []string        `json:"differences"`
+	Summary      string          `json:"summary,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// ComparisonRow ist ein Vergleichskriterium mit einem Wert je Gegenstand (Reihenfolge wie Subjects)
+type ComparisonRow struct {
+	Criterion string   `json:"criterion"`
+	Values    []string `json:"values"`
+}
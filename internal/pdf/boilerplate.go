@@ -0,0 +1,122 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Erst ab so vielen Seiten lässt sich wiederkehrender Text zuverlässig erkennen
+const minBoilerplatePages = 4
+
+// Boilerplate ist das Ergebnis von StripBoilerplate
+type Boilerplate struct {
+	Content        string
+	Lines          []string // entfernte Kopf-/Fußzeilen (je einmal)
+	DuplicatePages []int    // Seiten, die nur die vorige Seite wiederholen
+}
+
+// StripBoilerplate entfernt Kopf- und Fußzeilen, Copyright-Hinweise u.ä., die auf mehr als
+// der Hälfte der Seiten vorkommen, sowie Seiten, die ihre Vorgängerseite nur wiederholen
+// (z.B. Folien mit Animationsschritten). Die "--- Seite N ---"-Markierungen bleiben erhalten.
+func StripBoilerplate(content string) *Boilerplate {
+	result := &Boilerplate{Lines: []string{}, DuplicatePages: []int{}}
+
+	// Seiten aufteilen; pages[0] ist der Text vor der ersten Markierung
+	type page struct {
+		marker string
+		number int
+		lines  []string
+	}
+	pages := []page{{}}
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--- Seite ") && strings.HasSuffix(trimmed, " ---") {
+			p := page{marker: line, number: len(pages)}
+			fmt.Sscanf(trimmed, "--- Seite %d ---", &p.number)
+			pages = append(pages, p)
+			continue
+		}
+		pages[len(pages)-1].lines = append(pages[len(pages)-1].lines, line)
+	}
+
+	// Auf wie vielen Seiten kommt eine Zeile vor? Ziffern zählen nicht ("Seite 3 von 20")
+	pageCount := len(pages) - 1
+	boilerplate := make(map[string]bool)
+	if pageCount >= minBoilerplatePages {
+		onPages := make(map[string]int)
+		for _, p := range pages[1:] {
+			seen := make(map[string]bool)
+			for _, line := range p.lines {
+				key := boilerplateKey(line)
+				if key != "" && !seen[key] {
+					seen[key] = true
+					onPages[key]++
+				}
+			}
+		}
+		for key, n := range onPages {
+			if n*2 > pageCount {
+				boilerplate[key] = true
+			}
+		}
+	}
+
+	var sb strings.Builder
+	reported := make(map[string]bool)
+	previous := ""
+	for i, p := range pages {
+		if i > 0 {
+			sb.WriteString(p.marker + "\n")
+		}
+		var kept []string
+		for _, line := range p.lines {
+			key := boilerplateKey(line)
+			if i > 0 && boilerplate[key] {
+				if !reported[key] {
+					reported[key] = true
+					result.Lines = append(result.Lines, strings.TrimSpace(line))
+				}
+				continue
+			}
+			kept = append(kept, line)
+		}
+
+		text := strings.TrimSpace(strings.Join(kept, "\n"))
+		if i > 0 && text != "" && text == previous {
+			result.DuplicatePages = append(result.DuplicatePages, p.number)
+			continue
+		}
+		if i > 0 {
+			previous = text
+		}
+		if len(kept) > 0 {
+			sb.WriteString(strings.Join(kept, "\n"))
+			if i < len(pages)-1 {
+				sb.WriteString("\n")
+			}
+		}
+	}
+
+	result.Content = sb.String()
+	return result
+}
+
+// boilerplateKey vereinheitlicht eine Zeile für den Vergleich zwischen Seiten:
+// Kleinschreibung, einfache Leerzeichen, Ziffernfolgen als "#"
+func boilerplateKey(line string) string {
+	var sb strings.Builder
+	digit := false
+	for _, r := range strings.ToLower(strings.Join(strings.Fields(line), " ")) {
+		if unicode.IsDigit(r) {
+			if !digit {
+				sb.WriteByte('#')
+			}
+			digit = true
+			continue
+		}
+		digit = false
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,216 @@
+package pdf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultChunkTokens ist die Zielgröße eines Chunks in Tokens
+const DefaultChunkTokens = 500
+
+// charsPerToken: grobe Schätzung für deutsche Texte (wie im LLM-Paket)
+const charsPerToken = 3
+
+// Chunk ist ein zusammenhängender Textabschnitt mit Kapitel und Startseite
+type Chunk struct {
+	Section string `json:"section,omitempty"`
+	Page    int    `json:"page,omitempty"`
+	Text    string `json:"text"`
+}
+
+// ExtractChunks teilt den Text in Chunks für die LLM-Verarbeitung. Ein Chunk endet an
+// Überschriften, danach bevorzugt an Absätzen und nur bei langen Absätzen an Satzgrenzen.
+// targetTokens ist die Zielgröße (0 = DefaultChunkTokens).
+func ExtractChunks(content string, targetTokens int) []Chunk {
+	if targetTokens <= 0 {
+		targetTokens = DefaultChunkTokens
+	}
+	target := targetTokens * charsPerToken
+
+	var chunks []Chunk
+	var current strings.Builder
+	var paragraph []string
+	section, page, chunkPage := "", 0, 0
+
+	flush := func() {
+		if text := strings.TrimSpace(current.String()); text != "" {
+			chunks = append(chunks, Chunk{Section: section, Page: chunkPage, Text: text})
+		}
+		current.Reset()
+	}
+	add := func(unit string) {
+		if current.Len() > 0 && utf8.RuneCountInString(current.String())+utf8.RuneCountInString(unit) > target {
+			flush()
+		}
+		if current.Len() == 0 {
+			chunkPage = page
+		}
+		current.WriteString(unit)
+	}
+	endParagraph := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		text := strings.Join(paragraph, " ")
+		paragraph = paragraph[:0]
+		if utf8.RuneCountInString(text) <= target {
+			add(text + "\n\n")
+			return
+		}
+		for _, sentence := range splitSentences(text) {
+			for _, part := range splitLong(sentence, target) {
+				add(part + " ")
+			}
+		}
+		add("\n\n")
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "--- Seite ") && strings.HasSuffix(trimmed, " ---"):
+			endParagraph()
+			fmt.Sscanf(trimmed, "--- Seite %d ---", &page)
+		case trimmed == "":
+			endParagraph()
+		case isHeading(trimmed):
+			endParagraph()
+			flush()
+			section = trimmed
+			add(trimmed + "\n")
+		default:
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	endParagraph()
+	flush()
+
+	return chunks
+}
+
+// splitSentences trennt einen Absatz an Satzenden (. ! ? gefolgt von Leerzeichen und Großbuchstabe/Ziffer)
+func splitSentences(text string) []string {
+	var sentences []string
+	runes := []rune(text)
+	start := 0
+	for i := 0; i+2 < len(runes); i++ {
+		if !strings.ContainsRune(".!?", runes[i]) || runes[i+1] != ' ' {
+			continue
+		}
+		next := runes[i+2]
+		if !unicode.IsUpper(next) && !unicode.IsDigit(next) {
+			continue
+		}
+		sentences = append(sentences, strings.TrimSpace(string(runes[start:i+1])))
+		start = i + 2
+	}
+	if rest := strings.TrimSpace(string(runes[start:])); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
+
+// splitLong teilt einen Satz ohne Satzzeichen (z.B. Tabellen) an Wortgrenzen
+func splitLong(text string, size int) []string {
+	if utf8.RuneCountInString(text) <= size {
+		return []string{text}
+	}
+	var parts []string
+	var part strings.Builder
+	for _, word := range strings.Fields(text) {
+		if part.Len() > 0 && utf8.RuneCountInString(part.String())+1+utf8.RuneCountInString(word) > size {
+			parts = append(parts, part.String())
+			part.Reset()
+		}
+		if part.Len() > 0 {
+			part.WriteByte(' ')
+		}
+		part.WriteString(word)
+	}
+	if part.Len() > 0 {
+		parts = append(parts, part.String())
+	}
+	return parts
+}
+
+// RelevantChunks wählt die Chunks mit den meisten Suchbegriffen aus, bis maxChars erreicht ist,
+// in der ursprünglichen Reihenfolge. Ohne Treffer werden die ersten Chunks genommen.
+func RelevantChunks(chunks []Chunk, query string, maxChars int) []Chunk {
+	terms := queryTerms(query)
+	scores := make([]int, len(chunks))
+	for i, c := range chunks {
+		text := strings.ToLower(c.Section + " " + c.Text)
+		for _, term := range terms {
+			if n := strings.Count(text, term); n > 0 {
+				// Verschiedene Begriffe zählen mehr als Wiederholungen
+				scores[i] += 10 + min(n, 10)
+			}
+		}
+	}
+
+	order := make([]int, len(chunks))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return scores[order[a]] > scores[order[b]]
+	})
+
+	var picked []int
+	used := 0
+	for _, i := range order {
+		size := utf8.RuneCountInString(chunks[i].Text)
+		if used+size > maxChars {
+			if len(picked) == 0 {
+				picked = append(picked, i) // mindestens ein Chunk
+			}
+			continue
+		}
+		picked = append(picked, i)
+		used += size
+	}
+	sort.Ints(picked)
+
+	result := make([]Chunk, 0, len(picked))
+	for _, i := range picked {
+		result = append(result, chunks[i])
+	}
+	return result
+}
+
+// queryTerms zerlegt eine Suchanfrage in Begriffe ohne Funktionswörter
+func queryTerms(query string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, w := range strings.Fields(strings.ToLower(query)) {
+		w = strings.TrimFunc(w, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if utf8.RuneCountInString(w) < 3 || seen[w] || languageStopwords["de"][w] || languageStopwords["en"][w] {
+			continue
+		}
+		seen[w] = true
+		terms = append(terms, w)
+	}
+	return terms
+}
+
+// FormatChunks setzt Chunks mit Kapitel- und Seitenangabe zu einem Kontext zusammen
+func FormatChunks(chunks []Chunk) string {
+	var sb strings.Builder
+	for _, c := range chunks {
+		var label []string
+		if c.Section != "" {
+			label = append(label, c.Section)
+		}
+		if c.Page > 0 {
+			label = append(label, fmt.Sprintf("Seite %d", c.Page))
+		}
+		if len(label) > 0 {
+			sb.WriteString("[" + strings.Join(label, ", ") + "]\n")
+		}
+		sb.WriteString(c.Text + "\n\n")
+	}
+	return sb.String()
+}
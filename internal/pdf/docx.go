@@ -0,0 +1,160 @@
+package pdf
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"lernplattform/internal/models"
+)
+
+// Obergrenze für den entpackten Dokumenttext (word/document.xml), Schutz vor ZIP-Bomben
+const maxDocxXMLBytes = 100 << 20
+
+// ParseDOCX liest den Text eines Word-Dokuments (.docx). Seitenumbrüche werden zu
+// "--- Seite N ---"-Markierungen, Überschriften-Formatvorlagen ergeben das Inhaltsverzeichnis.
+func (p *Parser) ParseDOCX(reader io.Reader, filename string) (*models.Document, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("fehler beim Lesen der DOCX: %w", err)
+	}
+
+	var body io.ReadCloser
+	for _, f := range archive.File {
+		if f.Name == "word/document.xml" {
+			if f.UncompressedSize64 > maxDocxXMLBytes {
+				return nil, fmt.Errorf("DOCX-Inhalt zu groß (maximal %d MB)", maxDocxXMLBytes>>20)
+			}
+			if body, err = f.Open(); err != nil {
+				return nil, fmt.Errorf("fehler beim Lesen der DOCX: %w", err)
+			}
+			break
+		}
+	}
+	if body == nil {
+		return nil, fmt.Errorf("keine gültige DOCX-Datei (word/document.xml fehlt)")
+	}
+	defer body.Close()
+
+	var content strings.Builder
+	var paragraph strings.Builder
+	var headings []models.TocEntry
+	page, level := 1, 0
+	pageHasText := false
+	content.WriteString("\n--- Seite 1 ---\n")
+
+	endParagraph := func() {
+		text := strings.TrimSpace(paragraph.String())
+		paragraph.Reset()
+		if text == "" {
+			return
+		}
+		content.WriteString(text + "\n")
+		pageHasText = true
+		if level > 0 {
+			headings = append(headings, models.TocEntry{Title: text, Level: min(level, maxTocLevels), Page: page})
+		}
+	}
+
+	// Auch bei falscher Größenangabe im Archiv wird nicht mehr als das Limit entpackt
+	decoder := xml.NewDecoder(io.LimitReader(body, maxDocxXMLBytes))
+	inText := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fehler beim Lesen der DOCX: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				paragraph.Reset()
+				level = 0
+			case "pStyle":
+				level = headingLevel(xmlAttr(t, "val"))
+			case "t":
+				inText = true
+			case "tab":
+				paragraph.WriteString(" ")
+			case "br", "lastRenderedPageBreak":
+				if t.Name.Local == "br" && xmlAttr(t, "type") != "page" {
+					paragraph.WriteString(" ")
+					continue
+				}
+				// Word vermerkt einen erzwungenen Umbruch zusätzlich als lastRenderedPageBreak:
+				// eine Seite ohne Text zählt daher nicht
+				endParagraph()
+				if !pageHasText {
+					continue
+				}
+				pageHasText = false
+				page++
+				content.WriteString(fmt.Sprintf("\n--- Seite %d ---\n", page))
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				endParagraph()
+			}
+		case xml.CharData:
+			if inText {
+				paragraph.Write(t)
+			}
+		}
+	}
+
+	doc := &models.Document{
+		ID:          generateID(),
+		Name:        filename,
+		Content:     content.String(),
+		RawContent:  content.String(),
+		PageCount:   page,
+		UploadedAt:  time.Now(),
+		ProcessedAt: time.Now(),
+		Headings:    headings,
+	}
+	doc.Content = StripBoilerplate(doc.RawContent).Content
+	ApplyTextStats(doc)
+
+	return doc, nil
+}
+
+// headingLevel liest die Ebene aus einer Formatvorlage ("Heading2", "berschrift2", "Title"; 0 = keine Überschrift)
+func headingLevel(style string) int {
+	lower := strings.ToLower(style)
+	if lower == "title" || lower == "titel" {
+		return 1
+	}
+	for _, prefix := range []string{"heading", "berschrift", "überschrift"} {
+		if strings.HasPrefix(lower, prefix) {
+			level := 0
+			fmt.Sscanf(lower[len(prefix):], "%d", &level)
+			return max(level, 1)
+		}
+	}
+	return 0
+}
+
+// xmlAttr liefert ein Attribut unabhängig vom Namensraum (w:val)
+func xmlAttr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,194 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+	"lernplattform/internal/models"
+)
+
+// Abbildungen unter dieser Kantenlänge (Pixel) sind meist Logos, Icons oder Aufzählungszeichen
+const minFigureSize = 150
+
+// Höchstens so viele Abbildungen je Dokument
+const maxFigures = 60
+
+// Größere Bilder werden übersprungen (Fotos in Druckauflösung, Scans ganzer Seiten)
+const maxFigureBytes = 4 << 20
+
+// ReadFigures liest die Abbildungen einer PDF-Datei (für vor dem Speichern der Abbildungen eingelesene Dokumente)
+func ReadFigures(filePath string) ([]models.Figure, error) {
+	f, r, err := pdf.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readerFigures(r, f), nil
+}
+
+// readerFigures sammelt die eingebetteten Bilder aller Seiten. JPEG-Bilder (DCTDecode) werden
+// unverändert übernommen, unkomprimierte bzw. Flate-komprimierte RGB- und Graustufenbilder als PNG.
+// Andere Formate (JBIG2, CCITT, Farbpaletten) werden übersprungen.
+func readerFigures(r *pdf.Reader, file io.ReaderAt) []models.Figure {
+	var figures []models.Figure
+	seen := make(map[string]bool) // dasselbe Bild auf mehreren Seiten (z.B. Logo) nur einmal
+	for pageNum := 1; pageNum <= r.NumPage() && len(figures) < maxFigures; pageNum++ {
+		page := r.Page(pageNum)
+		if page.V.IsNull() {
+			continue
+		}
+		xobjects := page.Resources().Key("XObject")
+		for _, name := range xobjects.Keys() {
+			obj := xobjects.Key(name)
+			if obj.Key("Subtype").Name() != "Image" {
+				continue
+			}
+			key := obj.String()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			figure, ok := readFigure(obj, file)
+			if !ok {
+				continue
+			}
+			figure.Page = pageNum
+			figures = append(figures, figure)
+			if len(figures) >= maxFigures {
+				break
+			}
+		}
+	}
+	return figures
+}
+
+// readFigure liest ein Bild-XObject; defekte Bilder werden übersprungen (die Bibliothek bricht dort mit panic ab)
+func readFigure(obj pdf.Value, file io.ReaderAt) (figure models.Figure, ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
+	width, height := int(obj.Key("Width").Int64()), int(obj.Key("Height").Int64())
+	if width < minFigureSize || height < minFigureSize {
+		return figure, false
+	}
+	figure.Width, figure.Height = width, height
+
+	switch filterName(obj) {
+	case "DCTDecode":
+		// Der Stream ist bereits eine JPEG-Datei; die Bibliothek kann ihn nicht dekodieren,
+		// daher werden die Rohdaten an der Position des Streams gelesen
+		length := obj.Key("Length").Int64()
+		offset, err := streamOffset(obj)
+		if err != nil || length <= 0 || length > maxFigureBytes {
+			return figure, false
+		}
+		data := make([]byte, length)
+		if _, err := file.ReadAt(data, offset); err != nil {
+			return figure, false
+		}
+		if !bytes.HasPrefix(data, []byte{0xFF, 0xD8}) {
+			return figure, false // z.B. verschlüsselte PDF
+		}
+		figure.MimeType, figure.Data = "image/jpeg", data
+		return figure, true
+
+	case "", "FlateDecode":
+		if obj.Key("BitsPerComponent").Int64() != 8 {
+			return figure, false
+		}
+		channels := 0
+		switch obj.Key("ColorSpace").Name() {
+		case "DeviceRGB":
+			channels = 3
+		case "DeviceGray":
+			channels = 1
+		default:
+			return figure, false
+		}
+		size := width * height * channels
+		if size > 4*maxFigureBytes || hasPredictor(obj) {
+			return figure, false
+		}
+		// Nicht mehr entpacken als das Bild braucht (Schutz vor aufgeblähten Streams)
+		pixels, err := io.ReadAll(io.LimitReader(obj.Reader(), int64(size)+1))
+		if err != nil || len(pixels) < size {
+			return figure, false
+		}
+		data, err := encodePNG(pixels, width, height, channels)
+		if err != nil || len(data) > maxFigureBytes {
+			return figure, false
+		}
+		figure.MimeType, figure.Data = "image/png", data
+		return figure, true
+	}
+	return figure, false
+}
+
+// filterName liefert den einzigen Filter eines Streams ("" = keiner, "?" = mehrere)
+func filterName(obj pdf.Value) string {
+	filter := obj.Key("Filter")
+	switch filter.Kind() {
+	case pdf.Name:
+		return filter.Name()
+	case pdf.Array:
+		if filter.Len() == 1 {
+			return filter.Index(0).Name()
+		}
+		return "?"
+	}
+	return ""
+}
+
+// hasPredictor meldet, ob ein Stream mit Prädiktor (PNG- oder TIFF-Zeilenfilter) kodiert ist;
+// solche Pixeldaten lassen sich nicht direkt als Bild übernehmen
+func hasPredictor(obj pdf.Value) bool {
+	parms := obj.Key("DecodeParms")
+	if parms.Kind() == pdf.Array {
+		if parms.Len() == 0 {
+			return false
+		}
+		parms = parms.Index(0)
+	}
+	return parms.Kind() == pdf.Dict && parms.Key("Predictor").Int64() > 1
+}
+
+// streamOffset liest die Dateiposition eines Streams aus seiner Textdarstellung ("<<...>>@1234")
+func streamOffset(obj pdf.Value) (int64, error) {
+	s := obj.String()
+	at := strings.LastIndex(s, "@")
+	if at < 0 {
+		return 0, fmt.Errorf("keine Stream-Position")
+	}
+	return strconv.ParseInt(s[at+1:], 10, 64)
+}
+
+func encodePNG(pixels []byte, width, height, channels int) ([]byte, error) {
+	var img image.Image
+	if channels == 1 {
+		gray := image.NewGray(image.Rect(0, 0, width, height))
+		copy(gray.Pix, pixels)
+		img = gray
+	} else {
+		rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+		for i := 0; i < width*height; i++ {
+			rgba.Set(i%width, i/width, color.RGBA{pixels[3*i], pixels[3*i+1], pixels[3*i+2], 255})
+		}
+		img = rgba
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
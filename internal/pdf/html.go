@@ -0,0 +1,127 @@
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"lernplattform/internal/models"
+)
+
+// Elemente, deren Inhalt kein Lernstoff ist (Navigation, Skripte, Seitenrahmen)
+var skippedHTMLElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true,
+	"nav": true, "footer": true, "aside": true, "form": true, "svg": true,
+}
+
+// Block-Elemente beginnen eine neue Zeile
+var blockHTMLElements = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "section": true, "article": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"pre": true, "blockquote": true, "dt": true, "dd": true, "table": true, "hr": true,
+}
+
+// ParseHTML liest den sichtbaren Text einer HTML-Seite. Die Seite zählt als eine Seite,
+// h1-h3 ergeben das Inhaltsverzeichnis; der <title> wird zum Namen, wenn filename leer ist.
+func (p *Parser) ParseHTML(reader io.Reader, filename string) (*models.Document, error) {
+	tokenizer := html.NewTokenizer(reader)
+
+	var content strings.Builder
+	var line strings.Builder
+	var headings []models.TocEntry
+	var title strings.Builder
+	skip, heading := 0, 0
+	inTitle := false
+	content.WriteString("\n--- Seite 1 ---\n")
+
+	endLine := func() {
+		text := strings.Join(strings.Fields(line.String()), " ")
+		line.Reset()
+		if text == "" {
+			return
+		}
+		content.WriteString(text + "\n")
+		if heading > 0 {
+			headings = append(headings, models.TocEntry{Title: text, Level: heading, Page: 1})
+		}
+	}
+
+	for {
+		tt := tokenizer.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return nil, fmt.Errorf("fehler beim Lesen der HTML-Seite: %w", err)
+			}
+			endLine()
+			return p.htmlDocument(content.String(), filename, strings.TrimSpace(title.String()), headings), nil
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			if tag == "title" {
+				inTitle = true
+			}
+			if skippedHTMLElements[tag] {
+				if tt == html.StartTagToken {
+					skip++
+				}
+				continue
+			}
+			if blockHTMLElements[tag] {
+				endLine()
+			}
+			if len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '3' {
+				heading = int(tag[1] - '0')
+			}
+
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			if tag == "title" {
+				inTitle = false
+			}
+			if skippedHTMLElements[tag] {
+				skip = max(skip-1, 0)
+				continue
+			}
+			if blockHTMLElements[tag] {
+				endLine()
+				if len(tag) == 2 && tag[0] == 'h' {
+					heading = 0
+				}
+			}
+
+		case html.TextToken:
+			text := string(tokenizer.Text())
+			if inTitle {
+				title.WriteString(text)
+				continue
+			}
+			if skip == 0 {
+				line.WriteString(text + " ")
+			}
+		}
+	}
+}
+
+// htmlDocument baut das Dokument aus dem gelesenen HTML-Text
+func (p *Parser) htmlDocument(content, filename, title string, headings []models.TocEntry) *models.Document {
+	if filename == "" {
+		filename = title
+	}
+	doc := &models.Document{
+		ID:          generateID(),
+		Name:        filename,
+		Content:     content,
+		RawContent:  content,
+		PageCount:   1,
+		UploadedAt:  time.Now(),
+		ProcessedAt: time.Now(),
+		Headings:    headings,
+	}
+	ApplyTextStats(doc)
+	return doc
+}
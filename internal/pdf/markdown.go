@@ -0,0 +1,161 @@
+package pdf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"lernplattform/internal/models"
+)
+
+var (
+	// [[Ziel]], [[Ziel|Alias]], [[Ziel#Abschnitt]] und eingebettete ![[Ziel]]
+	wikiLinkPattern = regexp.MustCompile(`!?\[\[([^\[\]]+)\]\]`)
+	// [Text](ziel.md) – so verlinkt der Notion-Export seine Seiten
+	markdownLinkPattern = regexp.MustCompile(`!?\[([^\[\]]*)\]\(([^()\s]+)\)`)
+	// Notion hängt eine 32-stellige ID an Dateinamen: "Regelkreis 1a2b…9f.md"
+	notionIDPattern = regexp.MustCompile(`\s+[0-9a-f]{32}$`)
+	headingPattern  = regexp.MustCompile(`^(#{1,6})\s+(.+?)(?:\s+#+)?$`)
+)
+
+// Endungen von Anhängen, auf die Notizen verlinken (Bilder, PDFs usw.), keine Notizen
+var attachmentExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".webp": true, ".bmp": true,
+	".pdf": true, ".docx": true, ".pptx": true, ".xlsx": true, ".csv": true, ".zip": true,
+	".mp3": true, ".wav": true, ".mp4": true, ".webm": true, ".mov": true,
+	".canvas": true, ".excalidraw": true,
+}
+
+// NoteName macht aus einem Dateinamen oder Linkziel den Namen der Notiz, über den verlinkt wird:
+// ohne Ordner, Endung .md, Abschnitt (#…), Alias (|…) und Notion-ID
+func NoteName(target string) string {
+	if i := strings.IndexAny(target, "|#^"); i >= 0 {
+		target = target[:i]
+	}
+	if unescaped, err := url.PathUnescape(target); err == nil {
+		target = unescaped
+	}
+	target = path.Base(strings.ReplaceAll(strings.TrimSpace(target), "\\", "/"))
+	if strings.EqualFold(path.Ext(target), ".md") {
+		target = target[:len(target)-3]
+	}
+	return strings.TrimSpace(notionIDPattern.ReplaceAllString(target, ""))
+}
+
+// ParseMarkdown liest eine Markdown-Notiz (Obsidian, Notion-Export). Die Notiz zählt als eine Seite,
+// #-Überschriften bis Ebene 3 ergeben das Inhaltsverzeichnis. Wiki-Links und Links auf andere .md-Dateien
+// werden im Text durch ihren Anzeigenamen ersetzt und als Links (noch ohne Kind/RefID) am Dokument vermerkt.
+func (p *Parser) ParseMarkdown(reader io.Reader, filename string) (*models.Document, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var content strings.Builder
+	var headings []models.TocEntry
+	var links []models.DocumentLink
+	seen := make(map[string]bool)
+	addLink := func(target string) {
+		name := NoteName(target)
+		if name == "" || seen[strings.ToLower(name)] {
+			return
+		}
+		seen[strings.ToLower(name)] = true
+		links = append(links, models.DocumentLink{Target: name})
+	}
+
+	content.WriteString("\n--- Seite 1 ---\n")
+	first, frontMatter, fence := true, false, false
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+
+		// YAML-Kopf (--- … ---) gehört nicht zum Text
+		if first && line == "---" {
+			first, frontMatter = false, true
+			continue
+		}
+		first = false
+		if frontMatter {
+			if line == "---" || line == "..." {
+				frontMatter = false
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = !fence
+			continue
+		}
+		if fence {
+			content.WriteString(line + "\n")
+			continue
+		}
+
+		line = wikiLinkPattern.ReplaceAllStringFunc(line, func(match string) string {
+			inner := wikiLinkPattern.FindStringSubmatch(match)[1]
+			target, alias, _ := strings.Cut(inner, "|")
+			if strings.HasPrefix(match, "!") && !isNoteTarget(target) {
+				return "" // eingebettetes Bild o.ä.
+			}
+			addLink(target)
+			if alias != "" {
+				return strings.TrimSpace(alias)
+			}
+			name, section, _ := strings.Cut(target, "#")
+			if section != "" {
+				return strings.TrimSpace(name) + " – " + strings.TrimSpace(section)
+			}
+			return strings.TrimSpace(name)
+		})
+		line = markdownLinkPattern.ReplaceAllStringFunc(line, func(match string) string {
+			parts := markdownLinkPattern.FindStringSubmatch(match)
+			text, target := parts[1], parts[2]
+			if strings.HasPrefix(match, "!") {
+				return text
+			}
+			if !strings.Contains(target, ":") && isNoteTarget(target) { // keine http:, mailto: usw.
+				addLink(target)
+			}
+			return text
+		})
+
+		if m := headingPattern.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			title := strings.TrimSpace(m[2])
+			line = title
+			if level := len(m[1]); level <= 3 && title != "" {
+				headings = append(headings, models.TocEntry{Title: title, Level: level, Page: 1})
+			}
+		}
+		content.WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("fehler beim Lesen der Notiz: %w", err)
+	}
+
+	text := content.String()
+	doc := &models.Document{
+		ID:          generateID(),
+		Name:        filename,
+		Content:     text,
+		RawContent:  text,
+		PageCount:   1,
+		UploadedAt:  time.Now(),
+		ProcessedAt: time.Now(),
+		Headings:    headings,
+		Links:       links,
+	}
+	ApplyTextStats(doc)
+	return doc, nil
+}
+
+// isNoteTarget ist false für Links auf Anhänge wie Bilder oder PDFs
+func isNoteTarget(target string) bool {
+	if i := strings.IndexAny(target, "|#^"); i >= 0 {
+		target = target[:i]
+	}
+	return !attachmentExtensions[strings.ToLower(path.Ext(strings.TrimSpace(target)))]
+}
@@ -0,0 +1,52 @@
+package pdf
+
+import (
+	"strings"
+	"unicode"
+)
+
+// matchChunkTokens: Größe der Abschnitte, mit denen ein Text verglichen wird (etwa eine Aufgabe)
+const matchChunkTokens = 150
+
+// minMatchTerms: kürzere Texte sind für einen inhaltlichen Vergleich zu unspezifisch
+const minMatchTerms = 4
+
+// Matcher vergleicht Texte (z.B. Fragen) inhaltlich mit einem Dokument. Das Dokument wird
+// einmal in kurze Abschnitte zerlegt, damit viele Vergleiche schnell gehen.
+type Matcher struct {
+	chunks []map[string]bool
+}
+
+// NewMatcher bereitet den Vergleich mit einem Dokumenttext vor
+func NewMatcher(content string) *Matcher {
+	m := &Matcher{}
+	for _, chunk := range ExtractChunks(content, matchChunkTokens) {
+		words := make(map[string]bool)
+		for _, w := range strings.Fields(strings.ToLower(chunk.Text)) {
+			words[strings.TrimFunc(w, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })] = true
+		}
+		m.chunks = append(m.chunks, words)
+	}
+	return m
+}
+
+// Best sucht den Abschnitt, der die meisten Begriffe von text enthält, und liefert den Anteil
+// der gefundenen Begriffe (0..1). Funktionswörter zählen nicht mit; bei weniger als vier
+// Begriffen ist das Ergebnis 0.
+func (m *Matcher) Best(text string) float64 {
+	terms := queryTerms(text)
+	if len(terms) < minMatchTerms {
+		return 0
+	}
+	best := 0.0
+	for _, words := range m.chunks {
+		found := 0
+		for _, term := range terms {
+			if words[term] {
+				found++
+			}
+		}
+		best = max(best, float64(found)/float64(len(terms)))
+	}
+	return best
+}
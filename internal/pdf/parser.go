@@ -6,8 +6,10 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/ledongthuc/pdf"
 	"lernplattform/internal/models"
@@ -54,10 +56,15 @@ func (p *Parser) ParseFile(filePath string) (*models.Document, error) {
 		Name:        filepath.Base(filePath),
 		Path:        filePath,
 		Content:     content.String(),
+		RawContent:  content.String(),
 		PageCount:   totalPages,
 		UploadedAt:  time.Now(),
 		ProcessedAt: time.Now(),
+		Headings:    readerHeadings(r),
+		Figures:     readerFigures(r, f),
 	}
+	doc.Content = StripBoilerplate(doc.RawContent).Content
+	ApplyTextStats(doc)
 
 	return doc, nil
 }
@@ -129,42 +136,40 @@ func (p *Parser) ParseFromReader(reader io.Reader, filename string) (*models.Doc
 		ID:          generateID(),
 		Name:        filename,
 		Content:     content.String(),
+		RawContent:  content.String(),
 		PageCount:   totalPages,
 		UploadedAt:  time.Now(),
 		ProcessedAt: time.Now(),
+		Headings:    readerHeadings(r),
+		Figures:     readerFigures(r, bytes.NewReader(data)),
 	}
+	doc.Content = StripBoilerplate(doc.RawContent).Content
+	ApplyTextStats(doc)
 
 	return doc, nil
 }
 
-// ExtractChunks teilt den Text in Chunks für die LLM-Verarbeitung
-func ExtractChunks(content string, chunkSize int, overlap int) []string {
-	if chunkSize <= 0 {
-		chunkSize = 2000
-	}
-	if overlap < 0 {
-		overlap = 200
-	}
-
-	var chunks []string
-	runes := []rune(content)
-	length := len(runes)
-
-	for i := 0; i < length; i += chunkSize - overlap {
-		end := i + chunkSize
-		if end > length {
-			end = length
-		}
+// Unter so vielen Wörtern pro Seite gilt ein PDF als eingescannt (nur Seitenzahlen, Kopfzeilen o.ä.)
+const minWordsPerPage = 5
 
-		chunk := string(runes[i:end])
-		chunks = append(chunks, chunk)
-
-		if end >= length {
-			break
+// ApplyTextStats berechnet Textlänge, Wortzahl, Sprache und ob das Dokument lesbaren Text enthält.
+// Die "--- Seite N ---"-Markierungen zählen nicht mit.
+func ApplyTextStats(doc *models.Document) {
+	length, words := 0, 0
+	for _, line := range strings.Split(doc.Content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--- Seite ") && strings.HasSuffix(trimmed, " ---") {
+			continue
 		}
+		length += utf8.RuneCountInString(trimmed)
+		words += len(strings.Fields(trimmed))
+	}
+	doc.ContentLength = length
+	doc.WordCount = words
+	doc.HasText = words >= minWordsPerPage*max(doc.PageCount, 1)
+	if doc.Language == "" {
+		doc.Language = DetectLanguage(doc.Content)
 	}
-
-	return chunks
 }
 
 // ExtractSections versucht, Abschnitte/Kapitel zu identifizieren
@@ -179,17 +184,7 @@ func ExtractSections(content string) []Section {
 			continue
 		}
 
-		// Heuristik für Überschriften
-		isHeading := false
-		if strings.HasPrefix(trimmed, "Kapitel") ||
-			strings.HasPrefix(trimmed, "Abschnitt") ||
-			strings.HasPrefix(trimmed, "Teil") ||
-			isNumberedHeading(trimmed) ||
-			(len(trimmed) < 80 && strings.ToUpper(trimmed) == trimmed && len(trimmed) > 3) {
-			isHeading = true
-		}
-
-		if isHeading {
+		if isHeading(trimmed) {
 			if currentSection != nil {
 				sections = append(sections, *currentSection)
 			}
@@ -209,12 +204,87 @@ func ExtractSections(content string) []Section {
 	return sections
 }
 
+// ExtractPages liefert die Seiten start bis end (inklusive) anhand der
+// "--- Seite N ---"-Markierungen. Ohne Markierungen wird "" zurückgegeben.
+func ExtractPages(content string, start, end int) string {
+	if end < start {
+		end = start
+	}
+
+	var result strings.Builder
+	page := 0
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--- Seite ") {
+			fmt.Sscanf(trimmed, "--- Seite %d ---", &page)
+			if page > end {
+				break
+			}
+		}
+		if page >= start && page <= end {
+			result.WriteString(line)
+			result.WriteString("\n")
+		}
+	}
+
+	return strings.TrimSpace(result.String())
+}
+
+// FindQuote sucht ein (vom LLM wiedergegebenes) Zitat im Text und liefert den Beginn
+// der Fundstelle oder -1. Groß-/Kleinschreibung und Zeilenumbrüche werden ignoriert;
+// gesucht wird mit den ersten Wörtern, da Zitate am Ende oft abweichen.
+func FindQuote(content, quote string) int {
+	words := strings.Fields(quote)
+	if len(words) > 8 {
+		words = words[:8]
+	}
+	if len(words) < 3 {
+		return -1
+	}
+
+	parts := make([]string, len(words))
+	for i, w := range words {
+		parts[i] = regexp.QuoteMeta(w)
+	}
+	re, err := regexp.Compile(`(?i)` + strings.Join(parts, `\s+`))
+	if err != nil {
+		return -1
+	}
+	if loc := re.FindStringIndex(content); loc != nil {
+		return loc[0]
+	}
+	return -1
+}
+
+// PageAt liefert die Seite, auf der die Textposition offset liegt (0 = keine Seitenmarkierung)
+func PageAt(content string, offset int) int {
+	if offset > len(content) {
+		offset = len(content)
+	}
+	i := strings.LastIndex(content[:offset], "--- Seite ")
+	if i < 0 {
+		return 0
+	}
+	var page int
+	fmt.Sscanf(content[i:], "--- Seite %d ---", &page)
+	return page
+}
+
 // Section repräsentiert einen erkannten Abschnitt
 type Section struct {
 	Title   string
 	Content string
 }
 
+// isHeading ist die Heuristik für Überschriften (Kapitel, Nummerierung, Großbuchstaben)
+func isHeading(trimmed string) bool {
+	return strings.HasPrefix(trimmed, "Kapitel") ||
+		strings.HasPrefix(trimmed, "Abschnitt") ||
+		strings.HasPrefix(trimmed, "Teil") ||
+		isNumberedHeading(trimmed) ||
+		(len(trimmed) < 80 && strings.ToUpper(trimmed) == trimmed && len(trimmed) > 3)
+}
+
 func isNumberedHeading(line string) bool {
 	// Prüft auf Muster wie "1.", "1.1", "1.1.1", etc.
 	if len(line) < 2 {
@@ -0,0 +1,139 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"lernplattform/internal/models"
+)
+
+// Lesegeschwindigkeit für Fachtexte in Wörtern pro Minute
+const readingWordsPerMinute = 180
+
+// Häufige Funktionswörter zur Spracherkennung
+var languageStopwords = map[string]map[string]bool{
+	"de": wordSet("der die das und ist nicht mit von den zu ein eine sich auf für des dem im werden wird oder auch als bei"),
+	"en": wordSet("the and is are of to in that it for with as on be this by an or from not which can"),
+}
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+// Mindestanzahl an Funktionswörtern für eine Sprachbestimmung
+const minLanguageHits = 10
+
+// DetectLanguage bestimmt die Sprache eines Texts über häufige Funktionswörter ("de", "en" oder "unknown")
+func DetectLanguage(content string) string {
+	hits := make(map[string]int)
+	for _, w := range strings.Fields(content) {
+		w = strings.ToLower(strings.TrimFunc(w, func(r rune) bool { return !unicode.IsLetter(r) }))
+		for lang, set := range languageStopwords {
+			if set[w] {
+				hits[lang]++
+			}
+		}
+	}
+	if hits["de"]+hits["en"] < minLanguageHits {
+		return "unknown"
+	}
+	if hits["en"] > hits["de"] {
+		return "en"
+	}
+	return "de"
+}
+
+// ComputeStats ermittelt Umfang, Sprache, Lesbarkeit und Abschnitte eines Dokuments
+func ComputeStats(doc *models.Document) *models.DocumentStats {
+	stats := &models.DocumentStats{
+		DocumentID: doc.ID,
+		PageCount:  doc.PageCount,
+		Sections:   []models.DocumentSection{},
+		ComputedAt: time.Now(),
+	}
+
+	syllables := 0
+	page := 0
+	var section *models.DocumentSection
+	for _, line := range strings.Split(doc.Content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "--- Seite ") && strings.HasSuffix(trimmed, " ---") {
+			fmt.Sscanf(trimmed, "--- Seite %d ---", &page)
+			continue
+		}
+		if isHeading(trimmed) {
+			stats.Sections = append(stats.Sections, models.DocumentSection{Title: trimmed, Page: page})
+			section = &stats.Sections[len(stats.Sections)-1]
+			continue
+		}
+
+		words := strings.Fields(trimmed)
+		stats.WordCount += len(words)
+		if section != nil {
+			section.WordCount += len(words)
+		}
+		for _, w := range words {
+			w = strings.ToLower(strings.TrimFunc(w, func(r rune) bool { return !unicode.IsLetter(r) }))
+			if w == "" {
+				continue
+			}
+			syllables += countSyllables(w)
+		}
+		stats.SentenceCount += strings.Count(trimmed, ". ") + strings.Count(trimmed, "? ") + strings.Count(trimmed, "! ")
+		if strings.HasSuffix(trimmed, ".") || strings.HasSuffix(trimmed, "?") || strings.HasSuffix(trimmed, "!") {
+			stats.SentenceCount++
+		}
+	}
+
+	stats.Language = doc.Language
+	if stats.Language == "" {
+		stats.Language = DetectLanguage(doc.Content)
+	}
+
+	if stats.WordCount == 0 {
+		return stats
+	}
+	stats.ReadingMinutes = int(math.Ceil(float64(stats.WordCount) / readingWordsPerMinute))
+	asl := float64(stats.WordCount) / float64(max(stats.SentenceCount, 1))
+	asw := float64(syllables) / float64(stats.WordCount)
+	stats.AvgSentenceWords = round1(asl)
+	stats.AvgWordSyllables = round1(asw)
+
+	// Flesch-Lesbarkeitsindex, für Deutsch in der Anpassung nach Amstad
+	var readability float64
+	if stats.Language == "en" {
+		readability = 206.835 - 1.015*asl - 84.6*asw
+	} else {
+		readability = 180 - asl - 58.5*asw
+	}
+	stats.Readability = round1(math.Max(0, math.Min(100, readability)))
+	return stats
+}
+
+// countSyllables schätzt die Silben eines Worts über Vokalgruppen
+func countSyllables(word string) int {
+	count := 0
+	inVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouyäöü", r)
+		if vowel && !inVowel {
+			count++
+		}
+		inVowel = vowel
+	}
+	return max(count, 1)
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
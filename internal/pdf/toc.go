@@ -0,0 +1,244 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+	"lernplattform/internal/models"
+)
+
+// Ab diesem Vielfachen der Fließtextgröße gilt eine Zeile als Überschrift
+const headingSizeRatio = 1.15
+
+// Höchstens so viele Gliederungsebenen im Inhaltsverzeichnis
+const maxTocLevels = 3
+
+// fontLine ist eine Textzeile mit ihrer größten Schrift
+type fontLine struct {
+	page  int
+	text  string
+	size  float64
+	bold  bool
+	chars int
+}
+
+// pageLines setzt die Zeichen einer Seite anhand der Y-Position zu Zeilen zusammen.
+// Defekte Seiten liefern keine Zeilen (die Bibliothek bricht dort mit panic ab).
+func pageLines(page pdf.Page, pageNum int) (lines []fontLine) {
+	defer func() {
+		if recover() != nil {
+			lines = nil
+		}
+	}()
+
+	var current *fontLine
+	var text strings.Builder
+	var prev pdf.Text
+	boldChars := 0
+	finish := func() {
+		if current == nil {
+			return
+		}
+		current.text = strings.Join(strings.Fields(text.String()), " ")
+		current.bold = boldChars*2 > current.chars
+		if current.text != "" {
+			lines = append(lines, *current)
+		}
+		current, boldChars = nil, 0
+		text.Reset()
+	}
+
+	for _, t := range page.Content().Text {
+		if current != nil && math.Abs(t.Y-prev.Y) > math.Max(prev.FontSize, 1)*0.5 {
+			finish()
+		}
+		if current == nil {
+			current = &fontLine{page: pageNum}
+		} else if t.X > prev.X+prev.W+t.FontSize*0.15 && !strings.HasSuffix(text.String(), " ") {
+			text.WriteByte(' ')
+		}
+		text.WriteString(t.S)
+		if strings.TrimSpace(t.S) != "" {
+			current.chars++
+			current.size = math.Max(current.size, t.FontSize)
+			if isBoldFont(t.Font) {
+				boldChars++
+			}
+		}
+		prev = t
+	}
+	finish()
+	return lines
+}
+
+// isBoldFont erkennt fette Schriftschnitte am Namen (z.B. "Arial-BoldMT", "Calibri,Bold")
+func isBoldFont(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "bold") || strings.Contains(name, "black") ||
+		strings.Contains(name, "heavy") || strings.Contains(name, "semibold")
+}
+
+// fontHeadings ermittelt Überschriften aus Schriftgröße und -schnitt: Zeilen deutlich größer
+// als der Fließtext, nach Größe in Ebenen eingeteilt; fette Zeilen in Textgröße bilden die unterste Ebene
+func fontHeadings(lines []fontLine, pageCount int) []models.TocEntry {
+	// Fließtextgröße = Schriftgröße mit den meisten Zeichen
+	chars := make(map[float64]int)
+	total, boldTotal := 0, 0
+	for _, l := range lines {
+		chars[roundSize(l.size)] += l.chars
+		total += l.chars
+		if l.bold {
+			boldTotal += l.chars
+		}
+	}
+	// Ist der Fließtext selbst fett, sagt der Schriftschnitt nichts aus
+	useBold := boldTotal*2 < total
+	body, most := 0.0, 0
+	for size, n := range chars {
+		if n > most || (n == most && size < body) {
+			body, most = size, n
+		}
+	}
+	if body == 0 {
+		return nil
+	}
+
+	// Kopf-/Fußzeilen (z.B. Vorlesungstitel auf jeder Folie) sind keine Überschriften
+	repeated := make(map[string]int)
+	for _, l := range lines {
+		repeated[strings.ToLower(l.text)]++
+	}
+
+	var candidates []fontLine
+	last := -2 // Index der letzten Überschriftszeile
+	for i, l := range lines {
+		if !isHeadingText(l.text) {
+			continue
+		}
+		if pageCount > 3 && repeated[strings.ToLower(l.text)]*2 > pageCount {
+			continue
+		}
+		large := l.size >= body*headingSizeRatio
+		if !large && !(useBold && l.bold && roundSize(l.size) >= body && utf8.RuneCountInString(l.text) < 80) {
+			continue
+		}
+		// Mehrzeilige Überschrift: an die vorige Zeile anhängen
+		if n := len(candidates); last == i-1 && candidates[n-1].page == l.page &&
+			roundSize(candidates[n-1].size) == roundSize(l.size) && candidates[n-1].bold == l.bold {
+			candidates[n-1].text += " " + l.text
+			last = i
+			continue
+		}
+		candidates = append(candidates, l)
+		last = i
+	}
+
+	// Ebenen: große Schriften absteigend, fette Textzeilen darunter
+	var sizes []float64
+	seen := make(map[float64]bool)
+	for _, c := range candidates {
+		if size := roundSize(c.size); c.size >= body*headingSizeRatio && !seen[size] {
+			seen[size] = true
+			sizes = append(sizes, size)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(sizes)))
+
+	entries := []models.TocEntry{}
+	for _, c := range candidates {
+		level := len(sizes) + 1
+		for i, size := range sizes {
+			if roundSize(c.size) == size {
+				level = i + 1
+				break
+			}
+		}
+		entries = append(entries, models.TocEntry{Title: c.text, Level: min(level, maxTocLevels), Page: c.page})
+	}
+	return entries
+}
+
+// isHeadingText schließt Zeilen aus, die keine Überschrift sein können
+// (Seitenzahlen, Formeln, ganze Sätze)
+func isHeadingText(text string) bool {
+	n := utf8.RuneCountInString(text)
+	if n < 3 || n > 120 || (strings.HasSuffix(text, ".") && !isNumberedHeading(text)) {
+		return false
+	}
+	letters := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	return letters*2 >= n
+}
+
+// roundSize rundet Schriftgrößen auf halbe Punkte, damit Rundungsfehler keine neue Ebene ergeben
+func roundSize(size float64) float64 {
+	return math.Round(size*2) / 2
+}
+
+// textHeadings ist der Rückfall ohne Schriftinformationen: Überschriften nach Textmuster
+// ("Kapitel", "1.2 ...", GROSSBUCHSTABEN), die Ebene aus der Nummerierung
+func textHeadings(content string) []models.TocEntry {
+	entries := []models.TocEntry{}
+	page := 0
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--- Seite ") && strings.HasSuffix(trimmed, " ---") {
+			fmt.Sscanf(trimmed, "--- Seite %d ---", &page)
+			continue
+		}
+		if trimmed == "" || !isHeading(trimmed) {
+			continue
+		}
+		level := 1
+		if isNumberedHeading(trimmed) {
+			number := strings.TrimRight(strings.Fields(trimmed)[0], ".")
+			level = min(strings.Count(number, ".")+1, maxTocLevels)
+		}
+		entries = append(entries, models.TocEntry{Title: trimmed, Level: level, Page: page})
+	}
+	return entries
+}
+
+// BuildTOC erstellt das Inhaltsverzeichnis eines Dokuments: aus den beim Einlesen
+// erkannten Schrift-Überschriften, sonst aus Textmustern
+func BuildTOC(doc *models.Document) *models.DocumentTOC {
+	toc := &models.DocumentTOC{DocumentID: doc.ID, Source: "font", Entries: doc.Headings, ComputedAt: time.Now()}
+	if len(toc.Entries) == 0 {
+		toc.Source = "text"
+		toc.Entries = textHeadings(doc.Content)
+	}
+	return toc
+}
+
+// ReadHeadings liest die Schrift-Überschriften einer PDF-Datei nachträglich (für früher eingelesene Dokumente)
+func ReadHeadings(filePath string) ([]models.TocEntry, error) {
+	f, r, err := pdf.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readerHeadings(r), nil
+}
+
+// readerHeadings sammelt die Zeilen aller Seiten und bestimmt daraus die Überschriften
+func readerHeadings(r *pdf.Reader) []models.TocEntry {
+	var lines []fontLine
+	for pageNum := 1; pageNum <= r.NumPage(); pageNum++ {
+		page := r.Page(pageNum)
+		if page.V.IsNull() {
+			continue
+		}
+		lines = append(lines, pageLines(page, pageNum)...)
+	}
+	return fontHeadings(lines, r.NumPage())
+}
@@ -0,0 +1,292 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Seitenmaße in Punkt (A4) und Seitenrand
+const (
+	pageWidth  = 595.28
+	pageHeight = 841.89
+	pageMargin = 56.0
+)
+
+// Writer erzeugt einfache Text-PDFs (A4, Helvetica) wie Arbeitsblätter zum Ausdrucken.
+// Der Text wird in WinAnsi kodiert; Zeichen außerhalb (z.B. griechische Buchstaben) werden ersetzt.
+type Writer struct {
+	title string
+	pages []*bytes.Buffer
+	y     float64
+}
+
+// NewWriter beginnt ein PDF mit dem Titel für die Dokumenteigenschaften
+func NewWriter(title string) *Writer {
+	w := &Writer{title: title}
+	w.PageBreak()
+	return w
+}
+
+// PageBreak beginnt eine neue Seite
+func (w *Writer) PageBreak() {
+	w.pages = append(w.pages, &bytes.Buffer{})
+	w.y = pageHeight - pageMargin
+}
+
+// Heading schreibt eine fette Überschrift (Ebene 1 bis 3)
+func (w *Writer) Heading(text string, level int) {
+	size := map[int]float64{1: 18, 2: 14}[level]
+	if size == 0 {
+		size = 12
+	}
+	// Überschrift nicht allein am Seitenende stehen lassen
+	if w.y-size*4 < pageMargin {
+		w.PageBreak()
+	} else if w.y < pageHeight-pageMargin {
+		w.Space(size * 0.6)
+	}
+	w.write(text, pageMargin, size, true)
+	w.Space(size * 0.3)
+}
+
+// Paragraph schreibt einen Absatz mit Zeilenumbruch; Zeilenumbrüche im Text bleiben erhalten
+func (w *Writer) Paragraph(text string) {
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			w.Space(5)
+			continue
+		}
+		w.write(line, pageMargin, 11, false)
+	}
+	w.Space(5)
+}
+
+// Bullet schreibt einen Aufzählungspunkt
+func (w *Writer) Bullet(text string) {
+	w.item("•", text, 0)
+}
+
+// Numbered schreibt einen nummerierten Eintrag, z.B. eine Aufgabe
+func (w *Writer) Numbered(n int, text string) {
+	w.item(fmt.Sprintf("%d.", n), text, 0)
+}
+
+// Choice schreibt eine eingerückte Antwortmöglichkeit, z.B. "a)" unter einer Aufgabe
+func (w *Writer) Choice(label, text string) {
+	w.item(label, text, 18)
+}
+
+// Term schreibt einen fetten Begriff mit eingerückter Erklärung
+func (w *Writer) Term(term, definition string) {
+	w.write(term, pageMargin, 11, true)
+	w.write(definition, pageMargin+18, 11, false)
+	w.Space(4)
+}
+
+// AnswerLines zeichnet Linien zum Ausfüllen
+func (w *Writer) AnswerLines(n int) {
+	for i := 0; i < n; i++ {
+		w.ensure(22)
+		w.y -= 22
+		fmt.Fprintf(w.page(), "0.7 G 0.5 w %.2f %.2f m %.2f %.2f l S 0 G\n", pageMargin+18, w.y, pageWidth-pageMargin, w.y)
+	}
+	w.Space(8)
+}
+
+// Space fügt vertikalen Abstand ein
+func (w *Writer) Space(points float64) {
+	w.y -= points
+}
+
+// Bytes liefert das fertige PDF mit Seitenzahlen in der Fußzeile
+func (w *Writer) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// 1 Katalog, 2 Seitenbaum, 3/4 Schriften, 5 Info, ab 6 je Seite: Seite und Inhalt
+	var kids []string
+	for i := range w.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 6+2*i))
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(w.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (Lernplattform) >>", pdfString(w.title)))
+	for i, page := range w.pages {
+		footer := fmt.Sprintf("Seite %d von %d", i+1, len(w.pages))
+		fmt.Fprintf(page, "BT /F1 9 Tf %.2f %.2f Td (%s) Tj ET\n",
+			pageWidth-pageMargin-textWidth(footer, 9, false), pageMargin/2, pdfString(footer))
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 7+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// item schreibt einen Eintrag mit Aufzählungszeichen bzw. Nummer und eingerücktem Text
+func (w *Writer) item(marker, text string, indent float64) {
+	w.ensure(15)
+	fmt.Fprintf(w.page(), "BT /F1 11 Tf %.2f %.2f Td (%s) Tj ET\n", pageMargin+indent+4, w.y-11, pdfString(marker))
+	w.write(text, pageMargin+indent+22, 11, false)
+	w.Space(3)
+}
+
+// write bricht den Text auf die Seitenbreite um und schreibt ihn ab der aktuellen Position
+func (w *Writer) write(text string, x, size float64, bold bool) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	leading := size * 1.35
+	for _, line := range wrapText(text, pageWidth-pageMargin-x, size, bold) {
+		w.ensure(leading)
+		w.y -= leading
+		fmt.Fprintf(w.page(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, w.y+size*0.3, pdfString(line))
+	}
+}
+
+// ensure beginnt eine neue Seite, wenn weniger als height Platz bleibt
+func (w *Writer) ensure(height float64) {
+	if w.y-height < pageMargin {
+		w.PageBreak()
+	}
+}
+
+func (w *Writer) page() *bytes.Buffer {
+	return w.pages[len(w.pages)-1]
+}
+
+// wrapText teilt Text an Leerzeichen in Zeilen der angegebenen Breite; überlange Wörter werden getrennt
+func wrapText(text string, width, size float64, bold bool) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		for textWidth(word, size, bold) > width {
+			runes := []rune(word)
+			cut := len(runes) - 1
+			for cut > 1 && textWidth(string(runes[:cut]), size, bold) > width {
+				cut--
+			}
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, string(runes[:cut]))
+			word = string(runes[cut:])
+		}
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && textWidth(candidate, size, bold) > width {
+			lines = append(lines, line)
+			candidate = word
+		}
+		line = candidate
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Zeichenbreiten von Helvetica (1/1000 der Schriftgröße) für ASCII 32-126
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// textWidth schätzt die Breite eines Texts in Punkt; fette Schrift ist etwa 8 % breiter
+func textWidth(text string, size float64, bold bool) float64 {
+	total := 0
+	for _, b := range winAnsi(text) {
+		switch {
+		case b >= 32 && b <= 126:
+			total += helveticaWidths[b-32]
+		case b == 0x95: // •
+			total += 350
+		case b == 0x97 || b == 0x85 || b == 0x89: // — … ‰
+			total += 1000
+		case b == 0xc4 || b == 0xc5 || b == 0xc0 || b == 0xc1 || b == 0xc2 || b == 0xc3: // Ä Å À Á Â Ã
+			total += 667
+		case b == 0xd6 || b == 0xd2 || b == 0xd3 || b == 0xd4 || b == 0xd5: // Ö Ò Ó Ô Õ
+			total += 778
+		case b == 0xdc || b == 0xd9 || b == 0xda || b == 0xdb: // Ü Ù Ú Û
+			total += 722
+		default:
+			total += 556
+		}
+	}
+	width := float64(total) * size / 1000
+	if bold {
+		width *= 1.08
+	}
+	return width
+}
+
+// Zeichen aus WinAnsi jenseits von Latin-1
+var winAnsiSpecial = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‰': 0x89, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// Ersatz für häufige Zeichen, die Helvetica nicht enthält
+var winAnsiReplacements = map[rune]string{
+	'→': "->", '←': "<-", '⇒': "=>", '⇔': "<=>", '≤': "<=", '≥': ">=", '≠': "!=", '≈': "~",
+	'∞': "unendlich", '√': "Wurzel", '∑': "Summe", '∫': "Integral", '−': "-",
+	'α': "alpha", 'β': "beta", 'γ': "gamma", 'δ': "delta", 'Δ': "Delta", 'ε': "epsilon", 'λ': "lambda",
+	'μ': "µ", 'π': "pi", 'σ': "sigma", 'Σ': "Sigma", 'τ': "tau", 'φ': "phi", 'ω': "omega", 'Ω': "Ohm",
+}
+
+// winAnsi kodiert Text für die Standardschriften; nicht darstellbare Zeichen werden zu "?"
+func winAnsi(text string) []byte {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		if replacement, ok := winAnsiReplacements[r]; ok {
+			out = append(out, winAnsi(replacement)...)
+			continue
+		}
+		switch {
+		case r == '\t':
+			out = append(out, ' ')
+		case r >= 32 && r <= 126, r >= 160 && r <= 255:
+			out = append(out, byte(r))
+		case winAnsiSpecial[r] != 0:
+			out = append(out, winAnsiSpecial[r])
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+// pdfString kodiert Text als Inhalt eines PDF-Strings in runden Klammern
+func pdfString(text string) string {
+	var sb strings.Builder
+	for _, b := range winAnsi(text) {
+		if b == '(' || b == ')' || b == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(b)
+	}
+	return sb.String()
+}
@@ -0,0 +1,463 @@
+// Package qr erzeugt QR-Codes (Byte-Modus, Fehlerkorrektur M, Version 1-10) ohne externe
+// Abhängigkeiten, z.B. für das Koppeln der Begleit-App mit Serveradresse und Token.
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// ErrTooLong: der Text passt nicht in einen QR-Code bis Version 10 (213 Byte)
+var ErrTooLong = errors.New("text zu lang für einen QR-Code")
+
+const maxVersion = 10
+
+// Blöcke je Version bei Fehlerkorrektur M: Fehlerkorrektur-Codewörter je Block,
+// Anzahl und Datenlänge der kurzen Blöcke, Anzahl der langen Blöcke (ein Datenbyte mehr)
+var eccBlocks = [maxVersion + 1]struct{ ecc, short, shortLen, long int }{
+	1:  {10, 1, 16, 0},
+	2:  {16, 1, 28, 0},
+	3:  {26, 1, 44, 0},
+	4:  {18, 2, 32, 0},
+	5:  {24, 2, 43, 0},
+	6:  {16, 4, 27, 0},
+	7:  {18, 4, 31, 0},
+	8:  {22, 2, 38, 2},
+	9:  {22, 3, 36, 2},
+	10: {26, 4, 43, 1},
+}
+
+// Code ist ein fertiger QR-Code; Module[y][x] ist true für dunkle Module
+type Code struct {
+	Size    int
+	Modules [][]bool
+}
+
+// Encode erzeugt den QR-Code mit der kleinsten passenden Version und der Maske mit der geringsten Strafpunktzahl
+func Encode(text string) (*Code, error) {
+	m, err := unmasked(text)
+	if err != nil {
+		return nil, err
+	}
+
+	best, bestPenalty := -1, 0
+	for mask := 0; mask < 8; mask++ {
+		m.applyMask(mask)
+		m.drawFormat(mask)
+		if p := m.penalty(); best < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		m.applyMask(mask) // XOR macht die Maske wieder rückgängig
+	}
+	m.applyMask(best)
+	m.drawFormat(best)
+
+	return &Code{Size: m.size, Modules: m.modules}, nil
+}
+
+// unmasked legt die Matrix mit der kleinsten passenden Version an und verteilt die Codewörter, noch ohne Maske
+func unmasked(text string) (*matrix, error) {
+	data := []byte(text)
+	version := 0
+	for v := 1; v <= maxVersion; v++ {
+		if 4+countBits(v)+8*len(data) <= 8*dataCodewords(v) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	m := newMatrix(version)
+	m.drawCodewords(interleave(version, encodeData(version, data)))
+	return m, nil
+}
+
+// PNG zeichnet den QR-Code mit scale Pixeln je Modul und der vorgeschriebenen Ruhezone von 4 Modulen
+func (c *Code) PNG(scale int) ([]byte, error) {
+	const quiet = 4
+	size := (c.Size + 2*quiet) * scale
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			mx, my := x/scale-quiet, y/scale-quiet
+			if mx >= 0 && my >= 0 && mx < c.Size && my < c.Size && c.Modules[my][mx] {
+				img.SetGray(x, y, color.Gray{0})
+			} else {
+				img.SetGray(x, y, color.Gray{255})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func countBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+func dataCodewords(version int) int {
+	b := eccBlocks[version]
+	return b.short*b.shortLen + b.long*(b.shortLen+1)
+}
+
+// encodeData schreibt Modus, Länge und Daten und füllt mit Abschluss und Füllbytes auf
+func encodeData(version int, data []byte) []byte {
+	var bits []bool
+	appendBits := func(value, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, value>>i&1 == 1)
+		}
+	}
+	appendBits(0b0100, 4) // Byte-Modus
+	appendBits(len(data), countBits(version))
+	for _, b := range data {
+		appendBits(int(b), 8)
+	}
+
+	capacity := 8 * dataCodewords(version)
+	appendBits(0, min(4, capacity-len(bits)))
+	appendBits(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		appendBits(pad, 8)
+	}
+
+	out := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			out[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return out
+}
+
+// interleave teilt die Daten in Blöcke, ergänzt je Block die Reed-Solomon-Codewörter
+// und verschränkt erst die Daten-, dann die Fehlerkorrektur-Codewörter aller Blöcke
+func interleave(version int, data []byte) []byte {
+	b := eccBlocks[version]
+	generator := rsGenerator(b.ecc)
+
+	var blocks, eccs [][]byte
+	offset := 0
+	for i := 0; i < b.short+b.long; i++ {
+		n := b.shortLen
+		if i >= b.short {
+			n++
+		}
+		block := data[offset : offset+n]
+		offset += n
+		blocks = append(blocks, block)
+		eccs = append(eccs, rsRemainder(block, generator))
+	}
+
+	var out []byte
+	for i := 0; i <= b.shortLen; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				out = append(out, block[i])
+			}
+		}
+	}
+	for i := 0; i < b.ecc; i++ {
+		for _, ecc := range eccs {
+			out = append(out, ecc[i])
+		}
+	}
+	return out
+}
+
+// Reed-Solomon über GF(256) mit dem Polynom x^8+x^4+x^3+x^2+1
+
+func gfMul(x, y byte) byte {
+	var z byte
+	for i := 7; i >= 0; i-- {
+		carry := z >> 7
+		z = z<<1 ^ carry*0x1D
+		z ^= (y >> i & 1) * x
+	}
+	return z
+}
+
+// rsGenerator liefert die Koeffizienten von (x-α^0)…(x-α^(n-1)) ohne den führenden
+func rsGenerator(n int) []byte {
+	g := make([]byte, n)
+	g[n-1] = 1
+	root := byte(1)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			g[j] = gfMul(g[j], root)
+			if j+1 < n {
+				g[j] ^= g[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return g
+}
+
+func rsRemainder(data, generator []byte) []byte {
+	rem := make([]byte, len(generator))
+	for _, b := range data {
+		factor := b ^ rem[0]
+		copy(rem, rem[1:])
+		rem[len(rem)-1] = 0
+		for i, g := range generator {
+			rem[i] ^= gfMul(g, factor)
+		}
+	}
+	return rem
+}
+
+// matrix hält die Module und merkt sich, welche zu Funktionsmustern gehören (nicht maskiert)
+type matrix struct {
+	size     int
+	version  int
+	modules  [][]bool
+	function [][]bool
+}
+
+func newMatrix(version int) *matrix {
+	size := 4*version + 17
+	m := &matrix{size: size, version: version, modules: make([][]bool, size), function: make([][]bool, size)}
+	for i := range m.modules {
+		m.modules[i] = make([]bool, size)
+		m.function[i] = make([]bool, size)
+	}
+
+	for i := 0; i < size; i++ {
+		m.set(6, i, i%2 == 0)
+		m.set(i, 6, i%2 == 0)
+	}
+	m.drawFinder(3, 3)
+	m.drawFinder(size-4, 3)
+	m.drawFinder(3, size-4)
+
+	positions := alignmentPositions(version)
+	last := len(positions) - 1
+	for i, y := range positions {
+		for j, x := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					m.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	m.drawFormat(0) // reserviert die Formatbereiche, die echten Bits folgen nach der Maskenwahl
+	m.drawVersion()
+	return m
+}
+
+func (m *matrix) set(x, y int, dark bool) {
+	m.modules[y][x] = dark
+	m.function[y][x] = true
+}
+
+// drawFinder zeichnet ein Positionsmuster samt hellem Rand
+func (m *matrix) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || y < 0 || x >= m.size || y >= m.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			m.set(x, y, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	count := version/7 + 2
+	step := (version*8 + count*3 + 5) / (count*4 - 4) * 2
+	positions := make([]int, count)
+	positions[0] = 6
+	for i, pos := count-1, 4*version+10; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+// drawFormat schreibt Fehlerkorrekturstufe M und Maske (BCH-geschützt) an beide Stellen
+func (m *matrix) drawFormat(mask int) {
+	data := 0<<3 | mask // Stufe M = 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		m.set(8, i, bit(i))
+	}
+	m.set(8, 7, bit(6))
+	m.set(8, 8, bit(7))
+	m.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		m.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		m.set(m.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.set(8, m.size-15+i, bit(i))
+	}
+	m.set(8, m.size-8, true) // immer dunkles Modul
+}
+
+// drawVersion schreibt ab Version 7 die Versionsinformation neben die Positionsmuster
+func (m *matrix) drawVersion() {
+	if m.version < 7 {
+		return
+	}
+	rem := m.version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	bits := m.version<<12 | rem
+	for i := 0; i < 18; i++ {
+		dark := bits>>i&1 == 1
+		a, b := m.size-11+i%3, i/3
+		m.set(a, b, dark)
+		m.set(b, a, dark)
+	}
+}
+
+// drawCodewords verteilt die Codewörter im Zickzack von rechts unten über alle freien Module
+func (m *matrix) drawCodewords(data []byte) {
+	i := 0
+	for right := m.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // die senkrechte Taktlinie wird übersprungen
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < m.size; vert++ {
+			y := vert
+			if upward {
+				y = m.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if m.function[y][x] || i >= len(data)*8 {
+					continue
+				}
+				m.modules[y][x] = data[i/8]>>(7-i%8)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+func (m *matrix) applyMask(mask int) {
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.function[y][x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				m.modules[y][x] = !m.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty bewertet eine Maske nach den Regeln der Norm: lange Reihen gleicher Farbe, 2x2-Flächen,
+// Muster, die wie Positionsmuster aussehen, und ein unausgewogener Anteil dunkler Module
+func (m *matrix) penalty() int {
+	score, dark := 0, 0
+	finderLike := []bool{true, false, true, true, true, false, true, false, false, false, false}
+	for y := 0; y < m.size; y++ {
+		for x := 0; x < m.size; x++ {
+			if m.modules[y][x] {
+				dark++
+			}
+			if x+1 < m.size && y+1 < m.size {
+				c := m.modules[y][x]
+				if m.modules[y][x+1] == c && m.modules[y+1][x] == c && m.modules[y+1][x+1] == c {
+					score += 3
+				}
+			}
+		}
+	}
+	for _, horizontal := range []bool{true, false} {
+		at := func(line, i int) bool {
+			if horizontal {
+				return m.modules[line][i]
+			}
+			return m.modules[i][line]
+		}
+		for line := 0; line < m.size; line++ {
+			run := 1
+			for i := 1; i <= m.size; i++ {
+				if i < m.size && at(line, i) == at(line, i-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += 3 + run - 5
+				}
+				run = 1
+			}
+			for i := 0; i+len(finderLike) <= m.size; i++ {
+				forward, backward := true, true
+				for k, want := range finderLike {
+					forward = forward && at(line, i+k) == want
+					backward = backward && at(line, i+len(finderLike)-1-k) == want
+				}
+				if forward {
+					score += 40
+				}
+				if backward {
+					score += 40
+				}
+			}
+		}
+	}
+	percent := dark * 100 / (m.size * m.size)
+	score += abs(percent-50) / 5 * 10
+	return score
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,85 @@
+package qr
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Die Matrizen in testdata stammen von github.com/skip2/go-qrcode (Fehlerkorrektur M, ohne Ruhezone);
+// '#' ist ein dunkles Modul. Die Maske steht dabei, weil sich die Maskenwahl je Bibliothek unterscheiden darf.
+const sample = "lernplattform://pair?server=http%3a%2f%2fstudy.local%3a8080&token=dev_a7f3k9x2q8w1e5r6t0y4u3i2o9p8a7s6d5f4g3h2j1k0l9z8x7c6v5b4n3m2q1w0e9r8t7y6u5i4o3p2a1s0d9f8g7h6j5k4l3z2x1c0v9b8n7m6qaswdefrgthyjukilopzxcvbnmlkjhgfdsapoiuytrewqmnbvcxzlkjhgfdsaqwertyuiopasdfghjklzxcvbnm"
+
+var goldenCodes = []struct {
+	version int
+	length  int // Länge des Texts (Anfang von sample)
+	mask    int
+}{
+	{1, 13, 5},
+	{2, 15, 4},
+	{3, 42, 5},
+	{5, 80, 3},
+	{6, 106, 4},
+	{7, 122, 6}, // mit Versionsinformation
+	{10, 213, 2},
+}
+
+func render(modules [][]bool) string {
+	var sb strings.Builder
+	for _, row := range modules {
+		for _, dark := range row {
+			if dark {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func TestGolden(t *testing.T) {
+	for _, tc := range goldenCodes {
+		want, err := os.ReadFile(filepath.Join("testdata", fmt.Sprintf("v%d.txt", tc.version)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m, err := unmasked(sample[:tc.length])
+		if err != nil {
+			t.Fatalf("Version %d: %v", tc.version, err)
+		}
+		if m.version != tc.version {
+			t.Errorf("%d Byte: Version %d, erwartet %d", tc.length, m.version, tc.version)
+			continue
+		}
+		m.applyMask(tc.mask)
+		m.drawFormat(tc.mask)
+
+		if got := render(m.modules); got != string(want) {
+			t.Errorf("Version %d, Maske %d weicht von der Referenz ab:\n%s\nerwartet:\n%s", tc.version, tc.mask, got, want)
+		}
+	}
+}
+
+func TestEncodeSize(t *testing.T) {
+	for _, tc := range goldenCodes {
+		code, err := Encode(sample[:tc.length])
+		if err != nil {
+			t.Fatalf("%d Byte: %v", tc.length, err)
+		}
+		if size := 4*tc.version + 17; code.Size != size || len(code.Modules) != size {
+			t.Errorf("%d Byte: Größe %d, erwartet %d (Version %d)", tc.length, code.Size, size, tc.version)
+		}
+	}
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	if _, err := Encode(sample[:214]); !errors.Is(err, ErrTooLong) {
+		t.Errorf("214 Byte: Fehler %v, erwartet ErrTooLong", err)
+	}
+}
@@ -0,0 +1,165 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// NextFunc berechnet den nächsten Ausführungszeitpunkt nach now
+type NextFunc func(now time.Time) time.Time
+
+// Job ist eine wiederkehrende Hintergrundaufgabe
+type Job struct {
+	Name string
+	Next NextFunc
+	Run  func(ctx context.Context) error
+	// Background: Job erzeugt LLM-Last und wird ausgelassen, wenn das Gate des Schedulers ablehnt
+	Background bool
+	// Retry: ausgelassene Jobs nach dieser Zeit erneut prüfen statt erst zum nächsten Termin (0 = nicht)
+	Retry time.Duration
+}
+
+// GateFunc entscheidet, ob Hintergrundjobs jetzt laufen dürfen; ein Fehler nennt den Grund
+type GateFunc func(now time.Time) error
+
+// JobStatus beschreibt den Zustand eines Jobs
+type JobStatus struct {
+	Name      string    `json:"name"`
+	NextRun   time.Time `json:"next_run"`
+	LastRun   time.Time `json:"last_run,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+	Skipped   string    `json:"skipped,omitempty"` // Grund, warum der letzte Lauf ausgelassen wurde
+}
+
+// Scheduler führt Jobs zu ihren Zeitpunkten aus (lokale Zeit, ein Goroutine pro Job)
+type Scheduler struct {
+	mu     sync.Mutex
+	jobs   []*Job
+	status map[string]*JobStatus
+	gate   GateFunc
+}
+
+// New erstellt einen leeren Scheduler
+func New() *Scheduler {
+	return &Scheduler{status: make(map[string]*JobStatus)}
+}
+
+// Add registriert einen Job; muss vor Start aufgerufen werden
+func (s *Scheduler) Add(job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, &job)
+	s.status[job.Name] = &JobStatus{Name: job.Name}
+}
+
+// SetGate setzt die Prüfung für Hintergrundjobs; muss vor Start aufgerufen werden
+func (s *Scheduler) SetGate(gate GateFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gate = gate
+}
+
+// Start startet alle Jobs, bis ctx beendet wird
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		go s.loop(ctx, job)
+	}
+}
+
+// Status gibt den Zustand aller Jobs zurück
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]JobStatus, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		result = append(result, *s.status[job.Name])
+	}
+	return result
+}
+
+func (s *Scheduler) loop(ctx context.Context, job *Job) {
+	var retry time.Time
+	for {
+		next := job.Next(time.Now())
+		if !retry.IsZero() && retry.Before(next) {
+			next = retry
+		}
+		retry = time.Time{}
+		s.mu.Lock()
+		s.status[job.Name].NextRun = next
+		s.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if job.Background && s.gate != nil {
+			if err := s.gate(time.Now()); err != nil {
+				s.mu.Lock()
+				s.status[job.Name].Skipped = err.Error()
+				s.mu.Unlock()
+				log.Printf("⏸️ [Scheduler] Job '%s' ausgelassen: %v", job.Name, err)
+				if job.Retry > 0 {
+					retry = time.Now().Add(job.Retry)
+				}
+				continue
+			}
+		}
+
+		log.Printf("⏰ [Scheduler] Starte Job '%s'", job.Name)
+		err := job.Run(ctx)
+
+		s.mu.Lock()
+		st := s.status[job.Name]
+		st.LastRun = time.Now()
+		st.LastError, st.Skipped = "", ""
+		if err != nil {
+			st.LastError = err.Error()
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			log.Printf("   [Scheduler] ⚠️ Job '%s' fehlgeschlagen: %v", job.Name, err)
+		} else {
+			log.Printf("   [Scheduler] ✓ Job '%s' erledigt", job.Name)
+		}
+	}
+}
+
+// Every läuft in festen Abständen
+func Every(interval time.Duration) NextFunc {
+	return func(now time.Time) time.Time {
+		return now.Add(interval)
+	}
+}
+
+// Daily läuft jeden Tag zur angegebenen Stunde
+func Daily(hour int) NextFunc {
+	return func(now time.Time) time.Time {
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+	}
+}
+
+// Weekly läuft einmal pro Woche am angegebenen Wochentag zur angegebenen Stunde
+func Weekly(weekday time.Weekday, hour int) NextFunc {
+	return func(now time.Time) time.Time {
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+		next = next.AddDate(0, 0, (int(weekday)-int(now.Weekday())+7)%7)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 7)
+		}
+		return next
+	}
+}
@@ -1,11 +1,16 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"lernplattform/internal/models"
+	"lernplattform/internal/pdf"
 
 	_ "modernc.org/sqlite"
 )
@@ -17,6 +22,16 @@ type Storage interface {
 	GetDocument(id string) (*models.Document, error)
 	GetAllDocuments() ([]models.Document, error)
 	DeleteDocument(id string) error
+	SetDocumentLanguage(id, language string) error
+	UpdateDocumentMetadata(doc *models.Document) error
+	GetDocumentRawContent(id string) (string, error)
+	SaveDocumentStats(stats *models.DocumentStats) error
+	GetDocumentStats(documentID string) (*models.DocumentStats, error)
+	SaveDocumentTOC(toc *models.DocumentTOC) error
+	GetDocumentTOC(documentID string) (*models.DocumentTOC, error)
+	SaveFigures(documentID string, figures []models.Figure) error
+	GetFigures(documentID string) ([]models.Figure, error)
+	GetFigure(id string) (*models.Figure, error)
 
 	// Lernpläne
 	SaveStudyPlan(plan *models.StudyPlan) error
@@ -24,26 +39,92 @@ type Storage interface {
 	GetActiveStudyPlan() (*models.StudyPlan, error)
 	GetAllStudyPlans() ([]models.StudyPlan, error)
 	UpdateStudyPlanProgress(id string, progress float64) error
+	UpdateStudyPlanDetails(plan *models.StudyPlan) error
 
 	// Themen
 	SaveTopic(topic *models.Topic) error
 	GetTopic(id string) (*models.Topic, error)
 	GetTopicsByPlan(planID string) ([]models.Topic, error)
 	UpdateTopicStatus(id string, status string, progress float64) error
+	UpdateTopicOrder(id string, order int) error
+	GetSubtopics(parentID string) ([]models.Topic, error)
+	SetTopicParent(id string, parentID string) error
+	RollUpTopicProgress(id string) error
+	MergeTopics(targetID string, sourceIDs []string) error
+	MoveQuestions(questionIDs []string, topicID string) error
+
+	SetTopicExamWeight(id string, weight float64, estMinutes int) error
+	SaveTopicSources(topicID string, sources []models.TopicSource) error
+	GetTopicSources(topicID string) ([]models.TopicSource, error)
+
+	// Syllabus (Prüfungsschwerpunkte)
+	ReplaceSyllabus(planID string, items []models.SyllabusItem) error
+	GetSyllabus(planID string) ([]models.SyllabusItem, error)
+
+	// Lernziele
+	SaveObjective(obj *models.LearningObjective) error
+	GetObjectivesByTopic(topicID string) ([]models.LearningObjective, error)
+	SetObjectiveAchieved(id string, achieved bool) error
+	DeleteObjectivesByTopic(topicID string) error
+
+	// Erklärungen
+	SaveExplanation(exp *models.Explanation) error
+	GetLatestExplanation(topicID string) (*models.Explanation, error)
+	GetExplanation(id string) (*models.Explanation, error)
+	GetExplanationsByTopic(topicID string) ([]models.Explanation, error)
 
 	// Fragen
 	SaveQuestion(q *models.Question) error
 	GetQuestion(id string) (*models.Question, error)
 	GetQuestionsByTopic(topicID string) ([]models.Question, error)
+	GetQuestionsByPlan(planID string) ([]models.Question, error)
 	SaveQuestionAnswer(id string, answer string, isCorrect bool, feedback string) error
+	MarkQuestionStarted(id string, startedAt time.Time) error
+	SaveAnswerTiming(id string, seconds int, late bool) error
+	SetQuestionExamSource(id, documentID string, year int) error
+	SaveAttempt(attempt *models.QuestionAttempt) error
+	GetAttempts(questionID string) ([]models.QuestionAttempt, error)
+	GetAttemptsByPlan(planID string) ([]models.QuestionAttempt, error)
+	GetAttemptsByPlanSince(planID string, since time.Time) ([]models.QuestionAttempt, error)
+	GetAllAttempts() ([]models.QuestionAttempt, error)
+	GetAttemptsSince(since time.Time) ([]models.QuestionAttempt, error)
+	GetQuestionIDsAnsweredBefore(before time.Time) ([]string, error)
+	GetAnswerSpeedStats(planID string) ([]models.TopicAnswerSpeed, error)
 
 	// Sitzungen
 	SaveSession(session *models.StudySession) error
 	GetSessionsByPlan(planID string) ([]models.StudySession, error)
+	GetSession(id string) (*models.StudySession, error)
+	GetAllSessions() ([]models.StudySession, error)
+	GetSessionsSince(since time.Time) ([]models.StudySession, error)
+	SaveSessionRecap(id string, status string, questionIDs []string) error
 
 	// Chat
 	SaveChatMessage(msg *models.ChatMessage) error
 	GetChatHistory(sessionID string) ([]models.ChatMessage, error)
+	GetChatMessage(id string) (*models.ChatMessage, error)
+	GetChatMessageVersions(id string) ([]models.ChatMessage, error)
+	ReplaceChatMessage(oldID string, msg *models.ChatMessage) error
+	EditChatMessage(id, content string) error
+	SaveChatPin(pin *models.ChatPin) error
+	GetChatPins(sessionID string) ([]models.ChatPin, error)
+	DeleteChatPin(sessionID, id string) error
+	SaveChatSession(session *models.ChatSession) error
+	GetChatSession(id string) (*models.ChatSession, error)
+
+	// Markierungen
+	SaveFlag(flag *models.Flag) error
+	GetOpenFlag(itemType string, itemID string) (*models.Flag, error)
+	GetFlags(itemType string, topicID string, includeResolved bool) ([]models.Flag, error)
+	ResolveFlags(ids []string) (int64, error)
+	DeleteFlag(id string) error
+
+	// Notizen
+	SaveNote(note *models.Note) error
+	GetNote(id string) (*models.Note, error)
+	GetNotesByTopic(topicID string) ([]models.Note, error)
+	GetNotesByQuestion(questionID string) ([]models.Note, error)
+	DeleteNote(id string) error
 
 	// Glossar
 	SaveGlossaryItem(item *models.GlossaryItem) error
@@ -51,6 +132,72 @@ type Storage interface {
 	GetAllGlossaryItems() ([]models.GlossaryItem, error)
 	DeleteGlossaryItem(id string) error
 
+	// Eselsbrücken
+	SaveMnemonic(m *models.Mnemonic) error
+	GetMnemonicsByTopic(topicID string) ([]models.Mnemonic, error)
+	GetMnemonicsByQuestion(questionID string) ([]models.Mnemonic, error)
+
+	// Rechenbeispiele
+	SaveWorkedExample(ex *models.WorkedExample) error
+	GetWorkedExamplesByTopic(topicID string) ([]models.WorkedExample, error)
+
+	// Eigene Erklärungen (Feynman-Methode)
+	SaveTeachBack(tb *models.TeachBack) error
+	GetTeachBacksByTopic(topicID string) ([]models.TeachBack, error)
+
+	// Vergleichstabellen
+	SaveComparison(c *models.Comparison) error
+	GetComparison(id string) (*models.Comparison, error)
+	GetComparisonByKey(key string) (*models.Comparison, error)
+	GetAllComparisons() ([]models.Comparison, error)
+
+	// Interleaving (gemischt abgefragte Themenpaare)
+	RecordInterleavedPair(planID, topicA, topicB string, at time.Time) error
+	GetInterleavedPairs(planID string) ([]models.InterleavePair, error)
+
+	// Lernplan-Erstellung (Zwischenstand zum Fortsetzen nach Neustart)
+	SavePlanJob(job *models.PlanJob) error
+	GetPlanJob(id string) (*models.PlanJob, error)
+	GetPlanJobs() ([]models.PlanJob, error)
+
+	// Dauer von LLM-Aufrufen (Grundlage der Restzeit-Schätzung)
+	SaveDurationSample(sample models.DurationSample) error
+	GetDurationSamples(limit int) ([]models.DurationSample, error)
+
+	// Eigene Quizze und ihre Durchläufe
+	SaveQuiz(quiz *models.Quiz) error
+	GetQuiz(id string) (*models.Quiz, error)
+	GetQuizzes(planID string) ([]models.Quiz, error)
+	DeleteQuiz(id string) error
+	SaveQuizResult(result *models.QuizResult) error
+	GetQuizResult(id string) (*models.QuizResult, error)
+	GetQuizResults(quizID string) ([]models.QuizResult, error)
+
+	// Gerätetokens (gekoppelte Begleit-Apps)
+	SaveDeviceToken(device *models.DeviceToken) error
+	GetDeviceTokens() ([]models.DeviceToken, error)
+	GetDeviceTokenByHash(hash string) (*models.DeviceToken, error)
+	TouchDeviceToken(id string, at time.Time) error
+	DeleteDeviceToken(id string) error
+
+	// Fachprofile (Prompts, Fragetypen, Bewertungsstrenge je Fach)
+	SaveSubjectProfile(profile *models.SubjectProfile) error
+	GetSubjectProfile(id string) (*models.SubjectProfile, error)
+	GetSubjectProfiles() ([]models.SubjectProfile, error)
+	DeleteSubjectProfile(id string) error
+
+	// Interne Einstellungen (z.B. Schlüssel), bleiben beim Löschen aller Daten erhalten
+	GetSetting(key string) (string, error)
+	SaveSetting(key, value string) error
+
+	// Betrieb (Readiness-Prüfung)
+	Ping(ctx context.Context) error
+	CheckMigrations() error
+
+	// Alle Daten löschen (Konto zurücksetzen); Ergebnis: Einträge je Tabelle
+	CountAllData() (map[string]int, error)
+	WipeAllData() (map[string]int, error)
+
 	Close() error
 }
 
@@ -70,6 +217,9 @@ func NewSQLiteStorage(dbPath string) (*SQLiteStorage, error) {
 	if err := storage.initSchema(); err != nil {
 		return nil, err
 	}
+	if err := storage.migrate(); err != nil {
+		return nil, err
+	}
 
 	return storage, nil
 }
@@ -148,10 +298,182 @@ func (s *SQLiteStorage) initSchema() error {
 		topic_id TEXT
 	);
 
+	CREATE TABLE IF NOT EXISTS chat_sessions (
+		id TEXT PRIMARY KEY,
+		mode TEXT DEFAULT 'standard',
+		topic_id TEXT,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS explanations (
+		id TEXT PRIMARY KEY,
+		topic_id TEXT NOT NULL,
+		title TEXT,
+		content TEXT NOT NULL,
+		key_points TEXT,
+		created_at DATETIME NOT NULL,
+		FOREIGN KEY (topic_id) REFERENCES topics(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS learning_objectives (
+		id TEXT PRIMARY KEY,
+		topic_id TEXT NOT NULL,
+		text TEXT NOT NULL,
+		objective_order INTEGER,
+		achieved INTEGER DEFAULT 0,
+		achieved_at DATETIME,
+		FOREIGN KEY (topic_id) REFERENCES topics(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS notes (
+		id TEXT PRIMARY KEY,
+		topic_id TEXT NOT NULL,
+		question_id TEXT DEFAULT '',
+		content TEXT NOT NULL,
+		created_at DATETIME,
+		updated_at DATETIME,
+		FOREIGN KEY (topic_id) REFERENCES topics(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS flags (
+		id TEXT PRIMARY KEY,
+		item_type TEXT NOT NULL,
+		item_id TEXT NOT NULL,
+		topic_id TEXT NOT NULL,
+		reason TEXT,
+		created_at DATETIME,
+		resolved_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS syllabus_items (
+		id TEXT PRIMARY KEY,
+		study_plan_id TEXT NOT NULL,
+		text TEXT NOT NULL,
+		topic_ids TEXT,
+		item_order INTEGER,
+		created_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS topic_sources (
+		topic_id TEXT NOT NULL,
+		document_id TEXT,
+		document_name TEXT,
+		page_start INTEGER DEFAULT 0,
+		page_end INTEGER DEFAULT 0
+	);
+
+	CREATE TABLE IF NOT EXISTS question_attempts (
+		id TEXT PRIMARY KEY,
+		question_id TEXT NOT NULL,
+		topic_id TEXT,
+		answer TEXT,
+		is_correct INTEGER,
+		score INTEGER DEFAULT 0,
+		feedback TEXT,
+		hints_used INTEGER DEFAULT 0,
+		answer_seconds INTEGER,
+		answered_late INTEGER DEFAULT 0,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS mnemonics (
+		id TEXT PRIMARY KEY,
+		topic_id TEXT NOT NULL,
+		term TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		content TEXT NOT NULL,
+		glossary_ids TEXT,
+		question_ids TEXT,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS worked_examples (
+		id TEXT PRIMARY KEY,
+		topic_id TEXT NOT NULL,
+		title TEXT,
+		problem TEXT NOT NULL,
+		steps TEXT,
+		answer TEXT,
+		pitfalls TEXT,
+		difficulty INTEGER DEFAULT 3,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS teach_backs (
+		id TEXT PRIMARY KEY,
+		topic_id TEXT NOT NULL,
+		explanation TEXT NOT NULL,
+		completeness INTEGER DEFAULT 0,
+		correctness INTEGER DEFAULT 0,
+		missing_points TEXT,
+		misconceptions TEXT,
+		strengths TEXT,
+		feedback TEXT,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS comparisons (
+		id TEXT PRIMARY KEY,
+		comparison_key TEXT NOT NULL,
+		subjects TEXT NOT NULL,
+		topic_ids TEXT,
+		glossary_ids TEXT,
+		rows_json TEXT,
+		similarities TEXT,
+		differences TEXT,
+		summary TEXT,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS document_stats (
+		document_id TEXT PRIMARY KEY,
+		stats TEXT NOT NULL,
+		computed_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS document_toc (
+		document_id TEXT PRIMARY KEY,
+		toc TEXT NOT NULL,
+		computed_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS interleave_pairs (
+		study_plan_id TEXT NOT NULL,
+		topic_a TEXT NOT NULL,
+		topic_b TEXT NOT NULL,
+		count INTEGER DEFAULT 0,
+		last_at DATETIME NOT NULL,
+		PRIMARY KEY (topic_a, topic_b)
+	);
+
+	CREATE TABLE IF NOT EXISTS chat_pins (
+		id TEXT PRIMARY KEY,
+		session_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		ref_id TEXT,
+		page INTEGER DEFAULT 0,
+		label TEXT,
+		content TEXT,
+		created_at DATETIME NOT NULL
+	);
+
 	CREATE INDEX IF NOT EXISTS idx_topics_plan ON topics(study_plan_id);
 	CREATE INDEX IF NOT EXISTS idx_questions_topic ON questions(topic_id);
 	CREATE INDEX IF NOT EXISTS idx_sessions_plan ON study_sessions(study_plan_id);
 	CREATE INDEX IF NOT EXISTS idx_chat_session ON chat_messages(session_id);
+	CREATE INDEX IF NOT EXISTS idx_explanations_topic ON explanations(topic_id);
+	CREATE INDEX IF NOT EXISTS idx_objectives_topic ON learning_objectives(topic_id);
+	CREATE INDEX IF NOT EXISTS idx_notes_topic ON notes(topic_id);
+	CREATE INDEX IF NOT EXISTS idx_syllabus_plan ON syllabus_items(study_plan_id);
+	CREATE INDEX IF NOT EXISTS idx_topic_sources_topic ON topic_sources(topic_id);
+	CREATE INDEX IF NOT EXISTS idx_flags_item ON flags(item_type, item_id);
+	CREATE INDEX IF NOT EXISTS idx_attempts_question ON question_attempts(question_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_mnemonics_topic ON mnemonics(topic_id);
+	CREATE INDEX IF NOT EXISTS idx_worked_examples_topic ON worked_examples(topic_id);
+	CREATE INDEX IF NOT EXISTS idx_teach_backs_topic ON teach_backs(topic_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_comparisons_key ON comparisons(comparison_key);
+	CREATE INDEX IF NOT EXISTS idx_interleave_plan ON interleave_pairs(study_plan_id);
+	CREATE INDEX IF NOT EXISTS idx_chat_pins_session ON chat_pins(session_id);
 
 	CREATE TABLE IF NOT EXISTS glossary (
 		id TEXT PRIMARY KEY,
@@ -164,384 +486,2557 @@ func (s *SQLiteStorage) initSchema() error {
 		updated_at DATETIME NOT NULL
 	);
 	CREATE INDEX IF NOT EXISTS idx_glossary_term ON glossary(term);
+
+	CREATE TABLE IF NOT EXISTS plan_jobs (
+		id TEXT PRIMARY KEY,
+		exam_date TEXT NOT NULL,
+		document_ids TEXT,
+		status TEXT NOT NULL,
+		document_topics TEXT,
+		topics TEXT,
+		analyzed INTEGER DEFAULT 0,
+		plan_id TEXT DEFAULT '',
+		error TEXT DEFAULT '',
+		attempts INTEGER DEFAULT 0,
+		estimated_seconds INTEGER DEFAULT 0,
+		model TEXT DEFAULT '',
+		profile_id TEXT DEFAULT '',
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS duration_samples (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		step TEXT NOT NULL,
+		model TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		seconds REAL NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS figures (
+		id TEXT PRIMARY KEY,
+		document_id TEXT NOT NULL,
+		page INTEGER DEFAULT 0,
+		width INTEGER DEFAULT 0,
+		height INTEGER DEFAULT 0,
+		mime_type TEXT NOT NULL,
+		data BLOB NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_figures_document ON figures(document_id);
+
+	CREATE TABLE IF NOT EXISTS quizzes (
+		id TEXT PRIMARY KEY,
+		study_plan_id TEXT DEFAULT '',
+		name TEXT NOT NULL,
+		question_ids TEXT,
+		topic_counts TEXT,
+		time_limit_minutes INTEGER DEFAULT 0,
+		shuffle INTEGER DEFAULT 0,
+		cognitive_level TEXT DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS quiz_results (
+		id TEXT PRIMARY KEY,
+		quiz_id TEXT NOT NULL,
+		question_ids TEXT,
+		started_at DATETIME NOT NULL,
+		finished_at DATETIME,
+		answered INTEGER DEFAULT 0,
+		correct INTEGER DEFAULT 0,
+		score INTEGER DEFAULT 0,
+		overtime INTEGER DEFAULT 0
+	);
+	CREATE INDEX IF NOT EXISTS idx_quiz_results_quiz ON quiz_results(quiz_id, started_at);
+
+	CREATE TABLE IF NOT EXISTS device_tokens (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		scopes TEXT,
+		created_at DATETIME NOT NULL,
+		last_used_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS subject_profiles (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		builtin INTEGER DEFAULT 0,
+		explanation_prompt TEXT DEFAULT '',
+		question_prompt TEXT DEFAULT '',
+		question_types TEXT,
+		strictness TEXT DEFAULT 'normal',
+		formulas INTEGER DEFAULT 0,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
 	`
 
 	_, err := s.db.Exec(schema)
 	return err
 }
 
-func (s *SQLiteStorage) Close() error {
-	return s.db.Close()
+// columnMigrations ergänzt Spalten, die in älteren Datenbanken noch fehlen
+var columnMigrations = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"questions", "time_limit_seconds", "INTEGER DEFAULT 0"},
+	{"questions", "started_at", "DATETIME"},
+	{"questions", "answer_seconds", "INTEGER"},
+	{"questions", "answered_late", "INTEGER DEFAULT 0"},
+	{"questions", "cognitive_level", "TEXT DEFAULT ''"},
+	{"questions", "source_document_id", "TEXT DEFAULT ''"},
+	{"questions", "source_page", "INTEGER DEFAULT 0"},
+	{"questions", "source_quote", "TEXT DEFAULT ''"},
+	{"questions", "exam_document_id", "TEXT DEFAULT ''"},
+	{"questions", "exam_year", "INTEGER DEFAULT 0"},
+	{"question_attempts", "error_type", "TEXT DEFAULT ''"},
+	{"chat_messages", "document_id", "TEXT DEFAULT ''"},
+	{"chat_messages", "replaced_by", "TEXT DEFAULT ''"},
+	{"topics", "parent_topic_id", "TEXT DEFAULT ''"},
+	{"topics", "exam_weight", "REAL DEFAULT 0"},
+	{"study_plans", "phases", "TEXT DEFAULT ''"},
+	{"study_plans", "subject", "TEXT DEFAULT ''"},
+	{"study_plans", "color", "TEXT DEFAULT ''"},
+	{"study_plans", "notes", "TEXT DEFAULT ''"},
+	{"explanations", "parent_id", "TEXT DEFAULT ''"},
+	{"explanations", "feedback", "TEXT DEFAULT ''"},
+	{"study_sessions", "recap_status", "TEXT DEFAULT ''"},
+	{"study_sessions", "recap_question_ids", "TEXT DEFAULT ''"},
+	{"study_sessions", "auto_closed", "INTEGER DEFAULT 0"},
+	{"documents", "content_length", "INTEGER DEFAULT -1"},
+	{"documents", "word_count", "INTEGER DEFAULT 0"},
+	{"documents", "has_text", "INTEGER DEFAULT 0"},
+	{"documents", "language", "TEXT DEFAULT ''"},
+	{"documents", "raw_content", "TEXT"},
+	{"documents", "tags", "TEXT DEFAULT '[]'"},
+	{"documents", "source_url", "TEXT DEFAULT ''"},
+	{"documents", "links", "TEXT DEFAULT '[]'"},
+	{"documents", "title", "TEXT DEFAULT ''"},
+	{"documents", "author", "TEXT DEFAULT ''"},
+	{"documents", "semester", "TEXT DEFAULT ''"},
+	{"plan_jobs", "estimated_seconds", "INTEGER DEFAULT 0"},
+	{"plan_jobs", "model", "TEXT DEFAULT ''"},
+	{"questions", "figure_id", "TEXT DEFAULT ''"},
+	{"study_plans", "profile_id", "TEXT DEFAULT ''"},
+	{"quizzes", "cognitive_level", "TEXT DEFAULT ''"},
+	{"plan_jobs", "profile_id", "TEXT DEFAULT ''"},
 }
 
-// Dokumente
+func (s *SQLiteStorage) migrate() error {
+	for _, m := range columnMigrations {
+		exists, err := s.hasColumn(m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)); err != nil {
+			return fmt.Errorf("migration %s.%s fehlgeschlagen: %w", m.table, m.column, err)
+		}
+	}
 
-func (s *SQLiteStorage) SaveDocument(doc *models.Document) error {
-	_, err := s.db.Exec(`
-		INSERT OR REPLACE INTO documents (id, name, path, content, page_count, uploaded_at, processed_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`, doc.ID, doc.Name, doc.Path, doc.Content, doc.PageCount, doc.UploadedAt, doc.ProcessedAt)
-	return err
+	// Bisher gespeicherte letzte Antworten als ersten Versuch übernehmen
+	if _, err := s.db.Exec(`
+		INSERT INTO question_attempts (id, question_id, topic_id, answer, is_correct, feedback, answer_seconds, answered_late, created_at)
+		SELECT 'att_' || q.id, q.id, q.topic_id, q.user_answer, q.is_correct, q.feedback, q.answer_seconds, q.answered_late, q.answered_at
+		FROM questions q
+		WHERE q.answered_at IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM question_attempts a WHERE a.question_id = q.id)
+	`); err != nil {
+		return fmt.Errorf("migration question_attempts fehlgeschlagen: %w", err)
+	}
+
+	// Antwortdauer 0 hieß bisher "nicht gemessen"; dafür steht jetzt NULL, 0 ist eine echte Messung
+	if err := s.migrateOnce("answer_seconds_null",
+		`UPDATE questions SET answer_seconds = NULL WHERE answer_seconds = 0`,
+		`UPDATE question_attempts SET answer_seconds = NULL WHERE answer_seconds = 0`,
+	); err != nil {
+		return fmt.Errorf("migration answer_seconds fehlgeschlagen: %w", err)
+	}
+
+	// Kopf-/Fußzeilen aus bereits eingelesenen Dokumenten entfernen (Rohtext bleibt erhalten)
+	if err := s.backfillBoilerplate(); err != nil {
+		return fmt.Errorf("migration documents.raw_content fehlgeschlagen: %w", err)
+	}
+
+	// Textstatistik für bereits eingelesene Dokumente nachtragen
+	if err := s.backfillDocumentStats(); err != nil {
+		return fmt.Errorf("migration documents.content_length fehlgeschlagen: %w", err)
+	}
+	return nil
 }
 
-func (s *SQLiteStorage) GetDocument(id string) (*models.Document, error) {
-	var doc models.Document
-	err := s.db.QueryRow(`
-		SELECT id, name, path, content, page_count, uploaded_at, processed_at
-		FROM documents WHERE id = ?
-	`, id).Scan(&doc.ID, &doc.Name, &doc.Path, &doc.Content, &doc.PageCount, &doc.UploadedAt, &doc.ProcessedAt)
+// migrateOnce führt Datenmigrationen aus, die nicht wiederholt werden dürfen; erledigte stehen in settings
+func (s *SQLiteStorage) migrateOnce(name string, statements ...string) error {
+	var done int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM settings WHERE key = ?`, "migration."+name).Scan(&done); err != nil {
+		return err
+	}
+	if done > 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return &doc, nil
+	defer tx.Rollback()
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO settings (key, value) VALUES (?, ?)`, "migration."+name, time.Now().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (s *SQLiteStorage) GetAllDocuments() ([]models.Document, error) {
-	rows, err := s.db.Query(`SELECT id, name, path, page_count, uploaded_at, processed_at FROM documents`)
+func (s *SQLiteStorage) backfillBoilerplate() error {
+	rows, err := s.db.Query(`SELECT id, content FROM documents WHERE raw_content IS NULL`)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
+	raw := make(map[string]string)
+	for rows.Next() {
+		var id string
+		var content sql.NullString
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return err
+		}
+		raw[id] = content.String
+	}
+	rows.Close()
+
+	for id, content := range raw {
+		// content_length -1: Textstatistik danach neu berechnen
+		if _, err := s.db.Exec(`UPDATE documents SET raw_content = ?, content = ?, content_length = -1 WHERE id = ?`,
+			content, pdf.StripBoilerplate(content).Content, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+func (s *SQLiteStorage) backfillDocumentStats() error {
+	rows, err := s.db.Query(`SELECT id, content, page_count FROM documents WHERE content_length < 0 OR language = ''`)
+	if err != nil {
+		return err
+	}
 	var docs []models.Document
 	for rows.Next() {
 		var doc models.Document
-		if err := rows.Scan(&doc.ID, &doc.Name, &doc.Path, &doc.PageCount, &doc.UploadedAt, &doc.ProcessedAt); err != nil {
-			return nil, err
+		var content sql.NullString
+		var pages sql.NullInt64
+		if err := rows.Scan(&doc.ID, &content, &pages); err != nil {
+			rows.Close()
+			return err
 		}
+		doc.Content = content.String
+		doc.PageCount = int(pages.Int64)
 		docs = append(docs, doc)
 	}
-	return docs, nil
+	rows.Close()
+
+	for _, doc := range docs {
+		pdf.ApplyTextStats(&doc)
+		if _, err := s.db.Exec(`UPDATE documents SET content_length = ?, word_count = ?, has_text = ?, language = ? WHERE id = ?`,
+			doc.ContentLength, doc.WordCount, doc.HasText, doc.Language, doc.ID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (s *SQLiteStorage) DeleteDocument(id string) error {
-	_, err := s.db.Exec(`DELETE FROM documents WHERE id = ?`, id)
-	return err
+func (s *SQLiteStorage) hasColumn(table, column string) (bool, error) {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
 }
 
-// Lernpläne
+// Ping prüft, ob die Datenbank erreichbar ist und Abfragen beantwortet
+func (s *SQLiteStorage) Ping(ctx context.Context) error {
+	var one int
+	return s.db.QueryRowContext(ctx, `SELECT 1`).Scan(&one)
+}
 
-func (s *SQLiteStorage) SaveStudyPlan(plan *models.StudyPlan) error {
-	docIDs, _ := json.Marshal(plan.Documents)
-	_, err := s.db.Exec(`
-		INSERT OR REPLACE INTO study_plans (id, name, exam_date, created_at, total_minutes, document_ids, status, progress)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`, plan.ID, plan.Name, plan.ExamDate, plan.CreatedAt, plan.TotalMinutes, string(docIDs), plan.Status, plan.Progress)
-	return err
+// CheckMigrations prüft, ob alle Spalten-Migrationen angewendet sind
+func (s *SQLiteStorage) CheckMigrations() error {
+	for _, m := range columnMigrations {
+		exists, err := s.hasColumn(m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("migration %s.%s fehlt", m.table, m.column)
+		}
+	}
+	return nil
 }
 
-func (s *SQLiteStorage) GetStudyPlan(id string) (*models.StudyPlan, error) {
-	var plan models.StudyPlan
-	var docIDs string
-	err := s.db.QueryRow(`
-		SELECT id, name, exam_date, created_at, total_minutes, document_ids, status, progress
-		FROM study_plans WHERE id = ?
-	`, id).Scan(&plan.ID, &plan.Name, &plan.ExamDate, &plan.CreatedAt, &plan.TotalMinutes, &docIDs, &plan.Status, &plan.Progress)
+// dataTables liefert alle Tabellen der Datenbank, auch die künftiger Migrationen
+// (ohne settings: dort stehen nur interne Einstellungen, keine Lerndaten)
+func (s *SQLiteStorage) dataTables() ([]string, error) {
+	rows, err := s.db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != 'settings' ORDER BY name`)
 	if err != nil {
 		return nil, err
 	}
-	json.Unmarshal([]byte(docIDs), &plan.Documents)
+	defer rows.Close()
 
-	// Themen laden
-	plan.Topics, _ = s.GetTopicsByPlan(plan.ID)
-	return &plan, nil
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
 }
 
-func (s *SQLiteStorage) GetActiveStudyPlan() (*models.StudyPlan, error) {
-	var plan models.StudyPlan
-	var docIDs string
-	err := s.db.QueryRow(`
-		SELECT id, name, exam_date, created_at, total_minutes, document_ids, status, progress
-		FROM study_plans WHERE status = 'active' ORDER BY created_at DESC LIMIT 1
-	`).Scan(&plan.ID, &plan.Name, &plan.ExamDate, &plan.CreatedAt, &plan.TotalMinutes, &docIDs, &plan.Status, &plan.Progress)
+// CountAllData zählt die Einträge je Tabelle
+func (s *SQLiteStorage) CountAllData() (map[string]int, error) {
+	tables, err := s.dataTables()
 	if err != nil {
 		return nil, err
 	}
-	json.Unmarshal([]byte(docIDs), &plan.Documents)
-	plan.Topics, _ = s.GetTopicsByPlan(plan.ID)
-	return &plan, nil
+	counts := make(map[string]int, len(tables))
+	for _, table := range tables {
+		var n int
+		if err := s.db.QueryRow(`SELECT COUNT(*) FROM "` + table + `"`).Scan(&n); err != nil {
+			return nil, err
+		}
+		counts[table] = n
+	}
+	return counts, nil
 }
 
-func (s *SQLiteStorage) GetAllStudyPlans() ([]models.StudyPlan, error) {
-	rows, err := s.db.Query(`
-		SELECT id, name, exam_date, created_at, total_minutes, document_ids, status, progress
-		FROM study_plans ORDER BY created_at DESC
-	`)
+// WipeAllData leert alle Tabellen. secure_delete überschreibt die gelöschten Inhalte,
+// VACUUM baut die Datei neu auf, damit nichts in freien Seiten zurückbleibt.
+func (s *SQLiteStorage) WipeAllData() (map[string]int, error) {
+	counts, err := s.CountAllData()
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var plans []models.StudyPlan
-	for rows.Next() {
-		var plan models.StudyPlan
-		var docIDs string
-		if err := rows.Scan(&plan.ID, &plan.Name, &plan.ExamDate, &plan.CreatedAt, &plan.TotalMinutes, &docIDs, &plan.Status, &plan.Progress); err != nil {
-			return nil, err
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`PRAGMA secure_delete = ON`); err != nil {
+		return nil, err
+	}
+	for table := range counts {
+		if _, err := tx.Exec(`DELETE FROM "` + table + `"`); err != nil {
+			return nil, fmt.Errorf("tabelle %s: %w", table, err)
 		}
-		json.Unmarshal([]byte(docIDs), &plan.Documents)
-		plans = append(plans, plan)
 	}
-	return plans, nil
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.Exec(`VACUUM`); err != nil {
+		return counts, fmt.Errorf("vacuum: %w", err)
+	}
+	return counts, nil
 }
 
-func (s *SQLiteStorage) UpdateStudyPlanProgress(id string, progress float64) error {
-	_, err := s.db.Exec(`UPDATE study_plans SET progress = ? WHERE id = ?`, progress, id)
-	return err
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
 }
 
-// Themen
+// Dokumente
 
-func (s *SQLiteStorage) SaveTopic(topic *models.Topic) error {
+func (s *SQLiteStorage) SaveDocument(doc *models.Document) error {
+	rawContent := doc.RawContent
+	if rawContent == "" {
+		rawContent = doc.Content
+	}
+	tags, _ := json.Marshal(doc.Tags)
+	links, _ := json.Marshal(doc.Links)
 	_, err := s.db.Exec(`
-		INSERT OR REPLACE INTO topics (id, study_plan_id, name, description, content, topic_order, difficulty, est_minutes, status, progress)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, topic.ID, topic.StudyPlanID, topic.Name, topic.Description, topic.Content, topic.Order, topic.Difficulty, topic.EstMinutes, topic.Status, topic.Progress)
+		INSERT OR REPLACE INTO documents (id, name, path, content, page_count, uploaded_at, processed_at, content_length, word_count, has_text, language, raw_content, tags, source_url, links, title, author, semester)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, doc.ID, doc.Name, doc.Path, doc.Content, doc.PageCount, doc.UploadedAt, doc.ProcessedAt, doc.ContentLength, doc.WordCount, doc.HasText, doc.Language, rawContent, string(tags), doc.SourceURL, string(links), doc.Title, doc.Author, doc.Semester)
 	return err
 }
 
-func (s *SQLiteStorage) GetTopic(id string) (*models.Topic, error) {
-	var topic models.Topic
+func (s *SQLiteStorage) GetDocument(id string) (*models.Document, error) {
+	var doc models.Document
+	var tags, links sql.NullString
 	err := s.db.QueryRow(`
-		SELECT id, study_plan_id, name, description, content, topic_order, difficulty, est_minutes, status, progress
-		FROM topics WHERE id = ?
-	`, id).Scan(&topic.ID, &topic.StudyPlanID, &topic.Name, &topic.Description, &topic.Content, &topic.Order, &topic.Difficulty, &topic.EstMinutes, &topic.Status, &topic.Progress)
+		SELECT id, name, path, content, page_count, uploaded_at, processed_at, content_length, word_count, has_text, language, tags, source_url, links, title, author, semester
+		FROM documents WHERE id = ?
+	`, id).Scan(&doc.ID, &doc.Name, &doc.Path, &doc.Content, &doc.PageCount, &doc.UploadedAt, &doc.ProcessedAt, &doc.ContentLength, &doc.WordCount, &doc.HasText, &doc.Language, &tags, &doc.SourceURL, &links, &doc.Title, &doc.Author, &doc.Semester)
 	if err != nil {
 		return nil, err
 	}
-	topic.Questions, _ = s.GetQuestionsByTopic(topic.ID)
-	return &topic, nil
+	if tags.Valid {
+		json.Unmarshal([]byte(tags.String), &doc.Tags)
+	}
+	if links.Valid {
+		json.Unmarshal([]byte(links.String), &doc.Links)
+	}
+	return &doc, nil
 }
 
-func (s *SQLiteStorage) GetTopicsByPlan(planID string) ([]models.Topic, error) {
-	rows, err := s.db.Query(`
-		SELECT id, study_plan_id, name, description, topic_order, difficulty, est_minutes, status, progress
-		FROM topics WHERE study_plan_id = ? ORDER BY topic_order
-	`, planID)
+func (s *SQLiteStorage) GetAllDocuments() ([]models.Document, error) {
+	rows, err := s.db.Query(`SELECT id, name, path, page_count, uploaded_at, processed_at, content_length, word_count, has_text, language, tags, source_url, links, title, author, semester FROM documents`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var topics []models.Topic
+	var docs []models.Document
 	for rows.Next() {
-		var topic models.Topic
-		if err := rows.Scan(&topic.ID, &topic.StudyPlanID, &topic.Name, &topic.Description, &topic.Order, &topic.Difficulty, &topic.EstMinutes, &topic.Status, &topic.Progress); err != nil {
+		var doc models.Document
+		var tags, links sql.NullString
+		if err := rows.Scan(&doc.ID, &doc.Name, &doc.Path, &doc.PageCount, &doc.UploadedAt, &doc.ProcessedAt, &doc.ContentLength, &doc.WordCount, &doc.HasText, &doc.Language, &tags, &doc.SourceURL, &links, &doc.Title, &doc.Author, &doc.Semester); err != nil {
 			return nil, err
 		}
-		topics = append(topics, topic)
+		if tags.Valid {
+			json.Unmarshal([]byte(tags.String), &doc.Tags)
+		}
+		if links.Valid {
+			json.Unmarshal([]byte(links.String), &doc.Links)
+		}
+		docs = append(docs, doc)
 	}
-	return topics, nil
+	return docs, nil
 }
 
-func (s *SQLiteStorage) UpdateTopicStatus(id string, status string, progress float64) error {
-	_, err := s.db.Exec(`UPDATE topics SET status = ?, progress = ? WHERE id = ?`, status, progress, id)
-	return err
-}
+func (s *SQLiteStorage) DeleteDocument(id string) error {
+	if _, err := s.db.Exec(`DELETE FROM document_stats WHERE document_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`DELETE FROM document_toc WHERE document_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`DELETE FROM figures WHERE document_id = ?`, id); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`DELETE FROM documents WHERE id = ?`, id)
+	return err
+}
+
+// SetDocumentLanguage ändert die Sprache eines Dokuments
+func (s *SQLiteStorage) SetDocumentLanguage(id, language string) error {
+	res, err := s.db.Exec(`UPDATE documents SET language = ? WHERE id = ?`, language, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateDocumentMetadata speichert Titel, Autor, Semester und Schlagworte eines Dokuments
+func (s *SQLiteStorage) UpdateDocumentMetadata(doc *models.Document) error {
+	tags, _ := json.Marshal(doc.Tags)
+	res, err := s.db.Exec(`UPDATE documents SET title = ?, author = ?, semester = ?, tags = ? WHERE id = ?`,
+		doc.Title, doc.Author, doc.Semester, string(tags), doc.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetDocumentRawContent liefert den Text eines Dokuments wie aus der PDF gelesen
+func (s *SQLiteStorage) GetDocumentRawContent(id string) (string, error) {
+	var raw, content sql.NullString
+	if err := s.db.QueryRow(`SELECT raw_content, content FROM documents WHERE id = ?`, id).Scan(&raw, &content); err != nil {
+		return "", err
+	}
+	if !raw.Valid {
+		return content.String, nil
+	}
+	return raw.String, nil
+}
+
+func (s *SQLiteStorage) SaveDocumentStats(stats *models.DocumentStats) error {
+	data, _ := json.Marshal(stats)
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO document_stats (document_id, stats, computed_at)
+		VALUES (?, ?, ?)
+	`, stats.DocumentID, string(data), stats.ComputedAt)
+	return err
+}
+
+func (s *SQLiteStorage) GetDocumentStats(documentID string) (*models.DocumentStats, error) {
+	var data string
+	if err := s.db.QueryRow(`SELECT stats FROM document_stats WHERE document_id = ?`, documentID).Scan(&data); err != nil {
+		return nil, err
+	}
+	var stats models.DocumentStats
+	if err := json.Unmarshal([]byte(data), &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (s *SQLiteStorage) SaveDocumentTOC(toc *models.DocumentTOC) error {
+	data, _ := json.Marshal(toc)
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO document_toc (document_id, toc, computed_at)
+		VALUES (?, ?, ?)
+	`, toc.DocumentID, string(data), toc.ComputedAt)
+	return err
+}
+
+func (s *SQLiteStorage) GetDocumentTOC(documentID string) (*models.DocumentTOC, error) {
+	var data string
+	if err := s.db.QueryRow(`SELECT toc FROM document_toc WHERE document_id = ?`, documentID).Scan(&data); err != nil {
+		return nil, err
+	}
+	var toc models.DocumentTOC
+	if err := json.Unmarshal([]byte(data), &toc); err != nil {
+		return nil, err
+	}
+	return &toc, nil
+}
+
+// SaveFigures ersetzt die Abbildungen eines Dokuments
+func (s *SQLiteStorage) SaveFigures(documentID string, figures []models.Figure) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM figures WHERE document_id = ?`, documentID); err != nil {
+		return err
+	}
+	for _, f := range figures {
+		if _, err := tx.Exec(`
+			INSERT INTO figures (id, document_id, page, width, height, mime_type, data, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, f.ID, documentID, f.Page, f.Width, f.Height, f.MimeType, f.Data, f.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const figureColumns = `id, document_id, page, width, height, mime_type, data, created_at`
+
+func scanFigure(row rowScanner) (*models.Figure, error) {
+	var f models.Figure
+	if err := row.Scan(&f.ID, &f.DocumentID, &f.Page, &f.Width, &f.Height, &f.MimeType, &f.Data, &f.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// GetFigures liefert die Abbildungen eines Dokuments nach Seiten sortiert
+func (s *SQLiteStorage) GetFigures(documentID string) ([]models.Figure, error) {
+	rows, err := s.db.Query(`SELECT `+figureColumns+` FROM figures WHERE document_id = ? ORDER BY page, id`, documentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var figures []models.Figure
+	for rows.Next() {
+		f, err := scanFigure(rows)
+		if err != nil {
+			return nil, err
+		}
+		figures = append(figures, *f)
+	}
+	return figures, rows.Err()
+}
+
+func (s *SQLiteStorage) GetFigure(id string) (*models.Figure, error) {
+	return scanFigure(s.db.QueryRow(`SELECT `+figureColumns+` FROM figures WHERE id = ?`, id))
+}
+
+// Lernpläne
+
+func (s *SQLiteStorage) SaveStudyPlan(plan *models.StudyPlan) error {
+	docIDs, _ := json.Marshal(plan.Documents)
+	phases, _ := json.Marshal(plan.Phases)
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO study_plans (id, name, exam_date, created_at, total_minutes, document_ids, status, progress, phases, subject, color, notes, profile_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, plan.ID, plan.Name, plan.ExamDate, plan.CreatedAt, plan.TotalMinutes, string(docIDs), plan.Status, plan.Progress, string(phases), plan.Subject, plan.Color, plan.Notes, plan.ProfileID)
+	return err
+}
+
+func (s *SQLiteStorage) GetStudyPlan(id string) (*models.StudyPlan, error) {
+	var plan models.StudyPlan
+	var docIDs, phases string
+	err := s.db.QueryRow(`
+		SELECT id, name, exam_date, created_at, total_minutes, document_ids, status, progress, phases, subject, color, notes, profile_id
+		FROM study_plans WHERE id = ?
+	`, id).Scan(&plan.ID, &plan.Name, &plan.ExamDate, &plan.CreatedAt, &plan.TotalMinutes, &docIDs, &plan.Status, &plan.Progress, &phases, &plan.Subject, &plan.Color, &plan.Notes, &plan.ProfileID)
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(docIDs), &plan.Documents)
+	json.Unmarshal([]byte(phases), &plan.Phases)
+
+	// Themen laden
+	plan.Topics, _ = s.GetTopicsByPlan(plan.ID)
+	return &plan, nil
+}
+
+func (s *SQLiteStorage) GetActiveStudyPlan() (*models.StudyPlan, error) {
+	var plan models.StudyPlan
+	var docIDs, phases string
+	err := s.db.QueryRow(`
+		SELECT id, name, exam_date, created_at, total_minutes, document_ids, status, progress, phases, subject, color, notes, profile_id
+		FROM study_plans WHERE status = 'active' ORDER BY created_at DESC LIMIT 1
+	`).Scan(&plan.ID, &plan.Name, &plan.ExamDate, &plan.CreatedAt, &plan.TotalMinutes, &docIDs, &plan.Status, &plan.Progress, &phases, &plan.Subject, &plan.Color, &plan.Notes, &plan.ProfileID)
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(docIDs), &plan.Documents)
+	json.Unmarshal([]byte(phases), &plan.Phases)
+	plan.Topics, _ = s.GetTopicsByPlan(plan.ID)
+	return &plan, nil
+}
+
+func (s *SQLiteStorage) GetAllStudyPlans() ([]models.StudyPlan, error) {
+	rows, err := s.db.Query(`
+		SELECT id, name, exam_date, created_at, total_minutes, document_ids, status, progress, phases, subject, color, notes, profile_id
+		FROM study_plans ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var plans []models.StudyPlan
+	for rows.Next() {
+		var plan models.StudyPlan
+		var docIDs, phases string
+		if err := rows.Scan(&plan.ID, &plan.Name, &plan.ExamDate, &plan.CreatedAt, &plan.TotalMinutes, &docIDs, &plan.Status, &plan.Progress, &phases, &plan.Subject, &plan.Color, &plan.Notes, &plan.ProfileID); err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(docIDs), &plan.Documents)
+		json.Unmarshal([]byte(phases), &plan.Phases)
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// UpdateStudyPlanDetails speichert Name, Fach, Farbe, Notizen und Fachprofil eines Lernplans
+func (s *SQLiteStorage) UpdateStudyPlanDetails(plan *models.StudyPlan) error {
+	res, err := s.db.Exec(`UPDATE study_plans SET name = ?, subject = ?, color = ?, notes = ?, profile_id = ? WHERE id = ?`,
+		plan.Name, plan.Subject, plan.Color, plan.Notes, plan.ProfileID, plan.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) UpdateStudyPlanProgress(id string, progress float64) error {
+	_, err := s.db.Exec(`UPDATE study_plans SET progress = ? WHERE id = ?`, progress, id)
+	return err
+}
+
+// Themen
+
+func (s *SQLiteStorage) SaveTopic(topic *models.Topic) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO topics (id, study_plan_id, parent_topic_id, name, description, content, topic_order, difficulty, est_minutes, status, progress, exam_weight)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, topic.ID, topic.StudyPlanID, topic.ParentTopicID, topic.Name, topic.Description, topic.Content, topic.Order, topic.Difficulty, topic.EstMinutes, topic.Status, topic.Progress, topic.ExamWeight)
+	return err
+}
+
+func (s *SQLiteStorage) GetTopic(id string) (*models.Topic, error) {
+	var topic models.Topic
+	err := s.db.QueryRow(`
+		SELECT id, study_plan_id, parent_topic_id, name, description, content, topic_order, difficulty, est_minutes, status, progress, exam_weight
+		FROM topics WHERE id = ?
+	`, id).Scan(&topic.ID, &topic.StudyPlanID, &topic.ParentTopicID, &topic.Name, &topic.Description, &topic.Content, &topic.Order, &topic.Difficulty, &topic.EstMinutes, &topic.Status, &topic.Progress, &topic.ExamWeight)
+	if err != nil {
+		return nil, err
+	}
+	topic.Questions, _ = s.GetQuestionsByTopic(topic.ID)
+	topic.Objectives, _ = s.GetObjectivesByTopic(topic.ID)
+	topic.Subtopics, _ = s.GetSubtopics(topic.ID)
+	topic.Sources, _ = s.GetTopicSources(topic.ID)
+	topic.Examples, _ = s.GetWorkedExamplesByTopic(topic.ID)
+	return &topic, nil
+}
+
+// GetTopicsByPlan liefert die Themen eines Plans als Baum (Unterthemen in Subtopics)
+func (s *SQLiteStorage) GetTopicsByPlan(planID string) ([]models.Topic, error) {
+	topics, err := s.queryTopics(`WHERE study_plan_id = ?`, planID)
+	if err != nil {
+		return nil, err
+	}
+	return nestTopics(topics, ""), nil
+}
+
+// GetSubtopics liefert die direkten Unterthemen eines Themas
+func (s *SQLiteStorage) GetSubtopics(parentID string) ([]models.Topic, error) {
+	return s.queryTopics(`WHERE parent_topic_id = ?`, parentID)
+}
+
+func (s *SQLiteStorage) queryTopics(where string, args ...interface{}) ([]models.Topic, error) {
+	rows, err := s.db.Query(`
+		SELECT id, study_plan_id, parent_topic_id, name, description, topic_order, difficulty, est_minutes, status, progress, exam_weight
+		FROM topics `+where+` ORDER BY topic_order
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var topics []models.Topic
+	for rows.Next() {
+		var topic models.Topic
+		if err := rows.Scan(&topic.ID, &topic.StudyPlanID, &topic.ParentTopicID, &topic.Name, &topic.Description, &topic.Order, &topic.Difficulty, &topic.EstMinutes, &topic.Status, &topic.Progress, &topic.ExamWeight); err != nil {
+			return nil, err
+		}
+		topics = append(topics, topic)
+	}
+	return topics, nil
+}
+
+// nestTopics baut aus der flachen Liste den Baum unterhalb von parentID.
+// Themen mit unbekanntem Elternthema landen auf oberster Ebene.
+func nestTopics(topics []models.Topic, parentID string) []models.Topic {
+	known := make(map[string]bool)
+	for _, t := range topics {
+		known[t.ID] = true
+	}
+
+	var result []models.Topic
+	for _, t := range topics {
+		parent := t.ParentTopicID
+		if !known[parent] {
+			parent = ""
+		}
+		if parent != parentID {
+			continue
+		}
+		t.Subtopics = nestTopics(topics, t.ID)
+		result = append(result, t)
+	}
+	return result
+}
+
+func (s *SQLiteStorage) SetTopicParent(id string, parentID string) error {
+	_, err := s.db.Exec(`UPDATE topics SET parent_topic_id = ? WHERE id = ?`, parentID, id)
+	return err
+}
+
+// RollUpTopicProgress berechnet Status und Fortschritt aller Elternthemen von id
+// aus ihren Unterthemen neu (gewichtet nach geschätzter Lernzeit)
+func (s *SQLiteStorage) RollUpTopicProgress(id string) error {
+	visited := make(map[string]bool)
+	for {
+		var parentID string
+		if err := s.db.QueryRow(`SELECT parent_topic_id FROM topics WHERE id = ?`, id).Scan(&parentID); err != nil {
+			return err
+		}
+		if parentID == "" || visited[parentID] {
+			return nil
+		}
+		visited[parentID] = true
+
+		children, err := s.GetSubtopics(parentID)
+		if err != nil {
+			return err
+		}
+		if len(children) == 0 {
+			return nil
+		}
+
+		var weighted, totalWeight float64
+		completed, started := 0, false
+		for _, c := range children {
+			weight := float64(c.EstMinutes)
+			if weight <= 0 {
+				weight = 1
+			}
+			weighted += c.Progress * weight
+			totalWeight += weight
+			if c.Status == "completed" {
+				completed++
+			}
+			if c.Status != "pending" || c.Progress > 0 {
+				started = true
+			}
+		}
+
+		status := "pending"
+		switch {
+		case completed == len(children):
+			status = "completed"
+		case started:
+			status = "in_progress"
+		}
+
+		if err := s.UpdateTopicStatus(parentID, status, weighted/totalWeight); err != nil {
+			return err
+		}
+		id = parentID
+	}
+}
+
+func (s *SQLiteStorage) UpdateTopicStatus(id string, status string, progress float64) error {
+	_, err := s.db.Exec(`UPDATE topics SET status = ?, progress = ? WHERE id = ?`, status, progress, id)
+	return err
+}
+
+func (s *SQLiteStorage) UpdateTopicOrder(id string, order int) error {
+	_, err := s.db.Exec(`UPDATE topics SET topic_order = ? WHERE id = ?`, order, id)
+	return err
+}
+
+// SetTopicExamWeight setzt das Prüfungsgewicht und die daraus angepasste Lernzeit eines Themas
+func (s *SQLiteStorage) SetTopicExamWeight(id string, weight float64, estMinutes int) error {
+	_, err := s.db.Exec(`UPDATE topics SET exam_weight = ?, est_minutes = ? WHERE id = ?`, weight, estMinutes, id)
+	return err
+}
+
+// SaveTopicSources ersetzt die Quellenangaben eines Themas
+func (s *SQLiteStorage) SaveTopicSources(topicID string, sources []models.TopicSource) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM topic_sources WHERE topic_id = ?`, topicID); err != nil {
+		return err
+	}
+	for _, src := range sources {
+		if _, err := tx.Exec(`
+			INSERT INTO topic_sources (topic_id, document_id, document_name, page_start, page_end)
+			VALUES (?, ?, ?, ?, ?)
+		`, topicID, src.DocumentID, src.DocumentName, src.PageStart, src.PageEnd); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteStorage) GetTopicSources(topicID string) ([]models.TopicSource, error) {
+	rows, err := s.db.Query(`
+		SELECT document_id, document_name, page_start, page_end
+		FROM topic_sources WHERE topic_id = ? ORDER BY document_name, page_start
+	`, topicID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sources []models.TopicSource
+	for rows.Next() {
+		var src models.TopicSource
+		if err := rows.Scan(&src.DocumentID, &src.DocumentName, &src.PageStart, &src.PageEnd); err != nil {
+			return nil, err
+		}
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
+
+// topicReferences listet alle Tabellen, die per topic_id auf ein Thema verweisen
+// (Listen von Themen-IDs stellen die remap-Funktionen unter MergeTopics um)
+var topicReferences = []string{"questions", "learning_objectives", "explanations", "study_sessions", "chat_messages", "chat_sessions", "notes", "flags", "topic_sources", "question_attempts", "mnemonics", "worked_examples", "teach_backs"}
+
+// MergeTopics hängt alle Daten der Quell-Themen an das Ziel-Thema und löscht die Quellen
+func (s *SQLiteStorage) MergeTopics(targetID string, sourceIDs []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, sourceID := range sourceIDs {
+		for _, table := range topicReferences {
+			if _, err := tx.Exec(`UPDATE `+table+` SET topic_id = ? WHERE topic_id = ?`, targetID, sourceID); err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+		}
+		if _, err := tx.Exec(`UPDATE topics SET parent_topic_id = ? WHERE parent_topic_id = ? AND id != ?`, targetID, sourceID, targetID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM topics WHERE id = ?`, sourceID); err != nil {
+			return err
+		}
+	}
+	merged := make(map[string]bool, len(sourceIDs))
+	for _, id := range sourceIDs {
+		merged[id] = true
+	}
+	if err := remapSyllabusTopics(tx, targetID, merged); err != nil {
+		return fmt.Errorf("syllabus_items: %w", err)
+	}
+	if err := remapComparisonTopics(tx, targetID, merged); err != nil {
+		return fmt.Errorf("comparisons: %w", err)
+	}
+	if err := remapQuizTopics(tx, targetID, merged); err != nil {
+		return fmt.Errorf("quizzes: %w", err)
+	}
+	if err := remapInterleavePairs(tx, targetID, merged); err != nil {
+		return fmt.Errorf("interleave_pairs: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// remapSyllabusTopics ersetzt in den Stoffplan-Einträgen die Quell-Themen durch das Ziel-Thema
+// (topic_ids ist eine JSON-Liste und steht deshalb nicht in topicReferences)
+func remapSyllabusTopics(tx *sql.Tx, targetID string, merged map[string]bool) error {
+	return remapColumn(tx, "syllabus_items", "topic_ids", func(raw string) (string, bool) {
+		return remapTopicList(raw, targetID, merged)
+	})
+}
+
+// remapComparisonTopics ersetzt die Quell-Themen in den gespeicherten Vergleichen; der Schlüssel
+// wird mit umgestellt, damit ein neuer Vergleich derselben Themen den gespeicherten findet
+func remapComparisonTopics(tx *sql.Tx, targetID string, merged map[string]bool) error {
+	if err := remapColumn(tx, "comparisons", "topic_ids", func(raw string) (string, bool) {
+		return remapTopicList(raw, targetID, merged)
+	}); err != nil {
+		return err
+	}
+	return remapColumn(tx, "comparisons", "comparison_key", func(raw string) (string, bool) {
+		changed := false
+		seen := make(map[string]bool)
+		var keys []string
+		for _, key := range strings.Split(raw, ",") {
+			if strings.HasPrefix(key, "t:") && merged[key[2:]] {
+				key = "t:" + targetID
+				changed = true
+			}
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		return strings.Join(keys, ","), changed
+	})
+}
+
+// remapQuizTopics legt die Themen-Anzahlen der eigenen Quizze zusammen
+func remapQuizTopics(tx *sql.Tx, targetID string, merged map[string]bool) error {
+	return remapColumn(tx, "quizzes", "topic_counts", func(raw string) (string, bool) {
+		var counts []models.QuizTopicCount
+		if json.Unmarshal([]byte(raw), &counts) != nil {
+			return "", false
+		}
+		changed := false
+		index := make(map[string]int, len(counts))
+		remapped := make([]models.QuizTopicCount, 0, len(counts))
+		for _, tc := range counts {
+			if merged[tc.TopicID] {
+				tc.TopicID = targetID
+				changed = true
+			}
+			if i, ok := index[tc.TopicID]; ok {
+				remapped[i].Count += tc.Count
+				continue
+			}
+			index[tc.TopicID] = len(remapped)
+			remapped = append(remapped, tc)
+		}
+		encoded, _ := json.Marshal(remapped)
+		return string(encoded), changed
+	})
+}
+
+// remapInterleavePairs stellt die Themenpaare auf das Ziel-Thema um; Paare, die dabei zusammenfallen,
+// werden addiert, Paare aus Ziel und Quelle entfallen
+func remapInterleavePairs(tx *sql.Tx, targetID string, merged map[string]bool) error {
+	rows, err := tx.Query(`SELECT study_plan_id, topic_a, topic_b, count, last_at FROM interleave_pairs`)
+	if err != nil {
+		return err
+	}
+	var pairs []models.InterleavePair
+	for rows.Next() {
+		var p models.InterleavePair
+		if err := rows.Scan(&p.StudyPlanID, &p.TopicA, &p.TopicB, &p.Count, &p.LastAt); err != nil {
+			rows.Close()
+			return err
+		}
+		if merged[p.TopicA] || merged[p.TopicB] {
+			pairs = append(pairs, p)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range pairs {
+		if _, err := tx.Exec(`DELETE FROM interleave_pairs WHERE topic_a = ? AND topic_b = ?`, p.TopicA, p.TopicB); err != nil {
+			return err
+		}
+	}
+	for _, p := range pairs {
+		if merged[p.TopicA] {
+			p.TopicA = targetID
+		}
+		if merged[p.TopicB] {
+			p.TopicB = targetID
+		}
+		if p.TopicA == p.TopicB {
+			continue
+		}
+		if p.TopicB < p.TopicA {
+			p.TopicA, p.TopicB = p.TopicB, p.TopicA
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO interleave_pairs (study_plan_id, topic_a, topic_b, count, last_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (topic_a, topic_b) DO UPDATE SET count = count + excluded.count, last_at = MAX(last_at, excluded.last_at)
+		`, p.StudyPlanID, p.TopicA, p.TopicB, p.Count, p.LastAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remapTopicList ersetzt in einer JSON-Liste von Themen-IDs die Quell-Themen durch das Ziel-Thema
+// und entfernt dabei entstehende Doppelte
+func remapTopicList(raw, targetID string, merged map[string]bool) (string, bool) {
+	var topicIDs []string
+	if json.Unmarshal([]byte(raw), &topicIDs) != nil {
+		return "", false
+	}
+	changed := false
+	seen := make(map[string]bool, len(topicIDs))
+	remapped := make([]string, 0, len(topicIDs))
+	for _, topicID := range topicIDs {
+		if merged[topicID] {
+			topicID = targetID
+			changed = true
+		}
+		if !seen[topicID] {
+			seen[topicID] = true
+			remapped = append(remapped, topicID)
+		}
+	}
+	encoded, _ := json.Marshal(remapped)
+	return string(encoded), changed
+}
+
+// remapColumn schreibt eine Spalte aller Zeilen einer Tabelle neu, für die remap eine Änderung meldet
+func remapColumn(tx *sql.Tx, table, column string, remap func(raw string) (string, bool)) error {
+	rows, err := tx.Query(`SELECT id, ` + column + ` FROM ` + table)
+	if err != nil {
+		return err
+	}
+	updated := make(map[string]string)
+	for rows.Next() {
+		var id string
+		var raw sql.NullString
+		if err := rows.Scan(&id, &raw); err != nil {
+			rows.Close()
+			return err
+		}
+		if value, changed := remap(raw.String); changed {
+			updated[id] = value
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, value := range updated {
+		if _, err := tx.Exec(`UPDATE `+table+` SET `+column+` = ? WHERE id = ?`, value, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MoveQuestions ordnet Fragen einem anderen Thema zu
+func (s *SQLiteStorage) MoveQuestions(questionIDs []string, topicID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, id := range questionIDs {
+		if _, err := tx.Exec(`UPDATE questions SET topic_id = ? WHERE id = ?`, topicID, id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE notes SET topic_id = ? WHERE question_id = ?`, topicID, id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE flags SET topic_id = ? WHERE item_type = 'question' AND item_id = ?`, topicID, id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE question_attempts SET topic_id = ? WHERE question_id = ?`, topicID, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Lernziele
+
+func (s *SQLiteStorage) SaveObjective(obj *models.LearningObjective) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO learning_objectives (id, topic_id, text, objective_order, achieved, achieved_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, obj.ID, obj.TopicID, obj.Text, obj.Order, obj.Achieved, obj.AchievedAt)
+	return err
+}
+
+func (s *SQLiteStorage) GetObjectivesByTopic(topicID string) ([]models.LearningObjective, error) {
+	rows, err := s.db.Query(`
+		SELECT id, topic_id, text, objective_order, achieved, achieved_at
+		FROM learning_objectives WHERE topic_id = ? ORDER BY objective_order
+	`, topicID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objectives []models.LearningObjective
+	for rows.Next() {
+		var obj models.LearningObjective
+		var achievedAt sql.NullTime
+		if err := rows.Scan(&obj.ID, &obj.TopicID, &obj.Text, &obj.Order, &obj.Achieved, &achievedAt); err != nil {
+			return nil, err
+		}
+		if achievedAt.Valid {
+			obj.AchievedAt = &achievedAt.Time
+		}
+		objectives = append(objectives, obj)
+	}
+	return objectives, nil
+}
+
+func (s *SQLiteStorage) SetObjectiveAchieved(id string, achieved bool) error {
+	var achievedAt *time.Time
+	if achieved {
+		now := time.Now()
+		achievedAt = &now
+	}
+	res, err := s.db.Exec(`UPDATE learning_objectives SET achieved = ?, achieved_at = ? WHERE id = ?`, achieved, achievedAt, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) DeleteObjectivesByTopic(topicID string) error {
+	_, err := s.db.Exec(`DELETE FROM learning_objectives WHERE topic_id = ?`, topicID)
+	return err
+}
+
+// Erklärungen
+
+func (s *SQLiteStorage) SaveExplanation(exp *models.Explanation) error {
+	keyPoints, _ := json.Marshal(exp.KeyPoints)
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO explanations (id, topic_id, title, content, key_points, created_at, parent_id, feedback)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, exp.ID, exp.TopicID, exp.Title, exp.Content, string(keyPoints), exp.CreatedAt, exp.ParentID, exp.Feedback)
+	return err
+}
+
+const explanationColumns = `id, topic_id, title, content, key_points, created_at, parent_id, feedback`
+
+func scanExplanation(row rowScanner) (*models.Explanation, error) {
+	var exp models.Explanation
+	var keyPoints string
+	if err := row.Scan(&exp.ID, &exp.TopicID, &exp.Title, &exp.Content, &keyPoints, &exp.CreatedAt, &exp.ParentID, &exp.Feedback); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(keyPoints), &exp.KeyPoints)
+	return &exp, nil
+}
+
+func (s *SQLiteStorage) GetExplanation(id string) (*models.Explanation, error) {
+	return scanExplanation(s.db.QueryRow(`SELECT `+explanationColumns+` FROM explanations WHERE id = ?`, id))
+}
+
+func (s *SQLiteStorage) GetLatestExplanation(topicID string) (*models.Explanation, error) {
+	return scanExplanation(s.db.QueryRow(`
+		SELECT `+explanationColumns+`
+		FROM explanations WHERE topic_id = ? ORDER BY created_at DESC LIMIT 1
+	`, topicID))
+}
+
+// GetExplanationsByTopic liefert alle Erklärungen (Varianten) eines Themas, älteste zuerst
+func (s *SQLiteStorage) GetExplanationsByTopic(topicID string) ([]models.Explanation, error) {
+	rows, err := s.db.Query(`
+		SELECT `+explanationColumns+`
+		FROM explanations WHERE topic_id = ? ORDER BY created_at
+	`, topicID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var explanations []models.Explanation
+	for rows.Next() {
+		exp, err := scanExplanation(rows)
+		if err != nil {
+			return nil, err
+		}
+		explanations = append(explanations, *exp)
+	}
+	return explanations, nil
+}
+
+// Fragen
+
+// questionColumns listet alle Spalten, die für eine Frage geladen werden
+const questionColumns = `id, topic_id, question, expected_answer, hints, difficulty, type, options, user_answer, is_correct, feedback, answered_at,
+		time_limit_seconds, started_at, answer_seconds, answered_late, cognitive_level, source_document_id, source_page, source_quote,
+		exam_document_id, exam_year, figure_id`
+
+// rowScanner wird von *sql.Row und *sql.Rows erfüllt
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanQuestion(row rowScanner) (*models.Question, error) {
+	var q models.Question
+	var hints, options string
+	var isCorrect sql.NullInt64
+	var answeredAt, startedAt sql.NullTime
+	err := row.Scan(&q.ID, &q.TopicID, &q.Question, &q.ExpectedAnswer, &hints, &q.Difficulty, &q.Type, &options, &q.UserAnswer, &isCorrect, &q.Feedback, &answeredAt,
+		&q.TimeLimit, &startedAt, &q.AnswerSeconds, &q.AnsweredLate, &q.CognitiveLevel, &q.SourceDocumentID, &q.SourcePage, &q.SourceQuote,
+		&q.ExamDocumentID, &q.ExamYear, &q.FigureID)
+	if err != nil {
+		return nil, err
+	}
+	q.ExamRelevant = q.ExamDocumentID != ""
+	json.Unmarshal([]byte(hints), &q.Hints)
+	json.Unmarshal([]byte(options), &q.Options)
+	if isCorrect.Valid {
+		val := isCorrect.Int64 == 1
+		q.IsCorrect = &val
+	}
+	if answeredAt.Valid {
+		q.AnsweredAt = &answeredAt.Time
+	}
+	if startedAt.Valid {
+		q.StartedAt = &startedAt.Time
+	}
+	return &q, nil
+}
+
+func (s *SQLiteStorage) SaveQuestion(q *models.Question) error {
+	hints, _ := json.Marshal(q.Hints)
+	options, _ := json.Marshal(q.Options)
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO questions (`+questionColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, q.ID, q.TopicID, q.Question, q.ExpectedAnswer, string(hints), q.Difficulty, q.Type, string(options), q.UserAnswer, q.IsCorrect, q.Feedback, q.AnsweredAt,
+		q.TimeLimit, q.StartedAt, q.AnswerSeconds, q.AnsweredLate, q.CognitiveLevel, q.SourceDocumentID, q.SourcePage, q.SourceQuote,
+		q.ExamDocumentID, q.ExamYear, q.FigureID)
+	return err
+}
+
+// SetQuestionExamSource markiert eine Frage als Klausurfrage (leere documentID hebt die Markierung auf)
+func (s *SQLiteStorage) SetQuestionExamSource(id, documentID string, year int) error {
+	_, err := s.db.Exec(`UPDATE questions SET exam_document_id = ?, exam_year = ? WHERE id = ?`, documentID, year, id)
+	return err
+}
+
+func (s *SQLiteStorage) GetQuestion(id string) (*models.Question, error) {
+	return scanQuestion(s.db.QueryRow(`SELECT `+questionColumns+` FROM questions WHERE id = ?`, id))
+}
+
+func (s *SQLiteStorage) GetQuestionsByTopic(topicID string) ([]models.Question, error) {
+	return s.queryQuestions(`WHERE topic_id = ?`, topicID)
+}
+
+// GetQuestionsByPlan liefert alle Fragen zu Themen eines Plans
+func (s *SQLiteStorage) GetQuestionsByPlan(planID string) ([]models.Question, error) {
+	return s.queryQuestions(`WHERE topic_id IN (SELECT id FROM topics WHERE study_plan_id = ?)`, planID)
+}
+
+func (s *SQLiteStorage) queryQuestions(where string, args ...interface{}) ([]models.Question, error) {
+	rows, err := s.db.Query(`
+		SELECT `+questionColumns+`
+		FROM questions `+where+` ORDER BY difficulty
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var questions []models.Question
+	for rows.Next() {
+		q, err := scanQuestion(rows)
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, *q)
+	}
+	return questions, nil
+}
+
+func (s *SQLiteStorage) SaveQuestionAnswer(id string, answer string, isCorrect bool, feedback string) error {
+	_, err := s.db.Exec(`
+		UPDATE questions SET user_answer = ?, is_correct = ?, feedback = ?, answered_at = ? WHERE id = ?
+	`, answer, isCorrect, feedback, time.Now(), id)
+	return err
+}
+
+// SaveAttempt speichert einen Antwortversuch (die Frage selbst behält nur den letzten)
+func (s *SQLiteStorage) SaveAttempt(a *models.QuestionAttempt) error {
+	_, err := s.db.Exec(`
+		INSERT INTO question_attempts (id, question_id, topic_id, answer, is_correct, score, feedback, hints_used, answer_seconds, answered_late, error_type, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, a.ID, a.QuestionID, a.TopicID, a.Answer, a.IsCorrect, a.Score, a.Feedback, a.HintsUsed, a.AnswerSeconds, a.AnsweredLate, a.ErrorType, a.CreatedAt)
+	return err
+}
+
+// GetAttempts liefert alle Antwortversuche einer Frage, ältester zuerst
+func (s *SQLiteStorage) GetAttempts(questionID string) ([]models.QuestionAttempt, error) {
+	return s.queryAttempts(`WHERE question_id = ?`, questionID)
+}
+
+// GetAllAttempts liefert alle Antwortversuche planübergreifend, ältester zuerst
+func (s *SQLiteStorage) GetAllAttempts() ([]models.QuestionAttempt, error) {
+	return s.queryAttempts(``)
+}
+
+// GetAttemptsSince liefert die Antwortversuche ab since planübergreifend, ältester zuerst.
+// Zeitpunkte vergleicht SQLite als Text mit Zeitzone; bei Tagesgrenzen mit Puffer abfragen.
+func (s *SQLiteStorage) GetAttemptsSince(since time.Time) ([]models.QuestionAttempt, error) {
+	return s.queryAttempts(`WHERE created_at >= ?`, since)
+}
+
+// GetQuestionIDsAnsweredBefore liefert die Fragen, die vor before schon beantwortet wurden
+func (s *SQLiteStorage) GetQuestionIDsAnsweredBefore(before time.Time) ([]string, error) {
+	rows, err := s.db.Query(`SELECT DISTINCT question_id FROM question_attempts WHERE created_at < ?`, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetAttemptsByPlan liefert alle Antwortversuche zu Fragen eines Plans, ältester zuerst
+func (s *SQLiteStorage) GetAttemptsByPlan(planID string) ([]models.QuestionAttempt, error) {
+	return s.queryAttempts(`WHERE question_id IN (
+		SELECT q.id FROM questions q JOIN topics t ON t.id = q.topic_id WHERE t.study_plan_id = ?)`, planID)
+}
+
+// GetAttemptsByPlanSince liefert die Antwortversuche eines Plans ab since, ältester zuerst
+// (Zeitpunkte als Text verglichen, siehe GetAttemptsSince)
+func (s *SQLiteStorage) GetAttemptsByPlanSince(planID string, since time.Time) ([]models.QuestionAttempt, error) {
+	return s.queryAttempts(`WHERE created_at >= ? AND question_id IN (
+		SELECT q.id FROM questions q JOIN topics t ON t.id = q.topic_id WHERE t.study_plan_id = ?)`, since, planID)
+}
+
+func (s *SQLiteStorage) queryAttempts(where string, args ...interface{}) ([]models.QuestionAttempt, error) {
+	rows, err := s.db.Query(`
+		SELECT id, question_id, topic_id, answer, is_correct, score, feedback, hints_used, answer_seconds, answered_late, error_type, created_at
+		FROM question_attempts `+where+` ORDER BY created_at
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []models.QuestionAttempt
+	for rows.Next() {
+		var a models.QuestionAttempt
+		var topicID, answer, feedback, errorType sql.NullString
+		var isCorrect sql.NullBool
+		if err := rows.Scan(&a.ID, &a.QuestionID, &topicID, &answer, &isCorrect, &a.Score, &feedback, &a.HintsUsed, &a.AnswerSeconds, &a.AnsweredLate, &errorType, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		a.TopicID = topicID.String
+		a.Answer = answer.String
+		a.IsCorrect = isCorrect.Bool
+		a.Feedback = feedback.String
+		a.ErrorType = errorType.String
+		attempts = append(attempts, a)
+	}
+	return attempts, nil
+}
+
+// MarkQuestionStarted merkt sich, wann eine Frage angezeigt wurde (Basis für das Zeitlimit)
+func (s *SQLiteStorage) MarkQuestionStarted(id string, startedAt time.Time) error {
+	_, err := s.db.Exec(`UPDATE questions SET started_at = ? WHERE id = ?`, startedAt, id)
+	return err
+}
+
+// SaveAnswerTiming speichert die Antwortdauer und setzt den Startzeitpunkt zurück
+func (s *SQLiteStorage) SaveAnswerTiming(id string, seconds int, late bool) error {
+	_, err := s.db.Exec(`
+		UPDATE questions SET answer_seconds = ?, answered_late = ?, started_at = NULL WHERE id = ?
+	`, seconds, late, id)
+	return err
+}
+
+// GetAnswerSpeedStats liefert die durchschnittliche Antwortgeschwindigkeit pro Thema eines Plans
+func (s *SQLiteStorage) GetAnswerSpeedStats(planID string) ([]models.TopicAnswerSpeed, error) {
+	rows, err := s.db.Query(`
+		SELECT t.id, t.name, COUNT(q.id), COALESCE(AVG(q.answer_seconds), 0), COALESCE(SUM(q.answered_late), 0)
+		FROM topics t
+		LEFT JOIN questions q ON q.topic_id = t.id AND q.answer_seconds IS NOT NULL
+		WHERE t.study_plan_id = ?
+		GROUP BY t.id, t.name
+		ORDER BY t.topic_order
+	`, planID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []models.TopicAnswerSpeed
+	for rows.Next() {
+		var st models.TopicAnswerSpeed
+		if err := rows.Scan(&st.TopicID, &st.TopicName, &st.TimedAnswers, &st.AvgSeconds, &st.LateAnswers); err != nil {
+			return nil, err
+		}
+		stats = append(stats, st)
+	}
+	return stats, nil
+}
+
+// Sitzungen
+
+func (s *SQLiteStorage) SaveSession(session *models.StudySession) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO study_sessions (id, study_plan_id, topic_id, started_at, ended_at, duration_minutes, questions_answered, correct_answers, recap_status, recap_question_ids, auto_closed)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, session.ID, session.StudyPlanID, session.TopicID, session.StartedAt, session.EndedAt, session.Duration, session.QuestionsAnswered, session.CorrectAnswers,
+		session.RecapStatus, marshalRecapIDs(session.RecapQuestionIDs), session.AutoClosed)
+	return err
+}
+
+// SaveSessionRecap speichert nur Status und Fragen des Rückblick-Quiz einer Sitzung
+func (s *SQLiteStorage) SaveSessionRecap(id string, status string, questionIDs []string) error {
+	_, err := s.db.Exec(`UPDATE study_sessions SET recap_status = ?, recap_question_ids = ? WHERE id = ?`,
+		status, marshalRecapIDs(questionIDs), id)
+	return err
+}
+
+func marshalRecapIDs(ids []string) string {
+	if len(ids) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(ids)
+	return string(data)
+}
+
+func (s *SQLiteStorage) GetSessionsByPlan(planID string) ([]models.StudySession, error) {
+	return s.querySessions(`WHERE study_plan_id = ?`, planID)
+}
+
+func (s *SQLiteStorage) GetSession(id string) (*models.StudySession, error) {
+	sessions, err := s.querySessions(`WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &sessions[0], nil
+}
+
+// GetAllSessions liefert alle Lernsitzungen planübergreifend (für Tagesziele)
+func (s *SQLiteStorage) GetAllSessions() ([]models.StudySession, error) {
+	return s.querySessions(``)
+}
+
+// GetSessionsSince liefert die ab since begonnenen Lernsitzungen planübergreifend
+// (Zeitpunkte als Text verglichen, siehe GetAttemptsSince)
+func (s *SQLiteStorage) GetSessionsSince(since time.Time) ([]models.StudySession, error) {
+	return s.querySessions(`WHERE started_at >= ?`, since)
+}
+
+func (s *SQLiteStorage) querySessions(where string, args ...interface{}) ([]models.StudySession, error) {
+	rows, err := s.db.Query(`
+		SELECT id, study_plan_id, topic_id, started_at, ended_at, duration_minutes, questions_answered, correct_answers, recap_status, recap_question_ids, auto_closed
+		FROM study_sessions `+where+` ORDER BY started_at DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []models.StudySession
+	for rows.Next() {
+		var session models.StudySession
+		var endedAt sql.NullTime
+		var duration sql.NullInt64
+		var recapStatus, recapIDs sql.NullString
+		if err := rows.Scan(&session.ID, &session.StudyPlanID, &session.TopicID, &session.StartedAt, &endedAt, &duration, &session.QuestionsAnswered, &session.CorrectAnswers, &recapStatus, &recapIDs, &session.AutoClosed); err != nil {
+			return nil, err
+		}
+		session.RecapStatus = recapStatus.String
+		if recapIDs.String != "" {
+			json.Unmarshal([]byte(recapIDs.String), &session.RecapQuestionIDs)
+		}
+		if endedAt.Valid {
+			session.EndedAt = &endedAt.Time
+		}
+		session.Duration = int(duration.Int64)
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// Chat
+
+func (s *SQLiteStorage) SaveChatMessage(msg *models.ChatMessage) error {
+	_, err := s.db.Exec(`
+		INSERT INTO chat_messages (id, session_id, role, content, timestamp, topic_id, document_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, msg.ID, msg.SessionID, msg.Role, msg.Content, msg.Timestamp, msg.TopicID, msg.DocumentID)
+	return err
+}
+
+// GetChatHistory liefert den aktuellen Verlauf einer Sitzung, ohne ersetzte Antworten
+func (s *SQLiteStorage) GetChatHistory(sessionID string) ([]models.ChatMessage, error) {
+	return s.queryChatMessages(`WHERE session_id = ? AND replaced_by = '' ORDER BY timestamp`, sessionID)
+}
+
+// GetChatMessage liefert eine einzelne Chat-Nachricht (auch eine ersetzte)
+func (s *SQLiteStorage) GetChatMessage(id string) (*models.ChatMessage, error) {
+	messages, err := s.queryChatMessages(`WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &messages[0], nil
+}
+
+// GetChatMessageVersions liefert die früheren Fassungen einer neu erzeugten Antwort, neueste zuerst
+func (s *SQLiteStorage) GetChatMessageVersions(id string) ([]models.ChatMessage, error) {
+	var versions []models.ChatMessage
+	for {
+		previous, err := s.queryChatMessages(`WHERE replaced_by = ?`, id)
+		if err != nil {
+			return nil, err
+		}
+		if len(previous) == 0 {
+			return versions, nil
+		}
+		versions = append(versions, previous[0])
+		id = previous[0].ID
+	}
+}
+
+// ReplaceChatMessage speichert eine neue Fassung einer Antwort; die alte bleibt als Version erhalten
+func (s *SQLiteStorage) ReplaceChatMessage(oldID string, msg *models.ChatMessage) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO chat_messages (id, session_id, role, content, timestamp, topic_id, document_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, msg.ID, msg.SessionID, msg.Role, msg.Content, msg.Timestamp, msg.TopicID, msg.DocumentID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE chat_messages SET replaced_by = ? WHERE id = ?`, msg.ID, oldID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// EditChatMessage ändert den Text einer Nachricht und löscht alle späteren Nachrichten der
+// Sitzung (einschließlich ersetzter Fassungen), weil sie sich auf den alten Text beziehen
+func (s *SQLiteStorage) EditChatMessage(id, content string) error {
+	msg, err := s.GetChatMessage(id)
+	if err != nil {
+		return err
+	}
+	all, err := s.queryChatMessages(`WHERE session_id = ? ORDER BY timestamp`, msg.SessionID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE chat_messages SET content = ? WHERE id = ?`, content, id); err != nil {
+		return err
+	}
+	later := false
+	for _, m := range all {
+		if later {
+			if _, err := tx.Exec(`DELETE FROM chat_messages WHERE id = ?`, m.ID); err != nil {
+				return err
+			}
+		}
+		later = later || m.ID == id
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStorage) queryChatMessages(where string, args ...interface{}) ([]models.ChatMessage, error) {
+	rows, err := s.db.Query(`
+		SELECT id, session_id, role, content, timestamp, topic_id, document_id, replaced_by
+		FROM chat_messages `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []models.ChatMessage
+	for rows.Next() {
+		var msg models.ChatMessage
+		if err := rows.Scan(&msg.ID, &msg.SessionID, &msg.Role, &msg.Content, &msg.Timestamp, &msg.TopicID, &msg.DocumentID, &msg.ReplacedBy); err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// SaveChatPin heftet Kontext an eine Chat-Sitzung an
+func (s *SQLiteStorage) SaveChatPin(pin *models.ChatPin) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO chat_pins (id, session_id, kind, ref_id, page, label, content, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, pin.ID, pin.SessionID, pin.Kind, pin.RefID, pin.Page, pin.Label, pin.Content, pin.CreatedAt)
+	return err
+}
+
+// GetChatPins liefert den angehefteten Kontext einer Chat-Sitzung, ältester zuerst
+func (s *SQLiteStorage) GetChatPins(sessionID string) ([]models.ChatPin, error) {
+	rows, err := s.db.Query(`
+		SELECT id, session_id, kind, ref_id, page, label, content, created_at
+		FROM chat_pins WHERE session_id = ? ORDER BY created_at
+	`, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pins []models.ChatPin
+	for rows.Next() {
+		var pin models.ChatPin
+		if err := rows.Scan(&pin.ID, &pin.SessionID, &pin.Kind, &pin.RefID, &pin.Page, &pin.Label, &pin.Content, &pin.CreatedAt); err != nil {
+			return nil, err
+		}
+		pins = append(pins, pin)
+	}
+	return pins, nil
+}
+
+// DeleteChatPin löst angehefteten Kontext von einer Chat-Sitzung
+func (s *SQLiteStorage) DeleteChatPin(sessionID, id string) error {
+	result, err := s.db.Exec(`DELETE FROM chat_pins WHERE id = ? AND session_id = ?`, id, sessionID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) SaveChatSession(session *models.ChatSession) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO chat_sessions (id, mode, topic_id, created_at)
+		VALUES (?, ?, ?, ?)
+	`, session.ID, session.Mode, session.TopicID, session.CreatedAt)
+	return err
+}
+
+func (s *SQLiteStorage) GetChatSession(id string) (*models.ChatSession, error) {
+	var session models.ChatSession
+	err := s.db.QueryRow(`
+		SELECT id, mode, topic_id, created_at
+		FROM chat_sessions WHERE id = ?
+	`, id).Scan(&session.ID, &session.Mode, &session.TopicID, &session.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Syllabus
+
+// ReplaceSyllabus ersetzt alle Syllabus-Punkte eines Plans
+func (s *SQLiteStorage) ReplaceSyllabus(planID string, items []models.SyllabusItem) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM syllabus_items WHERE study_plan_id = ?`, planID); err != nil {
+		return err
+	}
+	for _, item := range items {
+		topicIDs, _ := json.Marshal(item.TopicIDs)
+		if _, err := tx.Exec(`
+			INSERT INTO syllabus_items (id, study_plan_id, text, topic_ids, item_order, created_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, item.ID, planID, item.Text, string(topicIDs), item.Order, item.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteStorage) GetSyllabus(planID string) ([]models.SyllabusItem, error) {
+	rows, err := s.db.Query(`
+		SELECT id, study_plan_id, text, topic_ids, item_order, created_at
+		FROM syllabus_items WHERE study_plan_id = ? ORDER BY item_order
+	`, planID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.SyllabusItem
+	for rows.Next() {
+		var item models.SyllabusItem
+		var topicIDs string
+		if err := rows.Scan(&item.ID, &item.StudyPlanID, &item.Text, &topicIDs, &item.Order, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(topicIDs), &item.TopicIDs)
+		if item.TopicIDs == nil {
+			item.TopicIDs = []string{}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Markierungen
+
+func (s *SQLiteStorage) SaveFlag(flag *models.Flag) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO flags (id, item_type, item_id, topic_id, reason, created_at, resolved_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, flag.ID, flag.ItemType, flag.ItemID, flag.TopicID, flag.Reason, flag.CreatedAt, flag.ResolvedAt)
+	return err
+}
+
+// GetOpenFlag liefert die offene Markierung eines Elements (sql.ErrNoRows wenn keine)
+func (s *SQLiteStorage) GetOpenFlag(itemType string, itemID string) (*models.Flag, error) {
+	flags, err := s.queryFlags(`WHERE item_type = ? AND item_id = ? AND resolved_at IS NULL`, itemType, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if len(flags) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &flags[0], nil
+}
+
+// GetFlags liefert Markierungen, optional gefiltert nach Typ und Thema
+func (s *SQLiteStorage) GetFlags(itemType string, topicID string, includeResolved bool) ([]models.Flag, error) {
+	where := `WHERE 1=1`
+	var args []interface{}
+	if itemType != "" {
+		where += ` AND item_type = ?`
+		args = append(args, itemType)
+	}
+	if topicID != "" {
+		where += ` AND topic_id = ?`
+		args = append(args, topicID)
+	}
+	if !includeResolved {
+		where += ` AND resolved_at IS NULL`
+	}
+	return s.queryFlags(where, args...)
+}
+
+func (s *SQLiteStorage) queryFlags(where string, args ...interface{}) ([]models.Flag, error) {
+	rows, err := s.db.Query(`
+		SELECT id, item_type, item_id, topic_id, reason, created_at, resolved_at
+		FROM flags `+where+` ORDER BY created_at
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var flags []models.Flag
+	for rows.Next() {
+		var flag models.Flag
+		var reason sql.NullString
+		var resolvedAt sql.NullTime
+		if err := rows.Scan(&flag.ID, &flag.ItemType, &flag.ItemID, &flag.TopicID, &reason, &flag.CreatedAt, &resolvedAt); err != nil {
+			return nil, err
+		}
+		flag.Reason = reason.String
+		if resolvedAt.Valid {
+			flag.ResolvedAt = &resolvedAt.Time
+		}
+		flags = append(flags, flag)
+	}
+	return flags, nil
+}
+
+// ResolveFlags markiert mehrere Markierungen als erledigt und liefert die Anzahl
+func (s *SQLiteStorage) ResolveFlags(ids []string) (int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var total int64
+	now := time.Now()
+	for _, id := range ids {
+		result, err := tx.Exec(`UPDATE flags SET resolved_at = ? WHERE id = ? AND resolved_at IS NULL`, now, id)
+		if err != nil {
+			return 0, err
+		}
+		n, _ := result.RowsAffected()
+		total += n
+	}
+
+	return total, tx.Commit()
+}
+
+func (s *SQLiteStorage) DeleteFlag(id string) error {
+	_, err := s.db.Exec(`DELETE FROM flags WHERE id = ?`, id)
+	return err
+}
+
+// Notizen
+
+func (s *SQLiteStorage) SaveNote(note *models.Note) error {
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO notes (id, topic_id, question_id, content, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, note.ID, note.TopicID, note.QuestionID, note.Content, note.CreatedAt, note.UpdatedAt)
+	return err
+}
+
+func (s *SQLiteStorage) GetNote(id string) (*models.Note, error) {
+	var note models.Note
+	err := s.db.QueryRow(`
+		SELECT id, topic_id, question_id, content, created_at, updated_at
+		FROM notes WHERE id = ?
+	`, id).Scan(&note.ID, &note.TopicID, &note.QuestionID, &note.Content, &note.CreatedAt, &note.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// GetNotesByTopic liefert alle Notizen eines Themas, einschließlich der Notizen zu seinen Fragen
+func (s *SQLiteStorage) GetNotesByTopic(topicID string) ([]models.Note, error) {
+	return s.queryNotes(`WHERE topic_id = ?`, topicID)
+}
+
+func (s *SQLiteStorage) GetNotesByQuestion(questionID string) ([]models.Note, error) {
+	return s.queryNotes(`WHERE question_id = ?`, questionID)
+}
+
+func (s *SQLiteStorage) queryNotes(where string, args ...interface{}) ([]models.Note, error) {
+	rows, err := s.db.Query(`
+		SELECT id, topic_id, question_id, content, created_at, updated_at
+		FROM notes `+where+` ORDER BY created_at
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []models.Note
+	for rows.Next() {
+		var note models.Note
+		if err := rows.Scan(&note.ID, &note.TopicID, &note.QuestionID, &note.Content, &note.CreatedAt, &note.UpdatedAt); err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+func (s *SQLiteStorage) DeleteNote(id string) error {
+	_, err := s.db.Exec(`DELETE FROM notes WHERE id = ?`, id)
+	return err
+}
+
+// Glossar
+
+func (s *SQLiteStorage) SaveGlossaryItem(item *models.GlossaryItem) error {
+	relatedJSON, _ := json.Marshal(item.Related)
+
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO glossary (id, term, category, definition, details, related, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, item.ID, item.Term, item.Category, item.Definition, item.Details, string(relatedJSON), item.CreatedAt, item.UpdatedAt)
+	return err
+}
+
+func (s *SQLiteStorage) GetGlossaryItem(id string) (*models.GlossaryItem, error) {
+	var item models.GlossaryItem
+	var relatedJSON string
+
+	err := s.db.QueryRow(`
+		SELECT id, term, category, definition, details, related, created_at, updated_at
+		FROM glossary WHERE id = ?
+	`, id).Scan(&item.ID, &item.Term, &item.Category, &item.Definition, &item.Details, &relatedJSON, &item.CreatedAt, &item.UpdatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if relatedJSON != "" {
+		json.Unmarshal([]byte(relatedJSON), &item.Related)
+	}
+
+	return &item, nil
+}
+
+func (s *SQLiteStorage) GetAllGlossaryItems() ([]models.GlossaryItem, error) {
+	rows, err := s.db.Query(`
+		SELECT id, term, category, definition, details, related, created_at, updated_at
+		FROM glossary ORDER BY term
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.GlossaryItem
+	for rows.Next() {
+		var item models.GlossaryItem
+		var relatedJSON string
+
+		if err := rows.Scan(&item.ID, &item.Term, &item.Category, &item.Definition, &item.Details, &relatedJSON, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+
+		if relatedJSON != "" {
+			json.Unmarshal([]byte(relatedJSON), &item.Related)
+		}
+
+		items = append(items, item)
+	}
+	return items, nil
+}
 
-// Fragen
+func (s *SQLiteStorage) DeleteGlossaryItem(id string) error {
+	_, err := s.db.Exec(`DELETE FROM glossary WHERE id = ?`, id)
+	return err
+}
 
-func (s *SQLiteStorage) SaveQuestion(q *models.Question) error {
-	hints, _ := json.Marshal(q.Hints)
-	options, _ := json.Marshal(q.Options)
+// Eselsbrücken
+
+func (s *SQLiteStorage) SaveMnemonic(m *models.Mnemonic) error {
+	glossaryIDs, _ := json.Marshal(m.GlossaryIDs)
+	questionIDs, _ := json.Marshal(m.QuestionIDs)
 	_, err := s.db.Exec(`
-		INSERT OR REPLACE INTO questions (id, topic_id, question, expected_answer, hints, difficulty, type, options, user_answer, is_correct, feedback, answered_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, q.ID, q.TopicID, q.Question, q.ExpectedAnswer, string(hints), q.Difficulty, q.Type, string(options), q.UserAnswer, q.IsCorrect, q.Feedback, q.AnsweredAt)
+		INSERT OR REPLACE INTO mnemonics (id, topic_id, term, kind, content, glossary_ids, question_ids, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, m.ID, m.TopicID, m.Term, m.Kind, m.Content, string(glossaryIDs), string(questionIDs), m.CreatedAt)
 	return err
 }
 
-func (s *SQLiteStorage) GetQuestion(id string) (*models.Question, error) {
-	var q models.Question
-	var hints, options string
-	var isCorrect sql.NullInt64
-	var answeredAt sql.NullTime
-	err := s.db.QueryRow(`
-		SELECT id, topic_id, question, expected_answer, hints, difficulty, type, options, user_answer, is_correct, feedback, answered_at
-		FROM questions WHERE id = ?
-	`, id).Scan(&q.ID, &q.TopicID, &q.Question, &q.ExpectedAnswer, &hints, &q.Difficulty, &q.Type, &options, &q.UserAnswer, &isCorrect, &q.Feedback, &answeredAt)
+// GetMnemonicsByTopic liefert die Merkhilfen eines Themas, nach Begriff sortiert
+func (s *SQLiteStorage) GetMnemonicsByTopic(topicID string) ([]models.Mnemonic, error) {
+	return s.queryMnemonics(`WHERE topic_id = ?`, topicID)
+}
+
+// GetMnemonicsByQuestion liefert die Merkhilfen, die mit einer Frage verknüpft sind – unabhängig davon,
+// in welchem Thema die Frage inzwischen steht (z.B. nach dem Verschieben von Fragen)
+func (s *SQLiteStorage) GetMnemonicsByQuestion(questionID string) ([]models.Mnemonic, error) {
+	// Vorauswahl über den Text der JSON-Liste, genau geprüft wird danach
+	candidates, err := s.queryMnemonics(`WHERE question_ids LIKE ?`, `%"`+questionID+`"%`)
 	if err != nil {
 		return nil, err
 	}
-	json.Unmarshal([]byte(hints), &q.Hints)
-	json.Unmarshal([]byte(options), &q.Options)
-	if isCorrect.Valid {
-		val := isCorrect.Int64 == 1
-		q.IsCorrect = &val
+	var mnemonics []models.Mnemonic
+	for _, m := range candidates {
+		for _, id := range m.QuestionIDs {
+			if id == questionID {
+				mnemonics = append(mnemonics, m)
+				break
+			}
+		}
 	}
-	if answeredAt.Valid {
-		q.AnsweredAt = &answeredAt.Time
+	return mnemonics, nil
+}
+
+func (s *SQLiteStorage) queryMnemonics(where string, args ...interface{}) ([]models.Mnemonic, error) {
+	rows, err := s.db.Query(`
+		SELECT id, topic_id, term, kind, content, glossary_ids, question_ids, created_at
+		FROM mnemonics `+where+` ORDER BY term, created_at
+	`, args...)
+	if err != nil {
+		return nil, err
 	}
-	return &q, nil
+	defer rows.Close()
+
+	var mnemonics []models.Mnemonic
+	for rows.Next() {
+		var m models.Mnemonic
+		var glossaryIDs, questionIDs string
+		if err := rows.Scan(&m.ID, &m.TopicID, &m.Term, &m.Kind, &m.Content, &glossaryIDs, &questionIDs, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(glossaryIDs), &m.GlossaryIDs)
+		json.Unmarshal([]byte(questionIDs), &m.QuestionIDs)
+		mnemonics = append(mnemonics, m)
+	}
+	return mnemonics, nil
 }
 
-func (s *SQLiteStorage) GetQuestionsByTopic(topicID string) ([]models.Question, error) {
+// Rechenbeispiele
+
+func (s *SQLiteStorage) SaveWorkedExample(ex *models.WorkedExample) error {
+	steps, _ := json.Marshal(ex.Steps)
+	pitfalls, _ := json.Marshal(ex.Pitfalls)
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO worked_examples (id, topic_id, title, problem, steps, answer, pitfalls, difficulty, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, ex.ID, ex.TopicID, ex.Title, ex.Problem, string(steps), ex.Answer, string(pitfalls), ex.Difficulty, ex.CreatedAt)
+	return err
+}
+
+func (s *SQLiteStorage) GetWorkedExamplesByTopic(topicID string) ([]models.WorkedExample, error) {
 	rows, err := s.db.Query(`
-		SELECT id, topic_id, question, expected_answer, hints, difficulty, type, options, user_answer, is_correct, feedback, answered_at
-		FROM questions WHERE topic_id = ? ORDER BY difficulty
+		SELECT id, topic_id, title, problem, steps, answer, pitfalls, difficulty, created_at
+		FROM worked_examples WHERE topic_id = ? ORDER BY difficulty, created_at
 	`, topicID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var questions []models.Question
+	var examples []models.WorkedExample
 	for rows.Next() {
-		var q models.Question
-		var hints, options string
-		var isCorrect sql.NullInt64
-		var answeredAt sql.NullTime
-		if err := rows.Scan(&q.ID, &q.TopicID, &q.Question, &q.ExpectedAnswer, &hints, &q.Difficulty, &q.Type, &options, &q.UserAnswer, &isCorrect, &q.Feedback, &answeredAt); err != nil {
+		var ex models.WorkedExample
+		var steps, pitfalls string
+		if err := rows.Scan(&ex.ID, &ex.TopicID, &ex.Title, &ex.Problem, &steps, &ex.Answer, &pitfalls, &ex.Difficulty, &ex.CreatedAt); err != nil {
 			return nil, err
 		}
-		json.Unmarshal([]byte(hints), &q.Hints)
-		json.Unmarshal([]byte(options), &q.Options)
-		if isCorrect.Valid {
-			val := isCorrect.Int64 == 1
-			q.IsCorrect = &val
-		}
-		if answeredAt.Valid {
-			q.AnsweredAt = &answeredAt.Time
+		json.Unmarshal([]byte(steps), &ex.Steps)
+		json.Unmarshal([]byte(pitfalls), &ex.Pitfalls)
+		examples = append(examples, ex)
+	}
+	return examples, nil
+}
+
+// Eigene Erklärungen
+
+func (s *SQLiteStorage) SaveTeachBack(tb *models.TeachBack) error {
+	missing, _ := json.Marshal(tb.MissingPoints)
+	misconceptions, _ := json.Marshal(tb.Misconceptions)
+	strengths, _ := json.Marshal(tb.Strengths)
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO teach_backs (id, topic_id, explanation, completeness, correctness, missing_points, misconceptions, strengths, feedback, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, tb.ID, tb.TopicID, tb.Explanation, tb.Completeness, tb.Correctness, string(missing), string(misconceptions), string(strengths), tb.Feedback, tb.CreatedAt)
+	return err
+}
+
+// GetTeachBacksByTopic liefert die eigenen Erklärungen eines Themas, neueste zuerst
+func (s *SQLiteStorage) GetTeachBacksByTopic(topicID string) ([]models.TeachBack, error) {
+	rows, err := s.db.Query(`
+		SELECT id, topic_id, explanation, completeness, correctness, missing_points, misconceptions, strengths, feedback, created_at
+		FROM teach_backs WHERE topic_id = ? ORDER BY created_at DESC
+	`, topicID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var teachBacks []models.TeachBack
+	for rows.Next() {
+		var tb models.TeachBack
+		var missing, misconceptions, strengths string
+		if err := rows.Scan(&tb.ID, &tb.TopicID, &tb.Explanation, &tb.Completeness, &tb.Correctness, &missing, &misconceptions, &strengths, &tb.Feedback, &tb.CreatedAt); err != nil {
+			return nil, err
 		}
-		questions = append(questions, q)
+		json.Unmarshal([]byte(missing), &tb.MissingPoints)
+		json.Unmarshal([]byte(misconceptions), &tb.Misconceptions)
+		json.Unmarshal([]byte(strengths), &tb.Strengths)
+		teachBacks = append(teachBacks, tb)
 	}
-	return questions, nil
+	return teachBacks, nil
 }
 
-func (s *SQLiteStorage) SaveQuestionAnswer(id string, answer string, isCorrect bool, feedback string) error {
+// Vergleichstabellen
+
+func (s *SQLiteStorage) SaveComparison(c *models.Comparison) error {
+	subjects, _ := json.Marshal(c.Subjects)
+	topicIDs, _ := json.Marshal(c.TopicIDs)
+	glossaryIDs, _ := json.Marshal(c.GlossaryIDs)
+	rows, _ := json.Marshal(c.Rows)
+	similarities, _ := json.Marshal(c.Similarities)
+	differences, _ := json.Marshal(c.Differences)
 	_, err := s.db.Exec(`
-		UPDATE questions SET user_answer = ?, is_correct = ?, feedback = ?, answered_at = ? WHERE id = ?
-	`, answer, isCorrect, feedback, time.Now(), id)
+		INSERT OR REPLACE INTO comparisons (id, comparison_key, subjects, topic_ids, glossary_ids, rows_json, similarities, differences, summary, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, c.ID, c.Key, string(subjects), string(topicIDs), string(glossaryIDs), string(rows), string(similarities), string(differences), c.Summary, c.CreatedAt)
 	return err
 }
 
-// Sitzungen
+const comparisonColumns = `id, comparison_key, subjects, topic_ids, glossary_ids, rows_json, similarities, differences, summary, created_at`
 
-func (s *SQLiteStorage) SaveSession(session *models.StudySession) error {
+func scanComparison(row rowScanner) (*models.Comparison, error) {
+	var c models.Comparison
+	var subjects, topicIDs, glossaryIDs, rows, similarities, differences string
+	if err := row.Scan(&c.ID, &c.Key, &subjects, &topicIDs, &glossaryIDs, &rows, &similarities, &differences, &c.Summary, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(subjects), &c.Subjects)
+	json.Unmarshal([]byte(topicIDs), &c.TopicIDs)
+	json.Unmarshal([]byte(glossaryIDs), &c.GlossaryIDs)
+	json.Unmarshal([]byte(rows), &c.Rows)
+	json.Unmarshal([]byte(similarities), &c.Similarities)
+	json.Unmarshal([]byte(differences), &c.Differences)
+	return &c, nil
+}
+
+func (s *SQLiteStorage) GetComparison(id string) (*models.Comparison, error) {
+	return scanComparison(s.db.QueryRow(`SELECT `+comparisonColumns+` FROM comparisons WHERE id = ?`, id))
+}
+
+// GetComparisonByKey liefert den neuesten Vergleich derselben Themen/Begriffe
+func (s *SQLiteStorage) GetComparisonByKey(key string) (*models.Comparison, error) {
+	return scanComparison(s.db.QueryRow(`
+		SELECT `+comparisonColumns+`
+		FROM comparisons WHERE comparison_key = ? ORDER BY created_at DESC LIMIT 1
+	`, key))
+}
+
+func (s *SQLiteStorage) GetAllComparisons() ([]models.Comparison, error) {
+	rows, err := s.db.Query(`SELECT ` + comparisonColumns + ` FROM comparisons ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comparisons []models.Comparison
+	for rows.Next() {
+		c, err := scanComparison(rows)
+		if err != nil {
+			return nil, err
+		}
+		comparisons = append(comparisons, *c)
+	}
+	return comparisons, nil
+}
+
+// RecordInterleavedPair zählt, dass zwei Themen gemeinsam in einem gemischten Quiz vorkamen
+func (s *SQLiteStorage) RecordInterleavedPair(planID, topicA, topicB string, at time.Time) error {
+	if topicB < topicA {
+		topicA, topicB = topicB, topicA
+	}
 	_, err := s.db.Exec(`
-		INSERT OR REPLACE INTO study_sessions (id, study_plan_id, topic_id, started_at, ended_at, duration_minutes, questions_answered, correct_answers)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`, session.ID, session.StudyPlanID, session.TopicID, session.StartedAt, session.EndedAt, session.Duration, session.QuestionsAnswered, session.CorrectAnswers)
+		INSERT OR REPLACE INTO interleave_pairs (study_plan_id, topic_a, topic_b, count, last_at)
+		VALUES (?, ?, ?, COALESCE((SELECT count FROM interleave_pairs WHERE topic_a = ? AND topic_b = ?), 0) + 1, ?)
+	`, planID, topicA, topicB, topicA, topicB, at)
 	return err
 }
 
-func (s *SQLiteStorage) GetSessionsByPlan(planID string) ([]models.StudySession, error) {
+// GetInterleavedPairs liefert die gemischt abgefragten Themenpaare eines Plans, neueste zuerst
+func (s *SQLiteStorage) GetInterleavedPairs(planID string) ([]models.InterleavePair, error) {
 	rows, err := s.db.Query(`
-		SELECT id, study_plan_id, topic_id, started_at, ended_at, duration_minutes, questions_answered, correct_answers
-		FROM study_sessions WHERE study_plan_id = ? ORDER BY started_at DESC
+		SELECT study_plan_id, topic_a, topic_b, count, last_at
+		FROM interleave_pairs WHERE study_plan_id = ? ORDER BY last_at DESC
 	`, planID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var sessions []models.StudySession
+	var pairs []models.InterleavePair
 	for rows.Next() {
-		var session models.StudySession
-		var endedAt sql.NullTime
-		if err := rows.Scan(&session.ID, &session.StudyPlanID, &session.TopicID, &session.StartedAt, &endedAt, &session.Duration, &session.QuestionsAnswered, &session.CorrectAnswers); err != nil {
+		var p models.InterleavePair
+		if err := rows.Scan(&p.StudyPlanID, &p.TopicA, &p.TopicB, &p.Count, &p.LastAt); err != nil {
 			return nil, err
 		}
-		if endedAt.Valid {
-			session.EndedAt = &endedAt.Time
+		pairs = append(pairs, p)
+	}
+	return pairs, nil
+}
+
+// Lernplan-Erstellung
+
+func (s *SQLiteStorage) SavePlanJob(job *models.PlanJob) error {
+	documentIDs, _ := json.Marshal(job.DocumentIDs)
+	documentTopics, _ := json.Marshal(job.DocumentTopics)
+	topics, _ := json.Marshal(job.Topics)
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO plan_jobs (id, exam_date, document_ids, status, document_topics, topics, analyzed, plan_id, error, attempts, estimated_seconds, model, profile_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, job.ID, job.ExamDate, string(documentIDs), job.Status, string(documentTopics), string(topics), job.Analyzed, job.PlanID, job.Error, job.Attempts, job.EstimatedSeconds, job.Model, job.ProfileID, job.CreatedAt, job.UpdatedAt)
+	return err
+}
+
+const planJobColumns = `id, exam_date, document_ids, status, document_topics, topics, analyzed, plan_id, error, attempts, estimated_seconds, model, profile_id, created_at, updated_at`
+
+func scanPlanJob(row rowScanner) (*models.PlanJob, error) {
+	var job models.PlanJob
+	var documentIDs, documentTopics, topics string
+	if err := row.Scan(&job.ID, &job.ExamDate, &documentIDs, &job.Status, &documentTopics, &topics, &job.Analyzed, &job.PlanID, &job.Error, &job.Attempts, &job.EstimatedSeconds, &job.Model, &job.ProfileID, &job.CreatedAt, &job.UpdatedAt); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(documentIDs), &job.DocumentIDs)
+	json.Unmarshal([]byte(documentTopics), &job.DocumentTopics)
+	json.Unmarshal([]byte(topics), &job.Topics)
+	job.DocumentsDone = len(job.DocumentTopics)
+	return &job, nil
+}
+
+func (s *SQLiteStorage) GetPlanJob(id string) (*models.PlanJob, error) {
+	return scanPlanJob(s.db.QueryRow(`SELECT `+planJobColumns+` FROM plan_jobs WHERE id = ?`, id))
+}
+
+// GetPlanJobs liefert alle Lernplan-Erstellungen, neueste zuerst
+func (s *SQLiteStorage) GetPlanJobs() ([]models.PlanJob, error) {
+	rows, err := s.db.Query(`SELECT ` + planJobColumns + ` FROM plan_jobs ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []models.PlanJob
+	for rows.Next() {
+		job, err := scanPlanJob(rows)
+		if err != nil {
+			return nil, err
 		}
-		sessions = append(sessions, session)
+		jobs = append(jobs, *job)
 	}
-	return sessions, nil
+	return jobs, nil
 }
 
-// Chat
+// Eigene Quizze
 
-func (s *SQLiteStorage) SaveChatMessage(msg *models.ChatMessage) error {
+func (s *SQLiteStorage) SaveQuiz(quiz *models.Quiz) error {
+	questionIDs, _ := json.Marshal(quiz.QuestionIDs)
+	topicCounts, _ := json.Marshal(quiz.TopicCounts)
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO quizzes (id, study_plan_id, name, question_ids, topic_counts, time_limit_minutes, shuffle, cognitive_level, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, quiz.ID, quiz.StudyPlanID, quiz.Name, string(questionIDs), string(topicCounts), quiz.TimeLimitMinutes, quiz.Shuffle, quiz.CognitiveLevel, quiz.CreatedAt)
+	return err
+}
+
+const quizColumns = `id, study_plan_id, name, question_ids, topic_counts, time_limit_minutes, shuffle, cognitive_level, created_at`
+
+func scanQuiz(row rowScanner) (*models.Quiz, error) {
+	var quiz models.Quiz
+	var questionIDs, topicCounts sql.NullString
+	if err := row.Scan(&quiz.ID, &quiz.StudyPlanID, &quiz.Name, &questionIDs, &topicCounts, &quiz.TimeLimitMinutes, &quiz.Shuffle, &quiz.CognitiveLevel, &quiz.CreatedAt); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(questionIDs.String), &quiz.QuestionIDs)
+	json.Unmarshal([]byte(topicCounts.String), &quiz.TopicCounts)
+	return &quiz, nil
+}
+
+func (s *SQLiteStorage) GetQuiz(id string) (*models.Quiz, error) {
+	return scanQuiz(s.db.QueryRow(`SELECT `+quizColumns+` FROM quizzes WHERE id = ?`, id))
+}
+
+// GetQuizzes liefert die Quizze eines Lernplans (leer = alle), neueste zuerst
+func (s *SQLiteStorage) GetQuizzes(planID string) ([]models.Quiz, error) {
+	rows, err := s.db.Query(`SELECT `+quizColumns+` FROM quizzes WHERE ? = '' OR study_plan_id = ? ORDER BY created_at DESC`, planID, planID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var quizzes []models.Quiz
+	for rows.Next() {
+		quiz, err := scanQuiz(rows)
+		if err != nil {
+			return nil, err
+		}
+		quizzes = append(quizzes, *quiz)
+	}
+	return quizzes, rows.Err()
+}
+
+// DeleteQuiz löscht ein Quiz samt seiner Ergebnisse
+func (s *SQLiteStorage) DeleteQuiz(id string) error {
+	if _, err := s.db.Exec(`DELETE FROM quiz_results WHERE quiz_id = ?`, id); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`DELETE FROM quizzes WHERE id = ?`, id)
+	return err
+}
+
+func (s *SQLiteStorage) SaveQuizResult(result *models.QuizResult) error {
+	questionIDs, _ := json.Marshal(result.QuestionIDs)
+	_, err := s.db.Exec(`
+		INSERT OR REPLACE INTO quiz_results (id, quiz_id, question_ids, started_at, finished_at, answered, correct, score, overtime)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, result.ID, result.QuizID, string(questionIDs), result.StartedAt, result.FinishedAt, result.Answered, result.Correct, result.Score, result.Overtime)
+	return err
+}
+
+const quizResultColumns = `id, quiz_id, question_ids, started_at, finished_at, answered, correct, score, overtime`
+
+func scanQuizResult(row rowScanner) (*models.QuizResult, error) {
+	var result models.QuizResult
+	var questionIDs sql.NullString
+	var finishedAt sql.NullTime
+	if err := row.Scan(&result.ID, &result.QuizID, &questionIDs, &result.StartedAt, &finishedAt, &result.Answered, &result.Correct, &result.Score, &result.Overtime); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(questionIDs.String), &result.QuestionIDs)
+	if finishedAt.Valid {
+		result.FinishedAt = &finishedAt.Time
+	}
+	return &result, nil
+}
+
+func (s *SQLiteStorage) GetQuizResult(id string) (*models.QuizResult, error) {
+	return scanQuizResult(s.db.QueryRow(`SELECT `+quizResultColumns+` FROM quiz_results WHERE id = ?`, id))
+}
+
+// GetQuizResults liefert die Durchläufe eines Quiz, neueste zuerst
+func (s *SQLiteStorage) GetQuizResults(quizID string) ([]models.QuizResult, error) {
+	rows, err := s.db.Query(`SELECT `+quizResultColumns+` FROM quiz_results WHERE quiz_id = ? ORDER BY started_at DESC`, quizID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []models.QuizResult
+	for rows.Next() {
+		result, err := scanQuizResult(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *result)
+	}
+	return results, rows.Err()
+}
+
+// Gerätetokens
+
+func (s *SQLiteStorage) SaveDeviceToken(device *models.DeviceToken) error {
+	scopes, _ := json.Marshal(device.Scopes)
 	_, err := s.db.Exec(`
-		INSERT INTO chat_messages (id, session_id, role, content, timestamp, topic_id)
+		INSERT OR REPLACE INTO device_tokens (id, name, token_hash, scopes, created_at, last_used_at)
 		VALUES (?, ?, ?, ?, ?, ?)
-	`, msg.ID, msg.SessionID, msg.Role, msg.Content, msg.Timestamp, msg.TopicID)
+	`, device.ID, device.Name, device.TokenHash, string(scopes), device.CreatedAt, device.LastUsedAt)
 	return err
 }
 
-func (s *SQLiteStorage) GetChatHistory(sessionID string) ([]models.ChatMessage, error) {
-	rows, err := s.db.Query(`
-		SELECT id, session_id, role, content, timestamp, topic_id
-		FROM chat_messages WHERE session_id = ? ORDER BY timestamp
-	`, sessionID)
+const deviceTokenColumns = `id, name, token_hash, scopes, created_at, last_used_at`
+
+func scanDeviceToken(row rowScanner) (*models.DeviceToken, error) {
+	var device models.DeviceToken
+	var scopes sql.NullString
+	var lastUsed sql.NullTime
+	if err := row.Scan(&device.ID, &device.Name, &device.TokenHash, &scopes, &device.CreatedAt, &lastUsed); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(scopes.String), &device.Scopes)
+	if lastUsed.Valid {
+		device.LastUsedAt = &lastUsed.Time
+	}
+	return &device, nil
+}
+
+// GetDeviceTokens liefert alle gekoppelten Geräte, neueste zuerst
+func (s *SQLiteStorage) GetDeviceTokens() ([]models.DeviceToken, error) {
+	rows, err := s.db.Query(`SELECT ` + deviceTokenColumns + ` FROM device_tokens ORDER BY created_at DESC`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var messages []models.ChatMessage
+	var devices []models.DeviceToken
 	for rows.Next() {
-		var msg models.ChatMessage
-		if err := rows.Scan(&msg.ID, &msg.SessionID, &msg.Role, &msg.Content, &msg.Timestamp, &msg.TopicID); err != nil {
+		device, err := scanDeviceToken(rows)
+		if err != nil {
 			return nil, err
 		}
-		messages = append(messages, msg)
+		devices = append(devices, *device)
 	}
-	return messages, nil
+	return devices, rows.Err()
 }
 
-// Glossar
+func (s *SQLiteStorage) GetDeviceTokenByHash(hash string) (*models.DeviceToken, error) {
+	return scanDeviceToken(s.db.QueryRow(`SELECT `+deviceTokenColumns+` FROM device_tokens WHERE token_hash = ?`, hash))
+}
 
-func (s *SQLiteStorage) SaveGlossaryItem(item *models.GlossaryItem) error {
-	relatedJSON, _ := json.Marshal(item.Related)
-	
+func (s *SQLiteStorage) TouchDeviceToken(id string, at time.Time) error {
+	_, err := s.db.Exec(`UPDATE device_tokens SET last_used_at = ? WHERE id = ?`, at, id)
+	return err
+}
+
+func (s *SQLiteStorage) DeleteDeviceToken(id string) error {
+	_, err := s.db.Exec(`DELETE FROM device_tokens WHERE id = ?`, id)
+	return err
+}
+
+// Fachprofile
+
+func (s *SQLiteStorage) SaveSubjectProfile(profile *models.SubjectProfile) error {
+	questionTypes, _ := json.Marshal(profile.QuestionTypes)
 	_, err := s.db.Exec(`
-		INSERT OR REPLACE INTO glossary (id, term, category, definition, details, related, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`, item.ID, item.Term, item.Category, item.Definition, item.Details, string(relatedJSON), item.CreatedAt, item.UpdatedAt)
+		INSERT OR REPLACE INTO subject_profiles (id, name, builtin, explanation_prompt, question_prompt, question_types, strictness, formulas, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, profile.ID, profile.Name, profile.Builtin, profile.ExplanationPrompt, profile.QuestionPrompt, string(questionTypes), profile.Strictness, profile.Formulas, profile.CreatedAt, profile.UpdatedAt)
 	return err
 }
 
-func (s *SQLiteStorage) GetGlossaryItem(id string) (*models.GlossaryItem, error) {
-	var item models.GlossaryItem
-	var relatedJSON string
-	
-	err := s.db.QueryRow(`
-		SELECT id, term, category, definition, details, related, created_at, updated_at
-		FROM glossary WHERE id = ?
-	`, id).Scan(&item.ID, &item.Term, &item.Category, &item.Definition, &item.Details, &relatedJSON, &item.CreatedAt, &item.UpdatedAt)
-	
+const subjectProfileColumns = `id, name, builtin, explanation_prompt, question_prompt, question_types, strictness, formulas, created_at, updated_at`
+
+func scanSubjectProfile(row rowScanner) (*models.SubjectProfile, error) {
+	var profile models.SubjectProfile
+	var questionTypes sql.NullString
+	if err := row.Scan(&profile.ID, &profile.Name, &profile.Builtin, &profile.ExplanationPrompt, &profile.QuestionPrompt, &questionTypes, &profile.Strictness, &profile.Formulas, &profile.CreatedAt, &profile.UpdatedAt); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(questionTypes.String), &profile.QuestionTypes)
+	return &profile, nil
+}
+
+func (s *SQLiteStorage) GetSubjectProfile(id string) (*models.SubjectProfile, error) {
+	return scanSubjectProfile(s.db.QueryRow(`SELECT `+subjectProfileColumns+` FROM subject_profiles WHERE id = ?`, id))
+}
+
+// GetSubjectProfiles liefert alle Fachprofile, mitgelieferte zuerst
+func (s *SQLiteStorage) GetSubjectProfiles() ([]models.SubjectProfile, error) {
+	rows, err := s.db.Query(`SELECT ` + subjectProfileColumns + ` FROM subject_profiles ORDER BY builtin DESC, name`)
 	if err != nil {
 		return nil, err
 	}
-	
-	if relatedJSON != "" {
-		json.Unmarshal([]byte(relatedJSON), &item.Related)
+	defer rows.Close()
+
+	var profiles []models.SubjectProfile
+	for rows.Next() {
+		profile, err := scanSubjectProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, *profile)
 	}
-	
-	return &item, nil
+	return profiles, rows.Err()
 }
 
-func (s *SQLiteStorage) GetAllGlossaryItems() ([]models.GlossaryItem, error) {
+// DeleteSubjectProfile löscht ein Fachprofil; Lernpläne damit laufen ohne Profil weiter
+func (s *SQLiteStorage) DeleteSubjectProfile(id string) error {
+	if _, err := s.db.Exec(`UPDATE study_plans SET profile_id = '' WHERE profile_id = ?`, id); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`DELETE FROM subject_profiles WHERE id = ?`, id)
+	return err
+}
+
+// Dauer von LLM-Aufrufen
+
+// So viele Messungen je Schritt und Modell bleiben gespeichert (die Schätzung nutzt nur die neuesten 100)
+const maxDurationSamples = 100
+
+// SaveDurationSample speichert eine Messung und löscht ältere Messungen desselben Schritts und Modells
+func (s *SQLiteStorage) SaveDurationSample(sample models.DurationSample) error {
+	if _, err := s.db.Exec(`
+		INSERT INTO duration_samples (step, model, size, seconds, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, sample.Step, sample.Model, sample.Size, sample.Seconds, sample.CreatedAt); err != nil {
+		return err
+	}
+	_, err := s.db.Exec(`
+		DELETE FROM duration_samples WHERE step = ? AND model = ? AND id NOT IN (
+			SELECT id FROM duration_samples WHERE step = ? AND model = ? ORDER BY id DESC LIMIT ?)
+	`, sample.Step, sample.Model, sample.Step, sample.Model, maxDurationSamples)
+	return err
+}
+
+// GetDurationSamples liefert die letzten limit Messungen, älteste zuerst
+func (s *SQLiteStorage) GetDurationSamples(limit int) ([]models.DurationSample, error) {
 	rows, err := s.db.Query(`
-		SELECT id, term, category, definition, details, related, created_at, updated_at
-		FROM glossary ORDER BY term
-	`)
+		SELECT step, model, size, seconds, created_at FROM (
+			SELECT * FROM duration_samples ORDER BY id DESC LIMIT ?
+		) ORDER BY id
+	`, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var items []models.GlossaryItem
+	var samples []models.DurationSample
 	for rows.Next() {
-		var item models.GlossaryItem
-		var relatedJSON string
-		
-		if err := rows.Scan(&item.ID, &item.Term, &item.Category, &item.Definition, &item.Details, &relatedJSON, &item.CreatedAt, &item.UpdatedAt); err != nil {
+		var sample models.DurationSample
+		if err := rows.Scan(&sample.Step, &sample.Model, &sample.Size, &sample.Seconds, &sample.CreatedAt); err != nil {
 			return nil, err
 		}
-		
-		if relatedJSON != "" {
-			json.Unmarshal([]byte(relatedJSON), &item.Related)
-		}
-		
-		items = append(items, item)
+		samples = append(samples, sample)
 	}
-	return items, nil
+	return samples, rows.Err()
 }
 
-func (s *SQLiteStorage) DeleteGlossaryItem(id string) error {
-	_, err := s.db.Exec(`DELETE FROM glossary WHERE id = ?`, id)
+// Interne Einstellungen
+
+func (s *SQLiteStorage) GetSetting(key string) (string, error) {
+	var value string
+	err := s.db.QueryRow(`SELECT value FROM settings WHERE key = ?`, key).Scan(&value)
+	return value, err
+}
+
+func (s *SQLiteStorage) SaveSetting(key, value string) error {
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)`, key, value)
 	return err
 }
@@ -0,0 +1,35 @@
+// Package sysload liest Rechnerlast und Akkuzustand, damit Hintergrundjobs
+// auf Laptops nicht im Akkubetrieb oder bei hoher Last starten
+package sysload
+
+import (
+	"errors"
+	"runtime"
+)
+
+// ErrUnsupported: das Betriebssystem liefert keine Werte (nur Linux und macOS)
+var ErrUnsupported = errors.New("lastmessung auf " + runtime.GOOS + " nicht unterstützt")
+
+// Status ist eine Momentaufnahme des Rechners
+type Status struct {
+	Load1      float64 `json:"load1"`       // Load-Durchschnitt der letzten Minute
+	CPUs       int     `json:"cpus"`        // logische Kerne
+	HasBattery bool    `json:"has_battery"` // Akku vorhanden
+	OnBattery  bool    `json:"on_battery"`  // läuft gerade ohne Netzteil
+}
+
+// LoadPerCPU liefert die Last je Kern (1.0 = alle Kerne ausgelastet)
+func (s Status) LoadPerCPU() float64 {
+	return s.Load1 / float64(max(s.CPUs, 1))
+}
+
+// Read liest Last und Akkuzustand. Fehlt nur die Akku-Angabe, gilt der Rechner als am Netz.
+func Read() (Status, error) {
+	load, err := loadAverage()
+	if err != nil {
+		return Status{}, err
+	}
+	status := Status{Load1: load, CPUs: runtime.NumCPU()}
+	status.HasBattery, status.OnBattery = battery()
+	return status, nil
+}
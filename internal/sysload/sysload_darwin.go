@@ -0,0 +1,38 @@
+package sysload
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func command(name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	return string(out), err
+}
+
+// loadAverage liest "sysctl -n vm.loadavg" (Ausgabe: "{ 1.52 1.61 1.70 }")
+func loadAverage() (float64, error) {
+	out, err := command("sysctl", "-n", "vm.loadavg")
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(strings.Trim(strings.TrimSpace(out), "{}"))
+	if len(fields) == 0 {
+		return 0, ErrUnsupported
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// battery liest "pmset -g batt" (erste Zeile: "Now drawing from 'Battery Power'" bzw. "'AC Power'")
+func battery() (hasBattery, onBattery bool) {
+	out, err := command("pmset", "-g", "batt")
+	if err != nil {
+		return false, false
+	}
+	return strings.Contains(out, "InternalBattery"), strings.Contains(out, "'Battery Power'")
+}
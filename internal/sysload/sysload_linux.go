@@ -0,0 +1,37 @@
+package sysload
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func loadAverage() (float64, error) {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, ErrUnsupported
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
+
+// battery wertet /sys/class/power_supply aus: Akkus melden "Discharging", solange kein Netzteil steckt
+func battery() (hasBattery, onBattery bool) {
+	supplies, _ := filepath.Glob("/sys/class/power_supply/*")
+	for _, dir := range supplies {
+		kind, _ := os.ReadFile(filepath.Join(dir, "type"))
+		if strings.TrimSpace(string(kind)) != "Battery" {
+			continue
+		}
+		hasBattery = true
+		status, _ := os.ReadFile(filepath.Join(dir, "status"))
+		if strings.TrimSpace(string(status)) == "Discharging" {
+			onBattery = true
+		}
+	}
+	return hasBattery, onBattery
+}
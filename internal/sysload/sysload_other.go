@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package sysload
+
+func loadAverage() (float64, error) {
+	return 0, ErrUnsupported
+}
+
+func battery() (hasBattery, onBattery bool) {
+	return false, false
+}
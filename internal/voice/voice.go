@@ -0,0 +1,212 @@
+package voice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Transcriber wandelt gesprochene Sprache in Text um (Speech-to-Text)
+type Transcriber interface {
+	// Transcribe erkennt den Text einer Audiodatei
+	Transcribe(ctx context.Context, audio io.Reader, filename string, language string) (string, error)
+
+	// IsAvailable prüft, ob das Backend erreichbar ist
+	IsAvailable(ctx context.Context) bool
+}
+
+// Synthesizer wandelt Text in gesprochene Sprache um (Text-to-Speech)
+type Synthesizer interface {
+	// Synthesize erzeugt Audio aus Text und gibt Daten und Content-Type zurück
+	Synthesize(ctx context.Context, text string) ([]byte, string, error)
+
+	// IsAvailable prüft, ob das Backend nutzbar ist
+	IsAvailable(ctx context.Context) bool
+}
+
+// WhisperTranscriber nutzt einen lokalen whisper.cpp-Server (oder einen kompatiblen Endpunkt)
+type WhisperTranscriber struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewWhisperTranscriber erstellt einen Transcriber für den whisper.cpp-Server
+func NewWhisperTranscriber(baseURL string) *WhisperTranscriber {
+	return &WhisperTranscriber{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client: &http.Client{
+			Timeout: 5 * time.Minute, // Lange Sprachaufnahmen brauchen Zeit
+		},
+	}
+}
+
+func (w *WhisperTranscriber) IsAvailable(ctx context.Context) bool {
+	req, err := http.NewRequestWithContext(ctx, "GET", w.baseURL+"/", nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode < http.StatusInternalServerError
+}
+
+func (w *WhisperTranscriber) Transcribe(ctx context.Context, audio io.Reader, filename string, language string) (string, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(part, audio); err != nil {
+		return "", err
+	}
+	writer.WriteField("response_format", "json")
+	writer.WriteField("temperature", "0.0")
+	if language != "" {
+		writer.WriteField("language", language)
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", w.baseURL+"/inference", &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("whisper nicht erreichbar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("whisper-fehler (%d): %s", resp.StatusCode, string(data))
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(result.Text), nil
+}
+
+// PiperSynthesizer nutzt die lokale Piper-TTS-Engine als Kommandozeilenprogramm
+type PiperSynthesizer struct {
+	binaryPath string
+	modelPath  string
+}
+
+// NewPiperSynthesizer erstellt einen Synthesizer für Piper
+func NewPiperSynthesizer(binaryPath, modelPath string) *PiperSynthesizer {
+	if binaryPath == "" {
+		binaryPath = "piper"
+	}
+	return &PiperSynthesizer{
+		binaryPath: binaryPath,
+		modelPath:  modelPath,
+	}
+}
+
+func (p *PiperSynthesizer) IsAvailable(ctx context.Context) bool {
+	if _, err := exec.LookPath(p.binaryPath); err != nil {
+		return false
+	}
+	_, err := os.Stat(p.modelPath)
+	return err == nil
+}
+
+func (p *PiperSynthesizer) Synthesize(ctx context.Context, text string) ([]byte, string, error) {
+	out, err := os.CreateTemp("", "lernplattform-tts-*.wav")
+	if err != nil {
+		return nil, "", err
+	}
+	outPath := out.Name()
+	out.Close()
+	defer os.Remove(outPath)
+
+	cmd := exec.CommandContext(ctx, p.binaryPath, "--model", p.modelPath, "--output_file", outPath)
+	cmd.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, "", fmt.Errorf("piper fehlgeschlagen: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, "audio/wav", nil
+}
+
+// EncodeMP3 wandelt WAV-Audio mit ffmpeg in MP3 um
+func EncodeMP3(ctx context.Context, ffmpegPath string, wav []byte) ([]byte, error) {
+	if ffmpegPath == "" {
+		ffmpegPath = "ffmpeg"
+	}
+	if _, err := exec.LookPath(ffmpegPath); err != nil {
+		return nil, fmt.Errorf("ffmpeg nicht gefunden: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, ffmpegPath, "-hide_banner", "-loglevel", "error",
+		"-f", "wav", "-i", "pipe:0", "-codec:a", "libmp3lame", "-q:a", "4", "-f", "mp3", "pipe:1")
+	cmd.Stdin = bytes.NewReader(wav)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ffmpeg fehlgeschlagen: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// SpeakableText entfernt Markdown-Formatierung, damit sie nicht mit vorgelesen wird
+func SpeakableText(markdown string) string {
+	var out strings.Builder
+	for _, line := range strings.Split(markdown, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "#>")
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") {
+			line = line[2:]
+		}
+		if line == "---" {
+			continue
+		}
+		line = strings.NewReplacer("**", "", "__", "", "`", "", "*", "").Replace(line)
+		if line == "" {
+			continue
+		}
+		out.WriteString(line)
+		// Satzende erzwingen, damit Überschriften und Listenpunkte eine Pause bekommen
+		if !strings.HasSuffix(line, ".") && !strings.HasSuffix(line, "!") && !strings.HasSuffix(line, "?") && !strings.HasSuffix(line, ":") {
+			out.WriteString(".")
+		}
+		out.WriteString("\n")
+	}
+	return out.String()
+}
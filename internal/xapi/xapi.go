@@ -0,0 +1,195 @@
+package xapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"lernplattform/internal/config"
+)
+
+// Version der xAPI-Spezifikation, die im Header mitgeschickt wird
+const version = "1.0.3"
+
+// Standard-Präfix der Aktivitäts-IDs
+const defaultActivityBase = "http://lernplattform.local"
+
+// Verben aus dem ADL-Vokabular
+var (
+	VerbAnswered   = Verb{ID: "http://adlnet.gov/expapi/verbs/answered", Display: map[string]string{"de-DE": "beantwortete", "en-US": "answered"}}
+	VerbCompleted  = Verb{ID: "http://adlnet.gov/expapi/verbs/completed", Display: map[string]string{"de-DE": "schloss ab", "en-US": "completed"}}
+	VerbTerminated = Verb{ID: "http://adlnet.gov/expapi/verbs/terminated", Display: map[string]string{"de-DE": "beendete", "en-US": "terminated"}}
+)
+
+// Aktivitätstypen
+const (
+	TypeInteraction = "http://adlnet.gov/expapi/activities/cmi.interaction"
+	TypeModule      = "http://adlnet.gov/expapi/activities/module"
+	TypeCourse      = "http://adlnet.gov/expapi/activities/course"
+	TypeAttempt     = "http://adlnet.gov/expapi/activities/attempt"
+)
+
+// Statement ist eine xAPI-Aussage: Wer (Actor) hat was (Verb) mit welcher Aktivität (Object) getan
+type Statement struct {
+	ID        string   `json:"id"`
+	Actor     Actor    `json:"actor"`
+	Verb      Verb     `json:"verb"`
+	Object    Activity `json:"object"`
+	Result    *Result  `json:"result,omitempty"`
+	Context   *Context `json:"context,omitempty"`
+	Timestamp string   `json:"timestamp"`
+}
+
+type Actor struct {
+	ObjectType string `json:"objectType"`
+	Name       string `json:"name,omitempty"`
+	Mbox       string `json:"mbox"`
+}
+
+type Verb struct {
+	ID      string            `json:"id"`
+	Display map[string]string `json:"display"`
+}
+
+type Activity struct {
+	ObjectType string              `json:"objectType"`
+	ID         string              `json:"id"`
+	Definition *ActivityDefinition `json:"definition,omitempty"`
+}
+
+type ActivityDefinition struct {
+	Name            map[string]string `json:"name,omitempty"`
+	Type            string            `json:"type,omitempty"`
+	InteractionType string            `json:"interactionType,omitempty"` // choice, true-false, long-fill-in
+}
+
+type Result struct {
+	Success    *bool  `json:"success,omitempty"`
+	Completion *bool  `json:"completion,omitempty"`
+	Response   string `json:"response,omitempty"`
+	Duration   string `json:"duration,omitempty"` // ISO 8601, z.B. PT90S
+	Score      *Score `json:"score,omitempty"`
+}
+
+type Score struct {
+	Scaled float64 `json:"scaled"` // 0..1
+}
+
+type Context struct {
+	Language          string                `json:"language,omitempty"`
+	Platform          string                `json:"platform,omitempty"`
+	ContextActivities map[string][]Activity `json:"contextActivities,omitempty"` // parent, grouping
+}
+
+// Client sendet Statements an einen Learning Record Store
+type Client struct {
+	cfg    config.XAPIConfig
+	client *http.Client
+}
+
+// NewClient erstellt einen Client für den konfigurierten LRS
+func NewClient(cfg config.XAPIConfig) *Client {
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	cfg.ActivityBase = strings.TrimSuffix(cfg.ActivityBase, "/")
+	if cfg.ActivityBase == "" {
+		cfg.ActivityBase = defaultActivityBase
+	}
+	return &Client{cfg: cfg, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Enabled ist true, wenn Endpoint und E-Mail des Lernenden konfiguriert sind
+func (c *Client) Enabled() bool {
+	return c.cfg.Endpoint != "" && c.cfg.ActorEmail != ""
+}
+
+// Statement baut eine Aussage des konfigurierten Lernenden mit neuer ID und aktuellem Zeitstempel
+func (c *Client) Statement(verb Verb, object Activity) Statement {
+	return Statement{
+		ID: newUUID(),
+		Actor: Actor{
+			ObjectType: "Agent",
+			Name:       c.cfg.ActorName,
+			Mbox:       "mailto:" + c.cfg.ActorEmail,
+		},
+		Verb:      verb,
+		Object:    object,
+		Context:   &Context{Platform: "Lernplattform"},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+}
+
+// Activity baut eine Aktivität mit ID <activity_base>/<kind>/<id>
+func (c *Client) Activity(kind, id, name, activityType string) Activity {
+	activity := Activity{ObjectType: "Activity", ID: fmt.Sprintf("%s/%s/%s", c.cfg.ActivityBase, kind, id)}
+	if name != "" || activityType != "" {
+		activity.Definition = &ActivityDefinition{Type: activityType}
+		if name != "" {
+			activity.Definition.Name = map[string]string{"und": name}
+		}
+	}
+	return activity
+}
+
+// Send schickt Statements an den LRS (POST <endpoint>/statements)
+func (c *Client) Send(ctx context.Context, statements ...Statement) error {
+	body, err := json.Marshal(statements)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.Endpoint+"/statements", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Experience-API-Version", version)
+	if c.cfg.Username != "" {
+		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("lrs antwortet %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Emit sendet ein Statement im Hintergrund; Fehler werden nur protokolliert,
+// damit ein nicht erreichbarer LRS das Lernen nicht blockiert
+func (c *Client) Emit(statement Statement) {
+	if !c.Enabled() {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := c.Send(ctx, statement); err != nil {
+			log.Printf("⚠️ xAPI-Statement (%s) konnte nicht gesendet werden: %v", statement.Verb.Display["en-US"], err)
+		}
+	}()
+}
+
+// Duration formatiert Sekunden als ISO-8601-Dauer
+func Duration(seconds int) string {
+	return fmt.Sprintf("PT%dS", max(seconds, 0))
+}
+
+// newUUID erzeugt eine zufällige UUID (Version 4), wie sie xAPI als Statement-ID verlangt
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -21,6 +21,14 @@ function updateOnlineStatus() {
     }
 }
 
+// LLM-Backend nicht erreichbar: Lernen mit gespeicherten Inhalten geht weiter
+function markLLMOffline(reason) {
+    const statusBadge = document.getElementById('llm-status');
+    statusBadge.textContent = '🤖 LLM Offline';
+    statusBadge.className = 'status-badge status-offline';
+    statusBadge.title = `${reason ? reason + '\n' : ''}Weiter möglich: gespeicherte Erklärungen, Karteikarten (offene Antworten selbst bewerten), Notizen und Glossar`;
+}
+
 // === Local Storage Cache für schnelleres Laden ===
 const cache = {
     get(key) {
@@ -73,18 +81,26 @@ async function api(endpoint, options = {}) {
     }
 
     const config = {
+        ...options,
         headers: {
             'Content-Type': 'application/json',
+            ...authHeaders(),
             ...options.headers
-        },
-        ...options
+        }
     };
 
     try {
         const response = await fetch(url, config);
+        if (response.status === 401 && !options.retried && askForToken()) {
+            return api(endpoint, { ...options, retried: true });
+        }
         const data = await response.json();
         
         if (!response.ok) {
+            // LLM-Backend nicht erreichbar: Status anzeigen statt nur einer Fehlermeldung
+            if (response.status === 503 && data.offline) {
+                markLLMOffline(data.reason);
+            }
             throw new Error(data.error || 'API-Fehler');
         }
         
@@ -108,6 +124,30 @@ async function api(endpoint, options = {}) {
     }
 }
 
+// === Zugangstoken (nur nötig, wenn der Server security.tokens konfiguriert hat) ===
+function authHeaders() {
+    const token = localStorage.getItem('api_token');
+    return token ? { 'Authorization': `Bearer ${token}` } : {};
+}
+
+function askForToken() {
+    const token = prompt('Zugangstoken für die Lernplattform:');
+    if (!token) return false;
+    localStorage.setItem('api_token', token.trim());
+    cache.clear();
+    return true;
+}
+
+// Nur-Lese-Zugang (z.B. Eltern): Bedienelemente zum Ändern, Chat und Quiz ausblenden
+async function applyAccessRole() {
+    try {
+        const access = await api('/auth/me');
+        document.body.classList.toggle('read-only', access.role === 'viewer');
+    } catch (error) {
+        console.warn('Zugang konnte nicht geprüft werden:', error);
+    }
+}
+
 // === State Management ===
 const state = {
     documents: [],
@@ -232,8 +272,7 @@ async function loadDashboard() {
             llmStatus.textContent = `🤖 ${status.llm_provider} Online`;
             llmStatus.className = 'status-badge status-online';
         } else {
-            llmStatus.textContent = '🤖 LLM Offline';
-            llmStatus.className = 'status-badge status-offline';
+            markLLMOffline();
         }
 
         // Check steps
@@ -311,10 +350,11 @@ function renderDocuments() {
             <div class="document-info">
                 <span class="document-icon">📄</span>
                 <div>
-                    <div class="document-name">${doc.name}</div>
-                    <div class="document-meta">${doc.page_count} Seiten</div>
+                    <div class="document-name" title="${doc.name}">${doc.title || doc.name}</div>
+                    <div class="document-meta">${[doc.author, doc.semester, `${doc.page_count} Seiten`].filter(Boolean).join(' · ')}</div>
                 </div>
             </div>
+            <button class="btn btn-secondary" onclick="renameDocument('${doc.id}')">✏️</button>
             <button class="btn btn-secondary" onclick="deleteDocument('${doc.id}')">🗑️</button>
         </div>
     `).join('');
@@ -351,8 +391,8 @@ function initDocumentUpload() {
 
 async function uploadFiles(files) {
     for (const file of files) {
-        if (file.type !== 'application/pdf') {
-            alert('Nur PDF-Dateien werden unterstützt.');
+        if (!/\.(pdf|docx|zip)$/i.test(file.name)) {
+            alert('Nur PDF-, DOCX- und ZIP-Dateien werden unterstützt.');
             continue;
         }
 
@@ -360,10 +400,20 @@ async function uploadFiles(files) {
         formData.append('file', file);
 
         try {
-            await fetch(`${API_BASE}/documents`, {
+            const response = await fetch(`${API_BASE}/documents`, {
                 method: 'POST',
+                headers: authHeaders(),
                 body: formData
             });
+            if (/\.zip$/i.test(file.name)) {
+                const data = await response.json();
+                const failed = (data.report || []).filter(e => e.status !== 'ok');
+                let message = `${file.name}: ${data.count || 0} Dokumente eingelesen`;
+                if (failed.length) {
+                    message += `\n\nNicht eingelesen:\n` + failed.map(e => `${e.file}: ${e.error}`).join('\n');
+                }
+                alert(message);
+            }
         } catch (error) {
             console.error('Upload fehlgeschlagen:', error);
         }
@@ -382,6 +432,28 @@ async function scanFolder() {
     }
 }
 
+async function renameDocument(id) {
+    const doc = state.documents.find(d => d.id === id);
+    if (!doc) return;
+    const title = prompt('Anzeigename (leer = Dateiname):', doc.title || doc.name);
+    if (title === null) return;
+
+    try {
+        await api(`/documents/${id}`, {
+            method: 'PUT',
+            body: JSON.stringify({
+                title: title.trim() === doc.name ? '' : title,
+                author: doc.author || '',
+                semester: doc.semester || '',
+                tags: doc.tags || []
+            })
+        });
+        await loadDocuments();
+    } catch (error) {
+        alert('Fehler beim Umbenennen: ' + error.message);
+    }
+}
+
 async function deleteDocument(id) {
     if (!confirm('Dokument wirklich löschen?')) return;
     
@@ -405,7 +477,7 @@ async function loadStudyPlan() {
         container.innerHTML = state.documents.map(doc => `
             <label class="checkbox-item">
                 <input type="checkbox" name="doc" value="${doc.id}" checked>
-                <span>${doc.name}</span>
+                <span>${doc.title || doc.name}</span>
             </label>
         `).join('');
     }
@@ -438,9 +510,15 @@ function initStudyPlanForm() {
         btn.disabled = true;
         btn.textContent = '⏳ Erstelle Lernplan...';
 
+        const generationId = `plan_${Date.now()}`;
+        const stopWatching = watchGeneration(generationId, (progress) => {
+            btn.textContent = `⏳ ${formatProgress(progress)}`;
+        });
+
         try {
             const plan = await api('/plans', {
                 method: 'POST',
+                headers: { 'X-Generation-ID': generationId },
                 body: JSON.stringify({
                     exam_date: examDate,
                     document_ids: selectedDocs
@@ -452,21 +530,111 @@ function initStudyPlanForm() {
         } catch (error) {
             alert('Fehler beim Erstellen: ' + error.message);
         } finally {
+            stopWatching();
             btn.disabled = false;
             btn.textContent = '🚀 Lernplan erstellen';
         }
     });
 }
 
+// Zwischenstände einer Generierung über den Ereignis-WebSocket verfolgen; liefert eine Funktion zum Beenden
+function watchGeneration(id, onProgress) {
+    const token = localStorage.getItem('api_token');
+    const protocol = location.protocol === 'https:' ? 'wss:' : 'ws:';
+    let socket;
+    try {
+        socket = new WebSocket(`${protocol}//${location.host}${API_BASE}/generations/events${token ? `?token=${encodeURIComponent(token)}` : ''}`);
+    } catch (error) {
+        return () => {};
+    }
+    socket.onmessage = (event) => {
+        const data = JSON.parse(event.data);
+        if (data.generation.id === id && data.generation.progress) {
+            onProgress(data.generation.progress);
+        }
+    };
+    return () => socket.close();
+}
+
+const progressSteps = {
+    analyze_document: 'Analysiere',
+    exam_topics: 'Klausurthemen gewichten',
+    analyze: 'Analysiere Dokumente',
+    explain: 'Erkläre',
+    questions: 'Erstelle Fragen',
+    distractors: 'Prüfe Antwortoptionen',
+    figure_question: 'Erstelle Bildfrage'
+};
+
+function formatProgress(progress) {
+    let text = progressSteps[progress.step] || progress.step;
+    if (progress.detail) text += ` ${progress.detail}`;
+    if (progress.total > 1) text += ` (${Math.min(progress.done + 1, progress.total)}/${progress.total})`;
+    if (progress.eta_seconds) {
+        text += progress.eta_seconds < 60
+            ? `, noch ca. ${progress.eta_seconds} Sek.`
+            : `, noch ca. ${Math.round(progress.eta_seconds / 60)} Min.`;
+        if (progress.model) text += ` mit ${progress.model}`;
+    }
+    return text;
+}
+
+// Kalendertage ("2026-03-15") als lokales Datum lesen; new Date() würde sie als UTC deuten
+function parseDay(value) {
+    const [year, month, day] = value.slice(0, 10).split('-').map(Number);
+    return new Date(year, month - 1, day);
+}
+
+async function editPlan() {
+    const plan = state.activePlan;
+    if (!plan) return;
+    const name = prompt('Name des Lernplans:', plan.name);
+    if (name === null) return;
+    const subject = prompt('Fach (optional):', plan.subject || '');
+    if (subject === null) return;
+
+    // Fachprofil: Prompts, Fragetypen und Bewertungsstrenge für das Fach
+    let profileId = plan.profile_id || '';
+    let profiles = [];
+    try {
+        profiles = await api('/profiles');
+    } catch (error) {
+        // ohne Profilliste bleibt das Profil unverändert
+    }
+    if (profiles.length > 0) {
+        const list = profiles.map(p => `${p.id} = ${p.name}`).join('\n');
+        const choice = prompt(`Fachprofil (ID, leer = ohne):\n${list}`, profileId);
+        if (choice === null) return;
+        profileId = choice.trim();
+    }
+
+    try {
+        const updated = await api(`/plans/${plan.id}`, {
+            method: 'PUT',
+            body: JSON.stringify({ name, subject, profile_id: profileId })
+        });
+        renderActivePlan(updated);
+    } catch (error) {
+        alert('Fehler beim Speichern: ' + error.message);
+    }
+}
+
 function renderActivePlan(plan) {
     document.getElementById('no-plan').classList.add('hidden');
     document.getElementById('active-plan').classList.remove('hidden');
 
+    state.activePlan = plan;
     document.getElementById('plan-name').textContent = plan.name;
-    document.getElementById('plan-exam-date').textContent = 
-        new Date(plan.exam_date).toLocaleDateString('de-DE');
-    
-    const daysLeft = Math.ceil((new Date(plan.exam_date) - new Date()) / (1000 * 60 * 60 * 24));
+    const subject = document.getElementById('plan-subject');
+    subject.classList.toggle('hidden', !plan.subject);
+    subject.querySelector('strong').textContent = plan.subject || '';
+    document.querySelector('#active-plan .plan-header').style.borderLeft = plan.color ? `6px solid ${plan.color}` : '';
+    const examDate = parseDay(plan.exam_date);
+    document.getElementById('plan-exam-date').textContent = examDate.toLocaleDateString('de-DE');
+    
+    const today = new Date();
+    today.setHours(0, 0, 0, 0);
+    const daysLeft = Math.round((examDate - today) / (1000 * 60 * 60 * 24));
     document.getElementById('plan-days-left').textContent = Math.max(0, daysLeft);
     
     document.getElementById('plan-progress').textContent = Math.round(plan.progress);
@@ -474,12 +642,12 @@ function renderActivePlan(plan) {
 
     // Render topics
     const topicsContainer = document.getElementById('topics-list');
-    state.topics = plan.topics || [];
+    state.topics = flattenTopics(plan.topics || []);
     
-    topicsContainer.innerHTML = state.topics.map((topic, index) => `
-        <div class="topic-item" onclick="openTopic('${topic.id}')">
+    topicsContainer.innerHTML = state.topics.map(topic => `
+        <div class="topic-item" style="margin-left: ${topic.depth * 24}px" onclick="openTopic('${topic.id}')">
             <div class="topic-info">
-                <div class="topic-name">${index + 1}. ${topic.name}</div>
+                <div class="topic-name">${topic.number}. ${topic.name}</div>
                 <div class="topic-description">${topic.description}</div>
             </div>
             <span class="topic-status ${topic.status}">${getStatusLabel(topic.status)}</span>
@@ -487,6 +655,17 @@ function renderActivePlan(plan) {
     `).join('');
 }
 
+// Kapitelbaum in eine Liste mit Gliederungsnummern (1, 1.1, ...) umwandeln
+function flattenTopics(topics, depth = 0, prefix = '') {
+    return topics.flatMap((topic, index) => {
+        const number = prefix ? `${prefix}.${index + 1}` : `${index + 1}`;
+        return [
+            { ...topic, depth, number },
+            ...flattenTopics(topic.subtopics || [], depth + 1, number)
+        ];
+    });
+}
+
 function getStatusLabel(status) {
     const labels = {
         'pending': 'Offen',
@@ -508,7 +687,7 @@ async function loadLearnView() {
         try {
             const plan = await api('/plans/active');
             state.activePlan = plan;
-            state.topics = plan.topics || [];
+            state.topics = flattenTopics(plan.topics || []);
         } catch {
             document.getElementById('learn-select-topic').innerHTML = 
                 '<p class="placeholder">Erstelle zuerst einen Lernplan.</p>';
@@ -960,7 +1139,7 @@ async function loadQuizView() {
         try {
             const plan = await api('/plans/active');
             state.activePlan = plan;
-            state.topics = plan.topics || [];
+            state.topics = flattenTopics(plan.topics || []);
         } catch {
             return;
         }
@@ -1002,6 +1181,8 @@ async function startQuiz() {
     }
 
     const difficulty = parseInt(document.getElementById('difficulty-select').value);
+    const level = document.getElementById('level-select').value;
+    const type = document.getElementById('type-select').value;
     const settings = getSettings();
     
     document.getElementById('quiz-select').classList.add('hidden');
@@ -1022,9 +1203,11 @@ async function startQuiz() {
         // Prüfe zuerst ob gecachte Fragen existieren
         let questions;
         try {
-            const cached = await api(`/topics/${selectedQuizTopicId}/questions?difficulty=${difficulty}`);
-            if (cached && cached.length >= settings.questionsCount) {
-                questions = cached.slice(0, settings.questionsCount);
+            const levelParam = level ? `&level=${level}` : '';
+            const cached = await api(`/topics/${selectedQuizTopicId}/questions?difficulty=${difficulty}${levelParam}`);
+            const matching = (cached || []).filter(q => q.type === type);
+            if (matching.length >= settings.questionsCount) {
+                questions = matching.slice(0, settings.questionsCount);
             }
         } catch (e) {
             // Keine gecachten Fragen
@@ -1034,7 +1217,7 @@ async function startQuiz() {
         if (!questions || questions.length < settings.questionsCount) {
             questions = await api(`/topics/${selectedQuizTopicId}/questions/generate`, {
                 method: 'POST',
-                body: JSON.stringify({ difficulty, count: settings.questionsCount })
+                body: JSON.stringify({ difficulty, count: settings.questionsCount, cognitive_level: level, type })
             });
         }
 
@@ -1049,15 +1232,59 @@ async function startQuiz() {
     }
 }
 
+// Bildfrage: Abbildung aus dem Material über der Antwort anzeigen (img-Anfragen tragen das Token als Parameter)
+function showQuestionFigure(question) {
+    const figure = document.getElementById('question-figure');
+    if (!question.figure_id) {
+        figure.classList.add('hidden');
+        figure.removeAttribute('src');
+        return;
+    }
+    const token = localStorage.getItem('api_token');
+    figure.src = `${API_BASE}/figures/${encodeURIComponent(question.figure_id)}${token ? `?token=${encodeURIComponent(token)}` : ''}`;
+    figure.classList.remove('hidden');
+}
+
 function showQuestion() {
     const question = state.currentQuestions[state.currentQuestionIndex];
     
     document.getElementById('quiz-current').textContent = state.currentQuestionIndex + 1;
     document.getElementById('quiz-total').textContent = state.currentQuestions.length;
     document.getElementById('quiz-difficulty').textContent = '⭐'.repeat(question.difficulty);
+    const examBadge = document.getElementById('quiz-exam-badge');
+    examBadge.textContent = question.exam_year ? `📝 Klausur ${question.exam_year}` : '📝 Klausurfrage';
+    examBadge.classList.toggle('hidden', !question.exam_relevant);
+    document.getElementById('flag-question-btn').textContent = '🚩';
     
     document.getElementById('question-text').textContent = question.question;
+    showQuestionFigure(question);
     document.getElementById('answer-input').value = '';
+    document.getElementById('answer-input').placeholder = question.type === 'cloze' ? 'Fehlender Begriff...' : 'Deine Antwort...';
+
+    // Multiple Choice: Optionen als Buttons statt Freitext; Reihenfolge: Schritte verschieben
+    const optionsContainer = document.getElementById('answer-options');
+    const isOrdering = question.type === 'ordering';
+    const hasOptions = !isOrdering && question.options && question.options.length > 0;
+    optionsContainer.innerHTML = '';
+    optionsContainer.classList.toggle('hidden', !hasOptions && !isOrdering);
+    document.getElementById('answer-input').classList.toggle('hidden', hasOptions || isOrdering);
+    document.getElementById('submit-answer-btn').classList.toggle('hidden', hasOptions);
+    if (isOrdering) {
+        state.currentOrder = question.options.map((_, index) => index);
+        renderOrdering(question);
+    }
+    if (hasOptions) {
+        question.options.forEach((option, index) => {
+            const btn = document.createElement('button');
+            btn.className = 'btn btn-secondary';
+            btn.textContent = option;
+            btn.addEventListener('click', () => {
+                document.getElementById('answer-input').value = option;
+                submitAnswer(index);
+            });
+            optionsContainer.appendChild(btn);
+        });
+    }
     
     // Hide feedback, show answer section
     document.getElementById('feedback-section').classList.add('hidden');
@@ -1075,12 +1302,50 @@ function showQuestion() {
     }
 }
 
+// Reihenfolge-Fragen: Schritte mit ↑/↓ verschieben; state.currentOrder hält die Indizes der angezeigten Optionen
+function renderOrdering(question) {
+    const container = document.getElementById('answer-options');
+    container.innerHTML = '';
+    state.currentOrder.forEach((optionIndex, position) => {
+        const row = document.createElement('div');
+        row.className = 'ordering-step';
+
+        const label = document.createElement('span');
+        label.textContent = `${position + 1}. ${question.options[optionIndex]}`;
+        row.appendChild(label);
+
+        [['↑', -1], ['↓', 1]].forEach(([symbol, direction]) => {
+            const target = position + direction;
+            const btn = document.createElement('button');
+            btn.className = 'btn btn-secondary';
+            btn.textContent = symbol;
+            btn.disabled = target < 0 || target >= state.currentOrder.length;
+            btn.addEventListener('click', () => {
+                [state.currentOrder[position], state.currentOrder[target]] = [state.currentOrder[target], state.currentOrder[position]];
+                renderOrdering(question);
+            });
+            row.appendChild(btn);
+        });
+        container.appendChild(row);
+    });
+}
+
 function initQuizButtons() {
     document.getElementById('show-hint-btn').addEventListener('click', () => {
         document.getElementById('hint-text').classList.toggle('hidden');
     });
 
     document.getElementById('submit-answer-btn').addEventListener('click', submitAnswer);
+
+    document.getElementById('flag-question-btn').addEventListener('click', async () => {
+        const question = state.currentQuestions[state.currentQuestionIndex];
+        try {
+            await api(`/questions/${question.id}/flag`, { method: 'POST', body: '{}' });
+            document.getElementById('flag-question-btn').textContent = '✅';
+        } catch (error) {
+            alert('Fehler beim Markieren: ' + error.message);
+        }
+    });
     
     document.getElementById('next-question-btn').addEventListener('click', () => {
         state.currentQuestionIndex++;
@@ -1095,20 +1360,29 @@ function initQuizButtons() {
     document.getElementById('new-quiz-btn').addEventListener('click', showQuizSelect);
 }
 
-async function submitAnswer() {
-    const answer = document.getElementById('answer-input').value.trim();
+async function submitAnswer(optionIndex) {
+    const question = state.currentQuestions[state.currentQuestionIndex];
+    const isOrdering = question.type === 'ordering';
+    const answer = isOrdering
+        ? state.currentOrder.map(index => question.options[index]).join(' → ')
+        : document.getElementById('answer-input').value.trim();
     if (!answer) {
         alert('Bitte gib eine Antwort ein.');
         return;
     }
 
-    const question = state.currentQuestions[state.currentQuestionIndex];
     const settings = getSettings();
+    let body = { answer };
+    if (isOrdering && question.option_token) {
+        body = { answer, order: state.currentOrder, option_token: question.option_token };
+    } else if (Number.isInteger(optionIndex) && question.option_token) {
+        body = { answer, option: optionIndex, option_token: question.option_token };
+    }
     
     try {
         const result = await api(`/questions/${question.id}/answer`, {
             method: 'POST',
-            body: JSON.stringify({ answer })
+            body: JSON.stringify(body)
         });
 
         // Gamification
@@ -1167,7 +1441,7 @@ function showFeedback(result) {
         document.getElementById('feedback-status').innerHTML = 'Richtig!' + gamificationText;
     } else {
         document.getElementById('feedback-icon').textContent = '❌';
-        document.getElementById('feedback-status').textContent = 'Noch nicht ganz...';
+        document.getElementById('feedback-status').textContent = 'Noch nicht ganz...' + (result.error_label ? ` (${result.error_label})` : '');
     }
     
     document.getElementById('feedback-text').textContent = result.feedback;
@@ -1280,7 +1554,7 @@ function showGamificationResults(percent) {
 async function loadChatTopics() {
     if (state.topics.length > 0) {
         const select = document.getElementById('chat-topic-select');
-        select.innerHTML = '<option value="">Allgemein</option>' +
+        select.innerHTML = '<option value="">Automatisch (passendes Thema)</option>' +
             state.topics.map(t => `<option value="${t.id}">${t.name}</option>`).join('');
     }
 }
@@ -1312,25 +1586,52 @@ async function sendChatMessage() {
             body: JSON.stringify({
                 message,
                 topic_id: topicId,
-                session_id: state.chatSessionId
+                session_id: state.chatSessionId,
+                mode: topicId ? undefined : 'general'
             })
         });
 
-        addChatMessage(response.response, 'assistant');
+        if (response.routed) {
+            addChatMessage(`🧭 ${response.routed.plan_name} – ${response.routed.topic_name}`, 'system');
+        }
+        addChatMessage(response.response, 'assistant', response.message_id);
     } catch (error) {
         addChatMessage('Entschuldigung, es gab einen Fehler: ' + error.message, 'assistant');
     }
 }
 
-function addChatMessage(content, role) {
+function addChatMessage(content, role, messageId) {
     const container = document.getElementById('chat-messages');
     const messageDiv = document.createElement('div');
     messageDiv.className = `chat-message ${role}`;
     messageDiv.innerHTML = `<div class="message-content">${content}</div>`;
+    if (messageId) {
+        // Hilfreiche Antworten als Karteikarte oder Glossar-Eintrag übernehmen
+        messageDiv.innerHTML += `
+            <div class="chat-message-actions">
+                <button class="btn btn-secondary" onclick="saveChatAnswer(this, '${messageId}', 'to-flashcard')">🗂️ Als Karteikarte</button>
+                <button class="btn btn-secondary" onclick="saveChatAnswer(this, '${messageId}', 'to-glossary')">📖 Ins Glossar</button>
+            </div>
+        `;
+    }
     container.appendChild(messageDiv);
     container.scrollTop = container.scrollHeight;
 }
 
+async function saveChatAnswer(button, messageId, action) {
+    button.disabled = true;
+    try {
+        await api(`/chat/messages/${messageId}/${action}`, {
+            method: 'POST',
+            body: JSON.stringify({ topic_id: document.getElementById('chat-topic-select').value })
+        });
+        button.textContent = '✓ Gespeichert!';
+    } catch (error) {
+        button.disabled = false;
+        alert('Fehler beim Speichern: ' + error.message);
+    }
+}
+
 // === Settings ===
 async function loadSettings() {
     try {
@@ -1348,6 +1649,53 @@ async function loadSettings() {
     } catch (error) {
         console.error('Modelle laden fehlgeschlagen:', error);
     }
+    loadDevices();
+}
+
+// Gekoppelte Geräte (Begleit-App) mit Button zum Entkoppeln
+async function loadDevices() {
+    const list = document.getElementById('device-list');
+    try {
+        const devices = await api('/auth/devices');
+        list.innerHTML = '';
+        devices.forEach(device => {
+            const item = document.createElement('li');
+            const used = device.last_used_at ? `zuletzt ${new Date(device.last_used_at).toLocaleString('de-DE')}` : 'noch nicht benutzt';
+            item.textContent = `${device.name} (${device.scopes.join(', ')}, ${used}) `;
+            const button = document.createElement('button');
+            button.className = 'btn btn-hint';
+            button.textContent = 'Entkoppeln';
+            button.addEventListener('click', async () => {
+                if (!confirm(`Gerät "${device.name}" entkoppeln?`)) return;
+                await api(`/auth/devices/${device.id}`, { method: 'DELETE' });
+                loadDevices();
+            });
+            item.appendChild(button);
+            list.appendChild(item);
+        });
+    } catch (error) {
+        console.error('Geräte laden fehlgeschlagen:', error);
+    }
+}
+
+async function pairDevice() {
+    const name = document.getElementById('device-name').value.trim();
+    if (!name) {
+        alert('Bitte einen Gerätenamen eingeben');
+        return;
+    }
+    try {
+        const result = await api('/auth/devices', {
+            method: 'POST',
+            body: JSON.stringify({ name, scopes: document.getElementById('device-scopes').value.split(',') })
+        });
+        document.getElementById('device-qr').src = result.qr_code;
+        document.getElementById('device-pairing').classList.remove('hidden');
+        document.getElementById('device-name').value = '';
+        loadDevices();
+    } catch (error) {
+        alert('❌ Koppeln fehlgeschlagen: ' + error.message);
+    }
 }
 
 async function setModel(modelName) {
@@ -1366,6 +1714,7 @@ async function setModel(modelName) {
 
 function initSettings() {
     document.getElementById('refresh-models-btn').addEventListener('click', loadSettings);
+    document.getElementById('pair-device-btn').addEventListener('click', pairDevice);
     
     // Modell-Änderung beim Select
     document.getElementById('model-select').addEventListener('change', async (e) => {
@@ -1655,11 +2004,13 @@ document.addEventListener('DOMContentLoaded', () => {
     loadSettingsUI(); // Quiz-Einstellungen laden
     
     // Load dashboard
-    loadDashboard();
+    applyAccessRole().then(loadDashboard);
 });
 
 // Make functions available globally
+window.renameDocument = renameDocument;
 window.deleteDocument = deleteDocument;
+window.editPlan = editPlan;
 window.openTopic = openTopic;
 window.selectLearnTopic = selectLearnTopic;
 window.selectQuizTopic = selectQuizTopic;